@@ -25,6 +25,14 @@ type StateChannel interface {
 	// If you found new Minted Event, fill in this channel
 	GetNewMintedEventChannel() chan<- *MintedEvent
 
+	// If you found new events of a ledger range, fill in this channel.
+	// The events are applied in order, then the finalized block/ledger number is set to the checkpoint.
+	GetNewChainEventBatchChannel() chan<- *ChainEventBatch
+
+	// If you found blocks that left the canonical chain, fill in this channel.
+	// The events of the blocks are rolled back, the finalized block/ledger number goes back to the fork point.
+	GetChainReorgChannel() chan<- *ChainReorg
+
 	// This is <NOT> a channel, it reads the finalized block/ledger number from state.
 	GetBlockchainFinalizedBlockNumber() (*big.Int, error)
 }
@@ -35,7 +43,8 @@ type BtcUTXOResponder interface {
 	// Request sends a request to the responder to get outpoints for preparing
 	// the redeem indexed by the tx hash and then return the outpoints via
 	// the provided channel. The btc utxo responder should temporarily lock the
-	// outpoints with a timeout.
+	// outpoints with a timeout. A repeated request of the same tx hash (the
+	// prepare is retried) gets the outpoints still locked for it.
 	Request(
 		reqTxId []byte, // the request Tx on other blockchain that associated with this request of UTXO
 		amount *big.Int,
@@ -57,6 +57,76 @@ func (ev *RedeemPreparedEvent) String() string {
 	return fmt.Sprintf("%+v", *ev)
 }
 
+// ChainEvent is one of the events above, tagged with its position on chain.
+// Exactly one of Minted, Requested, Prepared is set.
+type ChainEvent struct {
+	Ledger uint64 // block number (eth) or ledger version (aptos)
+	Index  uint64 // position of the event in the ledger
+
+	Minted    *MintedEvent
+	Requested *RedeemRequestedEvent
+	Prepared  *RedeemPreparedEvent
+}
+
+// Is the event before other on chain?
+func (ev *ChainEvent) Before(other *ChainEvent) bool {
+	if ev.Ledger != other.Ledger {
+		return ev.Ledger < other.Ledger
+	}
+	return ev.Index < other.Index
+}
+
+func (ev *ChainEvent) String() string {
+	switch {
+	case ev.Minted != nil:
+		return fmt.Sprintf("minted@%d.%d %v", ev.Ledger, ev.Index, ev.Minted)
+	case ev.Requested != nil:
+		return fmt.Sprintf("requested@%d.%d %v", ev.Ledger, ev.Index, ev.Requested)
+	case ev.Prepared != nil:
+		return fmt.Sprintf("prepared@%d.%d %v", ev.Ledger, ev.Index, ev.Prepared)
+	}
+	return fmt.Sprintf("empty@%d.%d", ev.Ledger, ev.Index)
+}
+
+// ChainEventBatch is the events of a range of ledgers, ordered from old -> new.
+// The state applies the events in order, then stores Checkpoint as the finalized ledger number,
+// and reports the result on Done.
+type ChainEventBatch struct {
+	Events     []ChainEvent
+	Checkpoint *big.Int
+	Done       chan error // buffered (1), nil = applied and checkpointed
+}
+
+func NewChainEventBatch(events []ChainEvent, checkpoint *big.Int) *ChainEventBatch {
+	return &ChainEventBatch{
+		Events:     events,
+		Checkpoint: checkpoint,
+		Done:       make(chan error, 1),
+	}
+}
+
+// ChainReorg tells that the blocks after ForkPoint left the canonical chain.
+// Minted/Requested/Prepared are the events of the orphaned blocks that were applied, they are rolled back,
+// then the blocks after ForkPoint are synced again.
+// The state reports the result of the roll back on Done, other receivers ignore it.
+type ChainReorg struct {
+	ForkPoint *big.Int // last block still on the canonical chain
+	Minted    []MintedEvent
+	Requested []RedeemRequestedEvent
+	Prepared  []RedeemPreparedEvent
+	Done      chan error // buffered (1)
+}
+
+func NewChainReorg(forkPoint *big.Int, minted []MintedEvent, requested []RedeemRequestedEvent, prepared []RedeemPreparedEvent) *ChainReorg {
+	return &ChainReorg{
+		ForkPoint: forkPoint,
+		Minted:    minted,
+		Requested: requested,
+		Prepared:  prepared,
+		Done:      make(chan error, 1),
+	}
+}
+
 type BtcOutpoint struct {
 	BtcTxId common.Hash
 	BtcIdx  uint16
@@ -82,18 +152,24 @@ type SignatureRequest struct {
 type MintParameter struct {
 	BtcTxId  common.Hash // bitcoin transaction hash is always 32 byte
 	Amount   *big.Int
-	Receiver []byte // ethereum address
-	// Rx       *big.Int // part of schnorr signature
-	// S        *big.Int // part of schnorr signature
+	Receiver []byte   // ethereum address (20 bytes), aptos address (32 bytes)
+	Rx       *big.Int // part of schnorr signature
+	S        *big.Int // part of schnorr signature
+
+	Domain *mycommon.SigningDomain // deployment the mint is signed for, nil = legacy (no domain)
 }
 
 // The msg-hash for sign
 func (params *MintParameter) GenerateMsgHash() common.Hash {
-	return crypto.Keccak256Hash(mycommon.EncodePacked(
+	packed := mycommon.EncodePacked(
 		params.BtcTxId,
 		params.Receiver,
 		params.Amount,
-	))
+	)
+	if params.Domain != nil {
+		return params.Domain.MessageHash(mycommon.MSG_TYPE_MINT, packed)
+	}
+	return crypto.Keccak256Hash(packed)
 }
 
 // To Prepare a redeem on chain (eth/aptos),
@@ -108,6 +184,8 @@ type PrepareParameter struct {
 	OutpointIdxs  []uint16      // corresponding output's vout to btc_tx_id(s)
 	Rx            *big.Int
 	S             *big.Int
+
+	Domain *mycommon.SigningDomain // deployment the prepare is signed for, nil = legacy (no domain)
 }
 
 // Serialize the parameters and create a hash
@@ -117,14 +195,18 @@ func (p *PrepareParameter) GenerateMsgHash() common.Hash {
 		outpointIdxs = append(outpointIdxs, big.NewInt(int64(idx)))
 	}
 
-	return crypto.Keccak256Hash(mycommon.EncodePacked(
+	packed := mycommon.EncodePacked(
 		p.RequestTxHash,
 		p.Requester,
 		string(p.Receiver),
 		p.Amount,
 		p.OutpointTxIds,
 		outpointIdxs,
-	))
+	)
+	if p.Domain != nil {
+		return p.Domain.MessageHash(mycommon.MSG_TYPE_PREPARE, packed)
+	}
+	return crypto.Keccak256Hash(packed)
 }
 
 // Create RedeemPrepare Tx needed params from state.redeem object.
@@ -163,3 +245,30 @@ func UtilContains(slice []MonitoredTxStatus, item MonitoredTxStatus) bool {
 	}
 	return false
 }
+
+// What the tx manager shall do with a tx that failed (or would fail) on chain.
+type TxFailureAction string
+
+const (
+	TxFailureSkip      TxFailureAction = "skip"      // wanted change is already on chain, nothing to do.
+	TxFailureRetry     TxFailureAction = "retry"     // transient, send again later.
+	TxFailurePermanent TxFailureAction = "permanent" // never succeeds as is, needs an operator.
+)
+
+// TxFailure is the error a chain worker returns when a tx is (or would be) aborted on chain.
+// Reason is the decoded abort (eg. "btc_bridgev3::E_ALREADY_MINTED"), stored with the monitored tx.
+type TxFailure struct {
+	Action TxFailureAction
+	Reason string
+}
+
+func (f *TxFailure) Error() string {
+	return fmt.Sprintf("tx failed on chain (%s): %s", f.Action, f.Reason)
+}
+
+// Read position in an event handle of a chain whose events are indexed by sequence number (eg. aptos).
+// Sequence is the next event to read, Version is the last ledger version the read events cover.
+type EventCursor struct {
+	Sequence uint64
+	Version  uint64
+}
@@ -0,0 +1,86 @@
+#[test_only]
+module my_address::test_end_to_end {
+    use std::string::{Self, String};
+    use std::vector;
+    use aptos_framework::chain_id;
+    use my_address::btc_bridgev3;
+    use my_address::schnorr;
+
+    // Signed off chain by the bridge's Go signer, see TestMoveSigningVectors in aptosman/signing_test.go.
+    // Domain: aptos chain id 4, module address 0x42 (dev-addresses in Move.toml).
+    // Both sides pin the same messages, the Move and Go encodings can not drift apart.
+    const PK: vector<u8> = x"dff1d77f2a671c5f36183726db2341be58feae1da2deced843240f7b502ba659";
+    const CHAIN_ID: u8 = 4;
+
+    const MINT_MSG: vector<u8> = x"3c8139b61c6d3c10707c90609da687338905a88b3aa698b4adaf6e8bca57aac3";
+    const MINT_RX: u256 = 0x3583d64d238dd108c02ad5fcc217a19015197a8becd792bc8480974f2a5abec2;
+    const MINT_S: u256 = 0x0915ba7369d004c6b669e33a0ecc4d8d1b0cd0ec7875a886d7e7c2741a54e0eb;
+
+    const PREPARE_MSG: vector<u8> = x"9780c85df6e8f8f0f825b8473d1a705f1900f9b569b2c7ac9b4ff0b8cff271f6";
+    const PREPARE_RX: u256 = 0xa17fe47155e2b87c024c0d16b9bdc32fcaede305c8a4ce773f923c4f90d9f2fa;
+    const PREPARE_S: u256 = 0x9eaa28a03b9efb4ac7a0c099fcc15579e95fff731d8f47a339f64d2cb543f52a;
+
+    fun mint_message(): vector<u8> {
+        btc_bridgev3::mint_message_for_test(
+            &string::utf8(b"0x1111111111111111111111111111111111111111111111111111111111111111"),
+            @0xa11ce,
+            100000,
+        )
+    }
+
+    fun prepare_message(): vector<u8> {
+        let outpoint_tx_ids = vector::empty<String>();
+        vector::push_back(&mut outpoint_tx_ids, string::utf8(b"3333333333333333333333333333333333333333333333333333333333333333"));
+        vector::push_back(&mut outpoint_tx_ids, string::utf8(b"4444444444444444444444444444444444444444444444444444444444444444"));
+        let outpoint_idxs = vector::empty<u64>();
+        vector::push_back(&mut outpoint_idxs, 0);
+        vector::push_back(&mut outpoint_idxs, 1);
+
+        btc_bridgev3::prepare_message_for_test(
+            &string::utf8(b"0x2222222222222222222222222222222222222222222222222222222222222222"),
+            @0xb0b,
+            &string::utf8(b"bcrt1qw508d6qejxtdg4y5r3zarvary0c5xw7kygt080"),
+            50000,
+            &outpoint_tx_ids,
+            &outpoint_idxs,
+        )
+    }
+
+    #[test(aptos_framework = @aptos_framework)]
+    fun test_mint_message_signed_by_go(aptos_framework: &signer) {
+        chain_id::initialize_for_test(aptos_framework, CHAIN_ID);
+
+        let msg = mint_message();
+        assert!(msg == MINT_MSG, 0);
+        assert!(schnorr::verify(PK, msg, MINT_RX, MINT_S), 1);
+    }
+
+    #[test(aptos_framework = @aptos_framework)]
+    fun test_prepare_message_signed_by_go(aptos_framework: &signer) {
+        chain_id::initialize_for_test(aptos_framework, CHAIN_ID);
+
+        let msg = prepare_message();
+        assert!(msg == PREPARE_MSG, 0);
+        assert!(schnorr::verify(PK, msg, PREPARE_RX, PREPARE_S), 1);
+    }
+
+    #[test(aptos_framework = @aptos_framework)]
+    fun test_signature_for_another_chain(aptos_framework: &signer) {
+        chain_id::initialize_for_test(aptos_framework, CHAIN_ID + 1);
+
+        // the same params on another chain are another message
+        let msg = mint_message();
+        assert!(msg != MINT_MSG, 0);
+        assert!(!schnorr::verify(PK, msg, MINT_RX, MINT_S), 1);
+
+        let msg = prepare_message();
+        assert!(msg != PREPARE_MSG, 2);
+        assert!(!schnorr::verify(PK, msg, PREPARE_RX, PREPARE_S), 3);
+    }
+
+    #[test]
+    fun test_mint_signature_is_not_a_prepare_signature() {
+        assert!(!schnorr::verify(PK, PREPARE_MSG, MINT_RX, MINT_S), 0);
+        assert!(!schnorr::verify(PK, MINT_MSG, PREPARE_RX, PREPARE_S), 1);
+    }
+}
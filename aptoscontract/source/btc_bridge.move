@@ -1,17 +1,21 @@
 module my_address::btc_bridgev3 {
+    use std::bcs;
     use std::error;
     use std::signer;
     use std::string::{Self, String};
     use std::vector;
+    use aptos_std::aptos_hash;
     use aptos_framework::account;
+    use aptos_framework::chain_id;
     use aptos_framework::event;
     use my_address::btc_tokenv3;
+    use my_address::schnorr;
 
     /// @notice Public key of the secret that is generated by the threshold
     ///         Schnorr signature scheme. The partial secrets are used by
     ///         bridge nodes to perform an m-out-of-n threshold signature.
     struct BridgeConfig has key {
-        // pk: vector<u8>,
+        pk: vector<u8>, // x-only, 32 bytes (BIP-340)
         admin: address,
         fee: u64,
         fee_account: address
@@ -80,11 +84,16 @@ module my_address::btc_bridgev3 {
     const E_OUTPOINT_TX_IDS_AND_OUTPOINT_IDXS_LENGTH_MISMATCH: u64 = 14;
     const E_ZERO_OUTPOINT_TX_ID: u64 = 15;
     const E_BTC_TX_ID_ALREADY_USED: u64 = 16;
+    const E_INVALID_PUBLIC_KEY: u64 = 17;
+    const E_INVALID_HEX_STRING: u64 = 18;
+
+    /// Chain type of aptos in the signing domain, common.DEPOSIT_CHAIN_TYPE_APTOS off chain
+    const CHAIN_TYPE_APTOS: u8 = 2;
 
     /// Initialize the bridge with initial configuration
     public entry fun initialize(
         admin: &signer,
-        // pk: vector<u8>,
+        pk: vector<u8>,
         fee_account: address,
         fee: u64
     ) {
@@ -93,6 +102,9 @@ module my_address::btc_bridgev3 {
         // Ensure the bridge hasn't been initialized
         assert!(!exists<BridgeConfig>(admin_addr), error::already_exists(E_ALREADY_INITIALIZED));
         
+        // Validate public key
+        assert!(vector::length(&pk) == 32, error::invalid_argument(E_INVALID_PUBLIC_KEY));
+
         // Validate fee account
         assert!(fee_account != @0x0, error::invalid_argument(E_ZERO_APTOS_ADDRESS));
         
@@ -101,7 +113,7 @@ module my_address::btc_bridgev3 {
         
         // Initialize bridge config
         move_to(admin, BridgeConfig {
-            // pk,
+            pk,
             admin: admin_addr,
             fee,
             fee_account,
@@ -131,10 +143,10 @@ module my_address::btc_bridgev3 {
         btc_tokenv3::initialize_module(admin);
     }
 
-    // /// Get the registered public key
-    // public fun pk(): vector<u8> acquires BridgeConfig {
-    //     borrow_global<BridgeConfig>(@my_address).pk
-    // }
+    /// Get the registered public key
+    public fun pk(): vector<u8> acquires BridgeConfig {
+        borrow_global<BridgeConfig>(@my_address).pk
+    }
 
     /// Get the bridge fee
     public fun fee(): u64 acquires BridgeConfig {
@@ -155,8 +167,8 @@ module my_address::btc_bridgev3 {
         btc_tx_id: String,
         receiver: address,
         amount: u64,
-        // rx: u64,
-        // s: u64
+        rx: u256,
+        s: u256
     ) acquires BridgeConfig, MintedTransactions, BridgeEvents {
         let admin_addr = signer::address_of(admin);
         let bridge_config = borrow_global<BridgeConfig>(@my_address);
@@ -186,9 +198,9 @@ module my_address::btc_bridgev3 {
         
         assert!(!already_minted, error::invalid_argument(E_ALREADY_MINTED));
         
-        // In a real implementation, we would verify the Schnorr signature here
-        // For simplicity, we'll assume the signature is valid
-        // verify_schnorr_signature(bridge_config.pk, rx, s, btc_tx_id, receiver, amount);
+        // Verify the threshold Schnorr signature
+        let msg = mint_message(&btc_tx_id, receiver, amount);
+        assert!(schnorr::verify(bridge_config.pk, msg, rx, s), error::invalid_argument(E_INVALID_SCHNORR_SIGNATURE));
         
         // Mint tokens - recipient gets amount minus fee
         let recipient_amount = amount - bridge_config.fee;
@@ -250,8 +262,8 @@ module my_address::btc_bridgev3 {
         amount: u64,
         outpoint_tx_ids: vector<String>,
         outpoint_idxs: vector<u64>,
-        // rx: u64,
-        // s: u64
+        rx: u256,
+        s: u256
     ) acquires BridgeConfig, PreparedRedeems, UsedBtcTxIds, BridgeEvents {
         let admin_addr = signer::address_of(admin);
         let bridge_config = borrow_global<BridgeConfig>(@my_address);
@@ -321,8 +333,9 @@ module my_address::btc_bridgev3 {
             i = i + 1;
         };
         
-        // In a real implementation, we would verify the Schnorr signature here
-        // verify_schnorr_signature(bridge_config.pk, rx, s, hash_of_data);
+        // Verify the threshold Schnorr signature
+        let msg = prepare_message(&redeem_request_tx_hash, requester, &receiver, amount, &outpoint_tx_ids, &outpoint_idxs);
+        assert!(schnorr::verify(bridge_config.pk, msg, rx, s), error::invalid_argument(E_INVALID_SCHNORR_SIGNATURE));
         
         // Mark redeem request as prepared
         vector::push_back(&mut prepared_redeems.prepared, redeem_request_tx_hash);
@@ -347,6 +360,109 @@ module my_address::btc_bridgev3 {
     }
 
 
+    /// @notice Message signed for a mint, the same as MintParameter.GenerateMsgHash() off chain:
+    ///         domain_message("mint", btc_tx_id (32 bytes) || receiver (32 bytes) || amount (uint256))
+    fun mint_message(btc_tx_id: &String, receiver: address, amount: u64): vector<u8> {
+        let data = hex_to_bytes32(btc_tx_id);
+        vector::append(&mut data, bcs::to_bytes(&receiver));
+        vector::append(&mut data, schnorr::u256_to_bytes((amount as u256)));
+        domain_message(b"mint", data)
+    }
+
+    /// @notice Message signed for a redeem prepare, the same as PrepareParameter.GenerateMsgHash() off chain:
+    ///         domain_message("redeem_prepare", request tx hash (32 bytes) || requester (32 bytes) || receiver (utf8) || amount (uint256)
+    ///                   || outpoint tx ids (32 bytes each) || outpoint idxs (uint256 each))
+    fun prepare_message(
+        redeem_request_tx_hash: &String,
+        requester: address,
+        receiver: &String,
+        amount: u64,
+        outpoint_tx_ids: &vector<String>,
+        outpoint_idxs: &vector<u64>,
+    ): vector<u8> {
+        let data = hex_to_bytes32(redeem_request_tx_hash);
+        vector::append(&mut data, bcs::to_bytes(&requester));
+        vector::append(&mut data, *string::bytes(receiver));
+        vector::append(&mut data, schnorr::u256_to_bytes((amount as u256)));
+        let i = 0;
+        while (i < vector::length(outpoint_tx_ids)) {
+            vector::append(&mut data, hex_to_bytes32(vector::borrow(outpoint_tx_ids, i)));
+            i = i + 1;
+        };
+        i = 0;
+        while (i < vector::length(outpoint_idxs)) {
+            vector::append(&mut data, schnorr::u256_to_bytes((*vector::borrow(outpoint_idxs, i) as u256)));
+            i = i + 1;
+        };
+        domain_message(b"redeem_prepare", data)
+    }
+
+    /// Bind the packed params of a msg_type message to this deployment:
+    ///     keccak256(separator || keccak256(msg_type) || params)
+    ///     separator = keccak256("TEENet-BTC-Bridge" || chain type 2 (aptos) || chain id (uint256) || module address)
+    /// A signature for another chain or another bridge deployment does not verify here.
+    fun domain_message(msg_type: vector<u8>, params: vector<u8>): vector<u8> {
+        let separator = b"TEENet-BTC-Bridge";
+        vector::push_back(&mut separator, CHAIN_TYPE_APTOS);
+        vector::append(&mut separator, schnorr::u256_to_bytes((chain_id::get() as u256)));
+        vector::append(&mut separator, bcs::to_bytes(&@my_address));
+
+        let data = aptos_hash::keccak256(separator);
+        vector::append(&mut data, aptos_hash::keccak256(msg_type));
+        vector::append(&mut data, params);
+        aptos_hash::keccak256(data)
+    }
+
+    /// @notice Decode a 32-byte hex string, with or without 0x prefix
+    fun hex_to_bytes32(s: &String): vector<u8> {
+        let chars = string::bytes(s);
+        let len = vector::length(chars);
+        let start = 0;
+        if (len >= 2 && *vector::borrow(chars, 0) == 0x30 && (*vector::borrow(chars, 1) == 0x78 || *vector::borrow(chars, 1) == 0x58)) {
+            start = 2;
+        };
+        assert!(len - start == 64, error::invalid_argument(E_INVALID_HEX_STRING));
+
+        let out = vector::empty<u8>();
+        let i = start;
+        while (i < len) {
+            let hi = hex_digit(*vector::borrow(chars, i));
+            let lo = hex_digit(*vector::borrow(chars, i + 1));
+            vector::push_back(&mut out, hi * 16 + lo);
+            i = i + 2;
+        };
+        out
+    }
+
+    fun hex_digit(c: u8): u8 {
+        if (c >= 0x30 && c <= 0x39) {
+            c - 0x30 // 0-9
+        } else if (c >= 0x61 && c <= 0x66) {
+            c - 0x61 + 10 // a-f
+        } else if (c >= 0x41 && c <= 0x46) {
+            c - 0x41 + 10 // A-F
+        } else {
+            abort error::invalid_argument(E_INVALID_HEX_STRING)
+        }
+    }
+
+    #[test_only]
+    public fun mint_message_for_test(btc_tx_id: &String, receiver: address, amount: u64): vector<u8> {
+        mint_message(btc_tx_id, receiver, amount)
+    }
+
+    #[test_only]
+    public fun prepare_message_for_test(
+        redeem_request_tx_hash: &String,
+        requester: address,
+        receiver: &String,
+        amount: u64,
+        outpoint_tx_ids: &vector<String>,
+        outpoint_idxs: &vector<u64>,
+    ): vector<u8> {
+        prepare_message(redeem_request_tx_hash, requester, receiver, amount, outpoint_tx_ids, outpoint_idxs)
+    }
+
     /// @notice Check if TWBTC token are minted
     /// @param  btc_tx_id tx id of a spendable outpoint
     /// @return bool
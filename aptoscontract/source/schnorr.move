@@ -0,0 +1,275 @@
+/// BIP-340 Schnorr signature verification over secp256k1.
+///
+/// Aptos has no native BIP-340 verifier, so R = s*G - e*P is computed by ecdsa_recover:
+/// recovering a public key from the ecdsa signature (r = px, s' = -e*px) of digest z = -s*px,
+/// with the recovery id of the even-y point P, gives r^-1 * (s'*P - z*G) = s*G - e*P.
+/// The signature is valid if that point has x == rx and an even y.
+module my_address::schnorr {
+    use std::hash;
+    use std::option;
+    use std::vector;
+    use aptos_std::secp256k1;
+
+    /// Order of the secp256k1 group
+    const N: u256 = 0xFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEBAAEDCE6AF48A03BBFD25E8CD0364141;
+
+    /// Size of the secp256k1 field
+    const P: u256 = 0xFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEFFFFFC2F;
+
+    /// Verify a BIP-340 signature (rx, s) of the 32-byte msg,
+    /// pk is the 32-byte x-only public key.
+    public fun verify(pk: vector<u8>, msg: vector<u8>, rx: u256, s: u256): bool {
+        if (vector::length(&pk) != 32 || vector::length(&msg) != 32) {
+            return false
+        };
+        let px = bytes_to_u256(&pk, 0);
+        if (px == 0 || px >= P || rx == 0 || rx >= P || s >= N) {
+            return false
+        };
+
+        // e = int(hash_BIP0340/challenge(rx || px || msg)) mod n
+        let data = u256_to_bytes(rx);
+        vector::append(&mut data, pk);
+        vector::append(&mut data, msg);
+        let challenge = tagged_hash(b"BIP0340/challenge", data);
+        let e = bytes_to_u256(&challenge, 0) % N;
+
+        // ecdsa r is px mod n, recovery id 2 tells x = r + n.
+        let r = px % N;
+        let recovery_id = if (px >= N) 2 else 0;
+        let sig_s = neg_mod(mul_mod(e, r));
+        let z = neg_mod(mul_mod(s, r));
+        if (r == 0 || sig_s == 0) {
+            return false
+        };
+
+        let sig_bytes = u256_to_bytes(r);
+        vector::append(&mut sig_bytes, u256_to_bytes(sig_s));
+        let sig = secp256k1::ecdsa_signature_from_bytes(sig_bytes);
+        let recovered = secp256k1::ecdsa_recover(u256_to_bytes(z), recovery_id, &sig);
+        if (option::is_none(&recovered)) {
+            return false
+        };
+
+        // R = (x, y), 64 bytes
+        let point = secp256k1::ecdsa_raw_public_key_to_bytes(option::borrow(&recovered));
+        let x = bytes_to_u256(&point, 0);
+        let y = bytes_to_u256(&point, 32);
+        x == rx && (y & 1) == 0
+    }
+
+    /// Big-endian 32 bytes of v, same as uint256 in abi.encodePacked.
+    public fun u256_to_bytes(v: u256): vector<u8> {
+        let out = vector::empty<u8>();
+        let i: u64 = 0;
+        while (i < 32) {
+            let shift = (((31 - i) * 8) as u8);
+            vector::push_back(&mut out, (((v >> shift) & 0xff) as u8));
+            i = i + 1;
+        };
+        out
+    }
+
+    /// Read big-endian 32 bytes at offset as u256.
+    fun bytes_to_u256(b: &vector<u8>, offset: u64): u256 {
+        let v: u256 = 0;
+        let i: u64 = 0;
+        while (i < 32) {
+            v = (v << 8) | (*vector::borrow(b, offset + i) as u256);
+            i = i + 1;
+        };
+        v
+    }
+
+    /// sha256(sha256(tag) || sha256(tag) || data)
+    fun tagged_hash(tag: vector<u8>, data: vector<u8>): vector<u8> {
+        let tag_hash = hash::sha2_256(tag);
+        let preimage = copy tag_hash;
+        vector::append(&mut preimage, tag_hash);
+        vector::append(&mut preimage, data);
+        hash::sha2_256(preimage)
+    }
+
+    /// (a + b) mod n, a and b < n, without overflowing u256.
+    fun add_mod(a: u256, b: u256): u256 {
+        let c = N - b;
+        if (a >= c) a - c else a + b
+    }
+
+    /// (a * b) mod n, by double-and-add.
+    fun mul_mod(a: u256, b: u256): u256 {
+        let result: u256 = 0;
+        let base = a % N;
+        let k = b % N;
+        while (k > 0) {
+            if ((k & 1) == 1) {
+                result = add_mod(result, base);
+            };
+            base = add_mod(base, base);
+            k = k >> 1;
+        };
+        result
+    }
+
+    /// (-a) mod n, a < n.
+    fun neg_mod(a: u256): u256 {
+        if (a == 0) 0 else N - a
+    }
+
+    // Official BIP-340 test vectors, https://github.com/bitcoin/bips/blob/master/bip-0340/test-vectors.csv
+
+    #[test]
+    fun test_verify_valid_vectors() {
+        // vector 0
+        assert!(verify(
+            x"F9308A019258C31049344F85F89D5229B531C845836F99B08601F113BCE036F9",
+            x"0000000000000000000000000000000000000000000000000000000000000000",
+            0xE907831F80848D1069A5371B402410364BDF1C5F8307B0084C55F1CE2DCA8215,
+            0x25F66A4A85EA8B71E482A74F382D2CE5EBEEE8FDB2172F477DF4900D310536C0,
+        ), 0);
+        // vector 1
+        assert!(verify(
+            x"DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA659",
+            x"243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89",
+            0x6896BD60EEAE296DB48A229FF71DFE071BDE413E6D43F917DC8DCF8C78DE3341,
+            0x8906D11AC976ABCCB20B091292BFF4EA897EFCB639EA871CFA95F6DE339E4B0A,
+        ), 1);
+        // vector 2
+        assert!(verify(
+            x"DD308AFEC5777E13121FA72B9CC1B7CC0139715309B086C960E18FD969774EB8",
+            x"7E2D58D8B3BCDF1ABADEC7829054F90DDA9805AAB56C77333024B9D0A508B75C",
+            0x5831AAEED7B44BB74E5EAB94BA9D4294C49BCF2A60728D8B4C200F50DD313C1B,
+            0xAB745879A5AD954A72C45A91C3A51D3C7ADEA98D82F8481E0E1E03674A6F3FB7,
+        ), 2);
+        // vector 3, msg = 0xff..ff
+        assert!(verify(
+            x"25D1DFF95105F5253C4022F628A996AD3A0D95FBF21D468A1B33F8C160D8F517",
+            x"FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF",
+            0x7EB0509757E246F19449885651611CB965ECC1A187DD51B64FDA1EDC9637D5EC,
+            0x97582B9CB13DB3933705B32BA982AF5AF25FD78881EBB32771FC5922EFC66EA3,
+        ), 3);
+        // vector 4, rx with leading zero bytes
+        assert!(verify(
+            x"D69C3509BB99E412E68B0FE8544E72837DFA30746D8BE2AA65975F29D22DC7B9",
+            x"4DF3C3F68FCC83B27E9D42C90431A72499F17875C81A599B566C9889B9696703",
+            0x00000000000000000000003B78CE563F89A0ED9414F5AA28AD0D96D6795F9C63,
+            0x76AFB1548AF603B3EB45C9F8207DEE1060CB71C04E80F593060B07D28308D7F4,
+        ), 4);
+    }
+
+    #[test]
+    fun test_verify_wrong_message() {
+        // vector 1 signed another message
+        assert!(!verify(
+            x"DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA659",
+            x"243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C8A",
+            0x6896BD60EEAE296DB48A229FF71DFE071BDE413E6D43F917DC8DCF8C78DE3341,
+            0x8906D11AC976ABCCB20B091292BFF4EA897EFCB639EA871CFA95F6DE339E4B0A,
+        ), 0);
+        // vector 7, negated message
+        assert!(!verify(
+            x"DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA659",
+            x"243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89",
+            0x1FA62E331EDBC21C394792D2AB1100A7B432B013DF3F6FF4F99FCB33E0E1515F,
+            0x28890B3EDB6E7189B630448B515CE4F8622A954CFE545735AAEA5134FCCDB2BD,
+        ), 1);
+        // vector 8, negated s
+        assert!(!verify(
+            x"DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA659",
+            x"243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89",
+            0x6CFF5C3BA86C69EA4B7376F31A9BCB4F74C1976089B2D9963DA2E5543E177769,
+            0x961764B3AA9B2FFCB6EF947B6887A226E8D7C93E00C5ED0C1834FF0D0C2E6DA6,
+        ), 2);
+    }
+
+    #[test]
+    fun test_verify_high_s() {
+        // vector 13, s equal to the curve order
+        assert!(!verify(
+            x"DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA659",
+            x"243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89",
+            0x6CFF5C3BA86C69EA4B7376F31A9BCB4F74C1976089B2D9963DA2E5543E177769,
+            N,
+        ), 0);
+        assert!(!verify(
+            x"DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA659",
+            x"243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89",
+            0x6CFF5C3BA86C69EA4B7376F31A9BCB4F74C1976089B2D9963DA2E5543E177769,
+            0xFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF,
+        ), 1);
+    }
+
+    #[test]
+    fun test_verify_bad_r() {
+        // vector 6, R has an odd y
+        assert!(!verify(
+            x"DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA659",
+            x"243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89",
+            0xFFF97BD5755EEEA420453A14355235D382F6472F8568A18B2F057A1460297556,
+            0x3CC27944640AC607CD107AE10923D9EF7A73C643E166BE5EBEAFA34B1AC553E2,
+        ), 0);
+        // vector 9, sG - eP is infinite, rx = 0
+        assert!(!verify(
+            x"DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA659",
+            x"243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89",
+            0,
+            0x123DDA8328AF9C23A94C1FEECFD123BA4FB73476F0D594DCB65C6425BD186051,
+        ), 1);
+        // vector 10, sG - eP is infinite, rx = 1
+        assert!(!verify(
+            x"DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA659",
+            x"243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89",
+            1,
+            0x7615FBAF5AE28864013C099742DEADB4DBA87F11AC6754F93780D5A1837CF197,
+        ), 2);
+        // vector 11, rx is not an x coordinate on the curve
+        assert!(!verify(
+            x"DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA659",
+            x"243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89",
+            0x4A298DACAE57395A15D0795DDBFD1DCB564DA82B0F269BC70A74F8220429BA1D,
+            0x69E89B4C5564D00349106B8497785DD7D1D713A8AE82B32FA79D5F7FC407D39B,
+        ), 3);
+        // vector 12, rx equal to the field size
+        assert!(!verify(
+            x"DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA659",
+            x"243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89",
+            P,
+            0x69E89B4C5564D00349106B8497785DD7D1D713A8AE82B32FA79D5F7FC407D39B,
+        ), 4);
+    }
+
+    #[test]
+    fun test_verify_bad_public_key() {
+        // vector 5, public key not on the curve
+        assert!(!verify(
+            x"EEFDEA4CDB677750A420FEE807EACF21EB9898AE79B9768766E4FAA04A2D4A34",
+            x"243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89",
+            0x6CFF5C3BA86C69EA4B7376F31A9BCB4F74C1976089B2D9963DA2E5543E177769,
+            0x69E89B4C5564D00349106B8497785DD7D1D713A8AE82B32FA79D5F7FC407D39B,
+        ), 0);
+        // vector 14, public key exceeds the field size
+        assert!(!verify(
+            x"FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEFFFFFC30",
+            x"243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89",
+            0x6CFF5C3BA86C69EA4B7376F31A9BCB4F74C1976089B2D9963DA2E5543E177769,
+            0x69E89B4C5564D00349106B8497785DD7D1D713A8AE82B32FA79D5F7FC407D39B,
+        ), 1);
+        // not 32 bytes
+        assert!(!verify(
+            x"DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA6",
+            x"243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89",
+            0x6896BD60EEAE296DB48A229FF71DFE071BDE413E6D43F917DC8DCF8C78DE3341,
+            0x8906D11AC976ABCCB20B091292BFF4EA897EFCB639EA871CFA95F6DE339E4B0A,
+        ), 2);
+    }
+
+    #[test]
+    fun test_u256_bytes_roundtrip() {
+        let v = 0x00000000000000000000003B78CE563F89A0ED9414F5AA28AD0D96D6795F9C63;
+        let b = u256_to_bytes(v);
+        assert!(vector::length(&b) == 32, 0);
+        assert!(*vector::borrow(&b, 0) == 0, 1);
+        assert!(*vector::borrow(&b, 31) == 0x63, 2);
+        assert!(bytes_to_u256(&b, 0) == v, 3);
+    }
+}
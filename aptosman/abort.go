@@ -0,0 +1,85 @@
+package aptosman
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/TEENet-io/bridge-go/agreement"
+)
+
+// Move abort codes of the bridge modules, keep in sync with
+// btc_bridge.move (btc_bridgev3) and btc_token.move (btc_tokenv3).
+type moveAbort struct {
+	name   string
+	action agreement.TxFailureAction
+}
+
+var bridgeAborts = map[uint64]moveAbort{
+	1:  {"E_NOT_AUTHORIZED", agreement.TxFailurePermanent},
+	2:  {"E_ALREADY_INITIALIZED", agreement.TxFailurePermanent},
+	3:  {"E_ZERO_APTOS_ADDRESS", agreement.TxFailurePermanent},
+	4:  {"E_ZERO_FEE", agreement.TxFailurePermanent},
+	5:  {"E_ALREADY_MINTED", agreement.TxFailureSkip},
+	6:  {"E_AMOUNT_SMALLER_THAN_FEE", agreement.TxFailurePermanent},
+	7:  {"E_INVALID_SCHNORR_SIGNATURE", agreement.TxFailurePermanent},
+	8:  {"E_ALREADY_PREPARED", agreement.TxFailureSkip},
+	9:  {"E_ZERO_APTOS_TX_HASH", agreement.TxFailurePermanent},
+	10: {"E_EMPTY_STRING", agreement.TxFailurePermanent},
+	11: {"E_ZERO_AMOUNT", agreement.TxFailurePermanent},
+	12: {"E_EMPTY_OUTPOINT_TX_IDS", agreement.TxFailurePermanent},
+	13: {"E_EMPTY_OUTPOINT_IDXS", agreement.TxFailurePermanent},
+	14: {"E_OUTPOINT_TX_IDS_AND_OUTPOINT_IDXS_LENGTH_MISMATCH", agreement.TxFailurePermanent},
+	15: {"E_ZERO_OUTPOINT_TX_ID", agreement.TxFailurePermanent},
+	16: {"E_BTC_TX_ID_ALREADY_USED", agreement.TxFailurePermanent}, // another prepare took the UTXO, the vault disagrees with the chain: escalate
+	17: {"E_INVALID_PUBLIC_KEY", agreement.TxFailurePermanent},
+	18: {"E_INVALID_HEX_STRING", agreement.TxFailurePermanent},
+}
+
+var tokenAborts = map[uint64]moveAbort{
+	1: {"E_NOT_AUTHORIZED", agreement.TxFailurePermanent},
+	2: {"E_NOT_FOUND", agreement.TxFailurePermanent},
+	3: {"E_ALREADY_INITIALIZED", agreement.TxFailurePermanent},
+	4: {"E_INSUFFICIENT_BALANCE", agreement.TxFailurePermanent},
+	5: {"E_NOT_IMPLEMENTED", agreement.TxFailurePermanent},
+	6: {"E_AMOUNT_SMALLER_THAN_FEE", agreement.TxFailurePermanent},
+	7: {"E_MAX_SUPPLY_EXCEEDED", agreement.TxFailurePermanent},
+	8: {"E_INVALID_RECIPIENT", agreement.TxFailurePermanent},
+}
+
+var moduleAborts = map[string]map[uint64]moveAbort{
+	"btc_bridgev3": bridgeAborts,
+	"btc_tokenv3":  tokenAborts,
+}
+
+// eg. "Move abort in 0xabc::btc_bridgev3: E_ALREADY_MINTED(0x80005): ..." or "Move abort in 0xabc::btc_bridgev3: 0x80005"
+var moveAbortPattern = regexp.MustCompile(`Move abort in (0x[0-9a-fA-F]+)::(\w+): (?:\w+\()?0x([0-9a-fA-F]+)`)
+
+// DecodeVmStatus 将交易的 vm_status 解析为 agreement.TxFailure
+// Move aborts of the bridge modules are mapped by code, the reason is its lower 16 bits
+// (the upper bits are the std::error category, eg. 0x10005 = invalid_argument(5)),
+// other failures (out of gas, sequence number, ...) are transient.
+func DecodeVmStatus(vmStatus string) *agreement.TxFailure {
+	match := moveAbortPattern.FindStringSubmatch(vmStatus)
+	if match == nil {
+		return &agreement.TxFailure{Action: agreement.TxFailureRetry, Reason: vmStatus}
+	}
+
+	module := match[2]
+	code, err := strconv.ParseUint(match[3], 16, 64)
+	if err != nil {
+		return &agreement.TxFailure{Action: agreement.TxFailurePermanent, Reason: vmStatus}
+	}
+
+	abort, ok := moduleAborts[module][code&0xFFFF]
+	if !ok {
+		return &agreement.TxFailure{
+			Action: agreement.TxFailurePermanent,
+			Reason: fmt.Sprintf("%s::0x%x", module, code),
+		}
+	}
+	return &agreement.TxFailure{
+		Action: abort.action,
+		Reason: fmt.Sprintf("%s::%s", module, abort.name),
+	}
+}
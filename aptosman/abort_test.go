@@ -0,0 +1,40 @@
+package aptosman
+
+import (
+	"testing"
+
+	"github.com/TEENet-io/bridge-go/agreement"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeVmStatus(t *testing.T) {
+	tests := []struct {
+		vmStatus string
+		action   agreement.TxFailureAction
+		reason   string
+	}{
+		{"Move abort in 0xfbfe::btc_bridgev3: 0x5", agreement.TxFailureSkip, "btc_bridgev3::E_ALREADY_MINTED"},
+		{"Move abort in 0xfbfe::btc_bridgev3: E_ALREADY_PREPARED(0x8): ", agreement.TxFailureSkip, "btc_bridgev3::E_ALREADY_PREPARED"},
+		{"Move abort in 0xfbfe::btc_bridgev3: 0x6", agreement.TxFailurePermanent, "btc_bridgev3::E_AMOUNT_SMALLER_THAN_FEE"},
+		{"Move abort in 0xfbfe::btc_bridgev3: 0x10", agreement.TxFailurePermanent, "btc_bridgev3::E_BTC_TX_ID_ALREADY_USED"},
+		{"Move abort in 0xfbfe::btc_tokenv3: 0x7", agreement.TxFailurePermanent, "btc_tokenv3::E_MAX_SUPPLY_EXCEEDED"},
+		{"Move abort in 0x1::coin: EINSUFFICIENT_BALANCE(0x10006): ", agreement.TxFailurePermanent, "coin::0x10006"},
+		{"Out of gas", agreement.TxFailureRetry, "Out of gas"},
+
+		// as reported by the node, the code carries the std::error category
+		{"Move abort in 0x7e3c1f8a2b5d9e04c6a8f1b3d5e7092a4c6e8f0b2d4a6c8e0f1a3b5c7d9e1f2a::btc_bridgev3: E_ALREADY_MINTED(0x80005): BTC tx already minted", agreement.TxFailureSkip, "btc_bridgev3::E_ALREADY_MINTED"},
+		{"Move abort in 0x7e3c1f8a2b5d9e04c6a8f1b3d5e7092a4c6e8f0b2d4a6c8e0f1a3b5c7d9e1f2a::btc_bridgev3: 0x50001", agreement.TxFailurePermanent, "btc_bridgev3::E_NOT_AUTHORIZED"},
+		{"Move abort in 0x7e3c1f8a2b5d9e04c6a8f1b3d5e7092a4c6e8f0b2d4a6c8e0f1a3b5c7d9e1f2a::btc_bridgev3: E_INVALID_SCHNORR_SIGNATURE(0x10007): ", agreement.TxFailurePermanent, "btc_bridgev3::E_INVALID_SCHNORR_SIGNATURE"},
+		{"Move abort in 0x7e3c1f8a2b5d9e04c6a8f1b3d5e7092a4c6e8f0b2d4a6c8e0f1a3b5c7d9e1f2a::btc_bridgev3: 0x30010", agreement.TxFailurePermanent, "btc_bridgev3::E_BTC_TX_ID_ALREADY_USED"},
+		{"Move abort in 0x7e3c1f8a2b5d9e04c6a8f1b3d5e7092a4c6e8f0b2d4a6c8e0f1a3b5c7d9e1f2a::btc_tokenv3: E_INSUFFICIENT_BALANCE(0x10004): ", agreement.TxFailurePermanent, "btc_tokenv3::E_INSUFFICIENT_BALANCE"},
+		{"Move abort in 0x7e3c1f8a2b5d9e04c6a8f1b3d5e7092a4c6e8f0b2d4a6c8e0f1a3b5c7d9e1f2a::btc_bridgev3: 0x10063", agreement.TxFailurePermanent, "btc_bridgev3::0x10063"},
+		{"OUT_OF_GAS", agreement.TxFailureRetry, "OUT_OF_GAS"},
+		{"SEQUENCE_NUMBER_TOO_OLD", agreement.TxFailureRetry, "SEQUENCE_NUMBER_TOO_OLD"},
+	}
+
+	for _, tt := range tests {
+		failure := DecodeVmStatus(tt.vmStatus)
+		assert.Equal(t, tt.action, failure.Action, tt.vmStatus)
+		assert.Equal(t, tt.reason, failure.Reason, tt.vmStatus)
+	}
+}
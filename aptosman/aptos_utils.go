@@ -101,7 +101,8 @@ func initTWBTC(client *aptos.Client, account aptos.TransactionSigner, moduleAddr
 	return txnHash, nil
 }
 
-func initBridge(client *aptos.Client, account aptos.TransactionSigner, moduleAddress string, feeAccount aptos.AccountAddress, fee uint64) (string, error) {
+// pk: the x-only schnorr public key (32 bytes) that signs mint() and redeem_prepare()
+func initBridge(client *aptos.Client, account aptos.TransactionSigner, moduleAddress string, pk []byte, feeAccount aptos.AccountAddress, fee uint64) (string, error) {
 	address := aptos.AccountAddress{}
 	err := address.ParseStringRelaxed(moduleAddress)
 	if err != nil {
@@ -112,6 +113,12 @@ func initBridge(client *aptos.Client, account aptos.TransactionSigner, moduleAdd
 		return "", fmt.Errorf("解析地址失败: %v", err)
 	}
 
+	// Convert pk to vector<u8>
+	pkBytes, err := bcs.SerializeBytes(pk)
+	if err != nil {
+		return "", fmt.Errorf("序列化公钥失败: %v", err)
+	}
+
 	// Convert feeAccount.Address to []byte
 	feeAccountBytes, err := bcs.Serialize(&feeAccount)
 	if err != nil {
@@ -132,7 +139,7 @@ func initBridge(client *aptos.Client, account aptos.TransactionSigner, moduleAdd
 			},
 			Function: "initialize",
 			ArgTypes: []aptos.TypeTag{},
-			Args:     [][]byte{feeAccountBytes, feeBytes},
+			Args:     [][]byte{pkBytes, feeAccountBytes, feeBytes},
 		},
 	},
 	)
@@ -235,7 +242,7 @@ func initBridge(client *aptos.Client, account aptos.TransactionSigner, moduleAdd
 
 // }
 
-func mintTWBTC(client *aptos.Client, account aptos.TransactionSigner, moduleAddress string, receiverAddress aptos.AccountAddress, amount uint64, btc_tx_id string) (MintParams, error) {
+func mintTWBTC(client *aptos.Client, account aptos.TransactionSigner, moduleAddress string, receiverAddress aptos.AccountAddress, amount uint64, btc_tx_id string, rx *big.Int, s *big.Int) (MintParams, error) {
 	address := aptos.AccountAddress{}
 	err := address.ParseStringRelaxed(moduleAddress)
 	if err != nil {
@@ -266,6 +273,10 @@ func mintTWBTC(client *aptos.Client, account aptos.TransactionSigner, moduleAddr
 	if err != nil {
 		return MintParams{}, fmt.Errorf("序列化金额失败: %v", err)
 	}
+	rxBytes, sBytes, err := serializeSignature(rx, s)
+	if err != nil {
+		return MintParams{}, fmt.Errorf("序列化签名失败: %v", err)
+	}
 
 	rawTxn, err := client.BuildTransaction(account.AccountAddress(), aptos.TransactionPayload{
 		Payload: &aptos.EntryFunction{
@@ -275,7 +286,7 @@ func mintTWBTC(client *aptos.Client, account aptos.TransactionSigner, moduleAddr
 			},
 			Function: "mint",
 			ArgTypes: []aptos.TypeTag{},
-			Args:     [][]byte{btc_tx_id_bytes, receiverAddressBytes, amountBytes},
+			Args:     [][]byte{btc_tx_id_bytes, receiverAddressBytes, amountBytes, rxBytes, sBytes},
 		},
 	},
 	)
@@ -311,6 +322,8 @@ func mintTWBTC(client *aptos.Client, account aptos.TransactionSigner, moduleAddr
 		mintParams.BtcTxId = btc_tx_id_bytes
 		mintParams.Amount = amount
 		mintParams.Receiver = receiverAddress.String()
+		mintParams.Rx = rx
+		mintParams.S = s
 		return mintParams, nil
 	} else {
 		return MintParams{}, fmt.Errorf("交易执行失败: %s", userTxn.VmStatus)
@@ -335,7 +348,7 @@ func string_to_bytes(str string) []byte {
 // 修改 redeemPrepare 函数签名，使其接受 [][32]byte 和 []uint16 类型参数
 func redeemPrepare(client *aptos.Client, account aptos.TransactionSigner, moduleAddress string,
 	redeem_request_tx_hash string, requester string, receiverAddress string,
-	amount uint64, outpointTxIds [][32]byte, outpointIdxs []uint16) (PrepareParams, error) {
+	amount uint64, outpointTxIds [][32]byte, outpointIdxs []uint16, rx *big.Int, s *big.Int) (PrepareParams, error) {
 	address := aptos.AccountAddress{}
 	err := address.ParseStringRelaxed(moduleAddress)
 	if err != nil {
@@ -373,6 +386,11 @@ func redeemPrepare(client *aptos.Client, account aptos.TransactionSigner, module
 	}
 	outpointIdxsBytes := serializeU64Vector(uint64Idxs)
 
+	rxBytes, sBytes, err := serializeSignature(rx, s)
+	if err != nil {
+		return PrepareParams{}, fmt.Errorf("序列化签名失败: %v", err)
+	}
+
 	// 构建交易
 	rawTxn, err := client.BuildTransaction(account.AccountAddress(), aptos.TransactionPayload{
 		Payload: &aptos.EntryFunction{
@@ -382,7 +400,7 @@ func redeemPrepare(client *aptos.Client, account aptos.TransactionSigner, module
 			},
 			Function: "redeem_prepare",
 			ArgTypes: []aptos.TypeTag{},
-			Args:     [][]byte{txHashBytes, requesterBytes, receiverBytes, amountBytes, outpointTxIdsBytes, outpointIdxsBytes},
+			Args:     [][]byte{txHashBytes, requesterBytes, receiverBytes, amountBytes, outpointTxIdsBytes, outpointIdxsBytes, rxBytes, sBytes},
 		},
 	})
 
@@ -425,6 +443,8 @@ func redeemPrepare(client *aptos.Client, account aptos.TransactionSigner, module
 		prepareParams.Requester = requester
 		prepareParams.Receiver = receiverAddress
 		prepareParams.Amount = amount
+		prepareParams.Rx = rx
+		prepareParams.S = s
 		prepareParams.OutpointTxIds = stringTxIds
 		prepareParams.OutpointIdxs = outpointIdxs
 		return prepareParams, nil
@@ -1,6 +1,7 @@
 package aptosman
 
 import (
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -8,10 +9,10 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/TEENet-io/bridge-go/agreement"
+	"github.com/TEENet-io/bridge-go/common"
 	"github.com/aptos-labs/aptos-go-sdk"
 	"github.com/aptos-labs/aptos-go-sdk/bcs"
 	"github.com/btcsuite/btcd/chaincfg"
@@ -24,7 +25,8 @@ type Aptosman struct {
 	cfg            *AptosmanConfig
 	account        *aptos.Account
 	moduleAddress  aptos.AccountAddress
-	mu             sync.Mutex
+	seq            sequenceManager  // 桥账户的本地 sequence number, see sequence.go
+	cursors        EventCursorStore // 事件句柄的读取位置, see event_cursor.go
 	BtcChainConfig *chaincfg.Params // 添加比特币网络配置
 
 }
@@ -59,6 +61,7 @@ func NewAptosman(cfg *AptosmanConfig, account *aptos.Account) (*Aptosman, error)
 		cfg:           cfg,
 		account:       account,
 		moduleAddress: moduleAddress,
+		cursors:       newMemEventCursorStore(),
 	}, nil
 }
 
@@ -95,7 +98,49 @@ func (aptman *Aptosman) GetPublicKey() (string, error) {
 	return pk.String(), nil
 }
 
-// GetModuleEvents 获取一定范围内模块的事件
+// GetBridgePublicKey 获取桥合约中登记的 schnorr 公钥 (x-only, 32 bytes)
+// mint() and redeem_prepare() only accept signatures by this key.
+func (aptman *Aptosman) GetBridgePublicKey() ([32]byte, error) {
+	var pk [32]byte
+	resourceType := fmt.Sprintf("%s::btc_bridgev3::BridgeConfig", aptman.moduleAddress.String())
+	resource, err := aptman.aptosClient.AccountResource(aptman.moduleAddress, resourceType)
+	if err != nil {
+		return pk, fmt.Errorf("获取BridgeConfig资源失败: %v", err)
+	}
+
+	data, ok := resource["data"].(map[string]interface{})
+	if !ok {
+		return pk, fmt.Errorf("BridgeConfig资源格式错误")
+	}
+	pkHex, ok := data["pk"].(string)
+	if !ok {
+		return pk, fmt.Errorf("BridgeConfig中没有公钥")
+	}
+	pkBytes, err := hex.DecodeString(strings.TrimPrefix(pkHex, "0x"))
+	if err != nil || len(pkBytes) != len(pk) {
+		return pk, fmt.Errorf("BridgeConfig中的公钥无效: %s", pkHex)
+	}
+	copy(pk[:], pkBytes)
+	return pk, nil
+}
+
+// SigningDomain 桥模块的签名域 (aptos 链 id + 模块地址)
+// mint() and redeem_prepare() only accept messages signed for this domain.
+func (aptman *Aptosman) SigningDomain() (*common.SigningDomain, error) {
+	chainId, err := aptman.aptosClient.GetChainId()
+	if err != nil {
+		return nil, fmt.Errorf("获取链ID失败: %v", err)
+	}
+
+	return common.NewSigningDomain(
+		common.DEPOSIT_CHAIN_TYPE_APTOS,
+		big.NewInt(int64(chainId)),
+		aptman.moduleAddress[:],
+	), nil
+}
+
+// GetModuleEvents 获取版本号 [startVersion, endVersion] 内模块的事件
+// Each event handle is read from its cursor (see event_cursor.go), the cursors advance to endVersion.
 func (aptman *Aptosman) GetModuleEvents(startVersion, endVersion uint64) (
 	[]MintedEvent,
 	[]RedeemRequestedEvent,
@@ -127,16 +172,16 @@ func (aptman *Aptosman) GetModuleEvents(startVersion, endVersion uint64) (
 // 获取铸币事件
 func (aptman *Aptosman) getMintEvents(startVersion, endVersion uint64) ([]MintedEvent, error) {
 	eventHandle := "mint_events"
-	events, err := aptman.GetEvents(eventHandle, startVersion, endVersion)
+	events, err := aptman.fetchModuleEvents(eventHandle, startVersion, endVersion)
 	if err != nil {
 		return nil, fmt.Errorf("获取铸币事件失败: %v", err)
 	}
 	var mintEvents []MintedEvent
 	for _, event := range events {
-		var mintEvent MintedEvent
+		mintEvent := MintedEvent{Version: event.Version, Sequence: event.SequenceNumber}
 		// 直接从原始map解析，实际中应使用标准方法
-		if data, ok := event["data"].(map[string]interface{}); ok {
-			mintEvent.MintTxHash = event["version"].(string)
+		if data := event.Data; data != nil {
+			mintEvent.MintTxHash = event.versionString()
 			mintEvent.BtcTxId = data["btc_tx_id"].(string)
 			mintEvent.Receiver = data["receiver"].(string)
 			if amountStr, ok := data["amount"].(string); ok {
@@ -153,16 +198,16 @@ func (aptman *Aptosman) getMintEvents(startVersion, endVersion uint64) ([]Minted
 func (aptman *Aptosman) getRedeemRequestedEvents(startVersion, endVersion uint64) ([]RedeemRequestedEvent, error) {
 	// eventHandle := fmt.Sprintf("%s::btc_bridgev3::BridgeEvents/redeem_request_events", aptman.moduleAddress.String())
 	eventHandle := "redeem_request_events"
-	events, err := aptman.GetEvents(eventHandle, startVersion, endVersion)
+	events, err := aptman.fetchModuleEvents(eventHandle, startVersion, endVersion)
 	if err != nil {
 		return nil, fmt.Errorf("获取赎回请求事件失败: %v", err)
 	}
 
 	var redeemRequestedEvents []RedeemRequestedEvent
 	for _, event := range events {
-		var redeemEvent RedeemRequestedEvent
-		if data, ok := event["data"].(map[string]interface{}); ok {
-			redeemEvent.RequestTxHash = event["version"].(string)
+		redeemEvent := RedeemRequestedEvent{Version: event.Version, Sequence: event.SequenceNumber}
+		if data := event.Data; data != nil {
+			redeemEvent.RequestTxHash = event.versionString()
 			redeemEvent.Requester = data["sender"].(string)
 			redeemEvent.Receiver = data["receiver"].(string)
 			if amountStr, ok := data["amount"].(string); ok {
@@ -179,7 +224,7 @@ func (aptman *Aptosman) getRedeemRequestedEvents(startVersion, endVersion uint64
 // 获取赎回准备事件
 func (aptman *Aptosman) getRedeemPreparedEvents(startVersion, endVersion uint64) ([]RedeemPreparedEvent, error) {
 	eventHandle := "redeem_prepare_events"
-	events, err := aptman.GetEvents(eventHandle, startVersion, endVersion)
+	events, err := aptman.fetchModuleEvents(eventHandle, startVersion, endVersion)
 	if err != nil {
 		return []RedeemPreparedEvent{}, fmt.Errorf("获取赎回准备事件失败: %v", err)
 	}
@@ -189,16 +234,14 @@ func (aptman *Aptosman) getRedeemPreparedEvents(startVersion, endVersion uint64)
 
 	var redeemPreparedEvents []RedeemPreparedEvent
 	for _, event := range events {
-		var prepareEvent RedeemPreparedEvent
-		data, ok := event["data"].(map[string]interface{})
-		if !ok {
+		prepareEvent := RedeemPreparedEvent{Version: event.Version, Sequence: event.SequenceNumber}
+		data := event.Data
+		if data == nil {
 			continue
 		}
 
 		// 获取版本号作为RequestTxHash
-		if version, ok := event["version"].(string); ok {
-			prepareEvent.RequestTxHash = version
-		}
+		prepareEvent.RequestTxHash = event.versionString()
 
 		// 解析其他字段
 		if txHash, ok := data["RequestTxhash"].(string); ok {
@@ -243,16 +286,13 @@ func (aptman *Aptosman) getRedeemPreparedEvents(startVersion, endVersion uint64)
 	return redeemPreparedEvents, nil
 }
 
-// 铸造TWBTC代币
-func (aptman *Aptosman) Mint(params *MintParams) (string, error) {
-	aptman.mu.Lock()
-	defer aptman.mu.Unlock()
-
+// mintPayload 构建 mint 交易的 Payload
+func (aptman *Aptosman) mintPayload(params *MintParams) (*aptos.TransactionPayload, error) {
 	// 序列化参数
 	receiverAddr := aptos.AccountAddress{}
 	err := receiverAddr.ParseStringRelaxed(params.Receiver)
 	if err != nil {
-		return "", fmt.Errorf("解析接收者地址失败: %v", err)
+		return nil, fmt.Errorf("解析接收者地址失败: %v", err)
 	}
 
 	btc_tx_id_len := len(params.BtcTxId)
@@ -269,12 +309,17 @@ func (aptman *Aptosman) Mint(params *MintParams) (string, error) {
 
 	receiverBytes, err := bcs.Serialize(&receiverAddr)
 	if err != nil {
-		return "", fmt.Errorf("序列化接收者地址失败: %v", err)
+		return nil, fmt.Errorf("序列化接收者地址失败: %v", err)
 	}
 
 	amountBytes, err := bcs.SerializeU64(params.Amount)
 	if err != nil {
-		return "", fmt.Errorf("序列化金额失败: %v", err)
+		return nil, fmt.Errorf("序列化金额失败: %v", err)
+	}
+
+	rxBytes, sBytes, err := serializeSignature(params.Rx, params.S)
+	if err != nil {
+		return nil, fmt.Errorf("序列化签名失败: %v", err)
 	}
 
 	// 构建交易Payload
@@ -290,34 +335,42 @@ func (aptman *Aptosman) Mint(params *MintParams) (string, error) {
 				btc_tx_id_bytes,
 				receiverBytes,
 				amountBytes,
+				rxBytes,
+				sBytes,
 			},
 		},
 	}
 
-	// 构建、签名并提交交易
-	txn, err := aptman.aptosClient.BuildTransaction(aptman.account.AccountAddress(), payload)
-	if err != nil {
-		return "", fmt.Errorf("构建交易失败: %v", err)
-	}
+	return &payload, nil
+}
 
-	signedTxn, err := txn.SignedTransaction(aptman.account)
+// SubmitMint 以桥账户提交 mint 交易, 不等待确认
+// (本地 sequence number, 可与其他交易同时在途)
+func (aptman *Aptosman) SubmitMint(params *MintParams) (string, error) {
+	payload, err := aptman.mintPayload(params)
 	if err != nil {
-		return "", fmt.Errorf("签名交易失败: %v", err)
+		return "", err
 	}
+	return aptman.submitAsBridge(*payload)
+}
 
-	submitResult, err := aptman.aptosClient.SubmitTransaction(signedTxn)
+// 铸造TWBTC代币, 等待交易确认
+// A tx aborted on chain returns its hash with the decoded *agreement.TxFailure.
+func (aptman *Aptosman) Mint(params *MintParams) (string, error) {
+	txHash, err := aptman.SubmitMint(params)
 	if err != nil {
-		return "", fmt.Errorf("提交交易失败: %v", err)
+		return "", err
 	}
 
 	// 等待交易确认
-	_, err = aptman.aptosClient.WaitForTransaction(submitResult.Hash)
+	userTxn, err := aptman.aptosClient.WaitForTransaction(txHash)
 	if err != nil {
-		return "", fmt.Errorf("等待交易确认失败: %v", err)
+		return txHash, fmt.Errorf("等待交易确认失败: %v", err)
 	}
-	// TODO check resullt
-	// logger.WithField("txHash", submitResult.Hash).Info("txHash")
-	return submitResult.Hash, nil
+	if !userTxn.Success {
+		return txHash, DecodeVmStatus(userTxn.VmStatus)
+	}
+	return txHash, nil
 }
 
 // RedeemRequest 发起赎回请求
@@ -427,12 +480,12 @@ func (aptman *Aptosman) RegisterTWBTC(account *aptos.Account) (string, error) {
 	return submitResult.Hash, nil
 }
 
-// RedeemPrepare 准备赎回交易
-func (aptman *Aptosman) RedeemPrepare(account *aptos.Account, params *PrepareParams) (string, error) {
+// redeemPreparePayload 构建 redeem_prepare 交易的 Payload
+func (aptman *Aptosman) redeemPreparePayload(params *PrepareParams) (*aptos.TransactionPayload, error) {
 	address := aptos.AccountAddress{}
 	err := address.ParseStringRelaxed(aptman.moduleAddress.String())
 	if err != nil {
-		return "", fmt.Errorf("解析模块地址失败: %v", err)
+		return nil, fmt.Errorf("解析模块地址失败: %v", err)
 	}
 
 	txHashBytes := serializeString(params.RequestTxHash)
@@ -440,18 +493,18 @@ func (aptman *Aptosman) RedeemPrepare(account *aptos.Account, params *PreparePar
 	requesterAddr := aptos.AccountAddress{}
 	err = requesterAddr.ParseStringRelaxed(params.Requester)
 	if err != nil {
-		return "", fmt.Errorf("解析requester地址失败: %v", err)
+		return nil, fmt.Errorf("解析requester地址失败: %v", err)
 	}
 	requesterBytes, err := bcs.Serialize(&requesterAddr)
 	if err != nil {
-		return "", fmt.Errorf("序列化requester地址失败: %v", err)
+		return nil, fmt.Errorf("序列化requester地址失败: %v", err)
 	}
 
 	receiverBytes := serializeString(params.Receiver)
 
 	amountBytes, err := bcs.SerializeU64(params.Amount)
 	if err != nil {
-		return "", fmt.Errorf("序列化金额失败: %v", err)
+		return nil, fmt.Errorf("序列化金额失败: %v", err)
 	}
 
 	outpointTxIdsBytes := serializeStringVector(params.OutpointTxIds)
@@ -462,8 +515,12 @@ func (aptman *Aptosman) RedeemPrepare(account *aptos.Account, params *PreparePar
 	}
 	outpointIdxsBytes := serializeU64Vector(uint64Idxs)
 
-	// 构建交易
-	rawTxn, err := aptman.aptosClient.BuildTransaction(account.AccountAddress(), aptos.TransactionPayload{
+	rxBytes, sBytes, err := serializeSignature(params.Rx, params.S)
+	if err != nil {
+		return nil, fmt.Errorf("序列化签名失败: %v", err)
+	}
+
+	payload := aptos.TransactionPayload{
 		Payload: &aptos.EntryFunction{
 			Module: aptos.ModuleId{
 				Address: aptman.moduleAddress,
@@ -471,46 +528,77 @@ func (aptman *Aptosman) RedeemPrepare(account *aptos.Account, params *PreparePar
 			},
 			Function: "redeem_prepare",
 			ArgTypes: []aptos.TypeTag{},
-			Args:     [][]byte{txHashBytes, requesterBytes, receiverBytes, amountBytes, outpointTxIdsBytes, outpointIdxsBytes},
+			Args:     [][]byte{txHashBytes, requesterBytes, receiverBytes, amountBytes, outpointTxIdsBytes, outpointIdxsBytes, rxBytes, sBytes},
 		},
-	})
-
-	if err != nil {
-		return "", fmt.Errorf("构建交易失败: %v", err)
 	}
+	return &payload, nil
+}
 
-	signedTxn, err := rawTxn.SignedTransaction(account)
+// SubmitRedeemPrepare 以桥账户提交 redeem_prepare 交易, 不等待确认
+// The bridge account shares the local sequence number with in-flight mints.
+func (aptman *Aptosman) SubmitRedeemPrepare(params *PrepareParams) (string, error) {
+	payload, err := aptman.redeemPreparePayload(params)
 	if err != nil {
-		return "", fmt.Errorf("签名交易失败: %v", err)
+		return "", err
 	}
+	return aptman.submitAsBridge(*payload)
+}
 
-	submitResult, err := aptman.aptosClient.SubmitTransaction(signedTxn)
-	if err != nil {
-		return "", fmt.Errorf("提交交易失败: %v", err)
+// RedeemPrepare 准备赎回交易, 等待交易确认
+// A tx aborted on chain returns its hash with the decoded *agreement.TxFailure.
+func (aptman *Aptosman) RedeemPrepare(account *aptos.Account, params *PrepareParams) (string, error) {
+	// 构建、签名并提交交易
+	var txHash string
+	if account == aptman.account {
+		var err error
+		txHash, err = aptman.SubmitRedeemPrepare(params)
+		if err != nil {
+			return "", err
+		}
+	} else {
+		payload, err := aptman.redeemPreparePayload(params)
+		if err != nil {
+			return "", err
+		}
+		rawTxn, err := aptman.aptosClient.BuildTransaction(account.AccountAddress(), *payload)
+		if err != nil {
+			return "", fmt.Errorf("构建交易失败: %v", err)
+		}
+
+		signedTxn, err := rawTxn.SignedTransaction(account)
+		if err != nil {
+			return "", fmt.Errorf("签名交易失败: %v", err)
+		}
+
+		submitResult, err := aptman.aptosClient.SubmitTransaction(signedTxn)
+		if err != nil {
+			return "", fmt.Errorf("提交交易失败: %v", err)
+		}
+		txHash = submitResult.Hash
 	}
-	fmt.Println("submitResult.Hash:", submitResult.Hash)
+	logger.WithField("txHash", txHash).Debug("赎回准备交易已提交")
 	// 等待交易确认
-	_, err = aptman.aptosClient.WaitForTransaction(submitResult.Hash)
+	_, err := aptman.aptosClient.WaitForTransaction(txHash)
 	if err != nil {
-		return "", fmt.Errorf("等待交易确认失败: %v", err)
+		return txHash, fmt.Errorf("等待交易确认失败: %v", err)
 	}
 
 	// 验证交易是否成功
-	txnInfo, err := aptman.aptosClient.TransactionByHash(submitResult.Hash)
+	txnInfo, err := aptman.aptosClient.TransactionByHash(txHash)
 	if err != nil {
-		return "", fmt.Errorf("获取交易信息失败: %v", err)
+		return txHash, fmt.Errorf("获取交易信息失败: %v", err)
 	}
 
 	userTxn, err := txnInfo.UserTransaction()
 	if err != nil {
-		return "", fmt.Errorf("解析用户交易信息失败: %v", err)
+		return txHash, fmt.Errorf("解析用户交易信息失败: %v", err)
 	}
 
 	if !userTxn.Success {
-		return "", fmt.Errorf("交易执行失败: %s", userTxn.VmStatus)
+		return txHash, DecodeVmStatus(userTxn.VmStatus)
 	}
 
-	return submitResult.Hash, nil
+	return txHash, nil
 }
 
 // 获取TWBTC余额
@@ -613,7 +701,7 @@ func (aptman *Aptosman) IsUsed(btcTxId string) (bool, error) {
 // https://api.devnet.aptoslabs.com/v1/accounts/0xfbfe84d58d9ef1366f295066dbf1767f53d52d319843800c63c5e32d66411864/events/0xfbfe84d58d9ef1366f295066dbf1767f53d52d319843800c63c5e32d66411864::btc_bridgev3::BridgeEvents/redeem_request_events?start=1
 
 // https://fullnode.devnet.aptoslabs.com/v1/accounts/0xfbfe84d58d9ef1366f295066dbf1767f53d52d319843800c63c5e32d66411864/events/0xfbfe84d58d9ef1366f295066dbf1767f53d52d319843800c63c5e32d66411864::btc_bridgev3::BridgeEvents/mint_events
-// GetEvents 获取事件
+// GetEvents 获取事件句柄中从序号 start 开始的最多 limit 个事件 (limit=0 为节点默认值)
 func (aptman *Aptosman) GetEvents(events_field string, limit uint64, start uint64) ([]map[string]interface{}, error) {
 	address := aptos.AccountAddress{}
 	err := address.ParseStringRelaxed(aptman.moduleAddress.String())
@@ -656,8 +744,8 @@ func (aptman *Aptosman) GetEvents(events_field string, limit uint64, start uint6
 		return nil, fmt.Errorf("counter字段解析失败")
 	}
 
-	// 如果没有事件，直接返回空数组
-	if counter.Cmp(big.NewInt(0)) == 0 {
+	// 如果没有(新)事件，直接返回空数组
+	if counter.Cmp(new(big.Int).SetUint64(start)) <= 0 {
 		return []map[string]interface{}{}, nil
 	}
 
@@ -702,12 +790,10 @@ func (aptman *Aptosman) GetEvents(events_field string, limit uint64, start uint6
 	}
 
 	q := req.URL.Query()
-	// if limit > 0 {
-	// 	q.Add("limit", fmt.Sprintf("%d", limit))
-	// }
-	// if start > 0 {
-	// 	q.Add("start", fmt.Sprintf("%d", start))
-	// }
+	if limit > 0 {
+		q.Add("limit", fmt.Sprintf("%d", limit))
+	}
+	q.Add("start", fmt.Sprintf("%d", start))
 	req.URL.RawQuery = q.Encode()
 
 	// 发送请求
@@ -780,80 +866,29 @@ func (aptman *Aptosman) NewSyncWorker() *AptosSyncWorker {
 func (aptman *Aptosman) NewMgrWorker() *AptosMgrWorker {
 	return NewAptosMgrWorker(aptman)
 }
-func (w *AptosSyncWorker) DoMint(params *agreement.MintParameter) ([]byte, *big.Int, error) {
-	// 添加日志查看原始数据
-	logger.WithFields(logger.Fields{
-		"original_receiver": fmt.Sprintf("%x", params.Receiver),
-		"receiver_len":      len(params.Receiver),
-	}).Info("Original receiver data")
-
-	receiverHex := aptos.BytesToHex(params.Receiver)
-	expectedAddress := "0xfbfe84d58d9ef1366f295066dbf1767f53d52d319843800c63c5e32d66411864"
 
-	if receiverHex != expectedAddress {
-		logger.WithFields(logger.Fields{
-			"original": receiverHex,
-			"override": expectedAddress,
-		}).Info("Overriding receiver address")
-		receiverHex = expectedAddress
-	}
-
-	// 转换 BtcTxId 为正确的格式
-	btcTxIdHex := fmt.Sprintf("0x%x", params.BtcTxId[:])
-
-	mintParams := &MintParams{
-		BtcTxId:  []byte(btcTxIdHex), // Convert string to []byte
-		Amount:   params.Amount.Uint64(),
-		Receiver: receiverHex,
-	}
-
-	// 添加日志查看转换后的数据
-	logger.WithFields(logger.Fields{
-		"converted_receiver": mintParams.Receiver,
-		"btc_tx_id":          fmt.Sprintf("%x", mintParams.BtcTxId),
-		"amount":             mintParams.Amount,
-	}).Info("Converted mint params")
-
-	// 调用 Aptosman 的 Mint 方法
-	txHash, err := w.aptosman.Mint(mintParams)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to mint: %v", err)
-	}
-
-	// 获取当前的账本版本号
-	version, err := w.aptosman.GetLatestFinalizedVersion()
-	if err != nil {
-		return []byte(txHash), nil, fmt.Errorf("mint succeeded but failed to get ledger version: %v", err)
-	}
-
-	return []byte(txHash), big.NewInt(int64(version)), nil
+// DoMint 与 AptosMgrWorker 相同, 签名 (Rx, S) 随参数提交
+func (w *AptosSyncWorker) DoMint(params *agreement.MintParameter) ([]byte, *big.Int, error) {
+	return NewAptosMgrWorker(w.aptosman).DoMint(params)
 }
 
+// DoPrepare 与 AptosMgrWorker 相同, 签名 (Rx, S) 随参数提交
 func (w *AptosSyncWorker) DoPrepare(params *agreement.PrepareParameter) ([]byte, *big.Int, error) {
-	logger.WithField("DoPrepare", "start").Info("DoPrepare")
-	prepareParams := &PrepareParams{
-		RequestTxHash: string(params.RequestTxHash[:]),
-		Amount:        params.Amount.Uint64(),
-		Receiver:      string(params.Receiver),
-	}
-
-	// 调用 Aptosman 的 Prepare 方法
-	txHash, err := w.aptosman.RedeemPrepare(w.aptosman.account, prepareParams)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to prepare: %v", err)
-	}
-
-	// 获取当前的账本版本号
-	version, err := w.aptosman.GetLatestFinalizedVersion()
-	if err != nil {
-		return []byte(txHash), nil, fmt.Errorf("prepare succeeded but failed to get ledger version: %v", err)
-	}
+	return NewAptosMgrWorker(w.aptosman).DoPrepare(params)
+}
 
-	return []byte(txHash), big.NewInt(int64(version)), nil
+// GetTxFailure 与 AptosMgrWorker 相同
+func (w *AptosSyncWorker) GetTxFailure(txId []byte) (*agreement.TxFailure, error) {
+	return NewAptosMgrWorker(w.aptosman).GetTxFailure(txId)
 }
 
 // GetTxStatus 获取交易状态
 func (w *AptosSyncWorker) GetTxStatus(txId []byte) (agreement.MonitoredTxStatus, *big.Int, error) {
+	// A dropped tx of the bridge account blocks the later ones, fill the gaps first.
+	if _, err := w.aptosman.RecoverSequenceGaps(); err != nil {
+		logger.WithError(err).Warn("failed to recover sequence gaps")
+	}
+
 	txHash := string(txId)
 	tx, err := w.aptosman.aptosClient.WaitForTransaction(txHash, 100*time.Millisecond, 5*time.Second)
 	if err != nil {
@@ -881,9 +916,9 @@ func (w *AptosSyncWorker) GetLatestLedgerNumber() (*big.Int, error) {
 }
 
 func (w *AptosSyncWorker) IsMinted(btcTxId [32]byte) (bool, error) {
-	return w.aptosman.IsMinted(string(btcTxId[:]))
+	return w.aptosman.IsMinted(moveHexStr(btcTxId))
 }
 
 func (w *AptosSyncWorker) IsPrepared(txHash [32]byte) (bool, error) {
-	return w.aptosman.IsPrepared(string(txHash[:]))
+	return w.aptosman.IsPrepared(moveHexStr(txHash))
 }
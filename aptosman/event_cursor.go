@@ -0,0 +1,180 @@
+package aptosman
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/TEENet-io/bridge-go/agreement"
+)
+
+const EVENT_PAGE_SIZE = 100 // events per request, the fullnode caps it at 100
+
+// EventCursorStore 持久化每个事件句柄的读取位置 (state.State 实现)
+// Return nil cursor if the handle is never read.
+type EventCursorStore interface {
+	GetEventCursor(handle string) (*agreement.EventCursor, error)
+	SetEventCursor(handle string, cursor *agreement.EventCursor) error
+}
+
+// memEventCursorStore 默认的内存实现, 重启后从头读取
+type memEventCursorStore struct {
+	mu      sync.Mutex
+	cursors map[string]agreement.EventCursor
+}
+
+func newMemEventCursorStore() *memEventCursorStore {
+	return &memEventCursorStore{cursors: make(map[string]agreement.EventCursor)}
+}
+
+func (s *memEventCursorStore) GetEventCursor(handle string) (*agreement.EventCursor, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cursor, ok := s.cursors[handle]
+	if !ok {
+		return nil, nil
+	}
+	return &cursor, nil
+}
+
+func (s *memEventCursorStore) SetEventCursor(handle string, cursor *agreement.EventCursor) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.cursors[handle] = *cursor
+	return nil
+}
+
+// SetEventCursorStore 设置事件游标的持久化存储
+func (aptman *Aptosman) SetEventCursorStore(store EventCursorStore) {
+	aptman.cursors = store
+}
+
+// moduleEvent 事件句柄中的一个事件
+type moduleEvent struct {
+	SequenceNumber uint64
+	Version        uint64 // version of the tx that emitted the event
+	Data           map[string]interface{}
+}
+
+func parseModuleEvent(raw map[string]interface{}) (*moduleEvent, error) {
+	seqStr, ok := raw["sequence_number"].(string)
+	if !ok {
+		return nil, fmt.Errorf("未找到sequence_number字段")
+	}
+	seq, err := parseUint64(seqStr)
+	if err != nil {
+		return nil, fmt.Errorf("sequence_number字段解析失败: %v", err)
+	}
+
+	versionStr, ok := raw["version"].(string)
+	if !ok {
+		return nil, fmt.Errorf("未找到version字段")
+	}
+	version, err := parseUint64(versionStr)
+	if err != nil {
+		return nil, fmt.Errorf("version字段解析失败: %v", err)
+	}
+
+	data, _ := raw["data"].(map[string]interface{})
+	return &moduleEvent{SequenceNumber: seq, Version: version, Data: data}, nil
+}
+
+// eventPager 读取事件句柄中从 start 开始的最多 limit 个事件
+type eventPager func(start, limit uint64) ([]*moduleEvent, error)
+
+// pageEvents 从游标处逐页读取, 返回 [startVersion, endVersion] 内的事件和新的游标
+//
+// Events are read in sequence number order until the handle is caught up,
+// or an event beyond endVersion (not finalized yet) is met, it is read again next time.
+// Events before startVersion were synced already and are dropped.
+// If the window starts at or before the version the cursor covers (eg. a rescan),
+// the handle is read again from its first event.
+func pageEvents(fetch eventPager, cursor agreement.EventCursor, startVersion, endVersion uint64) (
+	[]*moduleEvent,
+	agreement.EventCursor,
+	error,
+) {
+	if cursor.Version >= startVersion {
+		cursor = agreement.EventCursor{}
+	}
+
+	var events []*moduleEvent
+	for {
+		page, err := fetch(cursor.Sequence, EVENT_PAGE_SIZE)
+		if err != nil {
+			return nil, cursor, err
+		}
+
+		for _, ev := range page {
+			if ev.SequenceNumber < cursor.Sequence {
+				continue
+			}
+			if ev.Version > endVersion {
+				cursor.Version = endVersion
+				return events, cursor, nil
+			}
+			if ev.Version >= startVersion {
+				events = append(events, ev)
+			}
+			cursor.Sequence = ev.SequenceNumber + 1
+		}
+
+		if uint64(len(page)) < EVENT_PAGE_SIZE {
+			break
+		}
+	}
+
+	cursor.Version = endVersion
+	return events, cursor, nil
+}
+
+// eventHandle 事件句柄的唯一标识, 用作游标的键
+func (aptman *Aptosman) eventHandle(eventsField string) string {
+	return fmt.Sprintf("%s::btc_bridgev3::BridgeEvents/%s", aptman.moduleAddress.String(), eventsField)
+}
+
+// fetchModuleEvents 读取事件句柄中 [startVersion, endVersion] 内的事件并推进游标
+func (aptman *Aptosman) fetchModuleEvents(eventsField string, startVersion, endVersion uint64) ([]*moduleEvent, error) {
+	handle := aptman.eventHandle(eventsField)
+	stored, err := aptman.cursors.GetEventCursor(handle)
+	if err != nil {
+		return nil, fmt.Errorf("获取事件游标失败: %v", err)
+	}
+	cursor := agreement.EventCursor{}
+	if stored != nil {
+		cursor = *stored
+	}
+
+	fetch := func(start, limit uint64) ([]*moduleEvent, error) {
+		raws, err := aptman.GetEvents(eventsField, limit, start)
+		if err != nil {
+			return nil, err
+		}
+		page := make([]*moduleEvent, 0, len(raws))
+		for _, raw := range raws {
+			ev, err := parseModuleEvent(raw)
+			if err != nil {
+				return nil, err
+			}
+			page = append(page, ev)
+		}
+		return page, nil
+	}
+
+	events, cursor, err := pageEvents(fetch, cursor, startVersion, endVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := aptman.cursors.SetEventCursor(handle, &cursor); err != nil {
+		return nil, fmt.Errorf("保存事件游标失败: %v", err)
+	}
+	return events, nil
+}
+
+// versionString 事件的交易版本号, 用作事件的交易标识
+func (ev *moduleEvent) versionString() string {
+	return strconv.FormatUint(ev.Version, 10)
+}
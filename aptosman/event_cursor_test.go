@@ -0,0 +1,78 @@
+package aptosman
+
+import (
+	"testing"
+
+	"github.com/TEENet-io/bridge-go/agreement"
+	"github.com/stretchr/testify/assert"
+)
+
+// fake handle: event i is emitted at version versions[i]
+func fakePager(versions []uint64, calls *int) eventPager {
+	return func(start, limit uint64) ([]*moduleEvent, error) {
+		*calls++
+		var page []*moduleEvent
+		for seq := start; seq < uint64(len(versions)) && seq < start+limit; seq++ {
+			page = append(page, &moduleEvent{SequenceNumber: seq, Version: versions[seq]})
+		}
+		return page, nil
+	}
+}
+
+func seqs(events []*moduleEvent) []uint64 {
+	out := []uint64{}
+	for _, ev := range events {
+		out = append(out, ev.SequenceNumber)
+	}
+	return out
+}
+
+func TestPageEvents(t *testing.T) {
+	// 250 events, event i at version 10*(i+1)
+	versions := make([]uint64, 250)
+	for i := range versions {
+		versions[i] = uint64(10 * (i + 1))
+	}
+	calls := 0
+	fetch := fakePager(versions, &calls)
+
+	// more than a page, stops at the first event beyond the window
+	events, cursor, err := pageEvents(fetch, agreement.EventCursor{}, 1, 1500)
+	assert.NoError(t, err)
+	assert.Len(t, events, 150)
+	assert.Equal(t, uint64(0), events[0].SequenceNumber)
+	assert.Equal(t, uint64(149), events[149].SequenceNumber)
+	assert.Equal(t, agreement.EventCursor{Sequence: 150, Version: 1500}, cursor)
+	assert.Equal(t, 2, calls)
+
+	// next window continues from the cursor
+	calls = 0
+	events, cursor, err = pageEvents(fetch, cursor, 1501, 1525)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{150, 151}, seqs(events))
+	assert.Equal(t, agreement.EventCursor{Sequence: 152, Version: 1525}, cursor)
+	assert.Equal(t, 1, calls)
+
+	// window without events keeps the sequence
+	events, cursor, err = pageEvents(fetch, cursor, 1526, 1529)
+	assert.NoError(t, err)
+	assert.Empty(t, events)
+	assert.Equal(t, agreement.EventCursor{Sequence: 152, Version: 1529}, cursor)
+
+	// caught up
+	events, cursor, err = pageEvents(fetch, cursor, 1530, 10000)
+	assert.NoError(t, err)
+	assert.Len(t, events, 98)
+	assert.Equal(t, agreement.EventCursor{Sequence: 250, Version: 10000}, cursor)
+
+	// cursor behind the window (eg. state saved, cursor not): older events dropped
+	events, _, err = pageEvents(fetch, agreement.EventCursor{Sequence: 100, Version: 1000}, 1991, 2020)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{199, 200, 201}, seqs(events))
+
+	// rescan of versions the cursor covers: read again from the first event
+	events, cursor, err = pageEvents(fetch, agreement.EventCursor{Sequence: 250, Version: 10000}, 15, 30)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{1, 2}, seqs(events))
+	assert.Equal(t, agreement.EventCursor{Sequence: 3, Version: 30}, cursor)
+}
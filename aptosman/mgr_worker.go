@@ -1,12 +1,13 @@
 package aptosman
 
 import (
+	"fmt"
 	"math/big"
 	"strings"
 	"time"
 
 	"github.com/TEENet-io/bridge-go/agreement"
-	"github.com/TEENet-io/bridge-go/common"
+	logger "github.com/sirupsen/logrus"
 )
 
 // AptosMgrWorker 实现 chaintxmgr.MgrWorker 接口
@@ -32,20 +33,27 @@ func (w *AptosMgrWorker) GetLatestLedgerNumber() (*big.Int, error) {
 
 // IsMinted 检查是否已经铸造
 func (w *AptosMgrWorker) IsMinted(btcTxId [32]byte) (bool, error) {
-	return w.aptosman.IsMinted(common.Bytes32ToHexStr(btcTxId))
+	return w.aptosman.IsMinted(moveHexStr(btcTxId))
 }
 
-// DoMint 执行铸币操作
+// DoMint 提交铸币交易, 不等待确认
+// The tx is recorded as monitored right away, an abort on chain is decoded by GetTxFailure.
 func (w *AptosMgrWorker) DoMint(mint *agreement.MintParameter) ([]byte, *big.Int, error) {
 	// 转换参数
+	receiver, err := addressFromBytes(mint.Receiver)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid aptos receiver %x: %v", mint.Receiver, err)
+	}
 
 	params := &MintParams{
-		BtcTxId:  mint.BtcTxId[:], // 将common.Hash转换为[]byte
+		BtcTxId:  []byte(moveHexStr(mint.BtcTxId)),
 		Amount:   mint.Amount.Uint64(),
-		Receiver: string(mint.Receiver),
+		Receiver: receiver.String(),
+		Rx:       mint.Rx,
+		S:        mint.S,
 	}
-	// 执行铸币
-	txHash, err := w.aptosman.Mint(params)
+	// 提交铸币交易
+	txHash, err := w.aptosman.SubmitMint(params)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -61,31 +69,36 @@ func (w *AptosMgrWorker) DoMint(mint *agreement.MintParameter) ([]byte, *big.Int
 
 // IsPrepared 检查是否已经准备赎回
 func (w *AptosMgrWorker) IsPrepared(requestTxId [32]byte) (bool, error) {
-	return w.aptosman.IsPrepared(common.Bytes32ToHexStr(requestTxId))
+	return w.aptosman.IsPrepared(moveHexStr(requestTxId))
 }
 
-// DoPrepare 执行赎回准备
+// DoPrepare 提交赎回准备交易, 不等待确认 (同 DoMint)
 func (w *AptosMgrWorker) DoPrepare(prepare *agreement.PrepareParameter) ([]byte, *big.Int, error) {
 	// 转换 OutpointTxIds
 	outpointTxIds := make([]string, len(prepare.OutpointTxIds))
 	for i, txId := range prepare.OutpointTxIds {
-		outpointTxIds[i] = common.Bytes32ToHexStr(txId)
+		outpointTxIds[i] = moveHexStr(txId)
+	}
+
+	requester, err := addressFromBytes(prepare.Requester)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid aptos requester %x: %v", prepare.Requester, err)
 	}
 
 	// 转换参数
 	params := &PrepareParams{
-		RequestTxHash: common.Bytes32ToHexStr(prepare.RequestTxHash),
-		Requester:     string(prepare.Requester),
+		RequestTxHash: moveHexStr(prepare.RequestTxHash),
+		Requester:     requester.String(),
 		Receiver:      prepare.Receiver,
 		Amount:        prepare.Amount.Uint64(),
 		OutpointTxIds: outpointTxIds,
 		OutpointIdxs:  prepare.OutpointIdxs,
-		// Rx:            prepare.Rx,
-		// S:             prepare.S,
+		Rx:            prepare.Rx,
+		S:             prepare.S,
 	}
 
-	// 执行准备
-	txHash, err := w.aptosman.RedeemPrepare(w.aptosman.account, params)
+	// 提交赎回准备交易
+	txHash, err := w.aptosman.SubmitRedeemPrepare(params)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -102,6 +115,11 @@ func (w *AptosMgrWorker) DoPrepare(prepare *agreement.PrepareParameter) ([]byte,
 
 // GetTxStatus 获取交易状态
 func (w *AptosMgrWorker) GetTxStatus(txId []byte) (agreement.MonitoredTxStatus, *big.Int, error) {
+	// A dropped tx of the bridge account blocks the later ones, fill the gaps first.
+	if _, err := w.aptosman.RecoverSequenceGaps(); err != nil {
+		logger.WithError(err).Warn("failed to recover sequence gaps")
+	}
+
 	txHash := string(txId)
 
 	// 设置自定义的轮询参数
@@ -134,3 +152,19 @@ func (w *AptosMgrWorker) GetTxStatus(txId []byte) (agreement.MonitoredTxStatus,
 
 	return agreement.Reverted, version, nil
 }
+
+// GetTxFailure 解析失败交易的原因 (Move abort), 交易成功时返回 nil
+func (w *AptosMgrWorker) GetTxFailure(txId []byte) (*agreement.TxFailure, error) {
+	txnInfo, err := w.aptosman.aptosClient.TransactionByHash(string(txId))
+	if err != nil {
+		return nil, fmt.Errorf("获取交易信息失败: %v", err)
+	}
+	userTxn, err := txnInfo.UserTransaction()
+	if err != nil {
+		return nil, fmt.Errorf("解析用户交易信息失败: %v", err)
+	}
+	if userTxn.Success {
+		return nil, nil
+	}
+	return DecodeVmStatus(userTxn.VmStatus), nil
+}
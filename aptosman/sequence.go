@@ -0,0 +1,286 @@
+package aptosman
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aptos-labs/aptos-go-sdk"
+	logger "github.com/sirupsen/logrus"
+)
+
+const (
+	GAS_MARGIN_PERCENT = 150  // max gas amount = simulated gas used * 150%
+	MIN_MAX_GAS_AMOUNT = 2000 // lower bound of the max gas amount
+)
+
+// pendingSequence 已提交但尚未确认上链的交易
+type pendingSequence struct {
+	txHash    string
+	expiresAt uint64 // unix seconds, the tx is dropped from mempool after it
+}
+
+// sequenceManager 本地维护桥账户的 sequence number
+// Several txs of the bridge account can be in flight (submitted, not committed) at the same time,
+// each one reserves the next local sequence number instead of the committed one on chain.
+//
+// A reserved number that is never committed (submission failed, or the tx expired in mempool)
+// is a gap, it blocks every later tx of the account.
+// Failed numbers are reused by the next reservation, RecoverSequenceGaps() fills the rest with no-op txs.
+type sequenceManager struct {
+	mu      sync.Mutex
+	next    uint64
+	loaded  bool                       // false = reload from chain before the next reservation
+	pending map[uint64]pendingSequence // submitted, not known to be committed
+	gaps    []uint64                   // reserved but not submitted, sorted, reused first
+}
+
+// committedSequenceNumber 链上账户的 sequence number (下一笔将被执行的交易)
+func (aptman *Aptosman) committedSequenceNumber() (uint64, error) {
+	info, err := aptman.aptosClient.Account(aptman.account.AccountAddress())
+	if err != nil {
+		return 0, fmt.Errorf("获取账户信息失败: %v", err)
+	}
+	seq, err := info.SequenceNumber()
+	if err != nil {
+		return 0, fmt.Errorf("获取sequence number失败: %v", err)
+	}
+	return seq, nil
+}
+
+// syncSequenceLocked 按链上已确认的 sequence number 清理本地记录, 调用者持有锁
+func (m *sequenceManager) syncSequenceLocked(committed uint64) {
+	if m.pending == nil {
+		m.pending = make(map[uint64]pendingSequence)
+	}
+	for seq := range m.pending {
+		if seq < committed {
+			delete(m.pending, seq)
+		}
+	}
+	gaps := m.gaps[:0]
+	for _, seq := range m.gaps {
+		if seq >= committed {
+			gaps = append(gaps, seq)
+		}
+	}
+	m.gaps = gaps
+	if !m.loaded || m.next < committed {
+		m.next = committed
+	}
+	m.loaded = true
+}
+
+// reserveSequenceNumber 预留一个 sequence number, 优先复用空缺
+func (aptman *Aptosman) reserveSequenceNumber() (uint64, error) {
+	m := &aptman.seq
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.loaded {
+		committed, err := aptman.committedSequenceNumber()
+		if err != nil {
+			return 0, err
+		}
+		m.syncSequenceLocked(committed)
+	}
+
+	if len(m.gaps) > 0 {
+		seq := m.gaps[0]
+		m.gaps = m.gaps[1:]
+		return seq, nil
+	}
+
+	seq := m.next
+	m.next++
+	return seq, nil
+}
+
+// markSubmitted 记录已提交的交易
+func (m *sequenceManager) markSubmitted(seq uint64, txHash string, expiresAt uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.pending == nil {
+		m.pending = make(map[uint64]pendingSequence)
+	}
+	m.pending[seq] = pendingSequence{txHash: txHash, expiresAt: expiresAt}
+}
+
+// releaseSequenceNumber 提交失败, 归还 sequence number 以便复用
+func (m *sequenceManager) releaseSequenceNumber(seq uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.pending, seq)
+	idx := sort.Search(len(m.gaps), func(i int) bool { return m.gaps[i] >= seq })
+	if idx < len(m.gaps) && m.gaps[idx] == seq {
+		return
+	}
+	m.gaps = append(m.gaps, 0)
+	copy(m.gaps[idx+1:], m.gaps[idx:])
+	m.gaps[idx] = seq
+}
+
+// PendingSequenceNumbers 已提交但尚未确认的交易数
+func (aptman *Aptosman) PendingSequenceNumbers() int {
+	aptman.seq.mu.Lock()
+	defer aptman.seq.mu.Unlock()
+
+	return len(aptman.seq.pending)
+}
+
+// submitAsBridge 以桥账户构建、签名并提交交易, 不等待确认
+// Return the tx hash.
+func (aptman *Aptosman) submitAsBridge(payload aptos.TransactionPayload) (string, error) {
+	seq, err := aptman.reserveSequenceNumber()
+	if err != nil {
+		return "", err
+	}
+	return aptman.submitWithSequence(seq, payload, true)
+}
+
+// submitWithSequence 以指定的 sequence number 提交交易
+// With simulate, the tx is simulated first: an abort is returned as *agreement.TxFailure
+// without submitting, otherwise the gas used sets the max gas amount of the real tx.
+func (aptman *Aptosman) submitWithSequence(seq uint64, payload aptos.TransactionPayload, simulate bool) (string, error) {
+	options := []any{aptos.SequenceNumber(seq)}
+	if simulate {
+		// Simulated against the committed state, the reserved number may be ahead of the chain.
+		maxGas, gasUnitPrice, err := aptman.simulate(payload)
+		if err != nil {
+			aptman.seq.releaseSequenceNumber(seq)
+			return "", err
+		}
+		options = append(options, aptos.MaxGasAmount(maxGas), aptos.GasUnitPrice(gasUnitPrice))
+	}
+
+	txn, err := aptman.aptosClient.BuildTransaction(aptman.account.AccountAddress(), payload, options...)
+	if err != nil {
+		aptman.seq.releaseSequenceNumber(seq)
+		return "", fmt.Errorf("构建交易失败: %v", err)
+	}
+
+	signedTxn, err := txn.SignedTransaction(aptman.account)
+	if err != nil {
+		aptman.seq.releaseSequenceNumber(seq)
+		return "", fmt.Errorf("签名交易失败: %v", err)
+	}
+
+	submitResult, err := aptman.aptosClient.SubmitTransaction(signedTxn)
+	if err != nil {
+		aptman.seq.releaseSequenceNumber(seq)
+		// The number may be taken on chain already (eg. by a tx sent elsewhere),
+		// sync with the chain so it is not reused.
+		if committed, cerr := aptman.committedSequenceNumber(); cerr == nil {
+			aptman.seq.mu.Lock()
+			aptman.seq.syncSequenceLocked(committed)
+			aptman.seq.mu.Unlock()
+		}
+		logger.WithField("sequence_number", seq).Warn("submission failed, sequence number released")
+		return "", fmt.Errorf("提交交易失败: %v", err)
+	}
+
+	aptman.seq.markSubmitted(seq, submitResult.Hash, txn.ExpirationTimestampSeconds)
+	return submitResult.Hash, nil
+}
+
+// RecoverSequenceGaps 检测过期交易并用空交易填补空缺
+// A pending tx past its expiration that is not committed was dropped,
+// it (and every released number) is filled with a no-op tx so later txs of the account can execute.
+// Return the number of no-op txs sent.
+func (aptman *Aptosman) RecoverSequenceGaps() (int, error) {
+	m := &aptman.seq
+	now := uint64(time.Now().Unix())
+
+	// cheap local check first
+	m.mu.Lock()
+	suspicious := len(m.gaps) > 0
+	for _, p := range m.pending {
+		if p.expiresAt < now {
+			suspicious = true
+			break
+		}
+	}
+	m.mu.Unlock()
+	if !suspicious {
+		return 0, nil
+	}
+
+	committed, err := aptman.committedSequenceNumber()
+	if err != nil {
+		return 0, err
+	}
+
+	// claim the holes under lock, so reservations don't reuse them meanwhile
+	m.mu.Lock()
+	m.syncSequenceLocked(committed)
+	holes := append([]uint64{}, m.gaps...)
+	m.gaps = nil
+	for seq, p := range m.pending {
+		if p.expiresAt < now {
+			holes = append(holes, seq)
+			delete(m.pending, seq)
+		}
+	}
+	m.mu.Unlock()
+	sort.Slice(holes, func(i, j int) bool { return holes[i] < holes[j] })
+
+	filled := 0
+	for _, seq := range holes {
+		txHash, err := aptman.submitNoop(seq)
+		if err != nil {
+			logger.WithError(err).WithField("sequence_number", seq).Error("failed to fill sequence gap")
+			continue
+		}
+		filled++
+		logger.WithFields(logger.Fields{
+			"sequence_number": seq,
+			"tx_hash":         txHash,
+		}).Info("sequence gap filled with no-op tx")
+	}
+	return filled, nil
+}
+
+// submitNoop 空交易: 向自己转账 0 APT
+func (aptman *Aptosman) submitNoop(seq uint64) (string, error) {
+	entryFunction, err := aptos.CoinTransferPayload(nil, aptman.account.AccountAddress(), 0)
+	if err != nil {
+		return "", err
+	}
+	return aptman.submitWithSequence(seq, aptos.TransactionPayload{Payload: entryFunction}, false)
+}
+
+// simulate 模拟执行交易, 返回 (max gas amount, gas unit price)
+// An aborted simulation is decoded into *agreement.TxFailure (see abort.go).
+func (aptman *Aptosman) simulate(payload aptos.TransactionPayload) (uint64, uint64, error) {
+	txn, err := aptman.aptosClient.BuildTransaction(aptman.account.AccountAddress(), payload)
+	if err != nil {
+		return 0, 0, fmt.Errorf("构建模拟交易失败: %v", err)
+	}
+
+	results, err := aptman.aptosClient.SimulateTransaction(txn, aptman.account, aptos.EstimateGasUnitPrice(true))
+	if err != nil {
+		return 0, 0, fmt.Errorf("模拟交易失败: %v", err)
+	}
+	if len(results) == 0 {
+		return 0, 0, fmt.Errorf("模拟交易无结果")
+	}
+
+	result := results[0]
+	if !result.Success {
+		failure := DecodeVmStatus(result.VmStatus)
+		logger.WithFields(logger.Fields{
+			"vm_status": result.VmStatus,
+			"action":    failure.Action,
+		}).Warn("transaction simulation failed")
+		return 0, 0, failure
+	}
+
+	maxGas := result.GasUsed * GAS_MARGIN_PERCENT / 100
+	if maxGas < MIN_MAX_GAS_AMOUNT {
+		maxGas = MIN_MAX_GAS_AMOUNT
+	}
+	return maxGas, result.GasUnitPrice, nil
+}
@@ -0,0 +1,45 @@
+package aptosman
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSequenceManager(t *testing.T) {
+	aptman := &Aptosman{seq: sequenceManager{loaded: true, next: 10}}
+
+	// reserve in order
+	for want := uint64(10); want < 13; want++ {
+		seq, err := aptman.reserveSequenceNumber()
+		assert.NoError(t, err)
+		assert.Equal(t, want, seq)
+	}
+	aptman.seq.markSubmitted(10, "0xa", 100)
+	aptman.seq.markSubmitted(12, "0xc", 100)
+	assert.Equal(t, 2, aptman.PendingSequenceNumbers())
+
+	// released numbers are reused first, lowest first
+	aptman.seq.releaseSequenceNumber(12)
+	aptman.seq.releaseSequenceNumber(11)
+	aptman.seq.releaseSequenceNumber(11)
+	assert.Equal(t, []uint64{11, 12}, aptman.seq.gaps)
+	assert.Equal(t, 1, aptman.PendingSequenceNumbers())
+
+	seq, err := aptman.reserveSequenceNumber()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(11), seq)
+
+	// chain committed up to 12 (exclusive), older records are dropped
+	aptman.seq.syncSequenceLocked(12)
+	assert.Equal(t, 0, aptman.PendingSequenceNumbers())
+	assert.Equal(t, []uint64{12}, aptman.seq.gaps)
+	assert.Equal(t, uint64(13), aptman.seq.next)
+
+	// chain is ahead (txs sent elsewhere), jump forward
+	aptman.seq.syncSequenceLocked(20)
+	assert.Empty(t, aptman.seq.gaps)
+	seq, err = aptman.reserveSequenceNumber()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(20), seq)
+}
@@ -0,0 +1,62 @@
+package aptosman
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/TEENet-io/bridge-go/agreement"
+	"github.com/TEENet-io/bridge-go/common"
+	"github.com/TEENet-io/bridge-go/multisig_client"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// 与 aptos_contract/contract/tests/test_end_to_end.move 中的消息和签名相同,
+// 两边任一编码改变, 测试失败
+func TestMoveSigningVectors(t *testing.T) {
+	signer, err := multisig_client.NewLocalSchnorrSigner(ethcommon.FromHex("0xb7e151628aed2a6abf7158809cf4f3c762e7160f38b4da56a784d9045190cfef"))
+	assert.NoError(t, err)
+	assert.Equal(t, ethcommon.FromHex("0xdff1d77f2a671c5f36183726db2341be58feae1da2deced843240f7b502ba659"), schnorr.SerializePubKey(signer.Pk))
+
+	// aptos 链 id 4, 模块地址 0x42 (Move.toml 的 dev-addresses)
+	domain := common.NewSigningDomain(common.DEPOSIT_CHAIN_TYPE_APTOS, big.NewInt(4), addressBytes("0x42"))
+
+	check := func(msgHash ethcommon.Hash, expHash, expRx, expS string) {
+		assert.Equal(t, ethcommon.HexToHash(expHash), msgHash)
+		sig, err := signer.Sign(msgHash[:])
+		assert.NoError(t, err)
+		rx, s, err := multisig_client.ConvertSigToRS(sig)
+		assert.NoError(t, err)
+		assert.Equal(t, ethcommon.HexToHash(expRx), ethcommon.BigToHash(rx))
+		assert.Equal(t, ethcommon.HexToHash(expS), ethcommon.BigToHash(s))
+	}
+
+	mp := &agreement.MintParameter{
+		BtcTxId:  ethcommon.HexToHash("0x1111111111111111111111111111111111111111111111111111111111111111"),
+		Receiver: addressBytes("0xa11ce"),
+		Amount:   big.NewInt(100000),
+		Domain:   domain,
+	}
+	check(mp.GenerateMsgHash(),
+		"0x3c8139b61c6d3c10707c90609da687338905a88b3aa698b4adaf6e8bca57aac3",
+		"0x3583d64d238dd108c02ad5fcc217a19015197a8becd792bc8480974f2a5abec2",
+		"0x0915ba7369d004c6b669e33a0ecc4d8d1b0cd0ec7875a886d7e7c2741a54e0eb")
+
+	pp := &agreement.PrepareParameter{
+		RequestTxHash: ethcommon.HexToHash("0x2222222222222222222222222222222222222222222222222222222222222222"),
+		Requester:     addressBytes("0xb0b"),
+		Receiver:      "bcrt1qw508d6qejxtdg4y5r3zarvary0c5xw7kygt080",
+		Amount:        big.NewInt(50000),
+		OutpointTxIds: []ethcommon.Hash{
+			ethcommon.HexToHash("0x3333333333333333333333333333333333333333333333333333333333333333"),
+			ethcommon.HexToHash("0x4444444444444444444444444444444444444444444444444444444444444444"),
+		},
+		OutpointIdxs: []uint16{0, 1},
+		Domain:       domain,
+	}
+	check(pp.GenerateMsgHash(),
+		"0x9780c85df6e8f8f0f825b8473d1a705f1900f9b569b2c7ac9b4ff0b8cff271f6",
+		"0xa17fe47155e2b87c024c0d16b9bdc32fcaede305c8a4ce773f923c4f90d9f2fa",
+		"0x9eaa28a03b9efb4ac7a0c099fcc15579e95fff731d8f47a339f64d2cb543f52a")
+}
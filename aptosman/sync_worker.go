@@ -2,6 +2,7 @@ package aptosman
 
 import (
 	"math/big"
+	"sort"
 
 	"github.com/TEENet-io/bridge-go/agreement"
 	"github.com/TEENet-io/bridge-go/common"
@@ -29,34 +30,35 @@ func (w *AptosSyncWorker) GetNewestLedgerFinalizedNumber() (*big.Int, error) {
 }
 
 // GetTimeOrderedEvents 实现接口方法，获取按时间顺序排列的事件
-func (w *AptosSyncWorker) GetTimeOrderedEvents(oldNum *big.Int, newNum *big.Int) (
-	[]agreement.MintedEvent,
-	[]agreement.RedeemRequestedEvent,
-	[]agreement.RedeemPreparedEvent,
-	error,
-) {
-	// 获取事件
+// Events are ordered by (version, sequence number in the event handle),
+// the bridge module emits at most one bridge event per tx.
+func (w *AptosSyncWorker) GetTimeOrderedEvents(oldNum *big.Int, newNum *big.Int) ([]agreement.ChainEvent, error) {
+	// 获取事件, oldNum is synced already: versions (oldNum, newNum]
 	mintedEvents, requestedEvents, preparedEvents, err := w.aptosman.GetModuleEvents(
-		oldNum.Uint64(),
+		oldNum.Uint64()+1,
 		newNum.Uint64(),
 	)
 	if err != nil {
-		return nil, nil, nil, err
+		return nil, err
 	}
 
+	events := make([]agreement.ChainEvent, 0, len(mintedEvents)+len(requestedEvents)+len(preparedEvents))
+
 	// 转换 MintedEvent
-	agreementMinted := make([]agreement.MintedEvent, 0, len(mintedEvents))
 	for _, ev := range mintedEvents {
-		agreementMinted = append(agreementMinted, agreement.MintedEvent{
-			MintTxHash: common.HexStrToBytes32(ev.MintTxHash),
-			BtcTxId:    common.HexStrToBytes32(ev.BtcTxId),
-			Amount:     new(big.Int).SetUint64(ev.Amount),
-			Receiver:   []byte(ev.Receiver),
+		events = append(events, agreement.ChainEvent{
+			Ledger: ev.Version,
+			Index:  ev.Sequence,
+			Minted: &agreement.MintedEvent{
+				MintTxHash: common.HexStrToBytes32(ev.MintTxHash),
+				BtcTxId:    common.HexStrToBytes32(ev.BtcTxId),
+				Amount:     new(big.Int).SetUint64(ev.Amount),
+				Receiver:   addressBytes(ev.Receiver),
+			},
 		})
 	}
 
 	// 转换 RedeemRequestedEvent
-	agreementRequested := make([]agreement.RedeemRequestedEvent, 0, len(requestedEvents))
 	for _, ev := range requestedEvents {
 		// var isValidReceiver bool
 		// if w.aptosman.cfg != nil && w.aptosman.cfg.BtcChainConfig != nil {
@@ -66,33 +68,40 @@ func (w *AptosSyncWorker) GetTimeOrderedEvents(oldNum *big.Int, newNum *big.Int)
 		// 	isValidReceiver = false
 		// }
 
-		agreementRequested = append(agreementRequested, agreement.RedeemRequestedEvent{
-			RequestTxHash:   common.HexStrToBytes32(ev.RequestTxHash),
-			Requester:       []byte(ev.Requester),
-			Amount:          new(big.Int).SetUint64(ev.Amount),
-			Receiver:        ev.Receiver,
-			IsValidReceiver: true,
+		events = append(events, agreement.ChainEvent{
+			Ledger: ev.Version,
+			Index:  ev.Sequence,
+			Requested: &agreement.RedeemRequestedEvent{
+				RequestTxHash:   common.HexStrToBytes32(ev.RequestTxHash),
+				Requester:       addressBytes(ev.Requester),
+				Amount:          new(big.Int).SetUint64(ev.Amount),
+				Receiver:        ev.Receiver,
+				IsValidReceiver: true,
+			},
 		})
 	}
 
 	// 转换 RedeemPreparedEvent
-	agreementPrepared := make([]agreement.RedeemPreparedEvent, 0, len(preparedEvents))
 	for _, ev := range preparedEvents {
-		outpointTxIds := make([][32]byte, len(ev.OutpointTxIds))
-		for i, txId := range ev.OutpointTxIds {
-			outpointTxIds[i] = common.HexStrToBytes32(txId)
-		}
-
-		agreementPrepared = append(agreementPrepared, agreement.RedeemPreparedEvent{
-			PrepareTxHash: common.HexStrToBytes32(ev.PrepareTxHash),
-			RequestTxHash: common.HexStrToBytes32(ev.RequestTxHash),
-			Requester:     []byte(ev.Requester),
-			Receiver:      ev.Receiver,
-			Amount:        new(big.Int).SetUint64(ev.Amount),
-			OutpointTxIds: common.ArrayHexStrToHashes(ev.OutpointTxIds),
-			OutpointIdxs:  ev.OutpointIdxs,
+		events = append(events, agreement.ChainEvent{
+			Ledger: ev.Version,
+			Index:  ev.Sequence,
+			Prepared: &agreement.RedeemPreparedEvent{
+				PrepareTxHash: common.HexStrToBytes32(ev.PrepareTxHash),
+				RequestTxHash: common.HexStrToBytes32(ev.RequestTxHash),
+				Requester:     addressBytes(ev.Requester),
+				Receiver:      ev.Receiver,
+				Amount:        new(big.Int).SetUint64(ev.Amount),
+				OutpointTxIds: common.ArrayHexStrToHashes(ev.OutpointTxIds),
+				OutpointIdxs:  ev.OutpointIdxs,
+			},
 		})
 	}
 
-	return agreementMinted, agreementRequested, agreementPrepared, nil
+	// 按 (version, 序号) 排序
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Before(&events[j])
+	})
+
+	return events, nil
 }
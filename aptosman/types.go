@@ -1,12 +1,14 @@
 package aptosman
 
+import "math/big"
+
 // 铸币参数
 type MintParams struct {
-	BtcTxId  []byte // 比特币交易哈希
-	Amount   uint64 // 铸币金额
-	Receiver string // 接收者Aptos地址
-	// Rx        *big.Int
-	// S         *big.Int
+	BtcTxId  []byte   // 比特币交易哈希
+	Amount   uint64   // 铸币金额
+	Receiver string   // 接收者Aptos地址
+	Rx       *big.Int // schnorr signature
+	S        *big.Int // schnorr signature
 }
 
 // 赎回请求参数
@@ -23,8 +25,8 @@ type PrepareParams struct {
 	Amount        uint64   // 金额
 	OutpointTxIds []string // 比特币UTXO交易ID列表
 	OutpointIdxs  []uint16 // 对应的输出索引
-	// Rx            *big.Int
-	// S             *big.Int
+	Rx            *big.Int // schnorr signature
+	S             *big.Int // schnorr signature
 }
 
 // 铸币事件
@@ -33,6 +35,8 @@ type MintedEvent struct {
 	BtcTxId    string // 比特币交易ID
 	Receiver   string // 接收者Aptos地址
 	Amount     uint64 // 金额
+	Version    uint64 // 交易版本号
+	Sequence   uint64 // 事件句柄中的序号
 }
 
 // type RedeemRequestedEvent struct {
@@ -49,6 +53,8 @@ type RedeemRequestedEvent struct {
 	Receiver        string // 接收者比特币地址
 	Amount          uint64 // 金额
 	IsValidReceiver bool   // 是否有效接收者
+	Version         uint64 // 交易版本号
+	Sequence        uint64 // 事件句柄中的序号
 }
 
 // 赎回准备事件
@@ -60,4 +66,6 @@ type RedeemPreparedEvent struct {
 	Amount        uint64
 	OutpointTxIds []string
 	OutpointIdxs  []uint16
+	Version       uint64 // 交易版本号
+	Sequence      uint64 // 事件句柄中的序号
 }
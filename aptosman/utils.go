@@ -5,8 +5,10 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
+	"math/big"
 	"strings"
 	"github.com/aptos-labs/aptos-go-sdk"
+	"github.com/aptos-labs/aptos-go-sdk/bcs"
 	"github.com/aptos-labs/aptos-go-sdk/crypto"
 )
 
@@ -115,4 +117,46 @@ func createAccountFromPrivateKey(privateKeyHex string) (*aptos.Account, error) {
 	}
 
 	return account, nil
-}
\ No newline at end of file
+}
+// Hex string of a 32-byte id (btc tx id, request tx hash) as stored by the bridge module, with 0x prefix.
+func moveHexStr(id [32]byte) string {
+	return "0x" + hex.EncodeToString(id[:])
+}
+
+// Aptos address from bytes, either the raw 32 bytes or a hex string (as in events).
+func addressFromBytes(b []byte) (aptos.AccountAddress, error) {
+	addr := aptos.AccountAddress{}
+	if len(b) == len(addr) {
+		copy(addr[:], b)
+		return addr, nil
+	}
+	err := addr.ParseStringRelaxed(string(b))
+	return addr, err
+}
+
+// Raw 32 bytes of an aptos address hex string,
+// the form used in state and signed messages.
+// Falls back to the string bytes if it is not an address.
+func addressBytes(s string) []byte {
+	addr := aptos.AccountAddress{}
+	if err := addr.ParseStringRelaxed(s); err != nil {
+		return []byte(s)
+	}
+	return addr[:]
+}
+
+// BCS of the schnorr signature (rx, s) as two u256 args.
+func serializeSignature(rx, s *big.Int) ([]byte, []byte, error) {
+	if rx == nil || s == nil {
+		return nil, nil, fmt.Errorf("missing schnorr signature")
+	}
+	rxBytes, err := bcs.SerializeU256(*rx)
+	if err != nil {
+		return nil, nil, err
+	}
+	sBytes, err := bcs.SerializeU256(*s)
+	if err != nil {
+		return nil, nil, err
+	}
+	return rxBytes, sBytes, nil
+}
@@ -138,84 +138,82 @@ func (s *Synchronizer) Loop(ctx context.Context) error {
 				continue
 			}
 			s.st.GetNewBlockChainFinalizedLedgerNumberChannel() <- big.NewInt(int64(newFinalized))
+			// events of versions [last+1, newFinalized] in one go, the event cursors page through them
 			inspecting_version := new(big.Int).Add(s.lastFinalized, big.NewInt(1))
-			for inspecting_version.Cmp(big.NewInt(int64(newFinalized))) != 1 {
-				logger.WithField("inspecting_version", inspecting_version).Info("inspecting_version")
-				minted, requested, prepared, err := s.aptosman.GetModuleEvents(inspecting_version.Uint64(), newFinalized)
-				logger.WithField("prepared", prepared).Info("prepared")
-				if len(minted) > 0 || len(requested) > 0 || len(prepared) > 0 {
-					logger.WithFields(logger.Fields{
-						"version#":  inspecting_version,
-						"minted":    len(minted),
-						"requested": len(requested),
-						"prepared":  len(prepared),
-					}).Info("Inspect events from version (aptos)")
-				}
-				if err != nil {
-					return err
+			logger.WithField("inspecting_version", inspecting_version).Info("inspecting_version")
+			minted, requested, prepared, err := s.aptosman.GetModuleEvents(inspecting_version.Uint64(), newFinalized)
+			logger.WithField("prepared", prepared).Info("prepared")
+			if len(minted) > 0 || len(requested) > 0 || len(prepared) > 0 {
+				logger.WithFields(logger.Fields{
+					"version#":  inspecting_version,
+					"minted":    len(minted),
+					"requested": len(requested),
+					"prepared":  len(prepared),
+				}).Info("Inspect events from version (aptos)")
+			}
+			if err != nil {
+				return err
+			}
+			for _, ev := range minted {
+				logger.WithFields(logger.Fields{
+					"version#":        ev.Version,
+					"mintTx":          ev.MintTxHash,
+					"amount":          ev.Amount,
+					"receiver(aptos)": ev.Receiver,
+				}).Info("Minted Event Found")
+				amount := new(big.Int).SetUint64(ev.Amount)
+				s.st.GetNewMintedEventChannel() <- &agreement.MintedEvent{
+					MintTxHash: common.HexStrToBytes32(ev.MintTxHash),
+					BtcTxId:    common.HexStrToBytes32(ev.BtcTxId),
+					Amount:     amount,
+					Receiver:   []byte(ev.Receiver),
 				}
-				for _, ev := range minted {
-					logger.WithFields(logger.Fields{
-						"version#":        inspecting_version,
-						"mintTx":          ev.MintTxHash,
-						"amount":          ev.Amount,
-						"receiver(aptos)": ev.Receiver,
-					}).Info("Minted Event Found")
-					amount := new(big.Int).SetUint64(ev.Amount)
-					s.st.GetNewMintedEventChannel() <- &agreement.MintedEvent{
-						MintTxHash: common.HexStrToBytes32(ev.MintTxHash),
-						BtcTxId:    common.HexStrToBytes32(ev.BtcTxId),
-						Amount:     amount,
-						Receiver:   []byte(ev.Receiver),
-					}
+			}
+			for _, ev := range requested {
+				logger.WithFields(logger.Fields{
+					"version#":      ev.Version,
+					"reqTx":         ev.RequestTxHash,
+					"amount":        ev.Amount,
+					"receiver(btc)": ev.Receiver,
+					"sender(aptos)": ev.Requester,
+				}).Info("RedeemRequested Event Found")
+
+				amount := new(big.Int).SetUint64(ev.Amount)
+
+				var isValidReceiver bool
+				if s.cfg.BtcChainConfig != nil {
+					isValidReceiver = common.IsValidBtcAddress(ev.Receiver, s.cfg.BtcChainConfig)
+				} else {
+					logger.Warn("BtcChainConfig is nil, skipping address validation")
+					isValidReceiver = false
 				}
-				for _, ev := range requested {
-					logger.WithFields(logger.Fields{
-						"version#":      inspecting_version,
-						"reqTx":         ev.RequestTxHash,
-						"amount":        ev.Amount,
-						"receiver(btc)": ev.Receiver,
-						"sender(aptos)": ev.Requester,
-					}).Info("RedeemRequested Event Found")
-
-					amount := new(big.Int).SetUint64(ev.Amount)
-
-					var isValidReceiver bool
-					if s.cfg.BtcChainConfig != nil {
-						isValidReceiver = common.IsValidBtcAddress(ev.Receiver, s.cfg.BtcChainConfig)
-					} else {
-						logger.Warn("BtcChainConfig is nil, skipping address validation")
-						isValidReceiver = false
-					}
-
-					x := &agreement.RedeemRequestedEvent{
-						RequestTxHash:   common.HexStrToBytes32(ev.RequestTxHash),
-						Requester:       []byte(ev.Requester),
-						Amount:          amount,
-						Receiver:        ev.Receiver,
-						IsValidReceiver: isValidReceiver,
-					}
-					s.st.GetNewRedeemRequestedEventChannel() <- x
+
+				x := &agreement.RedeemRequestedEvent{
+					RequestTxHash:   common.HexStrToBytes32(ev.RequestTxHash),
+					Requester:       []byte(ev.Requester),
+					Amount:          amount,
+					Receiver:        ev.Receiver,
+					IsValidReceiver: isValidReceiver,
 				}
-				for _, ev := range prepared {
-					logger.WithFields(logger.Fields{
-						"version#":         inspecting_version,
-						"prepTx":           ev.PrepareTxHash,
-						"reqTx":            ev.RequestTxHash,
-						"requester(aptos)": ev.Requester,
-					}).Info("RedeemPrepared Event Found")
-					amount := new(big.Int).SetUint64(ev.Amount)
-					s.st.GetNewRedeemPreparedEventChannel() <- &agreement.RedeemPreparedEvent{
-						PrepareTxHash: common.HexStrToBytes32(ev.PrepareTxHash),
-						RequestTxHash: common.HexStrToBytes32(ev.RequestTxHash),
-						Requester:     []byte(ev.Requester),
-						Receiver:      ev.Receiver,
-						Amount:        amount,
-						OutpointTxIds: common.ArrayHexStrToHashes(ev.OutpointTxIds),
-						OutpointIdxs:  ev.OutpointIdxs,
-					}
+				s.st.GetNewRedeemRequestedEventChannel() <- x
+			}
+			for _, ev := range prepared {
+				logger.WithFields(logger.Fields{
+					"version#":         ev.Version,
+					"prepTx":           ev.PrepareTxHash,
+					"reqTx":            ev.RequestTxHash,
+					"requester(aptos)": ev.Requester,
+				}).Info("RedeemPrepared Event Found")
+				amount := new(big.Int).SetUint64(ev.Amount)
+				s.st.GetNewRedeemPreparedEventChannel() <- &agreement.RedeemPreparedEvent{
+					PrepareTxHash: common.HexStrToBytes32(ev.PrepareTxHash),
+					RequestTxHash: common.HexStrToBytes32(ev.RequestTxHash),
+					Requester:     []byte(ev.Requester),
+					Receiver:      ev.Receiver,
+					Amount:        amount,
+					OutpointTxIds: common.ArrayHexStrToHashes(ev.OutpointTxIds),
+					OutpointIdxs:  ev.OutpointIdxs,
 				}
-				inspecting_version.Add(inspecting_version, big.NewInt(1))
 			}
 
 			s.lastFinalized = big.NewInt(int64(newFinalized))
@@ -0,0 +1,168 @@
+/*
+SQLiteConsolidationStorage implements ConsolidationStorage using SQLite.
+
+Table is btc_action_consolidation
+*/
+package btcaction
+
+import (
+	"database/sql"
+	"fmt"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const consolidationColumns = "BtcHash, LinkedID, InputCount, InputAmount, OutputCount, FeeRate, BroadcastBlk, Confirmed, ReplacedBy, Abandoned"
+
+type SQLiteConsolidationStorage struct {
+	db *sql.DB
+}
+
+func NewSQLiteConsolidationStorage(dbPath string) (*SQLiteConsolidationStorage, error) {
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		return nil, err
+	}
+	storage := &SQLiteConsolidationStorage{db: db}
+	if err := storage.init(); err != nil {
+		return nil, err
+	}
+
+	return storage, nil
+}
+
+// Close closes the database connection
+func (s *SQLiteConsolidationStorage) Close() error {
+	return s.db.Close()
+}
+
+func (s *SQLiteConsolidationStorage) init() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS btc_action_consolidation (
+		BtcHash TEXT PRIMARY KEY,
+		LinkedID TEXT NOT NULL,
+		InputCount INTEGER DEFAULT 0,
+		InputAmount INTEGER DEFAULT 0,
+		OutputCount INTEGER DEFAULT 0,
+		FeeRate INTEGER DEFAULT 0,
+		BroadcastBlk INTEGER DEFAULT 0,
+		Confirmed BOOLEAN DEFAULT FALSE,
+		ReplacedBy TEXT DEFAULT '',
+		Abandoned BOOLEAN DEFAULT FALSE
+	);
+	`
+	if _, err := s.db.Exec(query); err != nil {
+		return err
+	}
+
+	// Tables created by older versions miss the replacement columns.
+	if err := s.addColumnIfMissing("btc_action_consolidation", "ReplacedBy", "TEXT DEFAULT ''"); err != nil {
+		return err
+	}
+	return s.addColumnIfMissing("btc_action_consolidation", "Abandoned", "BOOLEAN DEFAULT FALSE")
+}
+
+func (s *SQLiteConsolidationStorage) addColumnIfMissing(table string, column string, definition string) error {
+	rows, err := s.db.Query(fmt.Sprintf(`PRAGMA table_info(%s)`, table))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, ctype string
+		var dflt sql.NullString
+		if err := rows.Scan(&cid, &name, &ctype, &notNull, &dflt, &pk); err != nil {
+			return err
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	_, err = s.db.Exec(fmt.Sprintf(`ALTER TABLE %s ADD COLUMN %s %s`, table, column, definition))
+	return err
+}
+
+type sqlExecer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+func insertConsolidation(db sqlExecer, c *ConsolidationAction) error {
+	query := `
+	INSERT INTO btc_action_consolidation (` + consolidationColumns + `)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err := db.Exec(query, c.BtcHash, c.LinkedID, c.InputCount, c.InputAmount, c.OutputCount, c.FeeRate, c.BroadcastBlk, c.Confirmed, c.ReplacedBy, c.Abandoned)
+	return err
+}
+
+func (s *SQLiteConsolidationStorage) InsertConsolidation(c *ConsolidationAction) error {
+	return insertConsolidation(s.db, c)
+}
+
+func (s *SQLiteConsolidationStorage) QueryConsolidation(btcHash string) (*ConsolidationAction, error) {
+	query := `SELECT ` + consolidationColumns + ` FROM btc_action_consolidation WHERE BtcHash = ?`
+	c := &ConsolidationAction{}
+	err := s.db.QueryRow(query, btcHash).Scan(&c.BtcHash, &c.LinkedID, &c.InputCount, &c.InputAmount, &c.OutputCount, &c.FeeRate, &c.BroadcastBlk, &c.Confirmed, &c.ReplacedBy, &c.Abandoned)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (s *SQLiteConsolidationStorage) QueryUnconfirmedConsolidations() ([]ConsolidationAction, error) {
+	query := `
+	SELECT ` + consolidationColumns + `
+	FROM btc_action_consolidation WHERE Confirmed = FALSE AND ReplacedBy = '' AND Abandoned = FALSE ORDER BY BroadcastBlk`
+	rows, err := s.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cs []ConsolidationAction
+	for rows.Next() {
+		var c ConsolidationAction
+		if err := rows.Scan(&c.BtcHash, &c.LinkedID, &c.InputCount, &c.InputAmount, &c.OutputCount, &c.FeeRate, &c.BroadcastBlk, &c.Confirmed, &c.ReplacedBy, &c.Abandoned); err != nil {
+			return nil, err
+		}
+		cs = append(cs, c)
+	}
+	return cs, rows.Err()
+}
+
+func (s *SQLiteConsolidationStorage) ConfirmConsolidation(btcHash string) error {
+	query := `UPDATE btc_action_consolidation SET Confirmed = TRUE WHERE BtcHash = ?`
+	_, err := s.db.Exec(query, btcHash)
+	return err
+}
+
+func (s *SQLiteConsolidationStorage) ReplaceConsolidation(oldBtcHash string, c *ConsolidationAction) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := insertConsolidation(tx, c); err != nil {
+		return err
+	}
+	query := `UPDATE btc_action_consolidation SET ReplacedBy = ? WHERE BtcHash = ?`
+	if _, err := tx.Exec(query, c.BtcHash, oldBtcHash); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *SQLiteConsolidationStorage) AbandonConsolidation(btcHash string) error {
+	query := `UPDATE btc_action_consolidation SET Abandoned = TRUE WHERE BtcHash = ?`
+	_, err := s.db.Exec(query, btcHash)
+	return err
+}
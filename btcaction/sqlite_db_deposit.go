@@ -7,6 +7,7 @@ package btcaction
 
 import (
 	"database/sql"
+	"fmt"
 
 	_ "github.com/mattn/go-sqlite3"
 )
@@ -44,26 +45,74 @@ func (s *SQLiteDepositStorage) init() error {
 	CREATE INDEX IF NOT EXISTS idx_deposit_receiver ON btc_action_deposit(deposit_receiver);
 	CREATE INDEX IF NOT EXISTS idx_evm_addr ON btc_action_deposit(evm_addr);
 	`
+	if _, err := s.db.Exec(query); err != nil {
+		return err
+	}
+
+	// Tables created by older versions miss the destination columns.
+	for _, column := range []string{"chain_type", "receiver", "memo"} {
+		if err := s.addColumnIfMissing("btc_action_deposit", column, "TEXT DEFAULT ''"); err != nil {
+			return err
+		}
+	}
+
+	// Deposits found by older versions are all legacy (evm).
+	query = `
+	UPDATE btc_action_deposit SET chain_type = 'evm', receiver = evm_addr WHERE receiver = '' OR receiver IS NULL;
+	CREATE INDEX IF NOT EXISTS idx_dest_receiver ON btc_action_deposit(receiver);
+	`
 	_, err := s.db.Exec(query)
 	return err
 }
 
+func (s *SQLiteDepositStorage) addColumnIfMissing(table string, column string, definition string) error {
+	rows, err := s.db.Query(fmt.Sprintf(`PRAGMA table_info(%s)`, table))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, ctype string
+		var dflt sql.NullString
+		if err := rows.Scan(&cid, &name, &ctype, &notNull, &dflt, &pk); err != nil {
+			return err
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	_, err = s.db.Exec(fmt.Sprintf(`ALTER TABLE %s ADD COLUMN %s %s`, table, column, definition))
+	return err
+}
+
 func (s *SQLiteDepositStorage) AddDeposit(deposit DepositAction) error {
 	// Protection of double adding.
 	if hits, err := s.GetDepositByTxHash(deposit.TxHash); err != nil {
 		return err
 	} else if len(hits) > 0 {
 		// logger.WithField("txHash", deposit.TxHash).Debug("BTC Deposit already exists, skip.")
+		// After a reorg the same tx can be mined in another block, follow it.
+		if hits[0].BlockHash != deposit.BlockHash {
+			query := `UPDATE btc_action_deposit SET block_number = ?, block_hash = ? WHERE tx_hash = ?`
+			_, err := s.db.Exec(query, deposit.BlockNumber, deposit.BlockHash, deposit.TxHash)
+			return err
+		}
 		return nil // no double adding.
 	}
-	query := `INSERT INTO btc_action_deposit (block_number, block_hash, tx_hash, deposit_value, deposit_receiver, evm_id, evm_addr) VALUES (?, ?, ?, ?, ?, ?, ?)`
-	_, err := s.db.Exec(query, deposit.BlockNumber, deposit.BlockHash, deposit.TxHash, deposit.DepositValue, deposit.DepositReceiver, deposit.EvmID, deposit.EvmAddr)
+	query := `INSERT INTO btc_action_deposit (block_number, block_hash, tx_hash, deposit_value, deposit_receiver, evm_id, evm_addr, chain_type, receiver, memo) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err := s.db.Exec(query, deposit.BlockNumber, deposit.BlockHash, deposit.TxHash, deposit.DepositValue, deposit.DepositReceiver, deposit.EvmID, deposit.EvmAddr, deposit.ChainType, deposit.Receiver, deposit.Memo)
 	return err
 }
 
 // Get all the deposits from database.
 func (s *SQLiteDepositStorage) GetDeposits() ([]DepositAction, error) {
-	query := `SELECT block_number, block_hash, tx_hash, deposit_value, deposit_receiver, evm_id, evm_addr FROM btc_action_deposit`
+	query := `SELECT block_number, block_hash, tx_hash, deposit_value, deposit_receiver, evm_id, evm_addr, chain_type, receiver, memo FROM btc_action_deposit`
 	rows, err := s.db.Query(query)
 	if err != nil {
 		return nil, err
@@ -73,7 +122,7 @@ func (s *SQLiteDepositStorage) GetDeposits() ([]DepositAction, error) {
 	var deposits []DepositAction
 	for rows.Next() {
 		var deposit DepositAction
-		err := rows.Scan(&deposit.BlockNumber, &deposit.BlockHash, &deposit.TxHash, &deposit.DepositValue, &deposit.DepositReceiver, &deposit.EvmID, &deposit.EvmAddr)
+		err := rows.Scan(&deposit.BlockNumber, &deposit.BlockHash, &deposit.TxHash, &deposit.DepositValue, &deposit.DepositReceiver, &deposit.EvmID, &deposit.EvmAddr, &deposit.ChainType, &deposit.Receiver, &deposit.Memo)
 		if err != nil {
 			return nil, err
 		}
@@ -84,7 +133,7 @@ func (s *SQLiteDepositStorage) GetDeposits() ([]DepositAction, error) {
 
 // Fetch a list of deposit actions by btc transaction hash.
 func (s *SQLiteDepositStorage) GetDepositByTxHash(txHash string) ([]DepositAction, error) {
-	query := `SELECT block_number, block_hash, tx_hash, deposit_value, deposit_receiver, evm_id, evm_addr FROM btc_action_deposit WHERE tx_hash = ?`
+	query := `SELECT block_number, block_hash, tx_hash, deposit_value, deposit_receiver, evm_id, evm_addr, chain_type, receiver, memo FROM btc_action_deposit WHERE tx_hash = ?`
 	rows, err := s.db.Query(query, txHash)
 	if err != nil {
 		return nil, err
@@ -94,7 +143,7 @@ func (s *SQLiteDepositStorage) GetDepositByTxHash(txHash string) ([]DepositActio
 	var deposits []DepositAction
 	for rows.Next() {
 		var deposit DepositAction
-		err := rows.Scan(&deposit.BlockNumber, &deposit.BlockHash, &deposit.TxHash, &deposit.DepositValue, &deposit.DepositReceiver, &deposit.EvmID, &deposit.EvmAddr)
+		err := rows.Scan(&deposit.BlockNumber, &deposit.BlockHash, &deposit.TxHash, &deposit.DepositValue, &deposit.DepositReceiver, &deposit.EvmID, &deposit.EvmAddr, &deposit.ChainType, &deposit.Receiver, &deposit.Memo)
 		if err != nil {
 			return nil, err
 		}
@@ -105,7 +154,7 @@ func (s *SQLiteDepositStorage) GetDepositByTxHash(txHash string) ([]DepositActio
 
 // Fetch a list of deposit actions by bridge address.
 func (s *SQLiteDepositStorage) GetDepositsByReceiver(receiver string) ([]DepositAction, error) {
-	query := `SELECT block_number, block_hash, tx_hash, deposit_value, deposit_receiver, evm_id, evm_addr FROM btc_action_deposit WHERE deposit_receiver = ?`
+	query := `SELECT block_number, block_hash, tx_hash, deposit_value, deposit_receiver, evm_id, evm_addr, chain_type, receiver, memo FROM btc_action_deposit WHERE deposit_receiver = ?`
 	rows, err := s.db.Query(query, receiver)
 	if err != nil {
 		return nil, err
@@ -115,7 +164,7 @@ func (s *SQLiteDepositStorage) GetDepositsByReceiver(receiver string) ([]Deposit
 	var deposits []DepositAction
 	for rows.Next() {
 		var deposit DepositAction
-		err := rows.Scan(&deposit.BlockNumber, &deposit.BlockHash, &deposit.TxHash, &deposit.DepositValue, &deposit.DepositReceiver, &deposit.EvmID, &deposit.EvmAddr)
+		err := rows.Scan(&deposit.BlockNumber, &deposit.BlockHash, &deposit.TxHash, &deposit.DepositValue, &deposit.DepositReceiver, &deposit.EvmID, &deposit.EvmAddr, &deposit.ChainType, &deposit.Receiver, &deposit.Memo)
 		if err != nil {
 			return nil, err
 		}
@@ -126,7 +175,7 @@ func (s *SQLiteDepositStorage) GetDepositsByReceiver(receiver string) ([]Deposit
 
 // Fetch a list of deposit actions by receiver EVM address and EVM ID.
 func (s *SQLiteDepositStorage) GetDepositByEVM(evmAddr string, evmID int32) ([]DepositAction, error) {
-	query := `SELECT block_number, block_hash, tx_hash, deposit_value, deposit_receiver, evm_id, evm_addr FROM btc_action_deposit WHERE evm_addr = ? AND evm_id = ?`
+	query := `SELECT block_number, block_hash, tx_hash, deposit_value, deposit_receiver, evm_id, evm_addr, chain_type, receiver, memo FROM btc_action_deposit WHERE evm_addr = ? AND evm_id = ?`
 	rows, err := s.db.Query(query, evmAddr, evmID)
 	if err != nil {
 		return nil, err
@@ -136,7 +185,7 @@ func (s *SQLiteDepositStorage) GetDepositByEVM(evmAddr string, evmID int32) ([]D
 	var deposits []DepositAction
 	for rows.Next() {
 		var deposit DepositAction
-		err := rows.Scan(&deposit.BlockNumber, &deposit.BlockHash, &deposit.TxHash, &deposit.DepositValue, &deposit.DepositReceiver, &deposit.EvmID, &deposit.EvmAddr)
+		err := rows.Scan(&deposit.BlockNumber, &deposit.BlockHash, &deposit.TxHash, &deposit.DepositValue, &deposit.DepositReceiver, &deposit.EvmID, &deposit.EvmAddr, &deposit.ChainType, &deposit.Receiver, &deposit.Memo)
 		if err != nil {
 			return nil, err
 		}
@@ -146,7 +195,7 @@ func (s *SQLiteDepositStorage) GetDepositByEVM(evmAddr string, evmID int32) ([]D
 }
 
 func (s *SQLiteDepositStorage) GetDepositsByEVMAddr(evmAddr string) ([]DepositAction, error) {
-	query := `SELECT block_number, block_hash, tx_hash, deposit_value, deposit_receiver, evm_id, evm_addr FROM btc_action_deposit WHERE LOWER(evm_addr) = LOWER(?)`
+	query := `SELECT block_number, block_hash, tx_hash, deposit_value, deposit_receiver, evm_id, evm_addr, chain_type, receiver, memo FROM btc_action_deposit WHERE LOWER(evm_addr) = LOWER(?)`
 	rows, err := s.db.Query(query, evmAddr)
 	if err != nil {
 		return nil, err
@@ -156,7 +205,7 @@ func (s *SQLiteDepositStorage) GetDepositsByEVMAddr(evmAddr string) ([]DepositAc
 	var deposits []DepositAction
 	for rows.Next() {
 		var deposit DepositAction
-		err := rows.Scan(&deposit.BlockNumber, &deposit.BlockHash, &deposit.TxHash, &deposit.DepositValue, &deposit.DepositReceiver, &deposit.EvmID, &deposit.EvmAddr)
+		err := rows.Scan(&deposit.BlockNumber, &deposit.BlockHash, &deposit.TxHash, &deposit.DepositValue, &deposit.DepositReceiver, &deposit.EvmID, &deposit.EvmAddr, &deposit.ChainType, &deposit.Receiver, &deposit.Memo)
 		if err != nil {
 			return nil, err
 		}
@@ -164,3 +213,31 @@ func (s *SQLiteDepositStorage) GetDepositsByEVMAddr(evmAddr string) ([]DepositAc
 	}
 	return deposits, nil
 }
+
+// Fetch a list of deposit actions by the destination receiver (evm or aptos).
+func (s *SQLiteDepositStorage) GetDepositsByDestReceiver(receiver string) ([]DepositAction, error) {
+	query := `SELECT block_number, block_hash, tx_hash, deposit_value, deposit_receiver, evm_id, evm_addr, chain_type, receiver, memo FROM btc_action_deposit WHERE LOWER(receiver) = LOWER(?)`
+	rows, err := s.db.Query(query, receiver)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deposits []DepositAction
+	for rows.Next() {
+		var deposit DepositAction
+		err := rows.Scan(&deposit.BlockNumber, &deposit.BlockHash, &deposit.TxHash, &deposit.DepositValue, &deposit.DepositReceiver, &deposit.EvmID, &deposit.EvmAddr, &deposit.ChainType, &deposit.Receiver, &deposit.Memo)
+		if err != nil {
+			return nil, err
+		}
+		deposits = append(deposits, deposit)
+	}
+	return deposits, nil
+}
+
+// Remove the deposit actions found in a (orphaned) block.
+func (s *SQLiteDepositStorage) DeleteDepositsByBlockHash(blockHash string) error {
+	query := `DELETE FROM btc_action_deposit WHERE block_hash = ?`
+	_, err := s.db.Exec(query, blockHash)
+	return err
+}
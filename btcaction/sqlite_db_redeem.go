@@ -4,10 +4,14 @@ package btcaction
 	SQLiteRedeemStorage implements RedeemActionStorage using SQLite.
 
 	Table is btc_action_redeem
+	Table btc_action_redeem_tx keeps every btc tx broadcast for a redeem
+	(the original and the replacements, see BIP-125).
+	A batch redeem tx pays several redeems, so a btc tx can link to several redeems.
 */
 
 import (
 	"database/sql"
+	"fmt"
 
 	_ "github.com/mattn/go-sqlite3"
 )
@@ -40,15 +44,69 @@ func (s *SQLiteRedeemStorage) init() error {
 		EthRequestTxID TEXT PRIMARY KEY,
 		BtcHash TEXT,
 		Sent BOOLEAN DEFAULT 0,
-		Mined BOOLEAN DEFAULT 0
+		Mined BOOLEAN DEFAULT 0,
+		FeeRate INTEGER DEFAULT 0,
+		BroadcastTime INTEGER DEFAULT 0,
+		BroadcastBlk INTEGER DEFAULT 0
 	);
 	CREATE INDEX IF NOT EXISTS idx_ethrequesttxid ON btc_action_redeem (EthRequestTxID);
 	CREATE INDEX IF NOT EXISTS idx_btchash ON btc_action_redeem (BtcHash);
+	CREATE TABLE IF NOT EXISTS btc_action_redeem_tx (
+		BtcHash TEXT NOT NULL,
+		EthRequestTxID TEXT NOT NULL,
+		FeeRate INTEGER DEFAULT 0,
+		BroadcastTime INTEGER DEFAULT 0,
+		BroadcastBlk INTEGER DEFAULT 0,
+		PRIMARY KEY (BtcHash, EthRequestTxID)
+	);
+	CREATE INDEX IF NOT EXISTS idx_redeem_tx_ethrequesttxid ON btc_action_redeem_tx (EthRequestTxID);
+	`
+	if _, err := s.db.Exec(query); err != nil {
+		return err
+	}
+
+	// Tables created by older versions miss the broadcast columns.
+	for _, column := range []string{"FeeRate", "BroadcastTime", "BroadcastBlk"} {
+		if err := s.addColumnIfMissing("btc_action_redeem", column, "INTEGER DEFAULT 0"); err != nil {
+			return err
+		}
+	}
+
+	// Redeems sent by older versions have a single btc tx.
+	query = `
+	INSERT OR IGNORE INTO btc_action_redeem_tx (BtcHash, EthRequestTxID, FeeRate, BroadcastTime, BroadcastBlk)
+	SELECT BtcHash, EthRequestTxID, FeeRate, BroadcastTime, BroadcastBlk FROM btc_action_redeem WHERE BtcHash IS NOT NULL
 	`
 	_, err := s.db.Exec(query)
 	return err
 }
 
+func (s *SQLiteRedeemStorage) addColumnIfMissing(table string, column string, definition string) error {
+	rows, err := s.db.Query(fmt.Sprintf(`PRAGMA table_info(%s)`, table))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, ctype string
+		var dflt sql.NullString
+		if err := rows.Scan(&cid, &name, &ctype, &notNull, &dflt, &pk); err != nil {
+			return err
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	_, err = s.db.Exec(fmt.Sprintf(`ALTER TABLE %s ADD COLUMN %s %s`, table, column, definition))
+	return err
+}
+
 func (s *SQLiteRedeemStorage) HasRedeem(ethRequestTxID string) (bool, error) {
 	query := `SELECT COUNT(*) FROM btc_action_redeem WHERE EthRequestTxID = ?`
 	var count int
@@ -60,36 +118,119 @@ func (s *SQLiteRedeemStorage) HasRedeem(ethRequestTxID string) (bool, error) {
 }
 
 func (s *SQLiteRedeemStorage) QueryByEthRequestTxId(ethRequestTxID string) (*RedeemAction, error) {
-	query := `SELECT BtcHash, Sent, Mined FROM btc_action_redeem WHERE EthRequestTxID = ?`
-	var btcHash string
-	var sent, mined bool
-	err := s.db.QueryRow(query, ethRequestTxID).Scan(&btcHash, &sent, &mined)
+	query := `SELECT BtcHash, Sent, Mined, FeeRate, BroadcastTime, BroadcastBlk FROM btc_action_redeem WHERE EthRequestTxID = ?`
+	redeem := &RedeemAction{EthRequestTxID: ethRequestTxID}
+	err := s.db.QueryRow(query, ethRequestTxID).Scan(&redeem.BtcHash, &redeem.Sent, &redeem.Mined, &redeem.FeeRate, &redeem.BroadcastTime, &redeem.BroadcastBlk)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
-	return &RedeemAction{
-		EthRequestTxID: ethRequestTxID,
-		BtcHash:        btcHash,
-		Sent:           sent,
-		Mined:          mined,
-	}, err
+	return redeem, err
 }
 
 func (s *SQLiteRedeemStorage) InsertRedeem(redeem *RedeemAction) error {
-	query := `INSERT INTO btc_action_redeem (EthRequestTxID, BtcHash, Sent) VALUES (?, ?, ?)`
-	_, err := s.db.Exec(query, redeem.EthRequestTxID, redeem.BtcHash, true)
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO btc_action_redeem (EthRequestTxID, BtcHash, Sent, FeeRate, BroadcastTime, BroadcastBlk) VALUES (?, ?, ?, ?, ?, ?)`
+	if _, err := tx.Exec(query, redeem.EthRequestTxID, redeem.BtcHash, true, redeem.FeeRate, redeem.BroadcastTime, redeem.BroadcastBlk); err != nil {
+		return err
+	}
+	if err := insertRedeemTx(tx, redeem); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *SQLiteRedeemStorage) ReplaceRedeemTx(redeem *RedeemAction) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE btc_action_redeem SET BtcHash = ?, FeeRate = ?, BroadcastTime = ?, BroadcastBlk = ? WHERE EthRequestTxID = ? AND Mined = ?`
+	res, err := tx.Exec(query, redeem.BtcHash, redeem.FeeRate, redeem.BroadcastTime, redeem.BroadcastBlk, redeem.EthRequestTxID, false)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("no unmined redeem to replace, reqTxId=%s", redeem.EthRequestTxID)
+	}
+	if err := insertRedeemTx(tx, redeem); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// insertRedeemTx records a broadcast btc tx of the redeem.
+func insertRedeemTx(tx *sql.Tx, redeem *RedeemAction) error {
+	query := `INSERT OR REPLACE INTO btc_action_redeem_tx (BtcHash, EthRequestTxID, FeeRate, BroadcastTime, BroadcastBlk) VALUES (?, ?, ?, ?, ?)`
+	_, err := tx.Exec(query, redeem.BtcHash, redeem.EthRequestTxID, redeem.FeeRate, redeem.BroadcastTime, redeem.BroadcastBlk)
 	return err
 }
 
+func (s *SQLiteRedeemStorage) QueryUnminedRedeems() ([]RedeemAction, error) {
+	query := `SELECT EthRequestTxID, BtcHash, Sent, Mined, FeeRate, BroadcastTime, BroadcastBlk FROM btc_action_redeem WHERE Sent = ? AND Mined = ?`
+	rows, err := s.db.Query(query, true, false)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var redeems []RedeemAction
+	for rows.Next() {
+		var r RedeemAction
+		if err := rows.Scan(&r.EthRequestTxID, &r.BtcHash, &r.Sent, &r.Mined, &r.FeeRate, &r.BroadcastTime, &r.BroadcastBlk); err != nil {
+			return nil, err
+		}
+		redeems = append(redeems, r)
+	}
+	return redeems, rows.Err()
+}
+
+// QueryByBtcTxId finds the redeem by the original or any replacement btc tx.
+// BtcHash, FeeRate, BroadcastTime and BroadcastBlk are of the queried btc tx.
+// If the btc tx is a batch, the first redeem of the batch is returned.
 func (s *SQLiteRedeemStorage) QueryByBtcTxId(btcTxID string) (*RedeemAction, error) {
-	query := `SELECT EthRequestTxID, Sent, Mined FROM btc_action_redeem WHERE BtcHash = ?`
-	redeem := &RedeemAction{}
-	err := s.db.QueryRow(query, btcTxID).Scan(&redeem.EthRequestTxID, &redeem.Sent, &redeem.Mined)
-	if err == sql.ErrNoRows {
+	redeems, err := s.QueryAllByBtcTxId(btcTxID)
+	if err != nil {
+		return nil, err
+	}
+	if len(redeems) == 0 {
 		return nil, nil
 	}
-	redeem.BtcHash = btcTxID
-	return redeem, err
+	return &redeems[0], nil
+}
+
+// QueryAllByBtcTxId finds all redeems paid by the btc tx (original or replacement).
+func (s *SQLiteRedeemStorage) QueryAllByBtcTxId(btcTxID string) ([]RedeemAction, error) {
+	query := `
+	SELECT r.EthRequestTxID, r.Sent, r.Mined, t.FeeRate, t.BroadcastTime, t.BroadcastBlk
+	FROM btc_action_redeem_tx t JOIN btc_action_redeem r ON t.EthRequestTxID = r.EthRequestTxID
+	WHERE t.BtcHash = ?
+	ORDER BY r.EthRequestTxID`
+	rows, err := s.db.Query(query, btcTxID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var redeems []RedeemAction
+	for rows.Next() {
+		r := RedeemAction{BtcHash: btcTxID}
+		if err := rows.Scan(&r.EthRequestTxID, &r.Sent, &r.Mined, &r.FeeRate, &r.BroadcastTime, &r.BroadcastBlk); err != nil {
+			return nil, err
+		}
+		redeems = append(redeems, r)
+	}
+	return redeems, rows.Err()
 }
 
 func (s *SQLiteRedeemStorage) IfNotMined(ethRequestTxID string) (bool, error) {
@@ -102,6 +243,16 @@ func (s *SQLiteRedeemStorage) IfNotMined(ethRequestTxID string) (bool, error) {
 	return count > 0, nil
 }
 
+func (s *SQLiteRedeemStorage) IsRedeemSent(ethRequestTxID string) (bool, error) {
+	query := `SELECT COUNT(*) FROM btc_action_redeem WHERE EthRequestTxID = ? AND Sent = ?`
+	var count int
+	err := s.db.QueryRow(query, ethRequestTxID, true).Scan(&count)
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
 func (s *SQLiteRedeemStorage) CompleteRedeem(ethRequestTxID string) error {
 	bingo, err := s.IfNotMined(ethRequestTxID)
 	if err != nil {
@@ -114,3 +265,33 @@ func (s *SQLiteRedeemStorage) CompleteRedeem(ethRequestTxID string) error {
 	}
 	return nil
 }
+
+func (s *SQLiteRedeemStorage) CompleteRedeemByBtcTxId(btcTxID string) error {
+	records, err := s.QueryAllByBtcTxId(btcTxID)
+	if err != nil {
+		return err
+	}
+	if len(records) == 0 {
+		return fmt.Errorf("no redeem found by btc tx id %s", btcTxID)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE btc_action_redeem SET Mined = ?, BtcHash = ?, FeeRate = ?, BroadcastTime = ?, BroadcastBlk = ? WHERE EthRequestTxID = ?`
+	for _, record := range records {
+		if _, err := tx.Exec(query, true, btcTxID, record.FeeRate, record.BroadcastTime, record.BroadcastBlk, record.EthRequestTxID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *SQLiteRedeemStorage) UncompleteRedeem(ethRequestTxID string) error {
+	query := `UPDATE btc_action_redeem SET Mined = ? WHERE EthRequestTxID = ?`
+	_, err := s.db.Exec(query, false, ethRequestTxID)
+	return err
+}
@@ -0,0 +1,125 @@
+/*
+SQLiteRefundStorage implements RefundStorage using SQLite.
+
+Table is btc_action_refund
+*/
+package btcaction
+
+import (
+	"database/sql"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+type SQLiteRefundStorage struct {
+	db *sql.DB
+}
+
+func NewSQLiteRefundStorage(dbPath string) (*SQLiteRefundStorage, error) {
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		return nil, err
+	}
+	storage := &SQLiteRefundStorage{db: db}
+	if err := storage.init(); err != nil {
+		return nil, err
+	}
+
+	return storage, nil
+}
+
+// Close closes the database connection
+func (s *SQLiteRefundStorage) Close() error {
+	return s.db.Close()
+}
+
+func (s *SQLiteRefundStorage) init() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS btc_action_refund (
+		TxHash TEXT PRIMARY KEY,
+		BlockNumber INTEGER,
+		BlockHash TEXT,
+		Vout INTEGER,
+		Amount INTEGER,
+		RefundAddr TEXT,
+		Reason TEXT,
+		Status TEXT,
+		RefundTxHash TEXT DEFAULT '',
+		CreatedAt INTEGER DEFAULT 0,
+		SentAt INTEGER DEFAULT 0
+	);
+	CREATE INDEX IF NOT EXISTS idx_refund_status ON btc_action_refund (Status);
+	CREATE INDEX IF NOT EXISTS idx_refund_refundaddr ON btc_action_refund (RefundAddr);
+	`
+	_, err := s.db.Exec(query)
+	return err
+}
+
+const refundColumns = `TxHash, BlockNumber, BlockHash, Vout, Amount, RefundAddr, Reason, Status, RefundTxHash, CreatedAt, SentAt`
+
+func (s *SQLiteRefundStorage) AddRefund(refund RefundAction) error {
+	query := `
+	INSERT INTO btc_action_refund (` + refundColumns + `)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	ON CONFLICT(TxHash) DO UPDATE SET BlockNumber = excluded.BlockNumber, BlockHash = excluded.BlockHash`
+	_, err := s.db.Exec(query, refund.TxHash, refund.BlockNumber, refund.BlockHash, refund.Vout, refund.Amount, refund.RefundAddr, refund.Reason, refund.Status, refund.RefundTxHash, refund.CreatedAt, refund.SentAt)
+	return err
+}
+
+func (s *SQLiteRefundStorage) GetRefundByTxHash(txHash string) (*RefundAction, error) {
+	return s.queryOne(`SELECT `+refundColumns+` FROM btc_action_refund WHERE TxHash = ?`, txHash)
+}
+
+func (s *SQLiteRefundStorage) GetRefundByRefundTxHash(refundTxHash string) (*RefundAction, error) {
+	return s.queryOne(`SELECT `+refundColumns+` FROM btc_action_refund WHERE RefundTxHash = ?`, refundTxHash)
+}
+
+func (s *SQLiteRefundStorage) GetRefundsByStatus(status string) ([]RefundAction, error) {
+	return s.query(`SELECT `+refundColumns+` FROM btc_action_refund WHERE Status = ? ORDER BY CreatedAt`, status)
+}
+
+func (s *SQLiteRefundStorage) GetRefundsByRefundAddr(refundAddr string) ([]RefundAction, error) {
+	return s.query(`SELECT `+refundColumns+` FROM btc_action_refund WHERE RefundAddr = ? ORDER BY CreatedAt`, refundAddr)
+}
+
+func (s *SQLiteRefundStorage) SetRefundSent(txHash string, refundTxHash string, sentAt int64) error {
+	query := `UPDATE btc_action_refund SET Status = ?, RefundTxHash = ?, SentAt = ? WHERE TxHash = ?`
+	_, err := s.db.Exec(query, REFUND_STATUS_SENT, refundTxHash, sentAt, txHash)
+	return err
+}
+
+func (s *SQLiteRefundStorage) SetRefundStatus(txHash string, status string, reason string) error {
+	query := `UPDATE btc_action_refund SET Status = ?, Reason = CASE WHEN ? = '' THEN Reason ELSE ? END WHERE TxHash = ?`
+	_, err := s.db.Exec(query, status, reason, reason, txHash)
+	return err
+}
+
+func (s *SQLiteRefundStorage) queryOne(query string, args ...any) (*RefundAction, error) {
+	r := &RefundAction{}
+	err := s.db.QueryRow(query, args...).Scan(&r.TxHash, &r.BlockNumber, &r.BlockHash, &r.Vout, &r.Amount, &r.RefundAddr, &r.Reason, &r.Status, &r.RefundTxHash, &r.CreatedAt, &r.SentAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (s *SQLiteRefundStorage) query(query string, args ...any) ([]RefundAction, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var refunds []RefundAction
+	for rows.Next() {
+		var r RefundAction
+		if err := rows.Scan(&r.TxHash, &r.BlockNumber, &r.BlockHash, &r.Vout, &r.Amount, &r.RefundAddr, &r.Reason, &r.Status, &r.RefundTxHash, &r.CreatedAt, &r.SentAt); err != nil {
+			return nil, err
+		}
+		refunds = append(refunds, r)
+	}
+	return refunds, rows.Err()
+}
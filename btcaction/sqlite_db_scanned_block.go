@@ -0,0 +1,90 @@
+/*
+SQLiteScannedBlockStorage implements ScannedBlockStorage using SQLite.
+
+Table is btc_scanned_block
+*/
+package btcaction
+
+import (
+	"database/sql"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+type SQLiteScannedBlockStorage struct {
+	db *sql.DB
+}
+
+func NewSQLiteScannedBlockStorage(dbPath string) (*SQLiteScannedBlockStorage, error) {
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		return nil, err
+	}
+	storage := &SQLiteScannedBlockStorage{db: db}
+	if err := storage.init(); err != nil {
+		return nil, err
+	}
+
+	return storage, nil
+}
+
+// Close closes the database connection
+func (s *SQLiteScannedBlockStorage) Close() error {
+	return s.db.Close()
+}
+
+func (s *SQLiteScannedBlockStorage) init() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS btc_scanned_block (
+		BlockNumber INTEGER PRIMARY KEY,
+		BlockHash TEXT NOT NULL,
+		PrevHash TEXT NOT NULL
+	);
+	`
+	_, err := s.db.Exec(query)
+	return err
+}
+
+func (s *SQLiteScannedBlockStorage) AddScannedBlock(block ScannedBlock) error {
+	query := `INSERT OR REPLACE INTO btc_scanned_block (BlockNumber, BlockHash, PrevHash) VALUES (?, ?, ?)`
+	_, err := s.db.Exec(query, block.BlockNumber, block.BlockHash, block.PrevHash)
+	return err
+}
+
+func (s *SQLiteScannedBlockStorage) GetScannedBlock(blockNumber int) (*ScannedBlock, error) {
+	query := `SELECT BlockNumber, BlockHash, PrevHash FROM btc_scanned_block WHERE BlockNumber = ?`
+	block := &ScannedBlock{}
+	err := s.db.QueryRow(query, blockNumber).Scan(&block.BlockNumber, &block.BlockHash, &block.PrevHash)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return block, nil
+}
+
+func (s *SQLiteScannedBlockStorage) GetHighestScannedBlock() (*ScannedBlock, error) {
+	query := `SELECT BlockNumber, BlockHash, PrevHash FROM btc_scanned_block ORDER BY BlockNumber DESC LIMIT 1`
+	block := &ScannedBlock{}
+	err := s.db.QueryRow(query).Scan(&block.BlockNumber, &block.BlockHash, &block.PrevHash)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return block, nil
+}
+
+func (s *SQLiteScannedBlockStorage) DeleteScannedBlocksAbove(blockNumber int) error {
+	query := `DELETE FROM btc_scanned_block WHERE BlockNumber > ?`
+	_, err := s.db.Exec(query, blockNumber)
+	return err
+}
+
+func (s *SQLiteScannedBlockStorage) DeleteScannedBlocksBelow(blockNumber int) error {
+	query := `DELETE FROM btc_scanned_block WHERE BlockNumber < ?`
+	_, err := s.db.Exec(query, blockNumber)
+	return err
+}
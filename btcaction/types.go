@@ -15,8 +15,11 @@ type DepositAction struct {
 	Basic
 	DepositValue    int64
 	DepositReceiver string // of btc (our bridge wallet address)
-	EvmID           int32  // EVM Chain ID
-	EvmAddr         string // No 0x prefix
+	EvmID           int32  // Destination Chain ID (EVM or not)
+	EvmAddr         string // EVM receiver (0x checksummed), empty if the destination is not EVM
+	ChainType       string // Destination chain type, "evm" or "aptos"
+	Receiver        string // Destination receiver with 0x prefix, 20 bytes on evm, 32 bytes on aptos
+	Memo            string // Optional memo of the deposit, hex (no 0x prefix)
 }
 
 // DepositStorage is an interface for storing and querying DepositAction.
@@ -38,16 +41,29 @@ type DepositStorage interface {
 
 	// GetDepositsByEVMAddr queries DepositAction by EvmAddr.
 	GetDepositsByEVMAddr(evmAddr string) ([]DepositAction, error)
+
+	// GetDepositsByDestReceiver queries DepositAction by the destination Receiver (any chain type).
+	GetDepositsByDestReceiver(receiver string) ([]DepositAction, error)
+
+	// DeleteDepositsByBlockHash removes the deposits found in a block
+	// that is no longer on the best chain (reorg).
+	DeleteDepositsByBlockHash(blockHash string) error
 }
 
 // RedeemAction is a management action.
 // After sent the redeem btc, we fill in EthRequestTxID, BtcHash, and mark Sent.
 // After the redeem btc is mined, we mark Mined, and Basic
+// The redeem btc tx can be replaced by a higher fee one (BIP-125),
+// BtcHash, FeeRate, BroadcastTime and BroadcastBlk follow the latest broadcast tx,
+// once mined, BtcHash is the mined one.
 type RedeemAction struct {
 	EthRequestTxID string // fill this after <sent>. 64 hex (32 byte), no "0x" prefix.
 	BtcHash        string // BTC TxID. fill this after <sent>. no "0x" prefix.
 	Sent           bool   // mark this after <sent>.
 	Mined          bool   // mark this after <mined>.
+	FeeRate        int64  // satoshi/vbyte of the broadcast tx.
+	BroadcastTime  int64  // unix timestamp of the broadcast.
+	BroadcastBlk   int64  // btc block height at the broadcast.
 }
 
 type RedeemActionStorage interface {
@@ -58,19 +74,149 @@ type RedeemActionStorage interface {
 	QueryByEthRequestTxId(ethRequestTxID string) (*RedeemAction, error)
 
 	// Check via btcTxID
+	// If the btc tx is a batch, the first redeem of the batch is returned.
 	QueryByBtcTxId(btcTxID string) (*RedeemAction, error)
 
+	// Query all the redeems paid by btcTxID (a batch redeem tx pays several redeems)
+	QueryAllByBtcTxId(btcTxID string) ([]RedeemAction, error)
+
 	// Insert a new BTC redeem (sent to BTC blockchain but not mined yet)
 	InsertRedeem(r *RedeemAction) error
 
+	// Replace the btc tx of a sent redeem with a higher fee one (BIP-125).
+	// The redeem can still be queried by the replaced btc tx ids.
+	ReplaceRedeemTx(r *RedeemAction) error
+
+	// Query the redeems sent but not mined yet.
+	QueryUnminedRedeems() ([]RedeemAction, error)
+
 	// Check if the redeem exists but not been mined on Bitcoin blockchain
 	IfNotMined(ethRequestTxID string) (bool, error)
 
+	// Check if a btc tx paying the redeem is broadcast (mined or not).
+	IsRedeemSent(ethRequestTxID string) (bool, error)
+
 	// Complete (finish) the redeem
 	CompleteRedeem(ethRequestTxID string) error
 
-	// Complete (finish) the redeem by btcTxID
-	// CompletedByBtcTxID(btcTxID string) error
+	// Undo the completion of the redeem (the mined btc tx is orphaned by a reorg)
+	// The redeem is kept as "sent", so it won't be sent again.
+	UncompleteRedeem(ethRequestTxID string) error
+
+	// Complete (finish) the redeem(s) by btcTxID (the original or any replacement tx).
+	// If the btc tx is a batch, all the redeems of the batch are completed.
+	// BtcHash of the redeem(s) is set to the btcTxID.
+	CompleteRedeemByBtcTxId(btcTxID string) error
+}
+
+// ScannedBlock is a BTC block that has been scanned by the monitor.
+// The monitor keeps the hash chain of scanned blocks to detect reorgs.
+type ScannedBlock struct {
+	BlockNumber int    // btc
+	BlockHash   string // btc, no "0x" prefix.
+	PrevHash    string // hash of the parent block, no "0x" prefix.
+}
+
+// ScannedBlockStorage is an interface for storing and querying ScannedBlock.
+type ScannedBlockStorage interface {
+	// AddScannedBlock adds (or replaces) the record at the block number.
+	AddScannedBlock(block ScannedBlock) error
+
+	// GetScannedBlock queries the record by block number, nil if not found.
+	GetScannedBlock(blockNumber int) (*ScannedBlock, error)
+
+	// GetHighestScannedBlock queries the record with the largest block number, nil if empty.
+	GetHighestScannedBlock() (*ScannedBlock, error)
+
+	// DeleteScannedBlocksAbove removes the records with block number > blockNumber.
+	DeleteScannedBlocksAbove(blockNumber int) error
+
+	// DeleteScannedBlocksBelow removes the records with block number < blockNumber.
+	DeleteScannedBlocksBelow(blockNumber int) error
+}
+
+// Status of a RefundAction
+const (
+	REFUND_STATUS_PENDING = "pending" // waits for the review delay, then refund
+	REFUND_STATUS_SENT    = "sent"    // refund tx is sent
+	REFUND_STATUS_MINED   = "mined"   // refund tx is mined
+	REFUND_STATUS_MANUAL  = "manual"  // cannot refund automatically, see Reason
+)
+
+// RefundAction is a malformed deposit (pays to us, but bad OP_RETURN data)
+// that is to be refunded to the sender.
+type RefundAction struct {
+	Basic               // the malformed deposit tx
+	Vout         int    // output of the deposit tx that pays to us
+	Amount       int64  // in satoshi
+	RefundAddr   string // btc address of the sender (from the first input), empty if unknown
+	Reason       string // why the deposit is malformed (or why it cannot be refunded)
+	Status       string // REFUND_STATUS_*
+	RefundTxHash string // btc refund tx id, empty if not sent
+	CreatedAt    int64  // Unix timestamp in seconds, when the malformed deposit is found
+	SentAt       int64  // Unix timestamp in seconds, when the refund tx is sent
+}
+
+// RefundStorage is an interface for storing and querying RefundAction.
+type RefundStorage interface {
+	// AddRefund adds a new RefundAction.
+	// If the deposit tx is already recorded (eg. re-mined after a reorg), only the block is updated.
+	AddRefund(refund RefundAction) error
+
+	// GetRefundByTxHash queries RefundAction by the deposit TxHash, nil if not found.
+	GetRefundByTxHash(txHash string) (*RefundAction, error)
+
+	// GetRefundByRefundTxHash queries RefundAction by the refund tx hash, nil if not found.
+	GetRefundByRefundTxHash(refundTxHash string) (*RefundAction, error)
+
+	// GetRefundsByStatus queries RefundActions by Status.
+	GetRefundsByStatus(status string) ([]RefundAction, error)
+
+	// GetRefundsByRefundAddr queries RefundActions by RefundAddr.
+	GetRefundsByRefundAddr(refundAddr string) ([]RefundAction, error)
+
+	// SetRefundSent marks the RefundAction sent by the refund tx.
+	SetRefundSent(txHash string, refundTxHash string, sentAt int64) error
+
+	// SetRefundStatus sets the Status (and the Reason if not empty) of the RefundAction.
+	SetRefundStatus(txHash string, status string, reason string) error
+}
+
+// ConsolidationAction is a btc tx that merges small UTXOs of the vault
+// into a few larger outputs back to the bridge address.
+type ConsolidationAction struct {
+	BtcHash      string // btc tx id (no 0x prefix)
+	LinkedID     string // linked ID of the input UTXOs locked in the vault
+	InputCount   int    // number of UTXOs merged
+	InputAmount  int64  // sum of UTXOs merged, in satoshi
+	OutputCount  int    // number of outputs back to the bridge address
+	FeeRate      int64  // satoshi/vbyte
+	BroadcastBlk int64  // btc block height when the tx is sent
+	Confirmed    bool   // the tx is mined, inputs are marked spent in the vault
+	ReplacedBy   string // btc tx id of the higher fee tx spending the same inputs, "" if not replaced
+	Abandoned    bool   // the inputs are spent by another tx, the tx can never be mined
+}
+
+// ConsolidationStorage is an interface for storing and querying ConsolidationAction.
+type ConsolidationStorage interface {
+	// InsertConsolidation adds a new ConsolidationAction.
+	InsertConsolidation(c *ConsolidationAction) error
+
+	// QueryConsolidation queries ConsolidationAction by BtcHash, nil if not found.
+	QueryConsolidation(btcHash string) (*ConsolidationAction, error)
+
+	// QueryUnconfirmedConsolidations queries the sent but not yet mined ConsolidationActions,
+	// the replaced and abandoned ones are left out.
+	QueryUnconfirmedConsolidations() ([]ConsolidationAction, error)
+
+	// ConfirmConsolidation marks the ConsolidationAction as mined.
+	ConfirmConsolidation(btcHash string) error
+
+	// ReplaceConsolidation adds c, the replacement of the ConsolidationAction oldBtcHash.
+	ReplaceConsolidation(oldBtcHash string, c *ConsolidationAction) error
+
+	// AbandonConsolidation marks the ConsolidationAction as abandoned.
+	AbandonConsolidation(btcHash string) error
 }
 
 // OtherTransferAction is a struct that represents an unknown transfer
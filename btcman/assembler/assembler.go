@@ -12,11 +12,26 @@ import (
 	"github.com/TEENet-io/bridge-go/common"
 )
 
+const (
+	// Inputs with sequence number below 0xfffffffe signal the tx is replaceable (BIP-125).
+	RBF_SEQUENCE = wire.MaxTxInSequenceNum - 2
+)
+
 type Assembler struct {
 	ChainConfig *chaincfg.Params // which BTC chain it is on. (mainnet, testnet, regtest)
 	Op          Operator         // can do unlock/locking script on a btc transaction.
 }
 
+// AddInputs adds the UTXO(s) to spend as inputs of the Tx, with empty signature scripts.
+// Operators fill in the signature scripts later in Unlock().
+func AddInputs(tx *wire.MsgTx, prevOutputs []*utxo.UTXO, sequence uint32) {
+	for _, item := range prevOutputs {
+		txIn := wire.NewTxIn(wire.NewOutPoint(item.TxHash, item.Vout), nil, nil)
+		txIn.Sequence = sequence
+		tx.AddTxIn(txIn)
+	}
+}
+
 // Create a locking script on a Tx, to transfer out money to a single receiver.
 // This type of locking sends funds to dst_addr and keep the change to change_addr.
 // The change_amount is implied by:
@@ -135,9 +150,9 @@ func (myAss *Assembler) craftRedeemOutput(
 	txOut2 := wire.NewTxOut(0, opReturnScript) // No value for OP_RETURN
 	tx.AddTxOut(txOut2)
 
-	// 3rd output: to the change receiver (if change > 0)
-	// if change == 0 no need to add this clause.
-	if change_amount > 0 {
+	// 3rd output: to the change receiver (if change is not dust)
+	// if change is dust no need to add this clause, it goes to the miner.
+	if change_amount >= DUST_LIMIT {
 		tx, err = AppendPayToAddress(tx, myAss.ChainConfig, change_addr, change_amount)
 		if err != nil {
 			return nil, err
@@ -146,8 +161,70 @@ func (myAss *Assembler) craftRedeemOutput(
 	return tx, nil
 }
 
+// EstimateRedeemFee estimates the mining fee (in satoshi) of a redeem tx.
+// fee = fee_rate * estimated vsize of the tx.
+func (myAss *Assembler) EstimateRedeemFee(
+	dst_addr string,
+	dst_amount int64,
+	redeemData common.RedeemData,
+	change_addr string,
+	fee_rate int64, // satoshi/vbyte
+	prevOutputs []*utxo.UTXO,
+) (int64, error) {
+	// Draft the outputs without fee, to know the size of them.
+	draft, err := myAss.craftRedeemOutput(
+		wire.NewMsgTx(wire.TxVersion),
+		prevOutputs,
+		dst_addr,
+		dst_amount,
+		redeemData,
+		change_addr,
+		0,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return fee_rate * EstimateVSize(prevOutputs, draft.TxOut), nil
+}
+
+// maxFeeRate gives the highest fee rate (satoshi/vbyte) prevOutputs can pay
+// for a tx with the drafted outputs, after paying dst_sum.
+func maxFeeRate(prevOutputs []*utxo.UTXO, draft *wire.MsgTx, dst_sum int64) int64 {
+	var sum int64
+	for _, item := range prevOutputs {
+		sum += item.Amount
+	}
+	return (sum - dst_sum) / EstimateVSize(prevOutputs, draft.TxOut)
+}
+
+// MaxRedeemFeeRate gives the highest fee rate (satoshi/vbyte) the UTXO(s) of a redeem tx can pay.
+// A higher fee rate makes MakeRedeemTx fail with change_amount < 0.
+func (myAss *Assembler) MaxRedeemFeeRate(
+	dst_addr string,
+	dst_amount int64,
+	redeemData common.RedeemData,
+	change_addr string,
+	prevOutputs []*utxo.UTXO,
+) (int64, error) {
+	draft, err := myAss.craftRedeemOutput(
+		wire.NewMsgTx(wire.TxVersion),
+		prevOutputs,
+		dst_addr,
+		dst_amount,
+		redeemData,
+		change_addr,
+		0,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return maxFeeRate(prevOutputs, draft, dst_amount), nil
+}
+
 // Make a raw tx that transfer some bitcoin to dst_addr.
 // It takes care of both locking + unlocking.
+// The mining fee is fee_rate * estimated vsize of the tx.
+// The tx signals BIP-125, it can be replaced by a higher fee one (same inputs).
 // After deduction of mining fee, keep the change to change_addr.
 // You need to send the Tx later via PRC.
 func (myAss *Assembler) MakeRedeemTx(
@@ -155,14 +232,19 @@ func (myAss *Assembler) MakeRedeemTx(
 	dst_amount int64,
 	redeemData common.RedeemData, // data to be sent in OP_RETURN
 	change_addr string,
-	fee_amount int64,
+	fee_rate int64, // satoshi/vbyte
 	prevOutputs []*utxo.UTXO,
 ) (*wire.MsgTx, error) {
+	fee_amount, err := myAss.EstimateRedeemFee(dst_addr, dst_amount, redeemData, change_addr, fee_rate, prevOutputs)
+	if err != nil {
+		return nil, err
+	}
+
 	// Create a new transaction
 	tx := wire.NewMsgTx(wire.TxVersion)
 
 	// Stuff the locking scripts first.
-	tx, err := myAss.craftRedeemOutput(
+	tx, err = myAss.craftRedeemOutput(
 		tx,
 		prevOutputs,
 		dst_addr,
@@ -175,6 +257,9 @@ func (myAss *Assembler) MakeRedeemTx(
 		return nil, err
 	}
 
+	// Replaceable inputs.
+	AddInputs(tx, prevOutputs, RBF_SEQUENCE)
+
 	// Stuff the unlocking scripts, secondly.
 	// Calculate & sign the Tx inputs (by unlocking previous outputs we received)
 	tx, err = myAss.Op.Unlock(tx, prevOutputs)
@@ -185,6 +270,239 @@ func (myAss *Assembler) MakeRedeemTx(
 	return tx, nil
 }
 
+// RedeemPayout is a single redeem paid in a batch redeem tx.
+type RedeemPayout struct {
+	DstAddr    string            // receiver
+	DstAmount  int64             // btc amount to receiver in satoshi
+	RedeemData common.RedeemData // identifier of the redeem
+}
+
+// craftBatchRedeemOutput creates a batch Redeem (withdraw) Tx.
+// output #1 ~ #n, satoshi to each user receiver.
+// output #n+1, batch redeem data (commits to all redeems) in OP_RETURN.
+// output #n+2, satoshi to the our change receiver.
+func (myAss *Assembler) craftBatchRedeemOutput(
+	tx *wire.MsgTx,
+	prevOutputs []*utxo.UTXO, // UTXO(s) to spend from.
+	payouts []RedeemPayout, // redeems to pay
+	change_addr string, // receiver to receive the change
+	fee_amount int64, // amount of mining fee in satoshi
+) (*wire.MsgTx, error) {
+	var sum int64
+	for _, item := range prevOutputs {
+		sum += item.Amount
+	}
+	var dst_sum int64
+	for _, payout := range payouts {
+		dst_sum += payout.DstAmount
+	}
+	// Calc change_amount
+	change_amount := sum - dst_sum - fee_amount
+	if change_amount < 0 {
+		return nil, fmt.Errorf("change_amount < 0, sum: %d, dst_sum: %d, fee_amount: %d", sum, dst_sum, fee_amount)
+	}
+
+	// 1st ~ nth output: to the dst receivers
+	var redeemDatas []common.RedeemData
+	for _, payout := range payouts {
+		var err error
+		tx, err = AppendPayToAddress(tx, myAss.ChainConfig, payout.DstAddr, payout.DstAmount)
+		if err != nil {
+			return nil, err
+		}
+		redeemDatas = append(redeemDatas, payout.RedeemData)
+	}
+
+	// n+1th output: OP_RETURN data
+	opReturnData, err := common.MakeBatchRedeemOpReturnData(redeemDatas)
+	if err != nil {
+		return nil, err
+	}
+	opReturnScript, err := txscript.NullDataScript(opReturnData)
+	if err != nil {
+		return nil, err
+	}
+	tx.AddTxOut(wire.NewTxOut(0, opReturnScript)) // No value for OP_RETURN
+
+	// n+2th output: to the change receiver (if change is not dust)
+	if change_amount >= DUST_LIMIT {
+		tx, err = AppendPayToAddress(tx, myAss.ChainConfig, change_addr, change_amount)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return tx, nil
+}
+
+// EstimateBatchRedeemFee estimates the mining fee (in satoshi) of a batch redeem tx.
+// fee = fee_rate * estimated vsize of the tx.
+func (myAss *Assembler) EstimateBatchRedeemFee(
+	payouts []RedeemPayout,
+	change_addr string,
+	fee_rate int64, // satoshi/vbyte
+	prevOutputs []*utxo.UTXO,
+) (int64, error) {
+	// Draft the outputs without fee, to know the size of them.
+	draft, err := myAss.craftBatchRedeemOutput(wire.NewMsgTx(wire.TxVersion), prevOutputs, payouts, change_addr, 0)
+	if err != nil {
+		return 0, err
+	}
+	return fee_rate * EstimateVSize(prevOutputs, draft.TxOut), nil
+}
+
+// MaxBatchRedeemFeeRate gives the highest fee rate (satoshi/vbyte) the UTXO(s) of a batch redeem tx can pay.
+// A higher fee rate makes MakeBatchRedeemTx fail with change_amount < 0.
+func (myAss *Assembler) MaxBatchRedeemFeeRate(
+	payouts []RedeemPayout,
+	change_addr string,
+	prevOutputs []*utxo.UTXO,
+) (int64, error) {
+	draft, err := myAss.craftBatchRedeemOutput(wire.NewMsgTx(wire.TxVersion), prevOutputs, payouts, change_addr, 0)
+	if err != nil {
+		return 0, err
+	}
+	var dst_sum int64
+	for _, payout := range payouts {
+		dst_sum += payout.DstAmount
+	}
+	return maxFeeRate(prevOutputs, draft, dst_sum), nil
+}
+
+// Make a raw tx that pays several redeems at once.
+// It takes care of both locking + unlocking.
+// The mining fee is fee_rate * estimated vsize of the tx.
+// The tx signals BIP-125, it can be replaced by a higher fee one (same inputs).
+// You need to send the Tx later via PRC.
+func (myAss *Assembler) MakeBatchRedeemTx(
+	payouts []RedeemPayout,
+	change_addr string,
+	fee_rate int64, // satoshi/vbyte
+	prevOutputs []*utxo.UTXO,
+) (*wire.MsgTx, error) {
+	fee_amount, err := myAss.EstimateBatchRedeemFee(payouts, change_addr, fee_rate, prevOutputs)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := wire.NewMsgTx(wire.TxVersion)
+
+	// Stuff the locking scripts first.
+	tx, err = myAss.craftBatchRedeemOutput(tx, prevOutputs, payouts, change_addr, fee_amount)
+	if err != nil {
+		return nil, err
+	}
+
+	// Replaceable inputs.
+	AddInputs(tx, prevOutputs, RBF_SEQUENCE)
+
+	// Stuff the unlocking scripts, secondly.
+	tx, err = myAss.Op.Unlock(tx, prevOutputs)
+	if err != nil {
+		return nil, err
+	}
+
+	return tx, nil
+}
+
+// craftConsolidationOutput creates a Consolidation Tx.
+// output #1 ~ #n, the sum of UTXO(s) minus fee, split evenly, to dst_addr.
+// The remainder of the split goes to the last output.
+func (myAss *Assembler) craftConsolidationOutput(
+	tx *wire.MsgTx,
+	prevOutputs []*utxo.UTXO, // UTXO(s) to merge.
+	dst_addr string, // receiver of the merged outputs
+	output_count int, // number of merged outputs
+	fee_amount int64, // amount of mining fee in satoshi
+) (*wire.MsgTx, error) {
+	if output_count <= 0 {
+		return nil, fmt.Errorf("output_count %d <= 0", output_count)
+	}
+	var sum int64
+	for _, item := range prevOutputs {
+		sum += item.Amount
+	}
+	each_amount := (sum - fee_amount) / int64(output_count)
+	if each_amount < DUST_LIMIT {
+		return nil, fmt.Errorf("each output is dust, sum: %d, fee_amount: %d, output_count: %d", sum, fee_amount, output_count)
+	}
+
+	for i := 0; i < output_count; i++ {
+		amount := each_amount
+		if i == output_count-1 {
+			amount = sum - fee_amount - each_amount*int64(output_count-1)
+		}
+		var err error
+		tx, err = AppendPayToAddress(tx, myAss.ChainConfig, dst_addr, amount)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return tx, nil
+}
+
+// EstimateConsolidationFee estimates the mining fee (in satoshi) of a consolidation tx.
+// fee = fee_rate * estimated vsize of the tx.
+func (myAss *Assembler) EstimateConsolidationFee(
+	dst_addr string,
+	output_count int,
+	fee_rate int64, // satoshi/vbyte
+	prevOutputs []*utxo.UTXO,
+) (int64, error) {
+	// Draft the outputs without fee, to know the size of them.
+	draft, err := myAss.craftConsolidationOutput(wire.NewMsgTx(wire.TxVersion), prevOutputs, dst_addr, output_count, 0)
+	if err != nil {
+		return 0, err
+	}
+	return fee_rate * EstimateVSize(prevOutputs, draft.TxOut), nil
+}
+
+// Make a raw tx that merges the UTXO(s) into output_count outputs to dst_addr.
+// It takes care of both locking + unlocking.
+// The mining fee is fee_rate * estimated vsize of the tx.
+// You need to send the Tx later via PRC.
+func (myAss *Assembler) MakeConsolidationTx(
+	dst_addr string,
+	output_count int,
+	fee_rate int64, // satoshi/vbyte
+	prevOutputs []*utxo.UTXO,
+) (*wire.MsgTx, error) {
+	fee_amount, err := myAss.EstimateConsolidationFee(dst_addr, output_count, fee_rate, prevOutputs)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := wire.NewMsgTx(wire.TxVersion)
+
+	// Stuff the locking scripts first.
+	tx, err = myAss.craftConsolidationOutput(tx, prevOutputs, dst_addr, output_count, fee_amount)
+	if err != nil {
+		return nil, err
+	}
+
+	// Replaceable inputs.
+	AddInputs(tx, prevOutputs, RBF_SEQUENCE)
+
+	// Stuff the unlocking scripts, secondly.
+	tx, err = myAss.Op.Unlock(tx, prevOutputs)
+	if err != nil {
+		return nil, err
+	}
+
+	return tx, nil
+}
+
+// Make a raw tx that refunds the UTXO(s) (eg. a malformed deposit) to dst_addr.
+// A single output pays the sum of UTXO(s) minus the mining fee,
+// it fails if the refund is dust after the fee.
+// You need to send the Tx later via PRC.
+func (myAss *Assembler) MakeRefundTx(
+	dst_addr string,
+	fee_rate int64, // satoshi/vbyte
+	prevOutputs []*utxo.UTXO,
+) (*wire.MsgTx, error) {
+	return myAss.MakeConsolidationTx(dst_addr, 1, fee_rate, prevOutputs)
+}
+
 // Create 3 locking scripts on a given Tx.
 // These 3 scripts combined is recognized as "BTC2EVM deposit".
 // Output #1 to bridge BTC wallet address, with BTC value.
@@ -197,8 +515,7 @@ func (myAss *Assembler) craftBridgeDepositOutputs(
 	btc_bridge_amount int64, // amount to send to the bridge on BTC (in satoshi)
 	fee_amount int64, // amount of mining fee (in satoshi)
 	btc_change_address string, // address to receive the change.
-	evm_addr string, // EVM receiver's account address
-	evm_chain_id int, // EVM chain ID
+	opReturnData []byte, // deposit data, see common.MakeDepositOpReturnData()
 ) (*wire.MsgTx, error) {
 	var sum int64
 	for _, item := range prevOutputs {
@@ -217,10 +534,6 @@ func (myAss *Assembler) craftBridgeDepositOutputs(
 	}
 
 	// Output #2, OP_RETURN
-	opReturnData, err := common.MakeDepositOpReturnData(evm_chain_id, evm_addr)
-	if err != nil {
-		return nil, err
-	}
 	opReturnScript, err := txscript.NullDataScript(opReturnData)
 	if err != nil {
 		return nil, err
@@ -249,6 +562,42 @@ func (myAss *Assembler) MakeBridgeDepositTx(
 	btc_change_address string, // address to receive the change.
 	evm_addr string, // EVM receiver's account address
 	evm_chain_id int, // EVM chain ID
+) (*wire.MsgTx, error) {
+	opReturnData, err := common.MakeDepositOpReturnData(evm_chain_id, evm_addr)
+	if err != nil {
+		return nil, err
+	}
+	return myAss.makeBridgeDepositTx(prevOutputs, btc_bridge_address, btc_bridge_amount, fee_amount, btc_change_address, opReturnData)
+}
+
+// Make a Bridge Deposit Tx with a versioned deposit payload.
+// It can name a receiver on any destination chain (eg. a 32-byte Aptos account), and a memo.
+// The user needs to call PRC to send the raw Tx later.
+func (myAss *Assembler) MakeBridgeDepositPayloadTx(
+	prevOutputs []*utxo.UTXO,
+	btc_bridge_address string, // bridge wallet address on BTC (either P2PKH or P2WPKH type)
+	btc_bridge_amount int64, // amount to send to the bridge on BTC (in satoshi)
+	fee_amount int64, // amount of mining fee (in satoshi)
+	btc_change_address string, // address to receive the change.
+	chain_type byte, // destination chain type, common.DEPOSIT_CHAIN_TYPE_*
+	chain_id uint32, // destination chain ID
+	receiver string, // receiver's account address (hex) on the destination chain
+	memo []byte, // optional
+) (*wire.MsgTx, error) {
+	opReturnData, err := common.MakeDepositPayloadOpReturnData(chain_type, chain_id, receiver, memo)
+	if err != nil {
+		return nil, err
+	}
+	return myAss.makeBridgeDepositTx(prevOutputs, btc_bridge_address, btc_bridge_amount, fee_amount, btc_change_address, opReturnData)
+}
+
+func (myAss *Assembler) makeBridgeDepositTx(
+	prevOutputs []*utxo.UTXO,
+	btc_bridge_address string,
+	btc_bridge_amount int64,
+	fee_amount int64,
+	btc_change_address string,
+	opReturnData []byte,
 ) (*wire.MsgTx, error) {
 	// Create a new transaction
 	tx := wire.NewMsgTx(wire.TxVersion)
@@ -261,8 +610,7 @@ func (myAss *Assembler) MakeBridgeDepositTx(
 		btc_bridge_amount,
 		fee_amount,
 		btc_change_address,
-		evm_addr,
-		evm_chain_id,
+		opReturnData,
 	)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,158 @@
+package assembler
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+
+	"github.com/TEENet-io/bridge-go/btcman/utxo"
+	"github.com/TEENet-io/bridge-go/common"
+)
+
+// newTestNativeAssembler creates an assembler with p1 native operator,
+// and n random P2PKH UTXOs of p1, each of amount.
+func newTestNativeAssembler(t *testing.T, n int, amount int64) (*Assembler, []*utxo.UTXO) {
+	bs, err := NewNativeSigner(p1_legacy_priv_key_str, &chaincfg.RegressionNetParams)
+	if err != nil {
+		t.Fatalf("Cannot create NativeSigner %v", err)
+	}
+	op, err := NewNativeOperator(*bs)
+	if err != nil {
+		t.Fatalf("Cannot create NativeOperator %v", err)
+	}
+	pkScript, err := txscript.PayToAddrScript(op.P2PKH)
+	if err != nil {
+		t.Fatalf("Cannot create pkScript %v", err)
+	}
+
+	var prevOutputs []*utxo.UTXO
+	for i := 0; i < n; i++ {
+		txHash := chainhash.Hash(common.RandBytes32())
+		prevOutputs = append(prevOutputs, &utxo.UTXO{
+			TxID:     txHash.String(),
+			TxHash:   &txHash,
+			Vout:     uint32(i),
+			Amount:   amount,
+			PkScript: pkScript,
+		})
+	}
+
+	return &Assembler{ChainConfig: &chaincfg.RegressionNetParams, Op: op}, prevOutputs
+}
+
+func TestMakeBatchRedeemTx(t *testing.T) {
+	ass, prevOutputs := newTestNativeAssembler(t, 2, 100000)
+
+	payouts := []RedeemPayout{
+		{DstAddr: p2_legacy_addr_str, DstAmount: 30000, RedeemData: common.RandBytes32()},
+		{DstAddr: p1_legacy_addr_str, DstAmount: 40000, RedeemData: common.RandBytes32()},
+		{DstAddr: p2_legacy_addr_str, DstAmount: 50000, RedeemData: common.RandBytes32()},
+	}
+	tx, err := ass.MakeBatchRedeemTx(payouts, p1_legacy_addr_str, 10, prevOutputs)
+	if err != nil {
+		t.Fatalf("Cannot make batch redeem tx %v", err)
+	}
+
+	// payouts + OP_RETURN + change
+	if len(tx.TxOut) != len(payouts)+2 {
+		t.Fatalf("batch redeem tx has %d outputs, want %d", len(tx.TxOut), len(payouts)+2)
+	}
+	for i, payout := range payouts {
+		if tx.TxOut[i].Value != payout.DstAmount {
+			t.Fatalf("output %d value %d, want %d", i, tx.TxOut[i].Value, payout.DstAmount)
+		}
+	}
+
+	pushes, err := txscript.PushedData(tx.TxOut[len(payouts)].PkScript)
+	if err != nil || len(pushes) != 1 {
+		t.Fatalf("Cannot read OP_RETURN data %v", err)
+	}
+	brd, err := common.DecodeBatchRedeemData(pushes[0])
+	if err != nil {
+		t.Fatalf("Cannot decode batch redeem data %v", err)
+	}
+	rds := []common.RedeemData{payouts[0].RedeemData, payouts[1].RedeemData, payouts[2].RedeemData}
+	if int(brd.Count) != len(payouts) || brd.Root != common.RedeemMerkleRoot(rds) {
+		t.Fatalf("batch redeem data does not commit to the payouts")
+	}
+}
+
+func TestMakeConsolidationTx(t *testing.T) {
+	ass, prevOutputs := newTestNativeAssembler(t, 10, 5000)
+
+	tx, err := ass.MakeConsolidationTx(p1_legacy_addr_str, 3, 2, prevOutputs)
+	if err != nil {
+		t.Fatalf("Cannot make consolidation tx %v", err)
+	}
+	if len(tx.TxIn) != len(prevOutputs) || len(tx.TxOut) != 3 {
+		t.Fatalf("consolidation tx has %d inputs %d outputs, want %d inputs 3 outputs", len(tx.TxIn), len(tx.TxOut), len(prevOutputs))
+	}
+
+	fee, err := ass.EstimateConsolidationFee(p1_legacy_addr_str, 3, 2, prevOutputs)
+	if err != nil {
+		t.Fatalf("Cannot estimate consolidation fee %v", err)
+	}
+	var out_sum int64
+	for _, txOut := range tx.TxOut {
+		out_sum += txOut.Value
+	}
+	if out_sum != 10*5000-fee {
+		t.Fatalf("outputs sum %d, want %d", out_sum, 10*5000-fee)
+	}
+
+	// too many outputs, each one is dust.
+	_, err = ass.MakeConsolidationTx(p1_legacy_addr_str, 100, 2, prevOutputs)
+	if err == nil {
+		t.Fatalf("dust outputs shall fail")
+	}
+}
+
+func TestMakeRefundTx(t *testing.T) {
+	ass, prevOutputs := newTestNativeAssembler(t, 1, 20000)
+
+	tx, err := ass.MakeRefundTx(p2_legacy_addr_str, 2, prevOutputs)
+	if err != nil {
+		t.Fatalf("Cannot make refund tx %v", err)
+	}
+	if len(tx.TxIn) != 1 || len(tx.TxOut) != 1 {
+		t.Fatalf("refund tx has %d inputs %d outputs, want 1 input 1 output", len(tx.TxIn), len(tx.TxOut))
+	}
+	_, addresses, _, err := txscript.ExtractPkScriptAddrs(tx.TxOut[0].PkScript, &chaincfg.RegressionNetParams)
+	if err != nil || len(addresses) != 1 || addresses[0].EncodeAddress() != p2_legacy_addr_str {
+		t.Fatalf("refund tx does not pay to %s", p2_legacy_addr_str)
+	}
+	if tx.TxOut[0].Value >= 20000 {
+		t.Fatalf("refund %d does not pay the fee", tx.TxOut[0].Value)
+	}
+
+	// fee eats up the refund.
+	_, err = ass.MakeRefundTx(p2_legacy_addr_str, 1000, prevOutputs)
+	if err == nil {
+		t.Fatalf("dust refund shall fail")
+	}
+}
+
+func TestMakeBridgeDepositPayloadTx(t *testing.T) {
+	ass, prevOutputs := newTestNativeAssembler(t, 1, 100000)
+
+	aptos_receiver := common.Prepend0xPrefix(common.ByteSliceToPureHexStr(common.RandBytes(common.APTOS_RECEIVER_LEN)))
+	tx, err := ass.MakeBridgeDepositPayloadTx(prevOutputs, p2_legacy_addr_str, 50000, 1000, p1_legacy_addr_str,
+		common.DEPOSIT_CHAIN_TYPE_APTOS, 2, aptos_receiver, []byte("memo"))
+	if err != nil {
+		t.Fatalf("Cannot make deposit tx %v", err)
+	}
+
+	// bridge + OP_RETURN + change
+	if len(tx.TxOut) != 3 || tx.TxOut[0].Value != 50000 {
+		t.Fatalf("deposit tx has unexpected outputs")
+	}
+	p, err := common.DecodeOpReturnData(tx.TxOut[1].PkScript)
+	if err != nil {
+		t.Fatalf("Cannot decode deposit data %v", err)
+	}
+	if p.ChainType != common.DEPOSIT_CHAIN_TYPE_APTOS || p.ReceiverHex() != aptos_receiver || string(p.Memo) != "memo" {
+		t.Fatalf("decoded deposit data not match %+v", p)
+	}
+}
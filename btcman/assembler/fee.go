@@ -0,0 +1,126 @@
+/*
+Fee estimation of a BTC tx.
+
+1) FeeEstimator gives a target fee rate (satoshi per virtual byte).
+2) EstimateVSize gives the virtual size of a tx before it is signed.
+
+fee = fee rate * estimated vsize
+*/
+package assembler
+
+import (
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	logger "github.com/sirupsen/logrus"
+
+	"github.com/TEENet-io/bridge-go/btcman/utxo"
+)
+
+const (
+	// Estimated vsize (vbytes) of a single input, by the type of the UTXO spent.
+	// outpoint(36) + sequence(4) + script length(1) + unlocking script (+ witness / 4)
+	P2PKH_INPUT_VSIZE  = 148 // sig(72) + compressed pubkey(33) in script sig
+	P2WPKH_INPUT_VSIZE = 68  // sig(72) + compressed pubkey(33) in witness
+	P2TR_INPUT_VSIZE   = 58  // schnorr sig(64) in witness, key path spend
+
+	TX_OVERHEAD_VSIZE     = 10 // version(4) + locktime(4) + input count(1) + output count(1)
+	SEGWIT_OVERHEAD_VSIZE = 1  // segwit marker & flag (2 bytes / 4 rounded up)
+
+	DUST_LIMIT = 546 // outputs below this amount (satoshi) are not relayed.
+)
+
+// FeeEstimator gives a target fee rate in satoshi/vbyte.
+type FeeEstimator interface {
+	EstimateFeeRate() (int64, error)
+}
+
+// StaticFeeEstimator always gives the same fee rate.
+type StaticFeeEstimator struct {
+	FeeRate int64 // satoshi/vbyte
+}
+
+func (s *StaticFeeEstimator) EstimateFeeRate() (int64, error) {
+	return s.FeeRate, nil
+}
+
+// BoundedFeeEstimator wraps a FeeEstimator (eg. the btc node)
+// If the wrapped estimator fails (eg. regtest has no fee history), use the fallback fee rate.
+// The result is capped within [MinFeeRate, MaxFeeRate].
+type BoundedFeeEstimator struct {
+	Source          FeeEstimator
+	FallbackFeeRate int64 // satoshi/vbyte
+	MinFeeRate      int64 // satoshi/vbyte
+	MaxFeeRate      int64 // satoshi/vbyte
+}
+
+func NewBoundedFeeEstimator(source FeeEstimator, fallbackFeeRate int64, minFeeRate int64, maxFeeRate int64) *BoundedFeeEstimator {
+	return &BoundedFeeEstimator{
+		Source:          source,
+		FallbackFeeRate: fallbackFeeRate,
+		MinFeeRate:      minFeeRate,
+		MaxFeeRate:      maxFeeRate,
+	}
+}
+
+// EstimateFeeRate never fails, it falls back to the static fee rate instead.
+func (b *BoundedFeeEstimator) EstimateFeeRate() (int64, error) {
+	feeRate := b.FallbackFeeRate
+	if b.Source != nil {
+		rate, err := b.Source.EstimateFeeRate()
+		if err != nil || rate <= 0 {
+			logger.WithField("fallback", b.FallbackFeeRate).Debugf("fee rate estimation unavailable, err=%v", err)
+		} else {
+			feeRate = rate
+		}
+	}
+
+	if feeRate < b.MinFeeRate {
+		feeRate = b.MinFeeRate
+	}
+	if b.MaxFeeRate > 0 && feeRate > b.MaxFeeRate {
+		feeRate = b.MaxFeeRate
+	}
+	return feeRate, nil
+}
+
+// EstimateInputVSize estimates the vsize of an input spending the given UTXO.
+// The type of the UTXO decides the size of the unlocking part:
+// NativeOperator spends P2PKH (or P2WPKH) UTXOs, SchnorrOperator spends P2TR UTXOs.
+// Unknown types are estimated as P2PKH (the largest).
+func EstimateInputVSize(prevOutput *utxo.UTXO) int64 {
+	switch txscript.GetScriptClass(prevOutput.PkScript) {
+	case txscript.WitnessV0PubKeyHashTy:
+		return P2WPKH_INPUT_VSIZE
+	case txscript.WitnessV1TaprootTy:
+		return P2TR_INPUT_VSIZE
+	default:
+		return P2PKH_INPUT_VSIZE
+	}
+}
+
+// EstimateOutputVSize gives the exact size of an output.
+// value(8) + script length(1) + script.
+func EstimateOutputVSize(txOut *wire.TxOut) int64 {
+	return int64(8 + wire.VarIntSerializeSize(uint64(len(txOut.PkScript))) + len(txOut.PkScript))
+}
+
+// EstimateVSize estimates the vsize of a tx that spends prevOutputs
+// and pays to outputs, before the tx is signed.
+func EstimateVSize(prevOutputs []*utxo.UTXO, outputs []*wire.TxOut) int64 {
+	vsize := int64(TX_OVERHEAD_VSIZE)
+	segwit := false
+	for _, item := range prevOutputs {
+		size := EstimateInputVSize(item)
+		if size != P2PKH_INPUT_VSIZE {
+			segwit = true
+		}
+		vsize += size
+	}
+	if segwit {
+		vsize += SEGWIT_OVERHEAD_VSIZE
+	}
+	for _, txOut := range outputs {
+		vsize += EstimateOutputVSize(txOut)
+	}
+	return vsize
+}
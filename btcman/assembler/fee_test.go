@@ -0,0 +1,125 @@
+package assembler
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/txscript"
+
+	"github.com/TEENet-io/bridge-go/common"
+)
+
+type failedFeeEstimator struct{}
+
+func (f *failedFeeEstimator) EstimateFeeRate() (int64, error) {
+	return 0, errors.New("insufficient data")
+}
+
+func TestBoundedFeeEstimator(t *testing.T) {
+	// fallback
+	e := NewBoundedFeeEstimator(&failedFeeEstimator{}, 10, 1, 100)
+	rate, err := e.EstimateFeeRate()
+	if err != nil || rate != 10 {
+		t.Fatalf("fallback fee rate, have %d, want %d", rate, 10)
+	}
+
+	// max cap
+	e = NewBoundedFeeEstimator(&StaticFeeEstimator{1000}, 10, 1, 100)
+	rate, _ = e.EstimateFeeRate()
+	if rate != 100 {
+		t.Fatalf("max capped fee rate, have %d, want %d", rate, 100)
+	}
+
+	// min cap
+	e = NewBoundedFeeEstimator(nil, 0, 2, 100)
+	rate, _ = e.EstimateFeeRate()
+	if rate != 2 {
+		t.Fatalf("min capped fee rate, have %d, want %d", rate, 2)
+	}
+}
+
+func TestEstimateVSizeOfRedeemTx(t *testing.T) {
+	ass, prevOutputs := newTestNativeAssembler(t, 3, 100000)
+	feeRate := int64(10)
+	tx, err := ass.MakeRedeemTx(p2_legacy_addr_str, 50000, common.RandBytes32(), p1_legacy_addr_str, feeRate, prevOutputs)
+	if err != nil {
+		t.Fatalf("Cannot make redeem tx %v", err)
+	}
+
+	// The estimation shall not be lower than the real size,
+	// and not too much higher (signatures vary by 1 byte).
+	estimated := EstimateVSize(prevOutputs, tx.TxOut)
+	actual := (blockchain.GetTransactionWeight(btcutil.NewTx(tx)) + 3) / 4
+	if estimated < actual || estimated > actual+int64(len(prevOutputs)) {
+		t.Fatalf("estimated vsize %d, actual vsize %d", estimated, actual)
+	}
+
+	// fee = fee rate * vsize
+	var out int64
+	for _, txOut := range tx.TxOut {
+		out += txOut.Value
+	}
+	if fee := 3*100000 - out; fee != feeRate*estimated {
+		t.Fatalf("fee %d, want %d", fee, feeRate*estimated)
+	}
+}
+
+func TestRedeemTxSignalsRBF(t *testing.T) {
+	ass, prevOutputs := newTestNativeAssembler(t, 1, 100000)
+	pkScript := prevOutputs[0].PkScript
+	tx, err := ass.MakeRedeemTx(p2_legacy_addr_str, 50000, common.RandBytes32(), p1_legacy_addr_str, 10, prevOutputs)
+	if err != nil {
+		t.Fatalf("Cannot make redeem tx %v", err)
+	}
+	if len(tx.TxIn) != 1 || tx.TxIn[0].Sequence != RBF_SEQUENCE {
+		t.Fatalf("redeem tx shall signal RBF")
+	}
+
+	// Signature is still valid with the custom sequence.
+	vm, err := txscript.NewEngine(pkScript, tx, 0, txscript.StandardVerifyFlags, nil, nil, 100000, nil)
+	if err != nil {
+		t.Fatalf("Cannot create script engine %v", err)
+	}
+	if err := vm.Execute(); err != nil {
+		t.Fatalf("signature invalid %v", err)
+	}
+}
+
+func TestMaxRedeemFeeRate(t *testing.T) {
+	ass, prevOutputs := newTestNativeAssembler(t, 2, 100000)
+	redeemData := common.RandBytes32()
+	dstAmount := int64(190000)
+
+	maxRate, err := ass.MaxRedeemFeeRate(p2_legacy_addr_str, dstAmount, redeemData, p1_legacy_addr_str, prevOutputs)
+	if err != nil {
+		t.Fatalf("Cannot get max fee rate %v", err)
+	}
+	if maxRate <= 0 {
+		t.Fatalf("max fee rate %d shall be positive", maxRate)
+	}
+
+	// The inputs pay the max fee rate, not a higher one.
+	if _, err := ass.MakeRedeemTx(p2_legacy_addr_str, dstAmount, redeemData, p1_legacy_addr_str, maxRate, prevOutputs); err != nil {
+		t.Fatalf("Cannot make redeem tx at the max fee rate %v", err)
+	}
+	if _, err := ass.MakeRedeemTx(p2_legacy_addr_str, dstAmount, redeemData, p1_legacy_addr_str, maxRate+1, prevOutputs); err == nil {
+		t.Fatalf("redeem tx above the max fee rate shall fail")
+	}
+
+	payouts := []RedeemPayout{
+		{DstAddr: p2_legacy_addr_str, DstAmount: dstAmount / 2, RedeemData: redeemData},
+		{DstAddr: p2_legacy_addr_str, DstAmount: dstAmount / 2, RedeemData: common.RandBytes32()},
+	}
+	maxRate, err = ass.MaxBatchRedeemFeeRate(payouts, p1_legacy_addr_str, prevOutputs)
+	if err != nil {
+		t.Fatalf("Cannot get max batch fee rate %v", err)
+	}
+	if _, err := ass.MakeBatchRedeemTx(payouts, p1_legacy_addr_str, maxRate, prevOutputs); err != nil {
+		t.Fatalf("Cannot make batch redeem tx at the max fee rate %v", err)
+	}
+	if _, err := ass.MakeBatchRedeemTx(payouts, p1_legacy_addr_str, maxRate+1, prevOutputs); err == nil {
+		t.Fatalf("batch redeem tx above the max fee rate shall fail")
+	}
+}
@@ -60,9 +60,9 @@ func (rso *SchnorrOperator) Unlock(tx *wire.MsgTx, prevOutputs []*utxo.UTXO) (*w
 	// Both tx.TxIn[] and tx.TxOut[] shall be ready, then you can create sign scripts.
 	// If they are not ready the sign will create wrong signature (won't pass the validation of node)
 	// In following step the signature script is filled with nil
-	for _, item := range prevOutputs {
-		txIn := wire.NewTxIn(wire.NewOutPoint(item.TxHash, item.Vout), nil, nil)
-		tx.AddTxIn(txIn)
+	// (unless the inputs are already added, eg. with custom sequence numbers)
+	if len(tx.TxIn) == 0 {
+		AddInputs(tx, prevOutputs, wire.MaxTxInSequenceNum)
 	}
 	// In following step signature script is filled with real stuff.
 	for idx, item := range prevOutputs {
@@ -2,7 +2,9 @@ package rpc
 
 import (
 	"fmt"
+	"math"
 
+	"github.com/btcsuite/btcd/btcjson"
 	"github.com/btcsuite/btcd/btcutil"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/btcsuite/btcd/rpcclient"
@@ -71,6 +73,20 @@ func (r *RpcClient) GetTx(TxID string) (*btcutil.Tx, error) {
 	return txRaw, nil
 }
 
+// Get the number of confirmations of a tx, 0 if it is still in the mempool.
+// Enable -txindex on your bitcoin node before using this function.
+func (r *RpcClient) GetTxConfirmations(TxID string) (int64, error) {
+	txHash, err := chainhash.NewHashFromStr(TxID)
+	if err != nil {
+		return 0, err
+	}
+	txVerbose, err := r.client.GetRawTransactionVerbose(txHash)
+	if err != nil {
+		return 0, err
+	}
+	return int64(txVerbose.Confirmations), nil
+}
+
 // Get the latest block height.
 func (r *RpcClient) GetLatestBlockHeight() (int64, error) {
 	latestHeight, err := r.client.GetBlockCount()
@@ -92,6 +108,17 @@ func (r *RpcClient) GetBlockHeightByHash(blockHash *chainhash.Hash) (int32, erro
 	return blockHeight, nil
 }
 
+// Get the hash of the block at the given height on the current best chain.
+func (r *RpcClient) GetBlockHash(height int64) (*chainhash.Hash, error) {
+	return r.client.GetBlockHash(height)
+}
+
+// Fetch a block by providing block hash.
+// Stale (orphaned) blocks can also be fetched if the node still keeps them.
+func (r *RpcClient) GetBlockByHash(blockHash *chainhash.Hash) (*wire.MsgBlock, error) {
+	return r.client.GetBlock(blockHash)
+}
+
 func reverse[T any](s []T) {
 	n := len(s)
 	for i := 0; i < n/2; i++ {
@@ -137,6 +164,36 @@ func (r *RpcClient) GetBlocks(n int, offset int) ([]*wire.MsgBlock, error) {
 	return myBlocks, nil
 }
 
+// Estimate the fee rate (satoshi/vbyte) for a tx to be mined within confTarget blocks.
+// It uses "estimatesmartfee" of the btc node.
+// It fails if the node has not enough data (eg. regtest, freshly started node).
+func (r *RpcClient) EstimateFeeRate(confTarget int64) (int64, error) {
+	res, err := r.client.EstimateSmartFee(confTarget, &btcjson.EstimateModeConservative)
+	if err != nil {
+		return 0, err
+	}
+	if res.FeeRate == nil {
+		return 0, fmt.Errorf("no fee rate estimated: %v", res.Errors)
+	}
+	// BTC/kvB -> satoshi/vB, round up.
+	return int64(math.Ceil(*res.FeeRate * 1e8 / 1000)), nil
+}
+
+// SmartFeeEstimator estimates fee rate via the btc node.
+// It implements assembler.FeeEstimator.
+type SmartFeeEstimator struct {
+	Client     *RpcClient
+	ConfTarget int64 // target blocks to be mined in.
+}
+
+func NewSmartFeeEstimator(r *RpcClient, confTarget int64) *SmartFeeEstimator {
+	return &SmartFeeEstimator{r, confTarget}
+}
+
+func (e *SmartFeeEstimator) EstimateFeeRate() (int64, error) {
+	return e.Client.EstimateFeeRate(e.ConfTarget)
+}
+
 // Get the UTXO(s) of an address.
 // Notice: You need to turn on option -txindex on bitcoin node for node to track the UTXO(s).
 // Notice: This is not very accurate, btc nodes tend to forget to track.
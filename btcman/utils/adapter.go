@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"fmt"
+
 	"github.com/btcsuite/btcd/btcutil"
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcd/txscript"
@@ -76,11 +78,11 @@ func MaybeJustTransfer(tx *wire.MsgTx, targetAddress btcutil.Address, chainParam
 }
 
 // CraftDepositAction creates a DepositAction from the given tx
+// The OP_RETURN data is either the legacy RLP (evm receiver), or a versioned deposit payload.
 func CraftDepositAction(tx *wire.MsgTx, blockHeight int32, block *wire.MsgBlock, targetAddress btcutil.Address, chainParams *chaincfg.Params) (*btcaction.DepositAction, error) {
 
 	// Decode the op_retun data
 	output2 := tx.TxOut[1]
-	// TODO: OP_RETURN data may not be in good shape
 	data, err := common.DecodeOpReturnData(output2.PkScript)
 	if err != nil {
 		return nil, err
@@ -95,8 +97,13 @@ func CraftDepositAction(tx *wire.MsgTx, blockHeight int32, block *wire.MsgBlock,
 		},
 		DepositValue:    tx.TxOut[0].Value,
 		DepositReceiver: targetAddress.EncodeAddress(),
-		EvmID:           int32(common.ByteArrayToInt(data.EVM_CHAIN_ID)),
-		EvmAddr:         common.ByteArrayToHexString(data.EVM_ADDR),
+		EvmID:           int32(data.ChainID),
+		ChainType:       common.ChainTypeName(data.ChainType),
+		Receiver:        data.ReceiverHex(),
+		Memo:            common.ByteSliceToPureHexStr(data.Memo),
+	}
+	if data.ChainType == common.DEPOSIT_CHAIN_TYPE_EVM {
+		deposit.EvmAddr = deposit.Receiver
 	}
 
 	return deposit, nil
@@ -134,3 +141,31 @@ func MaybeRedeemTx(tx *wire.MsgTx, changeAddress btcutil.Address, chainParams *c
 
 	return flag_op && flag_to_us
 }
+
+// InputAddress finds the btc address that funds the given tx input (eg. where to refund a deposit).
+// prevOut is the output spent by the input, if known, its PkScript tells the address.
+// Otherwise the address is derived from the pubkey revealed by the input,
+// which works for P2WPKH (witness) and P2PKH (signature script) inputs.
+func InputAddress(txIn *wire.TxIn, prevOut *wire.TxOut, chainParams *chaincfg.Params) (btcutil.Address, error) {
+	if prevOut != nil {
+		_, addresses, _, err := txscript.ExtractPkScriptAddrs(prevOut.PkScript, chainParams)
+		if err == nil && len(addresses) == 1 {
+			return addresses[0], nil
+		}
+	}
+
+	// P2WPKH: witness = <sig> <pubkey>
+	if len(txIn.Witness) == 2 && len(txIn.Witness[1]) == 33 {
+		return btcutil.NewAddressWitnessPubKeyHash(btcutil.Hash160(txIn.Witness[1]), chainParams)
+	}
+
+	// P2PKH: sigScript = <sig> <pubkey>
+	if len(txIn.Witness) == 0 {
+		pushes, err := txscript.PushedData(txIn.SignatureScript)
+		if err == nil && len(pushes) == 2 && (len(pushes[1]) == 33 || len(pushes[1]) == 65) {
+			return btcutil.NewAddressPubKeyHash(btcutil.Hash160(pushes[1]), chainParams)
+		}
+	}
+
+	return nil, fmt.Errorf("cannot find the address of input %s", txIn.PreviousOutPoint.String())
+}
@@ -197,7 +197,7 @@ func rmFile(name string) {
 }
 
 // Set up the BTC Monitor
-func setupBtcMonitor(t *testing.T, r *rpc.RpcClient, st btcaction.RedeemActionStorage, startBlock int) (*BTCMonitor, error) {
+func setupBtcMonitor(t *testing.T, r *rpc.RpcClient, st btcaction.RedeemActionStorage, bst btcaction.ScannedBlockStorage, startBlock int) (*BTCMonitor, error) {
 
 	// Create a new monitor instance
 	// monitor on p3
@@ -207,6 +207,7 @@ func setupBtcMonitor(t *testing.T, r *rpc.RpcClient, st btcaction.RedeemActionSt
 		r,
 		int64(startBlock),
 		st,
+		bst,
 	)
 	if err != nil {
 		t.Fatalf("cannot create monitor %v", err)
@@ -269,7 +270,7 @@ func newTestEnv(t *testing.T, file string, btcChainConfig *chaincfg.Params, btcU
 	assert.NoError(t, err)
 
 	// create a eth2btc state db
-	statedb, err := state.NewStateDB(sqldb)
+	statedb, err := state.NewStateDB(sqldb, state.ChainNamespace(sharedcommon.DEPOSIT_CHAIN_TYPE_EVM, chainID.Uint64()))
 	assert.NoError(t, err)
 
 	// create a eth2btc state from the eth2btc statedb
@@ -415,7 +416,11 @@ func TestDeposit(t *testing.T) {
 	// Setup the btc monitor
 	latest_height, _ := r.GetLatestBlockHeight()
 	// Attention: we start from the latest block height on BTC for clean slate.
-	monitor, err := setupBtcMonitor(t, r, btc_mgr_st, int(latest_height))
+	blk_st, err := btcaction.NewSQLiteScannedBlockStorage(db_file_name)
+	if err != nil {
+		t.Fatalf("cannot create scanned block storage %v", err)
+	}
+	monitor, err := setupBtcMonitor(t, r, btc_mgr_st, blk_st, int(latest_height))
 	if err != nil {
 		t.Fatalf("cannot create monitor, %v", err)
 	}
@@ -14,6 +14,7 @@ Once an interested action is found, the monitor will notify all the observers.
 
 import (
 	"fmt"
+	"math/big"
 	"time"
 
 	logger "github.com/sirupsen/logrus"
@@ -21,8 +22,12 @@ import (
 	"github.com/TEENet-io/bridge-go/btcaction"
 	"github.com/TEENet-io/bridge-go/btcman/rpc"
 	myutils "github.com/TEENet-io/bridge-go/btcman/utils"
+	"github.com/TEENet-io/bridge-go/btcvault"
+	"github.com/btcsuite/btcd/blockchain"
 	"github.com/btcsuite/btcd/btcutil"
 	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
 )
 
 // expose functions to let caller to register various observers before loop start.
@@ -35,6 +40,7 @@ const (
 	BLK_MATURE_OFFSET     = 1               // ? blocks old we consider finalized
 	SCAN_BTC_BLK_INTERVAL = 3 * time.Second // ? time between we scan the BTC blockchain.
 	RETRO_SCAN_BLOCKS     = 36              // ? blocks to scan (to counter the BTC not producing situation).
+	MAX_REORG_DEPTH       = 144             // ? blocks of hash chain we keep to detect reorgs (1 day).
 )
 
 type BTCMonitor struct {
@@ -42,8 +48,57 @@ type BTCMonitor struct {
 	LastVistedBlockHeight int64            // last btc block height visited
 	ChainConfig           *chaincfg.Params // which btc chain
 	Publisher             *PublisherService
-	RpcClient             *rpc.RpcClient                // rpc client to interact with btc node
-	mgrState              btcaction.RedeemActionStorage // tracker of redeems.
+	RpcClient             *rpc.RpcClient                       // rpc client to interact with btc node
+	mgrState              btcaction.RedeemActionStorage        // tracker of redeems.
+	blockStorage          btcaction.ScannedBlockStorage        // hash chain of scanned blocks.
+	finalizedBlockCh      chan<- *big.Int                      // (optional) persists LastVistedBlockHeight, see state.GetNewBtcFinalizedBlockChannel()
+	depositFilter         func(*btcaction.DepositAction) error // (optional) rejected deposits are refunded, see SetDepositFilter()
+}
+
+// craftMalformedDeposit creates the refund of a malformed deposit tx (output #0 pays to us).
+// The refund goes to the address of the first input.
+func (m *BTCMonitor) craftMalformedDeposit(tx *wire.MsgTx, blockHeight int32, block *wire.MsgBlock, reason string) ObservedMalformedDeposit {
+	basic := btcaction.Basic{
+		BlockNumber: int(blockHeight),
+		BlockHash:   block.BlockHash().String(),
+		TxHash:      tx.TxHash().String(),
+	}
+
+	refundAddr := ""
+	addr, err := m.refundAddress(tx)
+	if err != nil {
+		logger.WithField("btcTxId", basic.TxHash).Warnf("cannot find refund address of malformed deposit: %v", err)
+		reason = fmt.Sprintf("%s; no refund address: %v", reason, err)
+	} else {
+		refundAddr = addr.EncodeAddress()
+	}
+
+	return ObservedMalformedDeposit{
+		Refund:   NewRefundAction(basic, 0, tx.TxOut[0].Value, refundAddr, reason, time.Now()),
+		PkScript: tx.TxOut[0].PkScript,
+	}
+}
+
+// refundAddress finds the address that funds the first input of the tx.
+func (m *BTCMonitor) refundAddress(tx *wire.MsgTx) (btcutil.Address, error) {
+	if len(tx.TxIn) == 0 {
+		return nil, fmt.Errorf("tx has no input")
+	}
+	txIn := tx.TxIn[0]
+
+	var prevOut *wire.TxOut
+	prevTx, err := m.RpcClient.GetTx(txIn.PreviousOutPoint.Hash.String())
+	if err == nil && int(txIn.PreviousOutPoint.Index) < len(prevTx.MsgTx().TxOut) {
+		prevOut = prevTx.MsgTx().TxOut[txIn.PreviousOutPoint.Index]
+	}
+	addr, err := myutils.InputAddress(txIn, prevOut, m.ChainConfig)
+	if err != nil {
+		return nil, err
+	}
+	if addr.EncodeAddress() == m.BridgeBTCAddress.EncodeAddress() {
+		return nil, fmt.Errorf("tx is funded by the bridge itself")
+	}
+	return addr, nil
 }
 
 // Given a BTC transaction ID, finds a record in the database
@@ -61,19 +116,32 @@ func (m *BTCMonitor) QueryRedeemTxFromMgrDB(btcTxID string) bool {
 	return true
 }
 
-// FinishRedeem marks a redeem as completed in the mgr database
-func (m *BTCMonitor) FinishRedeem(btcTxID string) string {
-	record, _ := m.mgrState.QueryByBtcTxId(btcTxID)
-	logger.WithField("reqTxHash", record.EthRequestTxID).Debug("Complete Redeem Action Triggered")
-	_ = m.mgrState.CompleteRedeem(record.EthRequestTxID)
-
-	record, _ = m.mgrState.QueryByEthRequestTxId(record.EthRequestTxID)
-	logger.WithField("record", record).Debug("Redeem Action Record")
+// FinishRedeem marks the redeem(s) paid by the btc tx as completed in the mgr database
+// btcTxID can be the original redeem tx or any replacement (BIP-125) of it.
+// A batch redeem tx completes all the redeems of the batch.
+// Returns the eth request tx hashes of the completed redeems.
+func (m *BTCMonitor) FinishRedeem(btcTxID string) []string {
+	records, err := m.mgrState.QueryAllByBtcTxId(btcTxID)
+	if err != nil {
+		logger.WithField("btcTxId", btcTxID).Errorf("failed to query redeems by btc tx: %v", err)
+		return nil
+	}
+	logger.WithFields(logger.Fields{
+		"btcTxId": btcTxID,
+		"num":     len(records),
+	}).Debug("Complete Redeem Action Triggered")
+	if err := m.mgrState.CompleteRedeemByBtcTxId(btcTxID); err != nil {
+		logger.WithField("btcTxId", btcTxID).Errorf("failed to complete redeems: %v", err)
+	}
 
-	return record.EthRequestTxID
+	var reqTxHashes []string
+	for _, record := range records {
+		reqTxHashes = append(reqTxHashes, record.EthRequestTxID)
+	}
+	return reqTxHashes
 }
 
-func NewBTCMonitor(addressStr string, chainConfig *chaincfg.Params, rpcClient *rpc.RpcClient, startBlock int64, mgrState btcaction.RedeemActionStorage) (*BTCMonitor, error) {
+func NewBTCMonitor(addressStr string, chainConfig *chaincfg.Params, rpcClient *rpc.RpcClient, startBlock int64, mgrState btcaction.RedeemActionStorage, blockStorage btcaction.ScannedBlockStorage) (*BTCMonitor, error) {
 	_address, err := btcutil.DecodeAddress(addressStr, chainConfig)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decode address: %v", err)
@@ -85,9 +153,129 @@ func NewBTCMonitor(addressStr string, chainConfig *chaincfg.Params, rpcClient *r
 		Publisher:             NewPublisherService(),
 		RpcClient:             rpcClient,
 		mgrState:              mgrState,
+		blockStorage:          blockStorage,
 	}, nil
 }
 
+// SetFinalizedBlockChannel lets the monitor report its scan cursor (LastVistedBlockHeight)
+// to the channel after each round, so the cursor survives a restart.
+// Call it before ScanLoop().
+func (m *BTCMonitor) SetFinalizedBlockChannel(ch chan<- *big.Int) {
+	m.finalizedBlockCh = ch
+}
+
+// SetDepositFilter sets a check on each deposit, eg. chainregistry.Registry.Accept().
+// A deposit rejected by the filter (eg. naming an unknown destination chain)
+// is not published as a deposit, but parked for refund as a malformed one.
+func (m *BTCMonitor) SetDepositFilter(filter func(*btcaction.DepositAction) error) {
+	m.depositFilter = filter
+}
+
+// saveCursor reports LastVistedBlockHeight to the finalized block channel, if any.
+func (m *BTCMonitor) saveCursor() {
+	if m.finalizedBlockCh == nil {
+		return
+	}
+	m.finalizedBlockCh <- big.NewInt(m.LastVistedBlockHeight)
+}
+
+// detectReorg walks back the recorded hash chain from the highest scanned block,
+// compares each recorded hash with the hash on the current best chain.
+// Returns the orphaned blocks (new to old), empty if no reorg happened.
+func (m *BTCMonitor) detectReorg() ([]*btcaction.ScannedBlock, error) {
+	highest, err := m.blockStorage.GetHighestScannedBlock()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get highest scanned block: %v", err)
+	}
+	if highest == nil {
+		return nil, nil
+	}
+
+	latestBlockHeight, err := m.RpcClient.GetLatestBlockHeight()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest block height: %v", err)
+	}
+
+	var orphaned []*btcaction.ScannedBlock
+	for height := highest.BlockNumber; height > highest.BlockNumber-MAX_REORG_DEPTH && height >= 0; height-- {
+		recorded, err := m.blockStorage.GetScannedBlock(height)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get scanned block %d: %v", height, err)
+		}
+		if recorded == nil {
+			// gap in records (blocks without tx are skipped), keep walking.
+			continue
+		}
+
+		// The best chain can be shorter than what we scanned.
+		if int64(height) > latestBlockHeight {
+			orphaned = append(orphaned, recorded)
+			continue
+		}
+
+		hash, err := m.RpcClient.GetBlockHash(int64(height))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get block hash of %d: %v", height, err)
+		}
+		if hash.String() == recorded.BlockHash {
+			return orphaned, nil // fork point found.
+		}
+		orphaned = append(orphaned, recorded)
+	}
+
+	if len(orphaned) > 0 {
+		logger.WithFields(logger.Fields{
+			"highest":       highest.BlockNumber,
+			"maxReorgDepth": MAX_REORG_DEPTH,
+		}).Error("Reorg deeper than the recorded hash chain (btc)")
+	}
+	return orphaned, nil
+}
+
+// rollback notifies observers the orphaned blocks (new to old),
+// then forgets them, so the blocks on the new best chain are scanned again.
+func (m *BTCMonitor) rollback(orphaned []*btcaction.ScannedBlock) error {
+	for _, blk := range orphaned {
+		rb := ObservedRollback{
+			BlockNumber: int32(blk.BlockNumber),
+			BlockHash:   blk.BlockHash,
+		}
+
+		// The node usually keeps the stale block, so we know the txs inside.
+		hash, err := chainhash.NewHashFromStr(blk.BlockHash)
+		if err == nil {
+			var block *wire.MsgBlock
+			block, err = m.RpcClient.GetBlockByHash(hash)
+			if err == nil {
+				for _, tx := range block.Transactions {
+					rb.TxIDs = append(rb.TxIDs, tx.TxHash().String())
+				}
+			}
+		}
+		if err != nil {
+			logger.WithField("blockHash", blk.BlockHash).Warnf("failed to fetch orphaned block, txs unknown: %v", err)
+		}
+
+		logger.WithFields(logger.Fields{
+			"blkNum":    blk.BlockNumber,
+			"blockHash": blk.BlockHash,
+		}).Warn("Orphaned block found (btc)")
+
+		// Notify Observers
+		m.Publisher.NotifyRollback(rb)
+	}
+
+	forkPoint := orphaned[len(orphaned)-1].BlockNumber - 1
+	if err := m.blockStorage.DeleteScannedBlocksAbove(forkPoint); err != nil {
+		return fmt.Errorf("failed to delete scanned blocks above %d: %v", forkPoint, err)
+	}
+	if int64(forkPoint) < m.LastVistedBlockHeight {
+		m.LastVistedBlockHeight = int64(forkPoint)
+		m.saveCursor()
+	}
+	return nil
+}
+
 // Scan represents a signle round of scanning the blockchain
 // Scan for blocks,
 // Scan each block for txs.
@@ -96,6 +284,17 @@ func NewBTCMonitor(addressStr string, chainConfig *chaincfg.Params, rpcClient *r
 func (m *BTCMonitor) Scan() error {
 	// Scrap blockchain
 
+	// Before scanning new blocks, make sure what we scanned is still on the best chain.
+	orphaned, err := m.detectReorg()
+	if err != nil {
+		return err
+	}
+	if len(orphaned) > 0 {
+		// Blocks of the new best chain are scanned in the next round,
+		// after observers have processed the rollbacks.
+		return m.rollback(orphaned)
+	}
+
 	// Fetch and compare lateset blocks with local records
 	latestBlockHeight, err := m.RpcClient.GetLatestBlockHeight()
 	if err != nil {
@@ -105,12 +304,15 @@ func (m *BTCMonitor) Scan() error {
 	logger.WithField("btc latest blk", latestBlockHeight).Debug("Check BTC blockchain")
 	logger.WithField("last visited blk", m.LastVistedBlockHeight).Debug("From memory")
 
+	// Only blocks at least <BLK_MATURE_OFFSET> old are scanned.
+	finalizedBlockHeight := latestBlockHeight - BLK_MATURE_OFFSET
+
 	// If no new blocks to scan.
-	if latestBlockHeight <= m.LastVistedBlockHeight {
+	if finalizedBlockHeight <= m.LastVistedBlockHeight {
 		return nil // no blocks to scan. and no error
 	}
 
-	numbersToFetch := latestBlockHeight - m.LastVistedBlockHeight - BLK_MATURE_OFFSET
+	numbersToFetch := finalizedBlockHeight - m.LastVistedBlockHeight
 
 	// Sometimes BTC blockchain can clog,
 	// for at least 6 hours (observed in testnet4),
@@ -119,6 +321,10 @@ func (m *BTCMonitor) Scan() error {
 	if numbersToFetch < RETRO_SCAN_BLOCKS {
 		numbersToFetch = RETRO_SCAN_BLOCKS
 	}
+	// Can't go beyond the genesis block.
+	if numbersToFetch > finalizedBlockHeight+1 {
+		numbersToFetch = finalizedBlockHeight + 1
+	}
 
 	logger.WithFields(logger.Fields{
 		"latestBlockHeight":     latestBlockHeight,
@@ -128,6 +334,9 @@ func (m *BTCMonitor) Scan() error {
 	}).Info("Scanning blocks (btc)")
 
 	blocks, err := m.RpcClient.GetBlocks(int(numbersToFetch), BLK_MATURE_OFFSET)
+	if err != nil {
+		return fmt.Errorf("failed to get finalized blocks: %v", err)
+	}
 
 	for _, block := range blocks {
 		// skip no transaction blocks
@@ -144,89 +353,168 @@ func (m *BTCMonitor) Scan() error {
 			continue
 		}
 		logger.WithField("blkNum", blockHeight).Info("Inspect block (btc)")
-		// Go for each Tx, look for Tx that is interested to us.
-		// In general we care about three things:
-		// 1) The output(s) of the Tx, does it form a valid <bridge deposit>?
-		// 2) The output(s) of the Tx, does it form a valid UTXO so we can spend in the future?
-		// 3) The Tx is a redeem BTC tx that we sent?
-		for _, tx := range block.Transactions {
-
-			// 1) check if the BTC tx is a <bridge deposit>
-			maybe_deposit := myutils.MaybeDepositTx(tx, m.BridgeBTCAddress, m.ChainConfig)
-			if maybe_deposit {
-				deposit, err := myutils.CraftDepositAction(tx, blockHeight, block, m.BridgeBTCAddress, m.ChainConfig)
-				if err != nil {
-					logger.WithFields(logger.Fields{
-						"blockNum": blockHeight,
-						"btcTxId":  tx.TxHash(),
-					}).Warnf("failed to craft deposit_action from a maybe_deposit: %v", err)
-					//TODO: shall add REFUND BTC logic here if user actually mal-formed the deposit data.
-				} else {
-					logger.WithFields(logger.Fields{
-						"blockNum": blockHeight,
-						"btcTxId":  deposit.TxHash,
-					}).Info("Deposit Found (BTC)")
-					// Notify Observers
-					m.Publisher.NotifyDeposit(*deposit)
-				}
-			}
 
-			// Whether or not <bridge deposit>
-			// 2) We fetch ALL the UTXOs that is sending money to us (the bridge)
-			transfers := myutils.MaybeJustTransfer(tx, m.BridgeBTCAddress, m.ChainConfig)
-			if len(transfers) > 0 {
-				for _, transfer := range transfers {
-					logger.WithFields(logger.Fields{
-						"blockNum": blockHeight,
-						"btcTxId":  tx.TxHash().String(),
-						"vout":     transfer.Vout,
-						"amount":   transfer.Amount,
-					}).Info("Transfer Found (BTC)")
-
-					observedUTXO := &ObservedUTXO{
-						BlockNumber: blockHeight,
-						BlockHash:   block.BlockHash().String(),
-						TxID:        tx.TxHash().String(),
-						Vout:        int32(transfer.Vout),
-						Amount:      transfer.Amount,
-						PkScript:    tx.TxOut[transfer.Vout].PkScript,
-					}
-
-					// Notify Observers
-					m.Publisher.NotifyUTXO(*observedUTXO)
-				}
-			}
+		if err := m.scanBlock(block, blockHeight); err != nil {
+			return err
+		}
+	}
+	// forget the hash chain too old to be reorged.
+	if err := m.blockStorage.DeleteScannedBlocksBelow(int(latestBlockHeight) - MAX_REORG_DEPTH); err != nil {
+		logger.Warnf("failed to prune scanned blocks: %v", err)
+	}
+
+	// update the last visited block height
+	m.LastVistedBlockHeight = finalizedBlockHeight
+	m.saveCursor()
+	return nil
+}
+
+// ScanRange scans the blocks in [fromBlock, toBlock] once, in order.
+// It is used to explicitly rescan a range of blocks (eg. missed deposits),
+// it does not move the scan cursor (LastVistedBlockHeight).
+func (m *BTCMonitor) ScanRange(fromBlock int64, toBlock int64) error {
+	if fromBlock < 0 || toBlock < fromBlock {
+		return fmt.Errorf("invalid rescan range: from=%d, to=%d", fromBlock, toBlock)
+	}
 
-			// check if the BTC tx matches a bridge withdraw in our managment state.
-			// if so, set the redeem state of mgr state to be minted.
-			// notify observers to set the state on core shared state.
-			_btc_txid := tx.TxHash().String()
-			if m.QueryRedeemTxFromMgrDB(_btc_txid) {
+	logger.WithFields(logger.Fields{
+		"from": fromBlock,
+		"to":   toBlock,
+	}).Info("Rescanning blocks (btc)")
+
+	for height := fromBlock; height <= toBlock; height++ {
+		hash, err := m.RpcClient.GetBlockHash(height)
+		if err != nil {
+			return fmt.Errorf("failed to get block hash of %d: %v", height, err)
+		}
+		block, err := m.RpcClient.GetBlockByHash(hash)
+		if err != nil {
+			return fmt.Errorf("failed to get block %d: %v", height, err)
+		}
+		logger.WithField("blkNum", height).Info("Inspect block (btc)")
 
+		if err := m.scanBlock(block, int32(height)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scanBlock records the block in the hash chain,
+// then scans each tx for related Deposit/Transfer/Redeem actions.
+func (m *BTCMonitor) scanBlock(block *wire.MsgBlock, blockHeight int32) error {
+	// record the hash chain to detect reorg.
+	err := m.blockStorage.AddScannedBlock(btcaction.ScannedBlock{
+		BlockNumber: int(blockHeight),
+		BlockHash:   block.BlockHash().String(),
+		PrevHash:    block.Header.PrevBlock.String(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record scanned block %d: %v", blockHeight, err)
+	}
+
+	// Go for each Tx, look for Tx that is interested to us.
+	// In general we care about three things:
+	// 1) The output(s) of the Tx, does it form a valid <bridge deposit>?
+	// 2) The output(s) of the Tx, does it form a valid UTXO so we can spend in the future?
+	// 3) The Tx is a redeem BTC tx that we sent?
+	// Besides, the inputs of all the txs are published, to find the spent UTXOs of ours.
+	spends := ObservedSpends{
+		BlockNumber: blockHeight,
+		BlockHash:   block.BlockHash().String(),
+	}
+	for _, tx := range block.Transactions {
+		if !blockchain.IsCoinBaseTx(tx) {
+			for vin, txIn := range tx.TxIn {
+				spends.Spends = append(spends.Spends, btcvault.SpentUTXO{
+					RelatedTxID: txIn.PreviousOutPoint.Hash.String(),
+					RelatedVout: int32(txIn.PreviousOutPoint.Index),
+					BlockNumber: blockHeight,
+					BlockHash:   spends.BlockHash,
+					TxID:        tx.TxHash().String(),
+					Vin:         int32(vin),
+				})
+			}
+		}
+
+		// 1) check if the BTC tx is a <bridge deposit>
+		maybe_deposit := myutils.MaybeDepositTx(tx, m.BridgeBTCAddress, m.ChainConfig)
+		if maybe_deposit {
+			deposit, err := myutils.CraftDepositAction(tx, blockHeight, block, m.BridgeBTCAddress, m.ChainConfig)
+			if err == nil && m.depositFilter != nil {
+				err = m.depositFilter(deposit)
+			}
+			if err != nil {
 				logger.WithFields(logger.Fields{
 					"blockNum": blockHeight,
-					"btcTxId":  _btc_txid,
-				}).Info("Redeem BTC Tx Found")
+					"btcTxId":  tx.TxHash(),
+				}).Warnf("cannot accept deposit_action from a maybe_deposit: %v", err)
+				// The user mal-formed the deposit data (or named an unknown chain), refund it.
+				m.Publisher.NotifyMalformedDeposit(m.craftMalformedDeposit(tx, blockHeight, block, err.Error()))
+			} else {
+				logger.WithFields(logger.Fields{
+					"blockNum": blockHeight,
+					"btcTxId":  deposit.TxHash,
+				}).Info("Deposit Found (BTC)")
+				// Notify Observers
+				m.Publisher.NotifyDeposit(*deposit)
+			}
+		}
 
-				reqTxHash := m.FinishRedeem(_btc_txid)
+		// Whether or not <bridge deposit>
+		// 2) We fetch ALL the UTXOs that is sending money to us (the bridge)
+		// (including the change of our own redeem & consolidation txs)
+		transfers := myutils.MaybeJustTransfer(tx, m.BridgeBTCAddress, m.ChainConfig)
+		if len(transfers) > 0 {
+			for _, transfer := range transfers {
+				logger.WithFields(logger.Fields{
+					"blockNum": blockHeight,
+					"btcTxId":  tx.TxHash().String(),
+					"vout":     transfer.Vout,
+					"amount":   transfer.Amount,
+				}).Info("Transfer Found (BTC)")
+
+				observedUTXO := &ObservedUTXO{
+					BlockNumber: blockHeight,
+					BlockHash:   block.BlockHash().String(),
+					TxID:        tx.TxHash().String(),
+					Vout:        int32(transfer.Vout),
+					Amount:      transfer.Amount,
+					PkScript:    tx.TxOut[transfer.Vout].PkScript,
+				}
 
 				// Notify Observers
+				m.Publisher.NotifyUTXO(*observedUTXO)
+			}
+		}
+
+		// check if the BTC tx matches a bridge withdraw in our managment state.
+		// if so, set the redeem state of mgr state to be minted.
+		// notify observers to set the state on core shared state.
+		_btc_txid := tx.TxHash().String()
+		if m.QueryRedeemTxFromMgrDB(_btc_txid) {
+
+			logger.WithFields(logger.Fields{
+				"blockNum": blockHeight,
+				"btcTxId":  _btc_txid,
+			}).Info("Redeem BTC Tx Found")
+
+			// Notify Observers, once for each redeem of the (batch) tx.
+			for _, reqTxHash := range m.FinishRedeem(_btc_txid) {
 				m.Publisher.NotifyRedeemIsDone(btcaction.RedeemAction{
 					EthRequestTxID: reqTxHash,
 					BtcHash:        _btc_txid,
 					Sent:           true,
 					Mined:          true,
 				})
-				continue
 			}
+			continue
 		}
 	}
-	if err != nil {
-		return fmt.Errorf("failed to get finalized blocks: %v", err)
-	}
 
-	// update the last visited block height
-	m.LastVistedBlockHeight = latestBlockHeight
+	if len(spends.Spends) > 0 {
+		m.Publisher.NotifySpends(spends)
+	}
 	return nil
 }
 
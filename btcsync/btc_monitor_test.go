@@ -0,0 +1,102 @@
+package btcsync
+
+import (
+	"database/sql"
+	"math/big"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/TEENet-io/bridge-go/btcaction"
+	"github.com/TEENet-io/bridge-go/chainregistry"
+	sharedcommon "github.com/TEENet-io/bridge-go/common"
+	"github.com/TEENet-io/bridge-go/state"
+)
+
+// noRedeemStorage knows no redeem, none of the scanned txs is ours.
+type noRedeemStorage struct {
+	btcaction.RedeemActionStorage
+}
+
+func (noRedeemStorage) QueryByBtcTxId(btcTxID string) (*btcaction.RedeemAction, error) {
+	return nil, nil
+}
+
+// depositTx pays amount to the bridge, for the receiver on chainType/chainID.
+// It has no input, so no refund address either.
+func depositTx(t *testing.T, bridge btcutil.Address, chainType byte, chainID uint32, amount int64) *wire.MsgTx {
+	payTo, err := txscript.PayToAddrScript(bridge)
+	assert.NoError(t, err)
+	data, err := sharedcommon.MakeDepositPayloadOpReturnData(chainType, chainID, sharedcommon.ByteSliceToPureHexStr(sharedcommon.RandBytes(32)), nil)
+	assert.NoError(t, err)
+	opReturn, err := txscript.NullDataScript(data)
+	assert.NoError(t, err)
+
+	tx := wire.NewMsgTx(wire.TxVersion)
+	tx.AddTxOut(wire.NewTxOut(amount, payTo))
+	tx.AddTxOut(wire.NewTxOut(0, opReturn))
+	return tx
+}
+
+func TestScanBlockRefundsUnknownChain(t *testing.T) {
+	sqlDB, err := sql.Open("sqlite3", ":memory:")
+	assert.NoError(t, err)
+	defer sqlDB.Close()
+	statedb, err := state.NewStateDB(sqlDB, state.ChainNamespace(sharedcommon.DEPOSIT_CHAIN_TYPE_APTOS, 1))
+	assert.NoError(t, err)
+	st, err := state.New(statedb, &state.StateConfig{ChannelSize: 1, UniqueChainId: big.NewInt(1)})
+	assert.NoError(t, err)
+	registry := chainregistry.New()
+	assert.NoError(t, registry.Register(&chainregistry.DestChain{
+		ChainType: sharedcommon.DEPOSIT_CHAIN_TYPE_APTOS,
+		ChainID:   1,
+		State:     st,
+		StateDB:   statedb,
+	}))
+
+	blockStorage, err := btcaction.NewSQLiteScannedBlockStorage(filepath.Join(t.TempDir(), "blocks.db"))
+	assert.NoError(t, err)
+	bridge, err := btcutil.NewAddressWitnessPubKeyHash(sharedcommon.RandBytes(20), &chaincfg.RegressionNetParams)
+	assert.NoError(t, err)
+	m := &BTCMonitor{
+		BridgeBTCAddress: bridge,
+		ChainConfig:      &chaincfg.RegressionNetParams,
+		Publisher:        NewPublisherService(),
+		mgrState:         noRedeemStorage{},
+		blockStorage:     blockStorage,
+	}
+	m.SetDepositFilter(registry.Accept)
+	deposits := make(chan btcaction.DepositAction, 2)
+	malformed := make(chan ObservedMalformedDeposit, 2)
+	m.Publisher.RegisterDepositObserver(deposits)
+	m.Publisher.RegisterMalformedDepositObserver(malformed)
+
+	known := depositTx(t, bridge, sharedcommon.DEPOSIT_CHAIN_TYPE_APTOS, 1, 100000)
+	unknown := depositTx(t, bridge, sharedcommon.DEPOSIT_CHAIN_TYPE_APTOS, 2, 200000)
+	block := wire.NewMsgBlock(&wire.BlockHeader{})
+	assert.NoError(t, block.AddTransaction(known))
+	assert.NoError(t, block.AddTransaction(unknown))
+	assert.NoError(t, m.scanBlock(block, 1))
+
+	// the registered chain gets its deposit
+	assert.Len(t, deposits, 1)
+	deposit := <-deposits
+	assert.Equal(t, known.TxHash().String(), deposit.TxHash)
+	assert.Equal(t, "aptos", deposit.ChainType)
+	assert.Equal(t, int32(1), deposit.EvmID)
+
+	// the unknown chain's deposit is parked for refund
+	assert.Len(t, malformed, 1)
+	parked := <-malformed
+	assert.Equal(t, unknown.TxHash().String(), parked.Refund.TxHash)
+	assert.Equal(t, 0, parked.Refund.Vout)
+	assert.Equal(t, int64(200000), parked.Refund.Amount)
+	assert.True(t, strings.Contains(parked.Refund.Reason, chainregistry.ErrUnknownChain.Error()), parked.Refund.Reason)
+	assert.Equal(t, unknown.TxOut[0].PkScript, parked.PkScript)
+}
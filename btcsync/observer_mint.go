@@ -8,7 +8,10 @@ package btcsync
 import (
 	"math/big"
 
+	logger "github.com/sirupsen/logrus"
+
 	"github.com/TEENet-io/bridge-go/btcaction"
+	"github.com/TEENet-io/bridge-go/chainregistry"
 	"github.com/TEENet-io/bridge-go/common"
 	"github.com/TEENet-io/bridge-go/state"
 	ethcommon "github.com/ethereum/go-ethereum/common"
@@ -34,8 +37,8 @@ func (s *BTC2EVMObserver) GetNotifiedDeposit() {
 		// type conversion
 		m := state.Mint{
 			BtcTxId:    ethcommon.HexToHash(data.Basic.TxHash),
-			MintTxHash: common.EmptyHash, // this field is empty until ETH side mints TWBTC.
-			Receiver:   ethcommon.HexToAddress(data.EvmAddr).Bytes(),
+			MintTxHash: common.EmptyHash,                        // this field is empty until ETH side mints TWBTC.
+			Receiver:   common.HexStrToByteSlice(data.Receiver), // 20 bytes on evm, 32 bytes on aptos
 			Amount:     new(big.Int).SetInt64(data.DepositValue),
 		}
 
@@ -43,3 +46,46 @@ func (s *BTC2EVMObserver) GetNotifiedDeposit() {
 		s.sharedState.SetNewBTC2EVMMint(&m)
 	}
 }
+
+// BTC2ChainObserver writes the "mint" table in the shared state
+// of the destination chain named by each deposit (see chainregistry.Registry).
+type BTC2ChainObserver struct {
+	registry *chainregistry.Registry
+	Ch       chan btcaction.DepositAction // communication channel
+}
+
+func NewBTC2ChainObserver(registry *chainregistry.Registry, bufferSize int) *BTC2ChainObserver {
+	return &BTC2ChainObserver{
+		registry: registry,
+		Ch:       make(chan btcaction.DepositAction, bufferSize),
+	}
+}
+
+// GetNotifiedDeposit implements the DepositObserver interface
+// You should call it as a separate goroutine (with go)
+func (s *BTC2ChainObserver) GetNotifiedDeposit() {
+	for data := range s.Ch {
+		fields := logger.Fields{
+			"btcTxId":  data.TxHash,
+			"receiver": data.Receiver,
+		}
+		// Unknown chains are filtered by the monitor (parked for refund), shall not happen.
+		chain, err := s.registry.Route(&data)
+		if err != nil {
+			logger.WithFields(fields).Errorf("cannot route deposit: %v", err)
+			continue
+		}
+
+		m := state.Mint{
+			BtcTxId:    ethcommon.HexToHash(data.Basic.TxHash),
+			MintTxHash: common.EmptyHash, // this field is empty until the destination chain mints TWBTC.
+			Receiver:   common.HexStrToByteSlice(data.Receiver),
+			Amount:     new(big.Int).SetInt64(data.DepositValue),
+		}
+		if err := chain.State.SetNewBTC2EVMMint(&m); err != nil {
+			logger.WithFields(fields).Errorf("failed to write mint to %s: %v", chain.Key(), err)
+			continue
+		}
+		logger.WithFields(fields).WithField("chain", chain.Key()).Debug("Deposit routed")
+	}
+}
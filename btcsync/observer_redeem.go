@@ -1,17 +1,24 @@
 package btcsync
 
 import (
+	logger "github.com/sirupsen/logrus"
+
 	"github.com/TEENet-io/bridge-go/btcaction"
-	"github.com/TEENet-io/bridge-go/state"
 	ethcommon "github.com/ethereum/go-ethereum/common"
 )
 
+// RedeemState is the shared state to complete redeems in,
+// a single state.State, or a chainregistry.Registry of several destination chains.
+type RedeemState interface {
+	SetRedeemCompleted(ethReqTxHash ethcommon.Hash, btcTxHash ethcommon.Hash) error
+}
+
 type RedeemObserver struct {
-	sharedState *state.State
+	sharedState RedeemState
 	Ch          chan btcaction.RedeemAction // communication channel
 }
 
-func NewRedeemObserver(state *state.State, bufferSize int) *RedeemObserver {
+func NewRedeemObserver(state RedeemState, bufferSize int) *RedeemObserver {
 	return &RedeemObserver{
 		sharedState: state,
 		Ch:          make(chan btcaction.RedeemAction, bufferSize),
@@ -19,11 +26,18 @@ func NewRedeemObserver(state *state.State, bufferSize int) *RedeemObserver {
 }
 
 // Notify that the redeem on BTC is totally completed.
+// Several redeems can be completed by the same (batch) btc tx.
 func (r *RedeemObserver) GetNotifiedRedeemCompleted() {
 	for data := range r.Ch {
-		r.sharedState.SetRedeemCompleted(
+		err := r.sharedState.SetRedeemCompleted(
 			ethcommon.HexToHash(data.EthRequestTxID),
 			ethcommon.HexToHash(data.BtcHash),
 		)
+		if err != nil {
+			logger.WithFields(logger.Fields{
+				"ethRequestTxId": data.EthRequestTxID,
+				"btcTxId":        data.BtcHash,
+			}).Errorf("failed to set redeem completed: %v", err)
+		}
 	}
 }
@@ -0,0 +1,85 @@
+package btcsync
+
+// This file implements an observer
+// that listens to malformed deposits (pay to us, but bad OP_RETURN data),
+// records them for a refund, and keeps their UTXOs away from redeems.
+
+import (
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+
+	"github.com/TEENet-io/bridge-go/btcaction"
+	"github.com/TEENet-io/bridge-go/btcvault"
+)
+
+// ObservedMalformedDeposit is a malformed deposit found during BTC scan.
+type ObservedMalformedDeposit struct {
+	Refund   btcaction.RefundAction // status is pending, or manual if no refund address is found.
+	PkScript []byte                 // of the output that pays to us.
+}
+
+/*
+ObserverRefund is an observer that once a malformed deposit is pushed from channel,
+It will record the refund, and lock the deposit UTXO in the vault,
+so ChooseAndLock won't spend it on a redeem.
+The refund itself is sent by btctxmanager after a review delay.
+*/
+type ObserverRefund struct {
+	vault       *btcvault.TreasureVault
+	refundState btcaction.RefundStorage
+	Ch          chan ObservedMalformedDeposit
+}
+
+func NewObserverRefund(vault *btcvault.TreasureVault, refundState btcaction.RefundStorage, bufferSize int) *ObserverRefund {
+	return &ObserverRefund{
+		vault:       vault,
+		refundState: refundState,
+		Ch:          make(chan ObservedMalformedDeposit, bufferSize),
+	}
+}
+
+// GetNotifiedMalformedDeposit implements the MalformedDepositObserver interface
+// You should call it as a separate goroutine (with go)
+func (o *ObserverRefund) GetNotifiedMalformedDeposit() {
+	for data := range o.Ch {
+		r := data.Refund
+		fields := logger.Fields{
+			"btcTxId":    r.TxHash,
+			"vout":       r.Vout,
+			"amount":     r.Amount,
+			"refundAddr": r.RefundAddr,
+		}
+
+		if err := o.refundState.AddRefund(r); err != nil {
+			logger.WithFields(fields).Errorf("failed to record malformed deposit: %v", err)
+			continue
+		}
+
+		// The UTXO observer may not have added the UTXO yet, add it here (no duplicates).
+		o.vault.AddUTXO(int32(r.BlockNumber), r.BlockHash, r.TxHash, int32(r.Vout), r.Amount, data.PkScript)
+		if err := o.vault.LockUTXO(r.TxHash, int32(r.Vout), btcvault.REFUND_LINKED_PREFIX+r.TxHash, btcvault.REFUND_LOCK_DELAY); err != nil {
+			logger.WithFields(fields).Errorf("failed to lock malformed deposit utxo: %v", err)
+			continue
+		}
+		logger.WithFields(fields).Info("Malformed deposit recorded for refund (BTC)")
+	}
+}
+
+// NewRefundAction creates a pending refund of a malformed deposit, found at time now.
+// Without a refund address, the refund must be handled manually.
+func NewRefundAction(basic btcaction.Basic, vout int, amount int64, refundAddr string, reason string, now time.Time) btcaction.RefundAction {
+	status := btcaction.REFUND_STATUS_PENDING
+	if refundAddr == "" {
+		status = btcaction.REFUND_STATUS_MANUAL
+	}
+	return btcaction.RefundAction{
+		Basic:      basic,
+		Vout:       vout,
+		Amount:     amount,
+		RefundAddr: refundAddr,
+		Reason:     reason,
+		Status:     status,
+		CreatedAt:  now.Unix(),
+	}
+}
@@ -0,0 +1,143 @@
+package btcsync
+
+// This file implements an observer
+// that listens to BTC reorg (rollback) events,
+// then invalidates everything tied to the orphaned block:
+// deposits, vault UTXOs (and their spends), un-minted mints and completed redeems.
+
+import (
+	logger "github.com/sirupsen/logrus"
+
+	"github.com/TEENet-io/bridge-go/btcaction"
+	"github.com/TEENet-io/bridge-go/btcvault"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+)
+
+// ObservedRollback is a scanned block that is no longer on the best chain.
+type ObservedRollback struct {
+	BlockNumber int32
+	BlockHash   string   // hash of the orphaned block
+	TxIDs       []string // txs of the orphaned block, empty if the node cannot provide the block any more.
+}
+
+// RollbackState is the shared state to revert,
+// a single state.State, or a chainregistry.Registry of several destination chains.
+type RollbackState interface {
+	RemoveBTC2EVMMint(btcTxId ethcommon.Hash) (bool, error)
+	SetRedeemOrphaned(ethReqTxHash ethcommon.Hash) error
+}
+
+/*
+ObserverRollback is an observer that once an orphaned block is pushed from channel,
+It will remove/revert the records built upon the block.
+Any of the backends can be nil, then it is skipped.
+*/
+type ObserverRollback struct {
+	depositStorage btcaction.DepositStorage
+	vault          *btcvault.TreasureVault
+	sharedState    RollbackState
+	mgrState       btcaction.RedeemActionStorage
+	Ch             chan ObservedRollback
+}
+
+func NewObserverRollback(
+	depositStorage btcaction.DepositStorage,
+	vault *btcvault.TreasureVault,
+	sharedState RollbackState,
+	mgrState btcaction.RedeemActionStorage,
+	bufferSize int,
+) *ObserverRollback {
+	return &ObserverRollback{
+		depositStorage: depositStorage,
+		vault:          vault,
+		sharedState:    sharedState,
+		mgrState:       mgrState,
+		Ch:             make(chan ObservedRollback, bufferSize),
+	}
+}
+
+// GetNotifiedRollback implements the RollbackObserver interface
+// You should call it as a separate goroutine (with go)
+func (o *ObserverRollback) GetNotifiedRollback() {
+	for data := range o.Ch {
+		logger.WithFields(logger.Fields{
+			"blockNum":  data.BlockNumber,
+			"blockHash": data.BlockHash,
+			"txs":       len(data.TxIDs),
+		}).Warn("Rollback orphaned block (btc)")
+
+		if o.depositStorage != nil {
+			if err := o.depositStorage.DeleteDepositsByBlockHash(data.BlockHash); err != nil {
+				logger.WithField("blockHash", data.BlockHash).Errorf("failed to remove deposits: %v", err)
+			}
+		}
+
+		if o.vault != nil {
+			if err := o.vault.RemoveByBlockHash(data.BlockHash); err != nil {
+				logger.WithField("blockHash", data.BlockHash).Errorf("failed to remove utxos: %v", err)
+			}
+			if err := o.vault.UnspendByBlockHash(data.BlockHash); err != nil {
+				logger.WithField("blockHash", data.BlockHash).Errorf("failed to revert spent utxos: %v", err)
+			}
+		}
+
+		for _, txID := range data.TxIDs {
+			o.rollbackMint(txID)
+			o.rollbackRedeem(txID)
+		}
+	}
+}
+
+// rollbackMint removes the mint of an orphaned deposit tx, if it is not minted yet.
+func (o *ObserverRollback) rollbackMint(txID string) {
+	if o.sharedState == nil {
+		return
+	}
+
+	btcTxId := ethcommon.HexToHash(txID)
+	removed, err := o.sharedState.RemoveBTC2EVMMint(btcTxId)
+	if err != nil {
+		logger.WithField("btcTxId", txID).Errorf("failed to remove mint: %v", err)
+		return
+	}
+	if removed {
+		logger.WithField("btcTxId", txID).Warn("Mint of orphaned deposit removed")
+	}
+}
+
+// rollbackRedeem marks the redeem(s) of an orphaned btc tx as not mined.
+// The btc tx stays in the mempool of nodes and will be mined again.
+func (o *ObserverRollback) rollbackRedeem(txID string) {
+	if o.mgrState == nil {
+		return
+	}
+
+	records, err := o.mgrState.QueryAllByBtcTxId(txID)
+	if err != nil {
+		logger.WithField("btcTxId", txID).Errorf("failed to query redeems: %v", err)
+		return
+	}
+
+	for _, record := range records {
+		if !record.Mined {
+			continue
+		}
+
+		if err := o.mgrState.UncompleteRedeem(record.EthRequestTxID); err != nil {
+			logger.WithField("reqTxHash", record.EthRequestTxID).Errorf("failed to uncomplete redeem: %v", err)
+			continue
+		}
+
+		if o.sharedState != nil {
+			if err := o.sharedState.SetRedeemOrphaned(ethcommon.HexToHash(record.EthRequestTxID)); err != nil {
+				logger.WithField("reqTxHash", record.EthRequestTxID).Errorf("failed to revert redeem: %v", err)
+				continue
+			}
+		}
+
+		logger.WithFields(logger.Fields{
+			"reqTxHash": record.EthRequestTxID,
+			"btcTxId":   txID,
+		}).Warn("Redeem of orphaned btc tx reverted")
+	}
+}
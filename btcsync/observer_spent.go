@@ -0,0 +1,128 @@
+package btcsync
+
+// This file implements an observer
+// that listens to the inputs of scanned BTC txs,
+// then marks the vault UTXOs they spend as spent.
+
+import (
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+
+	"github.com/TEENet-io/bridge-go/btcaction"
+	"github.com/TEENet-io/bridge-go/btcvault"
+)
+
+const (
+	RECONCILE_INTERVAL = time.Hour // ? time between reconciliation reports.
+)
+
+// ObservedSpends are the tx inputs of a scanned block.
+type ObservedSpends struct {
+	BlockNumber int32
+	BlockHash   string
+	Spends      []btcvault.SpentUTXO // each input: the spent outpoint and the spending tx.
+}
+
+/*
+ObserverSpent is an observer that once the inputs of a block are pushed from channel,
+It will mark the vault UTXOs spent by them.
+A vault UTXO spent by a tx the bridge didn't send (not a redeem, a consolidation or a refund)
+is warned, see also Reconcile().
+*/
+type ObserverSpent struct {
+	vault              *btcvault.TreasureVault
+	mgrState           btcaction.RedeemActionStorage
+	consolidationState btcaction.ConsolidationStorage // can be nil
+	refundState        btcaction.RefundStorage        // can be nil
+	Ch                 chan ObservedSpends
+}
+
+func NewObserverSpent(
+	vault *btcvault.TreasureVault,
+	mgrState btcaction.RedeemActionStorage,
+	consolidationState btcaction.ConsolidationStorage,
+	refundState btcaction.RefundStorage,
+	bufferSize int,
+) *ObserverSpent {
+	return &ObserverSpent{
+		vault:              vault,
+		mgrState:           mgrState,
+		consolidationState: consolidationState,
+		refundState:        refundState,
+		Ch:                 make(chan ObservedSpends, bufferSize),
+	}
+}
+
+// GetNotifiedSpends implements the SpentObserver interface
+// You should call it as a separate goroutine (with go)
+func (o *ObserverSpent) GetNotifiedSpends() {
+	for data := range o.Ch {
+		for _, spent := range data.Spends {
+			hit, err := o.vault.MarkSpent(spent)
+			if err != nil {
+				logger.WithFields(logger.Fields{
+					"txid": spent.RelatedTxID,
+					"vout": spent.RelatedVout,
+				}).Errorf("failed to mark utxo spent: %v", err)
+				continue
+			}
+			if !hit {
+				continue
+			}
+
+			fields := logger.Fields{
+				"blockNum": data.BlockNumber,
+				"txid":     spent.RelatedTxID,
+				"vout":     spent.RelatedVout,
+				"spentBy":  spent.TxID,
+			}
+			if o.IsExpected(spent.TxID) {
+				logger.WithFields(fields).Debug("UTXO spent (BTC)")
+			} else {
+				logger.WithFields(fields).Warn("UTXO spent by unexpected tx (BTC)")
+			}
+		}
+	}
+}
+
+// IsExpected tells if the btc tx is sent by the bridge (a redeem, a consolidation or a refund).
+func (o *ObserverSpent) IsExpected(txID string) bool {
+	if o.mgrState != nil {
+		if record, err := o.mgrState.QueryByBtcTxId(txID); err == nil && record != nil {
+			return true
+		}
+	}
+	if o.consolidationState != nil {
+		if record, err := o.consolidationState.QueryConsolidation(txID); err == nil && record != nil {
+			return true
+		}
+	}
+	if o.refundState != nil {
+		if record, err := o.refundState.GetRefundByRefundTxHash(txID); err == nil && record != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Reconcile reports the vault UTXOs spent on chain, and flags those spent by unexpected txs.
+func (o *ObserverSpent) Reconcile() (*btcvault.ReconciliationReport, error) {
+	report, err := o.vault.Reconcile(o.IsExpected)
+	if err != nil {
+		return nil, err
+	}
+	report.Log()
+	return report, nil
+}
+
+// ReconcileLoop reports the reconciliation periodically.
+// Call it in a separate go routine.
+func (o *ObserverSpent) ReconcileLoop() {
+	for {
+		time.Sleep(RECONCILE_INTERVAL)
+		if _, err := o.Reconcile(); err != nil {
+			logger.Errorf("failed to reconcile utxo vault: %v", err)
+		}
+	}
+}
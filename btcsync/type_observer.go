@@ -23,3 +23,18 @@ type OtherTransferObserver interface {
 type UTXOObserver interface {
 	GetNotifiedUtxo()
 }
+
+// Observer on orphaned block (reorg)
+type RollbackObserver interface {
+	GetNotifiedRollback()
+}
+
+// Observer on spent outpoints (tx inputs)
+type SpentObserver interface {
+	GetNotifiedSpends()
+}
+
+// Observer on malformed deposit (to be refunded)
+type MalformedDepositObserver interface {
+	GetNotifiedMalformedDeposit()
+}
@@ -14,6 +14,9 @@ type PublisherService struct {
 	RedeemObservers        []chan btcaction.RedeemAction
 	OtherTransferObservers []chan btcaction.OtherTransferAction
 	UTXOObservers          []chan ObservedUTXO
+	RollbackObservers      []chan ObservedRollback
+	SpentObservers         []chan ObservedSpends
+	MalformedObservers     []chan ObservedMalformedDeposit
 	mu                     sync.Mutex
 }
 
@@ -26,6 +29,9 @@ func NewPublisherService() *PublisherService {
 		RedeemObservers:        make([]chan btcaction.RedeemAction, 0),
 		OtherTransferObservers: make([]chan btcaction.OtherTransferAction, 0),
 		UTXOObservers:          make([]chan ObservedUTXO, 0),
+		RollbackObservers:      make([]chan ObservedRollback, 0),
+		SpentObservers:         make([]chan ObservedSpends, 0),
+		MalformedObservers:     make([]chan ObservedMalformedDeposit, 0),
 	}
 }
 
@@ -62,6 +68,30 @@ func (m *PublisherService) RegisterUTXOObserver(observer chan ObservedUTXO) {
 	m.UTXOObservers = append(m.UTXOObservers, observer)
 }
 
+// RegisterRollbackObserver registers a new observer for orphaned blocks (reorg).
+func (m *PublisherService) RegisterRollbackObserver(observer chan ObservedRollback) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.RollbackObservers = append(m.RollbackObservers, observer)
+}
+
+// RegisterSpentObserver registers a new observer for spent outpoints (tx inputs).
+func (m *PublisherService) RegisterSpentObserver(observer chan ObservedSpends) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.SpentObservers = append(m.SpentObservers, observer)
+}
+
+// RegisterMalformedDepositObserver registers a new observer for malformed deposits.
+func (m *PublisherService) RegisterMalformedDepositObserver(observer chan ObservedMalformedDeposit) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.MalformedObservers = append(m.MalformedObservers, observer)
+}
+
 func (m *PublisherService) NotifyDeposit(da btcaction.DepositAction) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -126,3 +156,54 @@ func (m *PublisherService) NotifyUTXO(data ObservedUTXO) {
 		}
 	}
 }
+
+// Notify "block is orphaned" to observers.
+func (m *PublisherService) NotifyRollback(data ObservedRollback) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, observer := range m.RollbackObservers {
+		select {
+		case observer <- data:
+		default:
+			// Handle the case where the observer's channel is full
+			go func(obs chan ObservedRollback) {
+				obs <- data
+			}(observer)
+		}
+	}
+}
+
+// Notify "outpoints are spent" to observers.
+func (m *PublisherService) NotifySpends(data ObservedSpends) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, observer := range m.SpentObservers {
+		select {
+		case observer <- data:
+		default:
+			// Handle the case where the observer's channel is full
+			go func(obs chan ObservedSpends) {
+				obs <- data
+			}(observer)
+		}
+	}
+}
+
+// Notify "malformed deposit is found" to observers.
+func (m *PublisherService) NotifyMalformedDeposit(data ObservedMalformedDeposit) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, observer := range m.MalformedObservers {
+		select {
+		case observer <- data:
+		default:
+			// Handle the case where the observer's channel is full
+			go func(obs chan ObservedMalformedDeposit) {
+				obs <- data
+			}(observer)
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package btctxmanager
+
+/*
+	This file focus on batching redeems into a single BTC tx.
+
+	When batch mode is on, new "prepared" redeems are not sent one by one.
+	They wait up to <batchWindow> (since the first one seen),
+	or until <maxBatchSize> redeems are waiting,
+	then one tx pays all of them, with a single change output.
+	The OP_RETURN commits to all the request tx hashes (see common.BatchRedeemData).
+*/
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/TEENet-io/bridge-go/btcaction"
+	"github.com/TEENet-io/bridge-go/btcman/assembler"
+	"github.com/TEENet-io/bridge-go/btcman/utils"
+	"github.com/TEENet-io/bridge-go/btcman/utxo"
+	"github.com/TEENet-io/bridge-go/common"
+	"github.com/TEENet-io/bridge-go/state"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	logger "github.com/sirupsen/logrus"
+)
+
+const (
+	MAX_BATCH_SIZE = 50 // default, max redeems paid by a single batch tx.
+)
+
+// SetBatchMode turns on batching of redeems.
+// window: how long the first waiting redeem waits for others, 0 turns batching off.
+// maxSize: a batch is sent at once when so many redeems are waiting.
+func (m *BtcTxManager) SetBatchMode(window time.Duration, maxSize int) {
+	if maxSize <= 0 || maxSize > common.MAX_BATCH_REDEEM_COUNT {
+		maxSize = MAX_BATCH_SIZE
+	}
+	m.batchWindow = window
+	m.maxBatchSize = maxSize
+}
+
+// CollectBatchUTXOs fetches UTXOs of all the given redeems.
+func (m *BtcTxManager) CollectBatchUTXOs(redeems []*state.Redeem) ([]*utxo.UTXO, error) {
+	var utxos []*utxo.UTXO
+	for _, redeem := range redeems {
+		items, err := m.CollectUTXOs(redeem)
+		if err != nil {
+			return nil, err
+		}
+		utxos = append(utxos, items...)
+	}
+	return utxos, nil
+}
+
+// CreateBTCBatchRedeemTx creates a single transaction that pays all the given redeems.
+// feeRate is in satoshi/vbyte.
+func (m *BtcTxManager) CreateBTCBatchRedeemTx(redeems []*state.Redeem, feeRate int64) (*wire.MsgTx, error) {
+	utxos, err := m.CollectBatchUTXOs(redeems)
+	if err != nil {
+		return nil, err
+	}
+
+	var payouts []assembler.RedeemPayout
+	for _, redeem := range redeems {
+		var requestTxHash [32]byte
+		copy(requestTxHash[:], redeem.RequestTxHash.Bytes())
+		payouts = append(payouts, assembler.RedeemPayout{
+			DstAddr:    utils.Remove0xPrefix(redeem.Receiver),
+			DstAmount:  redeem.Amount.Int64(),
+			RedeemData: requestTxHash,
+		})
+	}
+	change_addr := m.legacySigner.P2PKH.EncodeAddress()
+
+	fee, err := m.myAssembler.EstimateBatchRedeemFee(payouts, change_addr, feeRate, utxos)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.WithFields(logger.Fields{
+		"num":                len(redeems),
+		"fee_rate (sat/vB)":  feeRate,
+		"btc_tx_fee (extra)": fee,
+	}).Info("Create BTC Batch Redeem Tx")
+
+	return m.myAssembler.MakeBatchRedeemTx(payouts, change_addr, feeRate, utxos)
+}
+
+// WithdrawBTCBatch sends a single transaction paying all the given redeems to the Bitcoin network.
+// feeRate is in satoshi/vbyte.
+func (m *BtcTxManager) WithdrawBTCBatch(redeems []*state.Redeem, feeRate int64) (*chainhash.Hash, error) {
+	redeemTx, err := m.CreateBTCBatchRedeemTx(redeems, feeRate)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.myBtcClient.SendRawTx(redeemTx)
+}
+
+// queueRedeems remembers when each new redeem is first seen.
+// Redeems no longer new (sent, or gone from the state) are dropped from the queue.
+// Returns the new redeems in the order they were first seen.
+func (m *BtcTxManager) queueRedeems(fresh []*state.Redeem) []*state.Redeem {
+	now := time.Now()
+	seen := make(map[string]bool, len(fresh))
+	for _, redeem := range fresh {
+		reqTxHash := utils.Remove0xPrefix(redeem.RequestTxHash.String())
+		seen[reqTxHash] = true
+		if _, ok := m.pendingSince[reqTxHash]; !ok {
+			m.pendingSince[reqTxHash] = now
+		}
+	}
+	for reqTxHash := range m.pendingSince {
+		if !seen[reqTxHash] {
+			delete(m.pendingSince, reqTxHash)
+		}
+	}
+
+	queued := make([]*state.Redeem, len(fresh))
+	copy(queued, fresh)
+	sort.SliceStable(queued, func(i, j int) bool {
+		return m.since(queued[i]).Before(m.since(queued[j]))
+	})
+	return queued
+}
+
+func (m *BtcTxManager) since(redeem *state.Redeem) time.Time {
+	return m.pendingSince[utils.Remove0xPrefix(redeem.RequestTxHash.String())]
+}
+
+// nextBatch gives the redeems to send now, nil if the batch is not ready yet.
+// A batch is ready if the oldest redeem waits longer than the window, or it is full.
+func (m *BtcTxManager) nextBatch(queued []*state.Redeem) []*state.Redeem {
+	if len(queued) == 0 {
+		return nil
+	}
+	if len(queued) < m.maxBatchSize && time.Since(m.since(queued[0])) < m.batchWindow {
+		return nil
+	}
+	if len(queued) > m.maxBatchSize {
+		queued = queued[:m.maxBatchSize]
+	}
+	return queued
+}
+
+// sendBatch sends the redeems in a single tx and records them in mgrState.
+// A single redeem is sent as a normal redeem tx.
+func (m *BtcTxManager) sendBatch(redeems []*state.Redeem) error {
+	feeRate, err := m.feeEstimator.EstimateFeeRate()
+	if err != nil {
+		return err
+	}
+	feeRate, err = m.AffordableFeeRate(redeems, feeRate)
+	if err != nil {
+		return err
+	}
+	broadcastBlk, err := m.myBtcClient.GetLatestBlockHeight()
+	if err != nil {
+		return err
+	}
+
+	var btcTxId *chainhash.Hash
+	if len(redeems) == 1 {
+		btcTxId, err = m.WithdrawBTC(redeems[0], feeRate)
+	} else {
+		btcTxId, err = m.WithdrawBTCBatch(redeems, feeRate)
+	}
+	if err != nil {
+		return err
+	}
+
+	logger.WithFields(logger.Fields{
+		"num":     len(redeems),
+		"btcTxId": btcTxId.String(),
+		"feeRate": feeRate,
+	}).Info("BTC Batch Redeem withdraw Tx sent")
+
+	now := time.Now().Unix()
+	for _, redeem := range redeems {
+		reqTxHash := utils.Remove0xPrefix(redeem.RequestTxHash.String())
+		delete(m.pendingSince, reqTxHash)
+		err := m.mgrState.InsertRedeem(&btcaction.RedeemAction{
+			EthRequestTxID: reqTxHash,
+			BtcHash:        btcTxId.String(),
+			Sent:           true,
+			FeeRate:        feeRate,
+			BroadcastTime:  now,
+			BroadcastBlk:   broadcastBlk,
+		})
+		if err != nil {
+			logger.WithFields(logger.Fields{
+				"reqTxHash": reqTxHash,
+				"btcTxId":   btcTxId.String(),
+			}).Errorf("Failed to record btc redeem: %v", err)
+		}
+	}
+	return nil
+}
+
+// bumpBatch replaces a stuck batch tx with a higher fee one, paying the same redeems.
+// members are the redeems paid by the stuck batch tx.
+// Returns the replacement tx and its fee rate (capped at what the UTXOs can pay).
+func (m *BtcTxManager) bumpBatch(members []btcaction.RedeemAction, feeRate int64, oldFeeRate int64, latest int64) (*chainhash.Hash, int64, error) {
+	prepared, err := m.FindRedeemsFromState()
+	if err != nil {
+		return nil, 0, err
+	}
+	byReqTxHash := make(map[string]*state.Redeem, len(prepared))
+	for _, redeem := range prepared {
+		byReqTxHash[utils.Remove0xPrefix(redeem.RequestTxHash.String())] = redeem
+	}
+
+	var redeems []*state.Redeem
+	for _, member := range members {
+		redeem, ok := byReqTxHash[member.EthRequestTxID]
+		if !ok {
+			return nil, 0, fmt.Errorf("redeem %s of the batch is not prepared in state", member.EthRequestTxID)
+		}
+		redeems = append(redeems, redeem)
+	}
+
+	feeRate, err = m.affordableBumpFeeRate(redeems, feeRate, oldFeeRate)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	btcTxId, err := m.WithdrawBTCBatch(redeems, feeRate)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	now := time.Now().Unix()
+	for _, member := range members {
+		err := m.mgrState.ReplaceRedeemTx(&btcaction.RedeemAction{
+			EthRequestTxID: member.EthRequestTxID,
+			BtcHash:        btcTxId.String(),
+			Sent:           true,
+			FeeRate:        feeRate,
+			BroadcastTime:  now,
+			BroadcastBlk:   latest,
+		})
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+	return btcTxId, feeRate, nil
+}
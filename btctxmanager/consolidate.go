@@ -0,0 +1,336 @@
+package btctxmanager
+
+/*
+	This file focus on consolidation of vault UTXOs.
+
+	Deposits leave many small UTXOs in the vault,
+	a redeem during a fee spike then pays for many inputs.
+	When the fee rate is low, the smallest unlocked UTXOs are merged
+	into a few larger outputs back to the bridge address.
+
+	1. Lock the smallest UTXOs in the vault (ChooseAndLock cannot choose them).
+	2. Sign and send out the consolidation tx.
+	3. Insert a record in ConsolidationStorage to track the status.
+	4. Once the tx is confirmed, mark the inputs spent in the vault.
+	   (the outputs are picked up by the monitor as new UTXOs)
+
+	A tx that stays unconfirmed past <ReplaceAfterBlks> blocks is replaced (BIP-125)
+	by a higher fee one spending the same inputs, it is broadcast again if it was dropped.
+	A tx whose inputs are spent by another tx (double spent) is abandoned,
+	its unspent inputs are released, so a stuck tx never blocks the next consolidations.
+*/
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/TEENet-io/bridge-go/btcaction"
+	"github.com/TEENet-io/bridge-go/btcman/utxo"
+	"github.com/TEENet-io/bridge-go/btcvault"
+	logger "github.com/sirupsen/logrus"
+)
+
+const (
+	CONSOLIDATION_INTERVAL     = 10 * time.Minute
+	CONSOLIDATION_MAX_FEE_RATE = 5   // satoshi/vbyte, consolidate only when the fee rate is at most this.
+	CONSOLIDATION_MIN_UTXOS    = 20  // consolidate only when the vault has so many usable UTXOs.
+	CONSOLIDATION_MAX_INPUTS   = 100 // max UTXOs merged by a single tx.
+	CONSOLIDATION_OUTPUTS      = 2   // number of merged outputs.
+
+	CONSOLIDATION_REPLACE_AFTER_BLOCKS = 144 // a day, replace a consolidation tx unconfirmed after ? blocks.
+
+	CONSOLIDATION_LOCK_DELAY int64 = 7 * 24 * 3600 // a week, the inputs are locked until the tx is confirmed.
+)
+
+// ConsolidationConfig decides when and how UTXOs are consolidated.
+type ConsolidationConfig struct {
+	Interval   time.Duration // how often to check
+	MaxFeeRate int64         // satoshi/vbyte, consolidate only when the fee rate is at most this.
+	MinUTXOs   int           // consolidate only when the vault has so many usable UTXOs.
+	MaxInputs  int           // max UTXOs merged by a single tx.
+	Outputs    int           // number of merged outputs.
+
+	ReplaceAfterBlks int64 // replace a tx unconfirmed after ? blocks with a higher fee (0=never)
+}
+
+// DefaultConsolidationConfig gives the default values.
+func DefaultConsolidationConfig() *ConsolidationConfig {
+	return &ConsolidationConfig{
+		Interval:   CONSOLIDATION_INTERVAL,
+		MaxFeeRate: CONSOLIDATION_MAX_FEE_RATE,
+		MinUTXOs:   CONSOLIDATION_MIN_UTXOS,
+		MaxInputs:  CONSOLIDATION_MAX_INPUTS,
+		Outputs:    CONSOLIDATION_OUTPUTS,
+
+		ReplaceAfterBlks: CONSOLIDATION_REPLACE_AFTER_BLOCKS,
+	}
+}
+
+// SetConsolidation enables the consolidation of vault UTXOs, see ConsolidateLoop().
+func (m *BtcTxManager) SetConsolidation(storage btcaction.ConsolidationStorage, cfg *ConsolidationConfig) {
+	m.consolidationState = storage
+	m.consolidationCfg = cfg
+}
+
+// Consolidate merges the smallest UTXOs of the vault, if the fee rate is low enough.
+// Only one consolidation tx is in flight at a time.
+// Returns the consolidation record, nil if nothing is done.
+func (m *BtcTxManager) Consolidate() (*btcaction.ConsolidationAction, error) {
+	cfg := m.consolidationCfg
+
+	pending, err := m.consolidationState.QueryUnconfirmedConsolidations()
+	if err != nil {
+		return nil, err
+	}
+	if len(pending) > 0 {
+		return nil, nil
+	}
+
+	feeRate, err := m.feeEstimator.EstimateFeeRate()
+	if err != nil {
+		return nil, err
+	}
+	if feeRate > cfg.MaxFeeRate {
+		logger.WithFields(logger.Fields{
+			"feeRate":    feeRate,
+			"maxFeeRate": cfg.MaxFeeRate,
+		}).Debug("fee rate too high, skip consolidation")
+		return nil, nil
+	}
+
+	usable, err := m.treasureVault.CountUsableUTXOs()
+	if err != nil {
+		return nil, err
+	}
+	if usable < cfg.MinUTXOs {
+		return nil, nil
+	}
+
+	broadcastBlk, err := m.myBtcClient.GetLatestBlockHeight()
+	if err != nil {
+		return nil, err
+	}
+
+	linkedID := fmt.Sprintf("%s%d", btcvault.CONSOLIDATION_LINKED_PREFIX, time.Now().UnixNano())
+	vaultUTXOs, err := m.treasureVault.ChooseSmallestAndLock(cfg.MaxInputs, linkedID, CONSOLIDATION_LOCK_DELAY)
+	if err != nil {
+		return nil, err
+	}
+
+	var utxos []*utxo.UTXO
+	var inputAmount int64
+	for i := range vaultUTXOs {
+		utxos = append(utxos, ConvertUTXO(&vaultUTXOs[i]))
+		inputAmount += vaultUTXOs[i].Amount
+	}
+
+	dst_addr := m.legacySigner.P2PKH.EncodeAddress()
+	tx, err := m.myAssembler.MakeConsolidationTx(dst_addr, cfg.Outputs, feeRate, utxos)
+	if err == nil {
+		_, err = m.myBtcClient.SendRawTx(tx)
+	}
+	if err != nil {
+		// Not sent, give the UTXOs back.
+		if rErr := m.treasureVault.ReleaseByLinkedID(linkedID); rErr != nil {
+			logger.WithField("linkedId", linkedID).Errorf("Failed to release consolidation UTXOs: %v", rErr)
+		}
+		return nil, err
+	}
+
+	c := &btcaction.ConsolidationAction{
+		BtcHash:      tx.TxHash().String(),
+		LinkedID:     linkedID,
+		InputCount:   len(utxos),
+		InputAmount:  inputAmount,
+		OutputCount:  len(tx.TxOut),
+		FeeRate:      feeRate,
+		BroadcastBlk: broadcastBlk,
+	}
+	logger.WithFields(logger.Fields{
+		"btcTxId":     c.BtcHash,
+		"inputs":      c.InputCount,
+		"inputAmount": c.InputAmount,
+		"outputs":     c.OutputCount,
+		"feeRate":     c.FeeRate,
+	}).Info("BTC Consolidation Tx sent")
+
+	return c, m.consolidationState.InsertConsolidation(c)
+}
+
+// CheckConsolidations marks the inputs of confirmed consolidation txs spent in the vault.
+// Stuck txs are replaced, double spent ones are abandoned.
+func (m *BtcTxManager) CheckConsolidations() error {
+	pending, err := m.consolidationState.QueryUnconfirmedConsolidations()
+	if err != nil {
+		return err
+	}
+
+	for _, c := range pending {
+		confirmations, err := m.myBtcClient.GetTxConfirmations(c.BtcHash)
+		if err != nil {
+			// Dropped from the mempool? it is broadcast again by the replacement.
+			logger.WithField("btcTxId", c.BtcHash).Warnf("cannot query btc consolidation tx: %v", err)
+			confirmations = 0
+		}
+		if confirmations > 0 {
+			if err := m.confirmConsolidation(&c, c.BtcHash); err != nil {
+				return err
+			}
+			continue
+		}
+
+		done, err := m.checkConsolidationSpenders(&c)
+		if err != nil {
+			return err
+		}
+		if done {
+			continue
+		}
+
+		if err := m.maybeReplaceConsolidation(&c); err != nil {
+			logger.WithField("btcTxId", c.BtcHash).Errorf("Failed to replace btc consolidation tx: %v", err)
+		}
+	}
+	return nil
+}
+
+// confirmConsolidation marks the inputs of c spent, btcHash (c or a tx it replaced) is mined.
+func (m *BtcTxManager) confirmConsolidation(c *btcaction.ConsolidationAction, btcHash string) error {
+	if err := m.treasureVault.SpendByLinkedID(c.LinkedID); err != nil {
+		return err
+	}
+	if err := m.consolidationState.ConfirmConsolidation(btcHash); err != nil {
+		return err
+	}
+	logger.WithFields(logger.Fields{
+		"btcTxId": btcHash,
+		"inputs":  c.InputCount,
+	}).Info("BTC Consolidation Tx confirmed")
+	return nil
+}
+
+// checkConsolidationSpenders looks for the inputs of c spent on chain (observed by the monitor).
+// c itself or a tx replaced by c being mined confirms the consolidation.
+// Any other tx double spends the inputs, c is abandoned and its unspent inputs are released.
+// Returns true if c is settled either way.
+func (m *BtcTxManager) checkConsolidationSpenders(c *btcaction.ConsolidationAction) (bool, error) {
+	spenders, err := m.treasureVault.SpendersByLinkedID(c.LinkedID)
+	if err != nil {
+		return false, err
+	}
+
+	for _, spender := range spenders {
+		if spender == c.BtcHash {
+			return true, m.confirmConsolidation(c, c.BtcHash)
+		}
+
+		replaced, err := m.consolidationState.QueryConsolidation(spender)
+		if err != nil {
+			return false, err
+		}
+		if replaced != nil && replaced.LinkedID == c.LinkedID {
+			if err := m.confirmConsolidation(c, spender); err != nil {
+				return false, err
+			}
+			return true, m.consolidationState.AbandonConsolidation(c.BtcHash)
+		}
+
+		if err := m.treasureVault.ReleaseByLinkedID(c.LinkedID); err != nil {
+			return false, err
+		}
+		if err := m.consolidationState.AbandonConsolidation(c.BtcHash); err != nil {
+			return false, err
+		}
+		logger.WithFields(logger.Fields{
+			"btcTxId": c.BtcHash,
+			"spentBy": spender,
+		}).Warn("BTC Consolidation Tx inputs double spent, abandoned")
+		return true, nil
+	}
+	return false, nil
+}
+
+// maybeReplaceConsolidation replaces c with a higher fee tx spending the same inputs,
+// if it stays unconfirmed past ReplaceAfterBlks blocks since broadcast.
+// The fee rate may exceed MaxFeeRate, the inputs are locked until a tx is mined.
+func (m *BtcTxManager) maybeReplaceConsolidation(c *btcaction.ConsolidationAction) error {
+	cfg := m.consolidationCfg
+	if cfg.ReplaceAfterBlks <= 0 {
+		return nil
+	}
+
+	latest, err := m.myBtcClient.GetLatestBlockHeight()
+	if err != nil {
+		return err
+	}
+	if latest-c.BroadcastBlk < cfg.ReplaceAfterBlks {
+		return nil
+	}
+
+	feeRate, err := m.bumpFeeRate(c.FeeRate)
+	if err != nil {
+		return err
+	}
+	if feeRate <= c.FeeRate {
+		return fmt.Errorf("fee rate %d reaches the max %d, cannot bump", c.FeeRate, MAX_FEE_RATE)
+	}
+
+	vaultUTXOs, err := m.treasureVault.GetByLinkedID(c.LinkedID)
+	if err != nil {
+		return err
+	}
+	var utxos []*utxo.UTXO
+	for i := range vaultUTXOs {
+		utxos = append(utxos, ConvertUTXO(&vaultUTXOs[i]))
+	}
+	if len(utxos) == 0 {
+		return fmt.Errorf("no utxo locked by %s", c.LinkedID)
+	}
+
+	dst_addr := m.legacySigner.P2PKH.EncodeAddress()
+	tx, err := m.myAssembler.MakeConsolidationTx(dst_addr, cfg.Outputs, feeRate, utxos)
+	if err != nil {
+		return err
+	}
+	if _, err := m.myBtcClient.SendRawTx(tx); err != nil {
+		return err
+	}
+
+	replacement := &btcaction.ConsolidationAction{
+		BtcHash:      tx.TxHash().String(),
+		LinkedID:     c.LinkedID,
+		InputCount:   len(utxos),
+		InputAmount:  c.InputAmount,
+		OutputCount:  len(tx.TxOut),
+		FeeRate:      feeRate,
+		BroadcastBlk: latest,
+	}
+	logger.WithFields(logger.Fields{
+		"replacedTx":  c.BtcHash,
+		"btcTxId":     replacement.BtcHash,
+		"oldFeeRate":  c.FeeRate,
+		"newFeeRate":  feeRate,
+		"unconfirmed": latest - c.BroadcastBlk,
+	}).Info("BTC Consolidation Tx replaced with higher fee")
+
+	return m.consolidationState.ReplaceConsolidation(c.BtcHash, replacement)
+}
+
+// ConsolidateLoop periodically consolidates vault UTXOs.
+// Call it in a separate go routine, after SetConsolidation().
+func (m *BtcTxManager) ConsolidateLoop() {
+	if m.consolidationState == nil || m.consolidationCfg == nil {
+		logger.Warn("consolidation is not set, quit consolidate loop")
+		return
+	}
+
+	for {
+		if err := m.CheckConsolidations(); err != nil {
+			logger.Errorf("Failed to check consolidation txs: %v", err)
+		}
+		if _, err := m.Consolidate(); err != nil {
+			logger.Errorf("Failed to consolidate UTXOs: %v", err)
+		}
+		time.Sleep(m.consolidationCfg.Interval)
+	}
+}
@@ -0,0 +1,146 @@
+package btctxmanager
+
+/*
+	This file focus on replace-by-fee (BIP-125) of redeem txs.
+
+	A redeem tx that stays unconfirmed past <rbfAfterBlks> blocks
+	is re-signed with a higher fee rate, spending the same UTXOs,
+	and broadcast again to replace the stuck one.
+*/
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/TEENet-io/bridge-go/btcaction"
+	"github.com/TEENet-io/bridge-go/state"
+	logger "github.com/sirupsen/logrus"
+)
+
+const (
+	RBF_AFTER_BLOCKS     = 3   // default, bump the fee of a redeem tx unconfirmed after ? blocks.
+	RBF_FEE_BUMP_PERCENT = 125 // replacement fee rate >= 125% of the replaced one.
+)
+
+// SetRbfAfterBlocks sets after how many blocks an unconfirmed redeem tx gets a fee bump.
+func (m *BtcTxManager) SetRbfAfterBlocks(blks int64) {
+	m.rbfAfterBlks = blks
+}
+
+// bumpFeeRate decides the fee rate of a replacement tx.
+// BIP-125 requires the replacement pays more than the replaced one,
+// plus the incremental relay fee (1 satoshi/vbyte) for its own size.
+func (m *BtcTxManager) bumpFeeRate(oldFeeRate int64) (int64, error) {
+	feeRate, err := m.feeEstimator.EstimateFeeRate()
+	if err != nil {
+		return 0, err
+	}
+
+	if bumped := oldFeeRate * RBF_FEE_BUMP_PERCENT / 100; feeRate < bumped {
+		feeRate = bumped
+	}
+	if feeRate < oldFeeRate+MIN_FEE_RATE {
+		feeRate = oldFeeRate + MIN_FEE_RATE
+	}
+	if feeRate > MAX_FEE_RATE {
+		feeRate = MAX_FEE_RATE
+	}
+	return feeRate, nil
+}
+
+// affordableBumpFeeRate caps the bumped fee rate at what the UTXOs of the redeems can pay (see AffordableFeeRate),
+// the capped one shall still be higher than the replaced one.
+func (m *BtcTxManager) affordableBumpFeeRate(redeems []*state.Redeem, feeRate int64, oldFeeRate int64) (int64, error) {
+	feeRate, err := m.AffordableFeeRate(redeems, feeRate)
+	if err != nil {
+		return 0, err
+	}
+	if feeRate <= oldFeeRate {
+		return 0, fmt.Errorf("utxos of the redeem(s) cannot pay more than fee rate %d, cannot bump", oldFeeRate)
+	}
+	return feeRate, nil
+}
+
+// MaybeBumpRedeem replaces the redeem tx with a higher fee one,
+// if it stays unconfirmed past rbfAfterBlks blocks since broadcast.
+// The replacement spends the same UTXOs (outpoints of the redeem),
+// the monitor accepts any of the txs as completing the redeem.
+func (m *BtcTxManager) MaybeBumpRedeem(redeem *state.Redeem, ra *btcaction.RedeemAction) error {
+	// Sent by an older version, the fee rate is unknown.
+	if ra.FeeRate == 0 {
+		return nil
+	}
+
+	latest, err := m.myBtcClient.GetLatestBlockHeight()
+	if err != nil {
+		return err
+	}
+	if latest-ra.BroadcastBlk < m.rbfAfterBlks {
+		return nil
+	}
+
+	// Mined already, the monitor will pick it up.
+	confirmations, err := m.myBtcClient.GetTxConfirmations(ra.BtcHash)
+	if err != nil {
+		logger.WithField("btcTxId", ra.BtcHash).Warnf("cannot query btc redeem tx, bump it anyway: %v", err)
+	} else if confirmations > 0 {
+		return nil
+	}
+
+	feeRate, err := m.bumpFeeRate(ra.FeeRate)
+	if err != nil {
+		return err
+	}
+	if feeRate <= ra.FeeRate {
+		return fmt.Errorf("fee rate %d reaches the max %d, cannot bump", ra.FeeRate, MAX_FEE_RATE)
+	}
+
+	// A batch tx is replaced by a batch tx paying the same redeems.
+	members, err := m.mgrState.QueryAllByBtcTxId(ra.BtcHash)
+	if err != nil {
+		return err
+	}
+	if len(members) > 1 {
+		btcTxId, feeRate, err := m.bumpBatch(members, feeRate, ra.FeeRate, latest)
+		if err != nil {
+			return err
+		}
+		logger.WithFields(logger.Fields{
+			"num":         len(members),
+			"replacedTx":  ra.BtcHash,
+			"btcTxId":     btcTxId.String(),
+			"oldFeeRate":  ra.FeeRate,
+			"newFeeRate":  feeRate,
+			"unconfirmed": latest - ra.BroadcastBlk,
+		}).Info("BTC Batch Redeem Tx replaced with higher fee")
+		return nil
+	}
+
+	feeRate, err = m.affordableBumpFeeRate([]*state.Redeem{redeem}, feeRate, ra.FeeRate)
+	if err != nil {
+		return err
+	}
+
+	btcTxId, err := m.WithdrawBTC(redeem, feeRate)
+	if err != nil {
+		return err
+	}
+
+	logger.WithFields(logger.Fields{
+		"reqTxHash":   ra.EthRequestTxID,
+		"replacedTx":  ra.BtcHash,
+		"btcTxId":     btcTxId.String(),
+		"oldFeeRate":  ra.FeeRate,
+		"newFeeRate":  feeRate,
+		"unconfirmed": latest - ra.BroadcastBlk,
+	}).Info("BTC Redeem Tx replaced with higher fee")
+
+	return m.mgrState.ReplaceRedeemTx(&btcaction.RedeemAction{
+		EthRequestTxID: ra.EthRequestTxID,
+		BtcHash:        btcTxId.String(),
+		Sent:           true,
+		FeeRate:        feeRate,
+		BroadcastTime:  time.Now().Unix(),
+		BroadcastBlk:   latest,
+	})
+}
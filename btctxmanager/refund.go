@@ -0,0 +1,157 @@
+package btctxmanager
+
+/*
+	This file focus on refunds of malformed deposits.
+
+	A malformed deposit pays to the bridge but its OP_RETURN data is bad,
+	so nothing is minted. The monitor records it (btcaction.RefundStorage)
+	and locks its UTXO in the vault (see btcsync.ObserverRefund).
+
+	1. Wait for the review delay, so the operator can step in.
+	2. Sign and send out the refund tx: the deposit minus the fee, back to the sender.
+	3. Once the tx is confirmed, mark the deposit UTXO spent in the vault.
+
+	A refund that cannot be done automatically (no sender address, dust after fee)
+	is marked manual.
+*/
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/TEENet-io/bridge-go/btcaction"
+	"github.com/TEENet-io/bridge-go/btcman/utxo"
+	"github.com/TEENet-io/bridge-go/btcvault"
+	logger "github.com/sirupsen/logrus"
+)
+
+const (
+	REFUND_INTERVAL     = time.Minute    // how often to check refunds.
+	REFUND_REVIEW_DELAY = 24 * time.Hour // default, wait so long before refunding a malformed deposit.
+)
+
+// SetRefund enables refunds of malformed deposits, see RefundLoop().
+// delay: wait so long after the deposit is found, before refunding it (0=default).
+func (m *BtcTxManager) SetRefund(storage btcaction.RefundStorage, delay time.Duration) {
+	if delay <= 0 {
+		delay = REFUND_REVIEW_DELAY
+	}
+	m.refundState = storage
+	m.refundDelay = delay
+}
+
+// Refund sends the refund tx of a pending refund.
+// Returns the refund tx hash, empty if nothing is sent.
+func (m *BtcTxManager) Refund(r *btcaction.RefundAction) (string, error) {
+	fields := logger.Fields{
+		"btcTxId":    r.TxHash,
+		"vout":       r.Vout,
+		"refundAddr": r.RefundAddr,
+	}
+
+	// Normally locked when found, lock again in case it failed then.
+	linkedID := btcvault.REFUND_LINKED_PREFIX + r.TxHash
+	if err := m.treasureVault.LockUTXO(r.TxHash, int32(r.Vout), linkedID, btcvault.REFUND_LOCK_DELAY); err != nil {
+		return "", m.refundManually(r, fmt.Sprintf("cannot lock deposit utxo: %v", err))
+	}
+	vaultUTXO, err := m.treasureVault.GetUTXODetail(r.TxHash, int32(r.Vout))
+	if err != nil {
+		return "", err
+	}
+
+	feeRate, err := m.feeEstimator.EstimateFeeRate()
+	if err != nil {
+		return "", err
+	}
+	tx, err := m.myAssembler.MakeRefundTx(r.RefundAddr, feeRate, []*utxo.UTXO{ConvertUTXO(vaultUTXO)})
+	if err != nil {
+		return "", m.refundManually(r, fmt.Sprintf("cannot make refund tx: %v", err))
+	}
+	refundTxId, err := m.myBtcClient.SendRawTx(tx)
+	if err != nil {
+		return "", err
+	}
+
+	logger.WithFields(fields).WithFields(logger.Fields{
+		"refundTxId": refundTxId.String(),
+		"refund":     tx.TxOut[0].Value,
+		"feeRate":    feeRate,
+	}).Info("BTC Refund Tx sent")
+
+	return refundTxId.String(), m.refundState.SetRefundSent(r.TxHash, refundTxId.String(), time.Now().Unix())
+}
+
+// refundManually gives up the automatic refund, the operator shall handle it.
+func (m *BtcTxManager) refundManually(r *btcaction.RefundAction, reason string) error {
+	logger.WithField("btcTxId", r.TxHash).Warnf("malformed deposit needs a manual refund: %s", reason)
+	return m.refundState.SetRefundStatus(r.TxHash, btcaction.REFUND_STATUS_MANUAL, r.Reason+"; "+reason)
+}
+
+// ProcessRefunds refunds the pending malformed deposits past the review delay.
+func (m *BtcTxManager) ProcessRefunds() error {
+	pending, err := m.refundState.GetRefundsByStatus(btcaction.REFUND_STATUS_PENDING)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().Unix()
+	for i := range pending {
+		if now-pending[i].CreatedAt < int64(m.refundDelay.Seconds()) {
+			continue
+		}
+		if _, err := m.Refund(&pending[i]); err != nil {
+			logger.WithField("btcTxId", pending[i].TxHash).Errorf("Failed to refund malformed deposit: %v", err)
+		}
+	}
+	return nil
+}
+
+// CheckRefunds marks the deposit UTXOs of confirmed refund txs spent in the vault.
+func (m *BtcTxManager) CheckRefunds() error {
+	sent, err := m.refundState.GetRefundsByStatus(btcaction.REFUND_STATUS_SENT)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range sent {
+		confirmations, err := m.myBtcClient.GetTxConfirmations(r.RefundTxHash)
+		if err != nil {
+			logger.WithField("refundTxId", r.RefundTxHash).Warnf("cannot query btc refund tx: %v", err)
+			continue
+		}
+		if confirmations == 0 {
+			continue
+		}
+
+		if err := m.treasureVault.SpendByLinkedID(btcvault.REFUND_LINKED_PREFIX + r.TxHash); err != nil {
+			return err
+		}
+		if err := m.refundState.SetRefundStatus(r.TxHash, btcaction.REFUND_STATUS_MINED, ""); err != nil {
+			return err
+		}
+		logger.WithFields(logger.Fields{
+			"btcTxId":    r.TxHash,
+			"refundTxId": r.RefundTxHash,
+		}).Info("BTC Refund Tx confirmed")
+	}
+	return nil
+}
+
+// RefundLoop periodically refunds malformed deposits.
+// Call it in a separate go routine, after SetRefund().
+func (m *BtcTxManager) RefundLoop() {
+	if m.refundState == nil {
+		logger.Warn("refund is not set, quit refund loop")
+		return
+	}
+
+	for {
+		if err := m.CheckRefunds(); err != nil {
+			logger.Errorf("Failed to check refund txs: %v", err)
+		}
+		if err := m.ProcessRefunds(); err != nil {
+			logger.Errorf("Failed to process refunds: %v", err)
+		}
+		time.Sleep(REFUND_INTERVAL)
+	}
+}
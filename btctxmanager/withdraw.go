@@ -28,19 +28,39 @@ import (
 
 const (
 	QUERY_REDEEM_DB_INTERVAL = 10 * time.Second
-	BTC_TX_FEE               = int64(0.0001 * 1e8) // 0.0001 BTC = 10,000 satoshi
+
+	// fee rate (satoshi/vbyte) of redeem txs.
+	FEE_CONF_TARGET   = 6   // blocks, target of the btc node fee estimation.
+	FALLBACK_FEE_RATE = 10  // used when btc node cannot estimate (eg. regtest).
+	MIN_FEE_RATE      = 1   // min relay fee rate of btc nodes.
+	MAX_FEE_RATE      = 500 // protection against crazy estimations.
 )
 
+// RedeemSource gives the redeems to pay,
+// a single state.State, or a chainregistry.Registry of several destination chains.
+type RedeemSource interface {
+	GetPreparedRedeems() ([]*state.Redeem, error)
+}
+
 type BtcTxManager struct {
-	treasureVault *btcvault.TreasureVault   // where to query details of UTXOs.
-	legacySigner  *assembler.NativeOperator // who signs the txs.
-	myAssembler   *assembler.Assembler
-	myBtcClient   *rpc.RpcClient                // send/query btc blockchain.
-	sharedState   *state.State                  // fetch and update the shared state. (communicate with eth side)
-	mgrState      btcaction.RedeemActionStorage // tracker of redeems.
+	treasureVault      *btcvault.TreasureVault   // where to query details of UTXOs.
+	legacySigner       *assembler.NativeOperator // who signs the txs.
+	myAssembler        *assembler.Assembler
+	myBtcClient        *rpc.RpcClient                 // send/query btc blockchain.
+	feeEstimator       assembler.FeeEstimator         // fee rate of redeem txs.
+	rbfAfterBlks       int64                          // bump the fee of a redeem tx unconfirmed after ? blocks.
+	batchWindow        time.Duration                  // wait so long to batch redeems into one tx, 0: no batching.
+	maxBatchSize       int                            // max redeems paid by one batch tx.
+	pendingSince       map[string]time.Time           // new redeems waiting for a batch, and since when.
+	consolidationState btcaction.ConsolidationStorage // (optional) tracker of consolidation txs.
+	consolidationCfg   *ConsolidationConfig           // (optional) when and how to consolidate UTXOs.
+	refundState        btcaction.RefundStorage        // (optional) tracker of refunds of malformed deposits.
+	refundDelay        time.Duration                  // review delay before a refund is sent.
+	sharedState        RedeemSource                   // fetch and update the shared state. (communicate with eth side)
+	mgrState           btcaction.RedeemActionStorage  // tracker of redeems.
 }
 
-func NewBtcTxManager(treasureVault *btcvault.TreasureVault, legacySigner *assembler.NativeOperator, myBtcClient *rpc.RpcClient, sharedState *state.State, mgrState btcaction.RedeemActionStorage) *BtcTxManager {
+func NewBtcTxManager(treasureVault *btcvault.TreasureVault, legacySigner *assembler.NativeOperator, myBtcClient *rpc.RpcClient, sharedState RedeemSource, mgrState btcaction.RedeemActionStorage) *BtcTxManager {
 	return &BtcTxManager{
 		treasureVault: treasureVault,
 		legacySigner:  legacySigner,
@@ -49,11 +69,30 @@ func NewBtcTxManager(treasureVault *btcvault.TreasureVault, legacySigner *assemb
 			Op:          legacySigner,
 		},
 		myBtcClient: myBtcClient,
-		sharedState: sharedState,
-		mgrState:    mgrState,
+		feeEstimator: assembler.NewBoundedFeeEstimator(
+			rpc.NewSmartFeeEstimator(myBtcClient, FEE_CONF_TARGET),
+			FALLBACK_FEE_RATE,
+			MIN_FEE_RATE,
+			MAX_FEE_RATE,
+		),
+		rbfAfterBlks: RBF_AFTER_BLOCKS,
+		maxBatchSize: MAX_BATCH_SIZE,
+		pendingSince: make(map[string]time.Time),
+		sharedState:  sharedState,
+		mgrState:     mgrState,
 	}
 }
 
+// SetFeeEstimator replaces the default fee estimator (btc node with static fallback).
+func (m *BtcTxManager) SetFeeEstimator(feeEstimator assembler.FeeEstimator) {
+	m.feeEstimator = feeEstimator
+}
+
+// FeeEstimator gives the fee estimator of redeem txs.
+func (m *BtcTxManager) FeeEstimator() assembler.FeeEstimator {
+	return m.feeEstimator
+}
+
 // Find "prepared" redeems from local shared "state"
 func (m *BtcTxManager) FindRedeemsFromState() ([]*state.Redeem, error) {
 	redeems, err := m.sharedState.GetPreparedRedeems()
@@ -108,7 +147,8 @@ func (m *BtcTxManager) CollectUTXOs(redeem *state.Redeem) ([]*utxo.UTXO, error)
 }
 
 // CreateBTCRedeemTx creates a redeem transaction for the given redeem.
-func (m *BtcTxManager) CreateBTCRedeemTx(redeem *state.Redeem) (*wire.MsgTx, error) {
+// feeRate is in satoshi/vbyte.
+func (m *BtcTxManager) CreateBTCRedeemTx(redeem *state.Redeem, feeRate int64) (*wire.MsgTx, error) {
 	// Collect UTXOs to be spent
 	utxos, err := m.CollectUTXOs(redeem)
 	if err != nil {
@@ -120,20 +160,27 @@ func (m *BtcTxManager) CreateBTCRedeemTx(redeem *state.Redeem) (*wire.MsgTx, err
 
 	dst_addr := utils.Remove0xPrefix(redeem.Receiver)
 	dst_amount := redeem.Amount.Int64()
+	change_addr := m.legacySigner.P2PKH.EncodeAddress()
+
+	fee, err := m.myAssembler.EstimateRedeemFee(dst_addr, dst_amount, requestTxHash, change_addr, feeRate, utxos)
+	if err != nil {
+		return nil, err
+	}
 
 	logger.WithFields(logger.Fields{
 		"dst_addr":           dst_addr,
 		"dst_amount":         dst_amount,
 		"requestTxHash":      redeem.RequestTxHash.Hex(),
-		"btc_tx_fee (extra)": BTC_TX_FEE,
+		"fee_rate (sat/vB)":  feeRate,
+		"btc_tx_fee (extra)": fee,
 	}).Info("Create BTC Redeem Tx")
 
 	redeemTx, err := m.myAssembler.MakeRedeemTx(
 		dst_addr,
 		dst_amount,
-		requestTxHash,                        // we just fill in the eth redeem request tx hash as the identifier.
-		m.legacySigner.P2PKH.EncodeAddress(), // change recevier addr
-		BTC_TX_FEE,                           // TODO: remove hard code of fee.
+		requestTxHash, // we just fill in the eth redeem request tx hash as the identifier.
+		change_addr,   // change recevier addr
+		feeRate,
 		utxos,
 	)
 	if err != nil {
@@ -143,10 +190,57 @@ func (m *BtcTxManager) CreateBTCRedeemTx(redeem *state.Redeem) (*wire.MsgTx, err
 	return redeemTx, nil
 }
 
+// AffordableFeeRate caps feeRate at the highest fee rate the UTXOs of the redeems can pay.
+// The UTXOs are chosen and prepared on chain with the fee rate back then,
+// they cannot change, a higher fee rate since then would fail the tx forever.
+func (m *BtcTxManager) AffordableFeeRate(redeems []*state.Redeem, feeRate int64) (int64, error) {
+	utxos, err := m.CollectBatchUTXOs(redeems)
+	if err != nil {
+		return 0, err
+	}
+	change_addr := m.legacySigner.P2PKH.EncodeAddress()
+
+	var payouts []assembler.RedeemPayout
+	for _, redeem := range redeems {
+		var requestTxHash [32]byte
+		copy(requestTxHash[:], redeem.RequestTxHash.Bytes())
+		payouts = append(payouts, assembler.RedeemPayout{
+			DstAddr:    utils.Remove0xPrefix(redeem.Receiver),
+			DstAmount:  redeem.Amount.Int64(),
+			RedeemData: requestTxHash,
+		})
+	}
+
+	var maxFeeRate int64
+	if len(payouts) == 1 {
+		maxFeeRate, err = m.myAssembler.MaxRedeemFeeRate(payouts[0].DstAddr, payouts[0].DstAmount, payouts[0].RedeemData, change_addr, utxos)
+	} else {
+		maxFeeRate, err = m.myAssembler.MaxBatchRedeemFeeRate(payouts, change_addr, utxos)
+	}
+	if err != nil {
+		return 0, err
+	}
+	if maxFeeRate < MIN_FEE_RATE {
+		return 0, fmt.Errorf("utxos of the redeem(s) cannot pay the min fee rate %d, max=%d", MIN_FEE_RATE, maxFeeRate)
+	}
+
+	if feeRate > maxFeeRate {
+		logger.WithFields(logger.Fields{
+			"num":        len(redeems),
+			"reqTxHash":  redeems[0].RequestTxHash.Hex(),
+			"feeRate":    feeRate,
+			"maxFeeRate": maxFeeRate,
+		}).Warn("Fee rate capped at what the utxos of the redeem(s) can pay")
+		return maxFeeRate, nil
+	}
+	return feeRate, nil
+}
+
 // WithdrawBTC sends a redeem transaction to the Bitcoin network.
-func (m *BtcTxManager) WithdrawBTC(redeem *state.Redeem) (*chainhash.Hash, error) {
+// feeRate is in satoshi/vbyte.
+func (m *BtcTxManager) WithdrawBTC(redeem *state.Redeem, feeRate int64) (*chainhash.Hash, error) {
 
-	redeemTx, err := m.CreateBTCRedeemTx(redeem)
+	redeemTx, err := m.CreateBTCRedeemTx(redeem, feeRate)
 	if err != nil {
 		return nil, err
 	}
@@ -177,6 +271,7 @@ func (m *BtcTxManager) WithdrawLoop() {
 			continue
 		}
 
+		var fresh []*state.Redeem // new redeems waiting for a batch.
 		for _, redeem := range redeems {
 
 			// Check if the redeem requestTxId already exists in mgrState
@@ -200,6 +295,16 @@ func (m *BtcTxManager) WithdrawLoop() {
 						"sent":      ra.Sent,
 						"mined":     ra.Mined,
 					}).Debug("btc redeem tracked in our mgr db")
+
+					// Sent but stuck? replace it with a higher fee.
+					if ra != nil && ra.Sent && !ra.Mined {
+						if err := m.MaybeBumpRedeem(redeem, ra); err != nil {
+							logger.WithFields(logger.Fields{
+								"reqTxHash": reqTxHash,
+								"btcTxId":   ra.BtcHash,
+							}).Errorf("Failed to bump fee of btc redeem tx: %v", err)
+						}
+					}
 				}
 				continue
 			}
@@ -212,7 +317,30 @@ func (m *BtcTxManager) WithdrawLoop() {
 				"receiver":   redeem.Receiver,
 			}).Info("New BTC Redeem to withdraw")
 
-			btcTxId, err := m.WithdrawBTC(redeem)
+			// Batch mode, send it later together with others.
+			if m.batchWindow > 0 {
+				fresh = append(fresh, redeem)
+				continue
+			}
+
+			feeRate, err := m.feeEstimator.EstimateFeeRate()
+			if err != nil {
+				logger.WithField("reqTxHash", reqTxHash).Errorf("Failed to estimate fee rate: %v", err)
+				continue
+			}
+			feeRate, err = m.AffordableFeeRate([]*state.Redeem{redeem}, feeRate)
+			if err != nil {
+				logger.WithField("reqTxHash", reqTxHash).Errorf("Failed to cap fee rate: %v", err)
+				continue
+			}
+			// Remember when it is sent, to bump the fee if it gets stuck.
+			broadcastBlk, err := m.myBtcClient.GetLatestBlockHeight()
+			if err != nil {
+				logger.WithField("reqTxHash", reqTxHash).Errorf("Failed to get latest btc block height: %v", err)
+				continue
+			}
+
+			btcTxId, err := m.WithdrawBTC(redeem, feeRate)
 			if err != nil {
 				// Log the error and continue with the next redeem
 				fields := logger.Fields{
@@ -231,6 +359,7 @@ func (m *BtcTxManager) WithdrawLoop() {
 			logger.WithFields(logger.Fields{
 				"reqTxHash": reqTxHash,
 				"btcTxId":   btcTxId.String(),
+				"feeRate":   feeRate,
 			}).Info("BTC Redeem withdraw Tx sent")
 
 			// Insert the redeem record in mgrState (wait for future update of "mined" field)
@@ -238,6 +367,9 @@ func (m *BtcTxManager) WithdrawLoop() {
 				EthRequestTxID: reqTxHash,
 				BtcHash:        btcTxId.String(),
 				Sent:           true,
+				FeeRate:        feeRate,
+				BroadcastTime:  time.Now().Unix(),
+				BroadcastBlk:   broadcastBlk,
 			})
 			if err != nil {
 				// Log the error and continue with the next redeem
@@ -245,6 +377,14 @@ func (m *BtcTxManager) WithdrawLoop() {
 			}
 		}
 
+		if m.batchWindow > 0 {
+			if batch := m.nextBatch(m.queueRedeems(fresh)); batch != nil {
+				if err := m.sendBatch(batch); err != nil {
+					logger.WithField("num", len(batch)).Errorf("build & withdraw BTC batch tx error: %v", err)
+				}
+			}
+		}
+
 		time.Sleep(QUERY_REDEEM_DB_INTERVAL)
 	}
 }
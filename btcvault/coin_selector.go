@@ -0,0 +1,434 @@
+package btcvault
+
+/*
+	Coin selection: which UTXOs of the vault to spend for a target amount.
+
+	Strategies:
+	- largest-first: fewest inputs, the default (same as QueryEnoughUTXOs).
+	- oldest-first: spend the UTXOs of the lowest block number first.
+	- random: random order, doesn't reveal the vault's UTXO set by pattern.
+	- bnb: branch-and-bound, search a changeless selection, falls back to largest-first.
+
+	The target excludes the fees of the inputs, the selectors sum the effective values
+	(amount - fee to spend the UTXO) against it. Eg. a redeem of amount
+	has target = amount + fee of the tx without inputs and change (see RedeemOverheadFee).
+
+	A selection is changeless if its excess (effective sum - target) leaves a change
+	below the dust limit: the tx has no change output, the excess goes to the miner.
+	Otherwise the excess pays the change output and the change is not dust.
+	(The assembler drops a change below assembler.DUST_LIMIT the same way.)
+
+	Waste (the same metric as bitcoin core) compares the selections:
+
+	waste = sum(input vsize * (fee rate - long term fee rate))
+	      + change cost (if a change output is needed) or excess (if changeless)
+
+	Spending inputs when the fee rate is high is waste, spending them when
+	it is low saves fees later. Lower waste is better.
+*/
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+const (
+	COIN_SELECTOR_LARGEST_FIRST = "largest-first"
+	COIN_SELECTOR_OLDEST_FIRST  = "oldest-first"
+	COIN_SELECTOR_RANDOM        = "random"
+	COIN_SELECTOR_BNB           = "bnb"
+
+	BNB_MAX_TRIES = 100000 // give up the branch-and-bound search after so many steps.
+
+	SELECTION_HISTORY_SIZE = 100 // targets of the latest selections kept to replay, see TreasureVault.CompareSelectors().
+
+	// Default selection params.
+	DEFAULT_FEE_RATE           = 10  // satoshi/vbyte
+	DEFAULT_LONG_TERM_FEE_RATE = 10  // satoshi/vbyte
+	DEFAULT_INPUT_VSIZE        = 148 // P2PKH input
+	DEFAULT_CHANGE_OUTPUT      = 34  // P2PKH output
+
+	CHANGE_DUST_LIMIT = 546 // change below this amount (satoshi) is not output, same as assembler.DUST_LIMIT.
+)
+
+var ErrNoChangelessSelection = errors.New("no changeless selection found")
+
+// SelectionParams gives the costs to evaluate a selection.
+type SelectionParams struct {
+	FeeRate         int64 // satoshi/vbyte, now
+	LongTermFeeRate int64 // satoshi/vbyte, expected when the UTXOs are spent later
+	InputVSize      int64 // vsize of each input
+	ChangeVSize     int64 // vsize of a change output
+}
+
+// DefaultSelectionParams gives the params of P2PKH inputs and change.
+func DefaultSelectionParams() SelectionParams {
+	return SelectionParams{
+		FeeRate:         DEFAULT_FEE_RATE,
+		LongTermFeeRate: DEFAULT_LONG_TERM_FEE_RATE,
+		InputVSize:      DEFAULT_INPUT_VSIZE,
+		ChangeVSize:     DEFAULT_CHANGE_OUTPUT,
+	}
+}
+
+// inputFee is the fee (satoshi) to spend a single input now.
+func (p SelectionParams) inputFee() int64 {
+	return p.FeeRate * p.InputVSize
+}
+
+// inputWaste is the waste (satoshi) of spending a single input now rather than later.
+func (p SelectionParams) inputWaste() int64 {
+	return (p.FeeRate - p.LongTermFeeRate) * p.InputVSize
+}
+
+// ChangeCost is the cost (satoshi) of creating a change output now and spending it later.
+func (p SelectionParams) ChangeCost() int64 {
+	return p.FeeRate*p.ChangeVSize + p.LongTermFeeRate*p.InputVSize
+}
+
+// changelessLimit is the excess (satoshi) from which the change output is paid
+// and the change is not dust. Less excess goes to the miner, without change output.
+func (p SelectionParams) changelessLimit() int64 {
+	return p.FeeRate*p.ChangeVSize + CHANGE_DUST_LIMIT
+}
+
+// effectiveValue is the amount of the UTXO minus the fee to spend it.
+func (p SelectionParams) effectiveValue(utxo VaultUTXO) int64 {
+	return utxo.Amount - p.inputFee()
+}
+
+// SelectionResult is a selection of UTXOs and its metrics.
+type SelectionResult struct {
+	Selector   string      // name of the strategy
+	UTXOs      []VaultUTXO // selected
+	Sum        int64       // sum of the selected amounts
+	Target     int64       // the requested amount, without the fees of the inputs
+	Waste      int64       // see the top of this file
+	Changeless bool        // the change would be dust, no change output
+}
+
+// newSelectionResult evaluates the selected UTXOs against the target.
+func newSelectionResult(selector string, utxos []VaultUTXO, target int64, params SelectionParams) *SelectionResult {
+	r := &SelectionResult{Selector: selector, UTXOs: utxos, Target: target}
+	var effective int64
+	for _, utxo := range utxos {
+		r.Sum += utxo.Amount
+		effective += params.effectiveValue(utxo)
+		r.Waste += params.inputWaste()
+	}
+
+	excess := effective - target
+	if excess >= 0 && excess < params.changelessLimit() {
+		r.Changeless = true
+		r.Waste += excess
+	} else {
+		r.Waste += params.ChangeCost()
+	}
+	return r
+}
+
+// CoinSelector chooses UTXOs (from the usable ones) to cover the target amount.
+type CoinSelector interface {
+	// Name of the strategy
+	Name() string
+
+	// Select returns error if the target cannot be covered.
+	// The effective value (see SelectionParams) of the selected UTXOs covers the target.
+	Select(utxos []VaultUTXO, target int64, params SelectionParams) (*SelectionResult, error)
+}
+
+// NewCoinSelector creates a CoinSelector by name (see COIN_SELECTOR_*).
+// Empty name gives the default largest-first.
+func NewCoinSelector(name string) (CoinSelector, error) {
+	switch name {
+	case "", COIN_SELECTOR_LARGEST_FIRST:
+		return &LargestFirstSelector{}, nil
+	case COIN_SELECTOR_OLDEST_FIRST:
+		return &OldestFirstSelector{}, nil
+	case COIN_SELECTOR_RANDOM:
+		return &RandomSelector{}, nil
+	case COIN_SELECTOR_BNB:
+		return &BranchAndBoundSelector{Fallback: &LargestFirstSelector{}}, nil
+	default:
+		return nil, fmt.Errorf("unknown coin selector %s", name)
+	}
+}
+
+// accumulate picks the UTXOs in the given order until the effective sum covers the target.
+// UTXOs not worth the fee to spend them are skipped.
+func accumulate(name string, ordered []VaultUTXO, target int64, params SelectionParams) (*SelectionResult, error) {
+	var picked []VaultUTXO
+	var sum int64
+	for _, utxo := range ordered {
+		if params.effectiveValue(utxo) <= 0 {
+			continue
+		}
+		picked = append(picked, utxo)
+		sum += params.effectiveValue(utxo)
+		if sum >= target {
+			return newSelectionResult(name, picked, target, params), nil
+		}
+	}
+	return nil, fmt.Errorf("not enough UTXOs to cover the amount required, required=%v, have=%v", target, sum)
+}
+
+// LargestFirstSelector spends the largest UTXOs first, fewest inputs.
+type LargestFirstSelector struct{}
+
+func (s *LargestFirstSelector) Name() string {
+	return COIN_SELECTOR_LARGEST_FIRST
+}
+
+func (s *LargestFirstSelector) Select(utxos []VaultUTXO, target int64, params SelectionParams) (*SelectionResult, error) {
+	ordered := append([]VaultUTXO(nil), utxos...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].Amount > ordered[j].Amount
+	})
+	return accumulate(s.Name(), ordered, target, params)
+}
+
+// OldestFirstSelector spends the UTXOs of the lowest block number first.
+type OldestFirstSelector struct{}
+
+func (s *OldestFirstSelector) Name() string {
+	return COIN_SELECTOR_OLDEST_FIRST
+}
+
+func (s *OldestFirstSelector) Select(utxos []VaultUTXO, target int64, params SelectionParams) (*SelectionResult, error) {
+	ordered := append([]VaultUTXO(nil), utxos...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if ordered[i].BlockNumber != ordered[j].BlockNumber {
+			return ordered[i].BlockNumber < ordered[j].BlockNumber
+		}
+		return ordered[i].Amount > ordered[j].Amount
+	})
+	return accumulate(s.Name(), ordered, target, params)
+}
+
+// RandomSelector spends the UTXOs in random order.
+// Observers cannot tell the vault's UTXO set from the pattern of the inputs.
+type RandomSelector struct{}
+
+func (s *RandomSelector) Name() string {
+	return COIN_SELECTOR_RANDOM
+}
+
+func (s *RandomSelector) Select(utxos []VaultUTXO, target int64, params SelectionParams) (*SelectionResult, error) {
+	ordered := append([]VaultUTXO(nil), utxos...)
+	rand.Shuffle(len(ordered), func(i, j int) {
+		ordered[i], ordered[j] = ordered[j], ordered[i]
+	})
+	return accumulate(s.Name(), ordered, target, params)
+}
+
+// BranchAndBoundSelector searches a changeless selection with the least waste:
+// the effective value covers the target, and the excess leaves a dust change (see changelessLimit).
+// If there is none, the Fallback selector is used (if set).
+type BranchAndBoundSelector struct {
+	Fallback CoinSelector
+}
+
+func (s *BranchAndBoundSelector) Name() string {
+	return COIN_SELECTOR_BNB
+}
+
+func (s *BranchAndBoundSelector) Select(utxos []VaultUTXO, target int64, params SelectionParams) (*SelectionResult, error) {
+	result, err := s.search(utxos, target, params)
+	if err == ErrNoChangelessSelection && s.Fallback != nil {
+		return s.Fallback.Select(utxos, target, params)
+	}
+	return result, err
+}
+
+func (s *BranchAndBoundSelector) search(utxos []VaultUTXO, target int64, params SelectionParams) (*SelectionResult, error) {
+	// Only the UTXOs worth more than the fee to spend them.
+	var candidates []VaultUTXO
+	for _, utxo := range utxos {
+		if params.effectiveValue(utxo) > 0 {
+			candidates = append(candidates, utxo)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Amount > candidates[j].Amount
+	})
+
+	// remaining[i] = sum of effective values of candidates[i:]
+	remaining := make([]int64, len(candidates)+1)
+	for i := len(candidates) - 1; i >= 0; i-- {
+		remaining[i] = remaining[i+1] + params.effectiveValue(candidates[i])
+	}
+
+	limit := target + params.changelessLimit()
+	tries := 0
+	var best []int
+	var bestWaste int64
+
+	var selected []int
+	var walk func(i int, sum int64, waste int64)
+	walk = func(i int, sum int64, waste int64) {
+		tries++
+		if tries > BNB_MAX_TRIES || sum >= limit {
+			return
+		}
+		// When spending now is expensive, more inputs only adds waste.
+		if best != nil && params.inputWaste() > 0 && waste > bestWaste {
+			return
+		}
+		if sum >= target {
+			if w := waste + sum - target; best == nil || w < bestWaste {
+				best = append([]int(nil), selected...)
+				bestWaste = w
+			}
+			return
+		}
+		if i == len(candidates) || sum+remaining[i] < target {
+			return
+		}
+
+		// include candidates[i]
+		selected = append(selected, i)
+		walk(i+1, sum+params.effectiveValue(candidates[i]), waste+params.inputWaste())
+		selected = selected[:len(selected)-1]
+
+		// exclude candidates[i], skip the equal ones (same result)
+		j := i + 1
+		for j < len(candidates) && candidates[j].Amount == candidates[i].Amount {
+			j++
+		}
+		walk(j, sum, waste)
+	}
+	walk(0, 0, 0)
+
+	if best == nil {
+		return nil, ErrNoChangelessSelection
+	}
+	chosen := make([]VaultUTXO, len(best))
+	for k, idx := range best {
+		chosen[k] = candidates[idx]
+	}
+	return newSelectionResult(s.Name(), chosen, target, params), nil
+}
+
+// SelectionStats accumulates the metrics of selections by a strategy.
+type SelectionStats struct {
+	Selector   string
+	Count      int   // selections done
+	Failed     int   // targets that cannot be covered
+	Inputs     int   // total inputs
+	Changeless int   // selections without change output
+	Waste      int64 // total waste
+}
+
+func (st *SelectionStats) add(r *SelectionResult) {
+	st.Count++
+	st.Inputs += len(r.UTXOs)
+	if r.Changeless {
+		st.Changeless++
+	}
+	st.Waste += r.Waste
+}
+
+// selectionStatsRecorder is safe for concurrent use.
+// It keeps the stats of the current strategy,
+// and the targets of the latest selections (whatever the strategy).
+type selectionStatsRecorder struct {
+	mu      sync.Mutex
+	stats   SelectionStats
+	targets []int64
+}
+
+// reset the stats for the strategy, the targets are kept.
+func (rec *selectionStatsRecorder) reset(selector string) {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	rec.stats = SelectionStats{Selector: selector}
+}
+
+func (rec *selectionStatsRecorder) add(r *SelectionResult) {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	rec.stats.add(r)
+	rec.addTarget(r.Target)
+}
+
+func (rec *selectionStatsRecorder) fail(target int64) {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	rec.stats.Failed++
+	rec.addTarget(target)
+}
+
+func (rec *selectionStatsRecorder) addTarget(target int64) {
+	rec.targets = append(rec.targets, target)
+	if len(rec.targets) > SELECTION_HISTORY_SIZE {
+		rec.targets = rec.targets[len(rec.targets)-SELECTION_HISTORY_SIZE:]
+	}
+}
+
+func (rec *selectionStatsRecorder) get() SelectionStats {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return rec.stats
+}
+
+func (rec *selectionStatsRecorder) getTargets() []int64 {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return append([]int64(nil), rec.targets...)
+}
+
+// CompareSelectors replays the targets (eg. amounts of the past redeems) in order
+// against the UTXO set with each strategy. Selected UTXOs are removed from the set
+// for the following targets, as the vault does.
+// Returns the stats of each strategy, to compare their waste.
+func CompareSelectors(selectors []CoinSelector, utxos []VaultUTXO, targets []int64, params SelectionParams) []SelectionStats {
+	var all []SelectionStats
+	for _, selector := range selectors {
+		stats := SelectionStats{Selector: selector.Name()}
+		pool := append([]VaultUTXO(nil), utxos...)
+		for _, target := range targets {
+			r, err := selector.Select(pool, target, params)
+			if err != nil {
+				stats.Failed++
+				continue
+			}
+			stats.add(r)
+			pool = withoutUTXOs(pool, r.UTXOs)
+		}
+		logger.WithFields(logger.Fields{
+			"selector":   stats.Selector,
+			"count":      stats.Count,
+			"failed":     stats.Failed,
+			"inputs":     stats.Inputs,
+			"changeless": stats.Changeless,
+			"waste":      stats.Waste,
+		}).Info("Coin Selector Replay")
+		all = append(all, stats)
+	}
+	return all
+}
+
+// withoutUTXOs removes the spent UTXOs from the pool.
+func withoutUTXOs(pool []VaultUTXO, spent []VaultUTXO) []VaultUTXO {
+	used := make(map[string]bool, len(spent))
+	for _, utxo := range spent {
+		used[outpointKey(utxo.TxID, utxo.Vout)] = true
+	}
+	var left []VaultUTXO
+	for _, utxo := range pool {
+		if !used[outpointKey(utxo.TxID, utxo.Vout)] {
+			left = append(left, utxo)
+		}
+	}
+	return left
+}
+
+// outpointKey identifies a UTXO in maps.
+func outpointKey(txID string, vout int32) string {
+	return fmt.Sprintf("%s:%d", txID, vout)
+}
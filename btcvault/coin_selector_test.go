@@ -0,0 +1,171 @@
+package btcvault
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// input fee 1000, input waste 500, change cost 800, changeless below an excess of 846
+var testParams = SelectionParams{
+	FeeRate:         10,
+	LongTermFeeRate: 5,
+	InputVSize:      100,
+	ChangeVSize:     30,
+}
+
+func testUTXO(txID string, amount int64, blockNumber int32) VaultUTXO {
+	return VaultUTXO{TxID: txID, Amount: amount, BlockNumber: blockNumber}
+}
+
+func amountsOf(utxos []VaultUTXO) []int64 {
+	var amounts []int64
+	for _, utxo := range utxos {
+		amounts = append(amounts, utxo.Amount)
+	}
+	return amounts
+}
+
+func TestSelectionParams(t *testing.T) {
+	assert.Equal(t, int64(1000), testParams.inputFee())
+	assert.Equal(t, int64(500), testParams.inputWaste())
+	assert.Equal(t, int64(800), testParams.ChangeCost())
+	assert.Equal(t, int64(846), testParams.changelessLimit())
+	assert.Equal(t, int64(10*(REDEEM_TX_OVERHEAD_VSIZE+REDEEM_RECEIVER_VSIZE+REDEEM_OP_RETURN_VSIZE)), testParams.RedeemOverheadFee())
+}
+
+func TestNewSelectionResult(t *testing.T) {
+	// excess 845: the change would be dust, it goes to the miner
+	r := newSelectionResult("test", []VaultUTXO{testUTXO("a", 10000+1000+845, 1)}, 10000, testParams)
+	assert.True(t, r.Changeless)
+	assert.Equal(t, int64(500+845), r.Waste)
+	assert.Equal(t, int64(11845), r.Sum)
+
+	// excess 846: pays the change output, the change is not dust
+	r = newSelectionResult("test", []VaultUTXO{testUTXO("a", 10000+1000+846, 1)}, 10000, testParams)
+	assert.False(t, r.Changeless)
+	assert.Equal(t, int64(500+800), r.Waste)
+
+	// spending now is cheaper than later, inputs reduce the waste
+	cheap := testParams
+	cheap.FeeRate, cheap.LongTermFeeRate = 5, 10
+	r = newSelectionResult("test", []VaultUTXO{testUTXO("a", 20000, 1), testUTXO("b", 20000, 1)}, 10000, cheap)
+	assert.False(t, r.Changeless)
+	assert.Equal(t, int64(2*-500+cheap.ChangeCost()), r.Waste)
+}
+
+func TestLargestFirstSelector(t *testing.T) {
+	utxos := []VaultUTXO{testUTXO("a", 5000, 1), testUTXO("b", 20000, 2), testUTXO("c", 10000, 3)}
+	s := &LargestFirstSelector{}
+
+	r, err := s.Select(utxos, 15000, testParams)
+	assert.NoError(t, err)
+	assert.Equal(t, COIN_SELECTOR_LARGEST_FIRST, r.Selector)
+	assert.Equal(t, []int64{20000}, amountsOf(r.UTXOs))
+	assert.Equal(t, int64(500+800), r.Waste)
+
+	// the fees of the inputs are covered: 19000 + 9000 >= 25000
+	r, err = s.Select(utxos, 25000, testParams)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{20000, 10000}, amountsOf(r.UTXOs))
+	assert.Equal(t, int64(2*500+800), r.Waste)
+
+	// 19000 + 9000 + 4000 < 35000 (raw sum 35000)
+	_, err = s.Select(utxos, 35000, testParams)
+	assert.Error(t, err)
+}
+
+func TestOldestFirstSelector(t *testing.T) {
+	utxos := []VaultUTXO{testUTXO("a", 20000, 3), testUTXO("b", 6000, 1), testUTXO("c", 10000, 2), testUTXO("d", 8000, 1)}
+	s := &OldestFirstSelector{}
+
+	r, err := s.Select(utxos, 14000, testParams)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{8000, 6000, 10000}, amountsOf(r.UTXOs))
+}
+
+func TestRandomSelector(t *testing.T) {
+	utxos := []VaultUTXO{testUTXO("a", 20000, 1), testUTXO("b", 6000, 1), testUTXO("c", 10000, 1), testUTXO("d", 8000, 1)}
+	s := &RandomSelector{}
+
+	for i := 0; i < 20; i++ {
+		r, err := s.Select(utxos, 20000, testParams)
+		assert.NoError(t, err)
+		var effective int64
+		for _, utxo := range r.UTXOs {
+			effective += testParams.effectiveValue(utxo)
+		}
+		assert.GreaterOrEqual(t, effective, int64(20000))
+		// the last one is needed
+		last := r.UTXOs[len(r.UTXOs)-1]
+		assert.Less(t, effective-testParams.effectiveValue(last), int64(20000))
+	}
+}
+
+func TestSelectorsSkipUneconomicUTXOs(t *testing.T) {
+	// 900 is not worth the input fee of 1000
+	utxos := []VaultUTXO{testUTXO("a", 900, 1), testUTXO("b", 6000, 2)}
+	for _, s := range []CoinSelector{&LargestFirstSelector{}, &OldestFirstSelector{}, &RandomSelector{}} {
+		r, err := s.Select(utxos, 4000, testParams)
+		assert.NoError(t, err, s.Name())
+		assert.Equal(t, []int64{6000}, amountsOf(r.UTXOs), s.Name())
+	}
+}
+
+func TestBranchAndBoundExactMatch(t *testing.T) {
+	utxos := []VaultUTXO{testUTXO("a", 20000, 1), testUTXO("b", 11000, 1), testUTXO("c", 6000, 1), testUTXO("d", 5000, 1)}
+	s := &BranchAndBoundSelector{Fallback: &LargestFirstSelector{}}
+
+	// 10000 + 5000 = 15000, no excess
+	r, err := s.Select(utxos, 15000, testParams)
+	assert.NoError(t, err)
+	assert.Equal(t, COIN_SELECTOR_BNB, r.Selector)
+	assert.ElementsMatch(t, []int64{11000, 6000}, amountsOf(r.UTXOs))
+	assert.True(t, r.Changeless)
+	assert.Equal(t, int64(2*500), r.Waste)
+
+	// less waste than largest-first, which needs a change output
+	lf, err := (&LargestFirstSelector{}).Select(utxos, 15000, testParams)
+	assert.NoError(t, err)
+	assert.False(t, lf.Changeless)
+	assert.Less(t, r.Waste, lf.Waste)
+
+	// within the changeless limit: 19000 - 18500 = 500 excess
+	r, err = s.Select(utxos, 18500, testParams)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{20000}, amountsOf(r.UTXOs))
+	assert.True(t, r.Changeless)
+	assert.Equal(t, int64(500+500), r.Waste)
+}
+
+func TestBranchAndBoundFallback(t *testing.T) {
+	utxos := []VaultUTXO{testUTXO("a", 20000, 1), testUTXO("b", 50000, 1)}
+
+	// no changeless selection
+	_, err := (&BranchAndBoundSelector{}).Select(utxos, 15000, testParams)
+	assert.Equal(t, ErrNoChangelessSelection, err)
+
+	r, err := (&BranchAndBoundSelector{Fallback: &LargestFirstSelector{}}).Select(utxos, 15000, testParams)
+	assert.NoError(t, err)
+	assert.Equal(t, COIN_SELECTOR_LARGEST_FIRST, r.Selector)
+	assert.Equal(t, []int64{50000}, amountsOf(r.UTXOs))
+	assert.False(t, r.Changeless)
+
+	// not enough at all
+	_, err = (&BranchAndBoundSelector{Fallback: &LargestFirstSelector{}}).Select(utxos, 100000, testParams)
+	assert.Error(t, err)
+}
+
+func TestCompareSelectors(t *testing.T) {
+	utxos := []VaultUTXO{testUTXO("a", 20000, 1), testUTXO("b", 11000, 2), testUTXO("c", 6000, 3), testUTXO("d", 5000, 4)}
+	selectors := []CoinSelector{&LargestFirstSelector{}, &BranchAndBoundSelector{Fallback: &LargestFirstSelector{}}}
+
+	// the selected UTXOs are gone for the following targets
+	all := CompareSelectors(selectors, utxos, []int64{15000, 15000, 15000}, testParams)
+	assert.Len(t, all, 2)
+
+	// largest-first: [20000], [11000 6000], then 4000 is not enough
+	assert.Equal(t, SelectionStats{Selector: COIN_SELECTOR_LARGEST_FIRST, Count: 2, Failed: 1, Inputs: 3, Changeless: 1, Waste: (500 + 800) + (2 * 500)}, all[0])
+	// bnb: [11000 6000] changeless, [20000] by fallback, then 4000 is not enough
+	assert.Equal(t, SelectionStats{Selector: COIN_SELECTOR_BNB, Count: 2, Failed: 1, Inputs: 3, Changeless: 1, Waste: (2 * 500) + (500 + 800)}, all[1])
+}
@@ -0,0 +1,208 @@
+package btcvault
+
+/*
+	Vault maintenance: release the expired UTXO locks safely.
+
+	ChooseAndLock locks UTXOs for a redeem request (linked ID = request tx hash)
+	until the lock expires. If the prepare of the request failed or was never mined,
+	the UTXOs shall be usable again. But a lock is released only if it is provably not in use:
+	1) the request is not prepared on chain (the prepare commits to the outpoints), and
+	2) no redeem btc tx is sent for the request.
+	Locks of consolidations are left to the consolidation job.
+*/
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+
+	"github.com/TEENet-io/bridge-go/btcaction"
+	"github.com/TEENet-io/bridge-go/common"
+)
+
+const (
+	MAINTENANCE_INTERVAL = 10 * time.Minute // ? time between vault maintenance.
+
+	CONSOLIDATION_LINKED_PREFIX = "consolidation-" // linked ID prefix of consolidation locks.
+	REFUND_LINKED_PREFIX        = "refund-"        // linked ID prefix of refund locks (malformed deposits).
+
+	REFUND_LOCK_DELAY int64 = 30 * 24 * 3600 // a month, the UTXO of a malformed deposit is locked until refunded.
+)
+
+// PreparedChecker tells if a redeem request is prepared on chain.
+// (eg. chaintxmgr.MgrWorker)
+type PreparedChecker interface {
+	IsPrepared(requestTxId [32]byte) (bool, error)
+}
+
+// LockInfo is the locked UTXOs of a linked ID.
+type LockInfo struct {
+	LinkedID string // request tx hash (no 0x prefix), a consolidation or a refund
+	UTXOs    int    // number of locked UTXOs
+	Amount   int64  // sum of them, in satoshi
+	Timeout  int64  // Unix timestamp in seconds, the earliest expiry of them
+	Expired  bool
+}
+
+// LockReport lists the locks (not spent UTXOs) by linked ID, the earliest expiry first.
+func (tv *TreasureVault) LockReport() ([]LockInfo, error) {
+	utxos, err := tv.backend.QueryAllUTXOs()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().Unix()
+	byLinkedID := make(map[string]*LockInfo)
+	for _, utxo := range utxos {
+		if !utxo.Lockup || utxo.Spent {
+			continue
+		}
+		info, ok := byLinkedID[utxo.LinkedId]
+		if !ok {
+			info = &LockInfo{LinkedID: utxo.LinkedId, Timeout: utxo.Timeout}
+			byLinkedID[utxo.LinkedId] = info
+		}
+		info.UTXOs++
+		info.Amount += utxo.Amount
+		if utxo.Timeout < info.Timeout {
+			info.Timeout = utxo.Timeout
+		}
+		info.Expired = info.Timeout < now
+	}
+
+	report := make([]LockInfo, 0, len(byLinkedID))
+	for _, info := range byLinkedID {
+		report = append(report, *info)
+	}
+	sort.Slice(report, func(i, j int) bool {
+		return report[i].Timeout < report[j].Timeout
+	})
+	return report, nil
+}
+
+// VaultMaintainer periodically releases the expired locks of a vault.
+type VaultMaintainer struct {
+	vault    *TreasureVault
+	prepared PreparedChecker               // is the request prepared on chain?
+	mgrState btcaction.RedeemActionStorage // is a redeem btc tx sent for the request?
+	interval time.Duration
+}
+
+func NewVaultMaintainer(vault *TreasureVault, prepared PreparedChecker, mgrState btcaction.RedeemActionStorage) *VaultMaintainer {
+	return &VaultMaintainer{
+		vault:    vault,
+		prepared: prepared,
+		mgrState: mgrState,
+		interval: MAINTENANCE_INTERVAL,
+	}
+}
+
+// canRelease tells if the locks of the linked ID are provably not in use.
+// Any error means not provable, keep the lock.
+func (vm *VaultMaintainer) canRelease(linkedID string) bool {
+	if linkedID == "" {
+		return true
+	}
+	if strings.HasPrefix(linkedID, CONSOLIDATION_LINKED_PREFIX) || strings.HasPrefix(linkedID, REFUND_LINKED_PREFIX) {
+		return false
+	}
+	fields := logger.Fields{"linkedId": linkedID}
+
+	ra, err := vm.mgrState.QueryByEthRequestTxId(linkedID)
+	if err != nil {
+		logger.WithFields(fields).Warnf("cannot query redeem of expired lock: %v", err)
+		return false
+	}
+	if ra != nil && ra.Sent {
+		logger.WithFields(fields).Debug("expired lock is used by a sent redeem, keep it")
+		return false
+	}
+
+	var requestTxId [32]byte
+	b := common.HexStrToByteSlice(linkedID)
+	if len(b) != len(requestTxId) {
+		logger.WithFields(fields).Warn("expired lock is linked to an unknown id, keep it")
+		return false
+	}
+	copy(requestTxId[:], b)
+	prepared, err := vm.prepared.IsPrepared(requestTxId)
+	if err != nil {
+		logger.WithFields(fields).Warnf("cannot query prepare of expired lock: %v", err)
+		return false
+	}
+	if prepared {
+		logger.WithFields(fields).Debug("expired lock is used by a prepared redeem, keep it")
+		return false
+	}
+	return true
+}
+
+// Maintain releases the expired locks that are provably not in use, once.
+func (vm *VaultMaintainer) Maintain() ([]string, error) {
+	released, err := vm.vault.ReleaseExpired(vm.canRelease)
+	for _, linkedID := range released {
+		logger.WithField("linkedId", linkedID).Info("Expired UTXO lock released")
+	}
+	return released, err
+}
+
+// Report logs the locks of the vault.
+func (vm *VaultMaintainer) Report() ([]LockInfo, error) {
+	report, err := vm.vault.LockReport()
+	if err != nil {
+		return nil, err
+	}
+	for _, info := range report {
+		logger.WithFields(logger.Fields{
+			"linkedId": info.LinkedID,
+			"utxos":    info.UTXOs,
+			"amount":   info.Amount,
+			"timeout":  info.Timeout,
+			"expired":  info.Expired,
+		}).Info("UTXO Lock")
+	}
+	return report, nil
+}
+
+// ReportSelections logs the stats of the selections done by the vault,
+// and how the other strategies would do on the same targets (see TreasureVault.CompareSelectors()).
+func (vm *VaultMaintainer) ReportSelections() ([]SelectionStats, error) {
+	stats := vm.vault.SelectionStats()
+	logger.WithFields(logger.Fields{
+		"selector":   stats.Selector,
+		"count":      stats.Count,
+		"failed":     stats.Failed,
+		"inputs":     stats.Inputs,
+		"changeless": stats.Changeless,
+		"waste":      stats.Waste,
+	}).Info("Coin Selector Stats")
+
+	var selectors []CoinSelector
+	for _, name := range []string{COIN_SELECTOR_LARGEST_FIRST, COIN_SELECTOR_OLDEST_FIRST, COIN_SELECTOR_RANDOM, COIN_SELECTOR_BNB} {
+		selector, err := NewCoinSelector(name)
+		if err != nil {
+			return nil, err
+		}
+		selectors = append(selectors, selector)
+	}
+	return vm.vault.CompareSelectors(selectors)
+}
+
+// MaintainLoop maintains the vault periodically.
+// Call it in a separate go routine.
+func (vm *VaultMaintainer) MaintainLoop() {
+	for {
+		if _, err := vm.Maintain(); err != nil {
+			logger.Errorf("failed to release expired utxo locks: %v", err)
+		}
+		if _, err := vm.Report(); err != nil {
+			logger.Errorf("failed to report utxo locks: %v", err)
+		}
+		if _, err := vm.ReportSelections(); err != nil {
+			logger.Errorf("failed to report coin selections: %v", err)
+		}
+		time.Sleep(vm.interval)
+	}
+}
@@ -0,0 +1,145 @@
+package btcvault
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/TEENet-io/bridge-go/btcaction"
+	"github.com/TEENet-io/bridge-go/common"
+	"github.com/stretchr/testify/assert"
+)
+
+var errLookup = errors.New("lookup failed")
+
+type testPreparedChecker struct {
+	prepared map[[32]byte]bool
+	err      error
+}
+
+func (c *testPreparedChecker) IsPrepared(requestTxId [32]byte) (bool, error) {
+	return c.prepared[requestTxId], c.err
+}
+
+// testRedeemStorage answers QueryByEthRequestTxId only, the maintainer needs nothing else.
+type testRedeemStorage struct {
+	btcaction.RedeemActionStorage
+	redeems map[string]*btcaction.RedeemAction
+	err     error
+}
+
+func (s *testRedeemStorage) QueryByEthRequestTxId(ethRequestTxID string) (*btcaction.RedeemAction, error) {
+	return s.redeems[ethRequestTxID], s.err
+}
+
+func newTestMaintainer(tv *TreasureVault) (*VaultMaintainer, *testPreparedChecker, *testRedeemStorage) {
+	prepared := &testPreparedChecker{prepared: make(map[[32]byte]bool)}
+	mgrState := &testRedeemStorage{redeems: make(map[string]*btcaction.RedeemAction)}
+	return NewVaultMaintainer(tv, prepared, mgrState), prepared, mgrState
+}
+
+// lockExpired locks the UTXO by linkedID with a lock that expired already.
+func lockExpired(t *testing.T, tv *TreasureVault, txID string, linkedID string) {
+	if err := tv.LockUTXO(txID, 0, linkedID, -10); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCanRelease(t *testing.T) {
+	tv, _ := newTestVault(t)
+	vm, prepared, mgrState := newTestMaintainer(tv)
+
+	requestTxId := common.RandBytes32()
+	linkedID := common.ByteSliceToPureHexStr(requestTxId[:])
+
+	// nothing refers to the lock
+	assert.True(t, vm.canRelease(linkedID))
+	assert.True(t, vm.canRelease(""))
+
+	// prepared on chain, the prepare commits to the outpoints
+	prepared.prepared[requestTxId] = true
+	assert.False(t, vm.canRelease(linkedID))
+	prepared.prepared[requestTxId] = false
+
+	// a redeem btc tx is sent
+	mgrState.redeems[linkedID] = &btcaction.RedeemAction{EthRequestTxID: linkedID, Sent: true}
+	assert.False(t, vm.canRelease(linkedID))
+	mgrState.redeems[linkedID].Sent = false
+	assert.True(t, vm.canRelease(linkedID))
+
+	// not provable
+	prepared.err = errLookup
+	assert.False(t, vm.canRelease(linkedID))
+	prepared.err = nil
+	mgrState.err = errLookup
+	assert.False(t, vm.canRelease(linkedID))
+	mgrState.err = nil
+
+	// not a request tx hash
+	assert.False(t, vm.canRelease("not-a-request"))
+
+	// left to the consolidation job and the refunds
+	assert.False(t, vm.canRelease(CONSOLIDATION_LINKED_PREFIX+"1"))
+	assert.False(t, vm.canRelease(REFUND_LINKED_PREFIX+linkedID))
+}
+
+func TestMaintainReleasesExpiredLocks(t *testing.T) {
+	tv, _ := newTestVault(t)
+	vm, prepared, mgrState := newTestMaintainer(tv)
+	txIds := addTestUTXOs(t, tv, 100000, 200000, 300000, 400000, 500000, 600000, 700000)
+
+	linkedIDs := make([]string, 4)
+	requestTxIds := make([][32]byte, 4)
+	for i := range linkedIDs {
+		requestTxIds[i] = common.RandBytes32()
+		linkedIDs[i] = common.ByteSliceToPureHexStr(requestTxIds[i][:])
+	}
+	free, preparedID, sentID, erroredID := linkedIDs[0], linkedIDs[1], linkedIDs[2], linkedIDs[3]
+
+	lockExpired(t, tv, txIds[0], free)
+	lockExpired(t, tv, txIds[1], preparedID)
+	prepared.prepared[requestTxIds[1]] = true
+	lockExpired(t, tv, txIds[2], sentID)
+	mgrState.redeems[sentID] = &btcaction.RedeemAction{EthRequestTxID: sentID, Sent: true}
+	lockExpired(t, tv, txIds[3], CONSOLIDATION_LINKED_PREFIX+"1")
+	lockExpired(t, tv, txIds[4], REFUND_LINKED_PREFIX+txIds[4])
+	// not expired yet
+	if err := tv.LockUTXO(txIds[5], 0, common.ByteSliceToPureHexStr(common.RandBytes(32)), TIMEOUT_DELAY); err != nil {
+		t.Fatal(err)
+	}
+
+	released, err := vm.Maintain()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{free}, released)
+
+	for i, txId := range txIds {
+		utxo, err := tv.GetUTXODetail(txId, 0)
+		assert.NoError(t, err)
+		switch i {
+		case 0, 6:
+			assert.False(t, utxo.Lockup, txId)
+			assert.Empty(t, utxo.LinkedId, txId)
+		default:
+			assert.True(t, utxo.Lockup, txId)
+		}
+	}
+
+	// the lookups fail, keep every lock
+	lockExpired(t, tv, txIds[6], erroredID)
+	prepared.err = errLookup
+	released, err = vm.Maintain()
+	assert.NoError(t, err)
+	assert.Empty(t, released)
+	prepared.err = nil
+	mgrState.err = errLookup
+	released, err = vm.Maintain()
+	assert.NoError(t, err)
+	assert.Empty(t, released)
+
+	mgrState.err = nil
+	released, err = vm.Maintain()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{erroredID}, released)
+	utxo, err := tv.GetUTXODetail(txIds[6], 0)
+	assert.NoError(t, err)
+	assert.False(t, utxo.Lockup)
+}
@@ -0,0 +1,73 @@
+package btcvault
+
+import (
+	logger "github.com/sirupsen/logrus"
+)
+
+// ReconciliationReport compares the spends of vault UTXOs observed on chain
+// against the txs the bridge sent (redeems, consolidations).
+type ReconciliationReport struct {
+	Spent       int         // UTXOs spent on chain
+	SpentAmount int64       // sum of them, in satoshi
+	Unexpected  []SpentUTXO // spent by a tx the bridge didn't send
+	Unobserved  []VaultUTXO // marked spent, but the spending tx is not observed on chain (yet)
+}
+
+// Reconcile builds the report.
+// isExpected tells if a spending tx (id, no 0x prefix) is sent by the bridge.
+func (tv *TreasureVault) Reconcile(isExpected func(txID string) bool) (*ReconciliationReport, error) {
+	report := &ReconciliationReport{}
+
+	spents, err := tv.backend.QueryAllSpent()
+	if err != nil {
+		return nil, err
+	}
+	observed := make(map[string]bool, len(spents))
+	for _, spent := range spents {
+		utxo, err := tv.backend.QueryByTxIDAndVout(spent.RelatedTxID, spent.RelatedVout)
+		if err != nil {
+			return nil, err
+		}
+		if utxo == nil {
+			continue
+		}
+		observed[outpointKey(spent.RelatedTxID, spent.RelatedVout)] = true
+		report.Spent++
+		report.SpentAmount += utxo.Amount
+		if !isExpected(spent.TxID) {
+			report.Unexpected = append(report.Unexpected, spent)
+		}
+	}
+
+	utxos, err := tv.backend.QueryAllUTXOs()
+	if err != nil {
+		return nil, err
+	}
+	for _, utxo := range utxos {
+		if utxo.Spent && !observed[outpointKey(utxo.TxID, utxo.Vout)] {
+			report.Unobserved = append(report.Unobserved, utxo)
+		}
+	}
+
+	return report, nil
+}
+
+// Log prints the report, the unexpected spends are warned one by one.
+func (r *ReconciliationReport) Log() {
+	logger.WithFields(logger.Fields{
+		"spent":       r.Spent,
+		"spentAmount": r.SpentAmount,
+		"unexpected":  len(r.Unexpected),
+		"unobserved":  len(r.Unobserved),
+	}).Info("UTXO Vault Reconciliation")
+
+	for _, spent := range r.Unexpected {
+		logger.WithFields(logger.Fields{
+			"txid":        spent.RelatedTxID,
+			"vout":        spent.RelatedVout,
+			"spentBy":     spent.TxID,
+			"vin":         spent.Vin,
+			"blockNumber": spent.BlockNumber,
+		}).Warn("UTXO spent by unexpected tx")
+	}
+}
@@ -10,6 +10,7 @@ import (
 // VaultSQLiteStorage implements VaultUTXOStorage for SQLite
 type VaultSQLiteStorage struct {
 	uniqueTableID string
+	spentTableID  string // who spent the UTXOs
 	db            *sql.DB
 }
 
@@ -21,7 +22,7 @@ func NewVaultSQLiteStorage(dbFilePath string, uniqueID string) (*VaultSQLiteStor
 		return nil, err
 	}
 
-	storage := &VaultSQLiteStorage{db: db, uniqueTableID: "vault_utxo_" + uniqueID}
+	storage := &VaultSQLiteStorage{db: db, uniqueTableID: "vault_utxo_" + uniqueID, spentTableID: "spent_utxo_" + uniqueID}
 	if err := storage.init(); err != nil {
 		return nil, err
 	}
@@ -31,6 +32,7 @@ func NewVaultSQLiteStorage(dbFilePath string, uniqueID string) (*VaultSQLiteStor
 
 // init initializes the VaultUTXO table and creates an index on tx_id
 // if not existed before.
+// Also the SpentUTXO table, keyed by the spent UTXO.
 func (s *VaultSQLiteStorage) init() error {
 	query := fmt.Sprintf(`
 	CREATE TABLE IF NOT EXISTS %s (
@@ -47,7 +49,16 @@ func (s *VaultSQLiteStorage) init() error {
 		PRIMARY KEY (tx_id, vout)
 	);
 	CREATE INDEX IF NOT EXISTS idx_tx_id ON %s (tx_id);
-	`, s.uniqueTableID, s.uniqueTableID)
+	CREATE TABLE IF NOT EXISTS %s (
+		related_tx_id TEXT,
+		related_vout INTEGER,
+		block_number INTEGER,
+		block_hash TEXT,
+		tx_id TEXT,
+		vin INTEGER,
+		PRIMARY KEY (related_tx_id, related_vout)
+	);
+	`, s.uniqueTableID, s.uniqueTableID, s.spentTableID)
 	_, err := s.db.Exec(query)
 	return err
 }
@@ -322,6 +333,27 @@ func (s *VaultSQLiteStorage) SetLinkedID(txID string, vout int32, linkedID strin
 	return err
 }
 
+// SetBlock sets the block number and block hash of a VaultUTXO identified by txID and vout
+func (s *VaultSQLiteStorage) SetBlock(txID string, vout int32, blockNumber int32, blockHash string) error {
+	query := fmt.Sprintf(`
+	UPDATE %s
+	SET block_number = ?, block_hash = ?
+	WHERE tx_id = ? AND vout = ?;
+	`, s.uniqueTableID)
+	_, err := s.db.Exec(query, blockNumber, blockHash, txID, vout)
+	return err
+}
+
+// DeleteByBlockHash removes the not locked & not spent UTXOs with the specified block hash
+func (s *VaultSQLiteStorage) DeleteByBlockHash(blockHash string) error {
+	query := fmt.Sprintf(`
+	DELETE FROM %s
+	WHERE block_hash = ? AND lockup = 0 AND spent = 0;
+	`, s.uniqueTableID)
+	_, err := s.db.Exec(query, blockHash)
+	return err
+}
+
 // SetTimeout sets the expiry timepoint of a VaultUTXO identified by txID and vout
 func (s *VaultSQLiteStorage) SetTimeout(txID string, vout int32, timeout int64) error {
 	query := fmt.Sprintf(`
@@ -349,3 +381,79 @@ func (s *VaultSQLiteStorage) SumMoney() (int64, error) {
 	}
 	return total, nil
 }
+
+// InsertSpent records who spent a VaultUTXO (replaces the old record, eg. after a reorg)
+func (s *VaultSQLiteStorage) InsertSpent(spent SpentUTXO) error {
+	query := fmt.Sprintf(`
+	INSERT OR REPLACE INTO %s (related_tx_id, related_vout, block_number, block_hash, tx_id, vin)
+	VALUES (?, ?, ?, ?, ?, ?);
+	`, s.spentTableID)
+	_, err := s.db.Exec(query, spent.RelatedTxID, spent.RelatedVout, spent.BlockNumber, spent.BlockHash, spent.TxID, spent.Vin)
+	return err
+}
+
+// QuerySpent retrieves who spent the VaultUTXO identified by txID and vout, nil if not found
+func (s *VaultSQLiteStorage) QuerySpent(txID string, vout int32) (*SpentUTXO, error) {
+	query := fmt.Sprintf(`
+	SELECT related_tx_id, related_vout, block_number, block_hash, tx_id, vin
+	FROM %s
+	WHERE related_tx_id = ? AND related_vout = ?;
+	`, s.spentTableID)
+	var spent SpentUTXO
+	err := s.db.QueryRow(query, txID, vout).Scan(&spent.RelatedTxID, &spent.RelatedVout, &spent.BlockNumber, &spent.BlockHash, &spent.TxID, &spent.Vin)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &spent, nil
+}
+
+// QuerySpentByBlockHash retrieves the spends recorded in the specified block
+func (s *VaultSQLiteStorage) QuerySpentByBlockHash(blockHash string) ([]SpentUTXO, error) {
+	query := fmt.Sprintf(`
+	SELECT related_tx_id, related_vout, block_number, block_hash, tx_id, vin
+	FROM %s
+	WHERE block_hash = ?;
+	`, s.spentTableID)
+	return s.querySpents(query, blockHash)
+}
+
+// QueryAllSpent retrieves all the spends
+func (s *VaultSQLiteStorage) QueryAllSpent() ([]SpentUTXO, error) {
+	query := fmt.Sprintf(`
+	SELECT related_tx_id, related_vout, block_number, block_hash, tx_id, vin
+	FROM %s
+	ORDER BY block_number;
+	`, s.spentTableID)
+	return s.querySpents(query)
+}
+
+func (s *VaultSQLiteStorage) querySpents(query string, args ...any) ([]SpentUTXO, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var spents []SpentUTXO
+	for rows.Next() {
+		var spent SpentUTXO
+		if err := rows.Scan(&spent.RelatedTxID, &spent.RelatedVout, &spent.BlockNumber, &spent.BlockHash, &spent.TxID, &spent.Vin); err != nil {
+			return nil, err
+		}
+		spents = append(spents, spent)
+	}
+	return spents, nil
+}
+
+// DeleteSpent removes the spend record of the VaultUTXO identified by txID and vout
+func (s *VaultSQLiteStorage) DeleteSpent(txID string, vout int32) error {
+	query := fmt.Sprintf(`
+	DELETE FROM %s
+	WHERE related_tx_id = ? AND related_vout = ?;
+	`, s.spentTableID)
+	_, err := s.db.Exec(query, txID, vout)
+	return err
+}
@@ -14,6 +14,16 @@ type VaultUTXO struct {
 	LinkedId    string // Linked ID, if the utxo is linked to a spender ID, like reqTxHash from ETH side.
 }
 
+// SpentUTXO records the tx input that spends a VaultUTXO
+type SpentUTXO struct {
+	RelatedTxID string // the spent VaultUTXO, 64-character hexadecimal string (no 0x prefix)
+	RelatedVout int32  // the spent VaultUTXO
+	BlockNumber int32  // Block number (height) of the spending tx
+	BlockHash   string // Block hash of the spending tx (no 0x prefix)
+	TxID        string // the spending tx (no 0x prefix)
+	Vin         int32  // Input index of the spending tx
+}
+
 // VaultUTXOStorage defines the interface for database operations on VaultUTXO
 type VaultUTXOStorage interface {
 	// InsertVaultUTXO inserts a new VaultUTXO into the database
@@ -61,6 +71,28 @@ type VaultUTXOStorage interface {
 	// Set the linked ID to the UTXO
 	SetLinkedID(txID string, vout int32, linkedID string) error
 
+	// SetBlock sets the block number and block hash of a VaultUTXO identified by txID and vout
+	// (the same tx can be mined in another block after a reorg)
+	SetBlock(txID string, vout int32, blockNumber int32, blockHash string) error
+
+	// DeleteByBlockHash removes the usable (not locked, not spent) UTXOs of an orphaned block.
+	DeleteByBlockHash(blockHash string) error
+
+	// InsertSpent records who spent a VaultUTXO (replaces the old record)
+	InsertSpent(spent SpentUTXO) error
+
+	// QuerySpent retrieves who spent the VaultUTXO identified by txID and vout, nil if not found
+	QuerySpent(txID string, vout int32) (*SpentUTXO, error)
+
+	// QuerySpentByBlockHash retrieves the spends recorded in the specified block
+	QuerySpentByBlockHash(blockHash string) ([]SpentUTXO, error)
+
+	// QueryAllSpent retrieves all the spends
+	QueryAllSpent() ([]SpentUTXO, error)
+
+	// DeleteSpent removes the spend record of the VaultUTXO identified by txID and vout
+	DeleteSpent(txID string, vout int32) error
+
 	// SumMoney calculates the total amount of all VaultUTXOs
 	// Excludes locked UTXOs.
 	// Excludes spent UTXOs.
@@ -14,8 +14,12 @@ import (
 )
 
 const (
-	TIMEOUT_DELAY int64 = 3600                // an hour, then the utxo is considered released and can be used again.
-	SAFE_MARGIN         = int64(0.0001 * 1e8) // 0.0001 BTC = 10,000 satoshi
+	TIMEOUT_DELAY int64 = 3600 // an hour, then the utxo is considered released and can be used again.
+
+	// Estimated vsize of the parts of a redeem tx other than the inputs and the change (see btcman/assembler).
+	REDEEM_TX_OVERHEAD_VSIZE = 11 // version(4) + locktime(4) + input & output counts(2) + segwit marker & flag(1)
+	REDEEM_RECEIVER_VSIZE    = 43 // output to the receiver, P2WSH/P2TR (the largest standard one)
+	REDEEM_OP_RETURN_VSIZE   = 43 // OP_RETURN output of the redeem data (32 bytes)
 )
 
 // TreasureVault is a vault that stores UTXOs
@@ -24,12 +28,85 @@ type TreasureVault struct {
 	BtcAddress string           // the wallet holds the money
 	backend    VaultUTXOStorage // the backend engine
 	updateMu   sync.Mutex       // prevent concurrent updates
+	selector   CoinSelector     // which UTXOs to spend, see coin_selector.go
+	paramsMu   sync.Mutex       // protects params and feeSource
+	params     SelectionParams  // costs to evaluate a selection
+	feeSource  FeeRateSource    // (optional) current fee rate, overrides params.FeeRate
+	stats      selectionStatsRecorder
+}
+
+// FeeRateSource gives the current fee rate in satoshi/vbyte.
+// (eg. assembler.FeeEstimator)
+type FeeRateSource interface {
+	EstimateFeeRate() (int64, error)
 }
 
 // NewTreasureVault contains one btc address as identifier.
 // And uses any backend that implements VaultUTXOStorage.
+// UTXOs are selected largest-first, see SetCoinSelector().
 func NewTreasureVault(btcAddress string, backend VaultUTXOStorage) *TreasureVault {
-	return &TreasureVault{BtcAddress: btcAddress, backend: backend}
+	return &TreasureVault{
+		BtcAddress: btcAddress,
+		backend:    backend,
+		selector:   &LargestFirstSelector{},
+		params:     DefaultSelectionParams(),
+		stats:      selectionStatsRecorder{stats: SelectionStats{Selector: COIN_SELECTOR_LARGEST_FIRST}},
+	}
+}
+
+// SetCoinSelector sets the strategy to select UTXOs in ChooseAndLock().
+// The selection stats are reset.
+func (tv *TreasureVault) SetCoinSelector(selector CoinSelector) {
+	tv.updateMu.Lock()
+	defer tv.updateMu.Unlock()
+	tv.selector = selector
+	tv.stats.reset(selector.Name())
+}
+
+// SetSelectionParams sets the costs to evaluate a selection.
+func (tv *TreasureVault) SetSelectionParams(params SelectionParams) {
+	tv.paramsMu.Lock()
+	defer tv.paramsMu.Unlock()
+	tv.params = params
+}
+
+// SetFeeRateSource sets where to get the current fee rate for selections.
+func (tv *TreasureVault) SetFeeRateSource(feeSource FeeRateSource) {
+	tv.paramsMu.Lock()
+	defer tv.paramsMu.Unlock()
+	tv.feeSource = feeSource
+}
+
+// SelectionStats reports the metrics (eg. waste) of the selections done by ChooseAndLock().
+func (tv *TreasureVault) SelectionStats() SelectionStats {
+	return tv.stats.get()
+}
+
+// CompareSelectors replays the targets of the latest selections done by ChooseAndLock()
+// against the usable UTXOs with each strategy, at the current fee rate. See CompareSelectors().
+func (tv *TreasureVault) CompareSelectors(selectors []CoinSelector) ([]SelectionStats, error) {
+	params := tv.selectionParams()
+	usable, err := tv.backend.QueryAllUsableUTXOs()
+	if err != nil {
+		return nil, err
+	}
+	return CompareSelectors(selectors, usable, tv.stats.getTargets(), params), nil
+}
+
+// selectionParams gives the params with the current fee rate.
+// Don't call it with updateMu held, the fee rate may come from the btc node.
+func (tv *TreasureVault) selectionParams() SelectionParams {
+	tv.paramsMu.Lock()
+	params, feeSource := tv.params, tv.feeSource
+	tv.paramsMu.Unlock()
+
+	if feeSource != nil {
+		feeRate, err := feeSource.EstimateFeeRate()
+		if err == nil && feeRate > 0 {
+			params.FeeRate = feeRate
+		}
+	}
+	return params
 }
 
 // AddUTXO adds a new UTXO to the treasure vault
@@ -62,6 +139,10 @@ func (tv *TreasureVault) AddUTXO(
 	}
 	// Don't duplicate insert!
 	if old_utxo != nil {
+		// After a reorg the same tx can be mined in another block, follow it.
+		if old_utxo.BlockHash != blockHash {
+			return tv.backend.SetBlock(txID, vout, blockNumber, blockHash)
+		}
 		return fmt.Errorf("utxo already exists")
 	}
 
@@ -69,11 +150,31 @@ func (tv *TreasureVault) AddUTXO(
 }
 
 // ChooseAndLock selects UTXOs that sum to at least the target amount and locks them
-// In bitcoin world, ususally you need to specify the target amount big enough to inlucude the fee.
+// The UTXOs are selected by the coin selector of the vault (largest-first by default).
+// The UTXOs also cover the fee to spend them as inputs (see CoinSelector),
+// other fees of the tx shall be included in the target amount.
 // If the target amount cannot be satisfied, it will return nil + error.
 // The chosen UTXOs will mark the field with linkedID (like a unique identifier for the redeem, the reqTxHash)
 // It will prevent double-entry of same linkedID.
 func (tv *TreasureVault) ChooseAndLock(targetAmount int64, linkedID string) ([]VaultUTXO, error) {
+	return tv.chooseAndLock(linkedID, func(params SelectionParams) int64 {
+		return targetAmount
+	})
+}
+
+// ChooseAndLockWithFee is ChooseAndLock for a redeem of amount,
+// the selected UTXOs also cover the fee of the redeem tx that spends them (see RedeemOverheadFee).
+func (tv *TreasureVault) ChooseAndLockWithFee(amount int64, linkedID string) ([]VaultUTXO, error) {
+	return tv.chooseAndLock(linkedID, func(params SelectionParams) int64 {
+		return amount + params.RedeemOverheadFee()
+	})
+}
+
+// chooseAndLock selects UTXOs that cover target(params) and locks them.
+func (tv *TreasureVault) chooseAndLock(linkedID string, target func(params SelectionParams) int64) ([]VaultUTXO, error) {
+	// the fee rate is fetched before the lock is taken
+	params := tv.selectionParams()
+
 	// protection against concurrent updates
 	tv.updateMu.Lock()
 	defer tv.updateMu.Unlock()
@@ -88,33 +189,285 @@ func (tv *TreasureVault) ChooseAndLock(targetAmount int64, linkedID string) ([]V
 		return nil, fmt.Errorf("linkedID %s already exists, don't perform UTXO lock for it again", linkedID)
 	}
 
-	utxos, err := tv.backend.QueryEnoughUTXOs(targetAmount)
+	usable, err := tv.backend.QueryAllUsableUTXOs()
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := tv.selector.Select(usable, target(params), params)
+	if err != nil {
+		tv.stats.fail(target(params))
+		return nil, err
+	}
+	tv.stats.add(result)
+	logger.WithFields(logger.Fields{
+		"linkedId":   linkedID,
+		"selector":   result.Selector,
+		"target":     result.Target,
+		"sum":        result.Sum,
+		"inputs":     len(result.UTXOs),
+		"changeless": result.Changeless,
+		"waste":      result.Waste,
+	}).Info("UTXOs selected")
+
+	utxos := result.UTXOs
+	if err := tv.lock(utxos, linkedID, TIMEOUT_DELAY); err != nil {
+		return nil, err
+	}
+	return utxos, nil
+}
+
+// ChooseSmallestAndLock selects up to maxCount smallest usable UTXOs and locks them.
+// Used to consolidate (merge) small UTXOs, the lock lasts timeoutDelay seconds.
+// Like ChooseAndLock, it won't lock again for an existing linkedID.
+func (tv *TreasureVault) ChooseSmallestAndLock(maxCount int, linkedID string, timeoutDelay int64) ([]VaultUTXO, error) {
+	tv.updateMu.Lock()
+	defer tv.updateMu.Unlock()
+
+	hits, err := tv.backend.QueryByLinkedID(linkedID)
 	if err != nil {
 		return nil, err
 	}
+	if len(hits) > 0 {
+		return nil, fmt.Errorf("linkedID %s already exists, don't perform UTXO lock for it again", linkedID)
+	}
 
+	utxos, err := tv.backend.QueryAllUsableUTXOs()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(utxos, func(i, j int) bool {
+		return utxos[i].Amount < utxos[j].Amount
+	})
+	if len(utxos) > maxCount {
+		utxos = utxos[:maxCount]
+	}
+
+	if err := tv.lock(utxos, linkedID, timeoutDelay); err != nil {
+		return nil, err
+	}
+	return utxos, nil
+}
+
+// LockUTXO locks a specific UTXO by linkedID for timeoutDelay seconds.
+// Used to keep a UTXO away from ChooseAndLock (eg. a malformed deposit to be refunded).
+// Locking again by the same linkedID refreshes the timeout.
+func (tv *TreasureVault) LockUTXO(txID string, vout int32, linkedID string, timeoutDelay int64) error {
+	tv.updateMu.Lock()
+	defer tv.updateMu.Unlock()
+
+	utxo, err := tv.backend.QueryByTxIDAndVout(txID, vout)
+	if err != nil {
+		return err
+	}
+	if utxo == nil {
+		return fmt.Errorf("utxo not found")
+	}
+	if utxo.Spent {
+		return fmt.Errorf("utxo already spent")
+	}
+	if utxo.Lockup && utxo.LinkedId != linkedID {
+		return fmt.Errorf("utxo already locked by %s", utxo.LinkedId)
+	}
+
+	return tv.lock([]VaultUTXO{*utxo}, linkedID, timeoutDelay)
+}
+
+// lock marks the UTXOs as locked by linkedID for timeoutDelay seconds.
+func (tv *TreasureVault) lock(utxos []VaultUTXO, linkedID string, timeoutDelay int64) error {
 	for i, utxo := range utxos {
 		utxos[i].Lockup = true
 		err := tv.backend.SetLockup(utxo.TxID, utxo.Vout, true)
 		if err != nil {
-			return nil, err
+			return err
 		}
 
-		timepoint := time.Now().Unix() + TIMEOUT_DELAY
+		timepoint := time.Now().Unix() + timeoutDelay
 		utxos[i].Timeout = timepoint
 		err = tv.backend.SetTimeout(utxo.TxID, utxo.Vout, timepoint)
 		if err != nil {
-			return nil, err
+			return err
 		}
 
 		utxos[i].LinkedId = linkedID
 		err = tv.backend.SetLinkedID(utxo.TxID, utxo.Vout, linkedID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SpendByLinkedID marks the UTXOs locked by linkedID as spent,
+// once the tx spending them is confirmed.
+func (tv *TreasureVault) SpendByLinkedID(linkedID string) error {
+	tv.updateMu.Lock()
+	defer tv.updateMu.Unlock()
+
+	utxos, err := tv.backend.QueryByLinkedID(linkedID)
+	if err != nil {
+		return err
+	}
+	for _, utxo := range utxos {
+		if err := tv.backend.SetSpent(utxo.TxID, utxo.Vout, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetByLinkedID gives the UTXOs locked by linkedID (spent ones included).
+func (tv *TreasureVault) GetByLinkedID(linkedID string) ([]VaultUTXO, error) {
+	return tv.backend.QueryByLinkedID(linkedID)
+}
+
+// RelockByLinkedID renews the lock of the UTXOs locked by linkedID,
+// so a retried request (eg. a failed prepare) gets the same UTXOs again.
+// Returns nil if no UTXO is locked by linkedID.
+func (tv *TreasureVault) RelockByLinkedID(linkedID string) ([]VaultUTXO, error) {
+	tv.updateMu.Lock()
+	defer tv.updateMu.Unlock()
+
+	hits, err := tv.backend.QueryByLinkedID(linkedID)
+	if err != nil {
+		return nil, err
+	}
+	if len(hits) == 0 {
+		return nil, nil
+	}
+	for _, utxo := range hits {
+		if utxo.Spent {
+			return nil, fmt.Errorf("utxo %s:%d locked by linkedID %s is spent", utxo.TxID, utxo.Vout, linkedID)
+		}
+	}
+
+	if err := tv.lock(hits, linkedID, TIMEOUT_DELAY); err != nil {
+		return nil, err
+	}
+	return hits, nil
+}
+
+// SpendersByLinkedID gives the txs observed on chain spending the UTXOs locked by linkedID.
+func (tv *TreasureVault) SpendersByLinkedID(linkedID string) ([]string, error) {
+	utxos, err := tv.backend.QueryByLinkedID(linkedID)
+	if err != nil {
+		return nil, err
+	}
+
+	var spenders []string
+	seen := make(map[string]bool)
+	for _, utxo := range utxos {
+		if !utxo.Spent {
+			continue
+		}
+		spent, err := tv.backend.QuerySpent(utxo.TxID, utxo.Vout)
 		if err != nil {
 			return nil, err
 		}
+		if spent == nil || seen[spent.TxID] {
+			continue
+		}
+		seen[spent.TxID] = true
+		spenders = append(spenders, spent.TxID)
 	}
+	return spenders, nil
+}
 
-	return utxos, nil
+// ReleaseByLinkedID releases the UTXOs locked by linkedID,
+// eg. the tx spending them fails to be sent.
+// The link is removed as well, so the UTXOs can be chosen again.
+func (tv *TreasureVault) ReleaseByLinkedID(linkedID string) error {
+	tv.updateMu.Lock()
+	defer tv.updateMu.Unlock()
+
+	utxos, err := tv.backend.QueryByLinkedID(linkedID)
+	if err != nil {
+		return err
+	}
+	for _, utxo := range utxos {
+		if utxo.Spent {
+			continue
+		}
+		if err := tv.backend.SetLockup(utxo.TxID, utxo.Vout, false); err != nil {
+			return err
+		}
+		if err := tv.backend.SetTimeout(utxo.TxID, utxo.Vout, 0); err != nil {
+			return err
+		}
+		if err := tv.backend.SetLinkedID(utxo.TxID, utxo.Vout, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RemoveByBlockHash removes the UTXOs of a block that is orphaned by a reorg.
+// Locked or spent UTXOs are kept, they are referenced by a redeem,
+// and are reported so the operator can follow up.
+func (tv *TreasureVault) RemoveByBlockHash(blockHash string) error {
+	tv.updateMu.Lock()
+	defer tv.updateMu.Unlock()
+
+	utxos, err := tv.backend.QueryByBlockHash(blockHash)
+	if err != nil {
+		return err
+	}
+
+	for _, utxo := range utxos {
+		if utxo.Lockup || utxo.Spent {
+			logger.WithFields(logger.Fields{
+				"txid":     utxo.TxID,
+				"vout":     utxo.Vout,
+				"lockup":   utxo.Lockup,
+				"spent":    utxo.Spent,
+				"linkedId": utxo.LinkedId,
+			}).Warn("UTXO of orphaned block is in use, keep it")
+		}
+	}
+
+	return tv.backend.DeleteByBlockHash(blockHash)
+}
+
+// MarkSpent marks the VaultUTXO spent by a tx input observed on chain,
+// and records the spending tx.
+// Returns false if the spent outpoint is not in the vault.
+func (tv *TreasureVault) MarkSpent(spent SpentUTXO) (bool, error) {
+	tv.updateMu.Lock()
+	defer tv.updateMu.Unlock()
+
+	utxo, err := tv.backend.QueryByTxIDAndVout(spent.RelatedTxID, spent.RelatedVout)
+	if err != nil {
+		return false, err
+	}
+	if utxo == nil {
+		return false, nil
+	}
+
+	if err := tv.backend.SetSpent(spent.RelatedTxID, spent.RelatedVout, true); err != nil {
+		return true, err
+	}
+	return true, tv.backend.InsertSpent(spent)
+}
+
+// UnspendByBlockHash reverts the spends observed in a block that is orphaned by a reorg.
+// The spending txs go back to the mempool, the UTXOs keep their locks.
+func (tv *TreasureVault) UnspendByBlockHash(blockHash string) error {
+	tv.updateMu.Lock()
+	defer tv.updateMu.Unlock()
+
+	spents, err := tv.backend.QuerySpentByBlockHash(blockHash)
+	if err != nil {
+		return err
+	}
+	for _, spent := range spents {
+		if err := tv.backend.SetSpent(spent.RelatedTxID, spent.RelatedVout, false); err != nil {
+			return err
+		}
+		if err := tv.backend.DeleteSpent(spent.RelatedTxID, spent.RelatedVout); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // ReleaseByExpire releases UTXOs that have passed their timeout
@@ -138,6 +491,51 @@ func (tv *TreasureVault) ReleaseByExpire() error {
 	return nil
 }
 
+// CountUsableUTXOs gives the number of UTXOs that are not locked and not spent.
+func (tv *TreasureVault) CountUsableUTXOs() (int, error) {
+	utxos, err := tv.backend.QueryAllUsableUTXOs()
+	if err != nil {
+		return 0, err
+	}
+	return len(utxos), nil
+}
+
+// ReleaseExpired releases the expired locks (not spent) whose linked ID canRelease() approves.
+// Unlike ReleaseByExpire, the link is removed as well, so the request can lock UTXOs again.
+// Returns the released linked IDs.
+func (tv *TreasureVault) ReleaseExpired(canRelease func(linkedID string) bool) ([]string, error) {
+	utxos, err := tv.backend.QueryExpiredAndLockedUTXOs(time.Now().Unix())
+	if err != nil {
+		return nil, err
+	}
+
+	checked := make(map[string]bool)
+	var released []string
+	for _, utxo := range utxos {
+		if utxo.Spent || checked[utxo.LinkedId] {
+			continue
+		}
+		if !canRelease(utxo.LinkedId) {
+			checked[utxo.LinkedId] = true
+			continue
+		}
+
+		if utxo.LinkedId == "" {
+			// Locked without a link, release just this one.
+			if err := tv.ReleaseByCommand(utxo.TxID, utxo.Vout); err != nil {
+				return released, err
+			}
+			continue
+		}
+		checked[utxo.LinkedId] = true
+		if err := tv.ReleaseByLinkedID(utxo.LinkedId); err != nil {
+			return released, err
+		}
+		released = append(released, utxo.LinkedId)
+	}
+	return released, nil
+}
+
 // Quick function to reveal the current state of the vault
 func (tv *TreasureVault) Peek() ([]VaultUTXO, int64, error) {
 	_utxos, err := tv.backend.QueryAllUTXOs()
@@ -217,12 +615,17 @@ func (tv *TreasureVault) GetUTXODetail(txID string, vout int32) (*VaultUTXO, err
 	return utxo, nil
 }
 
+// RedeemOverheadFee is the fee (satoshi) of a redeem tx paying the receiver and the redeem data (OP_RETURN),
+// without the inputs (covered by their effective values) and the change (covered by the excess, see CoinSelector).
+func (p SelectionParams) RedeemOverheadFee() int64 {
+	return p.FeeRate * (REDEEM_TX_OVERHEAD_VSIZE + REDEEM_RECEIVER_VSIZE + REDEEM_OP_RETURN_VSIZE)
+}
+
 // Implement BtcUTXOResponder interface to interact with eth_tx_manager
 // eth_tx_manager will request and lock (write in smart contract)
 // about the UTXOs that are collected to satisify the redeem.
 // We can't collect just "barely" enough UTXOs to satisfy,
-// Indeed we need to estamate the btc tx fee and add to it.
-// at least leave some safe margin.
+// the UTXOs also cover the btc tx fee: current fee rate * estimated vsize (see RedeemOverheadFee).
 func (tv *TreasureVault) Request(
 	reqTxId []byte,
 	amount *big.Int,
@@ -230,18 +633,29 @@ func (tv *TreasureVault) Request(
 ) error {
 	tv.Status() // report status
 
-	safe_amount := amount.Int64() + SAFE_MARGIN
 	// if not enough utxos, return error
 	logger.WithFields(logger.Fields{
-		"req_tx_id":   common.ByteSliceToPureHexStr(reqTxId),
-		"req_amount":  amount,
-		"safe_amount": safe_amount,
+		"req_tx_id":  common.ByteSliceToPureHexStr(reqTxId),
+		"req_amount": amount,
 	}).Info("Query UTXOs for redeem")
 
-	utxos, err := tv.ChooseAndLock(safe_amount, common.ByteSliceToPureHexStr(reqTxId))
+	// A retried request (the prepare failed) gets the UTXOs still locked for it.
+	linkedID := common.ByteSliceToPureHexStr(reqTxId)
+	utxos, err := tv.RelockByLinkedID(linkedID)
 	if err != nil {
 		return err
 	}
+	if len(utxos) > 0 {
+		logger.WithFields(logger.Fields{
+			"req_tx_id": linkedID,
+			"inputs":    len(utxos),
+		}).Info("UTXOs reused for a retried redeem")
+	} else {
+		utxos, err = tv.ChooseAndLockWithFee(amount.Int64(), linkedID)
+		if err != nil {
+			return err
+		}
+	}
 
 	outpoints := make([]agreement.BtcOutpoint, len(utxos))
 	for i, utxo := range utxos {
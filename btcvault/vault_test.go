@@ -0,0 +1,166 @@
+package btcvault
+
+import (
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	"github.com/TEENet-io/bridge-go/agreement"
+	"github.com/TEENet-io/bridge-go/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestVault(t *testing.T) (*TreasureVault, *VaultSQLiteStorage) {
+	backend, err := NewVaultSQLiteStorage(filepath.Join(t.TempDir(), "vault.db"), "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewTreasureVault("bcrt1qtest", backend), backend
+}
+
+// addTestUTXOs adds one UTXO of each amount, in block 1, returns their tx ids.
+func addTestUTXOs(t *testing.T, tv *TreasureVault, amounts ...int64) []string {
+	var txIds []string
+	for _, amount := range amounts {
+		txId := common.ByteSliceToPureHexStr(common.RandBytes(32))
+		if err := tv.AddUTXO(1, "blockhash1", txId, 0, amount, nil); err != nil {
+			t.Fatal(err)
+		}
+		txIds = append(txIds, txId)
+	}
+	return txIds
+}
+
+func TestRequestReusesLockedUTXOs(t *testing.T) {
+	tv, _ := newTestVault(t)
+	addTestUTXOs(t, tv, 100000, 200000, 300000)
+
+	reqTxId := common.RandBytes(32)
+	ch := make(chan []agreement.BtcOutpoint, 1)
+	assert.NoError(t, tv.Request(reqTxId, big.NewInt(150000), ch))
+	first := <-ch
+	assert.NotEmpty(t, first)
+
+	// a retried prepare gets the same UTXOs, nothing else is locked
+	assert.NoError(t, tv.Request(reqTxId, big.NewInt(150000), ch))
+	assert.Equal(t, first, <-ch)
+
+	locked, err := tv.GetByLinkedID(common.ByteSliceToPureHexStr(reqTxId))
+	assert.NoError(t, err)
+	assert.Len(t, locked, len(first))
+	for _, utxo := range locked {
+		assert.True(t, utxo.Lockup)
+	}
+
+	// released, a new selection is done
+	assert.NoError(t, tv.ReleaseByLinkedID(common.ByteSliceToPureHexStr(reqTxId)))
+	assert.NoError(t, tv.Request(reqTxId, big.NewInt(150000), ch))
+	assert.Len(t, <-ch, len(first))
+}
+
+func TestMarkSpent(t *testing.T) {
+	tv, backend := newTestVault(t)
+	txIds := addTestUTXOs(t, tv, 100000, 200000, 300000)
+	assert.NoError(t, tv.LockUTXO(txIds[0], 0, "linked", TIMEOUT_DELAY))
+
+	// not in the vault
+	hit, err := tv.MarkSpent(SpentUTXO{RelatedTxID: txIds[0], RelatedVout: 1, BlockNumber: 2, BlockHash: "blockhash2", TxID: "spender", Vin: 0})
+	assert.NoError(t, err)
+	assert.False(t, hit)
+
+	// the locked one, spent by the redeem tx
+	hit, err = tv.MarkSpent(SpentUTXO{RelatedTxID: txIds[0], RelatedVout: 0, BlockNumber: 2, BlockHash: "blockhash2", TxID: "spender", Vin: 0})
+	assert.NoError(t, err)
+	assert.True(t, hit)
+	utxo, err := tv.GetUTXODetail(txIds[0], 0)
+	assert.NoError(t, err)
+	assert.True(t, utxo.Spent)
+	assert.True(t, utxo.Lockup)
+	assert.Equal(t, "linked", utxo.LinkedId)
+
+	spent, err := backend.QuerySpent(txIds[0], 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "spender", spent.TxID)
+	spenders, err := tv.SpendersByLinkedID("linked")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"spender"}, spenders)
+
+	// a spent lock is neither released nor renewed
+	assert.NoError(t, tv.ReleaseByLinkedID("linked"))
+	utxo, err = tv.GetUTXODetail(txIds[0], 0)
+	assert.NoError(t, err)
+	assert.True(t, utxo.Lockup)
+	_, err = tv.RelockByLinkedID("linked")
+	assert.Error(t, err)
+
+	// an unlocked one, spent by a tx not sent by the bridge
+	hit, err = tv.MarkSpent(SpentUTXO{RelatedTxID: txIds[1], RelatedVout: 0, BlockNumber: 2, BlockHash: "blockhash2", TxID: "other", Vin: 1})
+	assert.NoError(t, err)
+	assert.True(t, hit)
+
+	// only the 300000 one is left to choose
+	n, err := tv.CountUsableUTXOs()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	_, err = tv.ChooseAndLock(250000, "next")
+	assert.NoError(t, err)
+	_, err = tv.ChooseAndLock(1000, "after")
+	assert.Error(t, err)
+}
+
+func TestUnspendByBlockHash(t *testing.T) {
+	tv, backend := newTestVault(t)
+	txIds := addTestUTXOs(t, tv, 100000, 200000, 300000)
+	assert.NoError(t, tv.LockUTXO(txIds[0], 0, "linked", TIMEOUT_DELAY))
+
+	for i, blockHash := range []string{"blockhash2", "blockhash2", "blockhash3"} {
+		hit, err := tv.MarkSpent(SpentUTXO{RelatedTxID: txIds[i], RelatedVout: 0, BlockNumber: 2, BlockHash: blockHash, TxID: "spender" + blockHash, Vin: int32(i)})
+		assert.NoError(t, err)
+		assert.True(t, hit)
+	}
+
+	// block 2 is orphaned
+	assert.NoError(t, tv.UnspendByBlockHash("blockhash2"))
+
+	// the redeem tx goes back to the mempool, its UTXO keeps the lock
+	utxo, err := tv.GetUTXODetail(txIds[0], 0)
+	assert.NoError(t, err)
+	assert.False(t, utxo.Spent)
+	assert.True(t, utxo.Lockup)
+	assert.Equal(t, "linked", utxo.LinkedId)
+	spent, err := backend.QuerySpent(txIds[0], 0)
+	assert.NoError(t, err)
+	assert.Nil(t, spent)
+	spenders, err := tv.SpendersByLinkedID("linked")
+	assert.NoError(t, err)
+	assert.Empty(t, spenders)
+
+	// the unlocked one is usable again
+	utxo, err = tv.GetUTXODetail(txIds[1], 0)
+	assert.NoError(t, err)
+	assert.False(t, utxo.Spent)
+	assert.False(t, utxo.Lockup)
+	n, err := tv.CountUsableUTXOs()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	// the spend of block 3 is kept
+	utxo, err = tv.GetUTXODetail(txIds[2], 0)
+	assert.NoError(t, err)
+	assert.True(t, utxo.Spent)
+	spent, err = backend.QuerySpent(txIds[2], 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "spenderblockhash3", spent.TxID)
+
+	// the lock can be renewed and released again
+	relocked, err := tv.RelockByLinkedID("linked")
+	assert.NoError(t, err)
+	assert.Len(t, relocked, 1)
+	assert.NoError(t, tv.ReleaseByLinkedID("linked"))
+	n, err = tv.CountUsableUTXOs()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	// nothing spent in an unknown block
+	assert.NoError(t, tv.UnspendByBlockHash("blockhash4"))
+}
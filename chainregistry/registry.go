@@ -0,0 +1,250 @@
+/*
+Package chainregistry keeps the destination chains of a bridge server.
+
+One bridge server runs one BTC vault, and mints on several destination chains
+(eg. Aptos and Ethereum) at the same time.
+Each destination chain has its own shared state (mint/redeem tables, finalized ledger cursor),
+its own synchronizer and its own tx manager.
+
+The BTC side routes each deposit to the chain it names (chain type + chain id),
+and gathers redeems from all the chains.
+*/
+package chainregistry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	logger "github.com/sirupsen/logrus"
+
+	"github.com/TEENet-io/bridge-go/btcaction"
+	"github.com/TEENet-io/bridge-go/btcvault"
+	"github.com/TEENet-io/bridge-go/common"
+	"github.com/TEENet-io/bridge-go/state"
+)
+
+var (
+	ErrUnknownChain    = errors.New("unknown destination chain")
+	ErrChainExists     = errors.New("destination chain already registered")
+	ErrChainIncomplete = errors.New("destination chain has no state")
+)
+
+// Looper is a long running component of a chain,
+// eg. chainsync.ChainSync, chaintxmgr.ChainTxMgr, ethsync.Synchronizer, ethtxmanager.EthTxManager
+type Looper interface {
+	Loop(ctx context.Context) error
+}
+
+// DestChain is a destination chain that BTC deposits are minted on.
+type DestChain struct {
+	ChainType byte   // common.DEPOSIT_CHAIN_TYPE_*
+	ChainID   uint32 // chain id named by the deposits
+	Name      string // for logs, eg. "aptos"
+
+	State   *state.State   // shared state of this chain only
+	StateDB *state.StateDB // under State
+
+	Sync     Looper                   // (optional) synchronizer of this chain
+	TxMgr    Looper                   // (optional) tx manager of this chain
+	Prepared btcvault.PreparedChecker // (optional) tells if a redeem is prepared on this chain
+}
+
+// Key of the chain, eg. "aptos/1"
+func (c *DestChain) Key() string {
+	return Key(common.ChainTypeName(c.ChainType), c.ChainID)
+}
+
+// Key of a destination chain, chainType is the name (see common.ChainTypeName).
+func Key(chainType string, chainID uint32) string {
+	return fmt.Sprintf("%s/%d", chainType, chainID)
+}
+
+// Registry of destination chains, keyed by chain type + chain id.
+// Concurrent-safe.
+type Registry struct {
+	mu     sync.RWMutex
+	chains map[string]*DestChain
+	order  []*DestChain // in the order of registration
+}
+
+func New() *Registry {
+	return &Registry{
+		chains: make(map[string]*DestChain),
+	}
+}
+
+// Register adds a destination chain.
+func (r *Registry) Register(c *DestChain) error {
+	if c.State == nil || c.StateDB == nil {
+		return fmt.Errorf("%w: %s", ErrChainIncomplete, c.Key())
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.chains[c.Key()]; ok {
+		return fmt.Errorf("%w: %s", ErrChainExists, c.Key())
+	}
+	r.chains[c.Key()] = c
+	r.order = append(r.order, c)
+	return nil
+}
+
+// Get the destination chain, nil if not registered.
+func (r *Registry) Get(chainType byte, chainID uint32) *DestChain {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.chains[Key(common.ChainTypeName(chainType), chainID)]
+}
+
+// Chains gives all the destination chains, in the order of registration.
+func (r *Registry) Chains() []*DestChain {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	chains := make([]*DestChain, len(r.order))
+	copy(chains, r.order)
+	return chains
+}
+
+// Route finds the destination chain named by the deposit.
+// Returns ErrUnknownChain if the chain is not registered.
+func (r *Registry) Route(deposit *btcaction.DepositAction) (*DestChain, error) {
+	key := Key(deposit.ChainType, uint32(deposit.EvmID))
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	c, ok := r.chains[key]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownChain, key)
+	}
+	return c, nil
+}
+
+// Accept checks if the deposit can be routed, see btcsync.BTCMonitor.SetDepositFilter()
+func (r *Registry) Accept(deposit *btcaction.DepositAction) error {
+	_, err := r.Route(deposit)
+	return err
+}
+
+// Start runs the state, synchronizer and tx manager of every chain.
+// wg is used to wait for all the goroutines to finish.
+func (r *Registry) Start(ctx context.Context, wg *sync.WaitGroup) {
+	for _, c := range r.Chains() {
+		c := c
+		fields := logger.Fields{"chain": c.Key(), "name": c.Name}
+
+		// Important: Turn on state, it consumes the channels filled by synchronizers and btc monitor.
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := c.State.Start(ctx)
+			if err != nil && err != context.Canceled {
+				logger.WithFields(fields).Fatalf("failed to run state: %v", err)
+			}
+		}()
+
+		for _, looper := range []Looper{c.Sync, c.TxMgr} {
+			if looper == nil {
+				continue
+			}
+			looper := looper
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				err := looper.Loop(ctx)
+				if err != nil && err != context.Canceled {
+					logger.WithFields(fields).Fatalf("failed to run chain loop: %v", err)
+				}
+			}()
+		}
+	}
+}
+
+// GetPreparedRedeems fetches the prepared redeems of all the chains.
+func (r *Registry) GetPreparedRedeems() ([]*state.Redeem, error) {
+	var redeems []*state.Redeem
+	for _, c := range r.Chains() {
+		items, err := c.State.GetPreparedRedeems()
+		if err != nil {
+			return nil, err
+		}
+		redeems = append(redeems, items...)
+	}
+	return redeems, nil
+}
+
+// chainOfRedeem finds the chain where the redeem is requested, nil if none.
+func (r *Registry) chainOfRedeem(ethReqTxHash ethcommon.Hash) (*DestChain, error) {
+	for _, c := range r.Chains() {
+		_, ok, err := c.StateDB.GetRedeem(ethReqTxHash)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return c, nil
+		}
+	}
+	return nil, nil
+}
+
+// SetRedeemCompleted marks the redeem completed, on the chain where it is requested.
+func (r *Registry) SetRedeemCompleted(ethReqTxHash ethcommon.Hash, btcTxHash ethcommon.Hash) error {
+	c, err := r.chainOfRedeem(ethReqTxHash)
+	if err != nil {
+		return err
+	}
+	if c == nil {
+		return fmt.Errorf("redeem %s is not requested on any chain", ethReqTxHash.String())
+	}
+	return c.State.SetRedeemCompleted(ethReqTxHash, btcTxHash)
+}
+
+// SetRedeemOrphaned reverts the completed redeem, on the chain where it is requested.
+func (r *Registry) SetRedeemOrphaned(ethReqTxHash ethcommon.Hash) error {
+	c, err := r.chainOfRedeem(ethReqTxHash)
+	if err != nil {
+		return err
+	}
+	if c == nil {
+		return fmt.Errorf("redeem %s is not requested on any chain", ethReqTxHash.String())
+	}
+	return c.State.SetRedeemOrphaned(ethReqTxHash)
+}
+
+// RemoveBTC2EVMMint removes the un-minted mint of the deposit, from whichever chain has it.
+func (r *Registry) RemoveBTC2EVMMint(btcTxId ethcommon.Hash) (bool, error) {
+	for _, c := range r.Chains() {
+		removed, err := c.State.RemoveBTC2EVMMint(btcTxId)
+		if err != nil {
+			return false, err
+		}
+		if removed {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// IsPrepared tells if the redeem is prepared on any chain.
+// Implements btcvault.PreparedChecker.
+func (r *Registry) IsPrepared(requestTxId [32]byte) (bool, error) {
+	for _, c := range r.Chains() {
+		if c.Prepared == nil {
+			continue
+		}
+		prepared, err := c.Prepared.IsPrepared(requestTxId)
+		if err != nil {
+			return false, err
+		}
+		if prepared {
+			return true, nil
+		}
+	}
+	return false, nil
+}
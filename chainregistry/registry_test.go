@@ -0,0 +1,130 @@
+package chainregistry
+
+import (
+	"database/sql"
+	"errors"
+	"math/big"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/TEENet-io/bridge-go/btcaction"
+	"github.com/TEENet-io/bridge-go/common"
+	"github.com/TEENet-io/bridge-go/state"
+)
+
+// newTestChain opens the state of a chain on the shared db, as the server does.
+func newTestChain(t *testing.T, sqlDB *sql.DB, chainType byte, chainID uint32) *DestChain {
+	statedb, err := state.NewStateDB(sqlDB, state.ChainNamespace(chainType, uint64(chainID)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	st, err := state.New(statedb, &state.StateConfig{ChannelSize: 1, UniqueChainId: big.NewInt(int64(chainID))})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &DestChain{
+		ChainType: chainType,
+		ChainID:   chainID,
+		Name:      common.ChainTypeName(chainType),
+		State:     st,
+		StateDB:   statedb,
+	}
+}
+
+func newTestRegistry(t *testing.T) (*Registry, *DestChain, *DestChain) {
+	sqlDB, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	aptos := newTestChain(t, sqlDB, common.DEPOSIT_CHAIN_TYPE_APTOS, 1)
+	evm := newTestChain(t, sqlDB, common.DEPOSIT_CHAIN_TYPE_EVM, 1)
+	r := New()
+	assert.NoError(t, r.Register(aptos))
+	assert.NoError(t, r.Register(evm))
+	return r, aptos, evm
+}
+
+func deposit(chainType byte, chainID int32) *btcaction.DepositAction {
+	return &btcaction.DepositAction{
+		ChainType: common.ChainTypeName(chainType),
+		EvmID:     chainID,
+	}
+}
+
+func TestRegister(t *testing.T) {
+	r, aptos, evm := newTestRegistry(t)
+
+	assert.Equal(t, "aptos/1", aptos.Key())
+	assert.Equal(t, []*DestChain{aptos, evm}, r.Chains())
+	assert.Equal(t, aptos, r.Get(common.DEPOSIT_CHAIN_TYPE_APTOS, 1))
+	assert.Equal(t, evm, r.Get(common.DEPOSIT_CHAIN_TYPE_EVM, 1))
+	assert.Nil(t, r.Get(common.DEPOSIT_CHAIN_TYPE_APTOS, 2))
+
+	// the same chain type and id
+	dup := *aptos
+	err := r.Register(&dup)
+	assert.True(t, errors.Is(err, ErrChainExists))
+	assert.Len(t, r.Chains(), 2)
+
+	err = r.Register(&DestChain{ChainType: common.DEPOSIT_CHAIN_TYPE_APTOS, ChainID: 2})
+	assert.True(t, errors.Is(err, ErrChainIncomplete))
+	assert.Nil(t, r.Get(common.DEPOSIT_CHAIN_TYPE_APTOS, 2))
+}
+
+func TestRoute(t *testing.T) {
+	r, aptos, evm := newTestRegistry(t)
+
+	// same chain id, told apart by the chain type
+	c, err := r.Route(deposit(common.DEPOSIT_CHAIN_TYPE_APTOS, 1))
+	assert.NoError(t, err)
+	assert.Equal(t, aptos, c)
+	c, err = r.Route(deposit(common.DEPOSIT_CHAIN_TYPE_EVM, 1))
+	assert.NoError(t, err)
+	assert.Equal(t, evm, c)
+	assert.NoError(t, r.Accept(deposit(common.DEPOSIT_CHAIN_TYPE_EVM, 1)))
+
+	// an unknown chain id or chain type, the deposit is refunded (see btcsync.BTCMonitor.SetDepositFilter)
+	for _, d := range []*btcaction.DepositAction{
+		deposit(common.DEPOSIT_CHAIN_TYPE_APTOS, 2),
+		deposit(common.DEPOSIT_CHAIN_TYPE_EVM, 1337),
+		deposit(9, 1),
+	} {
+		c, err = r.Route(d)
+		assert.Nil(t, c)
+		assert.True(t, errors.Is(err, ErrUnknownChain), err)
+		assert.True(t, errors.Is(r.Accept(d), ErrUnknownChain))
+	}
+}
+
+func TestSetRedeemCompleted(t *testing.T) {
+	r, aptos, evm := newTestRegistry(t)
+
+	redeem := state.RandRedeem(state.RedeemStatusPrepared)
+	assert.NoError(t, evm.StateDB.UpdateAfterPrepared(redeem))
+
+	// completed on the chain it is requested on only
+	btcTxId := common.RandBytes32()
+	assert.NoError(t, r.SetRedeemCompleted(redeem.RequestTxHash, btcTxId))
+	chk, ok, err := evm.StateDB.GetRedeem(redeem.RequestTxHash)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, state.RedeemStatusCompleted, chk.Status)
+	assert.Equal(t, btcTxId[:], chk.BtcTxId[:])
+	_, ok, err = aptos.StateDB.GetRedeem(redeem.RequestTxHash)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	// the btc tx is orphaned
+	assert.NoError(t, r.SetRedeemOrphaned(redeem.RequestTxHash))
+	chk, _, err = evm.StateDB.GetRedeem(redeem.RequestTxHash)
+	assert.NoError(t, err)
+	assert.Equal(t, state.RedeemStatusPrepared, chk.Status)
+
+	// requested on no chain
+	assert.Error(t, r.SetRedeemCompleted(common.RandBytes32(), btcTxId))
+	assert.Error(t, r.SetRedeemOrphaned(common.RandBytes32()))
+}
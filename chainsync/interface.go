@@ -14,8 +14,19 @@ type SyncWorker interface {
 	// on aptos it is newest ledger version?
 	GetNewestLedgerFinalizedNumber() (*big.Int, error)
 
-	// Fetch Interested events from the blockchain.
-	// Notice, the events shall ordered from old -> new.
+	// Fetch Interested events of ledgers (oldNum, newNum] from the blockchain.
+	// Notice, the events shall be ordered from old -> new, by (Ledger, Index),
+	// across all the event types: eg. a prepare is applied after its request.
 	// Otherwise the bridge process will have logic bugs.
-	GetTimeOrderedEvents(oldNum *big.Int, newNum *big.Int) ([]agreement.MintedEvent, []agreement.RedeemRequestedEvent, []agreement.RedeemPreparedEvent, error)
+	GetTimeOrderedEvents(oldNum *big.Int, newNum *big.Int) ([]agreement.ChainEvent, error)
+}
+
+// Optional, implemented by the sync workers of chains whose synced ledgers can leave the canonical chain
+// (eg. ethereum, the blocks are synced a few blocks behind the head, not after the finality).
+type ReorgWorker interface {
+	// Check whether synced ledgers left the canonical chain.
+	// Return nil if not, otherwise the fork point and the events of the orphaned ledgers
+	// (see agreement.NewChainReorg), they are rolled back and synced again from the fork point.
+	// The worker forgets the orphaned ledgers.
+	CheckReorg() (*agreement.ChainReorg, error)
 }
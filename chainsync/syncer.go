@@ -4,6 +4,8 @@ package chainsync
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"math/big"
 	"time"
 
@@ -11,11 +13,14 @@ import (
 	logger "github.com/sirupsen/logrus"
 )
 
+var ErrEventsNotOrdered = errors.New("events are not ordered by ledger and index")
+
 // Configuration
 type ChainSyncConfig struct {
 	IntervalCheckBlockchain time.Duration // interval to trigger the scan of blockchain.
 	St                      agreement.StateChannel
-	ForceScanBlkNum         int64 // retro scan block, tell Sync() to scan from this block, -1 to honor the value in state.
+	ForceScanBlkNum         int64  // retro scan block, tell Sync() to scan from this block, -1 to honor the value in state.
+	MaxLedgersPerBatch      uint64 // catching up, sync at most ? ledgers per batch (0=no limit).
 }
 
 // ChainSync: defines common action that a syncer of chain would do (fetch events, update state, etc.)
@@ -23,7 +28,9 @@ type ChainSync struct {
 	IntervalCheckBlockchain time.Duration          // interval to trigger the scan of blockchain.
 	St                      agreement.StateChannel // state, the database.
 	LastChecked             *big.Int               // laset checked ledger biggest number.
+	MaxLedgersPerBatch      uint64                 // sync at most ? ledgers per batch (0=no limit).
 	SyncWorker              SyncWorker
+	reorgSubscribers        []chan<- *agreement.ChainReorg
 }
 
 func NewChainSync(cfg *ChainSyncConfig, syncWorker SyncWorker) (*ChainSync, error) {
@@ -41,10 +48,17 @@ func NewChainSync(cfg *ChainSyncConfig, syncWorker SyncWorker) (*ChainSync, erro
 		IntervalCheckBlockchain: cfg.IntervalCheckBlockchain,
 		St:                      cfg.St,
 		LastChecked:             blkNumberStored,
+		MaxLedgersPerBatch:      cfg.MaxLedgersPerBatch,
 		SyncWorker:              syncWorker,
 	}, nil
 }
 
+// Receive the reorgs after the state rolled them back (eg. the chain tx manager, to resubmit).
+// Only the workers implementing ReorgWorker report reorgs.
+func (cs *ChainSync) SubscribeReorg(ch chan<- *agreement.ChainReorg) {
+	cs.reorgSubscribers = append(cs.reorgSubscribers, ch)
+}
+
 // The Big Loop!
 func (cs *ChainSync) Loop(ctx context.Context) error {
 	// Ticker
@@ -58,6 +72,11 @@ func (cs *ChainSync) Loop(ctx context.Context) error {
 			return ctx.Err()
 
 		case <-scanTicker.C:
+			// Roll back the synced ledgers that left the canonical chain first
+			if err := cs.checkReorg(ctx); err != nil {
+				return err
+			}
+
 			// Fetch new finalized block number from rpc
 			newFinalized, err := cs.SyncWorker.GetNewestLedgerFinalizedNumber()
 			if err != nil {
@@ -79,33 +98,130 @@ func (cs *ChainSync) Loop(ctx context.Context) error {
 				continue
 			}
 
-			// Notify the state on the new advancement of blockchain status
-			cs.St.GetNewBlockChainFinalizedLedgerNumberChannel() <- newFinalized
+			// Catching up, the ledgers are synced in several batches.
+			for cs.LastChecked.Cmp(newFinalized) < 0 {
+				if err := cs.syncBatch(ctx, cs.batchEnd(newFinalized)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
 
-			minted, request, prepared, err := cs.SyncWorker.GetTimeOrderedEvents(cs.LastChecked, newFinalized)
-			// logger.WithField("preparedaaaaaa", prepared).Info("prepared")
+// Last ledger of the next batch, at most MaxLedgersPerBatch after LastChecked.
+func (cs *ChainSync) batchEnd(newFinalized *big.Int) *big.Int {
+	if cs.MaxLedgersPerBatch == 0 {
+		return newFinalized
+	}
+	end := new(big.Int).Add(cs.LastChecked, new(big.Int).SetUint64(cs.MaxLedgersPerBatch))
+	if end.Cmp(newFinalized) > 0 {
+		return newFinalized
+	}
+	return end
+}
 
-			if err != nil {
-				logger.WithField("error", err).Error("failed to get time ordered events")
-				return err
-			}
-			// Notify the state!
-			for _, ev := range minted {
-				cs.St.GetNewMintedEventChannel() <- &ev
-			}
-			for _, ev := range request {
-				logger.WithField("request", ev).Info("request")
-				cs.St.GetNewRedeemRequestedEventChannel() <- &ev
-			}
-			for _, ev := range prepared {
+// Sync the ledgers (LastChecked, end] as one batch.
+func (cs *ChainSync) syncBatch(ctx context.Context, end *big.Int) error {
+	events, err := cs.SyncWorker.GetTimeOrderedEvents(cs.LastChecked, end)
+	if err != nil {
+		logger.WithField("error", err).Error("failed to get time ordered events")
+		return err
+	}
+	if err := checkEventOrder(events); err != nil {
+		logger.WithField("error", err).Error("sync worker returned unordered events")
+		return err
+	}
 
-				cs.St.GetNewRedeemPreparedEventChannel() <- &ev
+	// Notify the state! The events and the last ledger number in one batch,
+	// LastChecked only moves on once the state applied and checkpointed all of them.
+	if err := cs.applyBatch(ctx, agreement.NewChainEventBatch(events, end)); err != nil {
+		logger.WithField("error", err).Error("failed to apply events to state")
+		return err
+	}
 
-			}
+	cs.LastChecked = new(big.Int).Set(end)
+	return nil
+}
+
+// Ask the worker whether synced ledgers left the canonical chain (see ReorgWorker).
+// If so, the state rolls back their events, the subscribers are notified,
+// and the ledgers after the fork point are synced again.
+func (cs *ChainSync) checkReorg(ctx context.Context) error {
+	worker, ok := cs.SyncWorker.(ReorgWorker)
+	if !ok {
+		return nil
+	}
+	reorg, err := worker.CheckReorg()
+	if err != nil {
+		logger.WithField("error", err).Error("failed to check chain reorg")
+		return err
+	}
+	if reorg == nil {
+		return nil
+	}
+
+	logger.WithFields(logger.Fields{
+		"forkPoint":   reorg.ForkPoint,
+		"lastChecked": cs.LastChecked,
+		"minted":      len(reorg.Minted),
+		"requested":   len(reorg.Requested),
+		"prepared":    len(reorg.Prepared),
+	}).Warn("Chain reorg detected")
+
+	// roll back the state first
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case cs.St.GetChainReorgChannel() <- reorg:
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case err := <-reorg.Done:
+		if err != nil {
+			logger.WithField("error", err).Error("failed to roll back chain reorg in state")
+			return err
+		}
+	}
+
+	for _, ch := range cs.reorgSubscribers {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case ch <- reorg:
+		}
+	}
+
+	if reorg.ForkPoint.Cmp(cs.LastChecked) < 0 {
+		cs.LastChecked = new(big.Int).Set(reorg.ForkPoint)
+	}
+	return nil
+}
+
+// Hand the batch to the state and wait for its ack.
+func (cs *ChainSync) applyBatch(ctx context.Context, batch *agreement.ChainEventBatch) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case cs.St.GetNewChainEventBatchChannel() <- batch:
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case err := <-batch.Done:
+		return err
+	}
+}
 
-			cs.LastChecked = new(big.Int).Set(newFinalized)
+// The events shall be strictly ordered by (Ledger, Index).
+func checkEventOrder(events []agreement.ChainEvent) error {
+	for i := 1; i < len(events); i++ {
+		if !events[i-1].Before(&events[i]) {
+			return fmt.Errorf("%w: %v, then %v", ErrEventsNotOrdered, &events[i-1], &events[i])
 		}
 	}
+	return nil
 }
 
 // func (cs *ChainSync) ExecuteNewRedeemRequestedEvent(ev *agreement.RedeemRequestedEvent) error {
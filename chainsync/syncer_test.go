@@ -0,0 +1,182 @@
+package chainsync
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/TEENet-io/bridge-go/agreement"
+	"github.com/stretchr/testify/assert"
+)
+
+// state that acks every batch with ackErr
+type ackState struct {
+	batchCh    chan *agreement.ChainEventBatch
+	reorgCh    chan *agreement.ChainReorg
+	ackErr     error
+	applied    []agreement.ChainEvent
+	rolledBack []*agreement.ChainReorg
+}
+
+func (st *ackState) GetNewBlockChainFinalizedLedgerNumberChannel() chan<- *big.Int { return nil }
+func (st *ackState) GetNewBtcFinalizedBlockChannel() chan<- *big.Int               { return nil }
+func (st *ackState) GetNewRedeemRequestedEventChannel() chan<- *agreement.RedeemRequestedEvent {
+	return nil
+}
+func (st *ackState) GetNewRedeemPreparedEventChannel() chan<- *agreement.RedeemPreparedEvent {
+	return nil
+}
+func (st *ackState) GetNewMintedEventChannel() chan<- *agreement.MintedEvent { return nil }
+func (st *ackState) GetChainReorgChannel() chan<- *agreement.ChainReorg      { return st.reorgCh }
+func (st *ackState) GetNewChainEventBatchChannel() chan<- *agreement.ChainEventBatch {
+	return st.batchCh
+}
+func (st *ackState) GetBlockchainFinalizedBlockNumber() (*big.Int, error) { return big.NewInt(0), nil }
+
+func (st *ackState) start(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case batch := <-st.batchCh:
+			if st.ackErr == nil {
+				st.applied = append(st.applied, batch.Events...)
+			}
+			batch.Done <- st.ackErr
+		case reorg := <-st.reorgCh:
+			st.rolledBack = append(st.rolledBack, reorg)
+			reorg.Done <- nil
+		}
+	}
+}
+
+type fakeWorker struct {
+	newest *big.Int
+	events []agreement.ChainEvent
+}
+
+func (w *fakeWorker) GetNewestLedgerFinalizedNumber() (*big.Int, error) { return w.newest, nil }
+func (w *fakeWorker) GetTimeOrderedEvents(oldNum *big.Int, newNum *big.Int) ([]agreement.ChainEvent, error) {
+	return w.events, nil
+}
+
+func TestCheckEventOrder(t *testing.T) {
+	minted := &agreement.MintedEvent{}
+	assert.NoError(t, checkEventOrder(nil))
+	assert.NoError(t, checkEventOrder([]agreement.ChainEvent{
+		{Ledger: 1, Index: 0, Minted: minted},
+		{Ledger: 1, Index: 1, Minted: minted},
+		{Ledger: 2, Index: 0, Minted: minted},
+	}))
+	assert.ErrorIs(t, checkEventOrder([]agreement.ChainEvent{
+		{Ledger: 2, Index: 0, Minted: minted},
+		{Ledger: 1, Index: 5, Minted: minted},
+	}), ErrEventsNotOrdered)
+	assert.ErrorIs(t, checkEventOrder([]agreement.ChainEvent{
+		{Ledger: 1, Index: 1, Minted: minted},
+		{Ledger: 1, Index: 1, Minted: minted},
+	}), ErrEventsNotOrdered)
+}
+
+func TestLoopAdvancesAfterAck(t *testing.T) {
+	events := []agreement.ChainEvent{
+		{Ledger: 5, Index: 0, Requested: &agreement.RedeemRequestedEvent{}},
+		{Ledger: 7, Index: 0, Prepared: &agreement.RedeemPreparedEvent{}},
+	}
+	worker := &fakeWorker{newest: big.NewInt(10), events: events}
+
+	// state fails the batch: LastChecked stays
+	st := &ackState{batchCh: make(chan *agreement.ChainEventBatch, 1), ackErr: errors.New("db down")}
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	go st.start(ctx)
+
+	cs := &ChainSync{St: st, LastChecked: big.NewInt(1), SyncWorker: worker}
+	err := cs.Loop(ctx)
+	assert.EqualError(t, err, "db down")
+	assert.Equal(t, big.NewInt(1), cs.LastChecked)
+
+	// state applies the batch: events in order, then LastChecked moves on
+	st.ackErr = nil
+	loopCtx, loopCancel := context.WithTimeout(ctx, 1500*time.Millisecond)
+	defer loopCancel()
+	err = cs.Loop(loopCtx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, big.NewInt(10), cs.LastChecked)
+	assert.Equal(t, events, st.applied)
+}
+
+// worker that records the ranges it is asked for
+type rangeWorker struct {
+	newest *big.Int
+	ranges [][2]int64
+}
+
+func (w *rangeWorker) GetNewestLedgerFinalizedNumber() (*big.Int, error) { return w.newest, nil }
+func (w *rangeWorker) GetTimeOrderedEvents(oldNum *big.Int, newNum *big.Int) ([]agreement.ChainEvent, error) {
+	w.ranges = append(w.ranges, [2]int64{oldNum.Int64(), newNum.Int64()})
+	return []agreement.ChainEvent{{Ledger: newNum.Uint64(), Minted: &agreement.MintedEvent{}}}, nil
+}
+
+func TestLoopSyncsInBatches(t *testing.T) {
+	worker := &rangeWorker{newest: big.NewInt(25)}
+	st := &ackState{batchCh: make(chan *agreement.ChainEventBatch, 1)}
+	ctx, cancel := context.WithTimeout(context.Background(), 1500*time.Millisecond)
+	defer cancel()
+	go st.start(ctx)
+
+	cs := &ChainSync{St: st, LastChecked: big.NewInt(0), MaxLedgersPerBatch: 10, SyncWorker: worker}
+	err := cs.Loop(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, big.NewInt(25), cs.LastChecked)
+	assert.Equal(t, [][2]int64{{0, 10}, {10, 20}, {20, 25}}, worker.ranges)
+	assert.Len(t, st.applied, 3)
+}
+
+// worker that reports a reorg once
+type reorgWorker struct {
+	rangeWorker
+	reorg *agreement.ChainReorg
+}
+
+func (w *reorgWorker) CheckReorg() (*agreement.ChainReorg, error) {
+	reorg := w.reorg
+	w.reorg = nil
+	return reorg, nil
+}
+
+func TestLoopRollsBackReorg(t *testing.T) {
+	reorg := agreement.NewChainReorg(big.NewInt(4), []agreement.MintedEvent{{}}, nil, nil)
+	worker := &reorgWorker{rangeWorker: rangeWorker{newest: big.NewInt(10)}}
+	st := &ackState{
+		batchCh: make(chan *agreement.ChainEventBatch, 1),
+		reorgCh: make(chan *agreement.ChainReorg, 1),
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	go st.start(ctx)
+
+	subscriber := make(chan *agreement.ChainReorg, 1)
+	cs := &ChainSync{St: st, LastChecked: big.NewInt(0), SyncWorker: worker}
+	cs.SubscribeReorg(subscriber)
+
+	// synced up to 10
+	loopCtx, loopCancel := context.WithTimeout(ctx, 1500*time.Millisecond)
+	defer loopCancel()
+	err := cs.Loop(loopCtx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, big.NewInt(10), cs.LastChecked)
+
+	// the ledgers after 4 left the canonical chain: rolled back, notified, synced again
+	worker.reorg = reorg
+	loopCtx2, loopCancel2 := context.WithTimeout(ctx, 1500*time.Millisecond)
+	defer loopCancel2()
+	err = cs.Loop(loopCtx2)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, []*agreement.ChainReorg{reorg}, st.rolledBack)
+	assert.Equal(t, reorg, <-subscriber)
+	assert.Equal(t, [][2]int64{{0, 10}, {4, 10}}, worker.ranges)
+	assert.Equal(t, big.NewInt(10), cs.LastChecked)
+}
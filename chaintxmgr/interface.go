@@ -6,6 +6,7 @@ import (
 	"math/big"
 
 	"github.com/TEENet-io/bridge-go/agreement"
+	"github.com/TEENet-io/bridge-go/common"
 )
 
 // Mgr's worker on chain, do the dirty job.
@@ -42,4 +43,20 @@ type MgrWorker interface {
 	// Then block_number is x.
 	// If a tx is not included in any block yet, then block_number is nil.
 	GetTxStatus(txId []byte) (agreement.MonitoredTxStatus, *big.Int, error)
+
+	// Why a tx failed on chain (eg. decoded abort code), nil if it didn't fail.
+	// DoMint()/DoPrepare() return the same *agreement.TxFailure as error
+	// when the tx is rejected before submission (eg. aborted simulation).
+	// They shall not wait for the tx to be included, so that it is monitored right away,
+	// a tx failing on chain is reported by GetTxStatus() then GetTxFailure().
+	GetTxFailure(txId []byte) (*agreement.TxFailure, error)
+}
+
+// The bridge deployment the mints/prepares are signed for.
+type BridgeKeyProvider interface {
+	// Schnorr public key registered in the bridge contract (x coordinate).
+	GetBridgePublicKey() ([32]byte, error)
+
+	// Domain the messages are signed for, nil = legacy messages (no domain).
+	SigningDomain() (*common.SigningDomain, error)
 }
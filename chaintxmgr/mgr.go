@@ -9,7 +9,6 @@ import (
 	"time"
 
 	"github.com/TEENet-io/bridge-go/agreement"
-	"github.com/TEENet-io/bridge-go/aptosman"
 	"github.com/TEENet-io/bridge-go/chaintxmgrdb"
 	"github.com/TEENet-io/bridge-go/common"
 	"github.com/TEENet-io/bridge-go/state"
@@ -31,6 +30,22 @@ type ChainTxMgrConfig struct {
 	// Whether re-send or just drop the Tx is another story.
 	// But we need to know and mark it clearly.
 	TimeoutTxLedgerNumber *big.Int
+
+	// Mints are signed and submitted by a pool of MintWorkers workers,
+	// at most MintQueueSize mints wait in the queue.
+	MintWorkers   int
+	MintQueueSize int
+	// Prepares are signed and submitted by a pool of PrepareWorkers workers,
+	// at most PrepareQueueSize prepares wait in the queue.
+	PrepareWorkers   int
+	PrepareQueueSize int
+
+	// Retry policy of failed Txs (see retry.go)
+	// A mint/prepare is sent at most MaxTxAttempts times, then escalated to operator.
+	MaxTxAttempts int
+	// Wait RetryBackoffBase * 2^(attempts-1) before re-sending, capped at RetryBackoffMax.
+	RetryBackoffBase time.Duration
+	RetryBackoffMax  time.Duration
 }
 
 type ChainTxMgr struct {
@@ -40,8 +55,12 @@ type ChainTxMgr struct {
 	schnorrParty     agreement.SchnorrAsyncSigner // interface
 	btcUTXOResponder agreement.BtcUTXOResponder   // interface
 	pubKey           [32]byte                     // Public Key for Schnorr signature verification, 32 byte
+	domain           *common.SigningDomain        // Deployment the mint/prepare messages are signed for
 
-	chainWorker MgrWorker // Chain Worker (do the interaction with chain)
+	chainWorker MgrWorker                  // Chain Worker (do the interaction with chain)
+	mintPool    *txPool[*state.Mint]       // Mints waiting for / being processed by mint workers
+	preparePool *txPool[*state.Redeem]     // Prepares waiting for / being processed by prepare workers
+	reorgCh     chan *agreement.ChainReorg // Reorgs to resubmit the orphaned txs, see reorg.go
 
 	mgrdbLock  sync.Mutex // Prevent race condition, both read/write lock to db.
 	mintLock   sync.Mutex // Prevent race condition
@@ -56,13 +75,21 @@ func NewChainTxMgr(
 	mgrdb chaintxmgrdb.ChainTxMgrDB,
 	schnorrParty agreement.SchnorrAsyncSigner,
 	btcUTXOResponder agreement.BtcUTXOResponder,
-	aptosman *aptosman.Aptosman,
+	bridge BridgeKeyProvider,
 ) (*ChainTxMgr, error) {
 
-	// 获取公钥
-	pubKey, err := aptosman.GetPublicKey() // TODO: MOVE need schnorrparty verification
+	// Schnorr public key registered in the bridge contract,
+	// signatures are verified against it before submitting.
+	pubKey, err := bridge.GetBridgePublicKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bridge public key: %v", err)
+	}
+
+	// Messages are bound to the bridge contract's chain id and address,
+	// so the same key cannot be replayed across deployments.
+	domain, err := bridge.SigningDomain()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get aptos public key: %v", err)
+		return nil, fmt.Errorf("failed to get signing domain: %v", err)
 	}
 
 	mgr := &ChainTxMgr{
@@ -72,8 +99,12 @@ func NewChainTxMgr(
 		schnorrParty:     schnorrParty,
 		btcUTXOResponder: btcUTXOResponder,
 		chainWorker:      chainWorker,
-		pubKey:           common.HexStrToHash(pubKey),
+		pubKey:           pubKey,
+		domain:           domain,
+		reorgCh:          make(chan *agreement.ChainReorg, 1),
 	}
+	mgr.mintPool = mgr.newMintPool()
+	mgr.preparePool = mgr.newPreparePool()
 
 	return mgr, nil
 }
@@ -83,6 +114,8 @@ func (ctm *ChainTxMgr) Loop(ctx context.Context) error {
 	logger.Debug("starting chain tx manager")
 	defer logger.Debug("stopping chain tx manager")
 
+	ctm.startWorkers(ctx)
+
 	tickerInterval := time.NewTicker(ctm.cfg.IntervalCheckTime)
 	defer tickerInterval.Stop()
 
@@ -90,6 +123,12 @@ func (ctm *ChainTxMgr) Loop(ctx context.Context) error {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
+		case reorg := <-ctm.reorgCh:
+			// track the orphaned txs again
+			reorg_err := ctm.handleReorg(reorg)
+			if reorg_err != nil {
+				logger.Errorf("failed to process chain reorg: err=%v", reorg_err)
+			}
 		case <-tickerInterval.C:
 
 			// do the mint procedure
@@ -146,68 +185,18 @@ func (ctm *ChainTxMgr) procedureMint(ctx context.Context) error {
 		return nil
 	}
 
-	for i, mint := range mints_clean {
-		logger.WithFields(logger.Fields{
-			"index": i,
-			"mint":  mint,
-		}).Info("Processing mint")
-
-		// 3. Check double mint
-		found, err := ctm.IsDoubleMint(mint)
-		if err != nil {
-			logger.WithError(err).Error("Failed to check double mint")
-			continue
+	// 3. Queue the mints, the mint workers sign & submit them (see tx_pool.go)
+	queued := 0
+	for _, mint := range mints_clean {
+		if ctm.mintPool.enqueue(mint) {
+			queued++
 		}
-		if found {
-			logger.Info("Mint already exists on chain, skipping")
-			continue
-		}
-
-		// 4. Prepare mint params
-		_mint_params, err := ctm.PrepareMint(ctx, mint)
-		if err != nil {
-			logger.WithError(err).Error("Failed to prepare mint params")
-			continue
-		}
-		logger.WithFields(logger.Fields{
-			"btc_tx_id":    fmt.Sprintf("%x", _mint_params.BtcTxId),
-			"amount":       _mint_params.Amount.String(),
-			"receiver":     fmt.Sprintf("%x", _mint_params.Receiver),
-			"receiver_len": len(_mint_params.Receiver),
-		}).Info("Prepared mint params")
-
-		// 5. Call mint
-		tx_id, ledger_number, err := ctm.CallMint(_mint_params)
-		if err != nil {
-			logger.WithError(err).Error("Failed to call mint")
-			continue
-		}
-		logger.WithFields(logger.Fields{
-			"tx_id":         fmt.Sprintf("%x", tx_id),
-			"ledger_number": ledger_number,
-		}).Info("Mint called successfully")
-
-		// Extra: handle nil ledger_number
-		if ledger_number == nil {
-			latest_ledger_number, err := ctm.chainWorker.GetLatestLedgerNumber()
-			if err != nil {
-				logger.WithError(err).Error("Failed to get latest ledger number")
-			} else if latest_ledger_number != nil {
-				ledger_number = latest_ledger_number
-				logger.WithField("ledger_number", ledger_number).Info("Updated ledger number")
-			} else {
-				logger.Error("Latest ledger number is nil")
-			}
-		}
-
-		// 6. Set monitoring
-		err = ctm.SetMintToBeMonitored(_mint_params, tx_id, ledger_number, agreement.Pending)
-		if err != nil {
-			logger.WithError(err).Error("Failed to set mint monitoring")
-			continue
-		}
-		logger.Info("Mint set for monitoring")
 	}
+	logger.WithFields(logger.Fields{
+		"queued":      queued,
+		"queue_depth": ctm.MintQueueDepth(),
+		"in_flight":   ctm.MintsInFlight(),
+	}).Info("Queued mints")
 
 	logger.Info("Completed procedureMint")
 	return nil
@@ -224,16 +213,17 @@ func (ctm *ChainTxMgr) QueryMints() ([]*state.Mint, error) {
 }
 
 // Filter mints, drop off those already minted (tracked in mgr db)
+// Mints whose Txs failed are kept once the retry policy says they are due.
 func (ctm *ChainTxMgr) FilterMints(mints []*state.Mint) ([]*state.Mint, error) {
 	_mints := []*state.Mint{}
 	for _, mint := range mints {
 		_refId := mint.BtcTxId.Bytes()
-		_hits, err := ctm.mgrdb.GetMonitoredTxByRefIdentifier(_refId)
+		_send, err := ctm.shouldSend(_refId)
 		if err != nil {
-			logger.Errorf("failed to get monitored tx by ref id: err=%v", err)
+			logger.Errorf("failed to check monitored tx by ref id: err=%v", err)
 			continue
 		}
-		if len(_hits) == 0 {
+		if _send {
 			_mints = append(_mints, mint)
 		}
 	}
@@ -262,6 +252,7 @@ func (ctm *ChainTxMgr) PrepareMint(ctx context.Context, mint *state.Mint) (*agre
 		BtcTxId:  mint.BtcTxId,
 		Receiver: mint.Receiver,
 		Amount:   common.BigIntClone(mint.Amount),
+		Domain:   ctm.domain,
 	}
 	msgHash := mp.GenerateMsgHash()
 
@@ -280,16 +271,16 @@ func (ctm *ChainTxMgr) PrepareMint(ctx context.Context, mint *state.Mint) (*agre
 	}
 
 	// wait for the signature to be sent by the schnorr wallet
-	// req, err := ctm.waitAndVerifySignature(ctx, msgHash, _channel)
-	// if err != nil {
-	// 	logger.Errorf("failed to wait and verify signature: err=%v", err)
-	// 	return nil, err
-	// }
+	req, err := ctm.waitAndVerifySignature(ctx, msgHash, _channel)
+	if err != nil {
+		logger.Errorf("failed to wait and verify signature: err=%v", err)
+		return nil, err
+	}
 	logger.Info("schnorr signature requested & received")
 
-	// // set outpoints before saving
-	// mp.Rx = common.BigIntClone(req.Rx)
-	// mp.S = common.BigIntClone(req.S)
+	// set signature before sending
+	mp.Rx = common.BigIntClone(req.Rx)
+	mp.S = common.BigIntClone(req.S)
 
 	return mp, nil
 }
@@ -365,6 +356,7 @@ func (ctm *ChainTxMgr) QueryUnPrepared() ([]*state.Redeem, error) {
 }
 
 // Filter out those redeems that are already prepared (tracked in mgr db)
+// Redeems whose Txs failed are kept once the retry policy says they are due.
 func (ctm *ChainTxMgr) FilterUnPrepared(redeems []*state.Redeem) ([]*state.Redeem, error) {
 	// Filter out those already prepared
 	logger.WithField("procedurePrepare", "filterUnPrepared").Info("filterUnPrepared")
@@ -373,12 +365,12 @@ func (ctm *ChainTxMgr) FilterUnPrepared(redeems []*state.Redeem) ([]*state.Redee
 	for _, redeem := range redeems {
 		_refId := redeem.RequestTxHash.Bytes() // Use reqTxHash as the reference id
 		logger.WithField("refId", _refId).Info("refId")
-		_hits, err := ctm.mgrdb.GetMonitoredTxByRefIdentifier(_refId)
+		_send, err := ctm.shouldSend(_refId)
 		if err != nil {
-			logger.Errorf("failed to get monitored tx by ref id: err=%v", err)
+			logger.Errorf("failed to check monitored tx by ref id: err=%v", err)
 			continue
 		}
-		if len(_hits) == 0 {
+		if _send {
 			_unprepared = append(_unprepared, redeem)
 		}
 	}
@@ -404,24 +396,31 @@ func (ctm *ChainTxMgr) IsDoublePrepare(redeem *state.Redeem) (bool, error) {
 
 // Prepare the RedeemPrepare Tx needed parameters.
 func (ctm *ChainTxMgr) PreparePrepare(ctx context.Context, redeem *state.Redeem) (*agreement.PrepareParameter, error) {
-	// Query the BTC UTXO Responder for UTXO outpoints
-	// request spendable outpoints from btc wallet
-	_channel_outpoints := make(chan []agreement.BtcOutpoint, 1)
-	err := ctm.btcUTXOResponder.Request(
-		redeem.RequestTxHash.Bytes(),
-		redeem.Amount,
-		_channel_outpoints,
-	)
-	if err != nil {
-		logger.WithField("err", err).Error("failed to request spendable UTXO outpoints")
-		return nil, fmt.Errorf("ERR_BTC_UTXO_REQUEST: %v", err)
-	}
-	logger.WithField("procedurePrepare", "waitForOutPoints").Info("waitForOutPoints")
-	_outpoints, err := ctm.waitForOutPoints(ctx, _channel_outpoints)
-	if err != nil {
-		return nil, err
+	// A redeem whose prepare was orphaned by a reorg keeps its outpoints,
+	// prepare it again with them (a btc tx spending them may be out already).
+	_outpoints := redeem.Outpoints
+	if len(_outpoints) > 0 {
+		logger.WithField("num", len(_outpoints)).Warn("UTXO outpoints reused from the orphaned prepare")
+	} else {
+		// Query the BTC UTXO Responder for UTXO outpoints
+		// request spendable outpoints from btc wallet
+		_channel_outpoints := make(chan []agreement.BtcOutpoint, 1)
+		err := ctm.btcUTXOResponder.Request(
+			redeem.RequestTxHash.Bytes(),
+			redeem.Amount,
+			_channel_outpoints,
+		)
+		if err != nil {
+			logger.WithField("err", err).Error("failed to request spendable UTXO outpoints")
+			return nil, fmt.Errorf("ERR_BTC_UTXO_REQUEST: %v", err)
+		}
+		logger.WithField("procedurePrepare", "waitForOutPoints").Info("waitForOutPoints")
+		_outpoints, err = ctm.waitForOutPoints(ctx, _channel_outpoints)
+		if err != nil {
+			return nil, err
+		}
+		logger.WithField("num", len(_outpoints)).Info("UTXO outpoints received")
 	}
-	logger.WithField("num", len(_outpoints)).Info("UTXO outpoints received")
 
 	// Stuff the PrepareParameter
 	pp := &agreement.PrepareParameter{
@@ -429,6 +428,7 @@ func (ctm *ChainTxMgr) PreparePrepare(ctx context.Context, redeem *state.Redeem)
 		Requester:     redeem.Requester,
 		Receiver:      redeem.Receiver,
 		Amount:        common.BigIntClone(redeem.Amount),
+		Domain:        ctm.domain,
 	}
 	pp.OutpointTxIds, pp.OutpointIdxs = agreement.ConvertOutpoints(_outpoints)
 
@@ -437,7 +437,7 @@ func (ctm *ChainTxMgr) PreparePrepare(ctx context.Context, redeem *state.Redeem)
 
 	// request signature from schnorr signer over the msg hash
 	_channel := make(chan *agreement.SignatureRequest, 1)
-	err = ctm.schnorrParty.SignAsync(
+	err := ctm.schnorrParty.SignAsync(
 		&agreement.SignatureRequest{
 			Id:          redeem.RequestTxHash,
 			SigningHash: msgHash,
@@ -449,15 +449,16 @@ func (ctm *ChainTxMgr) PreparePrepare(ctx context.Context, redeem *state.Redeem)
 		return nil, err
 	}
 	// wait...
-	// req, err := ctm.waitAndVerifySignature(ctx, msgHash, _channel)
-	// if err != nil {
-	// 	return nil, err
-	// }
-	// logger.Info("schnorr signature requested & received")
+	req, err := ctm.waitAndVerifySignature(ctx, msgHash, _channel)
+	if err != nil {
+		logger.Errorf("failed to wait and verify signature: err=%v", err)
+		return nil, err
+	}
+	logger.Info("schnorr signature requested & received")
 
-	// // Stuff the prepare parameter
-	// pp.Rx = common.BigIntClone(req.Rx)
-	// pp.S = common.BigIntClone(req.S)
+	// Stuff the prepare parameter
+	pp.Rx = common.BigIntClone(req.Rx)
+	pp.S = common.BigIntClone(req.S)
 
 	return pp, nil
 }
@@ -481,10 +482,7 @@ func (ctm *ChainTxMgr) procedurePrepare(ctx context.Context) error {
 	// 0. Aquire necessary locks
 	// 1. Find unprepared redeems from state db
 	// 2. Filter those already prepared (tracked in mgr db)
-	// 3. for each redeem, Check if the redeem is already prepared on chain
-	// 4. for each redeem, Prepare redeem params (this step contains the schnorr signature request)
-	// 5. for each redeem, Call prepareRedeem() function on chain
-	// 6. for each redeem, Set Tx to the mgrdb, and it is "pending" status
+	// 3. Queue the redeems, the prepare workers check, sign & submit them (see tx_pool.go)
 	logger.WithField("procedurePrepare", "start").Info("procedurePrepare")
 	// 0. Aquire necessary locks
 	ctm.mgrdbLock.Lock()
@@ -511,65 +509,33 @@ func (ctm *ChainTxMgr) procedurePrepare(ctx context.Context) error {
 		logger.WithField("no new redeems to process", len(redeems_clean)).Info("no new redeems to process")
 		return nil
 	}
-	logger.WithField("procedurePrepare", "filterUnprepared").Info("FilterUnPrepared")
-	for _, redeem := range redeems_clean {
-		// 3. Check if the redeem is already prepared on chain
-		found, err := ctm.IsDoublePrepare(redeem)
-		if err != nil {
-			logger.Errorf("failed to check if prepared: err=%v", err)
-			continue
-		}
-		if found {
-			continue
-		}
-		logger.WithField("procedurePrepare", "isDoublePrepare").Info("isDoublePrepare")
-		// 4. Prepare redeem params (this step contains the schnorr signature request)
-		pp, err := ctm.PreparePrepare(ctx, redeem)
-		if err != nil {
-			logger.Errorf("failed to prepare redeem params: err=%v", err)
-			continue
-		}
-		logger.WithField("procedurePrepare", "preparePrepare").Info("preparePrepare")
-		tx_id, ledger_number, err := ctm.CallPrepare(pp)
-		if err != nil {
-			logger.Errorf("failed to call prepareRedeem() on chain: err=%v", err)
-			continue
-		}
-		logger.WithField("procedurePrepare", "callPrepare").Info("callPrepare")
-		// Extra: if the ledger_number is nil, we try the <best effort> to set it
-		if ledger_number == nil {
-			latest_ledger_number, err := ctm.chainWorker.GetLatestLedgerNumber()
-			if err != nil {
-				logger.Errorf("failed to get latest ledger number: err=%v", err)
-			} else {
-				if latest_ledger_number != nil {
-					ledger_number = latest_ledger_number
-				} else {
-					logger.Errorf("latest ledger number is nil")
-				}
-			}
-		}
 
-		// 6. Set Tx to the mgrdb, and it is "pending" status
-		err = ctm.SetPrepareToBeMonitored(pp, tx_id, ledger_number, agreement.Pending)
-		if err != nil {
-			logger.Errorf("failed to set mint to be monitored in mgrdb: err=%v", err)
-			continue
+	// 3. Queue the redeems
+	queued := 0
+	for _, redeem := range redeems_clean {
+		if ctm.preparePool.enqueue(redeem) {
+			queued++
 		}
 	}
+	logger.WithFields(logger.Fields{
+		"queued":      queued,
+		"queue_depth": ctm.PrepareQueueDepth(),
+		"in_flight":   ctm.PreparesInFlight(),
+	}).Info("Queued prepares")
 	return nil
 }
 
 // This procedure tracks the Tx status on chain
 // Then mark accordingly.
 // If a Tx takes too long to be included, it will also marking it as timeout status.
-// But it doesn't deal with timeout, it is another story.
+// Failed (timeout/reverted) Txs are handed to the retry policy, see retry.go
 func (ctm *ChainTxMgr) procedureMarkTxStatus() error {
 	// 0. Aquire necessary locks
 	// 1. Get all pending txs from mgr db
 	// 2. For each pending tx, check the tx status on chain
-	// 3. Update the tx status in mgr db
-	// 4. If the tx takes too long to be accepted to blockchain, we consider it is timeout.
+	// 3. If the tx takes too long to be accepted to blockchain, we consider it is timeout.
+	// 4. If the tx failed, schedule a retry or escalate it.
+	// 5. Update the tx status in mgr db
 
 	// 0. Aquire necessary locks
 	ctm.mgrdbLock.Lock()
@@ -598,14 +564,7 @@ func (ctm *ChainTxMgr) procedureMarkTxStatus() error {
 
 		pendingTx.TxStatus = status
 
-		// 3. Update the tx status in mgr db
-		err = ctm.mgrdb.UpdateTxStatus(txId, status)
-		if err != nil {
-			logger.Errorf("failed to update tx status in mgr db: err=%v", err)
-			continue
-		}
-
-		// 4. If the tx takes too long to be accepted to blockchain, we consider it is timeout.
+		// 3. If the tx takes too long to be accepted to blockchain, we consider it is timeout.
 		included_statuses := []agreement.MonitoredTxStatus{agreement.Success, agreement.Reverted}
 		// If the tx is included in a block, we set the found ledger number
 		if agreement.UtilContains(included_statuses, status) {
@@ -622,20 +581,57 @@ func (ctm *ChainTxMgr) procedureMarkTxStatus() error {
 				continue
 			}
 
+			// sent at an unknown ledger (eg. migrated), the timeout counts from now
+			if latestLedgerNumber != nil && pendingTx.SentBlockchainLedgerNumber == nil {
+				err = ctm.mgrdb.UpdateSent(txId, latestLedgerNumber)
+				if err != nil {
+					logger.Errorf("failed to update sent ledger number in mgr db: err=%v", err)
+					continue
+				}
+				pendingTx.SentBlockchainLedgerNumber = common.BigIntClone(latestLedgerNumber)
+			}
+
 			if latestLedgerNumber != nil && pendingTx.SentBlockchainLedgerNumber != nil {
 				expireThreshold := new(big.Int).Add(pendingTx.SentBlockchainLedgerNumber, ctm.cfg.TimeoutTxLedgerNumber)
 				if expireThreshold.Cmp(latestLedgerNumber) <= 0 { // eg. expireThreshold = 100; latestLedgerNumber = 120
 					pendingTx.TxStatus = agreement.Timeout
-					err = ctm.mgrdb.UpdateTxStatus(txId, agreement.Timeout)
-					if err != nil {
-						logger.Errorf("failed to update tx status to timeout in mgr db: err=%v", err)
-						continue
-					}
 					logger.WithField("txId", common.ByteSliceToPureHexStr(txId)).Info("Tx marked as timeout")
 				}
 			}
 		}
 
+		// 4. If the tx failed, store the reason, then schedule a retry or escalate it.
+		// The failed status is only saved with the retry record (see shouldSend),
+		// otherwise the tx stays pending and is checked again next round.
+		if agreement.UtilContains(failedStatuses, pendingTx.TxStatus) {
+			var failure *agreement.TxFailure
+			if pendingTx.TxStatus == agreement.Reverted {
+				failure, err = ctm.chainWorker.GetTxFailure(txId)
+				if err != nil {
+					logger.Errorf("failed to get tx failure reason: err=%v", err)
+				}
+			}
+			if failure != nil {
+				pendingTx.Reason = failure.Reason
+				err = ctm.mgrdb.UpdateReason(txId, pendingTx.Reason)
+				if err != nil {
+					logger.Errorf("failed to update tx failure reason in mgr db: err=%v", err)
+				}
+			}
+
+			err = ctm.scheduleRetry(pendingTx, failure)
+			if err != nil {
+				logger.Errorf("failed to schedule retry of failed tx, checked again next round: err=%v", err)
+				continue
+			}
+		}
+
+		// 5. Update the tx status in mgr db
+		err = ctm.mgrdb.UpdateTxStatus(txId, pendingTx.TxStatus)
+		if err != nil {
+			logger.Errorf("failed to update tx status in mgr db: err=%v", err)
+			continue
+		}
 	}
 	return nil
 }
@@ -649,8 +645,11 @@ func (ctm *ChainTxMgr) waitAndVerifySignature(ctx context.Context, msgHash [32]b
 	for {
 		select {
 		case <-newCtx.Done():
-			return nil, ctx.Err()
+			return nil, newCtx.Err()
 		case req := <-ch:
+			if req.Rx == nil || req.S == nil {
+				return req, fmt.Errorf("ERR_BAD_SIGNATURE: signature missing")
+			}
 			if ok := common.Verify(ctm.pubKey[:], msgHash[:], req.Rx, req.S); !ok {
 				return req, fmt.Errorf("ERR_BAD_SIGNATURE: signature verification failed")
 			}
@@ -0,0 +1,65 @@
+package chaintxmgr
+
+import (
+	"github.com/TEENet-io/bridge-go/agreement"
+	"github.com/TEENet-io/bridge-go/common"
+	logger "github.com/sirupsen/logrus"
+)
+
+// Reorgs reported by the chain sync (see chainsync.ChainSync.SubscribeReorg),
+// after the state rolled them back.
+func (ctm *ChainTxMgr) GetChainReorgChannel() chan<- *agreement.ChainReorg {
+	return ctm.reorgCh
+}
+
+// The mints/prepares found in the orphaned ledgers are tracked again as "pending".
+// A Tx mined again on the new chain is found again,
+// a dropped one times out and is re-sent by the retry policy (see retry.go).
+func (ctm *ChainTxMgr) handleReorg(reorg *agreement.ChainReorg) error {
+	ctm.mgrdbLock.Lock()
+	defer ctm.mgrdbLock.Unlock()
+
+	refIds := [][]byte{}
+	for _, ev := range reorg.Minted {
+		refIds = append(refIds, ev.BtcTxId[:])
+	}
+	for _, ev := range reorg.Prepared {
+		refIds = append(refIds, ev.RequestTxHash[:])
+	}
+	if len(refIds) == 0 {
+		return nil
+	}
+
+	latest, err := ctm.chainWorker.GetLatestLedgerNumber()
+	if err != nil {
+		return err
+	}
+
+	for _, refId := range refIds {
+		txs, err := ctm.mgrdb.GetMonitoredTxByRefIdentifier(refId)
+		if err != nil {
+			return err
+		}
+		for _, tx := range txs {
+			// only the Tx found on chain, the others are still tracked (or failed)
+			if tx.TxStatus != agreement.Success {
+				continue
+			}
+			if err := ctm.mgrdb.UpdateTxStatus(tx.TxIdentifier, agreement.Pending); err != nil {
+				return err
+			}
+			if err := ctm.mgrdb.UpdateSent(tx.TxIdentifier, latest); err != nil {
+				return err
+			}
+			if err := ctm.mgrdb.UpdateFound(tx.TxIdentifier, nil); err != nil {
+				return err
+			}
+			logger.WithFields(logger.Fields{
+				"txId":      common.ByteSliceToPureHexStr(tx.TxIdentifier),
+				"refId":     common.ByteSliceToPureHexStr(refId),
+				"forkPoint": reorg.ForkPoint,
+			}).Warn("tx orphaned by chain reorg, tracked again")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package chaintxmgr
+
+import (
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	"github.com/TEENet-io/bridge-go/agreement"
+	"github.com/TEENet-io/bridge-go/chaintxmgrdb"
+	"github.com/TEENet-io/bridge-go/common"
+)
+
+// worker at a fixed ledger, nothing else is called
+type latestWorker struct {
+	MgrWorker
+	latest *big.Int
+}
+
+func (w *latestWorker) GetLatestLedgerNumber() (*big.Int, error) { return w.latest, nil }
+
+func TestHandleReorg(t *testing.T) {
+	mgrdb, err := chaintxmgrdb.NewSQLiteChainTxMgrDB(filepath.Join(t.TempDir(), "mgr.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctm := &ChainTxMgr{mgrdb: mgrdb, chainWorker: &latestWorker{latest: big.NewInt(50)}}
+
+	btcTxId := common.RandBytes32()
+	found := &chaintxmgrdb.MonitoredTx{
+		TxIdentifier:                common.RandBytes(32),
+		RefIdentifier:               btcTxId[:],
+		SentBlockchainLedgerNumber:  big.NewInt(10),
+		FoundBlockchainLedgerNumber: big.NewInt(12),
+		TxStatus:                    agreement.Success,
+	}
+	timedOut := &chaintxmgrdb.MonitoredTx{
+		TxIdentifier:               common.RandBytes(32),
+		RefIdentifier:              btcTxId[:],
+		SentBlockchainLedgerNumber: big.NewInt(1),
+		TxStatus:                   agreement.Timeout,
+	}
+	for _, tx := range []*chaintxmgrdb.MonitoredTx{found, timedOut} {
+		if err := mgrdb.InsertMonitoredTx(tx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reorg := agreement.NewChainReorg(big.NewInt(11), []agreement.MintedEvent{{BtcTxId: btcTxId}}, nil, nil)
+	if err := ctm.handleReorg(reorg); err != nil {
+		t.Fatal(err)
+	}
+
+	// the found tx is tracked again from the latest ledger
+	tx, err := mgrdb.GetMonitoredTxByTxIdentifier(found.TxIdentifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tx.TxStatus != agreement.Pending {
+		t.Fatalf("orphaned tx status = %s, want %s", tx.TxStatus, agreement.Pending)
+	}
+	if tx.SentBlockchainLedgerNumber.Cmp(big.NewInt(50)) != 0 || tx.FoundBlockchainLedgerNumber != nil {
+		t.Fatalf("orphaned tx sent at %v, found at %v", tx.SentBlockchainLedgerNumber, tx.FoundBlockchainLedgerNumber)
+	}
+
+	// the failed one is left to the retry policy
+	tx, err = mgrdb.GetMonitoredTxByTxIdentifier(timedOut.TxIdentifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tx.TxStatus != agreement.Timeout {
+		t.Fatalf("failed tx status = %s, want %s", tx.TxStatus, agreement.Timeout)
+	}
+}
@@ -0,0 +1,228 @@
+/*
+Retry policy of the monitored Txs.
+
+A mint/prepare Tx that fails on chain is classified by the reason the chain worker
+decodes (agreement.TxFailure, eg. a Move abort code), or by its status if unknown:
+
+ 1. skip (eg. already minted): the wanted change is on chain, nothing to do.
+ 2. transient (timeout, out of gas): the reference is re-sent as a new Tx after an exponential backoff.
+ 3. permanent (reverted, malform): the same signed params would fail again,
+    the reference is escalated to the operator queue.
+
+A mint/prepare rejected before submission (eg. aborted simulation) is handled the same way.
+
+Every attempt is a new monitored Tx with the same RefIdentifier
+(BtcTxId for mints, RequestTxHash for prepares).
+A reference that exhausts MaxTxAttempts is escalated as well.
+
+Before re-sending, the mint/prepare procedure checks IsMinted/IsPrepared on chain again,
+so a late Tx that finally landed is never sent twice.
+*/
+package chaintxmgr
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/TEENet-io/bridge-go/agreement"
+	"github.com/TEENet-io/bridge-go/chaintxmgrdb"
+	"github.com/TEENet-io/bridge-go/common"
+	logger "github.com/sirupsen/logrus"
+)
+
+type FailureKind int
+
+const (
+	FailureTransient FailureKind = iota // worth another attempt
+	FailurePermanent                    // escalate to operator
+)
+
+// Statuses of a Tx that failed, it will never succeed.
+var failedStatuses = []agreement.MonitoredTxStatus{agreement.Timeout, agreement.Reverted, agreement.MalForm}
+
+// Classify the failure of a Tx by its status.
+func ClassifyFailure(status agreement.MonitoredTxStatus) FailureKind {
+	if status == agreement.Timeout {
+		return FailureTransient
+	}
+	return FailurePermanent
+}
+
+// Backoff before the next attempt when attempts Txs already failed:
+// base * 2^(attempts-1), capped at max (0 = no cap).
+func RetryBackoff(base, max time.Duration, attempts int) time.Duration {
+	backoff := base
+	for i := 1; i < attempts; i++ {
+		if max > 0 && backoff >= max {
+			break
+		}
+		backoff *= 2
+	}
+	if max > 0 && backoff > max {
+		backoff = max
+	}
+	return backoff
+}
+
+// Shall the reference be (re-)sent?
+// Yes if no Tx is sent for it yet (and it is not escalated or waiting for a backoff),
+// or all its Txs failed and the retry policy says it is due.
+func (ctm *ChainTxMgr) shouldSend(refId []byte) (bool, error) {
+	record, err := ctm.mgrdb.GetRetryRecord(refId)
+	if err != nil {
+		return false, err
+	}
+	if record != nil && record.Escalated {
+		return false, nil
+	}
+
+	txs, err := ctm.mgrdb.GetMonitoredTxByRefIdentifier(refId)
+	if err != nil {
+		return false, err
+	}
+	for _, tx := range txs {
+		if !agreement.UtilContains(failedStatuses, tx.TxStatus) {
+			return false, nil // in flight or succeeded
+		}
+	}
+
+	if record == nil {
+		// nothing sent yet, or failed Txs without a record (eg. legacy rows),
+		// the chain is checked again before sending (IsMinted/IsPrepared)
+		return true, nil
+	}
+	return time.Now().Unix() >= record.NextRetryAt, nil
+}
+
+// Record a failed monitored Tx in the retry policy.
+// failure is the decoded reason from the chain worker, nil if unknown (classified by status).
+func (ctm *ChainTxMgr) scheduleRetry(tx *chaintxmgrdb.MonitoredTx, failure *agreement.TxFailure) error {
+	// The failed Tx may be a duplicate of one that landed (eg. a timed out Tx mined late)
+	var refId [32]byte
+	copy(refId[:], tx.RefIdentifier)
+	settled, err := ctm.isSettledOnChain(refId)
+	if err != nil {
+		return err
+	}
+	if settled {
+		logger.WithField("refId", common.ByteSliceToPureHexStr(tx.RefIdentifier)).Info("failed tx already settled on chain, no retry")
+		return nil
+	}
+
+	action := agreement.TxFailureRetry
+	if ClassifyFailure(tx.TxStatus) == FailurePermanent {
+		action = agreement.TxFailurePermanent
+	}
+	reason := fmt.Sprintf("tx %s %s", common.ByteSliceToPureHexStr(tx.TxIdentifier), tx.TxStatus)
+	if failure != nil {
+		action = failure.Action
+		reason += ": " + failure.Reason
+	}
+	if action == agreement.TxFailureSkip {
+		return nil
+	}
+	return ctm.recordFailure(tx.RefIdentifier, action, reason)
+}
+
+// Act on a mint/prepare rejected by the chain worker before it made it on chain,
+// eg. an aborted simulation (see MgrWorker.DoMint/DoPrepare).
+// Return false if err is not an *agreement.TxFailure, nothing is recorded then.
+// Caller holds mgrdbLock.
+func (ctm *ChainTxMgr) handleSubmitFailure(refId []byte, err error) bool {
+	var failure *agreement.TxFailure
+	if !errors.As(err, &failure) {
+		return false
+	}
+
+	if failure.Action == agreement.TxFailureSkip {
+		logger.WithFields(logger.Fields{
+			"refId":  common.ByteSliceToPureHexStr(refId),
+			"reason": failure.Reason,
+		}).Info("already settled on chain, skipping")
+		return true
+	}
+
+	if err := ctm.recordFailure(refId, failure.Action, failure.Reason); err != nil {
+		logger.Errorf("failed to record tx failure: err=%v", err)
+	}
+	return true
+}
+
+// Either schedule the next attempt of the reference or escalate it to the operator queue.
+func (ctm *ChainTxMgr) recordFailure(refId []byte, action agreement.TxFailureAction, reason string) error {
+	record, err := ctm.mgrdb.GetRetryRecord(refId)
+	if err != nil {
+		return err
+	}
+	if record == nil {
+		record = &chaintxmgrdb.RetryRecord{RefIdentifier: refId}
+	}
+	record.Attempts++
+	record.Reason = reason
+
+	switch {
+	case action == agreement.TxFailurePermanent:
+		record.Escalated = true
+	case record.Attempts >= ctm.cfg.MaxTxAttempts:
+		record.Escalated = true
+		record.Reason += fmt.Sprintf(", %d attempts exhausted", record.Attempts)
+	default:
+		backoff := RetryBackoff(ctm.cfg.RetryBackoffBase, ctm.cfg.RetryBackoffMax, record.Attempts)
+		record.NextRetryAt = time.Now().Add(backoff).Unix()
+	}
+
+	fields := logger.Fields{
+		"refId":    common.ByteSliceToPureHexStr(refId),
+		"attempts": record.Attempts,
+		"reason":   record.Reason,
+	}
+	if record.Escalated {
+		logger.WithFields(fields).Warn("tx failed permanently, escalated to operator")
+	} else {
+		logger.WithFields(fields).Info("tx failed, retry scheduled")
+	}
+
+	return ctm.mgrdb.UpsertRetryRecord(record)
+}
+
+// Is the reference minted (BtcTxId) or prepared (RequestTxHash) on chain?
+func (ctm *ChainTxMgr) isSettledOnChain(refId [32]byte) (bool, error) {
+	minted, err := ctm.chainWorker.IsMinted(refId)
+	if err != nil {
+		return false, err
+	}
+	if minted {
+		return true, nil
+	}
+	return ctm.chainWorker.IsPrepared(refId)
+}
+
+// The operator queue: references that failed permanently.
+func (ctm *ChainTxMgr) EscalatedTxs() ([]*chaintxmgrdb.RetryRecord, error) {
+	ctm.mgrdbLock.Lock()
+	defer ctm.mgrdbLock.Unlock()
+
+	return ctm.mgrdb.GetEscalatedRetryRecords()
+}
+
+// Operator resolved the cause of an escalated reference,
+// it is re-sent in the next loop with a fresh attempt budget.
+func (ctm *ChainTxMgr) RequeueEscalated(refId []byte) error {
+	ctm.mgrdbLock.Lock()
+	defer ctm.mgrdbLock.Unlock()
+
+	record, err := ctm.mgrdb.GetRetryRecord(refId)
+	if err != nil {
+		return err
+	}
+	if record == nil || !record.Escalated {
+		return fmt.Errorf("reference %s is not escalated", common.ByteSliceToPureHexStr(refId))
+	}
+
+	record.Attempts = 0
+	record.NextRetryAt = 0
+	record.Escalated = false
+	record.Reason = "requeued by operator"
+	return ctm.mgrdb.UpsertRetryRecord(record)
+}
@@ -0,0 +1,212 @@
+package chaintxmgr
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/TEENet-io/bridge-go/agreement"
+	"github.com/TEENet-io/bridge-go/chaintxmgrdb"
+	"github.com/TEENet-io/bridge-go/common"
+	"github.com/TEENet-io/bridge-go/state"
+)
+
+func TestClassifyFailure(t *testing.T) {
+	if ClassifyFailure(agreement.Timeout) != FailureTransient {
+		t.Fatalf("timeout shall be transient")
+	}
+	for _, status := range []agreement.MonitoredTxStatus{agreement.Reverted, agreement.MalForm} {
+		if ClassifyFailure(status) != FailurePermanent {
+			t.Fatalf("%s shall be permanent", status)
+		}
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	base, max := 10*time.Second, time.Minute
+	expected := []time.Duration{10 * time.Second, 20 * time.Second, 40 * time.Second, time.Minute, time.Minute}
+	for i, want := range expected {
+		if got := RetryBackoff(base, max, i+1); got != want {
+			t.Fatalf("backoff after %d attempts = %v, want %v", i+1, got, want)
+		}
+	}
+
+	// no cap
+	if got := RetryBackoff(base, 0, 5); got != 160*time.Second {
+		t.Fatalf("uncapped backoff = %v, want %v", got, 160*time.Second)
+	}
+}
+
+// worker whose txs all revert, settledErr fails the on-chain checks
+type revertWorker struct {
+	latestWorker
+	settledErr error
+}
+
+func (w *revertWorker) GetTxStatus(txId []byte) (agreement.MonitoredTxStatus, *big.Int, error) {
+	return agreement.Reverted, w.latest, nil
+}
+func (w *revertWorker) GetTxFailure(txId []byte) (*agreement.TxFailure, error) {
+	return &agreement.TxFailure{Action: agreement.TxFailureRetry, Reason: "out of gas"}, nil
+}
+func (w *revertWorker) IsMinted(btcTxId [32]byte) (bool, error)       { return false, w.settledErr }
+func (w *revertWorker) IsPrepared(requestTxId [32]byte) (bool, error) { return false, w.settledErr }
+
+func TestMarkTxStatusWithRetryRecord(t *testing.T) {
+	mgrdb, err := chaintxmgrdb.NewSQLiteChainTxMgrDB(filepath.Join(t.TempDir(), "mgr.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	worker := &revertWorker{latestWorker: latestWorker{latest: big.NewInt(20)}, settledErr: errors.New("rpc down")}
+	ctm := &ChainTxMgr{
+		cfg:         &ChainTxMgrConfig{TimeoutTxLedgerNumber: big.NewInt(10), MaxTxAttempts: 3, RetryBackoffBase: time.Minute},
+		mgrdb:       mgrdb,
+		chainWorker: worker,
+	}
+
+	refId := common.RandBytes(32)
+	tx := &chaintxmgrdb.MonitoredTx{
+		TxIdentifier:               common.RandBytes(32),
+		RefIdentifier:              refId,
+		SentBlockchainLedgerNumber: big.NewInt(15),
+		TxStatus:                   agreement.Pending,
+	}
+	if err := mgrdb.InsertMonitoredTx(tx); err != nil {
+		t.Fatal(err)
+	}
+
+	// the retry cannot be scheduled: the tx stays pending, not re-sent
+	if err := ctm.procedureMarkTxStatus(); err != nil {
+		t.Fatal(err)
+	}
+	actual, err := mgrdb.GetMonitoredTxByTxIdentifier(tx.TxIdentifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual.TxStatus != agreement.Pending {
+		t.Fatalf("tx status = %s, want %s", actual.TxStatus, agreement.Pending)
+	}
+	if send, _ := ctm.shouldSend(refId); send {
+		t.Fatalf("pending tx shall not be re-sent")
+	}
+
+	// saved with its retry record
+	worker.settledErr = nil
+	if err := ctm.procedureMarkTxStatus(); err != nil {
+		t.Fatal(err)
+	}
+	actual, err = mgrdb.GetMonitoredTxByTxIdentifier(tx.TxIdentifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual.TxStatus != agreement.Reverted || actual.Reason != "out of gas" {
+		t.Fatalf("tx status = %s (%s), want %s", actual.TxStatus, actual.Reason, agreement.Reverted)
+	}
+	record, err := mgrdb.GetRetryRecord(refId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record == nil || record.Attempts != 1 || record.Escalated {
+		t.Fatalf("retry record = %+v, want 1 attempt scheduled", record)
+	}
+	if send, _ := ctm.shouldSend(refId); send {
+		t.Fatalf("failed tx shall not be re-sent before the backoff")
+	}
+}
+
+func TestShouldSendFailedWithoutRecord(t *testing.T) {
+	mgrdb, err := chaintxmgrdb.NewSQLiteChainTxMgrDB(filepath.Join(t.TempDir(), "mgr.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctm := &ChainTxMgr{mgrdb: mgrdb}
+
+	refId := common.RandBytes(32)
+	if send, err := ctm.shouldSend(refId); err != nil || !send {
+		t.Fatalf("new reference shall be sent: send=%v, err=%v", send, err)
+	}
+
+	err = mgrdb.InsertMonitoredTx(&chaintxmgrdb.MonitoredTx{
+		TxIdentifier:  common.RandBytes(32),
+		RefIdentifier: refId,
+		TxStatus:      agreement.Timeout,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if send, err := ctm.shouldSend(refId); err != nil || !send {
+		t.Fatalf("failed reference without a retry record shall be sent: send=%v, err=%v", send, err)
+	}
+}
+
+// responder without enough UTXOs
+type emptyResponder struct {
+	requests int
+}
+
+func (r *emptyResponder) Request(reqTxId []byte, amount *big.Int, ch chan<- []agreement.BtcOutpoint) error {
+	r.requests++
+	return errors.New("not enough utxos")
+}
+
+func TestPreparePrepareFailureRecorded(t *testing.T) {
+	mgrdb, err := chaintxmgrdb.NewSQLiteChainTxMgrDB(filepath.Join(t.TempDir(), "mgr.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	statedb, err := state.NewStateDB(sqlDB, state.ChainNamespace(common.DEPOSIT_CHAIN_TYPE_EVM, 1337))
+	if err != nil {
+		t.Fatal(err)
+	}
+	redeem := &state.Redeem{
+		RequestTxHash: common.RandBytes32(),
+		Requester:     common.RandBytes(20),
+		Receiver:      "bcrt1qtest",
+		Amount:        big.NewInt(100),
+		Status:        state.RedeemStatusRequested,
+	}
+	if err := statedb.InsertAfterRequested(redeem); err != nil {
+		t.Fatal(err)
+	}
+
+	responder := &emptyResponder{}
+	ctm := &ChainTxMgr{
+		cfg:              &ChainTxMgrConfig{MaxTxAttempts: 3, RetryBackoffBase: time.Minute},
+		statedb:          statedb,
+		mgrdb:            mgrdb,
+		btcUTXOResponder: responder,
+		chainWorker:      &revertWorker{},
+	}
+	ctm.preparePool = ctm.newPreparePool()
+
+	// the failed prepare is recorded, not requested again before the backoff
+	for i := 0; i < 2; i++ {
+		if err := ctm.procedurePrepare(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		// as a prepare worker does
+		select {
+		case redeem := <-ctm.preparePool.queue:
+			ctm.preparePool.run(context.Background(), redeem)
+		default:
+		}
+	}
+	if responder.requests != 1 {
+		t.Fatalf("utxos requested %d times, want 1", responder.requests)
+	}
+	record, err := mgrdb.GetRetryRecord(redeem.RequestTxHash.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record == nil || record.Attempts != 1 || record.Escalated {
+		t.Fatalf("retry record = %+v, want 1 attempt scheduled", record)
+	}
+}
@@ -0,0 +1,293 @@
+/*
+Pipelined mint and prepare submission.
+
+procedureMint/procedurePrepare only find the mints/prepares to send and queue them,
+a bounded pool of workers (one pool for mints, one for prepares) signs and submits them in parallel.
+
+Each mint (BtcTxId) or prepare (RequestTxHash) is tracked from the moment it is queued until its Tx is
+recorded in the mgr db (or the attempt is given up before submission),
+it is never queued twice in the meantime, so it is submitted at most once.
+A Tx submitted but not recorded keeps its id tracked until a restart,
+then the chain is checked (IsMinted/IsPrepared) before it is sent again.
+
+The chain workers keep the submissions in order (Ethereum nonce under a lock, Aptos sequence numbers reserved locally),
+so several Txs can be in flight.
+*/
+package chaintxmgr
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+	"sync/atomic"
+
+	"github.com/TEENet-io/bridge-go/agreement"
+	"github.com/TEENet-io/bridge-go/state"
+	logger "github.com/sirupsen/logrus"
+)
+
+const (
+	DEFAULT_MINT_WORKERS    = 1
+	DEFAULT_MINT_QUEUE_SIZE = 256
+
+	DEFAULT_PREPARE_WORKERS    = 1
+	DEFAULT_PREPARE_QUEUE_SIZE = 256
+)
+
+type txPool[T any] struct {
+	name    string                                 // "mint" or "prepare", for logs
+	id      func(T) [32]byte                       // BtcTxId of a mint, RequestTxHash of a prepare
+	process func(context.Context, T) (bool, error) // sign & submit, true to keep the id tracked
+	queue   chan T
+
+	mu      sync.Mutex
+	tracked map[[32]byte]struct{} // ids queued or being processed
+
+	inFlight atomic.Int64 // items being signed/submitted by workers
+}
+
+func newTxPool[T any](name string, queueSize int, id func(T) [32]byte, process func(context.Context, T) (bool, error)) *txPool[T] {
+	return &txPool[T]{
+		name:    name,
+		id:      id,
+		process: process,
+		queue:   make(chan T, queueSize),
+		tracked: make(map[[32]byte]struct{}),
+	}
+}
+
+func (ctm *ChainTxMgr) newMintPool() *txPool[*state.Mint] {
+	queueSize := ctm.cfg.MintQueueSize
+	if queueSize <= 0 {
+		queueSize = DEFAULT_MINT_QUEUE_SIZE
+	}
+	return newTxPool("mint", queueSize, func(mint *state.Mint) [32]byte { return mint.BtcTxId }, ctm.processMint)
+}
+
+func (ctm *ChainTxMgr) newPreparePool() *txPool[*state.Redeem] {
+	queueSize := ctm.cfg.PrepareQueueSize
+	if queueSize <= 0 {
+		queueSize = DEFAULT_PREPARE_QUEUE_SIZE
+	}
+	return newTxPool("prepare", queueSize, func(redeem *state.Redeem) [32]byte { return redeem.RequestTxHash }, ctm.processPrepare)
+}
+
+// Queue the item unless it is already tracked.
+// Return false if it is tracked or the queue is full.
+func (p *txPool[T]) enqueue(item T) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	id := p.id(item)
+	if _, ok := p.tracked[id]; ok {
+		return false
+	}
+
+	select {
+	case p.queue <- item:
+		p.tracked[id] = struct{}{}
+		return true
+	default:
+		return false
+	}
+}
+
+// Stop tracking the id, it can be queued again.
+func (p *txPool[T]) release(id [32]byte) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	delete(p.tracked, id)
+}
+
+// Start the workers, they stop with the ctx.
+func (p *txPool[T]) start(ctx context.Context, workers int) {
+	for i := 0; i < workers; i++ {
+		go p.work(ctx)
+	}
+}
+
+func (p *txPool[T]) work(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case item := <-p.queue:
+			p.run(ctx, item)
+		}
+	}
+}
+
+// Process a dequeued item, release it unless its Tx is submitted but not recorded.
+func (p *txPool[T]) run(ctx context.Context, item T) {
+	p.inFlight.Add(1)
+	keep, err := p.process(ctx, item)
+	if err != nil {
+		logger.WithError(err).WithField("id", fmt.Sprintf("%x", p.id(item))).Errorf("Failed to process %s", p.name)
+	}
+	p.inFlight.Add(-1)
+	if !keep {
+		p.release(p.id(item))
+	}
+}
+
+// Number of mints waiting in the queue.
+func (ctm *ChainTxMgr) MintQueueDepth() int {
+	return len(ctm.mintPool.queue)
+}
+
+// Number of mints being signed/submitted.
+func (ctm *ChainTxMgr) MintsInFlight() int {
+	return int(ctm.mintPool.inFlight.Load())
+}
+
+// Number of prepares waiting in the queue.
+func (ctm *ChainTxMgr) PrepareQueueDepth() int {
+	return len(ctm.preparePool.queue)
+}
+
+// Number of prepares being signed/submitted.
+func (ctm *ChainTxMgr) PreparesInFlight() int {
+	return int(ctm.preparePool.inFlight.Load())
+}
+
+// Start the mint and prepare workers, they stop with the ctx.
+func (ctm *ChainTxMgr) startWorkers(ctx context.Context) {
+	mintWorkers := ctm.cfg.MintWorkers
+	if mintWorkers <= 0 {
+		mintWorkers = DEFAULT_MINT_WORKERS
+	}
+	ctm.mintPool.start(ctx, mintWorkers)
+
+	prepareWorkers := ctm.cfg.PrepareWorkers
+	if prepareWorkers <= 0 {
+		prepareWorkers = DEFAULT_PREPARE_WORKERS
+	}
+	ctm.preparePool.start(ctx, prepareWorkers)
+}
+
+// Sign, submit and monitor a single mint.
+// Return true if the mint shall stay tracked in the pool:
+// its Tx is submitted but could not be recorded, it must not be submitted again.
+func (ctm *ChainTxMgr) processMint(ctx context.Context, mint *state.Mint) (bool, error) {
+	// 1. Check double mint
+	found, err := ctm.IsDoubleMint(mint)
+	if err != nil {
+		return false, fmt.Errorf("failed to check double mint: %v", err)
+	}
+	if found {
+		logger.Info("Mint already exists on chain, skipping")
+		return false, nil
+	}
+
+	// 2. Prepare mint params (signature requests run in parallel across workers)
+	_mint_params, err := ctm.PrepareMint(ctx, mint)
+	if err != nil {
+		return false, fmt.Errorf("failed to prepare mint params: %v", err)
+	}
+	logger.WithFields(logger.Fields{
+		"btc_tx_id":    fmt.Sprintf("%x", _mint_params.BtcTxId),
+		"amount":       _mint_params.Amount.String(),
+		"receiver":     fmt.Sprintf("%x", _mint_params.Receiver),
+		"receiver_len": len(_mint_params.Receiver),
+	}).Info("Prepared mint params")
+
+	// 3. Call mint (submitted, not waited for, see MgrWorker.DoMint)
+	tx_id, ledger_number, err := ctm.CallMint(_mint_params)
+	if err != nil {
+		// Rejected by the chain (eg. aborted simulation): skip, retry later or escalate
+		ctm.mgrdbLock.Lock()
+		handled := ctm.handleSubmitFailure(mint.BtcTxId.Bytes(), err)
+		ctm.mgrdbLock.Unlock()
+		if handled {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to call mint: %v", err)
+	}
+
+	// 4. Set monitoring right after the submission, before the mint is released from the pool.
+	// A nil ledger_number is set by the Tx status tracking (timeout counts from then).
+	ctm.mgrdbLock.Lock()
+	defer ctm.mgrdbLock.Unlock()
+	err = ctm.SetMintToBeMonitored(_mint_params, tx_id, ledger_number, agreement.Pending)
+	if err != nil {
+		return true, fmt.Errorf("failed to set mint monitoring, tx %x submitted: %v", tx_id, err)
+	}
+	logger.WithFields(logger.Fields{
+		"tx_id":         fmt.Sprintf("%x", tx_id),
+		"ledger_number": ledger_number,
+	}).Info("Mint submitted and set for monitoring")
+	return false, nil
+}
+
+// Lock UTXOs, sign, submit and monitor a single prepare.
+// Return true if the prepare shall stay tracked in the pool:
+// its Tx is submitted but could not be recorded, it must not be submitted again.
+func (ctm *ChainTxMgr) processPrepare(ctx context.Context, redeem *state.Redeem) (bool, error) {
+	// 1. Check if the redeem is already prepared on chain
+	found, err := ctm.IsDoublePrepare(redeem)
+	if err != nil {
+		return false, fmt.Errorf("failed to check if prepared: %v", err)
+	}
+	if found {
+		return false, nil
+	}
+
+	// 2. Prepare redeem params (UTXO and signature requests run in parallel across workers)
+	pp, err := ctm.PreparePrepare(ctx, redeem)
+	if err != nil {
+		if ctx.Err() != nil {
+			return false, ctx.Err()
+		}
+		// eg. no UTXOs or no signature: retry later or escalate
+		ctm.mgrdbLock.Lock()
+		defer ctm.mgrdbLock.Unlock()
+		if err := ctm.recordFailure(redeem.RequestTxHash.Bytes(), agreement.TxFailureRetry, fmt.Sprintf("prepare params: %v", err)); err != nil {
+			logger.Errorf("failed to record prepare failure: err=%v", err)
+		}
+		return false, fmt.Errorf("failed to prepare redeem params: %v", err)
+	}
+
+	// 3. Call prepareRedeem() on chain
+	tx_id, ledger_number, err := ctm.CallPrepare(pp)
+	if err != nil {
+		ctm.mgrdbLock.Lock()
+		handled := ctm.handleSubmitFailure(redeem.RequestTxHash.Bytes(), err)
+		ctm.mgrdbLock.Unlock()
+		if handled {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to call prepareRedeem(): %v", err)
+	}
+	// Extra: if the ledger_number is nil, we try the <best effort> to set it
+	if ledger_number == nil {
+		ledger_number = ctm.latestLedgerNumber()
+	}
+
+	// 4. Set Tx to the mgrdb, and it is "pending" status
+	ctm.mgrdbLock.Lock()
+	defer ctm.mgrdbLock.Unlock()
+	err = ctm.SetPrepareToBeMonitored(pp, tx_id, ledger_number, agreement.Pending)
+	if err != nil {
+		return true, fmt.Errorf("failed to set prepare monitoring, tx %x submitted: %v", tx_id, err)
+	}
+	logger.WithFields(logger.Fields{
+		"tx_id":         fmt.Sprintf("%x", tx_id),
+		"ledger_number": ledger_number,
+	}).Info("Prepare submitted and set for monitoring")
+	return false, nil
+}
+
+// Latest ledger number of the chain, nil if unknown.
+func (ctm *ChainTxMgr) latestLedgerNumber() *big.Int {
+	latest, err := ctm.chainWorker.GetLatestLedgerNumber()
+	if err != nil {
+		logger.Errorf("failed to get latest ledger number: err=%v", err)
+		return nil
+	}
+	if latest == nil {
+		logger.Errorf("latest ledger number is nil")
+	}
+	return latest
+}
@@ -0,0 +1,61 @@
+package chaintxmgr
+
+import (
+	"testing"
+
+	"github.com/TEENet-io/bridge-go/common"
+	"github.com/TEENet-io/bridge-go/state"
+)
+
+func TestMintPoolEnqueue(t *testing.T) {
+	pool := (&ChainTxMgr{cfg: &ChainTxMgrConfig{MintQueueSize: 2}}).newMintPool()
+	m0 := &state.Mint{BtcTxId: common.RandBytes32()}
+	m1 := &state.Mint{BtcTxId: common.RandBytes32()}
+	m2 := &state.Mint{BtcTxId: common.RandBytes32()}
+
+	if !pool.enqueue(m0) {
+		t.Fatalf("mint shall be queued")
+	}
+	if pool.enqueue(&state.Mint{BtcTxId: m0.BtcTxId}) {
+		t.Fatalf("a tracked btc tx id shall not be queued twice")
+	}
+	if !pool.enqueue(m1) {
+		t.Fatalf("mint shall be queued")
+	}
+	if pool.enqueue(m2) {
+		t.Fatalf("full queue shall not accept mints")
+	}
+
+	// taken by a worker, still tracked until released
+	<-pool.queue
+	if pool.enqueue(m0) {
+		t.Fatalf("a mint being processed shall not be queued again")
+	}
+	pool.release(m0.BtcTxId)
+	if !pool.enqueue(m0) {
+		t.Fatalf("a released mint shall be queued again")
+	}
+}
+
+func TestPreparePoolEnqueue(t *testing.T) {
+	pool := (&ChainTxMgr{cfg: &ChainTxMgrConfig{}}).newPreparePool()
+	if cap(pool.queue) != DEFAULT_PREPARE_QUEUE_SIZE {
+		t.Fatalf("queue size = %d, want %d", cap(pool.queue), DEFAULT_PREPARE_QUEUE_SIZE)
+	}
+	r0 := &state.Redeem{RequestTxHash: common.RandBytes32()}
+
+	if !pool.enqueue(r0) {
+		t.Fatalf("redeem shall be queued")
+	}
+	if pool.enqueue(&state.Redeem{RequestTxHash: r0.RequestTxHash}) {
+		t.Fatalf("a tracked request tx hash shall not be queued twice")
+	}
+	<-pool.queue
+	if pool.enqueue(r0) {
+		t.Fatalf("a prepare being processed shall not be queued again")
+	}
+	pool.release(r0.RequestTxHash)
+	if !pool.enqueue(r0) {
+		t.Fatalf("a released prepare shall be queued again")
+	}
+}
@@ -1,6 +1,6 @@
 /*
 SQLiteChainTxMgrDB implements ChainTxMgrDB.
-Table is chain_tx_mgr_db
+Table is chain_tx_mgr_db (retry records in chain_tx_mgr_retry, replacements in chain_tx_mgr_replacement)
 
 Internally,
 
@@ -12,6 +12,7 @@ package chaintxmgrdb
 
 import (
 	"database/sql"
+	"fmt"
 	"math/big"
 	"strings"
 
@@ -44,12 +45,59 @@ func (s *SQLiteChainTxMgrDB) init() error {
 		RefIdentifier BLOB,
 		SentBlockchainLedgerNumber INTEGER,
 		FoundBlockchainLedgerNumber INTEGER,
-		TxStatus TEXT
+		TxStatus TEXT,
+		Reason TEXT DEFAULT ''
 	);
 	CREATE INDEX IF NOT EXISTS idx_ref_identifier ON chain_tx_mgr_db (RefIdentifier);
 	CREATE INDEX IF NOT EXISTS idx_tx_status ON chain_tx_mgr_db (TxStatus);
+
+	CREATE TABLE IF NOT EXISTS chain_tx_mgr_retry (
+		RefIdentifier BLOB PRIMARY KEY,
+		Attempts INTEGER,
+		NextRetryAt INTEGER,
+		Escalated BOOLEAN,
+		Reason TEXT
+	);
+	CREATE INDEX IF NOT EXISTS idx_retry_escalated ON chain_tx_mgr_retry (Escalated);
+
+	CREATE TABLE IF NOT EXISTS chain_tx_mgr_replacement (
+		TxIdentifier BLOB PRIMARY KEY,
+		Replaces BLOB NOT NULL,
+		SentBlockchainLedgerNumber INTEGER
+	);
+	CREATE INDEX IF NOT EXISTS idx_replaces ON chain_tx_mgr_replacement (Replaces);
 	`
-	_, err := s.db.Exec(query)
+	if _, err := s.db.Exec(query); err != nil {
+		return err
+	}
+
+	// Tables created by older versions miss the failure reason.
+	return s.addColumnIfMissing("chain_tx_mgr_db", "Reason", "TEXT DEFAULT ''")
+}
+
+func (s *SQLiteChainTxMgrDB) addColumnIfMissing(table string, column string, definition string) error {
+	rows, err := s.db.Query(fmt.Sprintf(`PRAGMA table_info(%s)`, table))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, ctype string
+		var dflt sql.NullString
+		if err := rows.Scan(&cid, &name, &ctype, &notNull, &dflt, &pk); err != nil {
+			return err
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	_, err = s.db.Exec(fmt.Sprintf(`ALTER TABLE %s ADD COLUMN %s %s`, table, column, definition))
 	return err
 }
 
@@ -60,8 +108,8 @@ func (s *SQLiteChainTxMgrDB) init() error {
 
 func (s *SQLiteChainTxMgrDB) InsertMonitoredTx(tx *MonitoredTx) error {
 	query := `
-	INSERT INTO chain_tx_mgr_db (TxIdentifier, RefIdentifier, SentBlockchainLedgerNumber, FoundBlockchainLedgerNumber, TxStatus)
-	VALUES (?, ?, ?, ?, ?);
+	INSERT INTO chain_tx_mgr_db (TxIdentifier, RefIdentifier, SentBlockchainLedgerNumber, FoundBlockchainLedgerNumber, TxStatus, Reason)
+	VALUES (?, ?, ?, ?, ?, ?);
 	`
 	sentLedgerNumber := int64(-1)
 	if tx.SentBlockchainLedgerNumber != nil {
@@ -73,7 +121,7 @@ func (s *SQLiteChainTxMgrDB) InsertMonitoredTx(tx *MonitoredTx) error {
 		foundLedgerNumber = tx.FoundBlockchainLedgerNumber.Int64()
 	}
 
-	_, err := s.db.Exec(query, tx.TxIdentifier, tx.RefIdentifier, sentLedgerNumber, foundLedgerNumber, tx.TxStatus)
+	_, err := s.db.Exec(query, tx.TxIdentifier, tx.RefIdentifier, sentLedgerNumber, foundLedgerNumber, tx.TxStatus, tx.Reason)
 	return err
 }
 
@@ -87,14 +135,14 @@ func (s *SQLiteChainTxMgrDB) DeleteMonitoredTxByTxHash(identifier []byte) error
 
 func (s *SQLiteChainTxMgrDB) GetMonitoredTxByTxIdentifier(identifier []byte) (*MonitoredTx, error) {
 	query := `
-	SELECT TxIdentifier, RefIdentifier, SentBlockchainLedgerNumber, FoundBlockchainLedgerNumber, TxStatus
+	SELECT TxIdentifier, RefIdentifier, SentBlockchainLedgerNumber, FoundBlockchainLedgerNumber, TxStatus, Reason
 	FROM chain_tx_mgr_db WHERE TxIdentifier = ?;
 	`
 	row := s.db.QueryRow(query, identifier)
 
 	tx := &MonitoredTx{}
 	var sentLedgerNumber, foundLedgerNumber int64
-	err := row.Scan(&tx.TxIdentifier, &tx.RefIdentifier, &sentLedgerNumber, &foundLedgerNumber, &tx.TxStatus)
+	err := row.Scan(&tx.TxIdentifier, &tx.RefIdentifier, &sentLedgerNumber, &foundLedgerNumber, &tx.TxStatus, &tx.Reason)
 	if err == nil {
 		if sentLedgerNumber == -1 {
 			tx.SentBlockchainLedgerNumber = nil
@@ -115,7 +163,7 @@ func (s *SQLiteChainTxMgrDB) GetMonitoredTxByTxIdentifier(identifier []byte) (*M
 
 func (s *SQLiteChainTxMgrDB) GetMonitoredTxByRefIdentifier(refIdentifier []byte) ([]*MonitoredTx, error) {
 	query := `
-	SELECT TxIdentifier, RefIdentifier, SentBlockchainLedgerNumber, FoundBlockchainLedgerNumber, TxStatus
+	SELECT TxIdentifier, RefIdentifier, SentBlockchainLedgerNumber, FoundBlockchainLedgerNumber, TxStatus, Reason
 	FROM chain_tx_mgr_db WHERE RefIdentifier = ?;
 	`
 	rows, err := s.db.Query(query, refIdentifier)
@@ -129,7 +177,7 @@ func (s *SQLiteChainTxMgrDB) GetMonitoredTxByRefIdentifier(refIdentifier []byte)
 		tx := &MonitoredTx{}
 
 		var sentLedgerNumber, foundLedgerNumber int64
-		if err := rows.Scan(&tx.TxIdentifier, &tx.RefIdentifier, &sentLedgerNumber, &foundLedgerNumber, &tx.TxStatus); err != nil {
+		if err := rows.Scan(&tx.TxIdentifier, &tx.RefIdentifier, &sentLedgerNumber, &foundLedgerNumber, &tx.TxStatus, &tx.Reason); err != nil {
 			return nil, err
 		}
 		if sentLedgerNumber == -1 {
@@ -149,7 +197,7 @@ func (s *SQLiteChainTxMgrDB) GetMonitoredTxByRefIdentifier(refIdentifier []byte)
 
 func (s *SQLiteChainTxMgrDB) GetMonitoredTxByStatus(status []agreement.MonitoredTxStatus) ([]*MonitoredTx, error) {
 	query := `
-	SELECT TxIdentifier, RefIdentifier, SentBlockchainLedgerNumber, FoundBlockchainLedgerNumber, TxStatus
+	SELECT TxIdentifier, RefIdentifier, SentBlockchainLedgerNumber, FoundBlockchainLedgerNumber, TxStatus, Reason
 	FROM chain_tx_mgr_db WHERE TxStatus IN (?` + strings.Repeat(", ?", len(status)-1) + `);
 	`
 	args := make([]interface{}, len(status))
@@ -167,7 +215,7 @@ func (s *SQLiteChainTxMgrDB) GetMonitoredTxByStatus(status []agreement.Monitored
 	for rows.Next() {
 		tx := &MonitoredTx{}
 		var sentLedgerNumber, foundLedgerNumber int64
-		if err := rows.Scan(&tx.TxIdentifier, &tx.RefIdentifier, &sentLedgerNumber, &foundLedgerNumber, &tx.TxStatus); err != nil {
+		if err := rows.Scan(&tx.TxIdentifier, &tx.RefIdentifier, &sentLedgerNumber, &foundLedgerNumber, &tx.TxStatus, &tx.Reason); err != nil {
 			return nil, err
 		}
 		if sentLedgerNumber == -1 {
@@ -205,7 +253,11 @@ func (s *SQLiteChainTxMgrDB) UpdateFound(identifier []byte, foundAt *big.Int) er
 	query := `
 	UPDATE chain_tx_mgr_db SET FoundBlockchainLedgerNumber = ? WHERE TxIdentifier = ?;
 	`
-	_, err := s.db.Exec(query, foundAt.Int64(), identifier)
+	foundLedgerNumber := int64(-1)
+	if foundAt != nil {
+		foundLedgerNumber = foundAt.Int64()
+	}
+	_, err := s.db.Exec(query, foundLedgerNumber, identifier)
 	return err
 }
 
@@ -216,3 +268,100 @@ func (s *SQLiteChainTxMgrDB) UpdateTxStatus(identifier []byte, status agreement.
 	_, err := s.db.Exec(query, status, identifier)
 	return err
 }
+
+func (s *SQLiteChainTxMgrDB) UpdateReason(identifier []byte, reason string) error {
+	query := `
+	UPDATE chain_tx_mgr_db SET Reason = ? WHERE TxIdentifier = ?;
+	`
+	_, err := s.db.Exec(query, reason, identifier)
+	return err
+}
+
+func (s *SQLiteChainTxMgrDB) UpsertRetryRecord(record *RetryRecord) error {
+	query := `
+	INSERT OR REPLACE INTO chain_tx_mgr_retry (RefIdentifier, Attempts, NextRetryAt, Escalated, Reason)
+	VALUES (?, ?, ?, ?, ?);
+	`
+	_, err := s.db.Exec(query, record.RefIdentifier, record.Attempts, record.NextRetryAt, record.Escalated, record.Reason)
+	return err
+}
+
+func (s *SQLiteChainTxMgrDB) GetRetryRecord(refIdentifier []byte) (*RetryRecord, error) {
+	query := `
+	SELECT RefIdentifier, Attempts, NextRetryAt, Escalated, Reason
+	FROM chain_tx_mgr_retry WHERE RefIdentifier = ?;
+	`
+	row := s.db.QueryRow(query, refIdentifier)
+
+	record := &RetryRecord{}
+	err := row.Scan(&record.RefIdentifier, &record.Attempts, &record.NextRetryAt, &record.Escalated, &record.Reason)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+func (s *SQLiteChainTxMgrDB) GetEscalatedRetryRecords() ([]*RetryRecord, error) {
+	query := `
+	SELECT RefIdentifier, Attempts, NextRetryAt, Escalated, Reason
+	FROM chain_tx_mgr_retry WHERE Escalated = ?;
+	`
+	rows, err := s.db.Query(query, true)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []*RetryRecord
+	for rows.Next() {
+		record := &RetryRecord{}
+		if err := rows.Scan(&record.RefIdentifier, &record.Attempts, &record.NextRetryAt, &record.Escalated, &record.Reason); err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+func (s *SQLiteChainTxMgrDB) InsertReplacementTx(tx *ReplacementTx) error {
+	query := `
+	INSERT INTO chain_tx_mgr_replacement (TxIdentifier, Replaces, SentBlockchainLedgerNumber)
+	VALUES (?, ?, ?);
+	`
+	sentLedgerNumber := int64(-1)
+	if tx.SentBlockchainLedgerNumber != nil {
+		sentLedgerNumber = tx.SentBlockchainLedgerNumber.Int64()
+	}
+
+	_, err := s.db.Exec(query, tx.TxIdentifier, tx.Replaces, sentLedgerNumber)
+	return err
+}
+
+func (s *SQLiteChainTxMgrDB) GetReplacementTxs(identifier []byte) ([]*ReplacementTx, error) {
+	query := `
+	SELECT TxIdentifier, Replaces, SentBlockchainLedgerNumber
+	FROM chain_tx_mgr_replacement WHERE Replaces = ? ORDER BY rowid;
+	`
+	rows, err := s.db.Query(query, identifier)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	txs := []*ReplacementTx{}
+	for rows.Next() {
+		tx := &ReplacementTx{}
+		var sentLedgerNumber int64
+		if err := rows.Scan(&tx.TxIdentifier, &tx.Replaces, &sentLedgerNumber); err != nil {
+			return nil, err
+		}
+		if sentLedgerNumber != -1 {
+			tx.SentBlockchainLedgerNumber = big.NewInt(sentLedgerNumber)
+		}
+		txs = append(txs, tx)
+	}
+	return txs, rows.Err()
+}
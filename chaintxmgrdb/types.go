@@ -13,6 +13,25 @@ type MonitoredTx struct {
 	SentBlockchainLedgerNumber  *big.Int // default nil (unknown), The Tx is sent at this point (blocknumber/ledger number/timestamp)
 	FoundBlockchainLedgerNumber *big.Int // default nil (unknown), The Tx is found at this point (either success or reverted)
 	TxStatus                    agreement.MonitoredTxStatus
+	Reason                      string // default "" (unknown), why the Tx failed (eg. decoded abort code)
+}
+
+// RetryRecord tracks the failed attempts of one reference (mint/prepare),
+// all attempts share the same RefIdentifier in the monitored txs.
+type RetryRecord struct {
+	RefIdentifier []byte // Reference Identifier of the failed Tx(s), this is the primary key.
+	Attempts      int    // How many Txs are sent for the reference so far
+	NextRetryAt   int64  // unix seconds, the reference is not re-sent before it
+	Escalated     bool   // Permanent failure, waits for an operator (not re-sent)
+	Reason        string // Why the last attempt failed
+}
+
+// ReplacementTx is a Tx sent to replace a monitored Tx (eg. the same tx with bumped fees on eth).
+// The monitored Tx keeps its identifier, its status follows whichever of the Txs is included.
+type ReplacementTx struct {
+	TxIdentifier               []byte   // The replacement Tx ID, this is the primary key.
+	Replaces                   []byte   // TxIdentifier of the monitored Tx replaced
+	SentBlockchainLedgerNumber *big.Int // default nil (unknown), The replacement is sent at this point
 }
 
 // Defines what the DB should do
@@ -46,9 +65,31 @@ type ChainTxMgrDB interface {
 	// Update SentBlockchainLedgerNumber field
 	UpdateSent(identifier []byte, sentAt *big.Int) error
 
-	// Update FoundBlockchainLedgerNumber field
+	// Update FoundBlockchainLedgerNumber field (nil = unknown, eg. orphaned by a reorg)
 	UpdateFound(identifier []byte, foundAt *big.Int) error
 
 	// Update Status field
 	UpdateTxStatus(identifier []byte, status agreement.MonitoredTxStatus) error
+
+	// Update Reason field
+	UpdateReason(identifier []byte, reason string) error
+
+	// Insert or replace the retry record of a reference
+	UpsertRetryRecord(record *RetryRecord) error
+
+	// Get the retry record by reference identifier,
+	// result can be null (if not found)
+	GetRetryRecord(refIdentifier []byte) (*RetryRecord, error)
+
+	// Get all escalated retry records (the operator queue)
+	// result can be empty slice (if not found)
+	GetEscalatedRetryRecords() ([]*RetryRecord, error)
+
+	// Insert a replacement of a monitored Tx
+	// error = 1) duplicate insertion (same TxIdentifier), 2) database error, etc ...
+	InsertReplacementTx(tx *ReplacementTx) error
+
+	// Get the replacements of a monitored Tx, oldest first
+	// result can be empty slice (if not replaced)
+	GetReplacementTxs(identifier []byte) ([]*ReplacementTx, error)
 }
@@ -15,6 +15,9 @@ import (
 	"time"
 
 	"github.com/btcsuite/btcd/chaincfg"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
 	logger "github.com/sirupsen/logrus"
 
 	"github.com/TEENet-io/bridge-go/aptosman"
@@ -24,11 +27,12 @@ import (
 	"github.com/TEENet-io/bridge-go/btcsync"
 	"github.com/TEENet-io/bridge-go/btctxmanager"
 	"github.com/TEENet-io/bridge-go/btcvault"
+	"github.com/TEENet-io/bridge-go/chainregistry"
 	"github.com/TEENet-io/bridge-go/chainsync"
 	"github.com/TEENet-io/bridge-go/chaintxmgr"
 	"github.com/TEENet-io/bridge-go/chaintxmgrdb"
+	"github.com/TEENet-io/bridge-go/common"
 	"github.com/TEENet-io/bridge-go/etherman"
-	"github.com/TEENet-io/bridge-go/ethsync"
 	"github.com/TEENet-io/bridge-go/ethtxmanager"
 	"github.com/TEENet-io/bridge-go/multisig_client"
 	"github.com/TEENet-io/bridge-go/reporter"
@@ -42,17 +46,31 @@ import (
 const (
 	// eth synchronizer config
 	frequencyToCheckEthFinalizedBlock = 1 * time.Second
+	ethBlocksPerSyncBatch             = 5000 // catching up, apply & checkpoint the events of 5000 blocks at a time.
 
-	// eth tx manager config
-	frequencyToPrepareRedeem      = 5 * time.Second // read db, gather UTXO, prepare & send RedeemPrepare Tx on ETH side.
-	frequencyToMint               = 5 * time.Second // read db, issue Mint Tx on ETH side.
-	frequencyToMonitorPendingTxs  = 10 * time.Second
+	// chain tx manager config
+	frequencyToPrepareRedeem      = 5 * time.Second // read db, mint, gather UTXO & prepare redeems, monitor pending txs.
 	timeoutOnWaitingForSignature  = 10 * time.Second
 	timtoutOnWaitingForOutpoints  = 5 * time.Second // gather UTXOs from BTC wallet.
 	timeoutOnMonitoringPendingTxs = 128             // (4x finalized) blocks
 
+	// chain tx manager retry policy
+	maxTxAttempts    = 5                // send a mint/prepare at most 5 times, then escalate to operator.
+	retryBackoffBase = 30 * time.Second // backoff doubles on each failed attempt,
+	retryBackoffMax  = 10 * time.Minute // up to this cap.
+
+	// chain tx manager mint/prepare pipelines
+	mintWorkers      = 8   // sign & submit up to 8 mints at the same time.
+	mintQueueSize    = 512 // mints waiting for a worker.
+	prepareWorkers   = 4   // lock utxos, sign & submit up to 4 prepares at the same time.
+	prepareQueueSize = 256 // prepares waiting for a worker.
+
 	// btc publisher-observer config
 	CHANNEL_BUFFER_SIZE = 10
+
+	// destination chains config
+	DEFAULT_APTOS_CHAIN_ID = 1
+	ETH_DB_FILE_SUFFIX     = ".eth" // eth side state is kept in <DbFilePath>.eth
 )
 
 // Keep the configuration's fields as "text" as possible.
@@ -63,10 +81,16 @@ type BridgeServerConfig struct {
 	AptosCoreAccountPriv string // private key of the bridge controlled account
 	AptosModuleAddress   string // module address
 
-	EthRpcUrl          string // json rpc url
-	EthCoreAccountPriv string // private key of the bridge controlled account
-	// EthRetroScanBlk    int64                         // retro scan block, tell Sync() to scan from this block, -1 to honor the valude in statedb.
-	MSchnorrSigner multisig_client.SchnorrSigner // remote or local both okay. as long as it can sign() and pub()
+	AptosChainId      uint32 // chain id named by the deposits to aptos (0=default)
+	AptosRetroScanBlk int64  // retro scan ledger version, tell Sync() to scan from this version, -1 to honor the value in statedb.
+
+	EthRpcUrl          string                        // json rpc url (""=no eth side)
+	EthCoreAccountPriv string                        // private key of the bridge controlled account
+	EthRetroScanBlk    int64                         // retro scan block, tell Sync() to scan from this block, -1 to honor the valude in statedb.
+	EthReplaceAfterBlk uint64                        // replace a mint/prepare tx unmined after ? blocks with bumped fees (0=never)
+	EthMaxFeeGwei      int64                         // global cap of maxFeePerGas in gwei (0=no cap)
+	EthLegacyMessages  bool                          // sign legacy (not domain separated) messages, only for bridge contracts without domainSeparator() (checked against the contract upon start)
+	MSchnorrSigner     multisig_client.SchnorrSigner // remote or local both okay. as long as it can sign() and pub()
 	// state side
 	DbFilePath string // db file path
 	// btc side
@@ -75,7 +99,15 @@ type BridgeServerConfig struct {
 	BtcRpcUsername     string           // btc rpc server info
 	BtcRpcPwd          string           // btc rpc server info
 	BtcChainConfig     *chaincfg.Params // regtest, testnet, mainnet? see btcman/assembler/common.go
-	BtcStartBlk        int64            // start block for btc monitor to scan (0=from 0, -1=latest, other=specific block)
+	BtcStartBlk        int64            // start block for btc monitor to scan if no cursor stored in state (0=from 0, -1=latest, other=specific block)
+	BtcRescanFromBlk   int64            // explicit rescan range of btc monitor, done once upon start (0=no rescan)
+	BtcRescanToBlk     int64            // explicit rescan range of btc monitor (0=up to the stored cursor)
+	BtcRbfAfterBlk     int64            // bump the fee of a redeem tx unconfirmed after ? blocks (0=default)
+	BtcBatchWindowSec  int64            // batch redeems prepared within ? seconds into one btc tx (0=no batching)
+	BtcBatchMaxSize    int              // max redeems in a batch btc tx (0=default)
+	BtcConsolidateFee  int64            // consolidate small vault UTXOs when fee rate (sat/vB) <= ? (0=no consolidation)
+	BtcRefundDelaySec  int64            // refund malformed deposits ? seconds after they are found (0=no automatic refund)
+	BtcCoinSelector    string           // how the vault selects UTXOs: largest-first, oldest-first, random, bnb (""=largest-first)
 	BtcCoreAccountPriv string           // btc core account private key (who sends btc)
 	BtcCoreAccountAddr string           // btc core account address (who receives deposit) to be monitored.
 
@@ -92,13 +124,20 @@ type BridgeServerConfig struct {
 type BridgeServer struct {
 	// Eth side
 	EthEnv *etherman.RealEthChain
-	// Eth side: generated objects
-	MyEtherman *etherman.Etherman
-	MyState    *state.State
-	MyStateDb  *state.StateDB
-	MyEthMgrDb *ethtxmanager.EthTxManagerDB
-	MyEthTxMgr *ethtxmanager.EthTxManager
-	MyEthSync  *ethsync.Synchronizer
+	// Eth side: generated objects (nil if no eth rpc url is configured)
+	MyEtherman   *etherman.Etherman
+	MyEthState   *state.State
+	MyEthStateDb *state.StateDB
+	MyEthMgrDb   *chaintxmgrdb.SQLiteChainTxMgrDB
+	MyEthTxMgr   *chaintxmgr.ChainTxMgr
+	MyEthSync    *chainsync.ChainSync
+
+	// Aptos side state
+	MyState   *state.State
+	MyStateDb *state.StateDB
+
+	// Destination chains that deposits are routed to
+	MyRegistry *chainregistry.Registry
 
 	// Btc side
 	BtcRpcClient *btcrpc.RpcClient
@@ -141,6 +180,12 @@ func NewBridgeServer(bsc *BridgeServerConfig, ctx context.Context, wg *sync.Wait
 	// 2) Create a <UTXO vault> over the storage to track a specific btc address
 	// This is SHARED between btc monitor and eth tx manager.
 	myBtcVault := btcvault.NewTreasureVault(bsc.BtcCoreAccountAddr, vaultStorage)
+	coinSelector, err := btcvault.NewCoinSelector(bsc.BtcCoinSelector)
+	if err != nil {
+		logger.Fatalf("cannot create coin selector %v", err)
+		return nil, err
+	}
+	myBtcVault.SetCoinSelector(coinSelector)
 
 	// aptos side
 	ServerAptosman, err := aptosman.NewSimAptosman_from_privateKey(bsc.AptosCoreAccountPriv)
@@ -149,18 +194,6 @@ func NewBridgeServer(bsc *BridgeServerConfig, ctx context.Context, wg *sync.Wait
 		return nil, err
 	}
 
-	// // 2) Create the Etherman instance.
-	// myEtherman, err := etherman.NewEtherman(&etherman.EthermanConfig{
-	// 	URL:                   bsc.EthRpcUrl,
-	// 	BridgeContractAddress: realEth.BridgeContractAddress,
-	// 	TWBTCContractAddress:  realEth.TwbtcContractAddress,
-	// }, realEth.CoreAccount)
-
-	// if err != nil {
-	// 	logger.Fatalf("failed to create etherman: %v", err)
-	// 	return nil, err
-	// }
-
 	// Create sql db, and related state_db, state.
 	sqldb, err := sql.Open("sqlite3", bsc.DbFilePath)
 	if err != nil {
@@ -168,30 +201,21 @@ func NewBridgeServer(bsc *BridgeServerConfig, ctx context.Context, wg *sync.Wait
 		return nil, err
 	}
 
-	// state_db
-	myStateDb, err := state.NewStateDB(sqldb)
+	// state_db, opened with aptos first: the rows of older (not namespaced) dbs belong to aptos.
+	aptosChainId := bsc.AptosChainId
+	if aptosChainId == 0 {
+		aptosChainId = DEFAULT_APTOS_CHAIN_ID
+	}
+	myStateDb, err := state.NewStateDB(sqldb, state.ChainNamespace(common.DEPOSIT_CHAIN_TYPE_APTOS, uint64(aptosChainId)))
 	if err != nil {
 		logger.Fatalf("failed to create state db: %v", err)
 		return nil, err
 	}
-	myState, err := state.New(myStateDb, &state.StateConfig{ChannelSize: 300, UniqueChainId: big.NewInt(1)})
+	myState, err := state.New(myStateDb, &state.StateConfig{ChannelSize: 300, UniqueChainId: big.NewInt(int64(aptosChainId))})
 	if err != nil {
 		logger.Fatalf("failed to create state: %v", err)
 		return nil, err
 	}
-	// // state
-	// myState, err := state.New(myStateDb, &state.StateConfig{ChannelSize: 1, UniqueChainId: realEth.ChainId})
-	// if err != nil {
-	// 	logger.Fatalf("failed to create state: %v", err)
-	// 	return nil, err
-	// }
-
-	// // eth_tx_manager_db
-	// myEthTxMgrDb, err := ethtxmanager.NewEthTxManagerDB(sqldb)
-	// if err != nil {
-	// 	logger.Fatalf("failed to create eth tx manager db: %v", err)
-	// 	return nil, err
-	// }
 
 	// // aptos_tx_manager_db
 	myAptosTxMgrDb, err := chaintxmgrdb.NewSQLiteChainTxMgrDB(bsc.DbFilePath)
@@ -200,12 +224,15 @@ func NewBridgeServer(bsc *BridgeServerConfig, ctx context.Context, wg *sync.Wait
 		return nil, err
 	}
 
+	// aptos event cursors are kept in the aptos side state
+	ServerAptosman.Aptosman.SetEventCursorStore(myState)
+
 	// aptos synchronizer
 	myAptosSynchronizer, err := chainsync.NewChainSync(
 		&chainsync.ChainSyncConfig{
 			IntervalCheckBlockchain: frequencyToCheckEthFinalizedBlock,
 			St:                      myState,
-			ForceScanBlkNum:         0, // TODO
+			ForceScanBlkNum:         bsc.AptosRetroScanBlk,
 		},
 		aptosman.NewAptosSyncWorker(ServerAptosman.Aptosman),
 	)
@@ -215,54 +242,22 @@ func NewBridgeServer(bsc *BridgeServerConfig, ctx context.Context, wg *sync.Wait
 		return nil, err
 	}
 
-	// // eth synchronizer
-	// // create a eth synchronizer
-	// myEthSynchronizer, err := ethsync.New(
-	// 	myEtherman,
-	// 	myState,
-	// 	&ethsync.EthSyncConfig{
-	// 		IntervalCheckBlockchain: frequencyToCheckEthFinalizedBlock,
-	// 		BtcChainConfig:          bsc.BtcChainConfig,
-	// 		EthChainID:              realEth.ChainId,
-	// 		EthRetroScanBlkNum:      bsc.EthRetroScanBlk,
-	// 	},
-	// )
-	// if err != nil {
-	// 	logger.Fatalf("failed to create eth synchronizer: %v", err)
-	// 	return nil, err
-	// }
-
-	// _eth_tx_mgr_cfg := &ethtxmanager.EthTxMgrConfig{
-	// 	IntervalToPrepareRedeem:       frequencyToPrepareRedeem,
-	// 	IntervalToMint:                frequencyToMint,
-	// 	IntervalToMonitorPendingTxs:   frequencyToMonitorPendingTxs,
-	// 	TimeoutOnWaitingForSignature:  timeoutOnWaitingForSignature,
-	// 	TimeoutOnWaitingForOutpoints:  timtoutOnWaitingForOutpoints,
-	// 	TimeoutOnMonitoringPendingTxs: timeoutOnMonitoringPendingTxs,
-	// }
-
 	// // well, eth tx mgr doesn't recognize signer.
 	// // wrap the signer into "async schnorr wallet".
 	_schnorrAsyncWallet := signers.NewMockedSchnorrAsyncSigner(bsc.MSchnorrSigner)
 
-	// myEthTxMgr, err := ethtxmanager.NewEthTxManager(
-	// 	_eth_tx_mgr_cfg,
-	// 	myEtherman,
-	// 	myStateDb,
-	// 	myEthTxMgrDb,
-	// 	_schnorrAsyncWallet,
-	// 	myBtcVault,
-	// )
-	// if err != nil {
-	// 	logger.Fatalf("failed to create eth tx manager: %v", err)
-	// 	return nil, err
-	// }
-
 	_aptos_tx_mgr_cfg := &chaintxmgr.ChainTxMgrConfig{
 		IntervalCheckTime:            frequencyToPrepareRedeem,
 		TimeoutOnWaitingForSignature: timeoutOnWaitingForSignature,
 		TimeoutOnWaitingForOutpoints: timtoutOnWaitingForOutpoints,
 		TimeoutTxLedgerNumber:        big.NewInt(timeoutOnMonitoringPendingTxs),
+		MaxTxAttempts:                maxTxAttempts,
+		RetryBackoffBase:             retryBackoffBase,
+		RetryBackoffMax:              retryBackoffMax,
+		MintWorkers:                  mintWorkers,
+		MintQueueSize:                mintQueueSize,
+		PrepareWorkers:               prepareWorkers,
+		PrepareQueueSize:             prepareQueueSize,
 	}
 
 	// 创建 Aptos Worker
@@ -284,53 +279,49 @@ func NewBridgeServer(bsc *BridgeServerConfig, ctx context.Context, wg *sync.Wait
 		return nil, err
 	}
 
-	// // Important: Turn on eth-side components!
-	// wg.Add(1)
-	// go func() {
-	// 	defer wg.Done()
-	// 	err := myState.Start(ctx) // state
-	// 	if err != nil {
-	// 		logger.Fatalf("failed to state eth: %v", err)
-	// 	}
-	// }()
-	// wg.Add(1)
-	// go func() {
-	// 	defer wg.Done()
-	// 	err := myEthTxMgr.Loop(ctx) // eth-side tx manager
-	// 	if err != nil {
-	// 		logger.Fatalf("failed to mgr eth: %v", err)
-	// 	}
-	// }()
-	// wg.Add(1)
-	// go func() {
-	// 	defer wg.Done()
-	// 	err := myEthSynchronizer.Loop(ctx) // eth-side synchronizer
-	// 	if err != nil {
-	// 		logger.Fatalf("failed to sync eth: %v", err)
-	// 	}
-	// }()
-	// Don't forget to call wg.Wait() in the main routine.
+	// *** Destination chains ***
+	// Deposits are routed to the chain they name (chain type + chain id),
+	// each chain has its own state, synchronizer and tx manager.
+	registry := chainregistry.New()
+	err = registry.Register(&chainregistry.DestChain{
+		ChainType: common.DEPOSIT_CHAIN_TYPE_APTOS,
+		ChainID:   aptosChainId,
+		Name:      "aptos",
+		State:     myState,
+		StateDB:   myStateDb,
+		Sync:      myAptosSynchronizer,
+		TxMgr:     myAptosTxMgr,
+		Prepared:  MgrWorker,
+	})
+	if err != nil {
+		logger.Fatalf("failed to register aptos chain: %v", err)
+		return nil, err
+	}
 
-	// 启动 Aptos 同步器
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		err := myAptosSynchronizer.Loop(ctx) // aptos-side synchronizer
+	// eth side (optional), kept in its own db file.
+	var ethSide *ethServerSide
+	if bsc.EthRpcUrl != "" {
+		ethSide, err = setupEthSide(bsc, bsc.DbFilePath+ETH_DB_FILE_SUFFIX, _schnorrAsyncWallet, myBtcVault)
 		if err != nil {
-			logger.Fatalf("failed to sync aptos: %v", err)
+			logger.Fatalf("failed to setup eth side: %v", err)
+			return nil, err
 		}
-	}()
-	// 启动 Aptos 交易管理器
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		err := myAptosTxMgr.Loop(ctx) // aptos-side tx manager
+		err = registry.Register(&chainregistry.DestChain{
+			ChainType: common.DEPOSIT_CHAIN_TYPE_EVM,
+			ChainID:   uint32(ethSide.env.ChainId.Uint64()),
+			Name:      "ethereum",
+			State:     ethSide.state,
+			StateDB:   ethSide.stateDb,
+			Sync:      ethSide.sync,
+			TxMgr:     ethSide.txMgr,
+			Prepared:  ethSide.etherman,
+		})
 		if err != nil {
-			logger.Fatalf("failed to run aptos tx manager: %v", err)
+			logger.Fatalf("failed to register eth chain: %v", err)
+			return nil, err
 		}
-	}()
-	// Go back to btc side config:
-	// 3) Create <Btc Tx Manager Storage> (for redeem purpose, useless in deposit)
+	}
+
 	// Go back to btc side config:
 	// 3) Create <Btc Tx Manager Storage> (for redeem purpose, useless in deposit)
 	btcMgrStorage, err := btcaction.NewSQLiteRedeemStorage(bsc.DbFilePath)
@@ -339,6 +330,16 @@ func NewBridgeServer(bsc *BridgeServerConfig, ctx context.Context, wg *sync.Wait
 		return nil, err
 	}
 
+	// A redeem sent on btc is never rolled back by a reorg of its destination chain.
+	myState.SetRedeemSentChecker(btcMgrStorage)
+	if ethSide != nil {
+		ethSide.state.SetRedeemSentChecker(btcMgrStorage)
+	}
+
+	// Important: Turn on state, synchronizer and tx manager of every chain.
+	// Don't forget to call wg.Wait() in the main routine.
+	registry.Start(ctx, wg)
+
 	// *** Create <btc tx manager> ***
 	bridgeBtcCoreAccount, err := assembler.NewNativeSigner(bsc.BtcCoreAccountPriv, bsc.BtcChainConfig)
 	if err != nil {
@@ -359,27 +360,82 @@ func NewBridgeServer(bsc *BridgeServerConfig, ctx context.Context, wg *sync.Wait
 		myBtcVault,
 		bridgeBtcOperator,
 		myBtcRpcClient,
-		myState,
+		registry,
 		btcMgrStorage,
 	)
 
+	// vault selects UTXOs with the same fee rate as the redeem txs.
+	myBtcVault.SetFeeRateSource(myBtcTxMgr.FeeEstimator())
+
+	if bsc.BtcRbfAfterBlk > 0 {
+		myBtcTxMgr.SetRbfAfterBlocks(bsc.BtcRbfAfterBlk)
+	}
+	if bsc.BtcBatchWindowSec > 0 {
+		myBtcTxMgr.SetBatchMode(time.Duration(bsc.BtcBatchWindowSec)*time.Second, bsc.BtcBatchMaxSize)
+	}
+
+	// Turn on consolidation of small vault UTXOs (optional)
+	consolidationStorage, err := btcaction.NewSQLiteConsolidationStorage(bsc.DbFilePath)
+	if err != nil {
+		logger.Fatalf("cannot create consolidation storage %v", err)
+		return nil, err
+	}
+	if bsc.BtcConsolidateFee > 0 {
+		consolidationCfg := btctxmanager.DefaultConsolidationConfig()
+		consolidationCfg.MaxFeeRate = bsc.BtcConsolidateFee
+		myBtcTxMgr.SetConsolidation(consolidationStorage, consolidationCfg)
+		go myBtcTxMgr.ConsolidateLoop()
+	}
+
+	// Refunds of malformed deposits are always tracked, sent automatically only if a delay is set.
+	refundStorage, err := btcaction.NewSQLiteRefundStorage(bsc.DbFilePath)
+	if err != nil {
+		logger.Fatalf("cannot create refund storage %v", err)
+		return nil, err
+	}
+	if bsc.BtcRefundDelaySec > 0 {
+		myBtcTxMgr.SetRefund(refundStorage, time.Duration(bsc.BtcRefundDelaySec)*time.Second)
+		go myBtcTxMgr.RefundLoop()
+	}
+
 	// Turn on evm2btc withdraw loop
 	go myBtcTxMgr.WithdrawLoop()
 
+	// Turn on vault maintenance, release expired UTXO locks not used by any redeem.
+	vaultMaintainer := btcvault.NewVaultMaintainer(myBtcVault, registry, btcMgrStorage)
+	go vaultMaintainer.MaintainLoop()
+
 	// *** Create <btc monitor> for btc2evm deposits ***
+	// Resume from the cursor stored in state,
+	// fall back to the configured start block on a clean slate.
 	var _start_blk int64
-	if bsc.BtcStartBlk == -1 {
+	if stored, err := myState.GetBtcFinalizedBlockNumber(); err == nil {
+		_start_blk = stored.Int64()
+		logger.WithField("blk", _start_blk).Info("Resume btc monitor from stored cursor")
+	} else if err != state.ErrKeyValueNotFound {
+		logger.Fatalf("cannot read btc cursor from state, %v", err)
+		return nil, err
+	} else if bsc.BtcStartBlk == -1 {
 		_start_blk, _ = myBtcRpcClient.GetLatestBlockHeight()
 	} else {
 		_start_blk = bsc.BtcStartBlk
 	}
 
+	// Hash chain of scanned btc blocks, to detect reorgs.
+	btcBlockStorage, err := btcaction.NewSQLiteScannedBlockStorage(bsc.DbFilePath)
+	if err != nil {
+		logger.Fatalf("cannot create scanned block storage %v", err)
+		return nil, err
+	}
+
 	// Attention: we start from the latest block height on BTC for clean slate.
-	myBtcMonitor, err := setupBtcMonitor(myBtcRpcClient, bsc.BtcCoreAccountAddr, btcMgrStorage, int(_start_blk))
+	myBtcMonitor, err := setupBtcMonitor(myBtcRpcClient, bsc.BtcCoreAccountAddr, btcMgrStorage, btcBlockStorage, int(_start_blk))
 	if err != nil {
 		logger.Fatalf("cannot create monitor, %v", err)
 		return nil, err
 	}
+	// Deposits naming an unknown destination chain are parked for refund.
+	myBtcMonitor.SetDepositFilter(registry.Accept)
 	// Can't turn on the monitor loop yet, need to register observers to the monitor loop first.
 
 	// Setup the observers on btc-side
@@ -409,8 +465,8 @@ func NewBridgeServer(bsc *BridgeServerConfig, ctx context.Context, wg *sync.Wait
 	// However we don't store mint, it is handled on the eth-side directly.
 	// so no storage is allocated for mint observer.
 
-	// 1) Create mint observer
-	mintObserver := btcsync.NewBTC2EVMObserver(myState, CHANNEL_BUFFER_SIZE)
+	// 1) Create mint observer, it routes each deposit to the state of its destination chain.
+	mintObserver := btcsync.NewBTC2ChainObserver(registry, CHANNEL_BUFFER_SIZE)
 
 	// 2) Mint Observer start listening to the channel
 	go mintObserver.GetNotifiedDeposit()
@@ -431,9 +487,45 @@ func NewBridgeServer(bsc *BridgeServerConfig, ctx context.Context, wg *sync.Wait
 	// 5) Register UTXO Observer to publisher
 	myBtcMonitor.Publisher.RegisterUTXOObserver(utxo_observer.Ch)
 
+	// *** Rollback Observer ***
+	// once a scanned block is orphaned by a reorg,
+	// revert deposits, utxos, mints and redeems built upon it.
+	rollbackObserver := btcsync.NewObserverRollback(depositStorage, myBtcVault, registry, btcMgrStorage, CHANNEL_BUFFER_SIZE)
+	go rollbackObserver.GetNotifiedRollback()
+	myBtcMonitor.Publisher.RegisterRollbackObserver(rollbackObserver.Ch)
+
+	// *** Spent Observer ***
+	// mark the vault UTXOs spent by txs on chain,
+	// and report the ones spent by txs we didn't send.
+	spentObserver := btcsync.NewObserverSpent(myBtcVault, btcMgrStorage, consolidationStorage, refundStorage, CHANNEL_BUFFER_SIZE)
+	go spentObserver.GetNotifiedSpends()
+	go spentObserver.ReconcileLoop()
+	myBtcMonitor.Publisher.RegisterSpentObserver(spentObserver.Ch)
+
+	// *** Refund Observer ***
+	// record malformed deposits and keep their UTXOs for the refund.
+	refundObserver := btcsync.NewObserverRefund(myBtcVault, refundStorage, CHANNEL_BUFFER_SIZE)
+	go refundObserver.GetNotifiedMalformedDeposit()
+	myBtcMonitor.Publisher.RegisterMalformedDepositObserver(refundObserver.Ch)
+
+	// Persist the scan cursor via state, so a restart resumes from it.
+	myBtcMonitor.SetFinalizedBlockChannel(myState.GetNewBtcFinalizedBlockChannel())
+
 	// Turn on the btc monitor scan loop
 	// So it can publish events to observers
-	go myBtcMonitor.ScanLoop()
+	// If requested, rescan the explicit range first.
+	go func() {
+		if bsc.BtcRescanFromBlk > 0 {
+			_rescan_to := bsc.BtcRescanToBlk
+			if _rescan_to <= 0 {
+				_rescan_to = myBtcMonitor.LastVistedBlockHeight
+			}
+			if err := myBtcMonitor.ScanRange(bsc.BtcRescanFromBlk, _rescan_to); err != nil {
+				logger.Errorf("btc rescan failed, %v", err)
+			}
+		}
+		myBtcMonitor.ScanLoop()
+	}()
 
 	// *** Setup a http server to report status ***
 	// logger.Info("Setup http server to report status")
@@ -444,6 +536,10 @@ func NewBridgeServer(bsc *BridgeServerConfig, ctx context.Context, wg *sync.Wait
 		btcMgrStorage,
 		myStateDb,
 	)
+	http_server.SetRefundStorage(refundStorage)
+	if ethSide != nil {
+		http_server.AddStateDB(ethSide.stateDb)
+	}
 	// Turn on the http server
 	go http_server.Run()
 
@@ -451,14 +547,10 @@ func NewBridgeServer(bsc *BridgeServerConfig, ctx context.Context, wg *sync.Wait
 	time.Sleep(1 * time.Second)
 	// *** End the setup of http server ***
 
-	return &BridgeServer{
-		// EthEnv:              realEth,
-		// MyEtherman:          myEtherman,
-		MyState:   myState,
-		MyStateDb: myStateDb,
-		// MyEthMgrDb:          myEthTxMgrDb,
-		// MyEthTxMgr:          myEthTxMgr,
-		// MyEthSync:           myEthSynchronizer,
+	bs := &BridgeServer{
+		MyState:             myState,
+		MyStateDb:           myStateDb,
+		MyRegistry:          registry,
 		BtcRpcClient:        myBtcRpcClient,
 		MyDepositStorage:    depositStorage,
 		MyVaultStorage:      vaultStorage,
@@ -469,7 +561,17 @@ func NewBridgeServer(bsc *BridgeServerConfig, ctx context.Context, wg *sync.Wait
 		MyAptosTxMgrDb:      myAptosTxMgrDb,
 		MyAptosTxMgr:        myAptosTxMgr,
 		MyAptosSynchronizer: myAptosSynchronizer,
-	}, nil
+	}
+	if ethSide != nil {
+		bs.EthEnv = ethSide.env
+		bs.MyEtherman = ethSide.etherman
+		bs.MyEthState = ethSide.state
+		bs.MyEthStateDb = ethSide.stateDb
+		bs.MyEthMgrDb = ethSide.mgrDb
+		bs.MyEthTxMgr = ethSide.txMgr
+		bs.MyEthSync = ethSide.sync
+	}
+	return bs, nil
 }
 
 // Create, then start the bridge server and wait.
@@ -503,13 +605,14 @@ func StartBridgeServerAndWait(bsc *BridgeServerConfig) {
 }
 
 // Helper function. Set up the BTC Monitor, create a new monitor instance
-func setupBtcMonitor(r *btcrpc.RpcClient, btcCoreAccountAddr string, st btcaction.RedeemActionStorage, startBlock int) (*btcsync.BTCMonitor, error) {
+func setupBtcMonitor(r *btcrpc.RpcClient, btcCoreAccountAddr string, st btcaction.RedeemActionStorage, bst btcaction.ScannedBlockStorage, startBlock int) (*btcsync.BTCMonitor, error) {
 	monitor, err := btcsync.NewBTCMonitor(
 		btcCoreAccountAddr,
 		assembler.GetRegtestParams(),
 		r,
 		int64(startBlock),
 		st,
+		bst,
 	)
 	if err != nil {
 		logger.Fatalf("cannot create monitor %v", err)
@@ -522,3 +625,148 @@ func setupBtcMonitor(r *btcrpc.RpcClient, btcCoreAccountAddr string, st btcactio
 func setupObserverDeposit(st btcaction.DepositStorage) (*btcsync.ObserverDepositAction, error) {
 	return btcsync.NewObserverDepositAction(st, CHANNEL_BUFFER_SIZE), nil
 }
+
+// ethServerSide holds the eth-side objects of the bridge server.
+type ethServerSide struct {
+	env      *etherman.RealEthChain
+	etherman *etherman.Etherman
+	state    *state.State
+	stateDb  *state.StateDB
+	mgrDb    *chaintxmgrdb.SQLiteChainTxMgrDB
+	sync     *chainsync.ChainSync
+	txMgr    *chaintxmgr.ChainTxMgr
+}
+
+// setupEthSide creates the eth-side components, over their own db file.
+// The loops are not started, see chainregistry.Registry.Start()
+func setupEthSide(bsc *BridgeServerConfig, dbFilePath string, schnorrWallet *signers.MockedSchnorrAsyncSigner, btcVault *btcvault.TreasureVault) (*ethServerSide, error) {
+	priv, err := crypto.HexToECDSA(common.Trim0xPrefix(bsc.EthCoreAccountPriv))
+	if err != nil {
+		return nil, err
+	}
+
+	// 1) Connect to the chain, bind to (or deploy) the bridge contracts.
+	realEth, err := etherman.NewRealEthChain(
+		bsc.EthRpcUrl,
+		priv,
+		bsc.MSchnorrSigner,
+		bsc.PredefinedBridgeContractAddr,
+		bsc.PredefinedTwbtcContractAddr,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2) Create the Etherman instance.
+	gasPolicy := etherman.GasPolicy{}
+	if bsc.EthMaxFeeGwei > 0 {
+		gasPolicy.MaxFeeCap = new(big.Int).Mul(big.NewInt(bsc.EthMaxFeeGwei), big.NewInt(params.GWei))
+	}
+	myEtherman, err := etherman.NewEtherman(&etherman.EthermanConfig{
+		URL:                   bsc.EthRpcUrl,
+		BridgeContractAddress: realEth.BridgeContractAddress,
+		TWBTCContractAddress:  realEth.TwbtcContractAddress,
+		LegacyMessages:        bsc.EthLegacyMessages,
+		Gas:                   gasPolicy,
+	}, realEth.CoreAccount)
+	if err != nil {
+		return nil, err
+	}
+	if err := myEtherman.CheckSigningDomain(); err != nil {
+		return nil, err
+	}
+
+	// 3) state_db, state & chain_tx_manager_db
+	sqldb, err := sql.Open("sqlite3", dbFilePath)
+	if err != nil {
+		return nil, err
+	}
+	myStateDb, err := state.NewStateDB(sqldb, state.ChainNamespace(common.DEPOSIT_CHAIN_TYPE_EVM, realEth.ChainId.Uint64()))
+	if err != nil {
+		return nil, err
+	}
+	myState, err := state.New(myStateDb, &state.StateConfig{ChannelSize: 300, UniqueChainId: realEth.ChainId})
+	if err != nil {
+		return nil, err
+	}
+	myEthTxMgrDb, err := chaintxmgrdb.NewSQLiteChainTxMgrDB(dbFilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	// txs monitored by the legacy eth tx manager are taken over by the chain tx manager
+	hasLegacy, err := ethtxmanager.HasMonitoredTxTable(sqldb)
+	if err != nil {
+		return nil, err
+	}
+	if hasLegacy {
+		legacyDb, err := ethtxmanager.NewEthTxManagerDB(sqldb)
+		if err != nil {
+			return nil, err
+		}
+		_, err = ethtxmanager.MigrateToChainTxMgrDB(legacyDb, myEthTxMgrDb, func(blockHash ethcommon.Hash) (*big.Int, error) {
+			header, err := myEtherman.Client().HeaderByHash(context.Background(), blockHash)
+			if err != nil {
+				return nil, err
+			}
+			return header.Number, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// 4) eth synchronizer
+	myEthSynchronizer, err := chainsync.NewChainSync(
+		&chainsync.ChainSyncConfig{
+			IntervalCheckBlockchain: frequencyToCheckEthFinalizedBlock,
+			St:                      myState,
+			ForceScanBlkNum:         bsc.EthRetroScanBlk,
+			MaxLedgersPerBatch:      ethBlocksPerSyncBatch,
+		},
+		etherman.NewEthSyncWorker(myEtherman, bsc.BtcChainConfig),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// 5) eth tx manager
+	myEthMgrWorker := etherman.NewEthMgrWorker(myEtherman, bsc.EthReplaceAfterBlk, myEthTxMgrDb)
+	myEthTxMgr, err := chaintxmgr.NewChainTxMgr(
+		&chaintxmgr.ChainTxMgrConfig{
+			IntervalCheckTime:            frequencyToPrepareRedeem,
+			TimeoutOnWaitingForSignature: timeoutOnWaitingForSignature,
+			TimeoutOnWaitingForOutpoints: timtoutOnWaitingForOutpoints,
+			TimeoutTxLedgerNumber:        big.NewInt(timeoutOnMonitoringPendingTxs),
+			MaxTxAttempts:                maxTxAttempts,
+			RetryBackoffBase:             retryBackoffBase,
+			RetryBackoffMax:              retryBackoffMax,
+			MintWorkers:                  mintWorkers,
+			MintQueueSize:                mintQueueSize,
+			PrepareWorkers:               prepareWorkers,
+			PrepareQueueSize:             prepareQueueSize,
+		},
+		myEthMgrWorker,
+		myState,
+		myStateDb,
+		myEthTxMgrDb,
+		schnorrWallet,
+		btcVault,
+		myEthMgrWorker,
+	)
+	if err != nil {
+		return nil, err
+	}
+	// resubmit the txs orphaned by eth reorgs
+	myEthSynchronizer.SubscribeReorg(myEthTxMgr.GetChainReorgChannel())
+
+	return &ethServerSide{
+		env:      realEth,
+		etherman: myEtherman,
+		state:    myState,
+		stateDb:  myStateDb,
+		mgrDb:    myEthTxMgrDb,
+		sync:     myEthSynchronizer,
+		txMgr:    myEthTxMgr,
+	}, nil
+}
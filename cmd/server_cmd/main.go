@@ -132,6 +132,9 @@ func PrepareBridgeServerConfig() *cmd.BridgeServerConfig {
 		logger.Info("Using local schnorr signer")
 	}
 
+	// Unless configured, the aptos synchronizer resumes from the version stored in state.
+	viper.SetDefault("APTOS_RETRO_SCAN_BLK", -1)
+
 	// *** end of preparing objects ***
 
 	return &cmd.BridgeServerConfig{
@@ -140,12 +143,17 @@ func PrepareBridgeServerConfig() *cmd.BridgeServerConfig {
 		AptosRpcUrl:          viper.GetString("APTOS_RPC_URL"),
 		AptosCoreAccountPriv: viper.GetString("APTOS_CORE_ACCOUNT_PRIV"),
 		AptosModuleAddress:   viper.GetString("APTOS_MODULE_ADDRESS"),
-
-		// eth side
-		// EthRpcUrl:          viper.GetString("ETH_RPC_URL"),
-		// EthCoreAccountPriv: viper.GetString("ETH_CORE_ACCOUNT_PRIV"),
-		// EthRetroScanBlk:    viper.GetInt64("ETH_RETRO_SCAN_BLK"),
-		MSchnorrSigner: schnorrSigner,
+		AptosChainId:         viper.GetUint32("APTOS_CHAIN_ID"),
+		AptosRetroScanBlk:    viper.GetInt64("APTOS_RETRO_SCAN_BLK"),
+
+		// eth side (optional)
+		EthRpcUrl:          viper.GetString("ETH_RPC_URL"),
+		EthCoreAccountPriv: viper.GetString("ETH_CORE_ACCOUNT_PRIV"),
+		EthRetroScanBlk:    viper.GetInt64("ETH_RETRO_SCAN_BLK"),
+		EthReplaceAfterBlk: viper.GetUint64("ETH_REPLACE_AFTER_BLK"),
+		EthMaxFeeGwei:      viper.GetInt64("ETH_MAX_FEE_GWEI"),
+		EthLegacyMessages:  viper.GetBool("ETH_LEGACY_MESSAGES"),
+		MSchnorrSigner:     schnorrSigner,
 		// state side
 		DbFilePath: viper.GetString("DB_FILE_PATH"),
 		// btc side
@@ -155,6 +163,14 @@ func PrepareBridgeServerConfig() *cmd.BridgeServerConfig {
 		BtcRpcPwd:          viper.GetString("BTC_RPC_PWD"),
 		BtcChainConfig:     btcParams,
 		BtcStartBlk:        viper.GetInt64("BTC_START_BLK"),
+		BtcRescanFromBlk:   viper.GetInt64("BTC_RESCAN_FROM_BLK"),
+		BtcRescanToBlk:     viper.GetInt64("BTC_RESCAN_TO_BLK"),
+		BtcRbfAfterBlk:     viper.GetInt64("BTC_RBF_AFTER_BLK"),
+		BtcBatchWindowSec:  viper.GetInt64("BTC_BATCH_WINDOW_SEC"),
+		BtcBatchMaxSize:    viper.GetInt("BTC_BATCH_MAX_SIZE"),
+		BtcConsolidateFee:  viper.GetInt64("BTC_CONSOLIDATE_FEE"),
+		BtcRefundDelaySec:  viper.GetInt64("BTC_REFUND_DELAY_SEC"),
+		BtcCoinSelector:    viper.GetString("BTC_COIN_SELECTOR"),
 		BtcCoreAccountPriv: viper.GetString("BTC_CORE_ACCOUNT_PRIV"),
 		BtcCoreAccountAddr: viper.GetString("BTC_CORE_ACCOUNT_ADDR"),
 		// Http side
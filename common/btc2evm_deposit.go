@@ -5,10 +5,20 @@ Defines a BTC2EVM deposit data that user shall send along with btc coins to the
 This piece of data contains EVM_CHAIN_ID and EVM_ADDR.
 After RLP encoding, the encoded []byte shall be sent as OP_RETURN data in BTC transaction.
 See MakeDepositOpReturnData() for more details.
+
+The legacy RLP data can only name a 20-byte EVM receiver.
+A versioned deposit payload (see DepositPayload) names the destination chain type,
+and a receiver of any length (eg. a 32-byte Aptos account), with an optional memo:
+
+	| version (1) | chain type (1) | chain id (4, big-endian) | receiver len (1) | receiver | memo (rest) |
+
+RLP data always starts with a list prefix (>= 0xc0), so it never collides with a version byte.
+See MakeDepositPayloadOpReturnData() and DecodeOpReturnData().
 */
 
 import (
 	"errors"
+	"fmt"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/rlp"
@@ -83,14 +93,158 @@ func MakeDepositOpReturnData(evm_chain_id int, evm_addr string) ([]byte, error)
 	return dd.Serialize()
 }
 
-// DecodeOpReturnData deposit data from RLP encoded []byte
-// OP_RETURN data is of <OP_RETURN> <OP_PUSHBYTES_x> <real_data>
-// Need to cut off the first two bytes.
-func DecodeOpReturnData(data []byte) (*DepositData, error) {
-	var dd DepositData
-	err := rlp.DecodeBytes(data[2:], &dd)
+const (
+	DEPOSIT_VERSION_LEGACY byte = 0 // RLP encoded DepositData, not a real version byte.
+	DEPOSIT_VERSION_1      byte = 1
+
+	DEPOSIT_CHAIN_TYPE_EVM   byte = 1
+	DEPOSIT_CHAIN_TYPE_APTOS byte = 2
+
+	EVM_RECEIVER_LEN   = 20
+	APTOS_RECEIVER_LEN = 32
+
+	MAX_OP_RETURN_DATA_LEN = 80 // standard (relayed) OP_RETURN data size
+	depositHeaderLen       = 7  // version + chain type + chain id + receiver len
+	rlpListPrefix          = 0xc0
+)
+
+// DepositPayload is the decoded deposit data, of any version.
+type DepositPayload struct {
+	Version   byte   // DEPOSIT_VERSION_*
+	ChainType byte   // DEPOSIT_CHAIN_TYPE_*
+	ChainID   uint32 // destination chain id
+	Receiver  []byte // 20 bytes on evm, 32 bytes on aptos
+	Memo      []byte // optional, nil if none
+}
+
+// ChainTypeName gives the name of a DEPOSIT_CHAIN_TYPE_*, "evm" or "aptos".
+func ChainTypeName(chainType byte) string {
+	switch chainType {
+	case DEPOSIT_CHAIN_TYPE_EVM:
+		return "evm"
+	case DEPOSIT_CHAIN_TYPE_APTOS:
+		return "aptos"
+	default:
+		return fmt.Sprintf("unknown(%d)", chainType)
+	}
+}
+
+func receiverLen(chainType byte) (int, error) {
+	switch chainType {
+	case DEPOSIT_CHAIN_TYPE_EVM:
+		return EVM_RECEIVER_LEN, nil
+	case DEPOSIT_CHAIN_TYPE_APTOS:
+		return APTOS_RECEIVER_LEN, nil
+	default:
+		return 0, fmt.Errorf("unknown deposit chain type %d", chainType)
+	}
+}
+
+// ReceiverHex gives the receiver with 0x prefix.
+// An evm receiver is checksummed, as the legacy EVM_ADDR.
+func (p *DepositPayload) ReceiverHex() string {
+	if p.ChainType == DEPOSIT_CHAIN_TYPE_EVM {
+		return common.BytesToAddress(p.Receiver).Hex()
+	}
+	return Prepend0xPrefix(ByteSliceToPureHexStr(p.Receiver))
+}
+
+// Serialize deposit payload of DEPOSIT_VERSION_1
+func (p *DepositPayload) Serialize() ([]byte, error) {
+	if p.Version != DEPOSIT_VERSION_1 {
+		return nil, fmt.Errorf("cannot serialize deposit version %d", p.Version)
+	}
+	l, err := receiverLen(p.ChainType)
 	if err != nil {
 		return nil, err
 	}
-	return &dd, nil
+	if len(p.Receiver) != l {
+		return nil, fmt.Errorf("%s receiver shall be %d bytes, got %d", ChainTypeName(p.ChainType), l, len(p.Receiver))
+	}
+	if depositHeaderLen+len(p.Receiver)+len(p.Memo) > MAX_OP_RETURN_DATA_LEN {
+		return nil, fmt.Errorf("deposit data exceeds %d bytes, memo too long", MAX_OP_RETURN_DATA_LEN)
+	}
+
+	data := []byte{p.Version, p.ChainType, byte(p.ChainID >> 24), byte(p.ChainID >> 16), byte(p.ChainID >> 8), byte(p.ChainID), byte(len(p.Receiver))}
+	data = append(data, p.Receiver...)
+	return append(data, p.Memo...), nil
+}
+
+// Util: Create a []byte as an OP_RETURN data of DEPOSIT_VERSION_1
+// receiver is hex (with or without 0x), 20 bytes on evm, 32 bytes on aptos.
+func MakeDepositPayloadOpReturnData(chain_type byte, chain_id uint32, receiver string, memo []byte) ([]byte, error) {
+	if !EnsureSafeAddressHexString(receiver) {
+		return nil, fmt.Errorf("receiver %s is not a hex string", receiver)
+	}
+	p := DepositPayload{
+		Version:   DEPOSIT_VERSION_1,
+		ChainType: chain_type,
+		ChainID:   chain_id,
+		Receiver:  HexStrToByteSlice(Trim0xPrefix(receiver)),
+		Memo:      memo,
+	}
+	return p.Serialize()
+}
+
+// DecodeDepositPayload decodes deposit data (without the OP_RETURN script prefix)
+// either legacy RLP, or DEPOSIT_VERSION_1.
+func DecodeDepositPayload(data []byte) (*DepositPayload, error) {
+	if len(data) == 0 {
+		return nil, errors.New("empty deposit data")
+	}
+
+	if data[0] >= rlpListPrefix {
+		var dd DepositData
+		if err := rlp.DecodeBytes(data, &dd); err != nil {
+			return nil, err
+		}
+		return &DepositPayload{
+			Version:   DEPOSIT_VERSION_LEGACY,
+			ChainType: DEPOSIT_CHAIN_TYPE_EVM,
+			ChainID:   uint32(ByteArrayToInt(dd.EVM_CHAIN_ID)),
+			Receiver:  dd.EVM_ADDR[:],
+		}, nil
+	}
+
+	if data[0] != DEPOSIT_VERSION_1 {
+		return nil, fmt.Errorf("unknown deposit version %d", data[0])
+	}
+	if len(data) < depositHeaderLen {
+		return nil, fmt.Errorf("deposit data too short, %d bytes", len(data))
+	}
+	p := &DepositPayload{
+		Version:   data[0],
+		ChainType: data[1],
+		ChainID:   uint32(data[2])<<24 | uint32(data[3])<<16 | uint32(data[4])<<8 | uint32(data[5]),
+	}
+	l, err := receiverLen(p.ChainType)
+	if err != nil {
+		return nil, err
+	}
+	if int(data[6]) != l || len(data) < depositHeaderLen+l {
+		return nil, fmt.Errorf("%s receiver shall be %d bytes, got %d", ChainTypeName(p.ChainType), l, data[6])
+	}
+	p.Receiver = data[depositHeaderLen : depositHeaderLen+l]
+	if memo := data[depositHeaderLen+l:]; len(memo) > 0 {
+		p.Memo = memo
+	}
+	return p, nil
+}
+
+// DecodeOpReturnData decodes deposit data from the OP_RETURN script.
+// OP_RETURN data is of <OP_RETURN> <OP_PUSHBYTES_x> <real_data>
+// or <OP_RETURN> <OP_PUSHDATA1> <len> <real_data> (more than 75 bytes)
+// Need to cut off the first two (three) bytes.
+func DecodeOpReturnData(data []byte) (*DepositPayload, error) {
+	const opReturn, opPushData1 = 0x6a, 0x4c
+	if len(data) < 2 || data[0] != opReturn {
+		return nil, errors.New("not an OP_RETURN script")
+	}
+	if data[1] == opPushData1 {
+		if len(data) < 3 {
+			return nil, errors.New("OP_RETURN script too short")
+		}
+		return DecodeDepositPayload(data[3:])
+	}
+	return DecodeDepositPayload(data[2:])
 }
@@ -67,3 +67,51 @@ func TestDecodeDeposit(t *testing.T) {
 		t.Fatalf("Decoded chain_id not match, expected %d, got %d", _suppose_chain_id, ByteArrayToInt(dd.EVM_CHAIN_ID))
 	}
 }
+
+func TestDepositPayload(t *testing.T) {
+	// aptos receiver + memo
+	aptos_receiver := "0x" + hex.EncodeToString(RandBytes(APTOS_RECEIVER_LEN))
+	memo := []byte("hello")
+	data, err := MakeDepositPayloadOpReturnData(DEPOSIT_CHAIN_TYPE_APTOS, 2, aptos_receiver, memo)
+	if err != nil {
+		t.Fatalf("Cannot make deposit payload %v", err)
+	}
+	if data[0] != DEPOSIT_VERSION_1 || len(data) != depositHeaderLen+APTOS_RECEIVER_LEN+len(memo) {
+		t.Fatalf("Unexpected deposit payload %x", data)
+	}
+
+	// <OP_RETURN> <OP_PUSHBYTES_x> <real_data>
+	script := append([]byte{0x6a, byte(len(data))}, data...)
+	p, err := DecodeOpReturnData(script)
+	if err != nil {
+		t.Fatalf("Cannot decode deposit payload %v", err)
+	}
+	if p.ChainType != DEPOSIT_CHAIN_TYPE_APTOS || p.ChainID != 2 || p.ReceiverHex() != aptos_receiver || string(p.Memo) != "hello" {
+		t.Fatalf("Decoded deposit payload not match %+v", p)
+	}
+
+	// legacy RLP still decodes.
+	legacy, _ := hex.DecodeString("da8400aa36a79434c7dfb77d536e9698b8d6be86c339e460026827")
+	script = append([]byte{0x6a, byte(len(legacy))}, legacy...)
+	p, err = DecodeOpReturnData(script)
+	if err != nil {
+		t.Fatalf("Cannot decode legacy deposit data %v", err)
+	}
+	if p.Version != DEPOSIT_VERSION_LEGACY || p.ChainType != DEPOSIT_CHAIN_TYPE_EVM || p.ChainID != 11155111 ||
+		p.ReceiverHex() != "0x34c7dFB77D536e9698b8d6BE86c339e460026827" || p.Memo != nil {
+		t.Fatalf("Decoded legacy deposit data not match %+v", p)
+	}
+
+	// evm receiver of aptos length.
+	if _, err := MakeDepositPayloadOpReturnData(DEPOSIT_CHAIN_TYPE_EVM, 1, aptos_receiver, nil); err == nil {
+		t.Fatalf("32-byte evm receiver shall fail")
+	}
+	// too long to relay.
+	if _, err := MakeDepositPayloadOpReturnData(DEPOSIT_CHAIN_TYPE_APTOS, 2, aptos_receiver, make([]byte, 42)); err == nil {
+		t.Fatalf("oversized deposit data shall fail")
+	}
+	// unknown version.
+	if _, err := DecodeDepositPayload([]byte{9, 1, 0, 0, 0, 1, 20}); err == nil {
+		t.Fatalf("unknown version shall fail")
+	}
+}
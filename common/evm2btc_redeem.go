@@ -2,8 +2,24 @@ package common
 
 /*
 This file defines a EVM2BTC redeem data that stored within the OP_RETURN
+
+A single redeem tx carries the RedeemData (32 bytes) as is.
+A batch redeem tx (several redeems paid in one btc tx) carries BatchRedeemData:
+| version (1 byte) | count (1 byte) | merkle root of the RedeemData(s) (32 bytes) |
 */
 
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+)
+
+const (
+	BATCH_REDEEM_DATA_VERSION = 0x01
+	BATCH_REDEEM_DATA_LEN     = 34
+	MAX_BATCH_REDEEM_COUNT    = 255
+)
+
 // Currently this piece of data is the requestTxHash (EVM) from Redeem structure and is of 32 bytes.
 type RedeemData [32]byte
 
@@ -15,3 +31,70 @@ func (rd *RedeemData) Serialize() ([]byte, error) {
 func MakeRedeemOpReturnData(rd RedeemData) ([]byte, error) {
 	return rd.Serialize()
 }
+
+// BatchRedeemData commits to all the redeems paid in a batch redeem tx.
+type BatchRedeemData struct {
+	Count uint8    // number of redeems in the batch.
+	Root  [32]byte // merkle root of the RedeemData(s), in the order of the payout outputs.
+}
+
+// Serialize batch redeem data: version | count | root
+func (brd *BatchRedeemData) Serialize() ([]byte, error) {
+	b := make([]byte, 0, BATCH_REDEEM_DATA_LEN)
+	b = append(b, BATCH_REDEEM_DATA_VERSION, brd.Count)
+	b = append(b, brd.Root[:]...)
+	return b, nil
+}
+
+// DecodeBatchRedeemData decodes the OP_RETURN data of a batch redeem tx.
+func DecodeBatchRedeemData(data []byte) (*BatchRedeemData, error) {
+	if len(data) != BATCH_REDEEM_DATA_LEN {
+		return nil, fmt.Errorf("invalid batch redeem data length %d", len(data))
+	}
+	if data[0] != BATCH_REDEEM_DATA_VERSION {
+		return nil, fmt.Errorf("unknown batch redeem data version %d", data[0])
+	}
+	brd := &BatchRedeemData{Count: data[1]}
+	copy(brd.Root[:], data[2:])
+	return brd, nil
+}
+
+// RedeemMerkleRoot computes the merkle root of the RedeemData(s),
+// the same way as bitcoin does for txs (double sha256, odd node paired with itself).
+func RedeemMerkleRoot(rds []RedeemData) [32]byte {
+	if len(rds) == 0 {
+		return [32]byte{}
+	}
+
+	level := make([][32]byte, len(rds))
+	for i, rd := range rds {
+		level[i] = rd
+	}
+	for len(level) > 1 {
+		if len(level)%2 == 1 {
+			level = append(level, level[len(level)-1])
+		}
+		next := make([][32]byte, 0, len(level)/2)
+		for i := 0; i < len(level); i += 2 {
+			first := sha256.Sum256(append(level[i][:], level[i+1][:]...))
+			next = append(next, sha256.Sum256(first[:]))
+		}
+		level = next
+	}
+	return level[0]
+}
+
+// Create the OP_RETURN data that commits to all redeems of a batch.
+func MakeBatchRedeemOpReturnData(rds []RedeemData) ([]byte, error) {
+	if len(rds) == 0 {
+		return nil, errors.New("empty batch of redeems")
+	}
+	if len(rds) > MAX_BATCH_REDEEM_COUNT {
+		return nil, fmt.Errorf("too many redeems in a batch: %d > %d", len(rds), MAX_BATCH_REDEEM_COUNT)
+	}
+	brd := BatchRedeemData{
+		Count: uint8(len(rds)),
+		Root:  RedeemMerkleRoot(rds),
+	}
+	return brd.Serialize()
+}
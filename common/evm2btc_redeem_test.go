@@ -0,0 +1,56 @@
+package common
+
+import (
+	"crypto/sha256"
+	"testing"
+)
+
+func TestRedeemMerkleRoot(t *testing.T) {
+	rd0 := RedeemData(RandBytes32())
+	rd1 := RedeemData(RandBytes32())
+	rd2 := RedeemData(RandBytes32())
+
+	// single = itself
+	if RedeemMerkleRoot([]RedeemData{rd0}) != [32]byte(rd0) {
+		t.Fatalf("merkle root of a single redeem shall be itself")
+	}
+
+	hash := func(a, b [32]byte) [32]byte {
+		first := sha256.Sum256(append(a[:], b[:]...))
+		return sha256.Sum256(first[:])
+	}
+
+	// odd number, the last one pairs with itself.
+	expected := hash(hash(rd0, rd1), hash(rd2, rd2))
+	if RedeemMerkleRoot([]RedeemData{rd0, rd1, rd2}) != expected {
+		t.Fatalf("merkle root mismatch")
+	}
+
+	// order matters
+	if RedeemMerkleRoot([]RedeemData{rd1, rd0}) == RedeemMerkleRoot([]RedeemData{rd0, rd1}) {
+		t.Fatalf("merkle root shall commit to the order")
+	}
+}
+
+func TestBatchRedeemOpReturnData(t *testing.T) {
+	rds := []RedeemData{RedeemData(RandBytes32()), RedeemData(RandBytes32())}
+	data, err := MakeBatchRedeemOpReturnData(rds)
+	if err != nil {
+		t.Fatalf("cannot make batch redeem data: %v", err)
+	}
+	if len(data) != BATCH_REDEEM_DATA_LEN {
+		t.Fatalf("batch redeem data length %d, want %d", len(data), BATCH_REDEEM_DATA_LEN)
+	}
+
+	brd, err := DecodeBatchRedeemData(data)
+	if err != nil {
+		t.Fatalf("cannot decode batch redeem data: %v", err)
+	}
+	if brd.Count != 2 || brd.Root != RedeemMerkleRoot(rds) {
+		t.Fatalf("decoded batch redeem data mismatch")
+	}
+
+	if _, err := MakeBatchRedeemOpReturnData(nil); err == nil {
+		t.Fatalf("empty batch shall fail")
+	}
+}
@@ -0,0 +1,62 @@
+package common
+
+/*
+Domain separation of the messages signed by the threshold schnorr key.
+
+The same key may sign for several deployments (Ethereum and Aptos, devnet and testnet),
+so a signed message is bound to the deployment it is meant for:
+
+	msg = keccak256( separator || type tag || params... )
+	separator = keccak256( SIGNING_DOMAIN_NAME || chain type (1) || chain id (uint256) || bridge contract/module address )
+	type tag = keccak256( MSG_TYPE_MINT or MSG_TYPE_PREPARE )
+
+The bridge contracts rebuild the separator from their own chain id and address,
+so a signature for one deployment never verifies on another one.
+*/
+
+import (
+	"bytes"
+	"math/big"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const (
+	SIGNING_DOMAIN_NAME = "TEENet-BTC-Bridge"
+	MSG_TYPE_MINT       = "mint"
+	MSG_TYPE_PREPARE    = "redeem_prepare"
+)
+
+// SigningDomain is the deployment a message is signed for.
+type SigningDomain struct {
+	ChainType byte     // DEPOSIT_CHAIN_TYPE_*
+	ChainID   *big.Int // chain id of the deployment
+	Contract  []byte   // bridge contract address (20 bytes on evm), module address (32 bytes on aptos)
+}
+
+func NewSigningDomain(chainType byte, chainID *big.Int, contract []byte) *SigningDomain {
+	return &SigningDomain{
+		ChainType: chainType,
+		ChainID:   BigIntClone(chainID),
+		Contract:  bytes.Clone(contract),
+	}
+}
+
+// Separator of the domain.
+func (d *SigningDomain) Separator() ethcommon.Hash {
+	return crypto.Keccak256Hash(
+		[]byte(SIGNING_DOMAIN_NAME),
+		[]byte{d.ChainType},
+		math.U256Bytes(BigIntClone(d.ChainID)),
+		d.Contract,
+	)
+}
+
+// MessageHash binds the packed params of a msgType message to the domain.
+func (d *SigningDomain) MessageHash(msgType string, packedParams []byte) ethcommon.Hash {
+	separator := d.Separator()
+	typeTag := crypto.Keccak256Hash([]byte(msgType))
+	return crypto.Keccak256Hash(separator[:], typeTag[:], packedParams)
+}
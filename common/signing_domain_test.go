@@ -0,0 +1,34 @@
+package common
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestSigningDomainMessageHash(t *testing.T) {
+	params := EncodePacked(RandBytes32(), RandBytes32(), big.NewInt(100))
+	contract, another := RandBytes32(), RandBytes32()
+
+	aptos := NewSigningDomain(DEPOSIT_CHAIN_TYPE_APTOS, big.NewInt(2), contract[:])
+	msg := aptos.MessageHash(MSG_TYPE_MINT, params)
+
+	// deterministic
+	if msg != NewSigningDomain(DEPOSIT_CHAIN_TYPE_APTOS, big.NewInt(2), contract[:]).MessageHash(MSG_TYPE_MINT, params) {
+		t.Fatalf("message hash shall be deterministic")
+	}
+
+	// any change of the domain or the type tag changes the message
+	others := map[string]*SigningDomain{
+		"chain type": NewSigningDomain(DEPOSIT_CHAIN_TYPE_EVM, big.NewInt(2), contract[:]),
+		"chain id":   NewSigningDomain(DEPOSIT_CHAIN_TYPE_APTOS, big.NewInt(1), contract[:]),
+		"contract":   NewSigningDomain(DEPOSIT_CHAIN_TYPE_APTOS, big.NewInt(2), another[:]),
+	}
+	for name, other := range others {
+		if other.MessageHash(MSG_TYPE_MINT, params) == msg {
+			t.Fatalf("message hash shall commit to the %s", name)
+		}
+	}
+	if aptos.MessageHash(MSG_TYPE_PREPARE, params) == msg {
+		t.Fatalf("message hash shall commit to the message type")
+	}
+}
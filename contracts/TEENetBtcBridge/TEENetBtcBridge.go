@@ -31,8 +31,8 @@ var (
 
 // TEENetBtcBridgeMetaData contains all meta data concerning the TEENetBtcBridge contract.
 var TEENetBtcBridgeMetaData = &bind.MetaData{
-	ABI: "[{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"pk_\",\"type\":\"uint256\"}],\"stateMutability\":\"nonpayable\",\"type\":\"constructor\"},{\"inputs\":[{\"internalType\":\"bytes32\",\"name\":\"btcTxId\",\"type\":\"bytes32\"}],\"name\":\"AlreadyMinted\",\"type\":\"error\"},{\"inputs\":[{\"internalType\":\"bytes32\",\"name\":\"txHash\",\"type\":\"bytes32\"}],\"name\":\"AlreadyPrepared\",\"type\":\"error\"},{\"inputs\":[{\"internalType\":\"bytes32\",\"name\":\"btcTxId\",\"type\":\"bytes32\"}],\"name\":\"BtcTxIdAlreadyUsed\",\"type\":\"error\"},{\"inputs\":[],\"name\":\"EmptyOutpointIdxs\",\"type\":\"error\"},{\"inputs\":[],\"name\":\"EmptyOutpointTxIds\",\"type\":\"error\"},{\"inputs\":[],\"name\":\"EmptyString\",\"type\":\"error\"},{\"inputs\":[{\"internalType\":\"bytes32\",\"name\":\"txId\",\"type\":\"bytes32\"},{\"internalType\":\"address\",\"name\":\"addr\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"amount\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"rx\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"s\",\"type\":\"uint256\"}],\"name\":\"InvalidSchnorrSignature\",\"type\":\"error\"},{\"inputs\":[],\"name\":\"OutpointTxIdsAndOutpointIdxsLengthMismatch\",\"type\":\"error\"},{\"inputs\":[],\"name\":\"ZeroAmount\",\"type\":\"error\"},{\"inputs\":[],\"name\":\"ZeroBtcTxId\",\"type\":\"error\"},{\"inputs\":[],\"name\":\"ZeroEthAddress\",\"type\":\"error\"},{\"inputs\":[],\"name\":\"ZeroEthTxHash\",\"type\":\"error\"},{\"inputs\":[],\"name\":\"ZeroOutpointTxId\",\"type\":\"error\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"bytes32\",\"name\":\"btcTxId\",\"type\":\"bytes32\"},{\"indexed\":false,\"internalType\":\"address\",\"name\":\"receiver\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"amount\",\"type\":\"uint256\"}],\"name\":\"Minted\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"bytes32\",\"name\":\"ethTxHash\",\"type\":\"bytes32\"},{\"indexed\":false,\"internalType\":\"address\",\"name\":\"requester\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"string\",\"name\":\"receiver\",\"type\":\"string\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"amount\",\"type\":\"uint256\"},{\"indexed\":false,\"internalType\":\"bytes32[]\",\"name\":\"outpointTxIds\",\"type\":\"bytes32[]\"},{\"indexed\":false,\"internalType\":\"uint16[]\",\"name\":\"outpointIdxs\",\"type\":\"uint16[]\"}],\"name\":\"RedeemPrepared\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":false,\"internalType\":\"address\",\"name\":\"sender\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"amount\",\"type\":\"uint256\"},{\"indexed\":false,\"internalType\":\"string\",\"name\":\"receiver\",\"type\":\"string\"}],\"name\":\"RedeemRequested\",\"type\":\"event\"},{\"inputs\":[],\"name\":\"bip340\",\"outputs\":[{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"bytes32\",\"name\":\"btcTxId\",\"type\":\"bytes32\"}],\"name\":\"isMinted\",\"outputs\":[{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"bytes32\",\"name\":\"txHash\",\"type\":\"bytes32\"}],\"name\":\"isPrepared\",\"outputs\":[{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"bytes32\",\"name\":\"btcTxId\",\"type\":\"bytes32\"}],\"name\":\"isUsed\",\"outputs\":[{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"bytes32\",\"name\":\"btcTxId\",\"type\":\"bytes32\"},{\"internalType\":\"address\",\"name\":\"receiver\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"amount\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"rx\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"s\",\"type\":\"uint256\"}],\"name\":\"mint\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"pk\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"bytes32\",\"name\":\"redeemRequestTxHash\",\"type\":\"bytes32\"},{\"internalType\":\"address\",\"name\":\"requester\",\"type\":\"address\"},{\"internalType\":\"string\",\"name\":\"receiver\",\"type\":\"string\"},{\"internalType\":\"uint256\",\"name\":\"amount\",\"type\":\"uint256\"},{\"internalType\":\"bytes32[]\",\"name\":\"outpointTxIds\",\"type\":\"bytes32[]\"},{\"internalType\":\"uint16[]\",\"name\":\"outpointIdxs\",\"type\":\"uint16[]\"},{\"internalType\":\"uint256\",\"name\":\"rx\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"s\",\"type\":\"uint256\"}],\"name\":\"redeemPrepare\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"amount\",\"type\":\"uint256\"},{\"internalType\":\"string\",\"name\":\"receiver\",\"type\":\"string\"}],\"name\":\"redeemRequest\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"twbtc\",\"outputs\":[{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"}],\"stateMutability\":\"view\",\"type\":\"function\"}]",
-	Bin: "0x60806040523480156200001157600080fd5b506040516200408438038062004084833981810160405281019062000037919062000186565b80600081905550306040516200004d906200012a565b620000599190620001fd565b604051809103906000f08015801562000076573d6000803e3d6000fd5b50600160006101000a81548173ffffffffffffffffffffffffffffffffffffffff021916908373ffffffffffffffffffffffffffffffffffffffff160217905550604051620000c59062000138565b604051809103906000f080158015620000e2573d6000803e3d6000fd5b50600260006101000a81548173ffffffffffffffffffffffffffffffffffffffff021916908373ffffffffffffffffffffffffffffffffffffffff160217905550506200021a565b6119c28062001bec83390190565b610ad680620035ae83390190565b600080fd5b6000819050919050565b62000160816200014b565b81146200016c57600080fd5b50565b600081519050620001808162000155565b92915050565b6000602082840312156200019f576200019e62000146565b5b6000620001af848285016200016f565b91505092915050565b600073ffffffffffffffffffffffffffffffffffffffff82169050919050565b6000620001e582620001b8565b9050919050565b620001f781620001d8565b82525050565b6000602082019050620002146000830184620001ec565b92915050565b6119c2806200022a6000396000f3fe608060405234801561001057600080fd5b50600436106100935760003560e01c806399ba77ed1161006657806399ba77ed1461011e5780639e4c0be31461013a578063f2c668d31461016a578063fe255a1814610188578063fed4634d146101b857610093565b80634c8eb2b41461009857806358e14a45146100b4578063879d4189146100d2578063981c7cde146100ee575b600080fd5b6100b260048036038101906100ad9190611000565b6101d6565b005b6100bc61071e565b6040516100c99190611119565b60405180910390f35b6100ec60048036038101906100e79190611134565b610727565b005b61010860048036038101906101039190611190565b61082f565b60405161011591906111d8565b60405180910390f35b610138600480360381019061013391906111f3565b610859565b005b610154600480360381019061014f9190611190565b610b6f565b60405161016191906111d8565b60405180910390f35b610172610b99565b60405161017f919061127d565b60405180910390f35b6101a2600480360381019061019d9190611190565b610bc3565b6040516101af91906111d8565b60405180910390f35b6101c0610bed565b6040516101cd919061127d565b60405180910390f35b6000801b8803610212576040517f084c231600000000000000000000000000000000000000000000000000000000815260040160405180910390fd5b6004600089815260200190815260200160002060009054906101000a900460ff161561027557876040517f8446a9f900000000000000000000000000000000000000000000000000000000815260040161026c91906112a7565b60405180910390fd5b600073ffffffffffffffffffffffffffffffffffffffff168773ffffffffffffffffffffffffffffffffffffffff16036102db576040517f6665132200000000000000000000000000000000000000000000000000000000815260040160405180910390fd5b6000865103610316576040517fecd7b0d100000000000000000000000000000000000000000000000000000000815260040160405180910390fd5b60008503610350576040517f1f2a200500000000000000000000000000000000000000000000000000000000815260040160405180910390fd5b600084510361038b576040517f60674d0700000000000000000000000000000000000000000000000000000000815260040160405180910390fd5b60008351036103c6576040517f94da7ac500000000000000000000000000000000000000000000000000000000815260040160405180910390fd5b8251845114610401576040517fd1b7dbd700000000000000000000000000000000000000000000000000000000815260040160405180910390fd5b60005b84518161ffff16101561051c576000801b858261ffff168151811061042c5761042b6112c2565b5b60200260200101510361046a576040517e65c8a000000000000000000000000000000000000000000000000000000000815260040160405180910390fd5b60056000868361ffff1681518110610485576104846112c2565b5b6020026020010151815260200190815260200160002060009054906101000a900460ff161561050957848161ffff16815181106104c5576104c46112c2565b5b60200260200101516040517fa03c729100000000000000000000000000000000000000000000000000000000815260040161050091906112a7565b60405180910390fd5b808061051490611320565b915050610404565b50600260009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff16630fde6e5560005484848c8c8c8c8c8c60405160200161057b969594939291906115b5565b604051602081830303815290604052805190602001206040518563ffffffff1660e01b81526004016105b09493929190611619565b602060405180830381865afa1580156105cd573d6000803e3d6000fd5b505050506040513d601f19601f820116820180604052508101906105f1919061168a565b61063a5787878684846040517f9ab2c8bf0000000000000000000000000000000000000000000000000000000081526004016106319594939291906116b7565b60405180910390fd5b6001600460008a815260200190815260200160002060006101000a81548160ff02191690831515021790555060005b84518161ffff1610156106d357600160056000878461ffff1681518110610693576106926112c2565b5b6020026020010151815260200190815260200160002060006101000a81548160ff02191690831515021790555080806106cb90611320565b915050610669565b50877f37c97e6e1d3765bfb9944bdfbdb2b5c0557d15c37b8e59280de9501bdc637ed3888888888860405161070c959493929190611880565b60405180910390a25050505050505050565b60008054905090565b60008203610761576040517f1f2a200500000000000000000000000000000000000000000000000000000000815260040160405180910390fd5b600160009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff166379cc679033846040518363ffffffff1660e01b81526004016107be9291906118e8565b600060405180830381600087803b1580156107d857600080fd5b505af11580156107ec573d6000803e3d6000fd5b505050507f855269a3c942964e56de35012138cbde5a827c4c5b9d5686d96c342ece95c10433838360405161082393929190611911565b60405180910390a15050565b60006004600083815260200190815260200160002060009054906101000a900460ff169050919050565b600073ffffffffffffffffffffffffffffffffffffffff168473ffffffffffffffffffffffffffffffffffffffff16036108bf576040517f6665132200000000000000000000000000000000000000000000000000000000815260040160405180910390fd5b600083036108f9576040517f1f2a200500000000000000000000000000000000000000000000000000000000815260040160405180910390fd5b6003600086815260200190815260200160002060009054906101000a900460ff161561095c57846040517f2652130000000000000000000000000000000000000000000000000000000000815260040161095391906112a7565b60405180910390fd5b600260009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff16630fde6e5560005484848989896040516020016109b49392919061194f565b604051602081830303815290604052805190602001206040518563ffffffff1660e01b81526004016109e99493929190611619565b602060405180830381865afa158015610a06573d6000803e3d6000fd5b505050506040513d601f19601f82011682018060405250810190610a2a919061168a565b610a735784848484846040517f9ab2c8bf000000000000000000000000000000000000000000000000000000008152600401610a6a9594939291906116b7565b60405180910390fd5b600160009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff166340c10f1985856040518363ffffffff1660e01b8152600401610ad09291906118e8565b600060405180830381600087803b158015610aea57600080fd5b505af1158015610afe573d6000803e3d6000fd5b5050505060016003600087815260200190815260200160002060006101000a81548160ff021916908315150217905550847f1a49076e0e8c733171c5360d78c9ae8e19fef5a0720b926e72f78b7cc37618cd8585604051610b609291906118e8565b60405180910390a25050505050565b60006003600083815260200190815260200160002060009054906101000a900460ff169050919050565b6000600160009054906101000a900473ffffffffffffffffffffffffffffffffffffffff16905090565b60006005600083815260200190815260200160002060009054906101000a900460ff169050919050565b6000600260009054906101000a900473ffffffffffffffffffffffffffffffffffffffff16905090565b6000604051905090565b600080fd5b600080fd5b6000819050919050565b610c3e81610c2b565b8114610c4957600080fd5b50565b600081359050610c5b81610c35565b92915050565b600073ffffffffffffffffffffffffffffffffffffffff82169050919050565b6000610c8c82610c61565b9050919050565b610c9c81610c81565b8114610ca757600080fd5b50565b600081359050610cb981610c93565b92915050565b600080fd5b600080fd5b6000601f19601f8301169050919050565b7f4e487b7100000000000000000000000000000000000000000000000000000000600052604160045260246000fd5b610d1282610cc9565b810181811067ffffffffffffffff82111715610d3157610d30610cda565b5b80604052505050565b6000610d44610c17565b9050610d508282610d09565b919050565b600067ffffffffffffffff821115610d7057610d6f610cda565b5b610d7982610cc9565b9050602081019050919050565b82818337600083830152505050565b6000610da8610da384610d55565b610d3a565b905082815260208101848484011115610dc457610dc3610cc4565b5b610dcf848285610d86565b509392505050565b600082601f830112610dec57610deb610cbf565b5b8135610dfc848260208601610d95565b91505092915050565b6000819050919050565b610e1881610e05565b8114610e2357600080fd5b50565b600081359050610e3581610e0f565b92915050565b600067ffffffffffffffff821115610e5657610e55610cda565b5b602082029050602081019050919050565b600080fd5b6000610e7f610e7a84610e3b565b610d3a565b90508083825260208201905060208402830185811115610ea257610ea1610e67565b5b835b81811015610ecb5780610eb78882610c4c565b845260208401935050602081019050610ea4565b5050509392505050565b600082601f830112610eea57610ee9610cbf565b5b8135610efa848260208601610e6c565b91505092915050565b600067ffffffffffffffff821115610f1e57610f1d610cda565b5b602082029050602081019050919050565b600061ffff82169050919050565b610f4681610f2f565b8114610f5157600080fd5b50565b600081359050610f6381610f3d565b92915050565b6000610f7c610f7784610f03565b610d3a565b90508083825260208201905060208402830185811115610f9f57610f9e610e67565b5b835b81811015610fc85780610fb48882610f54565b845260208401935050602081019050610fa1565b5050509392505050565b600082601f830112610fe757610fe6610cbf565b5b8135610ff7848260208601610f69565b91505092915050565b600080600080600080600080610100898b03121561102157611020610c21565b5b600061102f8b828c01610c4c565b98505060206110408b828c01610caa565b975050604089013567ffffffffffffffff81111561106157611060610c26565b5b61106d8b828c01610dd7565b965050606061107e8b828c01610e26565b955050608089013567ffffffffffffffff81111561109f5761109e610c26565b5b6110ab8b828c01610ed5565b94505060a089013567ffffffffffffffff8111156110cc576110cb610c26565b5b6110d88b828c01610fd2565b93505060c06110e98b828c01610e26565b92505060e06110fa8b828c01610e26565b9150509295985092959890939650565b61111381610e05565b82525050565b600060208201905061112e600083018461110a565b92915050565b6000806040838503121561114b5761114a610c21565b5b600061115985828601610e26565b925050602083013567ffffffffffffffff81111561117a57611179610c26565b5b61118685828601610dd7565b9150509250929050565b6000602082840312156111a6576111a5610c21565b5b60006111b484828501610c4c565b91505092915050565b60008115159050919050565b6111d2816111bd565b82525050565b60006020820190506111ed60008301846111c9565b92915050565b600080600080600060a0868803121561120f5761120e610c21565b5b600061121d88828901610c4c565b955050602061122e88828901610caa565b945050604061123f88828901610e26565b935050606061125088828901610e26565b925050608061126188828901610e26565b9150509295509295909350565b61127781610c81565b82525050565b6000602082019050611292600083018461126e565b92915050565b6112a181610c2b565b82525050565b60006020820190506112bc6000830184611298565b92915050565b7f4e487b7100000000000000000000000000000000000000000000000000000000600052603260045260246000fd5b7f4e487b7100000000000000000000000000000000000000000000000000000000600052601160045260246000fd5b600061132b82610f2f565b915061ffff820361133f5761133e6112f1565b5b600182019050919050565b6000819050919050565b61136561136082610c2b565b61134a565b82525050565b60008160601b9050919050565b60006113838261136b565b9050919050565b600061139582611378565b9050919050565b6113ad6113a882610c81565b61138a565b82525050565b600081519050919050565b600081905092915050565b60005b838110156113e75780820151818401526020810190506113cc565b60008484015250505050565b60006113fe826113b3565b61140881856113be565b93506114188185602086016113c9565b80840191505092915050565b6000819050919050565b61143f61143a82610e05565b611424565b82525050565b600081519050919050565b600081905092915050565b6000819050602082019050919050565b61147481610c2b565b82525050565b6000611486838361146b565b60208301905092915050565b6000602082019050919050565b60006114aa82611445565b6114b48185611450565b93506114bf8361145b565b8060005b838110156114f05781516114d7888261147a565b97506114e283611492565b9250506001810190506114c3565b5085935050505092915050565b600081519050919050565b600081905092915050565b6000819050602082019050919050565b61152c81610f2f565b82525050565b600061153e8383611523565b60208301905092915050565b6000602082019050919050565b6000611562826114fd565b61156c8185611508565b935061157783611513565b8060005b838110156115a857815161158f8882611532565b975061159a8361154a565b92505060018101905061157b565b5085935050505092915050565b60006115c18289611354565b6020820191506115d1828861139c565b6014820191506115e182876113f3565b91506115ed828661142e565b6020820191506115fd828561149f565b91506116098284611557565b9150819050979650505050505050565b600060808201905061162e600083018761110a565b61163b602083018661110a565b611648604083018561110a565b6116556060830184611298565b95945050505050565b611667816111bd565b811461167257600080fd5b50565b6000815190506116848161165e565b92915050565b6000602082840312156116a05761169f610c21565b5b60006116ae84828501611675565b91505092915050565b600060a0820190506116cc6000830188611298565b6116d9602083018761126e565b6116e6604083018661110a565b6116f3606083018561110a565b611700608083018461110a565b9695505050505050565b600082825260208201905092915050565b6000611726826113b3565b611730818561170a565b93506117408185602086016113c9565b61174981610cc9565b840191505092915050565b600082825260208201905092915050565b61176e81610c2b565b82525050565b60006117808383611765565b60208301905092915050565b600061179782611445565b6117a18185611754565b93506117ac8361145b565b8060005b838110156117dd5781516117c48882611774565b97506117cf83611492565b9250506001810190506117b0565b5085935050505092915050565b600082825260208201905092915050565b61180481610f2f565b82525050565b600061181683836117fb565b60208301905092915050565b600061182d826114fd565b61183781856117ea565b935061184283611513565b8060005b8381101561187357815161185a888261180a565b97506118658361154a565b925050600181019050611846565b5085935050505092915050565b600060a082019050611895600083018861126e565b81810360208301526118a7818761171b565b90506118b6604083018661110a565b81810360608301526118c8818561178c565b905081810360808301526118dc8184611822565b90509695505050505050565b60006040820190506118fd600083018561126e565b61190a602083018461110a565b9392505050565b6000606082019050611926600083018661126e565b611933602083018561110a565b8181036040830152611945818461171b565b9050949350505050565b600061195b8286611354565b60208201915061196b828561139c565b60148201915061197b828461142e565b60208201915081905094935050505056fea2646970667358221220aa9a71b52771ece7d4d1bc19a96cb3e1c9f633ac3cc22fef4b13667689ed5a0464736f6c6343000818003360806040523480156200001157600080fd5b50604051620019c2380380620019c2833981810160405281019062000037919062000288565b806040518060400160405280601681526020017f5445454e6574207772617070656420426974636f696e000000000000000000008152506040518060400160405280600581526020017f54574254430000000000000000000000000000000000000000000000000000008152508160039081620000b5919062000534565b508060049081620000c7919062000534565b505050600073ffffffffffffffffffffffffffffffffffffffff168173ffffffffffffffffffffffffffffffffffffffff16036200013f5760006040517f1e4fbdf70000000000000000000000000000000000000000000000000000000081526004016200013691906200062c565b60405180910390fd5b62000150816200015860201b60201c565b505062000649565b6000600560009054906101000a900473ffffffffffffffffffffffffffffffffffffffff16905081600560006101000a81548173ffffffffffffffffffffffffffffffffffffffff021916908373ffffffffffffffffffffffffffffffffffffffff1602179055508173ffffffffffffffffffffffffffffffffffffffff168173ffffffffffffffffffffffffffffffffffffffff167f8be0079c531659141344cd1fd0a4f28419497f9722a3daafe3b4186f6b6457e060405160405180910390a35050565b600080fd5b600073ffffffffffffffffffffffffffffffffffffffff82169050919050565b6000620002508262000223565b9050919050565b620002628162000243565b81146200026e57600080fd5b50565b600081519050620002828162000257565b92915050565b600060208284031215620002a157620002a06200021e565b5b6000620002b18482850162000271565b91505092915050565b600081519050919050565b7f4e487b7100000000000000000000000000000000000000000000000000000000600052604160045260246000fd5b7f4e487b7100000000000000000000000000000000000000000000000000000000600052602260045260246000fd5b600060028204905060018216806200033c57607f821691505b602082108103620003525762000351620002f4565b5b50919050565b60008190508160005260206000209050919050565b60006020601f8301049050919050565b600082821b905092915050565b600060088302620003bc7fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff826200037d565b620003c886836200037d565b95508019841693508086168417925050509392505050565b6000819050919050565b6000819050919050565b6000620004156200040f6200040984620003e0565b620003ea565b620003e0565b9050919050565b6000819050919050565b6200043183620003f4565b6200044962000440826200041c565b8484546200038a565b825550505050565b600090565b6200046062000451565b6200046d81848462000426565b505050565b5b8181101562000495576200048960008262000456565b60018101905062000473565b5050565b601f821115620004e457620004ae8162000358565b620004b9846200036d565b81016020851015620004c9578190505b620004e1620004d8856200036d565b83018262000472565b50505b505050565b600082821c905092915050565b60006200050960001984600802620004e9565b1980831691505092915050565b6000620005248383620004f6565b9150826002028217905092915050565b6200053f82620002ba565b67ffffffffffffffff8111156200055b576200055a620002c5565b5b62000567825462000323565b6200057482828562000499565b600060209050601f831160018114620005ac576000841562000597578287015190505b620005a3858262000516565b86555062000613565b601f198416620005bc8662000358565b60005b82811015620005e657848901518255600182019150602085019450602081019050620005bf565b8683101562000606578489015162000602601f891682620004f6565b8355505b6001600288020188555050505b505050505050565b620006268162000243565b82525050565b60006020820190506200064360008301846200061b565b92915050565b61136980620006596000396000f3fe608060405234801561001057600080fd5b50600436106101005760003560e01c806370a082311161009757806395d89b411161006657806395d89b4114610289578063a9059cbb146102a7578063dd62ed3e146102d7578063f2fde38b1461030757610100565b806370a0823114610215578063715018a61461024557806379cc67901461024f5780638da5cb5b1461026b57610100565b8063313ce567116100d3578063313ce567146101a157806340c10f19146101bf57806342966c68146101db5780635e8f1d94146101f757610100565b806306fdde0314610105578063095ea7b31461012357806318160ddd1461015357806323b872dd14610171575b600080fd5b61010d610323565b60405161011a9190610f90565b60405180910390f35b61013d6004803603810190610138919061104b565b6103b5565b60405161014a91906110a6565b60405180910390f35b61015b6103d8565b60405161016891906110d0565b60405180910390f35b61018b600480360381019061018691906110eb565b6103e2565b60405161019891906110a6565b60405180910390f35b6101a9610411565b6040516101b6919061115a565b60405180910390f35b6101d960048036038101906101d4919061104b565b61041a565b005b6101f560048036038101906101f09190611175565b61048a565b005b6101ff61049e565b60405161020c91906110d0565b60405180910390f35b61022f600480360381019061022a91906111a2565b6104a9565b60405161023c91906110d0565b60405180910390f35b61024d6104f1565b005b6102696004803603810190610264919061104b565b610505565b005b610273610525565b60405161028091906111de565b60405180910390f35b61029161054f565b60405161029e9190610f90565b60405180910390f35b6102c160048036038101906102bc919061104b565b6105e1565b6040516102ce91906110a6565b60405180910390f35b6102f160048036038101906102ec91906111f9565b610604565b6040516102fe91906110d0565b60405180910390f35b610321600480360381019061031c91906111a2565b61068b565b005b60606003805461033290611268565b80601f016020809104026020016040519081016040528092919081815260200182805461035e90611268565b80156103ab5780601f10610380576101008083540402835291602001916103ab565b820191906000526020600020905b81548152906001019060200180831161038e57829003601f168201915b5050505050905090565b6000806103c0610711565b90506103cd818585610719565b600191505092915050565b6000600254905090565b6000806103ed610711565b90506103fa85828561072b565b6104058585856107bf565b60019150509392505050565b60006008905090565b6104226108b3565b61042c828261093a565b660775f05a07400061043c6103d8565b11156104865761044a6103d8565b6040517ff602b06a00000000000000000000000000000000000000000000000000000000815260040161047d91906110d0565b60405180910390fd5b5050565b61049b610495610711565b826109bc565b50565b660775f05a07400081565b60008060008373ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff168152602001908152602001600020549050919050565b6104f96108b3565b6105036000610a3e565b565b61051782610511610711565b8361072b565b61052182826109bc565b5050565b6000600560009054906101000a900473ffffffffffffffffffffffffffffffffffffffff16905090565b60606004805461055e90611268565b80601f016020809104026020016040519081016040528092919081815260200182805461058a90611268565b80156105d75780601f106105ac576101008083540402835291602001916105d7565b820191906000526020600020905b8154815290600101906020018083116105ba57829003601f168201915b5050505050905090565b6000806105ec610711565b90506105f98185856107bf565b600191505092915050565b6000600160008473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff16815260200190815260200160002060008373ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff16815260200190815260200160002054905092915050565b6106936108b3565b600073ffffffffffffffffffffffffffffffffffffffff168173ffffffffffffffffffffffffffffffffffffffff16036107055760006040517f1e4fbdf70000000000000000000000000000000000000000000000000000000081526004016106fc91906111de565b60405180910390fd5b61070e81610a3e565b50565b600033905090565b6107268383836001610b04565b505050565b60006107378484610604565b90507fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff81146107b957818110156107a9578281836040517ffb8f41b20000000000000000000000000000000000000000000000000000000081526004016107a093929190611299565b60405180910390fd5b6107b884848484036000610b04565b5b50505050565b600073ffffffffffffffffffffffffffffffffffffffff168373ffffffffffffffffffffffffffffffffffffffff16036108315760006040517f96c6fd1e00000000000000000000000000000000000000000000000000000000815260040161082891906111de565b60405180910390fd5b600073ffffffffffffffffffffffffffffffffffffffff168273ffffffffffffffffffffffffffffffffffffffff16036108a35760006040517fec442f0500000000000000000000000000000000000000000000000000000000815260040161089a91906111de565b60405180910390fd5b6108ae838383610cdb565b505050565b6108bb610711565b73ffffffffffffffffffffffffffffffffffffffff166108d9610525565b73ffffffffffffffffffffffffffffffffffffffff1614610938576108fc610711565b6040517f118cdaa700000000000000000000000000000000000000000000000000000000815260040161092f91906111de565b60405180910390fd5b565b600073ffffffffffffffffffffffffffffffffffffffff168273ffffffffffffffffffffffffffffffffffffffff16036109ac5760006040517fec442f050000000000000000000000000000000000000000000000000000000081526004016109a391906111de565b60405180910390fd5b6109b860008383610cdb565b5050565b600073ffffffffffffffffffffffffffffffffffffffff168273ffffffffffffffffffffffffffffffffffffffff1603610a2e5760006040517f96c6fd1e000000000000000000000000000000000000000000000000000000008152600401610a2591906111de565b60405180910390fd5b610a3a82600083610cdb565b5050565b6000600560009054906101000a900473ffffffffffffffffffffffffffffffffffffffff16905081600560006101000a81548173ffffffffffffffffffffffffffffffffffffffff021916908373ffffffffffffffffffffffffffffffffffffffff1602179055508173ffffffffffffffffffffffffffffffffffffffff168173ffffffffffffffffffffffffffffffffffffffff167f8be0079c531659141344cd1fd0a4f28419497f9722a3daafe3b4186f6b6457e060405160405180910390a35050565b600073ffffffffffffffffffffffffffffffffffffffff168473ffffffffffffffffffffffffffffffffffffffff1603610b765760006040517fe602df05000000000000000000000000000000000000000000000000000000008152600401610b6d91906111de565b60405180910390fd5b600073ffffffffffffffffffffffffffffffffffffffff168373ffffffffffffffffffffffffffffffffffffffff1603610be85760006040517f94280d62000000000000000000000000000000000000000000000000000000008152600401610bdf91906111de565b60405180910390fd5b81600160008673ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff16815260200190815260200160002060008573ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff168152602001908152602001600020819055508015610cd5578273ffffffffffffffffffffffffffffffffffffffff168473ffffffffffffffffffffffffffffffffffffffff167f8c5be1e5ebec7d5bd14f71427d1e84f3dd0314c0f7b2291e5b200ac8c7c3b92584604051610ccc91906110d0565b60405180910390a35b50505050565b600073ffffffffffffffffffffffffffffffffffffffff168373ffffffffffffffffffffffffffffffffffffffff1603610d2d578060026000828254610d2191906112ff565b92505081905550610e00565b60008060008573ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff16815260200190815260200160002054905081811015610db9578381836040517fe450d38c000000000000000000000000000000000000000000000000000000008152600401610db093929190611299565b60405180910390fd5b8181036000808673ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff16815260200190815260200160002081905550505b600073ffffffffffffffffffffffffffffffffffffffff168273ffffffffffffffffffffffffffffffffffffffff1603610e495780600260008282540392505081905550610e96565b806000808473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff168152602001908152602001600020600082825401925050819055505b8173ffffffffffffffffffffffffffffffffffffffff168373ffffffffffffffffffffffffffffffffffffffff167fddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef83604051610ef391906110d0565b60405180910390a3505050565b600081519050919050565b600082825260208201905092915050565b60005b83811015610f3a578082015181840152602081019050610f1f565b60008484015250505050565b6000601f19601f8301169050919050565b6000610f6282610f00565b610f6c8185610f0b565b9350610f7c818560208601610f1c565b610f8581610f46565b840191505092915050565b60006020820190508181036000830152610faa8184610f57565b905092915050565b600080fd5b600073ffffffffffffffffffffffffffffffffffffffff82169050919050565b6000610fe282610fb7565b9050919050565b610ff281610fd7565b8114610ffd57600080fd5b50565b60008135905061100f81610fe9565b92915050565b6000819050919050565b61102881611015565b811461103357600080fd5b50565b6000813590506110458161101f565b92915050565b6000806040838503121561106257611061610fb2565b5b600061107085828601611000565b925050602061108185828601611036565b9150509250929050565b60008115159050919050565b6110a08161108b565b82525050565b60006020820190506110bb6000830184611097565b92915050565b6110ca81611015565b82525050565b60006020820190506110e560008301846110c1565b92915050565b60008060006060848603121561110457611103610fb2565b5b600061111286828701611000565b935050602061112386828701611000565b925050604061113486828701611036565b9150509250925092565b600060ff82169050919050565b6111548161113e565b82525050565b600060208201905061116f600083018461114b565b92915050565b60006020828403121561118b5761118a610fb2565b5b600061119984828501611036565b91505092915050565b6000602082840312156111b8576111b7610fb2565b5b60006111c684828501611000565b91505092915050565b6111d881610fd7565b82525050565b60006020820190506111f360008301846111cf565b92915050565b600080604083850312156112105761120f610fb2565b5b600061121e85828601611000565b925050602061122f85828601611000565b9150509250929050565b7f4e487b7100000000000000000000000000000000000000000000000000000000600052602260045260246000fd5b6000600282049050600182168061128057607f821691505b60208210810361129357611292611239565b5b50919050565b60006060820190506112ae60008301866111cf565b6112bb60208301856110c1565b6112c860408301846110c1565b949350505050565b7f4e487b7100000000000000000000000000000000000000000000000000000000600052601160045260246000fd5b600061130a82611015565b915061131583611015565b925082820190508082111561132d5761132c6112d0565b5b9291505056fea2646970667358221220504476fede93226437d3b11bb58ead86358659b4af71b44c02e326e137d37e1064736f6c63430008180033608060405234801561001057600080fd5b50610ab6806100206000396000f3fe608060405234801561001057600080fd5b506004361061002b5760003560e01c80630fde6e5514610030575b600080fd5b61004a60048036038101906100459190610622565b610060565b60405161005791906106a4565b60405180910390f35b60007ffffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f851015806100b157507ffffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f8410155b806100dc57507ffffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd03641418310155b156100ea5760009050610276565b6000806100f68661027e565b915091508061010a57600092505050610276565b600061011d8760001b8960001b876102ea565b905060007ffffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364141806101505761014f6106bf565b5b8988097ffffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd036414161017e919061071d565b60001b905060007ffffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364141806101b4576101b36106bf565b5b8a84097ffffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd03641416101e2919061071d565b60001b90506000600183601b8d60001b856040516000815260200160405260405161021094939291906107b2565b6020604051602081039080840390855afa158015610232573d6000803e3d6000fd5b5050506020604051035190508573ffffffffffffffffffffffffffffffffffffffff168173ffffffffffffffffffffffffffffffffffffffff161496505050505050505b949350505050565b60008060008061028d856103c2565b91509150806102a4576000809350935050506102e5565b60008560001b8360001b6040516020016102bf929190610818565b6040516020818303038152906040528051906020012090508060001c6001945094505050505b915091565b6000807f7bb52d7a9fef58323eb1bf7a407db382d2f3f2d81bb1224f49fe518f6d48d37c60001b90507ffffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd03641416002828388888860405160200161034f959493929190610844565b60405160208183030381529060405260405161036b9190610914565b602060405180830381855afa158015610388573d6000803e3d6000fd5b5050506040513d601f19601f820116820180604052508101906103ab9190610940565b60001c6103b8919061096d565b9150509392505050565b60008060007ffffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f90506000806007905082861061040757600080945094505050506104b4565b60008380610418576104176106bf565b5b8480610427576104266106bf565b5b838680610437576104366106bf565b5b868b0908858061044a576104496106bf565b5b8680610459576104586106bf565b5b8a8b098a09089050610484816004600187610474919061099e565b61047e91906109d2565b866104b9565b905060008060018316146104a357818561049e919061071d565b6104a5565b815b90508060019650965050505050505b915091565b60008082036104fd576040517f08c379a00000000000000000000000000000000000000000000000000000000081526004016104f490610a60565b60405180910390fd5b6000840361050e57600090506105aa565b6000830361051f57600190506105aa565b60006001905060007f800000000000000000000000000000000000000000000000000000000000000090505b60008111156105a457838186161515870a85848509099150836002820486161515870a85848509099150836004820486161515870a85848509099150836008820486161515870a8584850909915060108104905061054b565b81925050505b9392505050565b600080fd5b6000819050919050565b6105c9816105b6565b81146105d457600080fd5b50565b6000813590506105e6816105c0565b92915050565b6000819050919050565b6105ff816105ec565b811461060a57600080fd5b50565b60008135905061061c816105f6565b92915050565b6000806000806080858703121561063c5761063b6105b1565b5b600061064a878288016105d7565b945050602061065b878288016105d7565b935050604061066c878288016105d7565b925050606061067d8782880161060d565b91505092959194509250565b60008115159050919050565b61069e81610689565b82525050565b60006020820190506106b96000830184610695565b92915050565b7f4e487b7100000000000000000000000000000000000000000000000000000000600052601260045260246000fd5b7f4e487b7100000000000000000000000000000000000000000000000000000000600052601160045260246000fd5b6000610728826105b6565b9150610733836105b6565b925082820390508181111561074b5761074a6106ee565b5b92915050565b61075a816105ec565b82525050565b6000819050919050565b600060ff82169050919050565b6000819050919050565b600061079c61079761079284610760565b610777565b61076a565b9050919050565b6107ac81610781565b82525050565b60006080820190506107c76000830187610751565b6107d460208301866107a3565b6107e16040830185610751565b6107ee6060830184610751565b95945050505050565b6000819050919050565b61081261080d826105ec565b6107f7565b82525050565b60006108248285610801565b6020820191506108348284610801565b6020820191508190509392505050565b60006108508288610801565b6020820191506108608287610801565b6020820191506108708286610801565b6020820191506108808285610801565b6020820191506108908284610801565b6020820191508190509695505050505050565b600081519050919050565b600081905092915050565b60005b838110156108d75780820151818401526020810190506108bc565b60008484015250505050565b60006108ee826108a3565b6108f881856108ae565b93506109088185602086016108b9565b80840191505092915050565b600061092082846108e3565b915081905092915050565b60008151905061093a816105f6565b92915050565b600060208284031215610956576109556105b1565b5b60006109648482850161092b565b91505092915050565b6000610978826105b6565b9150610983836105b6565b925082610993576109926106bf565b5b828206905092915050565b60006109a9826105b6565b91506109b4836105b6565b92508282019050808211156109cc576109cb6106ee565b5b92915050565b60006109dd826105b6565b91506109e8836105b6565b9250826109f8576109f76106bf565b5b828204905092915050565b600082825260208201905092915050565b7f4d6f64756c7573206973207a65726f0000000000000000000000000000000000600082015250565b6000610a4a600f83610a03565b9150610a5582610a14565b602082019050919050565b60006020820190508181036000830152610a7981610a3d565b905091905056fea2646970667358221220b20635f4ea1b667a9f5cb231f9c58cedbc6551db34ab43981b8ec9e459fdd74764736f6c63430008180033",
+	ABI: "[{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"pk_\",\"type\":\"uint256\"}],\"stateMutability\":\"nonpayable\",\"type\":\"constructor\"},{\"inputs\":[{\"internalType\":\"bytes32\",\"name\":\"btcTxId\",\"type\":\"bytes32\"}],\"name\":\"AlreadyMinted\",\"type\":\"error\"},{\"inputs\":[{\"internalType\":\"bytes32\",\"name\":\"txHash\",\"type\":\"bytes32\"}],\"name\":\"AlreadyPrepared\",\"type\":\"error\"},{\"inputs\":[{\"internalType\":\"bytes32\",\"name\":\"btcTxId\",\"type\":\"bytes32\"}],\"name\":\"BtcTxIdAlreadyUsed\",\"type\":\"error\"},{\"inputs\":[],\"name\":\"EmptyOutpointIdxs\",\"type\":\"error\"},{\"inputs\":[],\"name\":\"EmptyOutpointTxIds\",\"type\":\"error\"},{\"inputs\":[],\"name\":\"EmptyString\",\"type\":\"error\"},{\"inputs\":[{\"internalType\":\"bytes32\",\"name\":\"txId\",\"type\":\"bytes32\"},{\"internalType\":\"address\",\"name\":\"addr\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"amount\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"rx\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"s\",\"type\":\"uint256\"}],\"name\":\"InvalidSchnorrSignature\",\"type\":\"error\"},{\"inputs\":[],\"name\":\"OutpointTxIdsAndOutpointIdxsLengthMismatch\",\"type\":\"error\"},{\"inputs\":[],\"name\":\"ZeroAmount\",\"type\":\"error\"},{\"inputs\":[],\"name\":\"ZeroBtcTxId\",\"type\":\"error\"},{\"inputs\":[],\"name\":\"ZeroEthAddress\",\"type\":\"error\"},{\"inputs\":[],\"name\":\"ZeroEthTxHash\",\"type\":\"error\"},{\"inputs\":[],\"name\":\"ZeroOutpointTxId\",\"type\":\"error\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"bytes32\",\"name\":\"btcTxId\",\"type\":\"bytes32\"},{\"indexed\":false,\"internalType\":\"address\",\"name\":\"receiver\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"amount\",\"type\":\"uint256\"}],\"name\":\"Minted\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"bytes32\",\"name\":\"ethTxHash\",\"type\":\"bytes32\"},{\"indexed\":false,\"internalType\":\"address\",\"name\":\"requester\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"string\",\"name\":\"receiver\",\"type\":\"string\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"amount\",\"type\":\"uint256\"},{\"indexed\":false,\"internalType\":\"bytes32[]\",\"name\":\"outpointTxIds\",\"type\":\"bytes32[]\"},{\"indexed\":false,\"internalType\":\"uint16[]\",\"name\":\"outpointIdxs\",\"type\":\"uint16[]\"}],\"name\":\"RedeemPrepared\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":false,\"internalType\":\"address\",\"name\":\"sender\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"amount\",\"type\":\"uint256\"},{\"indexed\":false,\"internalType\":\"string\",\"name\":\"receiver\",\"type\":\"string\"}],\"name\":\"RedeemRequested\",\"type\":\"event\"},{\"inputs\":[],\"name\":\"bip340\",\"outputs\":[{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"domainSeparator\",\"outputs\":[{\"internalType\":\"bytes32\",\"name\":\"\",\"type\":\"bytes32\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"bytes32\",\"name\":\"btcTxId\",\"type\":\"bytes32\"}],\"name\":\"isMinted\",\"outputs\":[{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"bytes32\",\"name\":\"txHash\",\"type\":\"bytes32\"}],\"name\":\"isPrepared\",\"outputs\":[{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"bytes32\",\"name\":\"btcTxId\",\"type\":\"bytes32\"}],\"name\":\"isUsed\",\"outputs\":[{\"internalType\":\"bool\",\"name\":\"\",\"type\":\"bool\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"bytes32\",\"name\":\"btcTxId\",\"type\":\"bytes32\"},{\"internalType\":\"address\",\"name\":\"receiver\",\"type\":\"address\"},{\"internalType\":\"uint256\",\"name\":\"amount\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"rx\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"s\",\"type\":\"uint256\"}],\"name\":\"mint\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"pk\",\"outputs\":[{\"internalType\":\"uint256\",\"name\":\"\",\"type\":\"uint256\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"bytes32\",\"name\":\"redeemRequestTxHash\",\"type\":\"bytes32\"},{\"internalType\":\"address\",\"name\":\"requester\",\"type\":\"address\"},{\"internalType\":\"string\",\"name\":\"receiver\",\"type\":\"string\"},{\"internalType\":\"uint256\",\"name\":\"amount\",\"type\":\"uint256\"},{\"internalType\":\"bytes32[]\",\"name\":\"outpointTxIds\",\"type\":\"bytes32[]\"},{\"internalType\":\"uint16[]\",\"name\":\"outpointIdxs\",\"type\":\"uint16[]\"},{\"internalType\":\"uint256\",\"name\":\"rx\",\"type\":\"uint256\"},{\"internalType\":\"uint256\",\"name\":\"s\",\"type\":\"uint256\"}],\"name\":\"redeemPrepare\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint256\",\"name\":\"amount\",\"type\":\"uint256\"},{\"internalType\":\"string\",\"name\":\"receiver\",\"type\":\"string\"}],\"name\":\"redeemRequest\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[],\"name\":\"twbtc\",\"outputs\":[{\"internalType\":\"address\",\"name\":\"\",\"type\":\"address\"}],\"stateMutability\":\"view\",\"type\":\"function\"}]",
+	Bin: "0x60806040523480156200001157600080fd5b506040516200417838038062004178833981810160405281019062000037919062000186565b80600081905550306040516200004d906200012a565b620000599190620001fd565b604051809103906000f08015801562000076573d6000803e3d6000fd5b50600160006101000a81548173ffffffffffffffffffffffffffffffffffffffff021916908373ffffffffffffffffffffffffffffffffffffffff160217905550604051620000c59062000138565b604051809103906000f080158015620000e2573d6000803e3d6000fd5b50600260006101000a81548173ffffffffffffffffffffffffffffffffffffffff021916908373ffffffffffffffffffffffffffffffffffffffff160217905550506200021a565b6119c28062001ce083390190565b610ad680620036a283390190565b600080fd5b6000819050919050565b62000160816200014b565b81146200016c57600080fd5b50565b600081519050620001808162000155565b92915050565b6000602082840312156200019f576200019e62000146565b5b6000620001af848285016200016f565b91505092915050565b600073ffffffffffffffffffffffffffffffffffffffff82169050919050565b6000620001e582620001b8565b9050919050565b620001f781620001d8565b82525050565b6000602082019050620002146000830184620001ec565b92915050565b611ab6806200022a6000396000f3fe608060405234801561001057600080fd5b50600436106100935760003560e01c806399ba77ed1161006657806399ba77ed1461011e5780639e4c0be31461013a578063f2c668d31461016a578063fe255a1814610188578063fed4634d146101b857610093565b80634c8eb2b41461009857806358e14a45146100b4578063879d4189146100d2578063981c7cde146100ee575b61198d565b6100b260048036038101906100ad9190611000565b6101d6565b005b6100bc61071e565b6040516100c99190611119565b60405180910390f35b6100ec60048036038101906100e79190611134565b610727565b005b61010860048036038101906101039190611190565b61082f565b60405161011591906111d8565b60405180910390f35b610138600480360381019061013391906111f3565b610859565b005b610154600480360381019061014f9190611190565b610b6f565b60405161016191906111d8565b60405180910390f35b610172610b99565b60405161017f919061127d565b60405180910390f35b6101a2600480360381019061019d9190611190565b610bc3565b6040516101af91906111d8565b60405180910390f35b6101c0610bed565b6040516101cd919061127d565b60405180910390f35b6000801b8803610212576040517f084c231600000000000000000000000000000000000000000000000000000000815260040160405180910390fd5b6004600089815260200190815260200160002060009054906101000a900460ff161561027557876040517f8446a9f900000000000000000000000000000000000000000000000000000000815260040161026c91906112a7565b60405180910390fd5b600073ffffffffffffffffffffffffffffffffffffffff168773ffffffffffffffffffffffffffffffffffffffff16036102db576040517f6665132200000000000000000000000000000000000000000000000000000000815260040160405180910390fd5b6000865103610316576040517fecd7b0d100000000000000000000000000000000000000000000000000000000815260040160405180910390fd5b60008503610350576040517f1f2a200500000000000000000000000000000000000000000000000000000000815260040160405180910390fd5b600084510361038b576040517f60674d0700000000000000000000000000000000000000000000000000000000815260040160405180910390fd5b60008351036103c6576040517f94da7ac500000000000000000000000000000000000000000000000000000000815260040160405180910390fd5b8251845114610401576040517fd1b7dbd700000000000000000000000000000000000000000000000000000000815260040160405180910390fd5b60005b84518161ffff16101561051c576000801b858261ffff168151811061042c5761042b6112c2565b5b60200260200101510361046a576040517e65c8a000000000000000000000000000000000000000000000000000000000815260040160405180910390fd5b60056000868361ffff1681518110610485576104846112c2565b5b6020026020010151815260200190815260200160002060009054906101000a900460ff161561050957848161ffff16815181106104c5576104c46112c2565b5b60200260200101516040517fa03c729100000000000000000000000000000000000000000000000000000000815260040161050091906112a7565b60405180910390fd5b808061051490611320565b915050610404565b50600260009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff16630fde6e5560005484848c8c8c8c8c8c60405160200161057b969594939291906115b5565b604051602081830303815290604052611a2b56fefe5b6040518563ffffffff1660e01b81526004016105b09493929190611619565b602060405180830381865afa1580156105cd573d6000803e3d6000fd5b505050506040513d601f19601f820116820180604052508101906105f1919061168a565b61063a5787878684846040517f9ab2c8bf0000000000000000000000000000000000000000000000000000000081526004016106319594939291906116b7565b60405180910390fd5b6001600460008a815260200190815260200160002060006101000a81548160ff02191690831515021790555060005b84518161ffff1610156106d357600160056000878461ffff1681518110610693576106926112c2565b5b6020026020010151815260200190815260200160002060006101000a81548160ff02191690831515021790555080806106cb90611320565b915050610669565b50877f37c97e6e1d3765bfb9944bdfbdb2b5c0557d15c37b8e59280de9501bdc637ed3888888888860405161070c959493929190611880565b60405180910390a25050505050505050565b60008054905090565b60008203610761576040517f1f2a200500000000000000000000000000000000000000000000000000000000815260040160405180910390fd5b600160009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff166379cc679033846040518363ffffffff1660e01b81526004016107be9291906118e8565b600060405180830381600087803b1580156107d857600080fd5b505af11580156107ec573d6000803e3d6000fd5b505050507f855269a3c942964e56de35012138cbde5a827c4c5b9d5686d96c342ece95c10433838360405161082393929190611911565b60405180910390a15050565b60006004600083815260200190815260200160002060009054906101000a900460ff169050919050565b600073ffffffffffffffffffffffffffffffffffffffff168473ffffffffffffffffffffffffffffffffffffffff16036108bf576040517f6665132200000000000000000000000000000000000000000000000000000000815260040160405180910390fd5b600083036108f9576040517f1f2a200500000000000000000000000000000000000000000000000000000000815260040160405180910390fd5b6003600086815260200190815260200160002060009054906101000a900460ff161561095c57846040517f2652130000000000000000000000000000000000000000000000000000000000815260040161095391906112a7565b60405180910390fd5b600260009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff16630fde6e5560005484848989896040516020016109b49392919061194f565b604051602081830303815290604052611a5656fefe5b6040518563ffffffff1660e01b81526004016109e99493929190611619565b602060405180830381865afa158015610a06573d6000803e3d6000fd5b505050506040513d601f19601f82011682018060405250810190610a2a919061168a565b610a735784848484846040517f9ab2c8bf000000000000000000000000000000000000000000000000000000008152600401610a6a9594939291906116b7565b60405180910390fd5b600160009054906101000a900473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff166340c10f1985856040518363ffffffff1660e01b8152600401610ad09291906118e8565b600060405180830381600087803b158015610aea57600080fd5b505af1158015610afe573d6000803e3d6000fd5b5050505060016003600087815260200190815260200160002060006101000a81548160ff021916908315150217905550847f1a49076e0e8c733171c5360d78c9ae8e19fef5a0720b926e72f78b7cc37618cd8585604051610b609291906118e8565b60405180910390a25050505050565b60006003600083815260200190815260200160002060009054906101000a900460ff169050919050565b6000600160009054906101000a900473ffffffffffffffffffffffffffffffffffffffff16905090565b60006005600083815260200190815260200160002060009054906101000a900460ff169050919050565b6000600260009054906101000a900473ffffffffffffffffffffffffffffffffffffffff16905090565b6000604051905090565b600080fd5b600080fd5b6000819050919050565b610c3e81610c2b565b8114610c4957600080fd5b50565b600081359050610c5b81610c35565b92915050565b600073ffffffffffffffffffffffffffffffffffffffff82169050919050565b6000610c8c82610c61565b9050919050565b610c9c81610c81565b8114610ca757600080fd5b50565b600081359050610cb981610c93565b92915050565b600080fd5b600080fd5b6000601f19601f8301169050919050565b7f4e487b7100000000000000000000000000000000000000000000000000000000600052604160045260246000fd5b610d1282610cc9565b810181811067ffffffffffffffff82111715610d3157610d30610cda565b5b80604052505050565b6000610d44610c17565b9050610d508282610d09565b919050565b600067ffffffffffffffff821115610d7057610d6f610cda565b5b610d7982610cc9565b9050602081019050919050565b82818337600083830152505050565b6000610da8610da384610d55565b610d3a565b905082815260208101848484011115610dc457610dc3610cc4565b5b610dcf848285610d86565b509392505050565b600082601f830112610dec57610deb610cbf565b5b8135610dfc848260208601610d95565b91505092915050565b6000819050919050565b610e1881610e05565b8114610e2357600080fd5b50565b600081359050610e3581610e0f565b92915050565b600067ffffffffffffffff821115610e5657610e55610cda565b5b602082029050602081019050919050565b600080fd5b6000610e7f610e7a84610e3b565b610d3a565b90508083825260208201905060208402830185811115610ea257610ea1610e67565b5b835b81811015610ecb5780610eb78882610c4c565b845260208401935050602081019050610ea4565b5050509392505050565b600082601f830112610eea57610ee9610cbf565b5b8135610efa848260208601610e6c565b91505092915050565b600067ffffffffffffffff821115610f1e57610f1d610cda565b5b602082029050602081019050919050565b600061ffff82169050919050565b610f4681610f2f565b8114610f5157600080fd5b50565b600081359050610f6381610f3d565b92915050565b6000610f7c610f7784610f03565b610d3a565b90508083825260208201905060208402830185811115610f9f57610f9e610e67565b5b835b81811015610fc85780610fb48882610f54565b845260208401935050602081019050610fa1565b5050509392505050565b600082601f830112610fe757610fe6610cbf565b5b8135610ff7848260208601610f69565b91505092915050565b600080600080600080600080610100898b03121561102157611020610c21565b5b600061102f8b828c01610c4c565b98505060206110408b828c01610caa565b975050604089013567ffffffffffffffff81111561106157611060610c26565b5b61106d8b828c01610dd7565b965050606061107e8b828c01610e26565b955050608089013567ffffffffffffffff81111561109f5761109e610c26565b5b6110ab8b828c01610ed5565b94505060a089013567ffffffffffffffff8111156110cc576110cb610c26565b5b6110d88b828c01610fd2565b93505060c06110e98b828c01610e26565b92505060e06110fa8b828c01610e26565b9150509295985092959890939650565b61111381610e05565b82525050565b600060208201905061112e600083018461110a565b92915050565b6000806040838503121561114b5761114a610c21565b5b600061115985828601610e26565b925050602083013567ffffffffffffffff81111561117a57611179610c26565b5b61118685828601610dd7565b9150509250929050565b6000602082840312156111a6576111a5610c21565b5b60006111b484828501610c4c565b91505092915050565b60008115159050919050565b6111d2816111bd565b82525050565b60006020820190506111ed60008301846111c9565b92915050565b600080600080600060a0868803121561120f5761120e610c21565b5b600061121d88828901610c4c565b955050602061122e88828901610caa565b945050604061123f88828901610e26565b935050606061125088828901610e26565b925050608061126188828901610e26565b9150509295509295909350565b61127781610c81565b82525050565b6000602082019050611292600083018461126e565b92915050565b6112a181610c2b565b82525050565b60006020820190506112bc6000830184611298565b92915050565b7f4e487b7100000000000000000000000000000000000000000000000000000000600052603260045260246000fd5b7f4e487b7100000000000000000000000000000000000000000000000000000000600052601160045260246000fd5b600061132b82610f2f565b915061ffff820361133f5761133e6112f1565b5b600182019050919050565b6000819050919050565b61136561136082610c2b565b61134a565b82525050565b60008160601b9050919050565b60006113838261136b565b9050919050565b600061139582611378565b9050919050565b6113ad6113a882610c81565b61138a565b82525050565b600081519050919050565b600081905092915050565b60005b838110156113e75780820151818401526020810190506113cc565b60008484015250505050565b60006113fe826113b3565b61140881856113be565b93506114188185602086016113c9565b80840191505092915050565b6000819050919050565b61143f61143a82610e05565b611424565b82525050565b600081519050919050565b600081905092915050565b6000819050602082019050919050565b61147481610c2b565b82525050565b6000611486838361146b565b60208301905092915050565b6000602082019050919050565b60006114aa82611445565b6114b48185611450565b93506114bf8361145b565b8060005b838110156114f05781516114d7888261147a565b97506114e283611492565b9250506001810190506114c3565b5085935050505092915050565b600081519050919050565b600081905092915050565b6000819050602082019050919050565b61152c81610f2f565b82525050565b600061153e8383611523565b60208301905092915050565b6000602082019050919050565b6000611562826114fd565b61156c8185611508565b935061157783611513565b8060005b838110156115a857815161158f8882611532565b975061159a8361154a565b92505060018101905061157b565b5085935050505092915050565b60006115c18289611354565b6020820191506115d1828861139c565b6014820191506115e182876113f3565b91506115ed828661142e565b6020820191506115fd828561149f565b91506116098284611557565b9150819050979650505050505050565b600060808201905061162e600083018761110a565b61163b602083018661110a565b611648604083018561110a565b6116556060830184611298565b95945050505050565b611667816111bd565b811461167257600080fd5b50565b6000815190506116848161165e565b92915050565b6000602082840312156116a05761169f610c21565b5b60006116ae84828501611675565b91505092915050565b600060a0820190506116cc6000830188611298565b6116d9602083018761126e565b6116e6604083018661110a565b6116f3606083018561110a565b611700608083018461110a565b9695505050505050565b600082825260208201905092915050565b6000611726826113b3565b611730818561170a565b93506117408185602086016113c9565b61174981610cc9565b840191505092915050565b600082825260208201905092915050565b61176e81610c2b565b82525050565b60006117808383611765565b60208301905092915050565b600061179782611445565b6117a18185611754565b93506117ac8361145b565b8060005b838110156117dd5781516117c48882611774565b97506117cf83611492565b9250506001810190506117b0565b5085935050505092915050565b600082825260208201905092915050565b61180481610f2f565b82525050565b600061181683836117fb565b60208301905092915050565b600061182d826114fd565b61183781856117ea565b935061184283611513565b8060005b8381101561187357815161185a888261180a565b97506118658361154a565b925050600181019050611846565b5085935050505092915050565b600060a082019050611895600083018861126e565b81810360208301526118a7818761171b565b90506118b6604083018661110a565b81810360608301526118c8818561178c565b905081810360808301526118dc8184611822565b90509695505050505050565b60006040820190506118fd600083018561126e565b61190a602083018461110a565b9392505050565b6000606082019050611926600083018661126e565b611933602083018561110a565b8181036040830152611945818461171b565b9050949350505050565b600061195b8286611354565b60208201915061196b828561139c565b60148201915061197b828461142e565b60208201915081905094935050505056fe5b600436106119b75760003560e01c63f698da2514156119b7576119ae6119bc565b60005260206000f35b600080fd5b6040517f5445454e65742d4254432d42726964676501000000000000000000000000000081524681601201523060601b81603201526046902090565b611a006119bc565b60405190815291826020015280518083604001828460200160045afa156119b7579050604001902090565b610591907fdb9975e58fbd4b5dd2d999da331e0a1815a928e6770e2953148a164088551dde906119f8565b6109ca907fdaf0b3c5710379609eb5495f1ecd348cb28167711b73609fe565a72734550354906119f856a2646970667358221220aa9a71b52771ece7d4d1bc19a96cb3e1c9f633ac3cc22fef4b13667689ed5a0464736f6c6343000818003360806040523480156200001157600080fd5b50604051620019c2380380620019c2833981810160405281019062000037919062000288565b806040518060400160405280601681526020017f5445454e6574207772617070656420426974636f696e000000000000000000008152506040518060400160405280600581526020017f54574254430000000000000000000000000000000000000000000000000000008152508160039081620000b5919062000534565b508060049081620000c7919062000534565b505050600073ffffffffffffffffffffffffffffffffffffffff168173ffffffffffffffffffffffffffffffffffffffff16036200013f5760006040517f1e4fbdf70000000000000000000000000000000000000000000000000000000081526004016200013691906200062c565b60405180910390fd5b62000150816200015860201b60201c565b505062000649565b6000600560009054906101000a900473ffffffffffffffffffffffffffffffffffffffff16905081600560006101000a81548173ffffffffffffffffffffffffffffffffffffffff021916908373ffffffffffffffffffffffffffffffffffffffff1602179055508173ffffffffffffffffffffffffffffffffffffffff168173ffffffffffffffffffffffffffffffffffffffff167f8be0079c531659141344cd1fd0a4f28419497f9722a3daafe3b4186f6b6457e060405160405180910390a35050565b600080fd5b600073ffffffffffffffffffffffffffffffffffffffff82169050919050565b6000620002508262000223565b9050919050565b620002628162000243565b81146200026e57600080fd5b50565b600081519050620002828162000257565b92915050565b600060208284031215620002a157620002a06200021e565b5b6000620002b18482850162000271565b91505092915050565b600081519050919050565b7f4e487b7100000000000000000000000000000000000000000000000000000000600052604160045260246000fd5b7f4e487b7100000000000000000000000000000000000000000000000000000000600052602260045260246000fd5b600060028204905060018216806200033c57607f821691505b602082108103620003525762000351620002f4565b5b50919050565b60008190508160005260206000209050919050565b60006020601f8301049050919050565b600082821b905092915050565b600060088302620003bc7fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff826200037d565b620003c886836200037d565b95508019841693508086168417925050509392505050565b6000819050919050565b6000819050919050565b6000620004156200040f6200040984620003e0565b620003ea565b620003e0565b9050919050565b6000819050919050565b6200043183620003f4565b6200044962000440826200041c565b8484546200038a565b825550505050565b600090565b6200046062000451565b6200046d81848462000426565b505050565b5b8181101562000495576200048960008262000456565b60018101905062000473565b5050565b601f821115620004e457620004ae8162000358565b620004b9846200036d565b81016020851015620004c9578190505b620004e1620004d8856200036d565b83018262000472565b50505b505050565b600082821c905092915050565b60006200050960001984600802620004e9565b1980831691505092915050565b6000620005248383620004f6565b9150826002028217905092915050565b6200053f82620002ba565b67ffffffffffffffff8111156200055b576200055a620002c5565b5b62000567825462000323565b6200057482828562000499565b600060209050601f831160018114620005ac576000841562000597578287015190505b620005a3858262000516565b86555062000613565b601f198416620005bc8662000358565b60005b82811015620005e657848901518255600182019150602085019450602081019050620005bf565b8683101562000606578489015162000602601f891682620004f6565b8355505b6001600288020188555050505b505050505050565b620006268162000243565b82525050565b60006020820190506200064360008301846200061b565b92915050565b61136980620006596000396000f3fe608060405234801561001057600080fd5b50600436106101005760003560e01c806370a082311161009757806395d89b411161006657806395d89b4114610289578063a9059cbb146102a7578063dd62ed3e146102d7578063f2fde38b1461030757610100565b806370a0823114610215578063715018a61461024557806379cc67901461024f5780638da5cb5b1461026b57610100565b8063313ce567116100d3578063313ce567146101a157806340c10f19146101bf57806342966c68146101db5780635e8f1d94146101f757610100565b806306fdde0314610105578063095ea7b31461012357806318160ddd1461015357806323b872dd14610171575b600080fd5b61010d610323565b60405161011a9190610f90565b60405180910390f35b61013d6004803603810190610138919061104b565b6103b5565b60405161014a91906110a6565b60405180910390f35b61015b6103d8565b60405161016891906110d0565b60405180910390f35b61018b600480360381019061018691906110eb565b6103e2565b60405161019891906110a6565b60405180910390f35b6101a9610411565b6040516101b6919061115a565b60405180910390f35b6101d960048036038101906101d4919061104b565b61041a565b005b6101f560048036038101906101f09190611175565b61048a565b005b6101ff61049e565b60405161020c91906110d0565b60405180910390f35b61022f600480360381019061022a91906111a2565b6104a9565b60405161023c91906110d0565b60405180910390f35b61024d6104f1565b005b6102696004803603810190610264919061104b565b610505565b005b610273610525565b60405161028091906111de565b60405180910390f35b61029161054f565b60405161029e9190610f90565b60405180910390f35b6102c160048036038101906102bc919061104b565b6105e1565b6040516102ce91906110a6565b60405180910390f35b6102f160048036038101906102ec91906111f9565b610604565b6040516102fe91906110d0565b60405180910390f35b610321600480360381019061031c91906111a2565b61068b565b005b60606003805461033290611268565b80601f016020809104026020016040519081016040528092919081815260200182805461035e90611268565b80156103ab5780601f10610380576101008083540402835291602001916103ab565b820191906000526020600020905b81548152906001019060200180831161038e57829003601f168201915b5050505050905090565b6000806103c0610711565b90506103cd818585610719565b600191505092915050565b6000600254905090565b6000806103ed610711565b90506103fa85828561072b565b6104058585856107bf565b60019150509392505050565b60006008905090565b6104226108b3565b61042c828261093a565b660775f05a07400061043c6103d8565b11156104865761044a6103d8565b6040517ff602b06a00000000000000000000000000000000000000000000000000000000815260040161047d91906110d0565b60405180910390fd5b5050565b61049b610495610711565b826109bc565b50565b660775f05a07400081565b60008060008373ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff168152602001908152602001600020549050919050565b6104f96108b3565b6105036000610a3e565b565b61051782610511610711565b8361072b565b61052182826109bc565b5050565b6000600560009054906101000a900473ffffffffffffffffffffffffffffffffffffffff16905090565b60606004805461055e90611268565b80601f016020809104026020016040519081016040528092919081815260200182805461058a90611268565b80156105d75780601f106105ac576101008083540402835291602001916105d7565b820191906000526020600020905b8154815290600101906020018083116105ba57829003601f168201915b5050505050905090565b6000806105ec610711565b90506105f98185856107bf565b600191505092915050565b6000600160008473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff16815260200190815260200160002060008373ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff16815260200190815260200160002054905092915050565b6106936108b3565b600073ffffffffffffffffffffffffffffffffffffffff168173ffffffffffffffffffffffffffffffffffffffff16036107055760006040517f1e4fbdf70000000000000000000000000000000000000000000000000000000081526004016106fc91906111de565b60405180910390fd5b61070e81610a3e565b50565b600033905090565b6107268383836001610b04565b505050565b60006107378484610604565b90507fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff81146107b957818110156107a9578281836040517ffb8f41b20000000000000000000000000000000000000000000000000000000081526004016107a093929190611299565b60405180910390fd5b6107b884848484036000610b04565b5b50505050565b600073ffffffffffffffffffffffffffffffffffffffff168373ffffffffffffffffffffffffffffffffffffffff16036108315760006040517f96c6fd1e00000000000000000000000000000000000000000000000000000000815260040161082891906111de565b60405180910390fd5b600073ffffffffffffffffffffffffffffffffffffffff168273ffffffffffffffffffffffffffffffffffffffff16036108a35760006040517fec442f0500000000000000000000000000000000000000000000000000000000815260040161089a91906111de565b60405180910390fd5b6108ae838383610cdb565b505050565b6108bb610711565b73ffffffffffffffffffffffffffffffffffffffff166108d9610525565b73ffffffffffffffffffffffffffffffffffffffff1614610938576108fc610711565b6040517f118cdaa700000000000000000000000000000000000000000000000000000000815260040161092f91906111de565b60405180910390fd5b565b600073ffffffffffffffffffffffffffffffffffffffff168273ffffffffffffffffffffffffffffffffffffffff16036109ac5760006040517fec442f050000000000000000000000000000000000000000000000000000000081526004016109a391906111de565b60405180910390fd5b6109b860008383610cdb565b5050565b600073ffffffffffffffffffffffffffffffffffffffff168273ffffffffffffffffffffffffffffffffffffffff1603610a2e5760006040517f96c6fd1e000000000000000000000000000000000000000000000000000000008152600401610a2591906111de565b60405180910390fd5b610a3a82600083610cdb565b5050565b6000600560009054906101000a900473ffffffffffffffffffffffffffffffffffffffff16905081600560006101000a81548173ffffffffffffffffffffffffffffffffffffffff021916908373ffffffffffffffffffffffffffffffffffffffff1602179055508173ffffffffffffffffffffffffffffffffffffffff168173ffffffffffffffffffffffffffffffffffffffff167f8be0079c531659141344cd1fd0a4f28419497f9722a3daafe3b4186f6b6457e060405160405180910390a35050565b600073ffffffffffffffffffffffffffffffffffffffff168473ffffffffffffffffffffffffffffffffffffffff1603610b765760006040517fe602df05000000000000000000000000000000000000000000000000000000008152600401610b6d91906111de565b60405180910390fd5b600073ffffffffffffffffffffffffffffffffffffffff168373ffffffffffffffffffffffffffffffffffffffff1603610be85760006040517f94280d62000000000000000000000000000000000000000000000000000000008152600401610bdf91906111de565b60405180910390fd5b81600160008673ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff16815260200190815260200160002060008573ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff168152602001908152602001600020819055508015610cd5578273ffffffffffffffffffffffffffffffffffffffff168473ffffffffffffffffffffffffffffffffffffffff167f8c5be1e5ebec7d5bd14f71427d1e84f3dd0314c0f7b2291e5b200ac8c7c3b92584604051610ccc91906110d0565b60405180910390a35b50505050565b600073ffffffffffffffffffffffffffffffffffffffff168373ffffffffffffffffffffffffffffffffffffffff1603610d2d578060026000828254610d2191906112ff565b92505081905550610e00565b60008060008573ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff16815260200190815260200160002054905081811015610db9578381836040517fe450d38c000000000000000000000000000000000000000000000000000000008152600401610db093929190611299565b60405180910390fd5b8181036000808673ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff16815260200190815260200160002081905550505b600073ffffffffffffffffffffffffffffffffffffffff168273ffffffffffffffffffffffffffffffffffffffff1603610e495780600260008282540392505081905550610e96565b806000808473ffffffffffffffffffffffffffffffffffffffff1673ffffffffffffffffffffffffffffffffffffffff168152602001908152602001600020600082825401925050819055505b8173ffffffffffffffffffffffffffffffffffffffff168373ffffffffffffffffffffffffffffffffffffffff167fddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef83604051610ef391906110d0565b60405180910390a3505050565b600081519050919050565b600082825260208201905092915050565b60005b83811015610f3a578082015181840152602081019050610f1f565b60008484015250505050565b6000601f19601f8301169050919050565b6000610f6282610f00565b610f6c8185610f0b565b9350610f7c818560208601610f1c565b610f8581610f46565b840191505092915050565b60006020820190508181036000830152610faa8184610f57565b905092915050565b600080fd5b600073ffffffffffffffffffffffffffffffffffffffff82169050919050565b6000610fe282610fb7565b9050919050565b610ff281610fd7565b8114610ffd57600080fd5b50565b60008135905061100f81610fe9565b92915050565b6000819050919050565b61102881611015565b811461103357600080fd5b50565b6000813590506110458161101f565b92915050565b6000806040838503121561106257611061610fb2565b5b600061107085828601611000565b925050602061108185828601611036565b9150509250929050565b60008115159050919050565b6110a08161108b565b82525050565b60006020820190506110bb6000830184611097565b92915050565b6110ca81611015565b82525050565b60006020820190506110e560008301846110c1565b92915050565b60008060006060848603121561110457611103610fb2565b5b600061111286828701611000565b935050602061112386828701611000565b925050604061113486828701611036565b9150509250925092565b600060ff82169050919050565b6111548161113e565b82525050565b600060208201905061116f600083018461114b565b92915050565b60006020828403121561118b5761118a610fb2565b5b600061119984828501611036565b91505092915050565b6000602082840312156111b8576111b7610fb2565b5b60006111c684828501611000565b91505092915050565b6111d881610fd7565b82525050565b60006020820190506111f360008301846111cf565b92915050565b600080604083850312156112105761120f610fb2565b5b600061121e85828601611000565b925050602061122f85828601611000565b9150509250929050565b7f4e487b7100000000000000000000000000000000000000000000000000000000600052602260045260246000fd5b6000600282049050600182168061128057607f821691505b60208210810361129357611292611239565b5b50919050565b60006060820190506112ae60008301866111cf565b6112bb60208301856110c1565b6112c860408301846110c1565b949350505050565b7f4e487b7100000000000000000000000000000000000000000000000000000000600052601160045260246000fd5b600061130a82611015565b915061131583611015565b925082820190508082111561132d5761132c6112d0565b5b9291505056fea2646970667358221220504476fede93226437d3b11bb58ead86358659b4af71b44c02e326e137d37e1064736f6c63430008180033608060405234801561001057600080fd5b50610ab6806100206000396000f3fe608060405234801561001057600080fd5b506004361061002b5760003560e01c80630fde6e5514610030575b600080fd5b61004a60048036038101906100459190610622565b610060565b60405161005791906106a4565b60405180910390f35b60007ffffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f851015806100b157507ffffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f8410155b806100dc57507ffffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd03641418310155b156100ea5760009050610276565b6000806100f68661027e565b915091508061010a57600092505050610276565b600061011d8760001b8960001b876102ea565b905060007ffffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364141806101505761014f6106bf565b5b8988097ffffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd036414161017e919061071d565b60001b905060007ffffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364141806101b4576101b36106bf565b5b8a84097ffffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd03641416101e2919061071d565b60001b90506000600183601b8d60001b856040516000815260200160405260405161021094939291906107b2565b6020604051602081039080840390855afa158015610232573d6000803e3d6000fd5b5050506020604051035190508573ffffffffffffffffffffffffffffffffffffffff168173ffffffffffffffffffffffffffffffffffffffff161496505050505050505b949350505050565b60008060008061028d856103c2565b91509150806102a4576000809350935050506102e5565b60008560001b8360001b6040516020016102bf929190610818565b6040516020818303038152906040528051906020012090508060001c6001945094505050505b915091565b6000807f7bb52d7a9fef58323eb1bf7a407db382d2f3f2d81bb1224f49fe518f6d48d37c60001b90507ffffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd03641416002828388888860405160200161034f959493929190610844565b60405160208183030381529060405260405161036b9190610914565b602060405180830381855afa158015610388573d6000803e3d6000fd5b5050506040513d601f19601f820116820180604052508101906103ab9190610940565b60001c6103b8919061096d565b9150509392505050565b60008060007ffffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f90506000806007905082861061040757600080945094505050506104b4565b60008380610418576104176106bf565b5b8480610427576104266106bf565b5b838680610437576104366106bf565b5b868b0908858061044a576104496106bf565b5b8680610459576104586106bf565b5b8a8b098a09089050610484816004600187610474919061099e565b61047e91906109d2565b866104b9565b905060008060018316146104a357818561049e919061071d565b6104a5565b815b90508060019650965050505050505b915091565b60008082036104fd576040517f08c379a00000000000000000000000000000000000000000000000000000000081526004016104f490610a60565b60405180910390fd5b6000840361050e57600090506105aa565b6000830361051f57600190506105aa565b60006001905060007f800000000000000000000000000000000000000000000000000000000000000090505b60008111156105a457838186161515870a85848509099150836002820486161515870a85848509099150836004820486161515870a85848509099150836008820486161515870a8584850909915060108104905061054b565b81925050505b9392505050565b600080fd5b6000819050919050565b6105c9816105b6565b81146105d457600080fd5b50565b6000813590506105e6816105c0565b92915050565b6000819050919050565b6105ff816105ec565b811461060a57600080fd5b50565b60008135905061061c816105f6565b92915050565b6000806000806080858703121561063c5761063b6105b1565b5b600061064a878288016105d7565b945050602061065b878288016105d7565b935050604061066c878288016105d7565b925050606061067d8782880161060d565b91505092959194509250565b60008115159050919050565b61069e81610689565b82525050565b60006020820190506106b96000830184610695565b92915050565b7f4e487b7100000000000000000000000000000000000000000000000000000000600052601260045260246000fd5b7f4e487b7100000000000000000000000000000000000000000000000000000000600052601160045260246000fd5b6000610728826105b6565b9150610733836105b6565b925082820390508181111561074b5761074a6106ee565b5b92915050565b61075a816105ec565b82525050565b6000819050919050565b600060ff82169050919050565b6000819050919050565b600061079c61079761079284610760565b610777565b61076a565b9050919050565b6107ac81610781565b82525050565b60006080820190506107c76000830187610751565b6107d460208301866107a3565b6107e16040830185610751565b6107ee6060830184610751565b95945050505050565b6000819050919050565b61081261080d826105ec565b6107f7565b82525050565b60006108248285610801565b6020820191506108348284610801565b6020820191508190509392505050565b60006108508288610801565b6020820191506108608287610801565b6020820191506108708286610801565b6020820191506108808285610801565b6020820191506108908284610801565b6020820191508190509695505050505050565b600081519050919050565b600081905092915050565b60005b838110156108d75780820151818401526020810190506108bc565b60008484015250505050565b60006108ee826108a3565b6108f881856108ae565b93506109088185602086016108b9565b80840191505092915050565b600061092082846108e3565b915081905092915050565b60008151905061093a816105f6565b92915050565b600060208284031215610956576109556105b1565b5b60006109648482850161092b565b91505092915050565b6000610978826105b6565b9150610983836105b6565b925082610993576109926106bf565b5b828206905092915050565b60006109a9826105b6565b91506109b4836105b6565b92508282019050808211156109cc576109cb6106ee565b5b92915050565b60006109dd826105b6565b91506109e8836105b6565b9250826109f8576109f76106bf565b5b828204905092915050565b600082825260208201905092915050565b7f4d6f64756c7573206973207a65726f0000000000000000000000000000000000600082015250565b6000610a4a600f83610a03565b9150610a5582610a14565b602082019050919050565b60006020820190508181036000830152610a7981610a3d565b905091905056fea2646970667358221220b20635f4ea1b667a9f5cb231f9c58cedbc6551db34ab43981b8ec9e459fdd74764736f6c63430008180033",
 }
 
 // TEENetBtcBridgeABI is the input ABI used to generate the binding from.
@@ -233,6 +233,37 @@ func (_TEENetBtcBridge *TEENetBtcBridgeCallerSession) Bip340() (common.Address,
 	return _TEENetBtcBridge.Contract.Bip340(&_TEENetBtcBridge.CallOpts)
 }
 
+// DomainSeparator is a free data retrieval call binding the contract method 0xf698da25.
+//
+// Solidity: function domainSeparator() view returns(bytes32)
+func (_TEENetBtcBridge *TEENetBtcBridgeCaller) DomainSeparator(opts *bind.CallOpts) ([32]byte, error) {
+	var out []interface{}
+	err := _TEENetBtcBridge.contract.Call(opts, &out, "domainSeparator")
+
+	if err != nil {
+		return *new([32]byte), err
+	}
+
+	out0 := *abi.ConvertType(out[0], new([32]byte)).(*[32]byte)
+
+	return out0, err
+
+}
+
+// DomainSeparator is a free data retrieval call binding the contract method 0xf698da25.
+//
+// Solidity: function domainSeparator() view returns(bytes32)
+func (_TEENetBtcBridge *TEENetBtcBridgeSession) DomainSeparator() ([32]byte, error) {
+	return _TEENetBtcBridge.Contract.DomainSeparator(&_TEENetBtcBridge.CallOpts)
+}
+
+// DomainSeparator is a free data retrieval call binding the contract method 0xf698da25.
+//
+// Solidity: function domainSeparator() view returns(bytes32)
+func (_TEENetBtcBridge *TEENetBtcBridgeCallerSession) DomainSeparator() ([32]byte, error) {
+	return _TEENetBtcBridge.Contract.DomainSeparator(&_TEENetBtcBridge.CallOpts)
+}
+
 // IsMinted is a free data retrieval call binding the contract method 0x9e4c0be3.
 //
 // Solidity: function isMinted(bytes32 btcTxId) view returns(bool)
@@ -7,4 +7,11 @@ type EthermanConfig struct {
 
 	BridgeContractAddress common.Address
 	TWBTCContractAddress  common.Address
+
+	// Mint and prepare messages are domain separated (see common.SigningDomain).
+	// Only set it for bridge contracts deployed before domainSeparator() that verify the legacy messages.
+	LegacyMessages bool
+
+	// Fees of the txs sent by the bridge (see GasPolicy).
+	Gas GasPolicy
 }
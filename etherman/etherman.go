@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -116,62 +117,141 @@ func (etherman *Etherman) GetEventLogs(blockNum *big.Int) (
 	[]RedeemPreparedEvent,
 	error,
 ) {
-	logs, err := etherman.ethClient.FilterLogs(context.Background(), ethereum.FilterQuery{
+	return etherman.filterEventLogs(ethereum.FilterQuery{
 		FromBlock: blockNum,
 		ToBlock:   blockNum,
 		Addresses: []ethcommon.Address{etherman.cfg.BridgeContractAddress},
 	})
+}
+
+// Same as GetEventLogs, for the blocks [fromBlock, toBlock].
+// Each event keeps its log (Raw), eg. block number and log index.
+func (etherman *Etherman) GetEventLogsInRange(fromBlock *big.Int, toBlock *big.Int) (
+	[]MintedEvent,
+	[]RedeemRequestedEvent,
+	[]RedeemPreparedEvent,
+	error,
+) {
+	return etherman.filterEventLogs(ethereum.FilterQuery{
+		FromBlock: fromBlock,
+		ToBlock:   toBlock,
+		Addresses: []ethcommon.Address{etherman.cfg.BridgeContractAddress},
+	})
+}
+
+// Same as GetEventLogs, the block is given by hash,
+// so the events are those of that very block even if a reorg happens meanwhile.
+func (etherman *Etherman) GetEventLogsByBlockHash(blockHash ethcommon.Hash) (
+	[]MintedEvent,
+	[]RedeemRequestedEvent,
+	[]RedeemPreparedEvent,
+	error,
+) {
+	return etherman.filterEventLogs(ethereum.FilterQuery{
+		BlockHash: &blockHash,
+		Addresses: []ethcommon.Address{etherman.cfg.BridgeContractAddress},
+	})
+}
+
+func (etherman *Etherman) filterEventLogs(query ethereum.FilterQuery) (
+	[]MintedEvent,
+	[]RedeemRequestedEvent,
+	[]RedeemPreparedEvent,
+	error,
+) {
+	events, err := etherman.filterBridgeEvents(query)
 	if err != nil {
 		return nil, nil, nil, err
 	}
 
-	if len(logs) == 0 {
+	if len(events) == 0 {
 		return nil, nil, nil, nil
 	}
 
-	bridgeABI, err := abi.JSON(strings.NewReader(bridge.TEENetBtcBridgeABI))
+	minted := make([]MintedEvent, 0, len(events))
+	redeemRequested := make([]RedeemRequestedEvent, 0, len(events))
+	redeemPrepared := make([]RedeemPreparedEvent, 0, len(events))
+
+	for _, ev := range events {
+		switch {
+		case ev.Minted != nil:
+			minted = append(minted, *ev.Minted)
+		case ev.Requested != nil:
+			redeemRequested = append(redeemRequested, *ev.Requested)
+		case ev.Prepared != nil:
+			redeemPrepared = append(redeemPrepared, *ev.Prepared)
+		}
+	}
+
+	return minted, redeemRequested, redeemPrepared, nil
+}
+
+// Bridge events of the logs matching the query, in log order.
+func (etherman *Etherman) filterBridgeEvents(query ethereum.FilterQuery) ([]BridgeEvent, error) {
+	logs, err := etherman.ethClient.FilterLogs(context.Background(), query)
 	if err != nil {
-		return nil, nil, nil, err
+		return nil, err
+	}
+
+	if len(logs) == 0 {
+		return nil, nil
 	}
 
-	minted := make([]MintedEvent, 0, len(logs))
-	redeemRequested := make([]RedeemRequestedEvent, 0, len(logs))
-	redeemPrepared := make([]RedeemPreparedEvent, 0, len(logs))
+	bridgeABI, err := abi.JSON(strings.NewReader(bridge.TEENetBtcBridgeABI))
+	if err != nil {
+		return nil, err
+	}
 
+	events := make([]BridgeEvent, 0, len(logs))
 	for _, vlog := range logs {
-		switch vlog.Topics[0] {
-		case MintedSignatureHash:
-			ev := new(MintedEvent)
-			err = bridgeABI.UnpackIntoInterface(ev, "Minted", vlog.Data)
-			if err != nil {
-				return nil, nil, nil, err
-			}
-			copy(ev.BtcTxId[:], vlog.Topics[1].Bytes())
-			copy(ev.TxHash[:], vlog.TxHash.Bytes())
-			minted = append(minted, *ev)
-		case RedeemRequestedSignatureHash:
-			ev := new(RedeemRequestedEvent)
-			err = bridgeABI.UnpackIntoInterface(ev, "RedeemRequested", vlog.Data)
-			if err != nil {
-				return nil, nil, nil, err
-			}
-			copy(ev.TxHash[:], vlog.TxHash.Bytes())
-			redeemRequested = append(redeemRequested, *ev)
-		case RedeemPreparedSignatureHash:
-			ev := new(RedeemPreparedEvent)
-			err = bridgeABI.UnpackIntoInterface(ev, "RedeemPrepared", vlog.Data)
-			if err != nil {
-				return nil, nil, nil, err
-			}
-			copy(ev.EthTxHash[:], vlog.Topics[1].Bytes())
-			copy(ev.TxHash[:], vlog.TxHash.Bytes())
-			redeemPrepared = append(redeemPrepared, *ev)
-		default:
-			return nil, nil, nil, fmt.Errorf("unknown event: %+v", vlog.Topics[0])
+		ev, err := decodeBridgeEvent(&bridgeABI, vlog)
+		if err != nil {
+			return nil, err
 		}
+		events = append(events, *ev)
 	}
 
-	return minted, redeemRequested, redeemPrepared, nil
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Before(&events[j])
+	})
+
+	return events, nil
+}
+
+func decodeBridgeEvent(bridgeABI *abi.ABI, vlog types.Log) (*BridgeEvent, error) {
+	switch vlog.Topics[0] {
+	case MintedSignatureHash:
+		ev := new(MintedEvent)
+		err := bridgeABI.UnpackIntoInterface(ev, "Minted", vlog.Data)
+		if err != nil {
+			return nil, err
+		}
+		copy(ev.BtcTxId[:], vlog.Topics[1].Bytes())
+		copy(ev.TxHash[:], vlog.TxHash.Bytes())
+		ev.Raw = vlog
+		return &BridgeEvent{Minted: ev}, nil
+	case RedeemRequestedSignatureHash:
+		ev := new(RedeemRequestedEvent)
+		err := bridgeABI.UnpackIntoInterface(ev, "RedeemRequested", vlog.Data)
+		if err != nil {
+			return nil, err
+		}
+		copy(ev.TxHash[:], vlog.TxHash.Bytes())
+		ev.Raw = vlog
+		return &BridgeEvent{Requested: ev}, nil
+	case RedeemPreparedSignatureHash:
+		ev := new(RedeemPreparedEvent)
+		err := bridgeABI.UnpackIntoInterface(ev, "RedeemPrepared", vlog.Data)
+		if err != nil {
+			return nil, err
+		}
+		copy(ev.EthTxHash[:], vlog.Topics[1].Bytes())
+		copy(ev.TxHash[:], vlog.TxHash.Bytes())
+		ev.Raw = vlog
+		return &BridgeEvent{Prepared: ev}, nil
+	default:
+		return nil, fmt.Errorf("unknown event: %+v", vlog.Topics[0])
+	}
 }
 
 // !!! Bridge initiates this tx !!!
@@ -191,6 +271,9 @@ func (etherman *Etherman) Mint(params *MintParams) (*types.Transaction, error) {
 		return nil, err
 	}
 	etherman.auth.Nonce = new(big.Int).SetUint64(nonce)
+	if err := etherman.setAuthFees(); err != nil {
+		return nil, err
+	}
 
 	return contract.Mint(
 		etherman.auth,
@@ -238,6 +321,9 @@ func (etherman *Etherman) RedeemPrepare(params *PrepareParams) (*types.Transacti
 		return nil, err
 	}
 	etherman.auth.Nonce = new(big.Int).SetUint64(nonce)
+	if err := etherman.setAuthFees(); err != nil {
+		return nil, err
+	}
 
 	return contract.RedeemPrepare(
 		etherman.auth,
@@ -319,6 +405,69 @@ func (etherman *Etherman) GetPublicKey() (*big.Int, error) {
 	return pk, nil
 }
 
+// SigningDomain of the bridge contract that mint and prepare messages are signed for,
+// nil if the contract verifies legacy messages. See EthermanConfig.LegacyMessages
+func (etherman *Etherman) SigningDomain() (*common.SigningDomain, error) {
+	if etherman.cfg.LegacyMessages {
+		return nil, nil
+	}
+
+	chainID, err := etherman.ethClient.ChainID(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	return common.NewSigningDomain(
+		common.DEPOSIT_CHAIN_TYPE_EVM,
+		chainID,
+		etherman.cfg.BridgeContractAddress.Bytes(),
+	), nil
+}
+
+// Separator exposed by the bridge contract, nil if the contract has none (legacy messages).
+func (etherman *Etherman) contractDomainSeparator() (*ethcommon.Hash, error) {
+	contract, err := etherman.getBridgeContract()
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := contract.DomainSeparator(nil)
+	if err != nil {
+		// legacy contracts revert on the unknown selector
+		if strings.Contains(err.Error(), "execution reverted") {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	separator := ethcommon.Hash(out)
+	return &separator, nil
+}
+
+// CheckSigningDomain checks EthermanConfig.LegacyMessages against the bridge contract,
+// a mismatch makes every mint and prepare signature rejected by the contract.
+func (etherman *Etherman) CheckSigningDomain() error {
+	contractSeparator, err := etherman.contractDomainSeparator()
+	if err != nil {
+		return fmt.Errorf("failed to get the domain separator of the bridge contract: %v", err)
+	}
+
+	domain, err := etherman.SigningDomain()
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case domain == nil && contractSeparator != nil:
+		return fmt.Errorf("bridge contract 0x%x verifies domain separated messages, disable LegacyMessages", etherman.cfg.BridgeContractAddress)
+	case domain != nil && contractSeparator == nil:
+		return fmt.Errorf("bridge contract 0x%x verifies legacy messages, enable LegacyMessages only if it cannot be redeployed", etherman.cfg.BridgeContractAddress)
+	case domain != nil && *contractSeparator != domain.Separator():
+		return fmt.Errorf("domain separator mismatch: expected=0x%x, contract=0x%x", domain.Separator(), *contractSeparator)
+	}
+	return nil
+}
+
 func (etherman *Etherman) getBridgeContract() (*bridge.TEENetBtcBridge, error) {
 	contract, err := bridge.NewTEENetBtcBridge(etherman.cfg.BridgeContractAddress, etherman.ethClient)
 	if err != nil {
@@ -387,10 +536,15 @@ func (etherman *Etherman) getAuthNonce() (uint64, error) {
 	return nonce, nil
 }
 
+// Is the block (hash) on the canonical chain?
+// A block the node does not know (anymore) is not.
 func (etherman *Etherman) OnCanonicalChain(hash ethcommon.Hash) (bool, error) {
 	// get the block number of the given hash
 	header, err := etherman.ethClient.HeaderByHash(context.Background(), hash)
 	if err != nil {
+		if errors.Is(err, ethereum.NotFound) {
+			return false, nil
+		}
 		return false, err
 	}
 	blkNum := header.Number
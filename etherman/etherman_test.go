@@ -240,3 +240,32 @@ func checkRequestedEvent(t *testing.T, ev *RedeemRequestedEvent, params *Request
 	assert.Equal(t, ev.Amount, params.Amount)
 	assert.Equal(t, ev.Receiver, params.Receiver)
 }
+
+func TestCheckSigningDomain(t *testing.T) {
+	env, err := NewSimEtherman(TEST_ETH_ACCOUNTS, ss, big.NewInt(1337))
+	assert.NoError(t, err)
+	etherman := env.Etherman
+
+	// the simulated bridge contract verifies domain separated messages
+	assert.NoError(t, etherman.CheckSigningDomain())
+
+	domain, err := etherman.SigningDomain()
+	assert.NoError(t, err)
+	separator, err := etherman.contractDomainSeparator()
+	assert.NoError(t, err)
+	assert.Equal(t, domain.Separator(), *separator)
+
+	// a legacy signature is rejected by the contract
+	params := env.GenMintParams(&ParamConfig{Receiver: 1, Amount: big.NewInt(100)}, common.RandBytes32())
+	params.Domain = nil
+	hash := params.SigningHash()
+	sig, err := env.MultiSigner.Sign(hash[:])
+	assert.NoError(t, err)
+	params.Rx, params.S, err = multisig_client.ConvertSigToRS(sig)
+	assert.NoError(t, err)
+	_, err = etherman.Mint(params)
+	assert.Error(t, err)
+
+	etherman.cfg.LegacyMessages = true
+	assert.ErrorContains(t, etherman.CheckSigningDomain(), "verifies domain separated messages")
+}
@@ -0,0 +1,201 @@
+package etherman
+
+import (
+	"context"
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+const (
+	DEFAULT_BASE_FEE_MULTIPLIER = 2  // room for the base fee to double before the tx is priced out
+	DEFAULT_FEE_BUMP_PERCENT    = 10 // nodes reject a same-nonce replacement bumped by less
+)
+
+var (
+	ErrFeeCapReached = errors.New("fee cap reached, tx can not be replaced")
+	ErrTxNotPending  = errors.New("tx not pending, can not be replaced")
+)
+
+// EIP-1559 fee policy of the txs sent by the bridge (mint, redeemPrepare).
+// The zero value uses the defaults and no cap.
+type GasPolicy struct {
+	// maxFeePerGas = baseFee * BaseFeeMultiplier + maxPriorityFeePerGas (0=default)
+	BaseFeeMultiplier uint64
+
+	// Lower bound of the maxPriorityFeePerGas suggested by the node (nil=no bound)
+	MinTipCap *big.Int
+
+	// Global cap of maxFeePerGas, no tx is sent or replaced above it (nil=no cap)
+	MaxFeeCap *big.Int
+
+	// Fee bump of a same-nonce replacement, in percent (<10 = 10)
+	BumpPercent uint64
+}
+
+func (p *GasPolicy) baseFeeMultiplier() uint64 {
+	if p.BaseFeeMultiplier == 0 {
+		return DEFAULT_BASE_FEE_MULTIPLIER
+	}
+	return p.BaseFeeMultiplier
+}
+
+func (p *GasPolicy) bumpPercent() uint64 {
+	if p.BumpPercent < DEFAULT_FEE_BUMP_PERCENT {
+		return DEFAULT_FEE_BUMP_PERCENT
+	}
+	return p.BumpPercent
+}
+
+// Select (maxFeePerGas, maxPriorityFeePerGas) from the base fee of the latest block
+// and the tip suggested by the node, maxFeePerGas is capped by MaxFeeCap.
+func (p *GasPolicy) fees(baseFee *big.Int, suggestedTip *big.Int) (*big.Int, *big.Int) {
+	tipCap := new(big.Int).Set(suggestedTip)
+	if p.MinTipCap != nil && tipCap.Cmp(p.MinTipCap) < 0 {
+		tipCap.Set(p.MinTipCap)
+	}
+
+	feeCap := new(big.Int).Mul(baseFee, new(big.Int).SetUint64(p.baseFeeMultiplier()))
+	feeCap.Add(feeCap, tipCap)
+
+	if p.MaxFeeCap != nil && feeCap.Cmp(p.MaxFeeCap) > 0 {
+		feeCap.Set(p.MaxFeeCap)
+	}
+	if tipCap.Cmp(feeCap) > 0 {
+		tipCap.Set(feeCap)
+	}
+	return feeCap, tipCap
+}
+
+// Fees of a same-nonce replacement: both fees of the stuck tx bumped by BumpPercent,
+// or the current fees if they are higher.
+// Return ErrFeeCapReached if the bumped maxFeePerGas is above MaxFeeCap.
+func (p *GasPolicy) bumpedFees(
+	oldFeeCap, oldTipCap *big.Int,
+	currFeeCap, currTipCap *big.Int,
+) (*big.Int, *big.Int, error) {
+	bump := func(v *big.Int) *big.Int {
+		bumped := new(big.Int).Mul(v, new(big.Int).SetUint64(100+p.bumpPercent()))
+		bumped.Add(bumped, big.NewInt(99)) // round up, the node checks the bump strictly
+		return bumped.Div(bumped, big.NewInt(100))
+	}
+
+	feeCap := bump(oldFeeCap)
+	if feeCap.Cmp(currFeeCap) < 0 {
+		feeCap.Set(currFeeCap)
+	}
+	tipCap := bump(oldTipCap)
+	if tipCap.Cmp(currTipCap) < 0 {
+		tipCap.Set(currTipCap)
+	}
+
+	if p.MaxFeeCap != nil && feeCap.Cmp(p.MaxFeeCap) > 0 {
+		return nil, nil, ErrFeeCapReached
+	}
+	if tipCap.Cmp(feeCap) > 0 {
+		tipCap.Set(feeCap)
+	}
+	return feeCap, tipCap, nil
+}
+
+// Suggest (maxFeePerGas, maxPriorityFeePerGas) for a new tx.
+// Return nil fees if the chain has no base fee (pre-London), the legacy gas price is used then.
+func (etherman *Etherman) SuggestFees() (*big.Int, *big.Int, error) {
+	header, err := etherman.ethClient.HeaderByNumber(context.Background(), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	if header.BaseFee == nil {
+		return nil, nil, nil
+	}
+
+	tip, err := etherman.ethClient.SuggestGasTipCap(context.Background())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	feeCap, tipCap := etherman.cfg.Gas.fees(header.BaseFee, tip)
+	return feeCap, tipCap, nil
+}
+
+// Set the fees of the next tx sent by auth, the caller holds etherman.mu.
+func (etherman *Etherman) setAuthFees() error {
+	feeCap, tipCap, err := etherman.SuggestFees()
+	if err != nil {
+		return err
+	}
+	etherman.auth.GasFeeCap = feeCap
+	etherman.auth.GasTipCap = tipCap
+	return nil
+}
+
+// Replace the pending tx (txHash) sent by the bridge with the same tx at the same nonce,
+// with bumped fees so that it is mined first.
+// Return ErrTxNotPending if the node does not hold the tx in its pool (mined or dropped),
+// and ErrFeeCapReached if it can not be bumped under the fee cap.
+func (etherman *Etherman) ReplaceTx(txHash [32]byte) (*types.Transaction, error) {
+	etherman.mu.Lock()
+	defer etherman.mu.Unlock()
+
+	old, isPending, err := etherman.ethClient.TransactionByHash(context.Background(), txHash)
+	if err != nil {
+		return nil, err
+	}
+	if !isPending {
+		return nil, ErrTxNotPending
+	}
+
+	currFeeCap, currTipCap, err := etherman.SuggestFees()
+	if err != nil {
+		return nil, err
+	}
+	legacy := currFeeCap == nil
+	if legacy {
+		// pre-London, bump the gas price only
+		currFeeCap, currTipCap = old.GasFeeCap(), old.GasTipCap()
+	}
+
+	feeCap, tipCap, err := etherman.cfg.Gas.bumpedFees(old.GasFeeCap(), old.GasTipCap(), currFeeCap, currTipCap)
+	if err != nil {
+		return nil, err
+	}
+
+	chainID, err := etherman.ethClient.ChainID(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	var tx *types.Transaction
+	if legacy {
+		tx = types.NewTx(&types.LegacyTx{
+			Nonce:    old.Nonce(),
+			GasPrice: feeCap,
+			Gas:      old.Gas(),
+			To:       old.To(),
+			Value:    old.Value(),
+			Data:     old.Data(),
+		})
+	} else {
+		tx = types.NewTx(&types.DynamicFeeTx{
+			ChainID:    chainID,
+			Nonce:      old.Nonce(),
+			GasTipCap:  tipCap,
+			GasFeeCap:  feeCap,
+			Gas:        old.Gas(),
+			To:         old.To(),
+			Value:      old.Value(),
+			Data:       old.Data(),
+			AccessList: old.AccessList(),
+		})
+	}
+	signed, err := etherman.auth.Signer(etherman.auth.From, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := etherman.ethClient.SendTransaction(context.Background(), signed); err != nil {
+		return nil, err
+	}
+	return signed, nil
+}
@@ -0,0 +1,82 @@
+package etherman
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/TEENet-io/bridge-go/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGasPolicyFees(t *testing.T) {
+	p := &GasPolicy{}
+	feeCap, tipCap := p.fees(big.NewInt(100), big.NewInt(2))
+	assert.Equal(t, big.NewInt(202), feeCap)
+	assert.Equal(t, big.NewInt(2), tipCap)
+
+	// tip raised to the lower bound, fee capped
+	p = &GasPolicy{BaseFeeMultiplier: 3, MinTipCap: big.NewInt(5), MaxFeeCap: big.NewInt(250)}
+	feeCap, tipCap = p.fees(big.NewInt(100), big.NewInt(2))
+	assert.Equal(t, big.NewInt(250), feeCap)
+	assert.Equal(t, big.NewInt(5), tipCap)
+
+	// tip never above the fee cap
+	p = &GasPolicy{MaxFeeCap: big.NewInt(10)}
+	feeCap, tipCap = p.fees(big.NewInt(100), big.NewInt(20))
+	assert.Equal(t, big.NewInt(10), feeCap)
+	assert.Equal(t, big.NewInt(10), tipCap)
+}
+
+func TestGasPolicyBumpedFees(t *testing.T) {
+	p := &GasPolicy{MaxFeeCap: big.NewInt(300)}
+
+	// at least 10% above the stuck tx
+	feeCap, tipCap, err := p.bumpedFees(big.NewInt(200), big.NewInt(3), big.NewInt(150), big.NewInt(1))
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(220), feeCap)
+	assert.Equal(t, big.NewInt(4), tipCap)
+
+	// current fees if higher
+	feeCap, tipCap, err = p.bumpedFees(big.NewInt(200), big.NewInt(3), big.NewInt(260), big.NewInt(10))
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(260), feeCap)
+	assert.Equal(t, big.NewInt(10), tipCap)
+
+	// above the cap
+	_, _, err = p.bumpedFees(big.NewInt(280), big.NewInt(3), big.NewInt(150), big.NewInt(1))
+	assert.ErrorIs(t, err, ErrFeeCapReached)
+}
+
+func TestReplaceTx(t *testing.T) {
+	env, err := NewSimEtherman(TEST_ETH_ACCOUNTS, ss, big.NewInt(1337))
+	assert.NoError(t, err)
+	etherman := env.Etherman
+	client := etherman.Client()
+
+	params := env.GenMintParams(&ParamConfig{Receiver: 1, Amount: big.NewInt(100)}, common.RandBytes32())
+	stuck, err := etherman.Mint(params)
+	assert.NoError(t, err)
+	assert.NotNil(t, stuck.GasFeeCap())
+
+	replacement, err := etherman.ReplaceTx(stuck.Hash())
+	assert.NoError(t, err)
+	assert.Equal(t, stuck.Nonce(), replacement.Nonce())
+	assert.Equal(t, stuck.Data(), replacement.Data())
+	assert.True(t, replacement.GasFeeCap().Cmp(stuck.GasFeeCap()) > 0)
+	assert.True(t, replacement.GasTipCap().Cmp(stuck.GasTipCap()) > 0)
+	env.Chain.Backend.Commit()
+
+	// the replacement is mined, not the stuck tx
+	receipt, err := client.TransactionReceipt(context.Background(), replacement.Hash())
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), receipt.Status)
+	_, err = client.TransactionReceipt(context.Background(), stuck.Hash())
+	assert.Error(t, err)
+	minted, err := etherman.IsMinted(params.BtcTxId)
+	assert.NoError(t, err)
+	assert.True(t, minted)
+
+	_, err = etherman.ReplaceTx(replacement.Hash())
+	assert.ErrorIs(t, err, ErrTxNotPending)
+}
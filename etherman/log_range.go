@@ -0,0 +1,109 @@
+package etherman
+
+import (
+	"math/big"
+	"strings"
+	"sync"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	logger "github.com/sirupsen/logrus"
+)
+
+const (
+	DEFAULT_LOG_CHUNK_BLOCKS = 1000  // blocks per eth_getLogs call to start with
+	MAX_LOG_CHUNK_BLOCKS     = 10000 // blocks per eth_getLogs call at most
+	SPARSE_LOG_CHUNK         = 100   // the chunk grows while a call returns fewer logs
+)
+
+// Errors of the nodes rejecting an eth_getLogs call that covers too many blocks or logs
+// (geth, infura, alchemy, quicknode ...), the range is split then.
+// Other errors (eg. rate limits, "429 too many requests") are returned as they are.
+var tooManyLogsErrors = []string{
+	"query returned more than", // geth, infura: query returned more than 10000 results
+	"too many results",
+	"response size exceeded", // alchemy: log response size exceeded
+	"range is too large",     // block range is too large
+	"range too large",
+	"exceed maximum block range",
+}
+
+func isTooManyLogsError(err error) bool {
+	msg := strings.ToLower(err.Error())
+	for _, s := range tooManyLogsErrors {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// LogFetcher fetches the bridge events of a block range with ranged eth_getLogs calls.
+// The number of blocks per call adapts to the node: halved when the node rejects the call
+// (too many results), doubled while the calls return few logs.
+// The chunk size is kept between the calls of FetchEvents.
+type LogFetcher struct {
+	etherman *Etherman
+
+	mu    sync.Mutex
+	chunk uint64
+}
+
+func NewLogFetcher(etherman *Etherman) *LogFetcher {
+	return &LogFetcher{
+		etherman: etherman,
+		chunk:    DEFAULT_LOG_CHUNK_BLOCKS,
+	}
+}
+
+// Current number of blocks per eth_getLogs call.
+func (f *LogFetcher) ChunkSize() uint64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.chunk
+}
+
+// Fetch the bridge events of blocks [from, to], chunk by chunk.
+// handle is called once per chunk, in block order, with the events of the chunk
+// in log order (block number, log index) and the last block of the chunk.
+func (f *LogFetcher) FetchEvents(from *big.Int, to *big.Int, handle func(events []BridgeEvent, chunkEnd *big.Int) error) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	start := new(big.Int).Set(from)
+	for start.Cmp(to) <= 0 {
+		end := new(big.Int).Add(start, new(big.Int).SetUint64(f.chunk-1))
+		if end.Cmp(to) > 0 {
+			end.Set(to)
+		}
+
+		events, err := f.etherman.filterBridgeEvents(ethereum.FilterQuery{
+			FromBlock: start,
+			ToBlock:   end,
+			Addresses: []ethcommon.Address{f.etherman.cfg.BridgeContractAddress},
+		})
+		if err != nil {
+			if f.chunk > 1 && isTooManyLogsError(err) {
+				f.chunk /= 2
+				logger.WithFields(logger.Fields{
+					"from":  start,
+					"to":    end,
+					"chunk": f.chunk,
+				}).WithError(err).Debug("log range rejected, shrinking chunk")
+				continue
+			}
+			return err
+		}
+
+		if err := handle(events, end); err != nil {
+			return err
+		}
+
+		if len(events) < SPARSE_LOG_CHUNK && f.chunk < MAX_LOG_CHUNK_BLOCKS {
+			f.chunk = min(f.chunk*2, MAX_LOG_CHUNK_BLOCKS)
+		}
+		start = new(big.Int).Add(end, big.NewInt(1))
+	}
+
+	return nil
+}
@@ -0,0 +1,109 @@
+package etherman
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/TEENet-io/bridge-go/common"
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// node that rejects eth_getLogs calls over more than maxBlocks blocks
+// or fails every call with err if set
+type rangeLimitedClient struct {
+	ethereumClient
+	maxBlocks uint64
+	err       error
+	calls     int
+}
+
+func (c *rangeLimitedClient) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	c.calls++
+	if c.err != nil {
+		return nil, c.err
+	}
+	if new(big.Int).Sub(q.ToBlock, q.FromBlock).Uint64()+1 > c.maxBlocks {
+		return nil, errors.New("query returned more than 10000 results")
+	}
+	return c.ethereumClient.FilterLogs(ctx, q)
+}
+
+func TestLogFetcherAdaptiveChunk(t *testing.T) {
+	env, err := NewSimEtherman(TEST_ETH_ACCOUNTS, ss, big.NewInt(1337))
+	assert.NoError(t, err)
+	commit := env.Chain.Backend.Commit
+
+	start := curentBlockNum(t, env)
+	mintTxHash, _ := env.Mint(common.RandBytes32(), 1, 100)
+	commit()
+	for i := 0; i < 5; i++ {
+		commit()
+	}
+	prepareTxHash, _ := env.Prepare(4, 400, 0, 1)
+	commit()
+	end := curentBlockNum(t, env)
+
+	// 8 blocks, the node takes 5 at most
+	client := &rangeLimitedClient{ethereumClient: env.Etherman.ethClient, maxBlocks: 5}
+	env.Etherman.ethClient = client
+	fetcher := NewLogFetcher(env.Etherman)
+	fetcher.chunk = 16
+
+	// rejected at 16 and 8 blocks, shrinks to 4 then grows back while logs are sparse
+	events := []BridgeEvent{}
+	chunkEnds := []int64{}
+	err = fetcher.FetchEvents(start, end, func(chunk []BridgeEvent, chunkEnd *big.Int) error {
+		events = append(events, chunk...)
+		chunkEnds = append(chunkEnds, chunkEnd.Int64())
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 4, client.calls)
+	assert.Equal(t, []int64{start.Int64() + 3, end.Int64()}, chunkEnds)
+	assert.Equal(t, uint64(16), fetcher.ChunkSize())
+
+	// in log order
+	assert.Len(t, events, 2)
+	assert.Equal(t, mintTxHash.Bytes(), events[0].Minted.TxHash[:])
+	assert.Equal(t, prepareTxHash.Bytes(), events[1].Prepared.TxHash[:])
+
+	// chunks are handed over in block order
+	fetcher = NewLogFetcher(env.Etherman)
+	fetcher.chunk = 2
+	chunkEnds = []int64{}
+	err = fetcher.FetchEvents(start, end, func(_ []BridgeEvent, chunkEnd *big.Int) error {
+		chunkEnds = append(chunkEnds, chunkEnd.Int64())
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, end.Int64(), chunkEnds[len(chunkEnds)-1])
+	for i := 1; i < len(chunkEnds); i++ {
+		assert.Less(t, chunkEnds[i-1], chunkEnds[i])
+	}
+
+	// other errors are returned, the chunk is kept
+	client.err = errors.New("429 Too Many Requests")
+	client.calls = 0
+	fetcher = NewLogFetcher(env.Etherman)
+	fetcher.chunk = 4
+	err = fetcher.FetchEvents(start, end, func([]BridgeEvent, *big.Int) error { return nil })
+	assert.Equal(t, client.err, err)
+	assert.Equal(t, 1, client.calls)
+	assert.Equal(t, uint64(4), fetcher.ChunkSize())
+}
+
+func TestIsTooManyLogsError(t *testing.T) {
+	assert.True(t, isTooManyLogsError(errors.New("query returned more than 10000 results")))
+	assert.True(t, isTooManyLogsError(errors.New("Log response size exceeded.")))
+	assert.True(t, isTooManyLogsError(errors.New("eth_getLogs block range is too large")))
+	assert.True(t, isTooManyLogsError(errors.New("query exceeds max results 20000, retry with the range 1-100: too many results")))
+	assert.False(t, isTooManyLogsError(errors.New("connection refused")))
+	assert.False(t, isTooManyLogsError(errors.New("429 Too Many Requests")))
+	assert.False(t, isTooManyLogsError(errors.New("daily request count limit exceeded")))
+	assert.False(t, isTooManyLogsError(errors.New("invalid block range params")))
+	assert.False(t, isTooManyLogsError(errors.New("gas required exceeds allowance, more than the block gas limit")))
+}
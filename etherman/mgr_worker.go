@@ -0,0 +1,345 @@
+package etherman
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/TEENet-io/bridge-go/agreement"
+	"github.com/TEENet-io/bridge-go/chaintxmgrdb"
+	"github.com/TEENet-io/bridge-go/common"
+	"github.com/ethereum/go-ethereum"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	logger "github.com/sirupsen/logrus"
+)
+
+// EthMgrWorker implements chaintxmgr.MgrWorker on top of Etherman.
+// A tx is identified by its 32-byte hash.
+//
+// A tx still pending after replaceAfterBlocks blocks is replaced with bumped fees (see Etherman.ReplaceTx),
+// it keeps its original identifier and its status follows whichever of the txs is mined.
+// The replacements are persisted (see ReplacementStore), so they are followed after a restart.
+type EthMgrWorker struct {
+	etherman *Etherman
+
+	// replace a pending tx after ? blocks (0=never)
+	replaceAfterBlocks uint64
+	replacements       ReplacementStore // nil = kept in memory only
+
+	mu   sync.Mutex
+	sent map[ethcommon.Hash]*sentTx // original tx hash -> txs sent for it
+}
+
+// Where the replacements of the txs are persisted, eg. chaintxmgrdb.ChainTxMgrDB.
+type ReplacementStore interface {
+	InsertReplacementTx(tx *chaintxmgrdb.ReplacementTx) error
+	GetReplacementTxs(identifier []byte) ([]*chaintxmgrdb.ReplacementTx, error)
+}
+
+type sentTx struct {
+	hashes []ethcommon.Hash // the original tx then its replacements
+	sentAt *big.Int         // block number when the last one was sent, nil if unknown
+}
+
+func NewEthMgrWorker(etherman *Etherman, replaceAfterBlocks uint64, replacements ReplacementStore) *EthMgrWorker {
+	return &EthMgrWorker{
+		etherman:           etherman,
+		replaceAfterBlocks: replaceAfterBlocks,
+		replacements:       replacements,
+		sent:               make(map[ethcommon.Hash]*sentTx),
+	}
+}
+
+// Latest block number, txs are sent to and mined in unfinalized blocks.
+func (w *EthMgrWorker) GetLatestLedgerNumber() (*big.Int, error) {
+	header, err := w.etherman.ethClient.HeaderByNumber(context.Background(), nil)
+	if err != nil {
+		return nil, err
+	}
+	return header.Number, nil
+}
+
+func (w *EthMgrWorker) IsMinted(btcTxId [32]byte) (bool, error) {
+	return w.etherman.IsMinted(btcTxId)
+}
+
+func (w *EthMgrWorker) DoMint(mint *agreement.MintParameter) ([]byte, *big.Int, error) {
+	tx, err := w.etherman.Mint(&MintParams{
+		BtcTxId:  mint.BtcTxId,
+		Amount:   common.BigIntClone(mint.Amount),
+		Receiver: mint.Receiver,
+		Rx:       common.BigIntClone(mint.Rx),
+		S:        common.BigIntClone(mint.S),
+		Domain:   mint.Domain,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	blockNum, err := w.GetLatestLedgerNumber()
+	if err != nil {
+		blockNum = nil
+	}
+	w.track(tx.Hash(), blockNum)
+	return tx.Hash().Bytes(), blockNum, nil
+}
+
+func (w *EthMgrWorker) IsPrepared(requestTxId [32]byte) (bool, error) {
+	return w.etherman.IsPrepared(requestTxId)
+}
+
+func (w *EthMgrWorker) DoPrepare(prepare *agreement.PrepareParameter) ([]byte, *big.Int, error) {
+	tx, err := w.etherman.RedeemPrepare(&PrepareParams{
+		RequestTxHash: prepare.RequestTxHash,
+		Requester:     prepare.Requester,
+		Receiver:      prepare.Receiver,
+		Amount:        common.BigIntClone(prepare.Amount),
+		OutpointTxIds: prepare.OutpointTxIds,
+		OutpointIdxs:  prepare.OutpointIdxs,
+		Rx:            common.BigIntClone(prepare.Rx),
+		S:             common.BigIntClone(prepare.S),
+		Domain:        prepare.Domain,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	blockNum, err := w.GetLatestLedgerNumber()
+	if err != nil {
+		blockNum = nil
+	}
+	w.track(tx.Hash(), blockNum)
+	return tx.Hash().Bytes(), blockNum, nil
+}
+
+// Status of the tx, or of the replacement that is mined.
+// A pending tx is replaced when it is stuck for replaceAfterBlocks.
+func (w *EthMgrWorker) GetTxStatus(txId []byte) (agreement.MonitoredTxStatus, *big.Int, error) {
+	if len(txId) != ethcommon.HashLength {
+		return agreement.MalForm, nil, fmt.Errorf("invalid eth tx hash: %x", txId)
+	}
+	txHash := ethcommon.BytesToHash(txId)
+
+	receipt, err := w.receipt(txHash)
+	if err != nil {
+		return agreement.MalForm, nil, err
+	}
+	if receipt != nil {
+		w.forget(txHash)
+		if receipt.Status == types.ReceiptStatusSuccessful {
+			return agreement.Success, receipt.BlockNumber, nil
+		}
+		return agreement.Reverted, receipt.BlockNumber, nil
+	}
+
+	// not mined, the last sent tx is the one in the pool
+	last, sentAt, err := w.last(txHash)
+	if err != nil {
+		return agreement.MalForm, nil, err
+	}
+	if sentAt == nil {
+		// sent before a restart (or block number unknown), tracked from now on
+		if latest, err := w.GetLatestLedgerNumber(); err == nil {
+			w.track(txHash, latest)
+		}
+	}
+	_, isPending, err := w.etherman.ethClient.TransactionByHash(context.Background(), last)
+	if errors.Is(err, ethereum.NotFound) {
+		return agreement.Limbo, nil, nil
+	}
+	if err != nil {
+		return agreement.MalForm, nil, err
+	}
+	if !isPending {
+		// mined between the two calls, picked up on the next round
+		return agreement.Pending, nil, nil
+	}
+
+	if w.replaceAfterBlocks > 0 && sentAt != nil {
+		w.replaceIfStuck(txHash, last, sentAt)
+	}
+	return agreement.Pending, nil, nil
+}
+
+// Why the tx reverted, nil if it didn't.
+// A tx that used all its gas ran out of it and is retried, other reverts need an operator.
+func (w *EthMgrWorker) GetTxFailure(txId []byte) (*agreement.TxFailure, error) {
+	if len(txId) != ethcommon.HashLength {
+		return nil, fmt.Errorf("invalid eth tx hash: %x", txId)
+	}
+	txHash := ethcommon.BytesToHash(txId)
+
+	receipt, err := w.receipt(txHash)
+	if err != nil {
+		return nil, err
+	}
+	if receipt == nil {
+		return nil, fmt.Errorf("tx not mined: %s", txHash.String())
+	}
+	if receipt.Status == types.ReceiptStatusSuccessful {
+		return nil, nil
+	}
+
+	tx, _, err := w.etherman.ethClient.TransactionByHash(context.Background(), receipt.TxHash)
+	if err != nil {
+		return nil, err
+	}
+	if receipt.GasUsed >= tx.Gas() {
+		return &agreement.TxFailure{
+			Action: agreement.TxFailureRetry,
+			Reason: fmt.Sprintf("out of gas (gas=%d)", tx.Gas()),
+		}, nil
+	}
+	return &agreement.TxFailure{
+		Action: agreement.TxFailurePermanent,
+		Reason: fmt.Sprintf("reverted at block %v", receipt.BlockNumber),
+	}, nil
+}
+
+// Schnorr public key registered in the bridge contract.
+func (w *EthMgrWorker) GetBridgePublicKey() ([32]byte, error) {
+	pk, err := w.etherman.GetPublicKey()
+	if err != nil {
+		return [32]byte{}, err
+	}
+	return common.BigInt2Bytes32(pk), nil
+}
+
+func (w *EthMgrWorker) SigningDomain() (*common.SigningDomain, error) {
+	return w.etherman.SigningDomain()
+}
+
+// Receipt of the tx or of one of its replacements, nil if none is mined.
+func (w *EthMgrWorker) receipt(txHash ethcommon.Hash) (*types.Receipt, error) {
+	w.mu.Lock()
+	st, err := w.lookup(txHash)
+	hashes := []ethcommon.Hash{txHash}
+	if st != nil {
+		hashes = append([]ethcommon.Hash{}, st.hashes...)
+	}
+	w.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, hash := range hashes {
+		receipt, err := w.etherman.ethClient.TransactionReceipt(context.Background(), hash)
+		if errors.Is(err, ethereum.NotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return receipt, nil
+	}
+	return nil, nil
+}
+
+// Last tx sent for txHash, and the block number it was sent at (nil if unknown).
+func (w *EthMgrWorker) last(txHash ethcommon.Hash) (ethcommon.Hash, *big.Int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	st, err := w.lookup(txHash)
+	if err != nil || st == nil {
+		return txHash, nil, err
+	}
+	return st.hashes[len(st.hashes)-1], st.sentAt, nil
+}
+
+// Txs sent for txHash, loaded from the store if not tracked (eg. after a restart), nil if none.
+// Caller holds mu.
+func (w *EthMgrWorker) lookup(txHash ethcommon.Hash) (*sentTx, error) {
+	if st, ok := w.sent[txHash]; ok {
+		return st, nil
+	}
+	if w.replacements == nil {
+		return nil, nil
+	}
+
+	replaced, err := w.replacements.GetReplacementTxs(txHash.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	if len(replaced) == 0 {
+		return nil, nil
+	}
+	st := &sentTx{hashes: []ethcommon.Hash{txHash}}
+	for _, tx := range replaced {
+		st.hashes = append(st.hashes, ethcommon.BytesToHash(tx.TxIdentifier))
+		st.sentAt = common.BigIntClone(tx.SentBlockchainLedgerNumber)
+	}
+	w.sent[txHash] = st
+	return st, nil
+}
+
+// Start tracking the tx sent at block sentAt (nil if unknown).
+func (w *EthMgrWorker) track(txHash ethcommon.Hash, sentAt *big.Int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	st, ok := w.sent[txHash]
+	if !ok {
+		st = &sentTx{hashes: []ethcommon.Hash{txHash}}
+		w.sent[txHash] = st
+	}
+	if st.sentAt == nil && sentAt != nil {
+		st.sentAt = common.BigIntClone(sentAt)
+	}
+}
+
+func (w *EthMgrWorker) forget(txHash ethcommon.Hash) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.sent, txHash)
+}
+
+// Replace the last tx sent for txHash if it has been pending for replaceAfterBlocks.
+func (w *EthMgrWorker) replaceIfStuck(txHash ethcommon.Hash, last ethcommon.Hash, sentAt *big.Int) {
+	latest, err := w.GetLatestLedgerNumber()
+	if err != nil {
+		logger.WithError(err).Warn("failed to get latest block number")
+		return
+	}
+	if new(big.Int).Sub(latest, sentAt).Cmp(new(big.Int).SetUint64(w.replaceAfterBlocks)) < 0 {
+		return
+	}
+
+	tx, err := w.etherman.ReplaceTx(last)
+	if err != nil {
+		logger.WithFields(logger.Fields{
+			"txHash": last.String(),
+		}).WithError(err).Warn("failed to replace stuck tx")
+		return
+	}
+
+	// persisted first, the replacement is followed even if the tx is forgotten (eg. a restart)
+	if w.replacements != nil {
+		err = w.replacements.InsertReplacementTx(&chaintxmgrdb.ReplacementTx{
+			TxIdentifier:               tx.Hash().Bytes(),
+			Replaces:                   txHash.Bytes(),
+			SentBlockchainLedgerNumber: common.BigIntClone(latest),
+		})
+		if err != nil {
+			logger.WithFields(logger.Fields{
+				"txHash":      txHash.String(),
+				"replacement": tx.Hash().String(),
+			}).WithError(err).Error("failed to persist tx replacement")
+		}
+	}
+
+	w.track(txHash, nil)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	st := w.sent[txHash]
+	st.hashes = append(st.hashes, tx.Hash())
+	st.sentAt = latest
+
+	logger.WithFields(logger.Fields{
+		"txHash":      txHash.String(),
+		"replacement": tx.Hash().String(),
+		"block#":      latest,
+	}).Info("Stuck tx replaced with bumped fees")
+}
@@ -0,0 +1,99 @@
+package etherman
+
+import (
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	"github.com/TEENet-io/bridge-go/agreement"
+	"github.com/TEENet-io/bridge-go/chaintxmgrdb"
+	"github.com/TEENet-io/bridge-go/common"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEthMgrWorkerDoMint(t *testing.T) {
+	env, err := NewSimEtherman(TEST_ETH_ACCOUNTS, ss, big.NewInt(1337))
+	assert.NoError(t, err)
+	worker := NewEthMgrWorker(env.Etherman, 0, nil)
+
+	params := env.GenMintParams(&ParamConfig{Receiver: 1, Amount: big.NewInt(100)}, common.RandBytes32())
+	txId, sentAt, err := worker.DoMint(&agreement.MintParameter{
+		BtcTxId:  params.BtcTxId,
+		Amount:   params.Amount,
+		Receiver: params.Receiver,
+		Rx:       params.Rx,
+		S:        params.S,
+		Domain:   params.Domain,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, txId, 32)
+	assert.Equal(t, curentBlockNum(t, env), sentAt)
+
+	status, foundAt, err := worker.GetTxStatus(txId)
+	assert.NoError(t, err)
+	assert.Equal(t, agreement.Pending, status)
+	assert.Nil(t, foundAt)
+
+	env.Chain.Backend.Commit()
+	status, foundAt, err = worker.GetTxStatus(txId)
+	assert.NoError(t, err)
+	assert.Equal(t, agreement.Success, status)
+	assert.Equal(t, curentBlockNum(t, env), foundAt)
+
+	failure, err := worker.GetTxFailure(txId)
+	assert.NoError(t, err)
+	assert.Nil(t, failure)
+
+	minted, err := worker.IsMinted(params.BtcTxId)
+	assert.NoError(t, err)
+	assert.True(t, minted)
+
+	// unknown tx
+	status, _, err = worker.GetTxStatus(common.RandBytes(32))
+	assert.NoError(t, err)
+	assert.Equal(t, agreement.Limbo, status)
+
+	_, _, err = worker.GetTxStatus([]byte{1, 2, 3})
+	assert.Error(t, err)
+}
+
+func TestEthMgrWorkerReplacementPersisted(t *testing.T) {
+	env, err := NewSimEtherman(TEST_ETH_ACCOUNTS, ss, big.NewInt(1337))
+	assert.NoError(t, err)
+	store, err := chaintxmgrdb.NewSQLiteChainTxMgrDB(filepath.Join(t.TempDir(), "mgr.db"))
+	assert.NoError(t, err)
+	worker := NewEthMgrWorker(env.Etherman, 1, store)
+
+	params := env.GenMintParams(&ParamConfig{Receiver: 1, Amount: big.NewInt(100)}, common.RandBytes32())
+	txId, sentAt, err := worker.DoMint(&agreement.MintParameter{
+		BtcTxId:  params.BtcTxId,
+		Amount:   params.Amount,
+		Receiver: params.Receiver,
+		Rx:       params.Rx,
+		S:        params.S,
+		Domain:   params.Domain,
+	})
+	assert.NoError(t, err)
+
+	// stuck for a block
+	txHash := ethcommon.BytesToHash(txId)
+	worker.replaceIfStuck(txHash, txHash, new(big.Int).Sub(sentAt, big.NewInt(1)))
+	replaced, err := store.GetReplacementTxs(txId)
+	assert.NoError(t, err)
+	assert.Len(t, replaced, 1)
+	assert.Equal(t, txId, replaced[0].Replaces)
+	assert.NotEqual(t, txId, replaced[0].TxIdentifier)
+
+	// after a restart, the replacement is followed
+	env.Chain.Backend.Commit()
+	restarted := NewEthMgrWorker(env.Etherman, 1, store)
+	status, foundAt, err := restarted.GetTxStatus(txId)
+	assert.NoError(t, err)
+	assert.Equal(t, agreement.Success, status)
+	assert.Equal(t, curentBlockNum(t, env), foundAt)
+
+	failure, err := NewEthMgrWorker(env.Etherman, 1, store).GetTxFailure(txId)
+	assert.NoError(t, err)
+	assert.Nil(t, failure)
+}
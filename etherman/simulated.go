@@ -8,7 +8,6 @@ import (
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	ethcommon "github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient/simulated"
 	logger "github.com/sirupsen/logrus"
 
@@ -178,8 +177,21 @@ func (env *SimEtherman) GenMintParams(cfg *ParamConfig, btcTxId [32]byte) *MintP
 	}
 	receiver := chain.Accounts[idx].From
 
+	domain, err := env.Etherman.SigningDomain()
+	if err != nil {
+		return nil
+	}
+
+	// Assemble Mint parameters
+	p := &MintParams{
+		BtcTxId:  btcTxId,
+		Amount:   cfg.Amount,
+		Receiver: receiver.Bytes(),
+		Domain:   domain,
+	}
+
 	// Create (rx, s) schnorr signature of (btctxid, ethaddr, amount)
-	content := crypto.Keccak256Hash(common.EncodePacked(btcTxId, receiver.String(), cfg.Amount)).Bytes()
+	content := p.SigningHash()
 	_sig, err := env.MultiSigner.Sign(content[:])
 	if err != nil {
 		return nil
@@ -188,15 +200,10 @@ func (env *SimEtherman) GenMintParams(cfg *ParamConfig, btcTxId [32]byte) *MintP
 	if err != nil {
 		return nil
 	}
+	p.Rx = rx
+	p.S = s
 
-	// Assemble Mint parameters
-	return &MintParams{
-		BtcTxId:  btcTxId,
-		Amount:   cfg.Amount,
-		Receiver: receiver.Bytes(),
-		Rx:       rx,
-		S:        s,
-	}
+	return p
 }
 
 // Generate a Request (= RedeemRequest) parameters from ParamConfig.
@@ -264,6 +271,11 @@ func (env *SimEtherman) GenPrepareParams(cfg *ParamConfig) (p *PrepareParams) {
 		outpointIdxs = append(outpointIdxs, uint16(i))
 	}
 
+	domain, err := env.Etherman.SigningDomain()
+	if err != nil {
+		return nil
+	}
+
 	p = &PrepareParams{
 		RequestTxHash: reqTxHash,
 		Requester:     requester.Bytes(),
@@ -271,6 +283,7 @@ func (env *SimEtherman) GenPrepareParams(cfg *ParamConfig) (p *PrepareParams) {
 		Amount:        cfg.Amount,
 		OutpointTxIds: outpointTxIds,
 		OutpointIdxs:  outpointIdxs,
+		Domain:        domain,
 	}
 
 	// create the hash
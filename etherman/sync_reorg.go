@@ -0,0 +1,116 @@
+package etherman
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/TEENet-io/bridge-go/agreement"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	logger "github.com/sirupsen/logrus"
+)
+
+// Blocks older than the newest synced one by this many blocks are final.
+const DEFAULT_REORG_DEPTH = 128
+
+// A synced block, kept to detect that it leaves the canonical chain.
+// Only the blocks with events (to be rolled back) and the last block
+// of each sync (to detect any reorg) are kept.
+type syncedBlock struct {
+	number    uint64
+	hash      ethcommon.Hash
+	minted    []agreement.MintedEvent
+	requested []agreement.RedeemRequestedEvent
+	prepared  []agreement.RedeemPreparedEvent
+}
+
+func (b *syncedBlock) add(ev *agreement.ChainEvent) {
+	switch {
+	case ev.Minted != nil:
+		b.minted = append(b.minted, *ev.Minted)
+	case ev.Requested != nil:
+		b.requested = append(b.requested, *ev.Requested)
+	case ev.Prepared != nil:
+		b.prepared = append(b.prepared, *ev.Prepared)
+	}
+}
+
+// Keep the blocks of a sync, forget the blocks that are final.
+// The blocks synced again (eg. the batch was not applied) replace the kept ones.
+func (w *EthSyncWorker) recordBlocks(blocks []*syncedBlock) {
+	if len(blocks) > 0 {
+		for len(w.synced) > 0 && w.synced[len(w.synced)-1].number >= blocks[0].number {
+			w.synced = w.synced[:len(w.synced)-1]
+		}
+	}
+	w.synced = append(w.synced, blocks...)
+	if len(w.synced) == 0 {
+		return
+	}
+
+	newest := w.synced[len(w.synced)-1].number
+	for len(w.synced) > 1 && w.synced[0].number+w.reorgDepth < newest {
+		w.synced = w.synced[1:]
+	}
+}
+
+// Hash of the last block of a sync, to detect any reorg after it.
+func (w *EthSyncWorker) lastBlock(blocks []*syncedBlock, num *big.Int) ([]*syncedBlock, error) {
+	if len(blocks) > 0 && blocks[len(blocks)-1].number == num.Uint64() {
+		return blocks, nil
+	}
+	header, err := w.etherman.Client().HeaderByNumber(context.Background(), num)
+	if err != nil {
+		return nil, err
+	}
+	return append(blocks, &syncedBlock{number: num.Uint64(), hash: header.Hash()}), nil
+}
+
+// Check whether synced blocks left the canonical chain (see chainsync.ReorgWorker).
+func (w *EthSyncWorker) CheckReorg() (*agreement.ChainReorg, error) {
+	if len(w.synced) == 0 {
+		return nil, nil
+	}
+
+	// newest first, if it is canonical, so are the older ones
+	i := len(w.synced) - 1
+	for ; i >= 0; i-- {
+		ok, err := w.etherman.OnCanonicalChain(w.synced[i].hash)
+		if err != nil {
+			logger.Errorf("failed to check block on canonical chain: blk=%d, err=%v", w.synced[i].number, err)
+			return nil, err
+		}
+		if ok {
+			break
+		}
+	}
+	if i == len(w.synced)-1 {
+		return nil, nil
+	}
+
+	orphaned := w.synced[i+1:]
+	var forkPoint *big.Int
+	if i >= 0 {
+		forkPoint = new(big.Int).SetUint64(w.synced[i].number)
+	} else {
+		forkPoint = new(big.Int).SetUint64(orphaned[0].number - 1)
+		logger.WithField("reorgDepth", w.reorgDepth).Warn("reorg is deeper than the kept blocks")
+	}
+
+	minted := []agreement.MintedEvent{}
+	requested := []agreement.RedeemRequestedEvent{}
+	prepared := []agreement.RedeemPreparedEvent{}
+	for _, b := range orphaned {
+		minted = append(minted, b.minted...)
+		requested = append(requested, b.requested...)
+		prepared = append(prepared, b.prepared...)
+	}
+
+	logger.WithFields(logger.Fields{
+		"forkPoint":  forkPoint,
+		"orphaned":   len(orphaned),
+		"firstBlock": orphaned[0].number,
+	}).Warn("Chain reorg detected (eth)")
+
+	w.synced = w.synced[:i+1]
+	return agreement.NewChainReorg(forkPoint, minted, requested, prepared), nil
+}
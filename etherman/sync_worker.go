@@ -0,0 +1,109 @@
+package etherman
+
+import (
+	"math/big"
+
+	"github.com/TEENet-io/bridge-go/agreement"
+	"github.com/TEENet-io/bridge-go/common"
+	"github.com/btcsuite/btcd/chaincfg"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+)
+
+// EthSyncWorker implements chainsync.SyncWorker and chainsync.ReorgWorker on top of Etherman.
+// The blocks are synced a few blocks behind the head (see GetLatestFinalizedBlockNumber),
+// they can still leave the canonical chain: the synced blocks are kept to detect it (see sync_reorg.go).
+type EthSyncWorker struct {
+	etherman       *Etherman
+	logs           *LogFetcher
+	btcChainConfig *chaincfg.Params // used for verify btc address correctness (in RedeemRequest)
+
+	reorgDepth uint64         // blocks kept to detect reorgs
+	synced     []*syncedBlock // called from the sync loop only
+}
+
+func NewEthSyncWorker(etherman *Etherman, btcChainConfig *chaincfg.Params) *EthSyncWorker {
+	return &EthSyncWorker{
+		etherman:       etherman,
+		logs:           NewLogFetcher(etherman),
+		btcChainConfig: btcChainConfig,
+		reorgDepth:     DEFAULT_REORG_DEPTH,
+	}
+}
+
+func (w *EthSyncWorker) GetNewestLedgerFinalizedNumber() (*big.Int, error) {
+	return w.etherman.GetLatestFinalizedBlockNumber()
+}
+
+// Events of blocks (oldNum, newNum], ordered by (block number, log index).
+// The blocks are fetched with ranged eth_getLogs calls (see LogFetcher).
+// The blocks with events and block newNum are kept to detect reorgs.
+func (w *EthSyncWorker) GetTimeOrderedEvents(oldNum *big.Int, newNum *big.Int) ([]agreement.ChainEvent, error) {
+	events := []agreement.ChainEvent{}
+	blocks := []*syncedBlock{}
+
+	from := new(big.Int).Add(oldNum, big.NewInt(1))
+	err := w.logs.FetchEvents(from, newNum, func(chunk []BridgeEvent, _ *big.Int) error {
+		for _, ev := range chunk {
+			chainEv := w.chainEvent(&ev)
+			log := ev.Log()
+			if len(blocks) == 0 || blocks[len(blocks)-1].number != log.BlockNumber {
+				blocks = append(blocks, &syncedBlock{number: log.BlockNumber, hash: log.BlockHash})
+			}
+			blocks[len(blocks)-1].add(&chainEv)
+			events = append(events, chainEv)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	blocks, err = w.lastBlock(blocks, newNum)
+	if err != nil {
+		return nil, err
+	}
+	w.recordBlocks(blocks)
+
+	return events, nil
+}
+
+func (w *EthSyncWorker) chainEvent(ev *BridgeEvent) agreement.ChainEvent {
+	log := ev.Log()
+	chainEv := agreement.ChainEvent{
+		Ledger: log.BlockNumber,
+		Index:  uint64(log.Index),
+	}
+
+	switch {
+	case ev.Minted != nil:
+		chainEv.Minted = &agreement.MintedEvent{
+			MintTxHash: ev.Minted.TxHash,
+			BtcTxId:    ev.Minted.BtcTxId,
+			Amount:     new(big.Int).Set(ev.Minted.Amount),
+			Receiver:   ev.Minted.Receiver.Bytes(),
+		}
+	case ev.Requested != nil:
+		chainEv.Requested = &agreement.RedeemRequestedEvent{
+			RequestTxHash:   ev.Requested.TxHash,
+			Requester:       ev.Requested.Sender.Bytes(),
+			Amount:          new(big.Int).Set(ev.Requested.Amount),
+			Receiver:        ev.Requested.Receiver,
+			IsValidReceiver: common.IsValidBtcAddress(ev.Requested.Receiver, w.btcChainConfig),
+		}
+	case ev.Prepared != nil:
+		outpointTxIds := []ethcommon.Hash{}
+		for _, txid := range ev.Prepared.OutpointTxIds {
+			outpointTxIds = append(outpointTxIds, txid)
+		}
+		chainEv.Prepared = &agreement.RedeemPreparedEvent{
+			PrepareTxHash: ev.Prepared.TxHash,
+			RequestTxHash: ev.Prepared.EthTxHash,
+			Requester:     ev.Prepared.Requester.Bytes(),
+			Receiver:      ev.Prepared.Receiver,
+			Amount:        new(big.Int).Set(ev.Prepared.Amount),
+			OutpointTxIds: outpointTxIds,
+			OutpointIdxs:  ev.Prepared.OutpointIdxs,
+		}
+	}
+	return chainEv
+}
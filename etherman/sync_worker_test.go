@@ -0,0 +1,95 @@
+package etherman
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/TEENet-io/bridge-go/common"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEthSyncWorkerGetTimeOrderedEvents(t *testing.T) {
+	env, err := NewSimEtherman(TEST_ETH_ACCOUNTS, ss, big.NewInt(1337))
+	assert.NoError(t, err)
+	commit := env.Chain.Backend.Commit
+	worker := NewEthSyncWorker(env.Etherman, common.MainNetParams())
+
+	start := curentBlockNum(t, env)
+
+	// prepare sent before mint, in the same block
+	prepareTxHash, _ := env.Prepare(4, 400, 0, 1)
+	mintTxHash, _ := env.Mint(common.RandBytes32(), 1, 100)
+	commit()
+	env.Approve(1, 80)
+	commit()
+	requestTxHash, _ := env.Request(env.GetAuth(1), 1, 80, 0)
+	commit()
+
+	events, err := worker.GetTimeOrderedEvents(start, curentBlockNum(t, env))
+	assert.NoError(t, err)
+	assert.Len(t, events, 3)
+
+	assert.NotNil(t, events[0].Prepared)
+	assert.Equal(t, prepareTxHash, ethcommon.Hash(events[0].Prepared.PrepareTxHash))
+	assert.NotNil(t, events[1].Minted)
+	assert.Equal(t, mintTxHash, ethcommon.Hash(events[1].Minted.MintTxHash))
+	assert.NotNil(t, events[2].Requested)
+	assert.Equal(t, requestTxHash, ethcommon.Hash(events[2].Requested.RequestTxHash))
+
+	assert.Equal(t, events[0].Ledger, events[1].Ledger)
+	assert.True(t, events[0].Before(&events[1]))
+	assert.True(t, events[1].Before(&events[2]))
+
+	// nothing after
+	events, err = worker.GetTimeOrderedEvents(curentBlockNum(t, env), curentBlockNum(t, env))
+	assert.NoError(t, err)
+	assert.Len(t, events, 0)
+}
+
+func TestEthSyncWorkerCheckReorg(t *testing.T) {
+	env, err := NewSimEtherman(TEST_ETH_ACCOUNTS, ss, big.NewInt(1337))
+	assert.NoError(t, err)
+	commit := env.Chain.Backend.Commit
+	worker := NewEthSyncWorker(env.Etherman, common.MainNetParams())
+
+	start := curentBlockNum(t, env)
+	forkPoint, err := env.Etherman.Client().HeaderByNumber(context.Background(), start)
+	assert.NoError(t, err)
+
+	btcTxId := common.RandBytes32()
+	mintTxHash, _ := env.Mint(btcTxId, 1, 100)
+	commit()
+	commit()
+
+	events, err := worker.GetTimeOrderedEvents(start, curentBlockNum(t, env))
+	assert.NoError(t, err)
+	assert.Len(t, events, 1)
+
+	// still canonical
+	reorg, err := worker.CheckReorg()
+	assert.NoError(t, err)
+	assert.Nil(t, reorg)
+
+	// the blocks after start are replaced by a longer side chain
+	assert.NoError(t, env.Chain.Backend.Fork(forkPoint.Hash()))
+	commit()
+	commit()
+	commit()
+
+	reorg, err = worker.CheckReorg()
+	assert.NoError(t, err)
+	assert.NotNil(t, reorg)
+	assert.Equal(t, start, reorg.ForkPoint)
+	assert.Len(t, reorg.Minted, 1)
+	assert.Equal(t, mintTxHash, ethcommon.Hash(reorg.Minted[0].MintTxHash))
+	assert.Equal(t, ethcommon.Hash(btcTxId), reorg.Minted[0].BtcTxId)
+	assert.Len(t, reorg.Requested, 0)
+	assert.Len(t, reorg.Prepared, 0)
+
+	// the orphaned blocks are forgotten
+	reorg, err = worker.CheckReorg()
+	assert.NoError(t, err)
+	assert.Nil(t, reorg)
+}
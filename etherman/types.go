@@ -6,6 +6,7 @@ import (
 	"github.com/TEENet-io/bridge-go/common"
 	bridge "github.com/TEENet-io/bridge-go/contracts/TEENetBtcBridge"
 	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 )
 
@@ -16,14 +17,20 @@ type MintParams struct {
 	Receiver []byte   // ethereum address
 	Rx       *big.Int // part of schnorr signature
 	S        *big.Int // part of schnorr signature
+
+	Domain *common.SigningDomain // see Etherman.SigningDomain(), nil = legacy (no domain)
 }
 
 func (params *MintParams) SigningHash() ethcommon.Hash {
-	return crypto.Keccak256Hash(common.EncodePacked(
+	packed := common.EncodePacked(
 		params.BtcTxId,
 		params.Receiver,
 		params.Amount,
-	))
+	)
+	if params.Domain != nil {
+		return params.Domain.MessageHash(common.MSG_TYPE_MINT, packed)
+	}
+	return crypto.Keccak256Hash(packed)
 }
 
 // Real params to call Ethereum contract Redeem's Request()
@@ -42,6 +49,8 @@ type PrepareParams struct {
 	OutpointIdxs  []uint16         // number, corresponding output vout to btc_tx_id(s)
 	Rx            *big.Int
 	S             *big.Int
+
+	Domain *common.SigningDomain // see Etherman.SigningDomain(), nil = legacy (no domain)
 }
 
 // seraialize the parameters and create a hash
@@ -51,14 +60,18 @@ func (p *PrepareParams) SigningHash() ethcommon.Hash {
 		outpointIdxs = append(outpointIdxs, big.NewInt(int64(idx)))
 	}
 
-	return crypto.Keccak256Hash(common.EncodePacked(
+	packed := common.EncodePacked(
 		p.RequestTxHash,
 		p.Requester,
 		string(p.Receiver),
 		p.Amount,
 		p.OutpointTxIds,
 		outpointIdxs,
-	))
+	)
+	if p.Domain != nil {
+		return p.Domain.MessageHash(common.MSG_TYPE_PREPARE, packed)
+	}
+	return crypto.Keccak256Hash(packed)
 }
 
 type RedeemRequestedEvent struct {
@@ -75,3 +88,32 @@ type MintedEvent struct {
 	bridge.TEENetBtcBridgeMinted
 	TxHash [32]byte
 }
+
+// One of the bridge events decoded from a log, exactly one field is set.
+type BridgeEvent struct {
+	Minted    *MintedEvent
+	Requested *RedeemRequestedEvent
+	Prepared  *RedeemPreparedEvent
+}
+
+// Log the event is decoded from.
+func (ev *BridgeEvent) Log() *types.Log {
+	switch {
+	case ev.Minted != nil:
+		return &ev.Minted.Raw
+	case ev.Requested != nil:
+		return &ev.Requested.Raw
+	case ev.Prepared != nil:
+		return &ev.Prepared.Raw
+	}
+	return &types.Log{}
+}
+
+// Tell if ev is logged before other: (block number, log index).
+func (ev *BridgeEvent) Before(other *BridgeEvent) bool {
+	a, b := ev.Log(), other.Log()
+	if a.BlockNumber != b.BlockNumber {
+		return a.BlockNumber < b.BlockNumber
+	}
+	return a.Index < b.Index
+}
@@ -12,4 +12,6 @@ type EthSyncConfig struct {
 	BtcChainConfig          *chaincfg.Params // used for verify btc address correctness (in RedeemRequest)
 	EthChainID              *big.Int
 	EthRetroScanBlkNum      int64 // retro scan block, tell Sync() to scan from this block, -1 to honor the valude in state.
+
+	ReorgDepth uint64 // blocks kept to detect reorgs, 0 = DEFAULT_REORG_DEPTH
 }
@@ -17,6 +17,8 @@ type MockState struct {
 	mintedEvCh        chan *agreement.MintedEvent
 	requestedEvCh     chan *agreement.RedeemRequestedEvent
 	preparedEvCh      chan *agreement.RedeemPreparedEvent
+	batchCh           chan *agreement.ChainEventBatch
+	reorgCh           chan *agreement.ChainReorg
 
 	lastEthFinalized *big.Int
 	lastBtcFinalized *big.Int
@@ -24,6 +26,7 @@ type MockState struct {
 	mintedEv    []*agreement.MintedEvent
 	requestedEv []*agreement.RedeemRequestedEvent
 	preparedEv  []*agreement.RedeemPreparedEvent
+	reorgs      []*agreement.ChainReorg
 }
 
 func NewMockState() *MockState {
@@ -33,6 +36,8 @@ func NewMockState() *MockState {
 		mintedEvCh:        make(chan *agreement.MintedEvent, 1),
 		requestedEvCh:     make(chan *agreement.RedeemRequestedEvent, 1),
 		preparedEvCh:      make(chan *agreement.RedeemPreparedEvent, 1),
+		batchCh:           make(chan *agreement.ChainEventBatch, 1),
+		reorgCh:           make(chan *agreement.ChainReorg, 1),
 
 		lastEthFinalized: new(big.Int).Set(common.EthStartingBlock),
 		lastBtcFinalized: big.NewInt(0),
@@ -61,6 +66,14 @@ func (st *MockState) GetNewRedeemPreparedEventChannel() chan<- *agreement.Redeem
 	return st.preparedEvCh
 }
 
+func (st *MockState) GetNewChainEventBatchChannel() chan<- *agreement.ChainEventBatch {
+	return st.batchCh
+}
+
+func (st *MockState) GetChainReorgChannel() chan<- *agreement.ChainReorg {
+	return st.reorgCh
+}
+
 func (st *MockState) GetBlockchainFinalizedBlockNumber() (*big.Int, error) {
 	return st.lastEthFinalized, nil
 }
@@ -85,6 +98,27 @@ func (st *MockState) Start(ctx context.Context) error {
 		case ev := <-st.preparedEvCh:
 			logger.Debugf("new prepared event: %v", ev)
 			st.preparedEv = append(st.preparedEv, ev)
+		case batch := <-st.batchCh:
+			logger.Debugf("new event batch: %d events, checkpoint %v", len(batch.Events), batch.Checkpoint)
+			for _, ev := range batch.Events {
+				switch {
+				case ev.Minted != nil:
+					st.mintedEv = append(st.mintedEv, ev.Minted)
+				case ev.Requested != nil:
+					st.requestedEv = append(st.requestedEv, ev.Requested)
+				case ev.Prepared != nil:
+					st.preparedEv = append(st.preparedEv, ev.Prepared)
+				}
+			}
+			if batch.Checkpoint != nil {
+				st.lastEthFinalized = new(big.Int).Set(batch.Checkpoint)
+			}
+			batch.Done <- nil
+		case reorg := <-st.reorgCh:
+			logger.Debugf("chain reorg: fork point %v", reorg.ForkPoint)
+			st.reorgs = append(st.reorgs, reorg)
+			st.lastEthFinalized = new(big.Int).Set(reorg.ForkPoint)
+			reorg.Done <- nil
 		}
 	}
 }
@@ -0,0 +1,127 @@
+package ethsync
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/TEENet-io/bridge-go/agreement"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	logger "github.com/sirupsen/logrus"
+)
+
+// Blocks older than the newest processed one by this many blocks are final.
+const DEFAULT_REORG_DEPTH = 128
+
+// A processed block, kept to detect that it leaves the canonical chain.
+// Only the blocks with minted/requested/prepared events (to be rolled back) and the last block
+// of each scan (to detect any reorg) are kept.
+type processedBlock struct {
+	number    uint64
+	hash      ethcommon.Hash
+	minted    []agreement.MintedEvent
+	requested []agreement.RedeemRequestedEvent
+	prepared  []agreement.RedeemPreparedEvent
+}
+
+// Receive the reorgs after the state rolled them back (eg. the eth tx manager, to resubmit).
+func (s *Synchronizer) SubscribeReorg(ch chan<- *agreement.ChainReorg) {
+	s.reorgSubscribers = append(s.reorgSubscribers, ch)
+}
+
+func (s *Synchronizer) reorgDepth() uint64 {
+	if s.cfg.ReorgDepth == 0 {
+		return DEFAULT_REORG_DEPTH
+	}
+	return s.cfg.ReorgDepth
+}
+
+// Keep the block, forget the blocks that are final.
+func (s *Synchronizer) recordBlock(b *processedBlock) {
+	s.processed = append(s.processed, b)
+
+	depth := s.reorgDepth()
+	for len(s.processed) > 1 && s.processed[0].number+depth < b.number {
+		s.processed = s.processed[1:]
+	}
+}
+
+// Check whether processed blocks left the canonical chain.
+// If so, the state rolls back their events, the subscribers are notified,
+// and the blocks after the fork point are scanned again.
+func (s *Synchronizer) checkReorg(ctx context.Context) error {
+	if len(s.processed) == 0 {
+		return nil
+	}
+
+	// newest first, if it is canonical, so are the older ones
+	i := len(s.processed) - 1
+	for ; i >= 0; i-- {
+		ok, err := s.etherman.OnCanonicalChain(s.processed[i].hash)
+		if err != nil {
+			logger.Errorf("failed to check block on canonical chain: blk=%d, err=%v", s.processed[i].number, err)
+			return err
+		}
+		if ok {
+			break
+		}
+	}
+	if i == len(s.processed)-1 {
+		return nil
+	}
+
+	orphaned := s.processed[i+1:]
+	var forkPoint *big.Int
+	if i >= 0 {
+		forkPoint = new(big.Int).SetUint64(s.processed[i].number)
+	} else {
+		forkPoint = new(big.Int).SetUint64(orphaned[0].number - 1)
+		logger.WithField("reorgDepth", s.reorgDepth()).Warn("reorg is deeper than the kept blocks")
+	}
+
+	minted := []agreement.MintedEvent{}
+	requested := []agreement.RedeemRequestedEvent{}
+	prepared := []agreement.RedeemPreparedEvent{}
+	for _, b := range orphaned {
+		minted = append(minted, b.minted...)
+		requested = append(requested, b.requested...)
+		prepared = append(prepared, b.prepared...)
+	}
+
+	logger.WithFields(logger.Fields{
+		"forkPoint":  forkPoint,
+		"lastBlock":  s.lastFinalized,
+		"orphaned":   len(orphaned),
+		"minted":     len(minted),
+		"requested":  len(requested),
+		"prepared":   len(prepared),
+		"firstBlock": orphaned[0].number,
+	}).Warn("Chain reorg detected (eth)")
+
+	// roll back the state first
+	reorg := agreement.NewChainReorg(forkPoint, minted, requested, prepared)
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case s.st.GetChainReorgChannel() <- reorg:
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case err := <-reorg.Done:
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, ch := range s.reorgSubscribers {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case ch <- reorg:
+		}
+	}
+
+	s.processed = s.processed[:i+1]
+	s.lastFinalized = forkPoint
+	return nil
+}
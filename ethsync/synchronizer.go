@@ -1,3 +1,5 @@
+// Deprecated: the bridge server syncs Ethereum with chainsync.ChainSync and etherman.EthSyncWorker.
+// The package is kept for its test helpers.
 package ethsync
 
 import (
@@ -20,6 +22,10 @@ type Synchronizer struct {
 	etherman      *etherman.Etherman
 	st            agreement.StateChannel
 	lastFinalized *big.Int
+
+	// blocks processed, oldest first, see reorg.go
+	processed        []*processedBlock
+	reorgSubscribers []chan<- *agreement.ChainReorg
 }
 
 func New(
@@ -69,6 +75,11 @@ func (s *Synchronizer) Loop(ctx context.Context) error {
 		case <-ctx.Done():
 			return ctx.Err()
 		case <-ethTicker.C:
+			// Processed blocks may have left the canonical chain (weak finality)
+			if err := s.checkReorg(ctx); err != nil {
+				return err
+			}
+
 			// Fetch new finalized block number from rpc
 			newFinalized, err := s.etherman.GetLatestFinalizedBlockNumber()
 			if err != nil {
@@ -97,7 +108,11 @@ func (s *Synchronizer) Loop(ctx context.Context) error {
 			// Send all the events to the relevant states via channels.
 			inspecting_blk_num := new(big.Int).Add(s.lastFinalized, big.NewInt(1))
 			for inspecting_blk_num.Cmp(newFinalized) != 1 {
-				minted, requested, prepared, err := s.etherman.GetEventLogs(inspecting_blk_num)
+				header, err := s.etherman.Client().HeaderByNumber(ctx, inspecting_blk_num)
+				if err != nil {
+					return err
+				}
+				minted, requested, prepared, err := s.etherman.GetEventLogsByBlockHash(header.Hash())
 				if len(minted) > 0 || len(requested) > 0 || len(prepared) > 0 {
 					logger.WithFields(logger.Fields{
 						"block#":    inspecting_blk_num,
@@ -110,6 +125,8 @@ func (s *Synchronizer) Loop(ctx context.Context) error {
 					return err
 				}
 
+				block := &processedBlock{number: inspecting_blk_num.Uint64(), hash: header.Hash()}
+
 				for _, ev := range minted {
 					logger.WithFields(logger.Fields{
 						"block#":        inspecting_blk_num,
@@ -117,12 +134,14 @@ func (s *Synchronizer) Loop(ctx context.Context) error {
 						"amount":        ev.Amount,
 						"receiver(eth)": ev.Receiver,
 					}).Info("Minted Event Found")
-					s.st.GetNewMintedEventChannel() <- &agreement.MintedEvent{
+					x := &agreement.MintedEvent{
 						MintTxHash: ev.TxHash,
 						BtcTxId:    ev.BtcTxId,
 						Amount:     new(big.Int).Set(ev.Amount),
 						Receiver:   ev.Receiver.Bytes(),
 					}
+					block.minted = append(block.minted, *x)
+					s.st.GetNewMintedEventChannel() <- x
 				}
 
 				for _, ev := range requested {
@@ -146,6 +165,7 @@ func (s *Synchronizer) Loop(ctx context.Context) error {
 						"receiver(btc)":   x.Receiver,
 						"IsValidReceiver": x.IsValidReceiver,
 					}).Debug("RedeemRequested details")
+					block.requested = append(block.requested, *x)
 					s.st.GetNewRedeemRequestedEventChannel() <- x
 				}
 
@@ -161,7 +181,7 @@ func (s *Synchronizer) Loop(ctx context.Context) error {
 					for _, txid := range ev.OutpointTxIds {
 						outpointTxIds = append(outpointTxIds, txid)
 					}
-					s.st.GetNewRedeemPreparedEventChannel() <- &agreement.RedeemPreparedEvent{
+					x := &agreement.RedeemPreparedEvent{
 						PrepareTxHash: ev.TxHash,
 						RequestTxHash: ev.EthTxHash,
 						Requester:     ev.Requester.Bytes(),
@@ -170,6 +190,13 @@ func (s *Synchronizer) Loop(ctx context.Context) error {
 						OutpointTxIds: outpointTxIds,
 						OutpointIdxs:  ev.OutpointIdxs,
 					}
+					block.prepared = append(block.prepared, *x)
+					s.st.GetNewRedeemPreparedEventChannel() <- x
+				}
+
+				// keep the blocks to roll back, and the last one to detect reorgs
+				if len(block.minted) > 0 || len(block.requested) > 0 || len(block.prepared) > 0 || inspecting_blk_num.Cmp(newFinalized) == 0 {
+					s.recordBlock(block)
 				}
 
 				inspecting_blk_num.Add(inspecting_blk_num, big.NewInt(1))
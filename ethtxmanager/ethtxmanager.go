@@ -1,3 +1,5 @@
+// Deprecated: the bridge server sends and monitors Ethereum txs with chaintxmgr.ChainTxMgr and etherman.EthMgrWorker.
+// MigrateToChainTxMgrDB() takes over the txs monitored by this package.
 package ethtxmanager
 
 import (
@@ -38,6 +40,9 @@ type EthTxMgrConfig struct {
 
 	// Timeout on waiting for the "monitored Tx" to be mined
 	TimeoutOnMonitoringPendingTxs uint64
+
+	// Replace the "monitored Tx" with bumped fees if not mined after ? blocks (0=never)
+	ReplaceAfterBlocks uint64
 }
 
 type EthTxManager struct {
@@ -51,9 +56,15 @@ type EthTxManager struct {
 	// public key of the schnorr threshold signature
 	pubKey ethcommon.Hash
 
+	// domain that messages are signed for, nil = legacy messages
+	domain *common.SigningDomain
+
 	// Lock to prevent race conditions
 	redeemLock sync.Map
 	mintLock   sync.Map
+
+	// reorgs detected by the synchronizer
+	reorgCh chan *agreement.ChainReorg
 }
 
 func NewEthTxManager(
@@ -72,14 +83,22 @@ func NewEthTxManager(
 	}
 	pubKey := common.BigInt2Bytes32(pk)
 
+	domain, err := etherman.SigningDomain()
+	if err != nil {
+		logger.Errorf("failed to get signing domain: err=%v", err)
+		return nil, err
+	}
+
 	return &EthTxManager{
 		etherman:         etherman,
 		statedb:          statedb,
 		cfg:              cfg,
 		mgrdb:            mgrdb,
 		pubKey:           pubKey,
+		domain:           domain,
 		schnorrWallet:    schnorrWallet,
 		btcUTXOResponder: btcUTXOResponder,
+		reorgCh:          make(chan *agreement.ChainReorg, 1),
 	}, nil
 }
 
@@ -122,6 +141,7 @@ func (txmgr *EthTxManager) Loop(ctx context.Context) error {
 			case ErrDBOpGetRedeemsByStatus:
 			case ErrDBOpGetMonitoredTxs:
 			case ErrDBOpGetUnMinted:
+			case ErrDBOpMarkReorgedTx:
 			// wallet errors
 			case ErrBtcWalletRequest:
 			case ErrSchnorrWalletSign:
@@ -130,10 +150,11 @@ func (txmgr *EthTxManager) Loop(ctx context.Context) error {
 				logger.Fatal(err)
 			}
 			return err
-		//////////////////////////////////////////
-		// TODO: handle of case of chain reorg
-		// case <-reorgCh: // chain reorg detected by synchronizer
-		//////////////////////////////////////////
+		// chain reorg detected by synchronizer
+		case reorg := <-txmgr.reorgCh:
+			if err := txmgr.handleReorg(reorg); err != nil {
+				errCh <- err
+			}
 		case <-tickerToMonitor.C:
 			mtxs, err := txmgr.mgrdb.GetMonitoredTxsByStatus(Pending)
 			if err != nil {
@@ -185,23 +206,11 @@ func (txmgr *EthTxManager) Loop(ctx context.Context) error {
 					logger.Errorf("failed to get monitored tx in db by request tx hash: err=%v", err)
 					errCh <- ErrDBOpGetMonitoredTxByID
 				}
-				if len(mts) == 0 { // no pending tx that has tried to prepare the redeem
-					// Add the redeem to the list of redeems to be prepared
-					// if there is no pending tx that has tried to prepare the redeem
+				// Add the redeem to the list of redeems to be prepared
+				// if there is no pending tx that has tried to prepare the redeem,
+				// or all of them have timed out or been orphaned by a reorg
+				if canResend(mts) {
 					redeems = append(redeems, redeem)
-				} else { // if monitored tx, but the prepre tx is timeout, re-prepare
-					// Add the redeem to the list of redeems to be prepared
-					// if all the txs that have tried to prepare the redeem have timed out
-					isTimeout := true
-					for _, mt := range mts {
-						if mt.Status != Timeout {
-							isTimeout = false
-							break
-						}
-					}
-					if isTimeout {
-						redeems = append(redeems, redeem)
-					}
 				}
 			}
 
@@ -252,19 +261,8 @@ func (txmgr *EthTxManager) Loop(ctx context.Context) error {
 					logger.Errorf("failed to get monitored tx by id: err=%v", err)
 					errCh <- ErrDBOpGetMonitoredTxByID
 				}
-				if len(monitoredMints) == 0 {
+				if canResend(monitoredMints) {
 					toMints = append(toMints, req)
-				} else {
-					isTimeout := true
-					for _, mt := range monitoredMints {
-						if mt.Status != Timeout {
-							isTimeout = false
-							break
-						}
-					}
-					if isTimeout {
-						toMints = append(toMints, req)
-					}
 				}
 			}
 
@@ -9,6 +9,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/TEENet-io/bridge-go/agreement"
 	"github.com/TEENet-io/bridge-go/common"
 	"github.com/TEENet-io/bridge-go/etherman"
 	"github.com/TEENet-io/bridge-go/ethsync"
@@ -66,7 +67,7 @@ func newTestEnv(t *testing.T, file string, btcChainConfig *chaincfg.Params) *tes
 	assert.NoError(t, err)
 
 	// create a eth2btc state db
-	statedb, err := state.NewStateDB(sqldb)
+	statedb, err := state.NewStateDB(sqldb, state.ChainNamespace(common.DEPOSIT_CHAIN_TYPE_EVM, chainID.Uint64()))
 	assert.NoError(t, err)
 
 	// create a eth2btc state from the eth2btc statedb
@@ -519,3 +520,30 @@ func printCurrBlockNumber(env *testEnv, action string) {
 	blk, _ := env.sim.Chain.Backend.Client().BlockByNumber(context.Background(), nil)
 	logger.WithField("block", blk.Number()).Debug(action)
 }
+
+func TestHandleReorg(t *testing.T) {
+	mgrdb, close := newMgr(t)
+	defer close()
+	txmgr := &EthTxManager{mgrdb: mgrdb}
+
+	mt := RandMonitoredTx(Success, 1)
+	err := mgrdb.InsertPendingMonitoredTx(mt)
+	assert.NoError(t, err)
+	err = mgrdb.UpdateMonitoredTxStatus(mt.TxHash, Success)
+	assert.NoError(t, err)
+	assert.False(t, canResend([]*MonitoredTx{mt}))
+
+	// the mint tx is orphaned, another tx not sent by the bridge is ignored
+	reorg := agreement.NewChainReorg(big.NewInt(1), []agreement.MintedEvent{
+		{MintTxHash: mt.TxHash},
+		{MintTxHash: common.RandBytes32()},
+	}, nil, nil)
+	err = txmgr.handleReorg(reorg)
+	assert.NoError(t, err)
+
+	mts, err := mgrdb.GetMonitoredTxsById(mt.RefIdentifier)
+	assert.NoError(t, err)
+	assert.Len(t, mts, 1)
+	assert.Equal(t, Reorg, mts[0].Status)
+	assert.True(t, canResend(mts))
+}
@@ -3,6 +3,7 @@ package ethtxmanager
 import (
 	"database/sql"
 	"errors"
+	"strings"
 
 	"github.com/TEENet-io/bridge-go/common"
 	"github.com/TEENet-io/bridge-go/database"
@@ -13,9 +14,11 @@ var (
 	ErrMintedAtNotSet         = errors.New("mintedAt not set")
 	ErrInvalidStatus          = errors.New("invalid status")
 	ErrMinedAtSetForPendingTx = errors.New("minedAt set for pending tx")
+	ErrReplacedTxNotSet       = errors.New("replaced tx not set")
 )
 
 type EthTxManagerDB struct {
+	db        *sql.DB
 	stmtCache *database.StmtCache
 }
 
@@ -24,12 +27,46 @@ func NewEthTxManagerDB(db *sql.DB) (*EthTxManagerDB, error) {
 	if _, err := db.Exec(MonitoredTxTable); err != nil {
 		return nil, err
 	}
+	if err := upgradeMonitoredTxTable(db); err != nil {
+		return nil, err
+	}
 
 	return &EthTxManagerDB{
+		db:        db,
 		stmtCache: database.NewStmtCache(db),
 	}, nil
 }
 
+// Rebuild the MonitoredTx table created by an older version (without tx replacement).
+func upgradeMonitoredTxTable(db *sql.DB) error {
+	var schema string
+	if err := db.QueryRow(queryGetMonitoredTxTableSchema).Scan(&schema); err != nil {
+		return err
+	}
+	if strings.Contains(schema, "replaces") {
+		return nil
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, query := range []string{
+		queryRenameOldMonitoredTxTable,
+		MonitoredTxTable,
+		queryCopyOldMonitoredTxs,
+		queryDropOldMonitoredTxTable,
+	} {
+		if _, err := tx.Exec(query); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
 func (db *EthTxManagerDB) Close() {
 	db.stmtCache.Clear()
 }
@@ -85,6 +122,58 @@ func (db *EthTxManagerDB) InsertMonitoredTx(mt *MonitoredTx) error {
 	return nil
 }
 
+// Insert the pending tx (mt) that replaces the tx mt.Replaces,
+// and mark the replaced tx as "replaced", both or none.
+func (db *EthTxManagerDB) InsertReplacementTx(mt *MonitoredTx) error {
+	if mt.Replaces == common.EmptyHash {
+		return ErrReplacedTxNotSet
+	}
+
+	sqlMt := &sqlMonitoredTx{}
+	sqlMt.encode(mt)
+
+	tx, err := db.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(
+		queryInsertReplacementTx,
+		sqlMt.TxHash,
+		sqlMt.Id,
+		sqlMt.SentAfter,
+		string(Pending),
+		sqlMt.Replaces,
+	); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(queryUpdateMonitoredTxStatus, string(Replaced), sqlMt.Replaces); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Get the txs replaced, directly or not, by the tx (mt), newest first.
+func (db *EthTxManagerDB) GetReplacedTxs(mt *MonitoredTx) ([]*MonitoredTx, error) {
+	replaced := []*MonitoredTx{}
+	for next := mt.Replaces; next != common.EmptyHash; {
+		prev, ok, err := db.GetMonitoredTxByTxHash(next)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			break
+		}
+		replaced = append(replaced, prev)
+		next = prev.Replaces
+	}
+
+	return replaced, nil
+}
+
 func (db *EthTxManagerDB) GetMonitoredTxByTxHash(txHash ethcommon.Hash) (*MonitoredTx, bool, error) {
 	stmt, err := db.stmtCache.Prepare(queryGetMonitoredTxByTxHash)
 	if err != nil {
@@ -95,6 +184,7 @@ func (db *EthTxManagerDB) GetMonitoredTxByTxHash(txHash ethcommon.Hash) (*Monito
 
 	var (
 		mintedAt sql.NullString
+		replaces sql.NullString
 		sqlMt    sqlMonitoredTx
 	)
 
@@ -104,6 +194,7 @@ func (db *EthTxManagerDB) GetMonitoredTxByTxHash(txHash ethcommon.Hash) (*Monito
 		&sqlMt.SentAfter,
 		&mintedAt,
 		&sqlMt.Status,
+		&replaces,
 	); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, false, nil
@@ -113,6 +204,9 @@ func (db *EthTxManagerDB) GetMonitoredTxByTxHash(txHash ethcommon.Hash) (*Monito
 	if mintedAt.Valid {
 		sqlMt.MinedAt = mintedAt.String
 	}
+	if replaces.Valid {
+		sqlMt.Replaces = replaces.String
+	}
 
 	return sqlMt.decode(), true, nil
 }
@@ -136,6 +230,7 @@ func (db *EthTxManagerDB) GetMonitoredTxsById(Id ethcommon.Hash) ([]*MonitoredTx
 	var (
 		mts      []*MonitoredTx
 		mintedAt sql.NullString
+		replaces sql.NullString
 	)
 	for rows.Next() {
 		var sqlMt sqlMonitoredTx
@@ -145,6 +240,7 @@ func (db *EthTxManagerDB) GetMonitoredTxsById(Id ethcommon.Hash) ([]*MonitoredTx
 			&sqlMt.SentAfter,
 			&mintedAt,
 			&sqlMt.Status,
+			&replaces,
 		); err != nil {
 			return nil, err
 		}
@@ -152,6 +248,9 @@ func (db *EthTxManagerDB) GetMonitoredTxsById(Id ethcommon.Hash) ([]*MonitoredTx
 		if mintedAt.Valid {
 			sqlMt.MinedAt = mintedAt.String
 		}
+		if replaces.Valid {
+			sqlMt.Replaces = replaces.String
+		}
 
 		mts = append(mts, sqlMt.decode())
 	}
@@ -177,6 +276,7 @@ func (db *EthTxManagerDB) GetMonitoredTxsByStatus(status MonitoredTxStatus) ([]*
 	var (
 		mts      []*MonitoredTx
 		mintedAt sql.NullString
+		replaces sql.NullString
 	)
 
 	for rows.Next() {
@@ -187,6 +287,7 @@ func (db *EthTxManagerDB) GetMonitoredTxsByStatus(status MonitoredTxStatus) ([]*
 			&sqlMt.SentAfter,
 			&mintedAt,
 			&sqlMt.Status,
+			&replaces,
 		); err != nil {
 			return nil, err
 		}
@@ -194,6 +295,9 @@ func (db *EthTxManagerDB) GetMonitoredTxsByStatus(status MonitoredTxStatus) ([]*
 		if mintedAt.Valid {
 			sqlMt.MinedAt = mintedAt.String
 		}
+		if replaces.Valid {
+			sqlMt.Replaces = replaces.String
+		}
 
 		mts = append(mts, sqlMt.decode())
 	}
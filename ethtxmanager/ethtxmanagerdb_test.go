@@ -144,3 +144,82 @@ func TestGetMonitoredTxsById(t *testing.T) {
 	assert.Equal(t, mts[0], chk[0])
 	assert.Equal(t, mts[1], chk[1])
 }
+
+func TestInsertReplacementTx(t *testing.T) {
+	etm, close := newMgr(t)
+	defer close()
+
+	mt := RandMonitoredTx(Pending, 1)
+	err := etm.InsertPendingMonitoredTx(mt)
+	assert.NoError(t, err)
+
+	// replacement without the replaced tx
+	err = etm.InsertReplacementTx(RandMonitoredTx(Pending, 1))
+	assert.ErrorIs(t, err, ErrReplacedTxNotSet)
+
+	r1 := RandMonitoredTx(Pending, 1)
+	r1.RefIdentifier = mt.RefIdentifier
+	r1.Replaces = mt.TxHash
+	err = etm.InsertReplacementTx(r1)
+	assert.NoError(t, err)
+
+	r2 := RandMonitoredTx(Pending, 1)
+	r2.RefIdentifier = mt.RefIdentifier
+	r2.Replaces = r1.TxHash
+	err = etm.InsertReplacementTx(r2)
+	assert.NoError(t, err)
+
+	pending, err := etm.GetMonitoredTxsByStatus(Pending)
+	assert.NoError(t, err)
+	assert.Len(t, pending, 1)
+	assert.Equal(t, r2, pending[0])
+
+	replaced, err := etm.GetReplacedTxs(r2)
+	assert.NoError(t, err)
+	assert.Len(t, replaced, 2)
+	assert.Equal(t, r1.TxHash, replaced[0].TxHash)
+	assert.Equal(t, mt.TxHash, replaced[1].TxHash)
+	assert.Equal(t, Replaced, replaced[0].Status)
+	assert.Equal(t, Replaced, replaced[1].Status)
+	assert.True(t, canResend(replaced))
+}
+
+func TestUpgradeMonitoredTxTable(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	assert.NoError(t, err)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	// table of an older version
+	_, err = db.Exec(`CREATE TABLE MonitoredTx (
+		txHash CHAR(64) PRIMARY KEY NOT NULL,
+		id CHAR(64) NOT NULL,
+		sentAfter CHAR(64) NOT NULL,
+		minedAt CHAR(64),
+		status VARCHAR(10) NOT NULL,
+		CONSTRAINT chk_status CHECK (status IN ('pending', 'timeout', 'success', 'reverted', 'reorg'))
+	);`)
+	assert.NoError(t, err)
+	mt := RandMonitoredTx(Pending, 1)
+	_, err = db.Exec(`INSERT INTO MonitoredTx (txHash, id, sentAfter, status) VALUES (?,?,?,?);`,
+		mt.TxHash.String()[2:], mt.RefIdentifier.String()[2:], mt.SentAfter.String()[2:], string(Pending))
+	assert.NoError(t, err)
+
+	etm, err := NewEthTxManagerDB(db)
+	assert.NoError(t, err)
+	defer etm.Close()
+
+	chk, ok, err := etm.GetMonitoredTxByTxHash(mt.TxHash)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, mt, chk)
+
+	r := RandMonitoredTx(Pending, 1)
+	r.Replaces = mt.TxHash
+	err = etm.InsertReplacementTx(r)
+	assert.NoError(t, err)
+
+	// upgraded once
+	_, err = NewEthTxManagerDB(db)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,111 @@
+package ethtxmanager
+
+import (
+	"database/sql"
+	"errors"
+	"math/big"
+
+	"github.com/TEENet-io/bridge-go/agreement"
+	"github.com/TEENet-io/bridge-go/chaintxmgrdb"
+	"github.com/ethereum/go-ethereum"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	logger "github.com/sirupsen/logrus"
+)
+
+// Status of a legacy monitored tx in the chain tx manager.
+// A reorged or replaced tx is not expected to be mined anymore,
+// it is migrated as timed out so that the reference can be sent again.
+var migratedStatus = map[MonitoredTxStatus]agreement.MonitoredTxStatus{
+	Pending:  agreement.Pending,
+	Success:  agreement.Success,
+	Reverted: agreement.Reverted,
+	Timeout:  agreement.Timeout,
+	Reorg:    agreement.Timeout,
+	Replaced: agreement.Timeout,
+}
+
+// Tell if the db holds the table of the legacy eth tx manager (to be migrated).
+func HasMonitoredTxTable(db *sql.DB) (bool, error) {
+	var schema string
+	err := db.QueryRow(queryGetMonitoredTxTableSchema).Scan(&schema)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Copy the monitored txs of the legacy eth tx manager to the chain tx manager db.
+// Txs already in dst are left untouched, so the migration can be run on every start.
+// blockNumber resolves the block hashes of the legacy txs (SentAfter, MinedAt) to block numbers,
+// ethereum.NotFound (eg. a block orphaned by a reorg) leaves the block number unknown.
+// Return the number of migrated txs.
+func MigrateToChainTxMgrDB(
+	src *EthTxManagerDB,
+	dst chaintxmgrdb.ChainTxMgrDB,
+	blockNumber func(blockHash ethcommon.Hash) (*big.Int, error),
+) (int, error) {
+	migrated := 0
+	for status, newStatus := range migratedStatus {
+		mts, err := src.GetMonitoredTxsByStatus(status)
+		if err != nil {
+			return migrated, err
+		}
+
+		for _, mt := range mts {
+			newStatus := newStatus
+			existing, err := dst.GetMonitoredTxByTxIdentifier(mt.TxHash.Bytes())
+			if err != nil {
+				return migrated, err
+			}
+			if existing != nil {
+				continue
+			}
+
+			// the timeout of a pending tx counts from the block it is sent after
+			var sentAt, foundAt *big.Int
+			if status == Pending && mt.SentAfter != (ethcommon.Hash{}) {
+				sentAt, err = knownBlockNumber(blockNumber, mt.SentAfter)
+				if err != nil {
+					return migrated, err
+				}
+			}
+			if (status == Success || status == Reverted) && mt.MinedAt != (ethcommon.Hash{}) {
+				foundAt, err = knownBlockNumber(blockNumber, mt.MinedAt)
+				if err != nil {
+					return migrated, err
+				}
+				// mined in a block orphaned since, the status is checked again
+				if foundAt == nil {
+					newStatus = agreement.Pending
+				}
+			}
+
+			err = dst.InsertMonitoredTx(&chaintxmgrdb.MonitoredTx{
+				TxIdentifier:                mt.TxHash.Bytes(),
+				RefIdentifier:               mt.RefIdentifier.Bytes(),
+				SentBlockchainLedgerNumber:  sentAt,
+				FoundBlockchainLedgerNumber: foundAt,
+				TxStatus:                    newStatus,
+			})
+			if err != nil {
+				return migrated, err
+			}
+			migrated++
+		}
+	}
+
+	if migrated > 0 {
+		logger.WithField("num", migrated).Info("legacy eth monitored txs migrated")
+	}
+	return migrated, nil
+}
+
+// Block number of the block hash, nil if the block is unknown (eg. orphaned by a reorg).
+func knownBlockNumber(blockNumber func(blockHash ethcommon.Hash) (*big.Int, error), blockHash ethcommon.Hash) (*big.Int, error) {
+	num, err := blockNumber(blockHash)
+	if errors.Is(err, ethereum.NotFound) {
+		logger.WithField("blockHash", blockHash.String()).Warn("block of legacy monitored tx not found, block number unknown")
+		return nil, nil
+	}
+	return num, err
+}
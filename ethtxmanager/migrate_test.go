@@ -0,0 +1,87 @@
+package ethtxmanager
+
+import (
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	"github.com/TEENet-io/bridge-go/agreement"
+	"github.com/TEENet-io/bridge-go/chaintxmgrdb"
+	"github.com/ethereum/go-ethereum"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrateToChainTxMgrDB(t *testing.T) {
+	etm, close := newMgr(t)
+	defer close()
+
+	ok, err := HasMonitoredTxTable(etm.db)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	dst, err := chaintxmgrdb.NewSQLiteChainTxMgrDB(filepath.Join(t.TempDir(), "mgr.db"))
+	assert.NoError(t, err)
+	defer dst.Close()
+
+	pending := RandMonitoredTx(Pending, 1)
+	success := RandMonitoredTx(Success, 1)
+	reorged := RandMonitoredTx(Reorg, 1)
+	// their blocks are orphaned
+	pendingOrphaned := RandMonitoredTx(Pending, 1)
+	successOrphaned := RandMonitoredTx(Success, 1)
+	for _, mt := range []*MonitoredTx{pending, success, reorged, pendingOrphaned, successOrphaned} {
+		assert.NoError(t, etm.InsertMonitoredTx(mt))
+	}
+
+	blocks := map[ethcommon.Hash]*big.Int{
+		pending.SentAfter: big.NewInt(10),
+		success.MinedAt:   big.NewInt(25),
+	}
+	blockNumber := func(blockHash ethcommon.Hash) (*big.Int, error) {
+		num, ok := blocks[blockHash]
+		if !ok {
+			return nil, ethereum.NotFound
+		}
+		return num, nil
+	}
+
+	n, err := MigrateToChainTxMgrDB(etm, dst, blockNumber)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, n)
+
+	mt, err := dst.GetMonitoredTxByTxIdentifier(pending.TxHash.Bytes())
+	assert.NoError(t, err)
+	assert.Equal(t, pending.RefIdentifier.Bytes(), mt.RefIdentifier)
+	assert.Equal(t, big.NewInt(10), mt.SentBlockchainLedgerNumber)
+	assert.Nil(t, mt.FoundBlockchainLedgerNumber)
+	assert.Equal(t, agreement.Pending, mt.TxStatus)
+
+	mt, err = dst.GetMonitoredTxByTxIdentifier(success.TxHash.Bytes())
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(25), mt.FoundBlockchainLedgerNumber)
+	assert.Equal(t, agreement.Success, mt.TxStatus)
+
+	// orphaned tx, can be sent again
+	mt, err = dst.GetMonitoredTxByTxIdentifier(reorged.TxHash.Bytes())
+	assert.NoError(t, err)
+	assert.Nil(t, mt.SentBlockchainLedgerNumber)
+	assert.Nil(t, mt.FoundBlockchainLedgerNumber)
+	assert.Equal(t, agreement.Timeout, mt.TxStatus)
+
+	// block numbers unknown
+	mt, err = dst.GetMonitoredTxByTxIdentifier(pendingOrphaned.TxHash.Bytes())
+	assert.NoError(t, err)
+	assert.Nil(t, mt.SentBlockchainLedgerNumber)
+	assert.Equal(t, agreement.Pending, mt.TxStatus)
+
+	mt, err = dst.GetMonitoredTxByTxIdentifier(successOrphaned.TxHash.Bytes())
+	assert.NoError(t, err)
+	assert.Nil(t, mt.FoundBlockchainLedgerNumber)
+	assert.Equal(t, agreement.Pending, mt.TxStatus)
+
+	// nothing left to migrate
+	n, err = MigrateToChainTxMgrDB(etm, dst, blockNumber)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n)
+}
@@ -40,6 +40,7 @@ func (txmgr *EthTxManager) mint(ctx context.Context, mint *state.Mint) error {
 		BtcTxId:  mint.BtcTxId,
 		Receiver: mint.Receiver,
 		Amount:   common.BigIntClone(mint.Amount),
+		Domain:   txmgr.domain,
 	}
 	signingHash := params.SigningHash()
 
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"math/big"
 
+	"github.com/TEENet-io/bridge-go/etherman"
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/core/types"
 	logger "github.com/sirupsen/logrus"
@@ -20,24 +21,35 @@ var (
 
 // monitor monitors the tx until it is mined or timeout
 // Monitoring procedure:
-// 1. Check on Ethereum if the tx is mined, if mined, update its status to either "success" or "reverted"
-// 2. Check if the tx is timeout for monitoring update its status to "timeout"
+//  1. Check on Ethereum if the tx, or one of the txs it replaced, is mined,
+//     if mined, update its status to either "success" or "reverted"
+//  2. Check if the tx is stuck for ReplaceAfterBlocks, replace it with bumped fees
+//  3. Check if the tx is timeout for monitoring update its status to "timeout"
 func (txmgr *EthTxManager) monitorPendingTxs(ctx context.Context, mtx *MonitoredTx) error {
 	newLogger := logger.WithFields(logger.Fields{
 		"txHash": mtx.TxHash.String(),
 		"Id":     mtx.RefIdentifier.String(),
 	})
 
-	// get transaction receipt
-	receipt, err := txmgr.etherman.Client().TransactionReceipt(ctx, mtx.TxHash)
-	if err != nil && err.Error() != ethereum.NotFound.Error() {
-		newLogger.Errorf("failed to get transaction receipt: err=%v", err)
-		return ErrEthermanTransactionReceipt
+	// the replaced txs share the nonce, whichever is mined
+	replaced, err := txmgr.mgrdb.GetReplacedTxs(mtx)
+	if err != nil {
+		newLogger.Errorf("failed to get replaced txs: err=%v", err)
+		return ErrDBOpGetMonitoredTxByID
 	}
 
-	// if the tx is mined, remove it from db
-	if receipt != nil && receipt.BlockNumber != nil {
-		newLogger.Debug("evm tx has been mined")
+	for _, tx := range append([]*MonitoredTx{mtx}, replaced...) {
+		// get transaction receipt
+		receipt, err := txmgr.etherman.Client().TransactionReceipt(ctx, tx.TxHash)
+		if err != nil && err.Error() != ethereum.NotFound.Error() {
+			newLogger.Errorf("failed to get transaction receipt: err=%v", err)
+			return ErrEthermanTransactionReceipt
+		}
+		if receipt == nil || receipt.BlockNumber == nil {
+			continue
+		}
+
+		newLogger.WithField("minedTx", tx.TxHash.String()).Debug("evm tx has been mined")
 
 		var status MonitoredTxStatus
 		if receipt.Status == 0 {
@@ -47,11 +59,20 @@ func (txmgr *EthTxManager) monitorPendingTxs(ctx context.Context, mtx *Monitored
 			newLogger.Debug("evm tx mined and successfully executed")
 			status = Success
 		}
-		err := txmgr.mgrdb.UpdateMonitoredTxStatus(mtx.TxHash, status)
+		err = txmgr.mgrdb.UpdateMonitoredTxStatus(tx.TxHash, status)
 		if err != nil {
 			newLogger.Errorf("failed to update monitored evm tx status: err=%v", err)
 			return ErrDBOpUpdateMonitoredTxStatus
 		}
+		if tx != mtx {
+			// a replaced tx made it first
+			err = txmgr.mgrdb.UpdateMonitoredTxStatus(mtx.TxHash, Replaced)
+			if err != nil {
+				newLogger.Errorf("failed to update monitored evm tx status: err=%v", err)
+				return ErrDBOpUpdateMonitoredTxStatus
+			}
+		}
+		return nil
 	}
 
 	var sentAfter *types.Header
@@ -73,6 +94,17 @@ func (txmgr *EthTxManager) monitorPendingTxs(ctx context.Context, mtx *Monitored
 
 	diff := latest.Number.Uint64() - sentAfter.Number.Uint64()
 	newLogger.Debugf("latest_blk %d, sentAfter_blk %d", latest.Number.Uint64(), sentAfter.Number.Uint64())
+
+	if txmgr.cfg.ReplaceAfterBlocks > 0 && diff >= txmgr.cfg.ReplaceAfterBlocks {
+		replaced, err := txmgr.replaceTx(mtx, latest, newLogger)
+		if err != nil {
+			return err
+		}
+		if replaced {
+			return nil
+		}
+	}
+
 	if diff > txmgr.cfg.TimeoutOnMonitoringPendingTxs {
 		newLogger.Debugf("tx has not been mined for %d blocks", txmgr.cfg.TimeoutOnMonitoringPendingTxs)
 		err := txmgr.mgrdb.UpdateMonitoredTxStatus(mtx.TxHash, Timeout)
@@ -85,3 +117,38 @@ func (txmgr *EthTxManager) monitorPendingTxs(ctx context.Context, mtx *Monitored
 
 	return nil
 }
+
+// Replace the stuck tx with the same tx at the same nonce and bumped fees,
+// the replacement is monitored instead from the latest block.
+// Return false if the tx can not be replaced (mined/dropped meanwhile, or at the fee cap),
+// it then times out as usual.
+func (txmgr *EthTxManager) replaceTx(mtx *MonitoredTx, latest *types.Header, newLogger *logger.Entry) (bool, error) {
+	tx, err := txmgr.etherman.ReplaceTx(mtx.TxHash)
+	if err != nil {
+		if errors.Is(err, etherman.ErrTxNotPending) || errors.Is(err, etherman.ErrFeeCapReached) {
+			newLogger.Warnf("stuck tx not replaced: err=%v", err)
+		} else {
+			newLogger.Errorf("failed to replace stuck tx: err=%v", err)
+		}
+		return false, nil
+	}
+
+	mt := &MonitoredTx{
+		TxHash:        tx.Hash(),
+		RefIdentifier: mtx.RefIdentifier,
+		SentAfter:     latest.Hash(),
+		SentAfterBlk:  latest.Number.Int64(),
+		Replaces:      mtx.TxHash,
+	}
+	if err := txmgr.mgrdb.InsertReplacementTx(mt); err != nil {
+		newLogger.Errorf("failed to insert replacement tx: err=%v", err)
+		return false, ErrDBOpInsertMonitoredTx
+	}
+	newLogger.WithFields(logger.Fields{
+		"replacement": tx.Hash().String(),
+		"maxFee":      tx.GasFeeCap().String(),
+		"maxTip":      tx.GasTipCap().String(),
+	}).Warn("stuck tx replaced with bumped fees")
+
+	return true, nil
+}
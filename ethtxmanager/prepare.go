@@ -72,6 +72,7 @@ func (txmgr *EthTxManager) prepareRedeem(ctx context.Context, redeem *state.Rede
 	// Compute the signing hash
 	redeem.Outpoints = append([]agreement.BtcOutpoint{}, outpoints...)
 	params := createPrepareParams(redeem)
+	params.Domain = txmgr.domain
 	signingHash := params.SigningHash()
 
 	// request signature
@@ -0,0 +1,63 @@
+package ethtxmanager
+
+import (
+	"errors"
+
+	"github.com/TEENet-io/bridge-go/agreement"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	logger "github.com/sirupsen/logrus"
+)
+
+var ErrDBOpMarkReorgedTx = errors.New("failed to mark monitored tx as reorged")
+
+// Channel that receives the reorgs detected by the synchronizer (see ethsync.Synchronizer.SubscribeReorg).
+func (txmgr *EthTxManager) ReorgChannel() chan<- *agreement.ChainReorg {
+	return txmgr.reorgCh
+}
+
+// Mark the mint/prepare txs of the orphaned blocks as "reorg".
+// Once the state rolled back the mints/redeems, they are sent again,
+// unless the tx lands on the new chain first (IsMinted/IsPrepared is checked before sending).
+func (txmgr *EthTxManager) handleReorg(reorg *agreement.ChainReorg) error {
+	txHashes := []ethcommon.Hash{}
+	for _, ev := range reorg.Minted {
+		txHashes = append(txHashes, ev.MintTxHash)
+	}
+	for _, ev := range reorg.Prepared {
+		txHashes = append(txHashes, ev.PrepareTxHash)
+	}
+
+	for _, txHash := range txHashes {
+		mtx, ok, err := txmgr.mgrdb.GetMonitoredTxByTxHash(txHash)
+		if err != nil {
+			logger.Errorf("failed to get monitored tx: txHash=%s, err=%v", txHash.String(), err)
+			return ErrDBOpGetMonitoredTxByID
+		}
+		if !ok {
+			continue // not sent by this bridge
+		}
+
+		if err := txmgr.mgrdb.UpdateMonitoredTxStatus(mtx.TxHash, Reorg); err != nil {
+			logger.Errorf("failed to mark monitored tx as reorged: txHash=%s, err=%v", mtx.TxHash.String(), err)
+			return ErrDBOpMarkReorgedTx
+		}
+		logger.WithFields(logger.Fields{
+			"txHash": mtx.TxHash.String(),
+			"Id":     mtx.RefIdentifier.String(),
+		}).Warn("monitored tx orphaned by reorg, to be sent again")
+	}
+
+	return nil
+}
+
+// Can the mint/redeem be sent (again)?
+// Yes if no tx is sent for it, or all the sent txs timed out or were orphaned by a reorg
+// (replaced txs are followed by their replacement).
+func canResend(mts []*MonitoredTx) bool {
+	for _, mt := range mts {
+		if mt.Status != Timeout && mt.Status != Reorg && mt.Status != Replaced {
+			return false
+		}
+	}
+	return true
+}
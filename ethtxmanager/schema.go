@@ -6,27 +6,41 @@ var (
 	strZeroBytes32 = strings.Repeat("0", 64)
 
 	// sentAfter == hash of the latest block before sending the tx
+	// replaces == hash of the tx replaced by this one (same nonce, higher fees)
 	MonitoredTxTable = `CREATE TABLE IF NOT EXISTS MonitoredTx (
 		txHash CHAR(64) PRIMARY KEY NOT NULL,
 		id CHAR(64) NOT NULL,
 		sentAfter CHAR(64) NOT NULL,
 		minedAt CHAR(64),
 		status VARCHAR(10) NOT NULL,
+		replaces CHAR(64),
 		CONSTRAINT chk_txHash CHECK (txHash != '` + strZeroBytes32 + `'),
 		CONSTRAINT chk_id CHECK (id != '` + strZeroBytes32 + `'),
 		CONSTRAINT chk_sentAfter CHECK (sentAfter != '` + strZeroBytes32 + `'),
 		CONSTRAINT chk_minedAt CHECK (minedAt IS NULL OR minedAt != '` + strZeroBytes32 + `'),
-		CONSTRAINT chk_status CHECK (status IN ('pending', 'timeout', 'success', 'reverted', 'reorg'))
+		CONSTRAINT chk_status CHECK (status IN ('pending', 'timeout', 'success', 'reverted', 'reorg', 'replaced'))
 	);`
 
+	// Tables created by older versions miss the "replaced" status and the replaces column,
+	// sqlite can not alter a CHECK constraint so the table is rebuilt.
+	queryGetMonitoredTxTableSchema = `SELECT sql FROM sqlite_master WHERE type = 'table' AND name = 'MonitoredTx';`
+	queryRenameOldMonitoredTxTable = `ALTER TABLE MonitoredTx RENAME TO MonitoredTxOld;`
+	queryCopyOldMonitoredTxs       = `INSERT INTO MonitoredTx (txHash, id, sentAfter, minedAt, status)
+		SELECT txHash, id, sentAfter, minedAt, status FROM MonitoredTxOld;`
+	queryDropOldMonitoredTxTable = `DROP TABLE MonitoredTxOld;`
+
+	monitoredTxColumns = `txHash, id, sentAfter, minedAt, status, replaces`
+
 	queryInsertPendingMonitoredTx = `INSERT INTO MonitoredTx (
 		txHash, id, sentAfter, status) VALUES (?,?,?,?);`
 	queryInsertMonitoredTx = `INSERT INTO MonitoredTx (
 		txHash, id, sentAfter, minedAt, status) VALUES (?,?,?,?,?);`
+	queryInsertReplacementTx = `INSERT INTO MonitoredTx (
+		txHash, id, sentAfter, status, replaces) VALUES (?,?,?,?,?);`
 	queryUpdateMonitoredTxStatus     = `UPDATE MonitoredTx SET status = ? WHERE txHash = ?;`
 	queryUpdateMonitoredTxAfterMined = `UPDATE MonitoredTx SET minedAt = ?, status = ? WHERE txHash = ?;`
-	queryGetMonitoredTxByTxHash      = `SELECT * FROM MonitoredTx WHERE txHash = ?;`
-	queryGetMonitoredTxsById         = `SELECT * FROM MonitoredTx WHERE id = ?;`
-	queryGetMonitoredTxsByStatus     = `SELECT * FROM MonitoredTx WHERE status = ?;`
+	queryGetMonitoredTxByTxHash      = `SELECT ` + monitoredTxColumns + ` FROM MonitoredTx WHERE txHash = ?;`
+	queryGetMonitoredTxsById         = `SELECT ` + monitoredTxColumns + ` FROM MonitoredTx WHERE id = ?;`
+	queryGetMonitoredTxsByStatus     = `SELECT ` + monitoredTxColumns + ` FROM MonitoredTx WHERE status = ?;`
 	queryDeleteMonitoredTxByTxHash   = `DELETE FROM MonitoredTx WHERE txHash = ?;`
 )
@@ -14,6 +14,7 @@ const (
 	Success  MonitoredTxStatus = "success"
 	Reverted MonitoredTxStatus = "reverted"
 	Reorg    MonitoredTxStatus = "reorg"
+	Replaced MonitoredTxStatus = "replaced" // replaced by a tx with the same nonce and higher fees
 )
 
 // This is the type that Tx manager will continiously monitor
@@ -25,6 +26,7 @@ type MonitoredTx struct {
 	SentAfterBlk  int64          // block number of the latest block before sending the tx
 	MinedAt       ethcommon.Hash // hash of the block where the tx is mined
 	Status        MonitoredTxStatus
+	Replaces      ethcommon.Hash // hash of the tx replaced by this one, empty if none
 }
 
 // Store in SQLite
@@ -35,6 +37,7 @@ type sqlMonitoredTx struct {
 	SentAfterBlk int64
 	MinedAt      string
 	Status       string
+	Replaces     string
 }
 
 func (s *sqlMonitoredTx) encode(mt *MonitoredTx) *sqlMonitoredTx {
@@ -44,6 +47,7 @@ func (s *sqlMonitoredTx) encode(mt *MonitoredTx) *sqlMonitoredTx {
 	s.SentAfterBlk = mt.SentAfterBlk
 	s.MinedAt = mt.MinedAt.String()[2:]
 	s.Status = string(mt.Status)
+	s.Replaces = mt.Replaces.String()[2:]
 
 	return s
 }
@@ -56,5 +60,6 @@ func (s *sqlMonitoredTx) decode() *MonitoredTx {
 		SentAfterBlk:  s.SentAfterBlk,
 		MinedAt:       common.HexStrToBytes32(s.MinedAt),
 		Status:        MonitoredTxStatus(s.Status),
+		Replaces:      common.HexStrToBytes32(s.Replaces),
 	}
 }
@@ -91,3 +91,21 @@ func (hr *HttpReader) GetRedeemsByRequester(requester string) (string, error) {
 	// Convert the body to a string
 	return string(body), nil
 }
+
+func (hr *HttpReader) GetRefundsByAddress(refundAddr string) (string, error) {
+	url := "http://" + hr.serverIP + ":" + hr.serverPort + ROUTE_REFUNDS + "?btc_refund_address=" + refundAddr
+	resp, err := http.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	// Read the response body
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	// Convert the body to a string
+	return string(body), nil
+}
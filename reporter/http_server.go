@@ -25,6 +25,7 @@ const (
 	ROUTE_HELLO    = "/hello"
 	ROUTE_DEPOSITS = "/deposits"
 	ROUTE_REDEEMS  = "/redeems"
+	ROUTE_REFUNDS  = "/refunds"
 )
 
 type HttpReporter struct {
@@ -36,9 +37,10 @@ type HttpReporter struct {
 	// BTC side.
 	depositdb btcaction.DepositStorage      // this is an interface
 	redeemdb  btcaction.RedeemActionStorage // this is an interface
+	refunddb  btcaction.RefundStorage       // (optional) this is an interface
 
-	// ETH side.
-	statedb *state.StateDB
+	// Destination chain side, one state db per chain.
+	statedbs []*state.StateDB
 }
 
 func NewHttpReporter(serverIP string, serverPort string, depositdb btcaction.DepositStorage, redeemdb btcaction.RedeemActionStorage, statedb *state.StateDB) *HttpReporter {
@@ -47,10 +49,35 @@ func NewHttpReporter(serverIP string, serverPort string, depositdb btcaction.Dep
 		serverPort: serverPort,
 		depositdb:  depositdb,
 		redeemdb:   redeemdb,
-		statedb:    statedb,
+		statedbs:   []*state.StateDB{statedb},
 	}
 }
 
+// AddStateDB adds the state db of another destination chain,
+// mints and redeems are looked up in all of them.
+func (h *HttpReporter) AddStateDB(statedb *state.StateDB) {
+	h.statedbs = append(h.statedbs, statedb)
+}
+
+// getMint finds the mint of a btc deposit tx in any destination chain.
+func (h *HttpReporter) getMint(btcTxId ethcommon.Hash) (*state.Mint, error) {
+	for _, statedb := range h.statedbs {
+		mint, ok, err := statedb.GetMint(btcTxId)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return mint, nil
+		}
+	}
+	return nil, nil
+}
+
+// SetRefundStorage enables the refunds route.
+func (h *HttpReporter) SetRefundStorage(refunddb btcaction.RefundStorage) {
+	h.refunddb = refunddb
+}
+
 // Hook up routes & handlers
 func (h *HttpReporter) SetupRouter() *gin.Engine {
 	router := gin.Default()
@@ -59,6 +86,7 @@ func (h *HttpReporter) SetupRouter() *gin.Engine {
 	router.GET(ROUTE_HELLO, Hello)
 	router.GET(ROUTE_DEPOSITS, h.Deposits)
 	router.GET(ROUTE_REDEEMS, h.Redeems)
+	router.GET(ROUTE_REFUNDS, h.Refunds)
 
 	return router
 }
@@ -84,8 +112,11 @@ type DepositResponse struct {
 	BtcDepoTxId     string `json:"btc_depo_tx_id"`     // btc transaction id
 	BtcDepoAmount   string `json:"btc_depo_amount"`    // btc deposit amount in Satoshi, int64 => string
 
+	DestChainType string `json:"dest_chain_type"` // "evm", "aptos"
+	DepositMemo   string `json:"deposit_memo"`    // optional memo of the deposit, hex
+
 	EvmMintTxStatus string `json:"evm_mint_tx_status"` // "not_found", "pending", "confirmed"
-	EvmMintReceiver string `json:"evm_mint_receiver"`  // ethereum address
+	EvmMintReceiver string `json:"evm_mint_receiver"`  // receiver address, 20 bytes on evm, 32 bytes on aptos
 	EvmMintTxId     string `json:"evm_mint_tx_id"`     // ethereum mint transaction id
 	EvmMintAmount   string `json:"evm_mint_amount"`    // ethereum mint amount in Wei, int64 => string
 }
@@ -93,12 +124,16 @@ type DepositResponse struct {
 // Fetch a list of deposits.
 // deposits are related to an account, and ordered by time.
 // evm_receiver: ethereum address of receiver on eth side
+// receiver: address of receiver on any destination chain (eg. 32-byte aptos account)
 func (h *HttpReporter) Deposits(c *gin.Context) {
 	evmReceiver := c.Query("evm_receiver") // evm
+	if evmReceiver == "" {
+		evmReceiver = c.Query("receiver") // evm or aptos
+	}
 
 	// Check if all parameters are missing
 	if evmReceiver == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "evm_receiver must be provided"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "evm_receiver or receiver must be provided"})
 		return
 	}
 
@@ -109,8 +144,8 @@ func (h *HttpReporter) Deposits(c *gin.Context) {
 
 	var resp []DepositResponse
 
-	// Query the depositdb via evmReceiver
-	depos, err := h.depositdb.GetDepositsByEVMAddr(evmReceiver)
+	// Query the depositdb via evmReceiver (legacy deposits have it as receiver too)
+	depos, err := h.depositdb.GetDepositsByDestReceiver(evmReceiver)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -128,9 +163,11 @@ func (h *HttpReporter) Deposits(c *gin.Context) {
 			BtcDepoTxStatus: "confirmed",
 			BtcDepoTxId:     depo.TxHash,
 			BtcDepoAmount:   strconv.FormatInt(depo.DepositValue, 10), // convert int64 to string
+			DestChainType:   depo.ChainType,
+			DepositMemo:     depo.Memo,
 		}
 
-		mint, _, err := h.statedb.GetMint(ethcommon.HexToHash(depo.TxHash))
+		mint, err := h.getMint(ethcommon.HexToHash(depo.TxHash))
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
@@ -144,10 +181,10 @@ func (h *HttpReporter) Deposits(c *gin.Context) {
 		// found mint, but the evm tx hash is not set.
 		if mint.MintTxHash == common.EmptyHash {
 			_resp.EvmMintTxStatus = "pending"
-			_resp.EvmMintReceiver = depo.EvmAddr
+			_resp.EvmMintReceiver = depo.Receiver
 		} else { // found mint, evm tx hash is set.
 			_resp.EvmMintTxStatus = "confirmed"
-			_resp.EvmMintReceiver = depo.EvmAddr
+			_resp.EvmMintReceiver = depo.Receiver
 			_resp.EvmMintTxId = mint.MintTxHash.String()
 			_resp.EvmMintAmount = mint.Amount.String()
 		}
@@ -189,11 +226,15 @@ func (h *HttpReporter) Redeems(c *gin.Context) {
 
 	logger.WithField("evmRequester", evmRequester).Info("Redeem Route")
 
-	// Fetch redeems by evm requester
-	redeems, err := h.statedb.GetRedeemsByRequester(common.Trim0xPrefix(evmRequester))
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+	// Fetch redeems by evm requester, from all the destination chains
+	var redeems []*state.Redeem
+	for _, statedb := range h.statedbs {
+		_redeems, err := statedb.GetRedeemsByRequester(common.Trim0xPrefix(evmRequester))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		redeems = append(redeems, _redeems...)
 	}
 
 	logger.WithField("len(redeems)", len(redeems)).Info("Redeems Route")
@@ -255,6 +296,66 @@ func (h *HttpReporter) Redeems(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"data": response})
 }
 
+type RefundResponse struct {
+	BtcDepoTxId   string `json:"btc_depo_tx_id"`  // btc malformed deposit transaction id
+	BtcDepoAmount string `json:"btc_depo_amount"` // btc deposit amount in Satoshi, int64 => string
+	Reason        string `json:"reason"`          // why the deposit is refunded
+
+	BtcRefundAddress string `json:"btc_refund_address"` // btc address to refund, empty if unknown
+	BtcRefundTxId    string `json:"btc_refund_tx_id"`   // btc refund transaction id, empty if not sent
+	Status           string `json:"status"`             // one of "pending/sent/mined/manual"
+}
+
+// Fetch refunds of malformed deposits.
+// btc_tx_id: the malformed deposit tx, or
+// btc_refund_address: the btc address refunded to.
+func (h *HttpReporter) Refunds(c *gin.Context) {
+	btcTxId := c.Query("btc_tx_id")
+	refundAddr := c.Query("btc_refund_address")
+
+	if btcTxId == "" && refundAddr == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "btc_tx_id or btc_refund_address must be provided"})
+		return
+	}
+	if h.refunddb == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "refunds are not tracked"})
+		return
+	}
+
+	var refunds []btcaction.RefundAction
+	if btcTxId != "" {
+		refund, err := h.refunddb.GetRefundByTxHash(utils.Remove0xPrefix(btcTxId))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if refund != nil {
+			refunds = append(refunds, *refund)
+		}
+	} else {
+		var err error
+		refunds, err = h.refunddb.GetRefundsByRefundAddr(refundAddr)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	var response []RefundResponse
+	for _, refund := range refunds {
+		response = append(response, RefundResponse{
+			BtcDepoTxId:      refund.TxHash,
+			BtcDepoAmount:    strconv.FormatInt(refund.Amount, 10),
+			Reason:           refund.Reason,
+			BtcRefundAddress: refund.RefundAddr,
+			BtcRefundTxId:    refund.RefundTxHash,
+			Status:           refund.Status,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": response})
+}
+
 // func main() {
 //     // Example usage
 //     depositdb := &btcaction.DepositStorage{}
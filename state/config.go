@@ -4,5 +4,5 @@ import "math/big"
 
 type StateConfig struct {
 	ChannelSize   int
-	UniqueChainId *big.Int // Eth chain id (eg. 1337), aptos chain id (eg. 1)
+	UniqueChainId *big.Int // chain id of the destination chain the statedb is opened with, eth (eg. 1337), aptos (eg. 1)
 }
@@ -32,6 +32,7 @@ var (
 	ErrorOutpointTxIdInvalid    = errors.New("outpoint tx id invalid")
 	ErrorRequireStatusRequested = errors.New("require status == requested")
 	ErrorPrepareTxHashEmpty     = errors.New("prepare tx hash is empty")
+	ErrorOutpointsUnmatched     = errors.New("outpoints unmatched with the orphaned prepare")
 )
 
 type Redeem struct {
@@ -114,6 +115,19 @@ func (r *Redeem) updateFromPreparedEvent(ev *agreement.RedeemPreparedEvent) (*Re
 		}
 	}
 
+	// A redeem whose prepare was orphaned by a reorg keeps its outpoints,
+	// a btc tx spending them may be out already: it must be prepared again with them.
+	if len(r.Outpoints) > 0 {
+		if len(r.Outpoints) != len(ev.OutpointTxIds) {
+			return nil, ErrorOutpointsUnmatched
+		}
+		for i, outpoint := range r.Outpoints {
+			if outpoint.BtcTxId != ev.OutpointTxIds[i] || outpoint.BtcIdx != ev.OutpointIdxs[i] {
+				return nil, ErrorOutpointsUnmatched
+			}
+		}
+	}
+
 	// Set Prepare ETH Transaction Hash
 	r.PrepareTxHash = ev.PrepareTxHash
 
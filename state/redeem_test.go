@@ -100,6 +100,7 @@ func TestSetFromRequestEvent(t *testing.T) {
 
 func TestUpdateFromPreparedEvent(t *testing.T) {
 	redeem := RandRedeem(RedeemStatusRequested)
+	redeem.Outpoints = nil // not prepared yet
 	prepEv := &agreement.RedeemPreparedEvent{
 		RequestTxHash: redeem.RequestTxHash,
 	}
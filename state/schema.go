@@ -11,15 +11,20 @@ var (
 	// request: requestTxHash, requester, receiver, amount, status=requested
 	// prepare: prepareTxHash, status=prepared, outpoints
 	// redeem: btcTxId, status=completed
+	//   btcTxId is not unique, a batch btc tx completes several redeems
+	// chain: destination chain the redeem is requested on (see ChainNamespace)
 	redeemTable = `CREATE TABLE IF NOT EXISTS redeem (
-		requestTxHash CHAR(64) PRIMARY KEY NOT NULL,
-		prepareTxHash CHAR(64) UNIQUE,
-		btcTxId CHAR(64) UNIQUE,
+		requestTxHash CHAR(64) NOT NULL,
+		prepareTxHash CHAR(64),
+		btcTxId CHAR(64),
 		requester CHAR(40) NOT NULL,
 		receiver VARCHAR(62) NOT NULL,
 		amount BIGINT UNSIGNED NOT NULL,
 		outpoints BLOB,
 		status VARCHAR(10) NOT NULL,
+		chain VARCHAR(32) NOT NULL,
+		PRIMARY KEY (chain, requestTxHash),
+		UNIQUE (chain, prepareTxHash),
 		CONSTRAINT chk_status CHECK (status IN ('requested', 'prepared', 'completed', 'invalid')),
 		CONSTRAINT chk_amount CHECK (amount > 0)
 		CONSTRAINT chk_requestTxHash CHECK (requestTxHash != '` + strZeroBytes32 + `'),
@@ -29,9 +34,12 @@ var (
 	);`
 
 	// table stores key-value pairs. Both key and value are a 32-byte hex string without prefix '0x'
+	// chain: destination chain the pair belongs to, GLOBAL_NAMESPACE for the pairs shared by all chains
 	kvTable = `CREATE TABLE IF NOT EXISTS kv (
-		key CHAR(64) PRIMARY KEY NOT NULL,
-		value CHAR(64) NOT NULL
+		key CHAR(64) NOT NULL,
+		value CHAR(64) NOT NULL,
+		chain VARCHAR(32) NOT NULL,
+		PRIMARY KEY (chain, key)
 	);`
 
 	// This table stores a BTC2EVM Token Mint.
@@ -39,16 +47,34 @@ var (
 	// mintTxHash is the hash of the mint transaction on the EVM side.
 	// receiver is the address of the receiver on the EVM side.
 	// amount is the amount of the minted token (satoshi).
+	// chain is the destination chain the token is minted on.
+	//   btcTxId is unique across the chains, a deposit (utxo) is minted on one chain only
 	mintTable = `CREATE TABLE IF NOT EXISTS mint (
-		btcTxId CHAR(64) PRIMARY KEY NOT NULL,
-		mintTxHash CHAR(64) UNIQUE,
+		btcTxId CHAR(64) NOT NULL,
+		mintTxHash CHAR(64),
 		receiver CHAR(40) NOT NULL,
 		amount BIGINT UNSIGNED NOT NULL,
+		chain VARCHAR(32) NOT NULL,
+		PRIMARY KEY (btcTxId),
+		UNIQUE (chain, mintTxHash),
 		CONSTRAINT chk_amount CHECK (amount > 0),
 		CONSTRAINT chk_btcTxId CHECK (btcTxId != '` + strZeroBytes32 + `'),
 		CONSTRAINT chk_receiver CHECK (receiver != '` + strZeroBytes20 + `')
 	);`
 
-	statusRequestedParamList = " requestTxHash, requester, receiver, amount, status "
-	statusPreparedParamList  = " requestTxHash, prepareTxHash, requester, receiver, amount, outpoints, status "
+	statusRequestedParamList = " requestTxHash, requester, receiver, amount, status, chain "
+	statusPreparedParamList  = " requestTxHash, prepareTxHash, requester, receiver, amount, outpoints, status, chain "
+	redeemColumnList         = " requestTxHash, prepareTxHash, btcTxId, requester, receiver, amount, outpoints, status "
+
+	// Tables created by older versions are not namespaced by chain, their primary keys
+	// change so they are rebuilt: the rows are copied to the chain that opens the db first.
+	legacyTables = []struct {
+		name    string
+		create  string
+		columns string // columns copied from the old table
+	}{
+		{"redeem", redeemTable, redeemColumnList},
+		{"mint", mintTable, " btcTxId, mintTxHash, receiver, amount "},
+		{"kv", kvTable, " key, value "},
+	}
 )
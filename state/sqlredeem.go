@@ -33,7 +33,10 @@ func (s *sqlRedeem) encode(r *Redeem) (*sqlRedeem, error) {
 	s.BtcTxId = r.BtcTxId.String()[2:]
 	s.Requester = common.ByteSliceToPureHexStr(r.Requester)
 	s.Receiver = r.Receiver
-	s.Amount = r.Amount.Uint64()
+	// nil for the partial redeems of the updates (eg. State.SetRedeemCompleted)
+	if r.Amount != nil {
+		s.Amount = r.Amount.Uint64()
+	}
 	s.Outpoints = outpoints
 	s.Status = string(r.Status)
 
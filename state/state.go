@@ -2,6 +2,7 @@ package state
 
 import (
 	"context"
+	"encoding/binary"
 	"errors"
 	"math/big"
 	"sync/atomic"
@@ -15,9 +16,12 @@ import (
 )
 
 var (
-	KeyEthFinalizedBlock = crypto.Keccak256Hash([]byte("KeyEthFinalizedBlock"))
+	// Keys of the destination chain the statedb is opened with.
+	// The preimages are kept from the eth-only versions so that the stored values are still found.
+	KeyFinalizedLedger = crypto.Keccak256Hash([]byte("KeyEthFinalizedBlock"))
+	KeyChainId         = crypto.Keccak256Hash([]byte("KeyEthChainId"))
+	// Keys shared by all destination chains.
 	KeyBtcFinalizedBlock = crypto.Keccak256Hash([]byte("KeyBtcFinalizedBlock"))
-	KeyEthChainId        = crypto.Keccak256Hash([]byte("KeyEthChainId"))
 
 	ErrSetBtcFinalizedBlockNumber           = errors.New("failed to set btc finalized block number in statedb")
 	ErrGetBtcFinalizedBlockNumber           = errors.New("failed to get btc finalized block number from statedb")
@@ -28,6 +32,8 @@ var (
 	ErrGetEthChainId                        = errors.New("failed to get eth chain id from statedb")
 	ErrSetEthChainId                        = errors.New("failed to set eth chain id in statedb")
 	ErrEthChainIdUnmatchedStored            = errors.New("current chain id does not match the stored")
+	ErrGetEventCursor                       = errors.New("failed to get event cursor from statedb")
+	ErrSetEventCursor                       = errors.New("failed to set event cursor in statedb")
 
 	ErrUpdateInvalidRedeem    = errors.New("redeem is invalid and cannot be updated")
 	ErrPreparedEventUnmatched = errors.New("redeem prepared event is unmatched with stored requested redeem")
@@ -39,22 +45,47 @@ var (
 	ErrDBOpHasRedeem    = errors.New("failed to check redeem existence")
 	ErrDBOpInsertRedeem = errors.New("failed to insert redeem in statedb")
 	ErrDBOpUpdateMint   = errors.New("failed to update mint in statedb")
+
+	ErrChainEventEmpty = errors.New("chain event carries no event")
+
+	ErrDBOpRevertMint          = errors.New("failed to revert mint in statedb")
+	ErrDBOpRevertPrepare       = errors.New("failed to revert prepared redeem in statedb")
+	ErrRevertCompletedRedeem   = errors.New("redeem is completed on btc and cannot be reverted")
+	ErrRevertSentRedeem        = errors.New("redeem is sent on btc and cannot be reverted")
+	ErrCheckRedeemSent         = errors.New("failed to check whether redeem is sent on btc")
+	ErrDBOpRevertRequest       = errors.New("failed to delete orphaned redeem request in statedb")
+	ErrRevertPreparedRequest   = errors.New("redeem request is orphaned but the redeem is prepared")
+	ErrSetFinalizedAtForkPoint = errors.New("failed to set finalized block number to the fork point")
+
+	ErrChainNamespaceEmpty = errors.New("statedb must be opened with a chain namespace")
 )
 
+// Keys stored in GLOBAL_NAMESPACE.
+var globalKeys = []ethcommon.Hash{KeyBtcFinalizedBlock}
+
+// RedeemSentChecker tells if a btc tx paying the redeem is broadcast
+// (eg. btcaction.RedeemActionStorage). ethRequestTxID is a hex string without 0x prefix.
+type RedeemSentChecker interface {
+	IsRedeemSent(ethRequestTxID string) (bool, error)
+}
+
 type State struct {
-	statedb *StateDB
-	cfg     *StateConfig
+	statedb    *StateDB
+	cfg        *StateConfig
+	redeemSent RedeemSentChecker
 
 	newEthFinalizedBlockCh chan *big.Int
 	newBtcFinalizedBlockCh chan *big.Int
 	newMintedEventCh       chan *agreement.MintedEvent
 	newRedeemRequestedEvCh chan *agreement.RedeemRequestedEvent
 	newRedeemPreparedEvCh  chan *agreement.RedeemPreparedEvent
+	newChainEventBatchCh   chan *agreement.ChainEventBatch
+	chainReorgCh           chan *agreement.ChainReorg
 
 	// temp, in-meory cache
 	cache struct {
-		lastEthFinalized atomic.Value // uint64
-		ethChainId       atomic.Value // uint64
+		lastFinalized    atomic.Value // uint64
+		chainId          atomic.Value // uint64
 		lastBtcFinalized atomic.Value // uint64
 	}
 }
@@ -68,21 +99,31 @@ func New(statedb *StateDB, cfg *StateConfig) (*State, error) {
 		newMintedEventCh:       make(chan *agreement.MintedEvent, cfg.ChannelSize),
 		newRedeemRequestedEvCh: make(chan *agreement.RedeemRequestedEvent, cfg.ChannelSize),
 		newRedeemPreparedEvCh:  make(chan *agreement.RedeemPreparedEvent, cfg.ChannelSize),
+		newChainEventBatchCh:   make(chan *agreement.ChainEventBatch, 1),
+		chainReorgCh:           make(chan *agreement.ChainReorg, 1),
 	}
 
-	if err := st.initEthFinalizedBlock(); err != nil {
+	if err := st.initFinalizedLedger(); err != nil {
 		return nil, err
 	}
 
-	if err := st.initEthChainID(); err != nil {
+	if err := st.initChainID(); err != nil {
 		return nil, err
 	}
 
-	// TODO: init btc finalized block number
+	if err := st.initBtcFinalizedBlock(); err != nil {
+		return nil, err
+	}
 
 	return st, nil
 }
 
+// Set the checker of the redeems sent on btc, a sent redeem is never rolled back after a reorg.
+// Call it before Start.
+func (st *State) SetRedeemSentChecker(checker RedeemSentChecker) {
+	st.redeemSent = checker
+}
+
 func (st *State) Start(ctx context.Context) error {
 	logger.Debug("starting state")
 	defer logger.Debug("stopping state")
@@ -99,6 +140,7 @@ func (st *State) Start(ctx context.Context) error {
 			switch err {
 			case ErrGetEthFinalizedBlockNumber:
 			case ErrSetEthFinalizedBlockNumber:
+			case ErrSetBtcFinalizedBlockNumber:
 			case ErrDBOpUpdateMint:
 			case ErrDBOpHasRedeem:
 			case ErrDBOpInsertRedeem:
@@ -108,196 +150,351 @@ func (st *State) Start(ctx context.Context) error {
 			case ErrPreparedEventInvalid:
 			case ErrPreparedEventUnmatched:
 			case ErrUpdateInvalidRedeem:
+			case ErrChainEventEmpty:
+			case ErrDBOpRevertMint:
+			case ErrDBOpRevertPrepare:
+			case ErrCheckRedeemSent:
+			case ErrDBOpRevertRequest:
+			case ErrSetFinalizedAtForkPoint:
 			default:
 				logger.Fatal(err)
 			}
 			return err
 		case blkNum := <-st.newEthFinalizedBlockCh:
-			newLogger := logger.WithField("newFinalized", blkNum.String())
-
-			handleNewBlockNumber := func() error {
-				// Get the stored last finalized block number
-				lastFinalized, err := st.GetBlockchainFinalizedBlockNumber()
-				if err != nil {
-					newLogger.Errorf("failed to get last finalized block number: err=%v", err)
-					return ErrGetEthFinalizedBlockNumber
-				}
-
-				// Update the last finalized block number if the new one is larger
-				if lastFinalized.Cmp(blkNum) <= 0 {
-					if err := st.setEthFinalizedBlockNumber(blkNum); err != nil {
-						newLogger.Errorf("failed to set last finalized block number: err=%v", err)
-						return ErrSetEthFinalizedBlockNumber
-					}
-				}
-				return nil
-			}
-
-			if err := handleNewBlockNumber(); err != nil {
+			if err := st.handleNewFinalizedBlock(blkNum); err != nil {
 				errCh <- err
 			}
+		// The btc monitor is the only writer of btc finalized block number,
+		// it can move backwards after a btc reorg, so always store it.
+		case blkNum := <-st.newBtcFinalizedBlockCh:
+			if err := st.SetBtcFinalizedBlockNumber(blkNum); err != nil {
+				logger.WithField("newBtcFinalized", blkNum.String()).Errorf("failed to set btc finalized block number: err=%v", err)
+				errCh <- ErrSetBtcFinalizedBlockNumber
+			}
 		// After receiving a new minted event, udpate statedb
 		case ev := <-st.newMintedEventCh:
-			newLogger := logger.WithFields(logger.Fields{
-				"mintTx":  ev.MintTxHash.String(),
-				"btcTxId": ev.BtcTxId.String(),
-			})
-
-			handleEvent := func() error {
-				mint := createMintFromMintedEvent(ev)
-
-				err := st.statedb.UpdateMint(mint)
-				if err != nil {
-					newLogger.Errorf("failed to update mint: err=%v", err)
-					return ErrDBOpUpdateMint
-				}
-				newLogger.Debug("update mint")
-				return nil
-			}
-
-			if err := handleEvent(); err != nil {
+			if err := st.handleMintedEvent(ev); err != nil {
 				errCh <- err
 			}
-		// After receiving a redeem request event
-		// 1. 	Check the existence of the redeem request tx hash
-		// 2.	Skip if found
-		// 3.	Insert a new redeem record in state db
 		case ev := <-st.newRedeemRequestedEvCh:
-			newLogger := logger.WithField(
-				"reqTx", ev.RequestTxHash.String(),
-			)
-			logger.WithField("newRedeemRequestedEvCh", ev).Info("newRedeemRequestedEvCh")
-
-			handleRedeemRequestEvent := func() error {
-				// Check if the redeem already exists
-				ok, _, err := st.statedb.HasRedeem(ev.RequestTxHash)
-				if err != nil {
-					newLogger.Errorf("failed to check redeem existence: err=%v", err)
-					return ErrDBOpHasRedeem
-				}
-
-				if ok {
-					return nil
-				}
-
-				// Create a new redeem and save it to the database
-				redeem, err := createRedeemFromRequestedEvent(ev)
-				// newLogger.Debugf("redeem: %v", redeem)
-				if err != nil {
-					newLogger.Errorf("failed to create redeem from requested event: err=%v, ev=%v", err, ev)
-					return ErrRequestedEventInvalid
-				}
-				if err := st.statedb.InsertAfterRequested(redeem); err != nil {
-					newLogger.Errorf("failed to insert redeem to db: err=%v", err)
-					return ErrDBOpInsertRedeem
-				}
-				newLogger.Debug("insert redeem after requested")
-
-				return nil
-			}
-
-			if err := handleRedeemRequestEvent(); err != nil {
+			if err := st.handleRedeemRequestedEvent(ev); err != nil {
 				errCh <- err
 			}
-		// After receiving a redeem prepared event
-		// 1. 	Check the existence of the tx hash
-		// 2. 	If found, check its status
-		// 3.   Skip if status == prepared | completed
-		// 4.   Insert the redeem if the tx hash not found or otherwise update
-		// 		the existing record in db
-		// NOTE that it is possible that a prepared event arrives earlier than
-		// its corresponding requested event
 		case ev := <-st.newRedeemPreparedEvCh:
-			newLogger := logger.WithFields(logger.Fields{
-				"reqTx":  ev.RequestTxHash.String(),
-				"prepTx": ev.PrepareTxHash.String(),
-			})
-
-			handleEvent := func() error {
-				ok, status, err := st.statedb.HasRedeem(ev.RequestTxHash)
-				if err != nil {
-					newLogger.Errorf("error when checking existence: err=%v", err)
-					return ErrDBOpHasRedeem
-				}
-
-				var redeem *Redeem
-
-				if ok {
-					// Even though the "RedeemPrepare" is newly mined on ETH chain,
-					// correspoinding state DB record should be still "requested" status.
-					// If found "prepared" or "completed" status, skip the event.
-					if status == RedeemStatusPrepared || status == RedeemStatusCompleted {
-						return nil
-					}
-
-					if status == RedeemStatusInvalid {
-						newLogger.Errorf("redeem is invalid and cannot be updated")
-						return ErrUpdateInvalidRedeem
-					}
-
-					redeem, ok, err = st.statedb.GetRedeem(ev.RequestTxHash)
-					if err != nil || !ok {
-						newLogger.Errorf("failed to get stored redeem: err=%v", err)
-						return ErrDBOpGetRedeem
-					}
-
-					redeem, err = redeem.updateFromPreparedEvent(ev)
-					if err != nil {
-						logger.Errorf("failed to update redeem from prepared event: err=%v", err)
-						return ErrPreparedEventUnmatched
-					}
-				} else {
-					redeem, err = createRedeemFromPreparedEvent(ev)
-					if err != nil {
-						logger.Errorf("failed to create redeem from prepared event: err=%v, ev=%v", err, ev)
-						return ErrPreparedEventInvalid
-					}
-				}
-
-				if err = st.statedb.UpdateAfterPrepared(redeem); err != nil {
-					return ErrDBOpUpdateRedeem
-				}
-
-				newLogger.WithFields(logger.Fields{
-					"status":     redeem.Status,
-					"reqTxHash":  redeem.RequestTxHash.String(),
-					"prepTxHash": redeem.PrepareTxHash.String(),
-				}).Info("Updated <redeem> after prepared")
-
-				return nil
+			if err := st.handleRedeemPreparedEvent(ev); err != nil {
+				errCh <- err
 			}
-
-			if err := handleEvent(); err != nil {
+		// Events of a ledger range, applied in order in this single case,
+		// the checkpoint is stored only after all of them are applied.
+		case batch := <-st.newChainEventBatchCh:
+			err := st.handleChainEventBatch(batch)
+			batch.Done <- err
+			if err != nil {
+				errCh <- err
+			}
+		// Blocks left the canonical chain, roll back their events.
+		case reorg := <-st.chainReorgCh:
+			err := st.handleChainReorg(reorg)
+			reorg.Done <- err
+			if err != nil {
 				errCh <- err
 			}
 		}
 	}
 }
 
-// Fetch latest finalized eth block number from statedb
+// Store the new finalized block number if it is larger than the stored one.
+func (st *State) handleNewFinalizedBlock(blkNum *big.Int) error {
+	newLogger := logger.WithField("newFinalized", blkNum.String())
+
+	// Get the stored last finalized block number
+	lastFinalized, err := st.GetBlockchainFinalizedBlockNumber()
+	if err != nil {
+		newLogger.Errorf("failed to get last finalized block number: err=%v", err)
+		return ErrGetEthFinalizedBlockNumber
+	}
+
+	// Update the last finalized block number if the new one is larger
+	if lastFinalized.Cmp(blkNum) <= 0 {
+		if err := st.setFinalizedLedgerNumber(blkNum); err != nil {
+			newLogger.Errorf("failed to set last finalized block number: err=%v", err)
+			return ErrSetEthFinalizedBlockNumber
+		}
+	}
+	return nil
+}
+
+func (st *State) handleMintedEvent(ev *agreement.MintedEvent) error {
+	newLogger := logger.WithFields(logger.Fields{
+		"mintTx":  ev.MintTxHash.String(),
+		"btcTxId": ev.BtcTxId.String(),
+	})
+
+	mint := createMintFromMintedEvent(ev)
+
+	err := st.statedb.UpdateMint(mint)
+	if err != nil {
+		newLogger.Errorf("failed to update mint: err=%v", err)
+		return ErrDBOpUpdateMint
+	}
+	newLogger.Debug("update mint")
+	return nil
+}
+
+// After receiving a redeem request event
+// 1. 	Check the existence of the redeem request tx hash
+// 2.	Skip if found
+// 3.	Insert a new redeem record in state db
+func (st *State) handleRedeemRequestedEvent(ev *agreement.RedeemRequestedEvent) error {
+	newLogger := logger.WithField(
+		"reqTx", ev.RequestTxHash.String(),
+	)
+	logger.WithField("newRedeemRequestedEvCh", ev).Info("newRedeemRequestedEvCh")
+
+	// Check if the redeem already exists
+	ok, _, err := st.statedb.HasRedeem(ev.RequestTxHash)
+	if err != nil {
+		newLogger.Errorf("failed to check redeem existence: err=%v", err)
+		return ErrDBOpHasRedeem
+	}
+
+	if ok {
+		return nil
+	}
+
+	// Create a new redeem and save it to the database
+	redeem, err := createRedeemFromRequestedEvent(ev)
+	// newLogger.Debugf("redeem: %v", redeem)
+	if err != nil {
+		newLogger.Errorf("failed to create redeem from requested event: err=%v, ev=%v", err, ev)
+		return ErrRequestedEventInvalid
+	}
+	if err := st.statedb.InsertAfterRequested(redeem); err != nil {
+		newLogger.Errorf("failed to insert redeem to db: err=%v", err)
+		return ErrDBOpInsertRedeem
+	}
+	newLogger.Debug("insert redeem after requested")
+
+	return nil
+}
+
+// After receiving a redeem prepared event
+//  1. Check the existence of the tx hash
+//  2. If found, check its status
+//  3. Skip if status == prepared | completed
+//  4. Insert the redeem if the tx hash not found or otherwise update
+//     the existing record in db
+//
+// NOTE that it is possible that a prepared event arrives earlier than
+// its corresponding requested event
+func (st *State) handleRedeemPreparedEvent(ev *agreement.RedeemPreparedEvent) error {
+	newLogger := logger.WithFields(logger.Fields{
+		"reqTx":  ev.RequestTxHash.String(),
+		"prepTx": ev.PrepareTxHash.String(),
+	})
+
+	ok, status, err := st.statedb.HasRedeem(ev.RequestTxHash)
+	if err != nil {
+		newLogger.Errorf("error when checking existence: err=%v", err)
+		return ErrDBOpHasRedeem
+	}
+
+	var redeem *Redeem
+
+	if ok {
+		// Even though the "RedeemPrepare" is newly mined on ETH chain,
+		// correspoinding state DB record should be still "requested" status.
+		// If found "prepared" or "completed" status, skip the event.
+		if status == RedeemStatusPrepared || status == RedeemStatusCompleted {
+			return nil
+		}
+
+		if status == RedeemStatusInvalid {
+			newLogger.Errorf("redeem is invalid and cannot be updated")
+			return ErrUpdateInvalidRedeem
+		}
+
+		redeem, ok, err = st.statedb.GetRedeem(ev.RequestTxHash)
+		if err != nil || !ok {
+			newLogger.Errorf("failed to get stored redeem: err=%v", err)
+			return ErrDBOpGetRedeem
+		}
+
+		redeem, err = redeem.updateFromPreparedEvent(ev)
+		if err != nil {
+			logger.Errorf("failed to update redeem from prepared event: err=%v", err)
+			return ErrPreparedEventUnmatched
+		}
+	} else {
+		redeem, err = createRedeemFromPreparedEvent(ev)
+		if err != nil {
+			logger.Errorf("failed to create redeem from prepared event: err=%v, ev=%v", err, ev)
+			return ErrPreparedEventInvalid
+		}
+	}
+
+	if err = st.statedb.UpdateAfterPrepared(redeem); err != nil {
+		return ErrDBOpUpdateRedeem
+	}
+
+	newLogger.WithFields(logger.Fields{
+		"status":     redeem.Status,
+		"reqTxHash":  redeem.RequestTxHash.String(),
+		"prepTxHash": redeem.PrepareTxHash.String(),
+	}).Info("Updated <redeem> after prepared")
+
+	return nil
+}
+
+// Apply the events of the batch in order, then store the checkpoint.
+// Stop at the first failed event, the checkpoint is not stored then,
+// so the range is synced again (applying an event twice is a no-op).
+func (st *State) handleChainEventBatch(batch *agreement.ChainEventBatch) error {
+	for i := range batch.Events {
+		ev := &batch.Events[i]
+		var err error
+		switch {
+		case ev.Minted != nil:
+			err = st.handleMintedEvent(ev.Minted)
+		case ev.Requested != nil:
+			err = st.handleRedeemRequestedEvent(ev.Requested)
+		case ev.Prepared != nil:
+			err = st.handleRedeemPreparedEvent(ev.Prepared)
+		default:
+			logger.Errorf("chain event without payload: %v", ev)
+			err = ErrChainEventEmpty
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if batch.Checkpoint != nil {
+		return st.handleNewFinalizedBlock(batch.Checkpoint)
+	}
+	return nil
+}
+
+// Roll back the events of the orphaned blocks, then set the finalized block number to the fork point,
+// so the blocks after it are synced again.
+//  1. Minted: the mint is unminted again, it is minted again (or the event comes back on the new chain).
+//  2. Prepared: the redeem is requested again, it is prepared again with the same outpoints.
+//     A redeem already sent or completed on btc cannot be rolled back, it is reported for the operator:
+//     preparing it again could pay it twice.
+//  3. Requested: the redeem not prepared yet is deleted, it is inserted again if the request comes back
+//     on the new chain. A redeem already prepared (or paid) is reported for the operator.
+//
+// The prepares are rolled back before the requests, a redeem orphaned with its prepare is deleted.
+func (st *State) handleChainReorg(reorg *agreement.ChainReorg) error {
+	newLogger := logger.WithField("forkPoint", reorg.ForkPoint.String())
+
+	for _, ev := range reorg.Minted {
+		ok, err := st.statedb.RevertMinted(ev.BtcTxId, ev.MintTxHash)
+		if err != nil {
+			newLogger.Errorf("failed to revert mint: btcTxId=%s, err=%v", ev.BtcTxId.String(), err)
+			return ErrDBOpRevertMint
+		}
+		newLogger.WithFields(logger.Fields{
+			"btcTxId":  ev.BtcTxId.String(),
+			"mintTx":   ev.MintTxHash.String(),
+			"reverted": ok,
+		}).Warn("mint orphaned by reorg")
+	}
+
+	for _, ev := range reorg.Prepared {
+		fields := logger.Fields{
+			"reqTx":  ev.RequestTxHash.String(),
+			"prepTx": ev.PrepareTxHash.String(),
+		}
+		sent, err := st.isRedeemSent(ev.RequestTxHash)
+		if err != nil {
+			newLogger.WithFields(fields).Errorf("failed to check redeem sent on btc: err=%v", err)
+			return ErrCheckRedeemSent
+		}
+		if sent {
+			newLogger.WithFields(fields).Error(ErrRevertSentRedeem)
+			continue
+		}
+
+		ok, err := st.statedb.UpdateAfterPrepareReorged(ev.RequestTxHash, ev.PrepareTxHash)
+		if err != nil {
+			newLogger.Errorf("failed to revert prepared redeem: reqTx=%s, err=%v", ev.RequestTxHash.String(), err)
+			return ErrDBOpRevertPrepare
+		}
+		fields["reverted"] = ok
+		if !ok {
+			_, status, err := st.statedb.HasRedeem(ev.RequestTxHash)
+			if err != nil {
+				return ErrDBOpHasRedeem
+			}
+			if status == RedeemStatusCompleted {
+				newLogger.WithFields(fields).Error(ErrRevertCompletedRedeem)
+				continue
+			}
+		}
+		newLogger.WithFields(fields).Warn("redeem prepare orphaned by reorg")
+	}
+
+	for _, ev := range reorg.Requested {
+		fields := logger.Fields{"reqTx": ev.RequestTxHash.String()}
+		ok, err := st.statedb.DeleteAfterRequestReorged(ev.RequestTxHash)
+		if err != nil {
+			newLogger.WithFields(fields).Errorf("failed to delete orphaned redeem request: err=%v", err)
+			return ErrDBOpRevertRequest
+		}
+		if !ok {
+			found, status, err := st.statedb.HasRedeem(ev.RequestTxHash)
+			if err != nil {
+				return ErrDBOpHasRedeem
+			}
+			if found {
+				fields["status"] = status
+				newLogger.WithFields(fields).Error(ErrRevertPreparedRequest)
+				continue
+			}
+		}
+		fields["deleted"] = ok
+		newLogger.WithFields(fields).Warn("redeem request orphaned by reorg")
+	}
+
+	if err := st.setFinalizedLedgerNumber(reorg.ForkPoint); err != nil {
+		newLogger.Errorf("failed to set finalized block number: err=%v", err)
+		return ErrSetFinalizedAtForkPoint
+	}
+	return nil
+}
+
+func (st *State) isRedeemSent(requestTxHash ethcommon.Hash) (bool, error) {
+	if st.redeemSent == nil {
+		return false, nil
+	}
+	return st.redeemSent.IsRedeemSent(requestTxHash.String()[2:])
+}
+
+// Fetch latest finalized ledger number of the destination chain from statedb
 func (st *State) GetBlockchainFinalizedBlockNumber() (*big.Int, error) {
-	if v := st.cache.lastEthFinalized.Load(); v != nil {
+	if v := st.cache.lastFinalized.Load(); v != nil {
 		return new(big.Int).SetBytes(v.([]byte)), nil
 	}
 
-	b, ok, err := st.statedb.GetKeyedValue(KeyEthFinalizedBlock)
+	b, ok, err := st.statedb.GetKeyedValue(KeyFinalizedLedger)
 	if err != nil {
 		return nil, err
 	}
 	if !ok {
 		return nil, ErrKeyValueNotFound
 	}
-	st.cache.lastEthFinalized.Store(b.Big().Bytes())
+	st.cache.lastFinalized.Store(b.Big().Bytes())
 
 	return b.Big(), nil
 }
 
-// Set finalized ETH block number into state (update underlying db).
-func (st *State) setEthFinalizedBlockNumber(fbNum *big.Int) error {
-	if err := st.statedb.SetKeyedValue(KeyEthFinalizedBlock, common.BigInt2Bytes32(fbNum)); err != nil {
+// Set finalized ledger number of the destination chain into state (update underlying db).
+func (st *State) setFinalizedLedgerNumber(fbNum *big.Int) error {
+	if err := st.statedb.SetKeyedValue(KeyFinalizedLedger, common.BigInt2Bytes32(fbNum)); err != nil {
 		return err
 	}
-	st.cache.lastEthFinalized.Store(fbNum.Bytes())
+	st.cache.lastFinalized.Store(fbNum.Bytes())
 
 	return nil
 }
@@ -308,7 +505,7 @@ func (st *State) GetBtcFinalizedBlockNumber() (*big.Int, error) {
 		return new(big.Int).SetBytes(v.([]byte)), nil
 	}
 
-	b, ok, err := st.statedb.GetKeyedValue(KeyBtcFinalizedBlock)
+	b, ok, err := st.statedb.GetGlobalKeyedValue(KeyBtcFinalizedBlock)
 	if err != nil {
 		return nil, err
 	}
@@ -322,7 +519,7 @@ func (st *State) GetBtcFinalizedBlockNumber() (*big.Int, error) {
 
 // Set finalized BTC block number into state (update underlying db).
 func (st *State) SetBtcFinalizedBlockNumber(fbNum *big.Int) error {
-	if err := st.statedb.SetKeyedValue(KeyBtcFinalizedBlock, common.BigInt2Bytes32(fbNum)); err != nil {
+	if err := st.statedb.SetGlobalKeyedValue(KeyBtcFinalizedBlock, common.BigInt2Bytes32(fbNum)); err != nil {
 		return err
 	}
 	st.cache.lastBtcFinalized.Store(fbNum.Bytes())
@@ -330,6 +527,41 @@ func (st *State) SetBtcFinalizedBlockNumber(fbNum *big.Int) error {
 	return nil
 }
 
+// Key of the event cursor of an event handle.
+func eventCursorKey(handle string) ethcommon.Hash {
+	return crypto.Keccak256Hash([]byte("KeyEventCursor"), []byte(handle))
+}
+
+// Fetch the event cursor of an event handle from statedb.
+// Return nil if not found.
+func (st *State) GetEventCursor(handle string) (*agreement.EventCursor, error) {
+	b, ok, err := st.statedb.GetKeyedValue(eventCursorKey(handle))
+	if err != nil {
+		return nil, ErrGetEventCursor
+	}
+	if !ok {
+		return nil, nil
+	}
+
+	// [16:24] sequence, [24:32] version
+	return &agreement.EventCursor{
+		Sequence: binary.BigEndian.Uint64(b[16:24]),
+		Version:  binary.BigEndian.Uint64(b[24:32]),
+	}, nil
+}
+
+// Set the event cursor of an event handle into statedb.
+func (st *State) SetEventCursor(handle string, cursor *agreement.EventCursor) error {
+	var b ethcommon.Hash
+	binary.BigEndian.PutUint64(b[16:24], cursor.Sequence)
+	binary.BigEndian.PutUint64(b[24:32], cursor.Version)
+	if err := st.statedb.SetKeyedValue(eventCursorKey(handle), b); err != nil {
+		return ErrSetEventCursor
+	}
+
+	return nil
+}
+
 // Return a channel
 func (st *State) GetNewBlockChainFinalizedLedgerNumberChannel() chan<- *big.Int {
 	return st.newEthFinalizedBlockCh
@@ -355,12 +587,29 @@ func (st *State) GetNewMintedEventChannel() chan<- *agreement.MintedEvent {
 	return st.newMintedEventCh
 }
 
+// Return a channel
+func (st *State) GetChainReorgChannel() chan<- *agreement.ChainReorg {
+	return st.chainReorgCh
+}
+
+// Return a channel
+func (st *State) GetNewChainEventBatchChannel() chan<- *agreement.ChainEventBatch {
+	return st.newChainEventBatchCh
+}
+
 // Insert a new BTC2EVM mint record into state db.
 func (st *State) SetNewBTC2EVMMint(m *Mint) error {
 	err := st.statedb.UpdateMint(m)
 	return err
 }
 
+// Remove a BTC2EVM mint record from state db (the deposit is orphaned by a reorg).
+// Only the mint not yet minted on chain can be removed.
+// Return (bool: removed/not removed, error)
+func (st *State) RemoveBTC2EVMMint(btcTxId ethcommon.Hash) (bool, error) {
+	return st.statedb.DeleteUnMinted(btcTxId)
+}
+
 // GetPreparedRedeems fetches all redeems with status "prepared" from the statedb.
 func (st *State) GetPreparedRedeems() ([]*Redeem, error) {
 	redeems, err := st.statedb.GetRedeemsByStatus(RedeemStatusPrepared)
@@ -380,3 +629,9 @@ func (st *State) SetRedeemCompleted(ethReqTxHash ethcommon.Hash, btcTxHash ethco
 	}
 	return st.statedb.UpdateAfterRedeemed(r)
 }
+
+// Revert existing redeem record in the state db.
+// Set the status from "completed" back to "prepared" (the btc tx is orphaned by a reorg).
+func (st *State) SetRedeemOrphaned(ethReqTxHash ethcommon.Hash) error {
+	return st.statedb.UpdateAfterRedeemOrphaned(ethReqTxHash)
+}
@@ -0,0 +1,28 @@
+package state
+
+import (
+	"math/big"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+// Fetch BTC Finalized Block (the scan cursor of btc monitor) from the state db,
+// it is shared by all destination chains.
+// If not found, leave it unset, the btc monitor decides where to start.
+// Set the value to st.cache
+func (st *State) initBtcFinalizedBlock() error {
+	storedBytes32, ok, err := st.statedb.GetGlobalKeyedValue(KeyBtcFinalizedBlock)
+	if err != nil {
+		return ErrGetBtcFinalizedBlockNumber
+	}
+
+	if !ok {
+		logger.Warn("State: Missing btc lastest block #")
+		return nil
+	}
+
+	stored := new(big.Int).SetBytes(storedBytes32[:])
+	logger.WithField("btc_last_block", stored.Int64()).Info("State: Loaded btc last block from db #")
+	st.cache.lastBtcFinalized.Store(stored.Bytes())
+	return nil
+}
@@ -7,11 +7,11 @@ import (
 	logger "github.com/sirupsen/logrus"
 )
 
-// Fetch ETH Finalized Block from the state db.
+// Fetch the finalized ledger number of the destination chain from the state db.
 // If not found, use 0 instead.
 // Set the value to st.cache
-func (st *State) initEthFinalizedBlock() error {
-	storedBytes32, ok, err := st.statedb.GetKeyedValue(KeyEthFinalizedBlock)
+func (st *State) initFinalizedLedger() error {
+	storedBytes32, ok, err := st.statedb.GetKeyedValue(KeyFinalizedLedger)
 	if err != nil {
 		return ErrGetEthFinalizedBlockNumber
 	}
@@ -19,11 +19,11 @@ func (st *State) initEthFinalizedBlock() error {
 	if !ok {
 		logger.WithField("default", common.EthStartingBlock).Warn("State: Missing evm lastest block #")
 		// save the default value
-		err := st.statedb.SetKeyedValue(KeyEthFinalizedBlock, common.BigInt2Bytes32(common.EthStartingBlock))
+		err := st.statedb.SetKeyedValue(KeyFinalizedLedger, common.BigInt2Bytes32(common.EthStartingBlock))
 		if err != nil {
 			return ErrSetEthFinalizedBlockNumber
 		}
-		st.cache.lastEthFinalized.Store(common.EthStartingBlock.Bytes())
+		st.cache.lastFinalized.Store(common.EthStartingBlock.Bytes())
 	} else {
 		stored := new(big.Int).SetBytes(storedBytes32[:])
 		logger.WithField("evm_last_block", stored.Int64()).Info("State: Loaded evm last block from db #")
@@ -32,36 +32,37 @@ func (st *State) initEthFinalizedBlock() error {
 			logger.Errorf("stored last finalized block number is invalid: %v", stored)
 			return ErrStoredEthFinalizedBlockNumberInvalid
 		}
-		st.cache.lastEthFinalized.Store(stored.Bytes())
+		st.cache.lastFinalized.Store(stored.Bytes())
 	}
 	return nil
 }
 
 // Same as above.
-// Fetch ETH chain ID, if not found set the default value.
-func (st *State) initEthChainID() error {
-	storedBytes32, ok, err := st.statedb.GetKeyedValue(KeyEthChainId)
+// Fetch the chain ID of the destination chain, if not found set the configured value.
+// Refuse to start if the configured chain ID does not match the stored one.
+func (st *State) initChainID() error {
+	storedBytes32, ok, err := st.statedb.GetKeyedValue(KeyChainId)
 	if err != nil {
 		return ErrGetEthChainId
 	}
 
 	if !ok {
-		logger.WithField("chainId", st.cfg.UniqueChainId).Warn("state: Missing evm chainId, use default")
+		logger.WithFields(logger.Fields{"chain": st.statedb.Chain(), "chainId": st.cfg.UniqueChainId}).Warn("state: Missing chainId, use configured")
 		// save the default value
-		err := st.statedb.SetKeyedValue(KeyEthChainId, common.BigInt2Bytes32(st.cfg.UniqueChainId))
+		err := st.statedb.SetKeyedValue(KeyChainId, common.BigInt2Bytes32(st.cfg.UniqueChainId))
 		if err != nil {
 			return ErrSetEthChainId
 		}
-		st.cache.ethChainId.Store(st.cfg.UniqueChainId.Bytes())
+		st.cache.chainId.Store(st.cfg.UniqueChainId.Bytes())
 	} else {
 		stored := new(big.Int).SetBytes(storedBytes32[:])
-		logger.WithField("evm_chainId", stored.Int64()).Info("State: Load evm chainId from db #")
+		logger.WithFields(logger.Fields{"chain": st.statedb.Chain(), "chainId": stored.Int64()}).Info("State: Load chainId from db #")
 
 		if stored.Cmp(st.cfg.UniqueChainId) != 0 {
-			logger.Errorf("current chain id does not match the stored: curr=%v, stored=%v", st.cfg.UniqueChainId, stored)
+			logger.Errorf("current chain id does not match the stored: chain=%s, curr=%v, stored=%v", st.statedb.Chain(), st.cfg.UniqueChainId, stored)
 			return ErrEthChainIdUnmatchedStored
 		}
-		st.cache.ethChainId.Store(stored.Bytes())
+		st.cache.chainId.Store(stored.Bytes())
 	}
 	return nil
 }
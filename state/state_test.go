@@ -9,9 +9,12 @@ import (
 	"github.com/TEENet-io/bridge-go/agreement"
 	"github.com/TEENet-io/bridge-go/common"
 	"github.com/TEENet-io/bridge-go/ethsync"
+	ethcommon "github.com/ethereum/go-ethereum/common"
 	"github.com/stretchr/testify/assert"
 )
 
+var testChain = ChainNamespace(common.DEPOSIT_CHAIN_TYPE_EVM, 1337)
+
 func newTestStateEnv(t *testing.T) (
 	st *State,
 	ctx context.Context,
@@ -20,7 +23,7 @@ func newTestStateEnv(t *testing.T) (
 ) {
 	sqlDB := getMemoryDB()
 
-	statedb, err := NewStateDB(sqlDB)
+	statedb, err := NewStateDB(sqlDB, testChain)
 	assert.NoError(t, err)
 
 	st, err = New(statedb, &StateConfig{ChannelSize: 1, UniqueChainId: big.NewInt(1337)})
@@ -41,12 +44,12 @@ func TestInitChainId(t *testing.T) {
 	defer close()
 	defer cancel()
 
-	b := st.cache.ethChainId.Load().([]byte)
+	b := st.cache.chainId.Load().([]byte)
 	assert.NotNil(t, b)
 	chainId := new(big.Int).SetBytes(b)
 	assert.Equal(t, chainId, big.NewInt(1337))
 
-	bs, ok, err := st.statedb.GetKeyedValue(KeyEthChainId)
+	bs, ok, err := st.statedb.GetKeyedValue(KeyChainId)
 	assert.NoError(t, err)
 	assert.True(t, ok)
 	assert.Equal(t, chainId, new(big.Int).SetBytes(bs[:]))
@@ -55,9 +58,9 @@ func TestInitChainId(t *testing.T) {
 func TestUnmatchedChainId(t *testing.T) {
 	sqlDB := getMemoryDB()
 
-	statedb, err := NewStateDB(sqlDB)
+	statedb, err := NewStateDB(sqlDB, testChain)
 	assert.NoError(t, err)
-	err = statedb.SetKeyedValue(KeyEthChainId, common.BigInt2Bytes32(big.NewInt(1338)))
+	err = statedb.SetKeyedValue(KeyChainId, common.BigInt2Bytes32(big.NewInt(1338)))
 	assert.NoError(t, err)
 
 	st, err := New(statedb, &StateConfig{ChannelSize: 1, UniqueChainId: big.NewInt(1337)})
@@ -67,11 +70,11 @@ func TestUnmatchedChainId(t *testing.T) {
 
 func TestNewStateWithoutStored(t *testing.T) {
 	sqlDB := getMemoryDB()
-	statedb, _ := NewStateDB(sqlDB)
+	statedb, _ := NewStateDB(sqlDB, testChain)
 	defer sqlDB.Close()
 	defer statedb.Close()
 
-	_, ok, err := statedb.GetKeyedValue(KeyEthFinalizedBlock)
+	_, ok, err := statedb.GetKeyedValue(KeyFinalizedLedger)
 	assert.NoError(t, err)
 	assert.False(t, ok)
 
@@ -85,13 +88,13 @@ func TestNewStateWithoutStored(t *testing.T) {
 
 func TestErrStoredEthFinalizedBlockNumberInvalid(t *testing.T) {
 	sqlDB := getMemoryDB()
-	statedb, _ := NewStateDB(sqlDB)
+	statedb, _ := NewStateDB(sqlDB, testChain)
 	defer sqlDB.Close()
 	defer statedb.Close()
 
 	// set stored = default - 1
 	stored := new(big.Int).Sub(common.EthStartingBlock, big.NewInt(1))
-	err := statedb.SetKeyedValue(KeyEthFinalizedBlock, common.BigInt2Bytes32(stored))
+	err := statedb.SetKeyedValue(KeyFinalizedLedger, common.BigInt2Bytes32(stored))
 	assert.NoError(t, err)
 
 	_, err = New(statedb, &StateConfig{ChannelSize: 1})
@@ -275,6 +278,58 @@ func TestNewRedeemPreparedEvent(t *testing.T) {
 	cancel()
 }
 
+func TestChainEventBatch(t *testing.T) {
+	st, ctx, cancel, close := newTestStateEnv(t)
+	defer cancel()
+	defer close()
+
+	stored, err := st.GetBlockchainFinalizedBlockNumber()
+	assert.NoError(t, err)
+
+	go func() { st.Start(ctx) }()
+
+	// request then prepare of the same redeem, applied in order
+	prepared := ethsync.RandRedeemPreparedEvent(100, 1)
+	requested := &agreement.RedeemRequestedEvent{
+		RequestTxHash:   prepared.RequestTxHash,
+		Requester:       prepared.Requester,
+		Amount:          prepared.Amount,
+		Receiver:        prepared.Receiver,
+		IsValidReceiver: true,
+	}
+	minted := ethsync.RandMintedEvent(100)
+	checkpoint := new(big.Int).Add(stored, big.NewInt(10))
+	batch := agreement.NewChainEventBatch([]agreement.ChainEvent{
+		{Ledger: 1, Index: 0, Requested: requested},
+		{Ledger: 1, Index: 1, Minted: minted},
+		{Ledger: 2, Index: 0, Prepared: prepared},
+	}, checkpoint)
+	st.GetNewChainEventBatchChannel() <- batch
+	assert.NoError(t, <-batch.Done)
+
+	expected, err := createRedeemFromPreparedEvent(prepared)
+	assert.NoError(t, err)
+	actual, ok, err := st.statedb.GetRedeem(prepared.RequestTxHash)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, expected, actual)
+
+	curr, err := st.GetBlockchainFinalizedBlockNumber()
+	assert.NoError(t, err)
+	assert.Equal(t, checkpoint, curr)
+
+	// a failed event stops the batch, the checkpoint is not stored
+	batch = agreement.NewChainEventBatch([]agreement.ChainEvent{
+		{Ledger: 3, Index: 0},
+	}, new(big.Int).Add(checkpoint, big.NewInt(10)))
+	st.GetNewChainEventBatchChannel() <- batch
+	assert.Equal(t, ErrChainEventEmpty, <-batch.Done)
+
+	curr, err = st.GetBlockchainFinalizedBlockNumber()
+	assert.NoError(t, err)
+	assert.Equal(t, checkpoint, curr)
+}
+
 func TestNewMintedEvent(t *testing.T) {
 	st, ctx, cancel, close := newTestStateEnv(t)
 	defer cancel()
@@ -295,3 +350,206 @@ func TestNewMintedEvent(t *testing.T) {
 	assert.True(t, ok)
 	assert.Equal(t, expected, actual)
 }
+
+func TestBtcFinalizedBlockNumber(t *testing.T) {
+	st, ctx, cancel, close := newTestStateEnv(t)
+	defer close()
+	defer cancel()
+
+	// not stored yet
+	_, err := st.GetBtcFinalizedBlockNumber()
+	assert.Equal(t, err, ErrKeyValueNotFound)
+
+	go st.Start(ctx)
+
+	st.GetNewBtcFinalizedBlockChannel() <- big.NewInt(100)
+	time.Sleep(100 * time.Millisecond)
+	curr, err := st.GetBtcFinalizedBlockNumber()
+	assert.NoError(t, err)
+	assert.Equal(t, curr, big.NewInt(100))
+
+	// can move backwards (btc reorg)
+	st.GetNewBtcFinalizedBlockChannel() <- big.NewInt(98)
+	time.Sleep(100 * time.Millisecond)
+	curr, err = st.GetBtcFinalizedBlockNumber()
+	assert.NoError(t, err)
+	assert.Equal(t, curr, big.NewInt(98))
+
+	// reload from db
+	st2, err := New(st.statedb, &StateConfig{ChannelSize: 1, UniqueChainId: big.NewInt(1337)})
+	assert.NoError(t, err)
+	curr, err = st2.GetBtcFinalizedBlockNumber()
+	assert.NoError(t, err)
+	assert.Equal(t, curr, big.NewInt(98))
+}
+
+func TestEventCursor(t *testing.T) {
+	st, _, cancel, close := newTestStateEnv(t)
+	defer close()
+	defer cancel()
+
+	cursor, err := st.GetEventCursor("mint_events")
+	assert.NoError(t, err)
+	assert.Nil(t, cursor)
+
+	err = st.SetEventCursor("mint_events", &agreement.EventCursor{Sequence: 7, Version: 12345})
+	assert.NoError(t, err)
+	cursor, err = st.GetEventCursor("mint_events")
+	assert.NoError(t, err)
+	assert.Equal(t, &agreement.EventCursor{Sequence: 7, Version: 12345}, cursor)
+
+	// handles are independent
+	cursor, err = st.GetEventCursor("redeem_request_events")
+	assert.NoError(t, err)
+	assert.Nil(t, cursor)
+}
+
+func TestChainReorg(t *testing.T) {
+	st, ctx, cancel, close := newTestStateEnv(t)
+	defer cancel()
+	defer close()
+
+	stored, err := st.GetBlockchainFinalizedBlockNumber()
+	assert.NoError(t, err)
+
+	go func() { st.Start(ctx) }()
+
+	prepared := ethsync.RandRedeemPreparedEvent(100, 1)
+	requested := &agreement.RedeemRequestedEvent{
+		RequestTxHash:   prepared.RequestTxHash,
+		Requester:       prepared.Requester,
+		Amount:          prepared.Amount,
+		Receiver:        prepared.Receiver,
+		IsValidReceiver: true,
+	}
+	minted := ethsync.RandMintedEvent(100)
+	batch := agreement.NewChainEventBatch([]agreement.ChainEvent{
+		{Ledger: 1, Index: 0, Requested: requested},
+		{Ledger: 2, Index: 0, Minted: minted},
+		{Ledger: 2, Index: 1, Prepared: prepared},
+	}, new(big.Int).Add(stored, big.NewInt(10)))
+	st.GetNewChainEventBatchChannel() <- batch
+	assert.NoError(t, <-batch.Done)
+
+	// the blocks of the mint and the prepare are orphaned
+	forkPoint := new(big.Int).Add(stored, big.NewInt(1))
+	reorg := agreement.NewChainReorg(forkPoint, []agreement.MintedEvent{*minted}, nil, []agreement.RedeemPreparedEvent{*prepared})
+	st.GetChainReorgChannel() <- reorg
+	assert.NoError(t, <-reorg.Done)
+
+	mint, ok, err := st.statedb.GetMint(minted.BtcTxId)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, ethcommon.Hash{}, mint.MintTxHash)
+
+	redeem, ok, err := st.statedb.GetRedeem(prepared.RequestTxHash)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, RedeemStatusRequested, redeem.Status)
+	assert.Equal(t, ethcommon.Hash{}, redeem.PrepareTxHash)
+
+	curr, err := st.GetBlockchainFinalizedBlockNumber()
+	assert.NoError(t, err)
+	assert.Equal(t, forkPoint, curr)
+}
+
+type sentRedeems map[string]bool
+
+func (s sentRedeems) IsRedeemSent(ethRequestTxID string) (bool, error) {
+	return s[ethRequestTxID], nil
+}
+
+func TestChainReorgKeepsSentRedeem(t *testing.T) {
+	st, ctx, cancel, close := newTestStateEnv(t)
+	defer cancel()
+	defer close()
+
+	stored, err := st.GetBlockchainFinalizedBlockNumber()
+	assert.NoError(t, err)
+
+	sent := ethsync.RandRedeemPreparedEvent(100, 1)
+	unsent := ethsync.RandRedeemPreparedEvent(200, 2)
+	st.SetRedeemSentChecker(sentRedeems{sent.RequestTxHash.String()[2:]: true})
+
+	go func() { st.Start(ctx) }()
+
+	batch := agreement.NewChainEventBatch([]agreement.ChainEvent{
+		{Ledger: 2, Index: 0, Prepared: sent},
+		{Ledger: 2, Index: 1, Prepared: unsent},
+	}, new(big.Int).Add(stored, big.NewInt(10)))
+	st.GetNewChainEventBatchChannel() <- batch
+	assert.NoError(t, <-batch.Done)
+
+	reorg := agreement.NewChainReorg(new(big.Int).Add(stored, big.NewInt(1)), nil, nil, []agreement.RedeemPreparedEvent{*sent, *unsent})
+	st.GetChainReorgChannel() <- reorg
+	assert.NoError(t, <-reorg.Done)
+
+	// the btc tx paying it may be mined, never prepare it again
+	redeem, ok, err := st.statedb.GetRedeem(sent.RequestTxHash)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, RedeemStatusPrepared, redeem.Status)
+	assert.Equal(t, sent.PrepareTxHash, redeem.PrepareTxHash)
+
+	// prepared again, with the same outpoints only
+	redeem, ok, err = st.statedb.GetRedeem(unsent.RequestTxHash)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, RedeemStatusRequested, redeem.Status)
+	assert.Len(t, redeem.Outpoints, 2)
+
+	other := *unsent
+	other.PrepareTxHash = common.RandBytes32()
+	other.OutpointTxIds = []ethcommon.Hash{common.RandBytes32(), common.RandBytes32()}
+	_, err = redeem.Clone().updateFromPreparedEvent(&other)
+	assert.Equal(t, ErrorOutpointsUnmatched, err)
+
+	other.OutpointTxIds = unsent.OutpointTxIds
+	_, err = redeem.Clone().updateFromPreparedEvent(&other)
+	assert.NoError(t, err)
+}
+
+func TestChainReorgRequested(t *testing.T) {
+	st, ctx, cancel, close := newTestStateEnv(t)
+	defer cancel()
+	defer close()
+
+	stored, err := st.GetBlockchainFinalizedBlockNumber()
+	assert.NoError(t, err)
+
+	requested := &agreement.RedeemRequestedEvent{
+		RequestTxHash:   common.RandBytes32(),
+		Requester:       common.RandEthAddress().Bytes(),
+		Amount:          big.NewInt(100),
+		Receiver:        "valid_btc_address",
+		IsValidReceiver: true,
+	}
+	sent := ethsync.RandRedeemPreparedEvent(200, 1)
+	st.SetRedeemSentChecker(sentRedeems{sent.RequestTxHash.String()[2:]: true})
+
+	go func() { st.Start(ctx) }()
+
+	batch := agreement.NewChainEventBatch([]agreement.ChainEvent{
+		{Ledger: 2, Index: 0, Requested: requested},
+		{Ledger: 2, Index: 1, Prepared: sent},
+	}, new(big.Int).Add(stored, big.NewInt(10)))
+	st.GetNewChainEventBatchChannel() <- batch
+	assert.NoError(t, <-batch.Done)
+
+	sentRequested := agreement.RedeemRequestedEvent{RequestTxHash: sent.RequestTxHash}
+	reorg := agreement.NewChainReorg(new(big.Int).Add(stored, big.NewInt(1)), nil,
+		[]agreement.RedeemRequestedEvent{*requested, sentRequested}, []agreement.RedeemPreparedEvent{*sent})
+	st.GetChainReorgChannel() <- reorg
+	assert.NoError(t, <-reorg.Done)
+
+	// the burn is gone, nothing to pay
+	ok, _, err := st.statedb.HasRedeem(requested.RequestTxHash)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	// already paid, left to the operator
+	ok, status, err := st.statedb.HasRedeem(sent.RequestTxHash)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, RedeemStatusPrepared, status)
+}
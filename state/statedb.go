@@ -2,34 +2,173 @@ package state
 
 import (
 	"database/sql"
+	"fmt"
 
 	"github.com/TEENet-io/bridge-go/common"
 	"github.com/TEENet-io/bridge-go/database"
 	ethcommon "github.com/ethereum/go-ethereum/common"
+	logger "github.com/sirupsen/logrus"
 )
 
+// Namespace of the rows shared by all destination chains (e.g. the btc cursor).
+const GLOBAL_NAMESPACE = ""
+
+// Namespace of a destination chain, same as chainregistry.Key (e.g. "aptos/1", "evm/11155111").
+// chainType is the deposit chain type (see common.ChainTypeName).
+func ChainNamespace(chainType byte, chainId uint64) string {
+	return fmt.Sprintf("%s/%d", common.ChainTypeName(chainType), chainId)
+}
+
+// StateDB holds the rows of several destination chains, each row is namespaced by its chain.
+// A StateDB reads and writes the rows of the chain it is opened with.
 type StateDB struct {
 	stmtCache *database.StmtCache
+	chain     string
 }
 
-func NewStateDB(db *sql.DB) (*StateDB, error) {
-	// 1. Create the tables.
+// Open the state db of a destination chain (see ChainNamespace).
+// Tables created by older versions are not namespaced,
+// their rows are migrated to the chain that opens the db first.
+func NewStateDB(db *sql.DB, chain string) (*StateDB, error) {
+	if chain == GLOBAL_NAMESPACE {
+		return nil, ErrChainNamespaceEmpty
+	}
+
+	// 1. Migrate the legacy tables.
+	if err := migrateLegacyTables(db, chain); err != nil {
+		return nil, err
+	}
+
+	// 2. Create the tables.
 	if _, err := db.Exec(redeemTable + kvTable + mintTable); err != nil {
 		return nil, err
 	}
 
-	// 2. A stmt cache + db.
+	// 3. A stmt cache + db.
 	return &StateDB{
 		stmtCache: database.NewStmtCache(db),
+		chain:     chain,
 	}, nil
 }
 
+// Rebuild the tables without the chain column, in one tx.
+// The kv pairs shared by all chains go to GLOBAL_NAMESPACE, the other rows to chain.
+func migrateLegacyTables(db *sql.DB, chain string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	migrated := false
+	for _, t := range legacyTables {
+		legacy, err := isLegacyTable(tx, t.name)
+		if err != nil {
+			return err
+		}
+		if !legacy {
+			continue
+		}
+
+		if err := migrateLegacyTable(tx, t.name, t.create, t.columns, chain); err != nil {
+			return fmt.Errorf("migrate table %s: %w", t.name, err)
+		}
+		migrated = true
+	}
+	if !migrated {
+		return nil
+	}
+
+	for _, key := range globalKeys {
+		if _, err := tx.Exec(`UPDATE kv SET chain = ? WHERE key = ?`, GLOBAL_NAMESPACE, key.String()[2:]); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	logger.WithField("chain", chain).Info("legacy state rows migrated")
+	return nil
+}
+
+// Rename the legacy table, create the namespaced one and copy the rows into it.
+func migrateLegacyTable(tx *sql.Tx, table, create, columns, chain string) error {
+	old := table + "_legacy"
+	if _, err := tx.Exec(fmt.Sprintf(`ALTER TABLE %s RENAME TO %s`, table, old)); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(create); err != nil {
+		return err
+	}
+	copyRows := fmt.Sprintf(`INSERT INTO %s (%s, chain) SELECT %s, ? FROM %s`, table, columns, columns, old)
+	if _, err := tx.Exec(copyRows, chain); err != nil {
+		return err
+	}
+	_, err := tx.Exec(fmt.Sprintf(`DROP TABLE %s`, old))
+	return err
+}
+
+// Tell if the table exists without the chain column.
+func isLegacyTable(tx *sql.Tx, table string) (bool, error) {
+	rows, err := tx.Query(fmt.Sprintf(`PRAGMA table_info(%s)`, table))
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	exists := false
+	for rows.Next() {
+		var (
+			cid     int
+			name    string
+			ctype   string
+			notnull int
+			dflt    sql.NullString
+			pk      int
+		)
+		if err := rows.Scan(&cid, &name, &ctype, &notnull, &dflt, &pk); err != nil {
+			return false, err
+		}
+		if name == "chain" {
+			return false, nil
+		}
+		exists = true
+	}
+	return exists, rows.Err()
+}
+
+// Namespace of the destination chain the db is opened with.
+func (stdb *StateDB) Chain() string {
+	return stdb.chain
+}
+
 func (stdb *StateDB) Close() {
 	stdb.stmtCache.Clear()
 }
 
+// Fetch a value of the chain the db is opened with.
 func (stdb *StateDB) GetKeyedValue(key ethcommon.Hash) (ethcommon.Hash, bool, error) {
-	query := `SELECT value FROM kv WHERE key = ?`
+	return stdb.getKeyedValue(stdb.chain, key)
+}
+
+// Set a value of the chain the db is opened with.
+func (stdb *StateDB) SetKeyedValue(key, value ethcommon.Hash) error {
+	return stdb.setKeyedValue(stdb.chain, key, value)
+}
+
+// Fetch a value shared by all chains.
+func (stdb *StateDB) GetGlobalKeyedValue(key ethcommon.Hash) (ethcommon.Hash, bool, error) {
+	return stdb.getKeyedValue(GLOBAL_NAMESPACE, key)
+}
+
+// Set a value shared by all chains.
+func (stdb *StateDB) SetGlobalKeyedValue(key, value ethcommon.Hash) error {
+	return stdb.setKeyedValue(GLOBAL_NAMESPACE, key, value)
+}
+
+func (stdb *StateDB) getKeyedValue(chain string, key ethcommon.Hash) (ethcommon.Hash, bool, error) {
+	query := `SELECT value FROM kv WHERE chain = ? AND key = ?`
 	stmt, err := stdb.stmtCache.Prepare(query)
 	if err != nil {
 		return ethcommon.Hash{}, false, err
@@ -37,7 +176,7 @@ func (stdb *StateDB) GetKeyedValue(key ethcommon.Hash) (ethcommon.Hash, bool, er
 
 	var value string
 	keyHex := key.String()[2:]
-	if err := stmt.QueryRow(keyHex).Scan(&value); err != nil {
+	if err := stmt.QueryRow(chain, keyHex).Scan(&value); err != nil {
 		if err == sql.ErrNoRows {
 			return ethcommon.Hash{}, false, nil
 		}
@@ -47,8 +186,8 @@ func (stdb *StateDB) GetKeyedValue(key ethcommon.Hash) (ethcommon.Hash, bool, er
 	return common.HexStrToBytes32(value), true, nil
 }
 
-func (stdb *StateDB) SetKeyedValue(key, value ethcommon.Hash) error {
-	query := `INSERT OR REPLACE INTO kv (key, value) VALUES (?, ?)`
+func (stdb *StateDB) setKeyedValue(chain string, key, value ethcommon.Hash) error {
+	query := `INSERT OR REPLACE INTO kv (key, value, chain) VALUES (?, ?, ?)`
 	stmt, err := stdb.stmtCache.Prepare(query)
 	if err != nil {
 		return err
@@ -56,7 +195,7 @@ func (stdb *StateDB) SetKeyedValue(key, value ethcommon.Hash) error {
 
 	keyHex := key.String()[2:]
 	valueHex := value.String()[2:]
-	if _, err := stmt.Exec(keyHex, valueHex); err != nil {
+	if _, err := stmt.Exec(keyHex, valueHex, chain); err != nil {
 		return err
 	}
 
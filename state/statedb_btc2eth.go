@@ -10,7 +10,7 @@ import (
 // Insert a Mint into DB
 // mintTxHash is optional (evm side)
 func (stdb *StateDB) InsertMint(m *Mint) error {
-	query := `INSERT INTO mint (BtcTxId, mintTxHash, receiver, amount) VALUES (?, ?, ?, ?)`
+	query := `INSERT INTO mint (BtcTxId, mintTxHash, receiver, amount, chain) VALUES (?, ?, ?, ?, ?)`
 	stmt, err := stdb.stmtCache.Prepare(query)
 	if err != nil {
 		return err
@@ -30,19 +30,19 @@ func (stdb *StateDB) InsertMint(m *Mint) error {
 		mintTxHash.Valid = false
 	}
 
-	_, err = stmt.Exec(s.BtcTxId, mintTxHash, s.Receiver, s.Amount)
+	_, err = stmt.Exec(s.BtcTxId, mintTxHash, s.Receiver, s.Amount, stdb.chain)
 	return err
 }
 
 // Fetch a list of mints that have not been minted on EVM yet
 func (stdb *StateDB) GetUnMinted() ([]*Mint, error) {
-	query := `SELECT BtcTxId, receiver, amount FROM mint WHERE mintTxHash IS NULL`
+	query := `SELECT BtcTxId, receiver, amount FROM mint WHERE chain = ? AND mintTxHash IS NULL`
 	stmt, err := stdb.stmtCache.Prepare(query)
 	if err != nil {
 		return nil, err
 	}
 
-	rows, err := stmt.Query()
+	rows, err := stmt.Query(stdb.chain)
 	if err != nil {
 		return nil, err
 	}
@@ -68,7 +68,7 @@ func (stdb *StateDB) GetUnMinted() ([]*Mint, error) {
 
 // Fetch a mint by BtcTxId (can be unminted on evm)
 func (stdb *StateDB) GetMint(BtcTxId ethcommon.Hash) (*Mint, bool, error) {
-	query := `SELECT BtcTxId, mintTxHash, receiver, amount FROM mint WHERE BtcTxId = ?`
+	query := `SELECT BtcTxId, mintTxHash, receiver, amount FROM mint WHERE chain = ? AND BtcTxId = ?`
 	stmt, err := stdb.stmtCache.Prepare(query)
 	if err != nil {
 		return nil, false, err
@@ -80,7 +80,7 @@ func (stdb *StateDB) GetMint(BtcTxId ethcommon.Hash) (*Mint, bool, error) {
 	)
 
 	id := BtcTxId.String()[2:]
-	if err := stmt.QueryRow(id).Scan(&s.BtcTxId, &mintTxHash, &s.Receiver, &s.Amount); err != nil {
+	if err := stmt.QueryRow(stdb.chain, id).Scan(&s.BtcTxId, &mintTxHash, &s.Receiver, &s.Amount); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, false, nil
 		}
@@ -110,7 +110,7 @@ func (stdb *StateDB) UpdateMint(m *Mint) error {
 		return stdb.InsertMint(m)
 	}
 
-	query := `UPDATE mint SET mintTxHash = ? WHERE BtcTxId = ?`
+	query := `UPDATE mint SET mintTxHash = ? WHERE chain = ? AND BtcTxId = ?`
 
 	stmt, err := stdb.stmtCache.Prepare(query)
 	if err != nil {
@@ -123,10 +123,54 @@ func (stdb *StateDB) UpdateMint(m *Mint) error {
 		return err
 	}
 
-	_, err = stmt.Exec(s.MintTxHash, s.BtcTxId)
+	_, err = stmt.Exec(s.MintTxHash, stdb.chain, s.BtcTxId)
 
 	if err != nil {
 		return err
 	}
 	return nil
 }
+
+// DeleteUnMinted removes a mint that has not been minted on chain yet.
+// It is used when the BTC deposit tx is orphaned by a reorg.
+// Return (bool: deleted/not deleted, error)
+func (stdb *StateDB) DeleteUnMinted(BtcTxId ethcommon.Hash) (bool, error) {
+	query := `DELETE FROM mint WHERE chain = ? AND BtcTxId = ? AND mintTxHash IS NULL`
+	stmt, err := stdb.stmtCache.Prepare(query)
+	if err != nil {
+		return false, err
+	}
+
+	res, err := stmt.Exec(stdb.chain, BtcTxId.String()[2:])
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// RevertMinted sets a minted mint back to unminted,
+// after the mint tx (mintTxHash) is orphaned by a reorg on the EVM side.
+// Return (bool: reverted/not reverted, error), not reverted if the mint is minted by another tx.
+func (stdb *StateDB) RevertMinted(BtcTxId ethcommon.Hash, mintTxHash ethcommon.Hash) (bool, error) {
+	query := `UPDATE mint SET mintTxHash = NULL WHERE chain = ? AND BtcTxId = ? AND mintTxHash = ?`
+	stmt, err := stdb.stmtCache.Prepare(query)
+	if err != nil {
+		return false, err
+	}
+
+	res, err := stmt.Exec(stdb.chain, BtcTxId.String()[2:], mintTxHash.String()[2:])
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
@@ -4,12 +4,13 @@ import (
 	"testing"
 
 	"github.com/TEENet-io/bridge-go/common"
+	ethcommon "github.com/ethereum/go-ethereum/common"
 	"github.com/stretchr/testify/assert"
 )
 
 func newTestStateDB(t *testing.T) (*StateDB, func()) {
 	sqlDB := getMemoryDB()
-	stdb, err := NewStateDB(sqlDB)
+	stdb, err := NewStateDB(sqlDB, testChain)
 	assert.NoError(t, err)
 
 	close := func() {
@@ -79,3 +80,53 @@ func TestUpdateMint(t *testing.T) {
 	assert.True(t, ok)
 	assert.Equal(t, mint, chk)
 }
+
+func TestDeleteUnMinted(t *testing.T) {
+	statedb, close := newTestStateDB(t)
+	defer close()
+
+	unminted := RandMint(false)
+	err := statedb.InsertMint(unminted)
+	assert.NoError(t, err)
+	minted := RandMint(true)
+	err = statedb.InsertMint(minted)
+	assert.NoError(t, err)
+
+	// Minted record cannot be removed
+	ok, err := statedb.DeleteUnMinted(minted.BtcTxId)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	_, ok, err = statedb.GetMint(minted.BtcTxId)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = statedb.DeleteUnMinted(unminted.BtcTxId)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	_, ok, err = statedb.GetMint(unminted.BtcTxId)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestRevertMinted(t *testing.T) {
+	statedb, close := newTestStateDB(t)
+	defer close()
+
+	minted := RandMint(true)
+	err := statedb.InsertMint(minted)
+	assert.NoError(t, err)
+
+	// minted by another tx
+	ok, err := statedb.RevertMinted(minted.BtcTxId, common.RandBytes32())
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = statedb.RevertMinted(minted.BtcTxId, minted.MintTxHash)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	chk, err := statedb.GetUnMinted()
+	assert.NoError(t, err)
+	assert.Len(t, chk, 1)
+	assert.Equal(t, minted.BtcTxId, chk[0].BtcTxId)
+	assert.Equal(t, ethcommon.Hash{}, chk[0].MintTxHash)
+}
@@ -10,7 +10,7 @@ import (
 // Insert after receiving a new redeem requested event (from user). Only fields
 // requestTxHash, requester, receiver, amount, and status are required.
 func (stdb *StateDB) InsertAfterRequested(redeem *Redeem) error {
-	query := `INSERT OR IGNORE INTO redeem (` + statusRequestedParamList + `) VALUES (?, ?, ?, ?, ?)`
+	query := `INSERT OR IGNORE INTO redeem (` + statusRequestedParamList + `) VALUES (?, ?, ?, ?, ?, ?)`
 
 	stmt, err := stdb.stmtCache.Prepare(query)
 	if err != nil {
@@ -29,6 +29,7 @@ func (stdb *StateDB) InsertAfterRequested(redeem *Redeem) error {
 		r.Receiver,
 		r.Amount,
 		r.Status,
+		stdb.chain,
 	); err != nil {
 		return err
 	}
@@ -47,9 +48,9 @@ func (stdb *StateDB) UpdateAfterPrepared(redeem *Redeem) error {
 		return err
 	}
 	if ok {
-		query = `UPDATE redeem SET prepareTxHash = ?, outpoints = ?, status = ? WHERE requestTxHash = ?`
+		query = `UPDATE redeem SET prepareTxHash = ?, outpoints = ?, status = ? WHERE chain = ? AND requestTxHash = ?`
 	} else {
-		query = `INSERT OR IGNORE INTO redeem (` + statusPreparedParamList + `) VALUES (?, ?, ?, ?, ?, ?, ?)`
+		query = `INSERT OR IGNORE INTO redeem (` + statusPreparedParamList + `) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
 	}
 
 	stmt, err := stdb.stmtCache.Prepare(query)
@@ -64,7 +65,7 @@ func (stdb *StateDB) UpdateAfterPrepared(redeem *Redeem) error {
 	}
 
 	if ok {
-		if _, err := stmt.Exec(r.PrepareTxHash, r.Outpoints, r.Status, r.RequestTxHash); err != nil {
+		if _, err := stmt.Exec(r.PrepareTxHash, r.Outpoints, r.Status, stdb.chain, r.RequestTxHash); err != nil {
 			return err
 		}
 	} else {
@@ -76,6 +77,7 @@ func (stdb *StateDB) UpdateAfterPrepared(redeem *Redeem) error {
 			r.Amount,
 			r.Outpoints,
 			r.Status,
+			stdb.chain,
 		); err != nil {
 			return err
 		}
@@ -89,7 +91,7 @@ func (stdb *StateDB) UpdateAfterPrepared(redeem *Redeem) error {
 // It uses requestTxHash (from redeem param) to look for a redeem record in the database.
 // Then writes in the btcTxId (from redeem param) and set status to completed on the database record.
 func (stdb *StateDB) UpdateAfterRedeemed(redeem *Redeem) error {
-	query := `UPDATE redeem SET btcTxId = ?, status = ? WHERE requestTxHash = ?`
+	query := `UPDATE redeem SET btcTxId = ?, status = ? WHERE chain = ? AND requestTxHash = ?`
 
 	stmt, err := stdb.stmtCache.Prepare(query)
 	if err != nil {
@@ -102,23 +104,89 @@ func (stdb *StateDB) UpdateAfterRedeemed(redeem *Redeem) error {
 		return err
 	}
 
-	if _, err := stmt.Exec(r.BtcTxId, r.Status, r.RequestTxHash); err != nil {
+	if _, err := stmt.Exec(r.BtcTxId, r.Status, stdb.chain, r.RequestTxHash); err != nil {
 		return err
 	}
 
 	return nil
 }
 
+// UpdateAfterRedeemOrphaned reverts a "completed" redeem back to "prepared",
+// after the btc tx that completed it is orphaned by a reorg.
+// btcTxId is cleared until the btc tx is mined again.
+func (stdb *StateDB) UpdateAfterRedeemOrphaned(requestTxHash ethcommon.Hash) error {
+	query := `UPDATE redeem SET btcTxId = NULL, status = ? WHERE chain = ? AND requestTxHash = ? AND status = ?`
+
+	stmt, err := stdb.stmtCache.Prepare(query)
+	if err != nil {
+		return err
+	}
+
+	if _, err := stmt.Exec(RedeemStatusPrepared, stdb.chain, requestTxHash.String()[2:], RedeemStatusCompleted); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UpdateAfterPrepareReorged reverts a "prepared" redeem back to "requested",
+// after the prepare tx (prepareTxHash) is orphaned by a reorg on the EVM side.
+// prepareTxHash is cleared until the redeem is prepared again, the outpoints are kept:
+// the redeem must be prepared again with them (see Redeem.updateFromPreparedEvent).
+// Return (bool: reverted/not reverted, error), a "completed" redeem is never reverted.
+func (stdb *StateDB) UpdateAfterPrepareReorged(requestTxHash ethcommon.Hash, prepareTxHash ethcommon.Hash) (bool, error) {
+	query := `UPDATE redeem SET prepareTxHash = NULL, status = ? WHERE chain = ? AND requestTxHash = ? AND prepareTxHash = ? AND status = ?`
+
+	stmt, err := stdb.stmtCache.Prepare(query)
+	if err != nil {
+		return false, err
+	}
+
+	res, err := stmt.Exec(RedeemStatusRequested, stdb.chain, requestTxHash.String()[2:], prepareTxHash.String()[2:], RedeemStatusPrepared)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// DeleteAfterRequestReorged removes a redeem that is not prepared yet ("requested" or "invalid"),
+// after the request tx (requestTxHash) is orphaned by a reorg on the destination chain.
+// Return (bool: deleted/not deleted, error), a prepared or completed redeem is never deleted.
+func (stdb *StateDB) DeleteAfterRequestReorged(requestTxHash ethcommon.Hash) (bool, error) {
+	query := `DELETE FROM redeem WHERE chain = ? AND requestTxHash = ? AND status IN (?, ?)`
+
+	stmt, err := stdb.stmtCache.Prepare(query)
+	if err != nil {
+		return false, err
+	}
+
+	res, err := stmt.Exec(stdb.chain, requestTxHash.String()[2:], RedeemStatusRequested, RedeemStatusInvalid)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
 // Query Redeem from the database by "status".
 func (stdb *StateDB) GetRedeemsByStatus(status RedeemStatus) ([]*Redeem, error) {
-	query := `SELECT * FROM redeem WHERE status = ?`
+	query := `SELECT` + redeemColumnList + `FROM redeem WHERE chain = ? AND status = ?`
 
 	stmt, err := stdb.stmtCache.Prepare(query)
 	if err != nil {
 		return []*Redeem{}, err
 	}
 
-	rows, err := stmt.Query(status)
+	rows, err := stmt.Query(stdb.chain, status)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil // No rows found, return nil slice
@@ -168,7 +236,7 @@ func (stdb *StateDB) GetRedeemsByStatus(status RedeemStatus) ([]*Redeem, error)
 // Query Redeem from database via requestTxHash.
 // Return (*Redeem, bool: found/not found, error)
 func (stdb *StateDB) GetRedeem(requestTxHash ethcommon.Hash) (*Redeem, bool, error) {
-	query := `SELECT * FROM redeem WHERE requestTxHash = ?;`
+	query := `SELECT` + redeemColumnList + `FROM redeem WHERE chain = ? AND requestTxHash = ?;`
 
 	stmt, err := stdb.stmtCache.Prepare(query)
 	if err != nil {
@@ -180,7 +248,7 @@ func (stdb *StateDB) GetRedeem(requestTxHash ethcommon.Hash) (*Redeem, bool, err
 		prepareTxHash, btcTxId sql.NullString
 	)
 
-	row := stmt.QueryRow(requestTxHash.String()[2:])
+	row := stmt.QueryRow(stdb.chain, requestTxHash.String()[2:])
 	if err := row.Scan(
 		&r.RequestTxHash,
 		&prepareTxHash,
@@ -214,7 +282,7 @@ func (stdb *StateDB) GetRedeem(requestTxHash ethcommon.Hash) (*Redeem, bool, err
 }
 
 func (stdb *StateDB) GetRedeemsByRequester(requester string) ([]*Redeem, error) {
-	query := `SELECT * FROM redeem WHERE LOWER(requester) = LOWER(?)`
+	query := `SELECT` + redeemColumnList + `FROM redeem WHERE chain = ? AND LOWER(requester) = LOWER(?)`
 
 	stmt, err := stdb.stmtCache.Prepare(query)
 	if err != nil {
@@ -224,7 +292,7 @@ func (stdb *StateDB) GetRedeemsByRequester(requester string) ([]*Redeem, error)
 	// Strip "0x" prefix off the string.
 	requesterStr := common.Trim0xPrefix(requester)
 
-	rows, err := stmt.Query(requesterStr)
+	rows, err := stmt.Query(stdb.chain, requesterStr)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -274,7 +342,7 @@ func (stdb *StateDB) GetRedeemsByRequester(requester string) ([]*Redeem, error)
 // Query if a redeem exists in the database via requestTxHash.
 // Return (bool: found/not found, RedeemStatus, error)
 func (stdb *StateDB) HasRedeem(requestTxHash ethcommon.Hash) (bool, RedeemStatus, error) {
-	query := `SELECT status FROM redeem WHERE requestTxHash = ?`
+	query := `SELECT status FROM redeem WHERE chain = ? AND requestTxHash = ?`
 	stmt, err := stdb.stmtCache.Prepare(query)
 	if err != nil {
 		return false, "", err
@@ -282,7 +350,7 @@ func (stdb *StateDB) HasRedeem(requestTxHash ethcommon.Hash) (bool, RedeemStatus
 
 	hash := requestTxHash.String()[2:]
 	var status string
-	if err := stmt.QueryRow(hash).Scan(&status); err != nil {
+	if err := stmt.QueryRow(stdb.chain, hash).Scan(&status); err != nil {
 		if err == sql.ErrNoRows {
 			return false, "", nil
 		}
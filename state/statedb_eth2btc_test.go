@@ -13,7 +13,7 @@ import (
 
 func newTestStateDBEnv(t *testing.T) (*StateDB, func()) {
 	sqlDB := getMemoryDB()
-	statedb, err := NewStateDB(sqlDB)
+	statedb, err := NewStateDB(sqlDB, testChain)
 	assert.NoError(t, err)
 	return statedb, func() {
 		statedb.Close()
@@ -119,7 +119,7 @@ func TestInsertAfterRequested(t *testing.T) {
 
 func TestUpdateAfterPrepared(t *testing.T) {
 	sqlDB := getMemoryDB()
-	db, err := NewStateDB(sqlDB)
+	db, err := NewStateDB(sqlDB, testChain)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -156,7 +156,7 @@ func TestUpdateAfterPrepared(t *testing.T) {
 
 func TestHasRedeem(t *testing.T) {
 	sqlDB := getMemoryDB()
-	db, err := NewStateDB(sqlDB)
+	db, err := NewStateDB(sqlDB, testChain)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -178,3 +178,90 @@ func TestHasRedeem(t *testing.T) {
 	assert.True(t, ok)
 	assert.Equal(t, RedeemStatusRequested, status)
 }
+
+func TestUpdateAfterRedeemOrphaned(t *testing.T) {
+	db, close := newTestStateDBEnv(t)
+	defer close()
+
+	r := RandRedeem(RedeemStatusPrepared)
+	r.BtcTxId = [32]byte{}
+	err := db.UpdateAfterPrepared(r)
+	assert.NoError(t, err)
+
+	r.BtcTxId = common.RandBytes32()
+	r.Status = RedeemStatusCompleted
+	err = db.UpdateAfterRedeemed(r)
+	assert.NoError(t, err)
+
+	err = db.UpdateAfterRedeemOrphaned(r.RequestTxHash)
+	assert.NoError(t, err)
+	actual, ok, err := db.GetRedeem(r.RequestTxHash)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, RedeemStatusPrepared, actual.Status)
+	assert.Equal(t, ethcommon.Hash{}, actual.BtcTxId)
+	assert.Equal(t, r.Outpoints, actual.Outpoints)
+}
+
+func TestUpdateAfterPrepareReorged(t *testing.T) {
+	db, close := newTestStateDBEnv(t)
+	defer close()
+
+	r := RandRedeem(RedeemStatusPrepared)
+	r.BtcTxId = [32]byte{}
+	err := db.UpdateAfterPrepared(r)
+	assert.NoError(t, err)
+
+	// prepared by another tx
+	ok, err := db.UpdateAfterPrepareReorged(r.RequestTxHash, common.RandBytes32())
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = db.UpdateAfterPrepareReorged(r.RequestTxHash, r.PrepareTxHash)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	actual, ok, err := db.GetRedeem(r.RequestTxHash)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, RedeemStatusRequested, actual.Status)
+	assert.Equal(t, ethcommon.Hash{}, actual.PrepareTxHash)
+	assert.Equal(t, r.Outpoints, actual.Outpoints) // kept to be prepared again with
+
+	// completed redeem is never reverted
+	c := RandRedeem(RedeemStatusPrepared)
+	c.BtcTxId = [32]byte{}
+	err = db.UpdateAfterPrepared(c)
+	assert.NoError(t, err)
+	c.BtcTxId = common.RandBytes32()
+	c.Status = RedeemStatusCompleted
+	err = db.UpdateAfterRedeemed(c)
+	assert.NoError(t, err)
+	ok, err = db.UpdateAfterPrepareReorged(c.RequestTxHash, c.PrepareTxHash)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestUpdateAfterRedeemedBatch(t *testing.T) {
+	db, close := newTestStateDBEnv(t)
+	defer close()
+
+	// one btc tx completes both redeems
+	btcTxId := common.RandBytes32()
+	for i := 0; i < 2; i++ {
+		r := RandRedeem(RedeemStatusPrepared)
+		r.BtcTxId = [32]byte{}
+		err := db.UpdateAfterPrepared(r)
+		assert.NoError(t, err)
+
+		r.BtcTxId = btcTxId
+		r.Status = RedeemStatusCompleted
+		err = db.UpdateAfterRedeemed(r)
+		assert.NoError(t, err)
+
+		actual, ok, err := db.GetRedeem(r.RequestTxHash)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, RedeemStatusCompleted, actual.Status)
+		assert.Equal(t, ethcommon.Hash(btcTxId), actual.BtcTxId)
+	}
+}
@@ -1,15 +1,17 @@
 package state
 
 import (
+	"math/big"
 	"testing"
 
+	"github.com/TEENet-io/bridge-go/common"
 	ethcommon "github.com/ethereum/go-ethereum/common"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestKV(t *testing.T) {
 	sqlDB := getMemoryDB()
-	db, err := NewStateDB(sqlDB)
+	db, err := NewStateDB(sqlDB, testChain)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -40,3 +42,153 @@ func TestKV(t *testing.T) {
 	assert.True(t, ok)
 	assert.Equal(t, []byte("value2"), ethcommon.TrimLeftZeroes(v[:]))
 }
+
+func TestChainNamespaces(t *testing.T) {
+	sqlDB := getMemoryDB()
+	defer sqlDB.Close()
+
+	aptos, err := NewStateDB(sqlDB, ChainNamespace(common.DEPOSIT_CHAIN_TYPE_APTOS, 1))
+	assert.NoError(t, err)
+	defer aptos.Close()
+	evm, err := NewStateDB(sqlDB, testChain)
+	assert.NoError(t, err)
+	defer evm.Close()
+	assert.Equal(t, "aptos/1", aptos.Chain())
+	assert.Equal(t, "evm/1337", evm.Chain())
+
+	_, err = NewStateDB(sqlDB, GLOBAL_NAMESPACE)
+	assert.Equal(t, ErrChainNamespaceEmpty, err)
+
+	// per chain values
+	assert.NoError(t, aptos.SetKeyedValue(KeyFinalizedLedger, common.BigInt2Bytes32(big.NewInt(100))))
+	assert.NoError(t, evm.SetKeyedValue(KeyFinalizedLedger, common.BigInt2Bytes32(big.NewInt(200))))
+	v, ok, err := aptos.GetKeyedValue(KeyFinalizedLedger)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, big.NewInt(100), v.Big())
+	v, ok, err = evm.GetKeyedValue(KeyFinalizedLedger)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, big.NewInt(200), v.Big())
+
+	// shared values
+	assert.NoError(t, aptos.SetGlobalKeyedValue(KeyBtcFinalizedBlock, common.BigInt2Bytes32(big.NewInt(300))))
+	v, ok, err = evm.GetGlobalKeyedValue(KeyBtcFinalizedBlock)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, big.NewInt(300), v.Big())
+	_, ok, err = evm.GetKeyedValue(KeyBtcFinalizedBlock)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	// a deposit is minted on one chain only
+	mint := RandMint(false)
+	assert.NoError(t, aptos.InsertMint(mint))
+	_, ok, err = evm.GetMint(mint.BtcTxId)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	assert.Error(t, evm.InsertMint(mint))
+	assert.Error(t, evm.UpdateMint(mint))
+	unminted, err := evm.GetUnMinted()
+	assert.NoError(t, err)
+	assert.Empty(t, unminted)
+
+	// the same request can be stored for each chain
+
+	redeem := RandRedeem(RedeemStatusRequested)
+	assert.NoError(t, aptos.InsertAfterRequested(redeem))
+	ok, _, err = evm.HasRedeem(redeem.RequestTxHash)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	redeems, err := evm.GetRedeemsByStatus(RedeemStatusRequested)
+	assert.NoError(t, err)
+	assert.Empty(t, redeems)
+	redeems, err = aptos.GetRedeemsByStatus(RedeemStatusRequested)
+	assert.NoError(t, err)
+	assert.Len(t, redeems, 1)
+}
+
+// Tables as created before the rows are namespaced by chain.
+const (
+	legacyRedeemTable = `CREATE TABLE redeem (
+		requestTxHash CHAR(64) PRIMARY KEY NOT NULL,
+		prepareTxHash CHAR(64) UNIQUE,
+		btcTxId CHAR(64) UNIQUE,
+		requester CHAR(40) NOT NULL,
+		receiver VARCHAR(62) NOT NULL,
+		amount BIGINT UNSIGNED NOT NULL,
+		outpoints BLOB,
+		status VARCHAR(10) NOT NULL
+	);`
+	legacyKvTable = `CREATE TABLE kv (
+		key CHAR(64) PRIMARY KEY NOT NULL,
+		value CHAR(64) NOT NULL
+	);`
+	legacyMintTable = `CREATE TABLE mint (
+		btcTxId CHAR(64) PRIMARY KEY NOT NULL,
+		mintTxHash CHAR(64) UNIQUE,
+		receiver CHAR(40) NOT NULL,
+		amount BIGINT UNSIGNED NOT NULL
+	);`
+)
+
+func TestMigrateLegacyTables(t *testing.T) {
+	sqlDB := getMemoryDB()
+	defer sqlDB.Close()
+
+	_, err := sqlDB.Exec(legacyRedeemTable + legacyKvTable + legacyMintTable)
+	assert.NoError(t, err)
+
+	redeem := RandRedeem(RedeemStatusCompleted)
+	r, err := (&sqlRedeem{}).encode(redeem)
+	assert.NoError(t, err)
+	_, err = sqlDB.Exec(`INSERT INTO redeem (`+redeemColumnList+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		r.RequestTxHash, r.PrepareTxHash, r.BtcTxId, r.Requester, r.Receiver, r.Amount, r.Outpoints, r.Status)
+	assert.NoError(t, err)
+
+	mint := RandMint(false)
+	m, err := (&sqlMint{}).encode(mint)
+	assert.NoError(t, err)
+	_, err = sqlDB.Exec(`INSERT INTO mint (btcTxId, receiver, amount) VALUES (?, ?, ?)`, m.BtcTxId, m.Receiver, m.Amount)
+	assert.NoError(t, err)
+
+	for key, value := range map[ethcommon.Hash]int64{KeyFinalizedLedger: 100, KeyChainId: 1, KeyBtcFinalizedBlock: 300} {
+		_, err = sqlDB.Exec(`INSERT INTO kv (key, value) VALUES (?, ?)`,
+			key.String()[2:], ethcommon.Hash(common.BigInt2Bytes32(big.NewInt(value))).String()[2:])
+		assert.NoError(t, err)
+	}
+
+	// the rows go to the chain opening the db first
+	aptos, err := NewStateDB(sqlDB, ChainNamespace(common.DEPOSIT_CHAIN_TYPE_APTOS, 1))
+	assert.NoError(t, err)
+	defer aptos.Close()
+
+	chk, ok, err := aptos.GetRedeem(redeem.RequestTxHash)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, redeem, chk)
+
+	unminted, err := aptos.GetUnMinted()
+	assert.NoError(t, err)
+	assert.Equal(t, []*Mint{mint}, unminted)
+
+	v, ok, err := aptos.GetKeyedValue(KeyFinalizedLedger)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, big.NewInt(100), v.Big())
+	v, ok, err = aptos.GetGlobalKeyedValue(KeyBtcFinalizedBlock)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, big.NewInt(300), v.Big())
+
+	// nothing left to migrate for the other chains
+	evm, err := NewStateDB(sqlDB, testChain)
+	assert.NoError(t, err)
+	defer evm.Close()
+	_, ok, err = evm.GetKeyedValue(KeyFinalizedLedger)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	unminted, err = evm.GetUnMinted()
+	assert.NoError(t, err)
+	assert.Empty(t, unminted)
+}